@@ -9,7 +9,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/good-yellow-bee/blazelog/internal/agent"
 	"github.com/good-yellow-bee/blazelog/internal/parser"
+	"github.com/good-yellow-bee/blazelog/internal/tailer"
 	"github.com/google/uuid"
 	"gopkg.in/yaml.v3"
 )
@@ -19,6 +21,9 @@ type Config struct {
 	Server      ServerConfig                 `yaml:"server"`
 	Agent       AgentConfig                  `yaml:"agent"`
 	Reliability ReliabilityConfig            `yaml:"reliability"`
+	Kubernetes  KubernetesConfig             `yaml:"kubernetes"`
+	Levels      LevelInferenceConfig         `yaml:"level_inference"`
+	Paths       PathNormalizationConfig      `yaml:"path_normalization"`
 	Parsers     []parser.CustomParserConfig  `yaml:"parsers"`
 	Sources     []SourceConfig               `yaml:"sources"`
 	Labels      map[string]string            `yaml:"labels"`
@@ -26,8 +31,13 @@ type Config struct {
 
 // ServerConfig contains server connection settings.
 type ServerConfig struct {
-	Address string    `yaml:"address"` // host:port
-	TLS     TLSConfig `yaml:"tls"`     // TLS configuration for mTLS
+	Address            string    `yaml:"address"`              // host:port
+	MaxMessageSize     int       `yaml:"max_message_size"`     // max gRPC message size in bytes (default: 4MB)
+	MaxInFlightBatches int       `yaml:"max_inflight_batches"` // unacked batches in flight (default: 0 = don't wait for acks)
+	TLS                TLSConfig `yaml:"tls"`                  // TLS configuration for mTLS
+
+	Compression            string        `yaml:"compression"`              // stream compression: none, gzip or zstd (default: none)
+	TransferReportInterval time.Duration `yaml:"transfer_report_interval"` // log bytes sent and compression ratio this often (default: 0 = off)
 }
 
 // TLSConfig contains TLS settings for the agent.
@@ -41,11 +51,21 @@ type TLSConfig struct {
 
 // AgentConfig contains agent settings.
 type AgentConfig struct {
-	ID            string        `yaml:"id"`             // optional, auto-generated if empty
-	Name          string        `yaml:"name"`           // human-readable name
-	ProjectID     string        `yaml:"project_id"`     // project this agent belongs to
-	BatchSize     int           `yaml:"batch_size"`     // entries per batch (default: 100)
-	FlushInterval time.Duration `yaml:"flush_interval"` // batch flush interval (default: 1s)
+	ID            string        `yaml:"id"`              // optional, auto-generated if empty
+	Name          string        `yaml:"name"`            // human-readable name
+	ProjectID     string        `yaml:"project_id"`      // project this agent belongs to
+	BatchSize     int           `yaml:"batch_size"`      // entries per batch (default: 100)
+	MaxBatchBytes int           `yaml:"max_batch_bytes"` // serialized bytes per batch (default: 0 = count only)
+	FlushInterval time.Duration `yaml:"flush_interval"`  // batch flush interval (default: 1s)
+
+	ParseWorkers   int    `yaml:"parse_workers"`   // max concurrent parses across sources (default: 0 = unlimited)
+	MetricsAddress string `yaml:"metrics_address"` // serve Prometheus metrics here, e.g. 127.0.0.1:9101 (default: off)
+	RawTail        bool   `yaml:"raw_tail"`        // let admins stream raw, unredacted source bytes via the server (default: false)
+
+	MaxLineLength int `yaml:"max_line_length"` // max_line_length of sources that don't set their own (default: 1MB)
+
+	StateFile          string        `yaml:"state_file"`          // keep file read positions here to resume after restarts (default: off)
+	CheckpointInterval time.Duration `yaml:"checkpoint_interval"` // how often read positions are saved (default: 5s)
 }
 
 // ReliabilityConfig contains reliability settings.
@@ -55,14 +75,59 @@ type ReliabilityConfig struct {
 	HeartbeatInterval time.Duration `yaml:"heartbeat_interval"` // heartbeat interval (default: 15s)
 	ReconnectInitial  time.Duration `yaml:"reconnect_initial"`  // initial reconnect delay (default: 1s)
 	ReconnectMax      time.Duration `yaml:"reconnect_max"`      // max reconnect delay (default: 30s)
+	RetryWindow       time.Duration `yaml:"retry_window"`       // exit after failing to reconnect for this long (default: 0 = retry forever)
+	BackfillRate      int           `yaml:"backfill_rate"`      // max buffered entries replayed per second (default: 0 = unlimited)
+}
+
+// KubernetesConfig controls automatic labels for Kubernetes pod logs.
+type KubernetesConfig struct {
+	Disabled   bool   `yaml:"disabled"`    // don't derive pod labels from file paths (default: false)
+	PathLabels string `yaml:"path_labels"` // pod log path template (default: /var/log/pods/{namespace}_{pod}_{uid}/{container}/)
+}
+
+// LevelInferenceConfig controls keyword-based levels for entries whose
+// parser leaves the level unknown.
+type LevelInferenceConfig struct {
+	Enabled  bool                `yaml:"enabled"`  // infer levels from message keywords (default: false)
+	Keywords map[string][]string `yaml:"keywords"` // extra keywords per level, added to the built-in ones
+}
+
+// PathNormalizationConfig controls rewriting of file paths in entries to
+// one form, for fleets mixing Windows and Unix hosts.
+type PathNormalizationConfig struct {
+	Enabled  bool `yaml:"enabled"`   // backslashes to forward slashes, upper-case drive letters (default: false)
+	CaseFold bool `yaml:"case_fold"` // also lower-case Windows paths (default: false)
 }
 
 // SourceConfig defines a log source to collect.
 type SourceConfig struct {
 	Name   string `yaml:"name"`   // source identifier
-	Type   string `yaml:"type"`   // parser type: nginx, apache, magento, prestashop, wordpress
-	Path   string `yaml:"path"`   // file path or glob pattern
+	Type   string `yaml:"type"`   // parser type: nginx, apache, magento, prestashop, wordpress, syslog, postgres, mysql, json, regex
+	Path   string `yaml:"path"`   // file path or glob pattern ("**" matches any number of directories); *.gz files are read once
 	Follow bool   `yaml:"follow"` // tail mode (default: true)
+
+	PathLabels    string `yaml:"path_labels"`    // template deriving labels from file paths, e.g. /var/log/{service}/{env}/
+	StartPosition string `yaml:"start_position"` // where to start in existing files: end or beginning (default: end when following)
+	ReadRotated   bool   `yaml:"read_rotated"`   // on rotation, read unread lines from the newest rotated copy, e.g. app.log.1.gz (default: false)
+
+	RescanInterval time.Duration `yaml:"rescan_interval"` // glob paths: how often to look for new and deleted files (default: 10s)
+
+	ReadBufferSize int `yaml:"read_buffer_size"` // file read buffer in bytes (default: 64KB)
+	MaxLineLength  int `yaml:"max_line_length"`  // longer lines are truncated, marked and flagged with truncated (default: 1MB)
+
+	OnParseError string `yaml:"on_parse_error"` // lines that don't parse: drop, or forward_raw to send them with level and type unknown (default: drop)
+
+	MultiLineTimeout  time.Duration `yaml:"multiline_timeout"`   // multiline parsers: send an entry after this long without continuation lines (default: 1s)
+	MultiLineMaxLines int           `yaml:"multiline_max_lines"` // multiline parsers: send an entry at this many lines (default: 500)
+
+	TimeKey    string `yaml:"time_key"`    // json sources: key holding the timestamp (default: time)
+	LevelKey   string `yaml:"level_key"`   // json sources: key holding the level (default: level)
+	MessageKey string `yaml:"message_key"` // json sources: key holding the message (default: msg)
+
+	ThrownOrigin string `yaml:"thrown_origin"` // magento, prestashop and wordpress sources: php_file/php_line from an exception's "thrown in" tail: override, fill or ignore (default: override)
+
+	Pattern    string `yaml:"pattern"`     // regex sources: regex with (?P<timestamp>), (?P<level>), (?P<message>) and other named groups
+	TimeLayout string `yaml:"time_layout"` // regex sources: Go time layout of the timestamp group (default: RFC 3339)
 }
 
 // LoadConfig loads configuration from a YAML file.
@@ -95,6 +160,12 @@ func (c *Config) setDefaults() {
 		hostname, _ := os.Hostname()
 		c.Agent.Name = hostname
 	}
+	if c.Server.MaxMessageSize <= 0 {
+		c.Server.MaxMessageSize = 4 * 1024 * 1024
+	}
+	if c.Server.Compression == "" {
+		c.Server.Compression = "none"
+	}
 	if c.Agent.BatchSize <= 0 {
 		c.Agent.BatchSize = 100
 	}
@@ -105,11 +176,18 @@ func (c *Config) setDefaults() {
 		if !c.Sources[i].Follow {
 			c.Sources[i].Follow = true
 		}
+		if c.Sources[i].MaxLineLength == 0 {
+			c.Sources[i].MaxLineLength = c.Agent.MaxLineLength
+		}
 	}
 	if c.Labels == nil {
 		c.Labels = make(map[string]string)
 	}
 
+	if c.Kubernetes.PathLabels == "" {
+		c.Kubernetes.PathLabels = agent.KubernetesPathLabels
+	}
+
 	// Reliability defaults
 	if c.Reliability.BufferMaxSize == "" {
 		c.Reliability.BufferMaxSize = "100MB"
@@ -141,6 +219,38 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("server.tls.ca_file is required when TLS is enabled and insecure_skip_verify is false")
 		}
 	}
+	if c.Server.MaxInFlightBatches < 0 {
+		return fmt.Errorf("server.max_inflight_batches must be >= 0")
+	}
+	if c.Server.Compression != "none" && c.Server.Compression != "gzip" && c.Server.Compression != "zstd" {
+		return fmt.Errorf("server.compression must be none, gzip or zstd, got %q", c.Server.Compression)
+	}
+	if c.Server.TransferReportInterval < 0 {
+		return fmt.Errorf("server.transfer_report_interval must be >= 0")
+	}
+	if c.Agent.MaxBatchBytes < 0 {
+		return fmt.Errorf("agent.max_batch_bytes must be >= 0")
+	}
+	if c.Agent.ParseWorkers < 0 {
+		return fmt.Errorf("agent.parse_workers must be >= 0")
+	}
+	if c.Agent.MaxLineLength < 0 {
+		return fmt.Errorf("agent.max_line_length must be >= 0")
+	}
+	if c.Agent.CheckpointInterval < 0 {
+		return fmt.Errorf("agent.checkpoint_interval must be >= 0")
+	}
+	if c.Reliability.BackfillRate < 0 {
+		return fmt.Errorf("reliability.backfill_rate must be >= 0")
+	}
+	if !c.Kubernetes.Disabled {
+		if _, err := agent.ParsePathTemplate(c.Kubernetes.PathLabels); err != nil {
+			return fmt.Errorf("kubernetes.path_labels: %w", err)
+		}
+	}
+	if _, err := parser.NewLevelInferencer(c.Levels.Keywords); err != nil {
+		return fmt.Errorf("level_inference.keywords: %w", err)
+	}
 	if len(c.Sources) == 0 {
 		return fmt.Errorf("at least one source is required")
 	}
@@ -154,6 +264,58 @@ func (c *Config) Validate() error {
 		if src.Type == "" {
 			return fmt.Errorf("sources[%d].type is required", i)
 		}
+		switch src.StartPosition {
+		case "", tailer.StartBeginning, tailer.StartEnd:
+		default:
+			return fmt.Errorf("sources[%d].start_position must be %q or %q", i, tailer.StartBeginning, tailer.StartEnd)
+		}
+		switch src.OnParseError {
+		case "", agent.ParseErrorDrop, agent.ParseErrorForwardRaw:
+		default:
+			return fmt.Errorf("sources[%d].on_parse_error must be %q or %q", i, agent.ParseErrorDrop, agent.ParseErrorForwardRaw)
+		}
+		if src.RescanInterval < 0 {
+			return fmt.Errorf("sources[%d].rescan_interval must not be negative", i)
+		}
+		if src.ReadBufferSize < 0 {
+			return fmt.Errorf("sources[%d].read_buffer_size must not be negative", i)
+		}
+		if src.MaxLineLength < 0 {
+			return fmt.Errorf("sources[%d].max_line_length must not be negative", i)
+		}
+		if src.MultiLineTimeout < 0 {
+			return fmt.Errorf("sources[%d].multiline_timeout must not be negative", i)
+		}
+		if src.MultiLineMaxLines < 0 {
+			return fmt.Errorf("sources[%d].multiline_max_lines must not be negative", i)
+		}
+		if (src.TimeKey != "" || src.LevelKey != "" || src.MessageKey != "") && src.Type != "json" {
+			return fmt.Errorf("sources[%d]: time_key, level_key and message_key require type json", i)
+		}
+		if src.ThrownOrigin != "" {
+			switch parser.ThrownOriginMode(src.ThrownOrigin) {
+			case parser.ThrownOriginOverride, parser.ThrownOriginFill, parser.ThrownOriginIgnore:
+			default:
+				return fmt.Errorf("sources[%d].thrown_origin must be %q, %q or %q", i, parser.ThrownOriginOverride, parser.ThrownOriginFill, parser.ThrownOriginIgnore)
+			}
+			switch src.Type {
+			case "magento", "prestashop", "wordpress":
+			default:
+				return fmt.Errorf("sources[%d]: thrown_origin requires type magento, prestashop or wordpress", i)
+			}
+		}
+		if src.Type == "regex" {
+			if _, err := parser.NewRegexParser(src.Pattern, nil); err != nil {
+				return fmt.Errorf("sources[%d].pattern: %w", i, err)
+			}
+		} else if src.Pattern != "" || src.TimeLayout != "" {
+			return fmt.Errorf("sources[%d]: pattern and time_layout require type regex", i)
+		}
+		if src.PathLabels != "" {
+			if _, err := agent.ParsePathTemplate(src.PathLabels); err != nil {
+				return fmt.Errorf("sources[%d].path_labels: %w", i, err)
+			}
+		}
 	}
 	return nil
 }
@@ -182,21 +344,25 @@ func parseBufferSize(s string) int64 {
 
 	s = strings.TrimSpace(strings.ToUpper(s))
 
-	multipliers := map[string]int64{
-		"B":  1,
-		"KB": 1024,
-		"MB": 1024 * 1024,
-		"GB": 1024 * 1024 * 1024,
+	// Longest suffixes first, so "MB" isn't read as "B"
+	multipliers := []struct {
+		suffix string
+		mult   int64
+	}{
+		{"GB", 1024 * 1024 * 1024},
+		{"MB", 1024 * 1024},
+		{"KB", 1024},
+		{"B", 1},
 	}
 
-	for suffix, mult := range multipliers {
-		if strings.HasSuffix(s, suffix) {
-			numStr := strings.TrimSuffix(s, suffix)
+	for _, m := range multipliers {
+		if strings.HasSuffix(s, m.suffix) {
+			numStr := strings.TrimSuffix(s, m.suffix)
 			num, err := strconv.ParseInt(strings.TrimSpace(numStr), 10, 64)
 			if err != nil {
 				return 0
 			}
-			return num * mult
+			return num * m.mult
 		}
 	}
 
@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/agent"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -96,6 +98,42 @@ sources:
 	if cfg.Agent.FlushInterval != time.Second {
 		t.Errorf("Agent.FlushInterval = %v, want 1s (default)", cfg.Agent.FlushInterval)
 	}
+	if cfg.Kubernetes.Disabled || cfg.Kubernetes.PathLabels != agent.KubernetesPathLabels {
+		t.Errorf("Kubernetes = %+v, want enabled with the standard pod log template", cfg.Kubernetes)
+	}
+}
+
+func TestLoadConfigAgentMaxLineLength(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "agent.yaml")
+	configContent := `
+server:
+  address: "localhost:9443"
+agent:
+  max_line_length: 262144
+
+sources:
+  - name: "app"
+    type: "nginx"
+    path: "/var/log/app.log"
+  - name: "frontend"
+    type: "nginx"
+    path: "/var/log/frontend.log"
+    max_line_length: 4194304
+`
+	if err := os.WriteFile(configFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if got := cfg.Sources[0].MaxLineLength; got != 262144 {
+		t.Errorf("Sources[0].MaxLineLength = %d, want the agent default 262144", got)
+	}
+	if got := cfg.Sources[1].MaxLineLength; got != 4194304 {
+		t.Errorf("Sources[1].MaxLineLength = %d, want its own 4194304", got)
+	}
 }
 
 func TestLoadConfigValidation(t *testing.T) {
@@ -129,6 +167,116 @@ func TestLoadConfigValidation(t *testing.T) {
 			config:  "server:\n  address: localhost:9443\nsources:\n  - name: test\n    path: /tmp/test.log",
 			wantErr: "sources[0].type is required",
 		},
+		{
+			name:    "negative max batch bytes",
+			config:  "server:\n  address: localhost:9443\nagent:\n  max_batch_bytes: -1\nsources:\n  - name: test\n    type: nginx\n    path: /tmp/test.log",
+			wantErr: "agent.max_batch_bytes must be >= 0",
+		},
+		{
+			name:    "negative max inflight batches",
+			config:  "server:\n  address: localhost:9443\n  max_inflight_batches: -1\nsources:\n  - name: test\n    type: nginx\n    path: /tmp/test.log",
+			wantErr: "server.max_inflight_batches must be >= 0",
+		},
+		{
+			name:    "unknown compression",
+			config:  "server:\n  address: localhost:9443\n  compression: brotli\nsources:\n  - name: test\n    type: nginx\n    path: /tmp/test.log",
+			wantErr: "server.compression must be none, gzip or zstd",
+		},
+		{
+			name:    "negative parse workers",
+			config:  "server:\n  address: localhost:9443\nagent:\n  parse_workers: -1\nsources:\n  - name: test\n    type: nginx\n    path: /tmp/test.log",
+			wantErr: "agent.parse_workers must be >= 0",
+		},
+		{
+			name:    "invalid kubernetes path labels",
+			config:  "server:\n  address: localhost:9443\nkubernetes:\n  path_labels: /var/log/pods/\nsources:\n  - name: test\n    type: nginx\n    path: /tmp/test.log",
+			wantErr: "kubernetes.path_labels",
+		},
+		{
+			name:    "unknown level inference level",
+			config:  "server:\n  address: localhost:9443\nlevel_inference:\n  enabled: true\n  keywords:\n    severe: [timeout]\nsources:\n  - name: test\n    type: nginx\n    path: /tmp/test.log",
+			wantErr: "level_inference.keywords",
+		},
+		{
+			name:    "negative backfill rate",
+			config:  "server:\n  address: localhost:9443\nreliability:\n  backfill_rate: -1\nsources:\n  - name: test\n    type: nginx\n    path: /tmp/test.log",
+			wantErr: "reliability.backfill_rate must be >= 0",
+		},
+		{
+			name:    "invalid start position",
+			config:  "server:\n  address: localhost:9443\nsources:\n  - name: test\n    type: nginx\n    path: /tmp/test.log\n    start_position: middle",
+			wantErr: "sources[0].start_position",
+		},
+		{
+			name:    "negative rescan interval",
+			config:  "server:\n  address: localhost:9443\nsources:\n  - name: test\n    type: nginx\n    path: /tmp/*.log\n    rescan_interval: -1s",
+			wantErr: "sources[0].rescan_interval",
+		},
+		{
+			name:    "negative max line length",
+			config:  "server:\n  address: localhost:9443\nsources:\n  - name: test\n    type: nginx\n    path: /tmp/test.log\n    max_line_length: -1",
+			wantErr: "sources[0].max_line_length",
+		},
+		{
+			name:    "negative agent max line length",
+			config:  "server:\n  address: localhost:9443\nagent:\n  max_line_length: -1\nsources:\n  - name: test\n    type: nginx\n    path: /tmp/test.log",
+			wantErr: "agent.max_line_length",
+		},
+		{
+			name:    "negative checkpoint interval",
+			config:  "server:\n  address: localhost:9443\nagent:\n  state_file: /tmp/state.json\n  checkpoint_interval: -1s\nsources:\n  - name: test\n    type: nginx\n    path: /tmp/test.log",
+			wantErr: "agent.checkpoint_interval",
+		},
+		{
+			name:    "negative multiline timeout",
+			config:  "server:\n  address: localhost:9443\nsources:\n  - name: test\n    type: magento\n    path: /tmp/test.log\n    multiline_timeout: -1s",
+			wantErr: "sources[0].multiline_timeout",
+		},
+		{
+			name:    "negative multiline max lines",
+			config:  "server:\n  address: localhost:9443\nsources:\n  - name: test\n    type: magento\n    path: /tmp/test.log\n    multiline_max_lines: -1",
+			wantErr: "sources[0].multiline_max_lines",
+		},
+		{
+			name:    "unknown parse error policy",
+			config:  "server:\n  address: localhost:9443\nsources:\n  - name: test\n    type: nginx\n    path: /tmp/test.log\n    on_parse_error: keep",
+			wantErr: "sources[0].on_parse_error",
+		},
+		{
+			name:    "json keys on a non-json source",
+			config:  "server:\n  address: localhost:9443\nsources:\n  - name: test\n    type: nginx\n    path: /tmp/test.log\n    message_key: message",
+			wantErr: "require type json",
+		},
+		{
+			name:    "unknown thrown origin mode",
+			config:  "server:\n  address: localhost:9443\nsources:\n  - name: test\n    type: magento\n    path: /tmp/test.log\n    thrown_origin: replace",
+			wantErr: "sources[0].thrown_origin must be",
+		},
+		{
+			name:    "thrown origin on a non-php source",
+			config:  "server:\n  address: localhost:9443\nsources:\n  - name: test\n    type: nginx\n    path: /tmp/test.log\n    thrown_origin: fill",
+			wantErr: "thrown_origin requires type magento",
+		},
+		{
+			name:    "regex source without pattern",
+			config:  "server:\n  address: localhost:9443\nsources:\n  - name: test\n    type: regex\n    path: /tmp/test.log",
+			wantErr: "sources[0].pattern: pattern is required",
+		},
+		{
+			name:    "invalid regex pattern",
+			config:  "server:\n  address: localhost:9443\nsources:\n  - name: test\n    type: regex\n    path: /tmp/test.log\n    pattern: \"(?P<message>.*\"",
+			wantErr: "sources[0].pattern: invalid pattern",
+		},
+		{
+			name:    "pattern on a non-regex source",
+			config:  "server:\n  address: localhost:9443\nsources:\n  - name: test\n    type: nginx\n    path: /tmp/test.log\n    pattern: \"(?P<message>.*)\"",
+			wantErr: "require type regex",
+		},
+		{
+			name:    "invalid path labels",
+			config:  "server:\n  address: localhost:9443\nsources:\n  - name: test\n    type: nginx\n    path: /var/log/*/app.log\n    path_labels: /var/log/{service/",
+			wantErr: "sources[0].path_labels",
+		},
 	}
 
 	for _, tt := range tests {
@@ -158,6 +306,31 @@ func TestLoadConfigFileNotFound(t *testing.T) {
 	}
 }
 
+func TestParseBufferSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"", 0},
+		{"512", 512},
+		{"512B", 512},
+		{"64KB", 64 * 1024},
+		{"100MB", 100 * 1024 * 1024},
+		{"2gb", 2 * 1024 * 1024 * 1024},
+		{" 10 MB ", 10 * 1024 * 1024},
+		{"lots", 0},
+	}
+
+	for _, tt := range tests {
+		// Repeat, since a wrong suffix match used to depend on map order
+		for i := 0; i < 20; i++ {
+			if got := parseBufferSize(tt.in); got != tt.want {
+				t.Fatalf("parseBufferSize(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+		}
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsSubstring(s, substr))
 }
@@ -7,8 +7,10 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/good-yellow-bee/blazelog/internal/agent"
+	"github.com/good-yellow-bee/blazelog/internal/metrics"
 	"github.com/good-yellow-bee/blazelog/internal/parser"
 	"github.com/good-yellow-bee/blazelog/pkg/config"
 	"github.com/spf13/cobra"
@@ -82,6 +84,29 @@ func runAgent(cmd *cobra.Command, args []string) error {
 			Type:   src.Type,
 			Path:   src.Path,
 			Follow: src.Follow,
+
+			PathLabels:    src.PathLabels,
+			StartPosition: src.StartPosition,
+			ReadRotated:   src.ReadRotated,
+
+			RescanInterval: src.RescanInterval,
+
+			ReadBufferSize: src.ReadBufferSize,
+			MaxLineLength:  src.MaxLineLength,
+
+			OnParseError: src.OnParseError,
+
+			MultiLineTimeout:  src.MultiLineTimeout,
+			MultiLineMaxLines: src.MultiLineMaxLines,
+
+			TimeKey:    src.TimeKey,
+			LevelKey:   src.LevelKey,
+			MessageKey: src.MessageKey,
+
+			ThrownOrigin: parser.ThrownOriginMode(src.ThrownOrigin),
+
+			Pattern:    src.Pattern,
+			TimeLayout: src.TimeLayout,
 		}
 	}
 
@@ -91,6 +116,7 @@ func runAgent(cmd *cobra.Command, args []string) error {
 		ProjectID:     cfg.Agent.ProjectID,
 		ServerAddress: cfg.Server.Address,
 		BatchSize:     cfg.Agent.BatchSize,
+		MaxBatchBytes: cfg.Agent.MaxBatchBytes,
 		FlushInterval: cfg.Agent.FlushInterval,
 		Sources:       sources,
 		Labels:        cfg.Labels,
@@ -102,6 +128,34 @@ func runAgent(cmd *cobra.Command, args []string) error {
 		HeartbeatInterval: cfg.Reliability.HeartbeatInterval,
 		ReconnectInitial:  cfg.Reliability.ReconnectInitial,
 		ReconnectMax:      cfg.Reliability.ReconnectMax,
+		RetryWindow:       cfg.Reliability.RetryWindow,
+		MaxMessageSize:    cfg.Server.MaxMessageSize,
+		MaxInFlight:       cfg.Server.MaxInFlightBatches,
+		Compression:       cfg.Server.Compression,
+		BackfillRate:      cfg.Reliability.BackfillRate,
+
+		CheckpointFile:     cfg.Agent.StateFile,
+		CheckpointInterval: cfg.Agent.CheckpointInterval,
+
+		ParseWorkers: cfg.Agent.ParseWorkers,
+		RawTail:      cfg.Agent.RawTail,
+
+		TransferReportInterval: cfg.Server.TransferReportInterval,
+	}
+	if !cfg.Kubernetes.Disabled {
+		agentCfg.KubernetesPathLabels = cfg.Kubernetes.PathLabels
+	}
+	if cfg.Levels.Enabled {
+		levels, err := parser.NewLevelInferencer(cfg.Levels.Keywords)
+		if err != nil {
+			return fmt.Errorf("level inference: %w", err)
+		}
+		agentCfg.LevelInference = levels
+	}
+	if cfg.Paths.Enabled {
+		agentCfg.PathNormalization = &parser.PathNormalizer{CaseFold: cfg.Paths.CaseFold}
+	} else if cfg.Paths.CaseFold {
+		log.Printf("path_normalization.case_fold has no effect unless path_normalization.enabled is set")
 	}
 
 	// Configure TLS if enabled
@@ -133,6 +187,22 @@ func runAgent(cmd *cobra.Command, args []string) error {
 		cancel()
 	}()
 
+	// Start metrics server (if enabled)
+	if cfg.Agent.MetricsAddress != "" {
+		metrics.SetBuildInfo(config.Version, config.Commit, config.BuildTime)
+		metricsServer := metrics.NewServer(cfg.Agent.MetricsAddress)
+		go func() {
+			if err := metricsServer.Start(); err != nil {
+				log.Printf("metrics server: %v", err)
+			}
+		}()
+		defer func() {
+			shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer shutdownCancel()
+			metricsServer.Shutdown(shutdownCtx)
+		}()
+	}
+
 	// Run agent
 	log.Printf("starting blazelog-agent %s", config.Version)
 	log.Printf("connecting to %s", cfg.Server.Address)
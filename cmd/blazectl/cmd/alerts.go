@@ -0,0 +1,512 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/good-yellow-bee/blazelog/internal/alerting"
+	"github.com/good-yellow-bee/blazelog/internal/models"
+)
+
+var (
+	alertsServer string
+	alertsRule   string
+	alertsSince  time.Duration
+	alertsFilter string
+	alertsDBPath string
+
+	alertsMaxEntries  int
+	alertsMaxDuration time.Duration
+
+	alertsControlAddr string
+)
+
+// replayProgressInterval is how often a dry run reports its progress.
+var replayProgressInterval = 5 * time.Second
+
+var alertsCmd = &cobra.Command{
+	Use:   "alerts",
+	Short: "Work with alert rules",
+	Long:  `Commands for developing and checking alert rules.`,
+}
+
+var alertsTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Dry-run alert rules against a server's recent logs",
+	Long: `Replay the logs a BlazeLog server stored over --since through the rules
+in --rule, oldest first, and report when each rule would have fired.
+
+Each entry is evaluated at its own timestamp, so threshold windows and
+cooldowns behave as they would have live. Rules with enabled: false are
+replayed too. No notifications are sent and rules are never auto-muted.
+parse_errors rules need the agent's parse results and are skipped.
+
+Each alert lists the channels it would have notified. Rules with
+project_id and no notify list inherit their project's default_notify,
+read from the database given with --db (needs BLAZELOG_DB_KEY).
+
+Logs are fetched with the API's pagination; narrow them with the filters
+(--level, --source, --filter, ...) for busy servers. The replay stops at
+--max-entries entries or after --max-duration, and the report is then
+marked partial. Long replays print their progress to stderr. Authenticate
+as for blazectl logs: --token or BLAZELOG_TOKEN.
+
+Examples:
+  # How often would the new rules have fired over the last day?
+  blazectl alerts test --server https://logs.example.com --rule rules.yaml --since 24h
+
+  # Only replay nginx logs, as JSON
+  blazectl alerts test --server https://logs.example.com --rule rules.yaml --type nginx -o json`,
+	Args: cobra.NoArgs,
+	RunE: runAlertsTest,
+}
+
+var alertsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List a running tail's alert rules and their mute state",
+	Long: `List the alert rules of a blazectl tail started with --control-addr,
+with the rules auto-muted for flooding and when each mute lifts on its own.
+
+Examples:
+  blazectl tail /var/log/app.log --alert-rules rules.yaml --control-addr 127.0.0.1:9477
+  blazectl alerts list --control-addr 127.0.0.1:9477`,
+	Args: cobra.NoArgs,
+	RunE: runAlertsList,
+}
+
+var alertsUnmuteCmd = &cobra.Command{
+	Use:   "unmute <rule>",
+	Short: "Lift a running tail's auto-mute on a rule",
+	Long: `Lift the flood auto-mute on a rule of a blazectl tail started with
+--control-addr, so it notifies again. Its flood window starts over.
+
+Examples:
+  blazectl alerts unmute "Error Burst" --control-addr 127.0.0.1:9477`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAlertsUnmute,
+}
+
+func init() {
+	rootCmd.AddCommand(alertsCmd)
+	alertsCmd.AddCommand(alertsTestCmd)
+	alertsCmd.AddCommand(alertsListCmd)
+	alertsCmd.AddCommand(alertsUnmuteCmd)
+
+	for _, c := range []*cobra.Command{alertsListCmd, alertsUnmuteCmd} {
+		c.Flags().StringVar(&alertsControlAddr, "control-addr", "", "control address of the running blazectl tail (its --control-addr)")
+		c.MarkFlagRequired("control-addr")
+	}
+
+	alertsTestCmd.Flags().StringVar(&alertsServer, "server", "", "server base URL")
+	alertsTestCmd.Flags().StringVar(&logsToken, "token", os.Getenv("BLAZELOG_TOKEN"), "API access token (default: $BLAZELOG_TOKEN)")
+	alertsTestCmd.Flags().BoolVar(&logsInsecure, "insecure-skip-verify", false, "skip TLS certificate verification (testing only)")
+	alertsTestCmd.Flags().StringVar(&alertsRule, "rule", "", "alert rules YAML file")
+	alertsTestCmd.Flags().DurationVar(&alertsSince, "since", 24*time.Hour, "replay logs from this long ago")
+	alertsTestCmd.Flags().StringVar(&alertsFilter, "filter", "", "DSL filter expression for the replayed logs (overrides the flat filters)")
+	alertsTestCmd.Flags().StringVar(&alertsDBPath, "db", "", "SQLite database with the projects of rules that set project_id, for their default channels")
+	alertsTestCmd.Flags().IntVar(&alertsMaxEntries, "max-entries", 1000000, "stop the replay after this many entries (0 = no limit)")
+	alertsTestCmd.Flags().DurationVar(&alertsMaxDuration, "max-duration", 10*time.Minute, "stop the replay after this long (0 = no limit)")
+	addLogFilterFlags(alertsTestCmd)
+	alertsTestCmd.MarkFlagRequired("server")
+	alertsTestCmd.MarkFlagRequired("rule")
+}
+
+// apiLogEntry converts one of the API's log objects to the entry that
+// rules are evaluated against.
+func apiLogEntry(raw json.RawMessage) (*models.LogEntry, error) {
+	var v struct {
+		Timestamp  string            `json:"timestamp"`
+		Level      string            `json:"level"`
+		Message    string            `json:"message"`
+		Source     string            `json:"source"`
+		Type       string            `json:"type"`
+		FilePath   string            `json:"file_path"`
+		LineNumber int64             `json:"line_number"`
+		Fields     map[string]any    `json:"fields"`
+		Labels     map[string]string `json:"labels"`
+		HTTPStatus int               `json:"http_status"`
+	}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, fmt.Errorf("decode entry: %w", err)
+	}
+	ts, err := time.Parse(time.RFC3339, v.Timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("entry timestamp: %w", err)
+	}
+
+	entry := models.NewLogEntry()
+	entry.Timestamp = ts
+	entry.Level = models.ParseLogLevel(v.Level)
+	entry.Message = v.Message
+	entry.Source = v.Source
+	entry.Type = models.LogType(v.Type)
+	entry.FilePath = v.FilePath
+	entry.LineNumber = v.LineNumber
+	for k, val := range v.Fields {
+		entry.SetField(k, val)
+	}
+	for k, val := range v.Labels {
+		entry.SetLabel(k, val)
+	}
+	// Stored as a column; parsers put it in the status field
+	if _, ok := entry.Fields["status"]; !ok && v.HTTPStatus > 0 {
+		entry.SetField("status", v.HTTPStatus)
+	}
+	return entry, nil
+}
+
+// Why a dry run stopped before the end of its logs (dryRun.PartialReason).
+const (
+	partialMaxEntries   = "max_entries"
+	partialMaxDuration  = "max_duration"
+	partialResultWindow = "result_window" // the server's max result rows
+)
+
+// errReplayLimit stops the log pager once a replay limit is reached.
+var errReplayLimit = errors.New("replay limit reached")
+
+// replayLimits bounds a dry run; zero values don't limit.
+type replayLimits struct {
+	maxEntries  int
+	maxDuration time.Duration
+}
+
+// dryRun is the result of replaying logs through alert rules.
+type dryRun struct {
+	Entries       int            `json:"entries"`
+	Partial       bool           `json:"partial"`                  // stopped before the end of the logs
+	PartialReason string         `json:"partial_reason,omitempty"` // a partial* constant
+	ReplayedTo    *time.Time     `json:"replayed_to,omitempty"`    // last replayed entry of a partial run
+	Skipped       []string       `json:"skipped_rules,omitempty"`  // rules that can't be replayed
+	Alerts        []*dryRunAlert `json:"alerts"`
+	Counts        map[string]int `json:"counts"` // by rule name
+
+	rules  []*alerting.Rule // in file order, for the report
+	limits replayLimits     // for the report of a partial run
+	last   time.Time        // timestamp of the last replayed entry
+}
+
+// dryRunAlert is an alert that would have fired.
+type dryRunAlert struct {
+	Rule      string            `json:"rule"`
+	Severity  alerting.Severity `json:"severity"`
+	Timestamp time.Time         `json:"timestamp"`
+	Message   string            `json:"message"`
+	Notify    []string          `json:"notify"` // channels it would have notified
+}
+
+// newDryRun returns a replay of rules and the engine that evaluates it.
+// Disabled rules are replayed too, since testing them is the point.
+// Notifications are discarded and rules are never auto-muted. projectNotify,
+// if not nil, resolves the channels of rules inheriting project defaults.
+func newDryRun(rules []*alerting.Rule, projectNotify alerting.ProjectNotifyResolver) (*dryRun, *alerting.Engine) {
+	run := &dryRun{Counts: make(map[string]int), rules: rules}
+	enabled := true
+	var replayed []*alerting.Rule
+	for _, rule := range rules {
+		if rule.Type == alerting.RuleTypeParseErrors {
+			run.Skipped = append(run.Skipped, rule.Name)
+			continue
+		}
+		rule.Enabled = &enabled
+		replayed = append(replayed, rule)
+		run.Counts[rule.Name] = 0
+	}
+
+	opts := alerting.DefaultEngineOptions()
+	opts.MuteAfter = -1
+	opts.ProjectNotify = projectNotify
+	engine := alerting.NewEngine(replayed, opts)
+	go func() {
+		for range engine.Alerts() {
+		}
+	}()
+	return run, engine
+}
+
+// evaluate runs one entry through engine at its own timestamp.
+func (r *dryRun) evaluate(engine *alerting.Engine, entry *models.LogEntry) {
+	r.Entries++
+	r.last = entry.Timestamp
+	for _, alert := range engine.EvaluateAt(entry, entry.Timestamp) {
+		r.Counts[alert.RuleName]++
+		notify := alert.Notify
+		if notify == nil {
+			notify = []string{}
+		}
+		r.Alerts = append(r.Alerts, &dryRunAlert{
+			Rule:      alert.RuleName,
+			Severity:  alert.Severity,
+			Timestamp: alert.Timestamp,
+			Message:   alert.Message,
+			Notify:    notify,
+		})
+	}
+}
+
+// replay runs the logs pager fetches through engine, until the last page
+// or one of limits. Stopping at a limit, or at the server's max result
+// window, marks the run partial. Every replayProgressInterval it writes
+// its progress to progress.
+func (r *dryRun) replay(ctx context.Context, pager *logPager, engine *alerting.Engine, limits replayLimits, progress io.Writer) error {
+	r.limits = limits
+	replayCtx := ctx
+	if limits.maxDuration > 0 {
+		var cancel context.CancelFunc
+		replayCtx, cancel = context.WithTimeout(ctx, limits.maxDuration)
+		defer cancel()
+	}
+
+	started := time.Now()
+	reported := started
+	truncated, err := pager.run(replayCtx, func(raw json.RawMessage) error {
+		if limits.maxEntries > 0 && r.Entries >= limits.maxEntries {
+			r.stop(partialMaxEntries)
+			return errReplayLimit
+		}
+		if replayCtx.Err() != nil && ctx.Err() == nil {
+			r.stop(partialMaxDuration)
+			return errReplayLimit
+		}
+		entry, err := apiLogEntry(raw)
+		if err != nil {
+			return err
+		}
+		r.evaluate(engine, entry)
+		if now := time.Now(); now.Sub(reported) >= replayProgressInterval {
+			reported = now
+			fmt.Fprintf(progress, "replayed %d entries in %s, up to %s\n", r.Entries,
+				now.Sub(started).Round(time.Second), r.last.Local().Format("2006-01-02 15:04:05"))
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, errReplayLimit):
+		return nil
+	case err != nil && replayCtx.Err() != nil && ctx.Err() == nil:
+		// The page in flight when --max-duration ran out
+		r.stop(partialMaxDuration)
+		return nil
+	case err != nil:
+		return err
+	case truncated:
+		r.stop(partialResultWindow)
+	}
+	return nil
+}
+
+// stop marks the run partial for reason.
+func (r *dryRun) stop(reason string) {
+	r.Partial, r.PartialReason = true, reason
+	if r.Entries > 0 {
+		last := r.last
+		r.ReplayedTo = &last
+	}
+}
+
+// partialNote explains in the text report why a partial run stopped.
+func (r *dryRun) partialNote() string {
+	var note string
+	switch r.PartialReason {
+	case partialMaxEntries:
+		note = fmt.Sprintf("stopped at --max-entries %d", r.limits.maxEntries)
+	case partialMaxDuration:
+		note = fmt.Sprintf("stopped after --max-duration %s", r.limits.maxDuration)
+	default:
+		return "Partial result: only the oldest logs up to the server's max result window were replayed; shorten --since or filter the logs"
+	}
+	if r.ReplayedTo == nil {
+		return "Partial result: " + note + " before any entry was replayed"
+	}
+	return fmt.Sprintf("Partial result: %s; logs after %s were not replayed", note, r.ReplayedTo.Local().Format("2006-01-02 15:04:05"))
+}
+
+// print writes the report: each rule's count and firing times.
+func (r *dryRun) print(w io.Writer, from, to time.Time) {
+	if GetOutput() == "json" {
+		if r.Alerts == nil {
+			r.Alerts = []*dryRunAlert{}
+		}
+		data, _ := json.MarshalIndent(r, "", "  ")
+		fmt.Fprintln(w, string(data))
+		return
+	}
+
+	fmt.Fprintf(w, "Replayed %d entries from %s to %s\n", r.Entries,
+		from.Local().Format("2006-01-02 15:04:05"), to.Local().Format("2006-01-02 15:04:05"))
+	if r.Partial {
+		fmt.Fprintln(w, r.partialNote())
+	}
+	for _, rule := range r.rules {
+		count, ok := r.Counts[rule.Name]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s [%s]: %d alert(s)\n", rule.Name, rule.Severity, count)
+		for _, a := range r.Alerts {
+			if a.Rule == rule.Name {
+				fmt.Fprintf(w, "  %s  %s%s\n", a.Timestamp.Local().Format("2006-01-02 15:04:05"), a.Message, notifySuffix(a.Notify))
+			}
+		}
+	}
+	for _, name := range r.Skipped {
+		fmt.Fprintf(w, "\n%s: skipped, parse_errors rules can't be replayed from stored logs\n", name)
+	}
+}
+
+// notifySuffix formats the channels of an alert for the text report.
+func notifySuffix(channels []string) string {
+	if len(channels) == 0 {
+		return ""
+	}
+	return "  -> " + strings.Join(channels, ", ")
+}
+
+func runAlertsTest(cmd *cobra.Command, args []string) error {
+	if alertsSince <= 0 {
+		return errors.New("--since must be > 0")
+	}
+	if alertsMaxEntries < 0 || alertsMaxDuration < 0 {
+		return errors.New("--max-entries and --max-duration must be >= 0")
+	}
+	data, err := os.ReadFile(alertsRule)
+	if err != nil {
+		return fmt.Errorf("read rules: %w", err)
+	}
+	rules, err := alerting.LoadRulesFromBytes(data)
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		return fmt.Errorf("%s has no rules", alertsRule)
+	}
+
+	base, err := apiURL(alertsServer, logsPath)
+	if err != nil {
+		return fmt.Errorf("server %q: %w", alertsServer, err)
+	}
+	end := time.Now()
+	start := end.Add(-alertsSince)
+	query := logFilterQuery()
+	query.Set("start", start.UTC().Format(time.RFC3339))
+	query.Set("end", end.UTC().Format(time.RFC3339))
+	if alertsFilter != "" {
+		query.Set("filter", alertsFilter)
+	}
+	// Windows and cooldowns need the entries in time order
+	query.Set("order", "timestamp")
+	query.Set("order_dir", "asc")
+	query.Set("collapse_traces", "false")
+	base.RawQuery = query.Encode()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	projectNotify, closeDB, err := openProjectNotify(alertsDBPath)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	run, engine := newDryRun(rules, projectNotify)
+	defer engine.Close()
+	pager := &logPager{
+		client: &http.Client{Transport: apiTransport()},
+		token:  logsToken,
+		url:    *base,
+	}
+	limits := replayLimits{maxEntries: alertsMaxEntries, maxDuration: alertsMaxDuration}
+	if err := run.replay(ctx, pager, engine, limits, os.Stderr); err != nil {
+		return err
+	}
+
+	run.print(os.Stdout, start, end)
+	return nil
+}
+
+// controlClient talks to a running tail's control endpoint.
+var controlClient = &http.Client{Timeout: 10 * time.Second}
+
+// controlRequest calls a tail's control endpoint and decodes its response.
+func controlRequest(method, path string, out any) error {
+	req, err := http.NewRequest(method, "http://"+alertsControlAddr+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := controlClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("reach tail at %s: %w", alertsControlAddr, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&e) == nil && e.Error != "" {
+			return errors.New(e.Error)
+		}
+		return fmt.Errorf("tail returned %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func runAlertsList(cmd *cobra.Command, args []string) error {
+	var resp struct {
+		Rules []controlRule `json:"rules"`
+	}
+	if err := controlRequest(http.MethodGet, tailControlPath, &resp); err != nil {
+		return err
+	}
+	printControlRules(os.Stdout, resp.Rules)
+	return nil
+}
+
+// printControlRules writes the rules with their state, as a table or JSON.
+func printControlRules(w io.Writer, rules []controlRule) {
+	if GetOutput() == "json" {
+		data, _ := json.MarshalIndent(rules, "", "  ")
+		fmt.Fprintln(w, string(data))
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tTYPE\tSEVERITY\tSTATE")
+	for _, r := range rules {
+		state := "active"
+		switch {
+		case r.Muted && r.MutedUntil != nil:
+			state = "muted until " + r.MutedUntil.Local().Format("2006-01-02 15:04:05")
+		case !r.Enabled:
+			state = "disabled"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Name, r.Type, r.Severity, state)
+	}
+	tw.Flush()
+}
+
+func runAlertsUnmute(cmd *cobra.Command, args []string) error {
+	var resp unmuteResponse
+	if err := controlRequest(http.MethodPost, tailControlPath+"/"+url.PathEscape(args[0])+"/unmute", &resp); err != nil {
+		return err
+	}
+	if resp.Unmuted {
+		fmt.Printf("Unmuted %q\n", resp.Rule)
+	} else {
+		fmt.Printf("%q was not muted\n", resp.Rule)
+	}
+	return nil
+}
@@ -0,0 +1,348 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/alerting"
+	"github.com/good-yellow-bee/blazelog/internal/models"
+	"github.com/good-yellow-bee/blazelog/internal/storage"
+)
+
+func TestAPILogEntry(t *testing.T) {
+	entry, err := apiLogEntry(json.RawMessage(`{"id":"1","timestamp":"2024-01-15T10:00:00Z","level":"error","message":"upstream timed out","source":"web","type":"nginx","fields":{"request_time":1.5},"labels":{"env":"prod"},"http_status":504}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !entry.Timestamp.Equal(time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Timestamp = %v", entry.Timestamp)
+	}
+	if entry.Level != models.LevelError || entry.Type != models.LogTypeNginx || entry.Source != "web" {
+		t.Errorf("entry = %+v", entry)
+	}
+	if entry.GetFieldInt("status") != 504 || entry.Fields["request_time"] != 1.5 || entry.Labels["env"] != "prod" {
+		t.Errorf("Fields = %v, Labels = %v", entry.Fields, entry.Labels)
+	}
+
+	if _, err := apiLogEntry(json.RawMessage(`{"timestamp":"yesterday"}`)); err == nil {
+		t.Error("apiLogEntry() with a bad timestamp: want error")
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	rules, err := alerting.LoadRulesFromBytes([]byte(`
+rules:
+  - name: "error-burst"
+    type: "threshold"
+    condition:
+      field: "level"
+      value: "error"
+      threshold: 3
+      window: "1m"
+    severity: "high"
+    cooldown: "10m"
+  - name: "fatal"
+    type: "pattern"
+    enabled: false
+    condition:
+      pattern: "FATAL"
+    severity: "critical"
+  - name: "parse-failures"
+    type: "parse_errors"
+    condition:
+      threshold: 5
+      window: "5m"
+    severity: "medium"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	run, engine := newDryRun(rules, nil)
+	defer engine.Close()
+
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	at := func(offset time.Duration, level, message string) {
+		entry := models.NewLogEntry()
+		entry.Timestamp = base.Add(offset)
+		entry.Level = models.LogLevel(level)
+		entry.Message = message
+		run.evaluate(engine, entry)
+	}
+	// A burst, another within the cooldown, and one after it
+	for i := 0; i < 3; i++ {
+		at(time.Duration(i)*time.Second, "error", "db timeout")
+	}
+	for i := 0; i < 3; i++ {
+		at(5*time.Minute+time.Duration(i)*time.Second, "error", "db timeout")
+	}
+	for i := 0; i < 3; i++ {
+		at(20*time.Minute+time.Duration(i)*time.Second, "error", "db timeout")
+	}
+	at(30*time.Minute, "fatal", "FATAL: out of memory")
+
+	if run.Entries != 10 {
+		t.Errorf("Entries = %d, want 10", run.Entries)
+	}
+	if run.Counts["error-burst"] != 2 || run.Counts["fatal"] != 1 {
+		t.Errorf("Counts = %v, want error-burst 2 and the disabled fatal rule 1", run.Counts)
+	}
+	if fmt.Sprint(run.Skipped) != "[parse-failures]" {
+		t.Errorf("Skipped = %v, want [parse-failures]", run.Skipped)
+	}
+	if len(run.Alerts) != 3 || !run.Alerts[1].Timestamp.Equal(base.Add(20*time.Minute+2*time.Second)) {
+		t.Errorf("Alerts = %+v, want the second burst alert at its entry's time", run.Alerts)
+	}
+
+	var buf bytes.Buffer
+	run.print(&buf, base, base.Add(time.Hour))
+	for _, want := range []string{"Replayed 10 entries", "error-burst [high]: 2 alert(s)", "fatal [critical]: 1 alert(s)", "parse-failures: skipped"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("report lacks %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestDryRunProjectNotify(t *testing.T) {
+	const dbKey = "test-db-key-32-bytes-long!!!!!"
+	t.Setenv("BLAZELOG_DB_KEY", dbKey)
+	t.Setenv("BLAZELOG_MASTER_KEY", "test-master-key-32-bytes-long!!!")
+	dbPath := filepath.Join(t.TempDir(), "blazelog.db")
+
+	store := storage.NewSQLiteStorage(dbPath, []byte("test-master-key-32-bytes-long!!!"), []byte(dbKey))
+	if err := store.Open(); err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := store.Migrate(); err != nil {
+		t.Fatalf("migrate database: %v", err)
+	}
+	project := models.NewProject("web", "")
+	project.ID = "project-1"
+	project.DefaultNotify = []string{"slack", "email"}
+	if err := store.Projects().Create(context.Background(), project); err != nil {
+		t.Fatalf("create project: %v", err)
+	}
+	store.Close()
+
+	rules, err := alerting.LoadRulesFromBytes([]byte(`
+rules:
+  - name: "inherits"
+    type: "pattern"
+    project_id: "project-1"
+    condition:
+      pattern: "FATAL"
+    severity: "critical"
+  - name: "opts-out"
+    type: "pattern"
+    project_id: "project-1"
+    notify: []
+    condition:
+      pattern: "FATAL"
+    severity: "critical"
+  - name: "own-channels"
+    type: "pattern"
+    project_id: "project-1"
+    notify: ["teams"]
+    condition:
+      pattern: "FATAL"
+    severity: "critical"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	projectNotify, closeDB, err := openProjectNotify(dbPath)
+	if err != nil {
+		t.Fatalf("openProjectNotify: %v", err)
+	}
+	defer closeDB()
+	run, engine := newDryRun(rules, projectNotify)
+	defer engine.Close()
+
+	entry := models.NewLogEntry()
+	entry.Timestamp = time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	entry.Message = "FATAL: out of memory"
+	run.evaluate(engine, entry)
+
+	got := make(map[string]string)
+	for _, a := range run.Alerts {
+		got[a.Rule] = fmt.Sprint(a.Notify)
+	}
+	want := map[string]string{"inherits": "[slack email]", "opts-out": "[]", "own-channels": "[teams]"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("notify by rule = %v, want %v", got, want)
+	}
+
+	var buf bytes.Buffer
+	run.print(&buf, entry.Timestamp, entry.Timestamp.Add(time.Hour))
+	if !strings.Contains(buf.String(), "-> slack, email") {
+		t.Errorf("report lacks the inherited channels:\n%s", buf.String())
+	}
+}
+
+func TestDryRunReplayLimits(t *testing.T) {
+	rules, err := alerting.LoadRulesFromBytes([]byte(`
+rules:
+  - name: "error-burst"
+    type: "threshold"
+    condition:
+      field: "level"
+      value: "error"
+      threshold: 5000
+      window: "1m"
+    severity: "low"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var requests []string
+	srv := pagedLogServer(t, 2500, &requests)
+	defer srv.Close()
+	base, err := apiURL(srv.URL, logsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replay := func(limits replayLimits, progress *bytes.Buffer) *dryRun {
+		t.Helper()
+		run, engine := newDryRun(rules, nil)
+		defer engine.Close()
+		pager := &logPager{client: srv.Client(), token: "secret", url: *base}
+		if err := run.replay(context.Background(), pager, engine, limits, progress); err != nil {
+			t.Fatalf("replay() error = %v", err)
+		}
+		return run
+	}
+
+	t.Run("complete", func(t *testing.T) {
+		run := replay(replayLimits{maxEntries: 2500}, &bytes.Buffer{})
+		if run.Entries != 2500 || run.Partial || run.ReplayedTo != nil {
+			t.Errorf("replay = %d entries, partial %v, want all 2500 and complete", run.Entries, run.Partial)
+		}
+	})
+
+	t.Run("max entries", func(t *testing.T) {
+		requests = nil
+		run := replay(replayLimits{maxEntries: 1200}, &bytes.Buffer{})
+		if run.Entries != 1200 || !run.Partial || run.PartialReason != partialMaxEntries || run.ReplayedTo == nil {
+			t.Errorf("replay = %+v, want 1200 entries, partial at max_entries", run)
+		}
+		if len(requests) != 2 {
+			t.Errorf("fetched %d pages, want 2 (no pages past the limit)", len(requests))
+		}
+
+		var buf bytes.Buffer
+		run.print(&buf, time.Now(), time.Now())
+		if !strings.Contains(buf.String(), "Partial result: stopped at --max-entries 1200; logs after") {
+			t.Errorf("report lacks the partial note:\n%s", buf.String())
+		}
+		buf.Reset()
+		saved := output
+		output = "json"
+		defer func() { output = saved }()
+		run.print(&buf, time.Now(), time.Now())
+		var report map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+			t.Fatalf("JSON report: %v", err)
+		}
+		if report["partial"] != true || report["partial_reason"] != "max_entries" || report["replayed_to"] == nil {
+			t.Errorf("JSON report = %v, want partial max_entries with replayed_to", report)
+		}
+	})
+
+	t.Run("max duration", func(t *testing.T) {
+		run := replay(replayLimits{maxDuration: time.Nanosecond}, &bytes.Buffer{})
+		if run.Entries != 0 || !run.Partial || run.PartialReason != partialMaxDuration || run.ReplayedTo != nil {
+			t.Errorf("replay = %+v, want no entries, partial at max_duration", run)
+		}
+		if note := run.partialNote(); !strings.Contains(note, "before any entry was replayed") {
+			t.Errorf("partialNote() = %q", note)
+		}
+	})
+
+	t.Run("progress", func(t *testing.T) {
+		interval := replayProgressInterval
+		replayProgressInterval = 0
+		defer func() { replayProgressInterval = interval }()
+		var progress bytes.Buffer
+		replay(replayLimits{}, &progress)
+		if lines := strings.Count(progress.String(), "\n"); lines != 2500 || !strings.HasPrefix(progress.String(), "replayed 1 entries in ") {
+			t.Errorf("progress = %d lines starting %q, want one per entry", lines, progress.String()[:min(len(progress.String()), 40)])
+		}
+	})
+}
+
+func TestTailControl(t *testing.T) {
+	rules, err := alerting.LoadRulesFromBytes([]byte(`
+rules:
+  - name: "runaway"
+    type: "pattern"
+    condition:
+      pattern: "boom"
+    severity: "high"
+  - name: "quiet"
+    type: "pattern"
+    condition:
+      pattern: "never"
+    severity: "low"
+    enabled: false
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := alerting.NewEngine(rules, &alerting.EngineOptions{AlertBufferSize: 10, MuteAfter: 1})
+	defer engine.Close()
+	entry := models.NewLogEntry()
+	entry.Message = "boom"
+	engine.Evaluate(entry)
+	engine.Evaluate(entry)
+
+	srv := httptest.NewServer(newTailControlHandler(engine))
+	defer srv.Close()
+	alertsControlAddr = strings.TrimPrefix(srv.URL, "http://")
+	defer func() { alertsControlAddr = "" }()
+
+	var list struct {
+		Rules []controlRule `json:"rules"`
+	}
+	if err := controlRequest(http.MethodGet, tailControlPath, &list); err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(list.Rules) != 2 || !list.Rules[0].Muted || list.Rules[0].MutedUntil == nil || list.Rules[1].Muted {
+		t.Fatalf("rules = %+v, want runaway muted", list.Rules)
+	}
+	var out bytes.Buffer
+	printControlRules(&out, list.Rules)
+	if got := out.String(); !strings.Contains(got, "muted until") || !strings.Contains(got, "disabled") {
+		t.Errorf("table = %q, want muted and disabled states", got)
+	}
+
+	var resp unmuteResponse
+	if err := controlRequest(http.MethodPost, tailControlPath+"/runaway/unmute", &resp); err != nil || !resp.Unmuted {
+		t.Fatalf("unmute = %+v, %v; want unmuted", resp, err)
+	}
+	if got := engine.MutedRules(); len(got) != 0 {
+		t.Errorf("MutedRules() after unmute = %v", got)
+	}
+	if err := controlRequest(http.MethodPost, tailControlPath+"/missing/unmute", &resp); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("unmute unknown rule error = %v, want not found", err)
+	}
+}
+
+func TestListenControlLoopbackOnly(t *testing.T) {
+	if _, err := listenControl("0.0.0.0:0"); err == nil {
+		t.Error("listenControl(0.0.0.0) should be refused")
+	}
+	lis, err := listenControl("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listenControl(127.0.0.1): %v", err)
+	}
+	lis.Close()
+}
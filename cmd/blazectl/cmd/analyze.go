@@ -60,7 +60,7 @@ func init() {
 	analyzeCmd.Flags().StringVar(&analyzeFrom, "from", "", "filter entries after date (YYYY-MM-DD or RFC3339)")
 	analyzeCmd.Flags().StringVar(&analyzeTo, "to", "", "filter entries before date (YYYY-MM-DD or RFC3339)")
 	analyzeCmd.Flags().IntVar(&analyzeWorkers, "workers", 0, "number of parallel workers (0 = auto)")
-	analyzeCmd.Flags().StringVarP(&analyzeParser, "parser", "p", "auto", "parser type (nginx, apache, magento, prestashop, wordpress, auto)")
+	analyzeCmd.Flags().StringVarP(&analyzeParser, "parser", "p", "auto", "parser type (nginx, apache, magento, prestashop, wordpress, syslog, postgres, mysql, json, auto)")
 	analyzeCmd.Flags().StringVar(&analyzeExport, "export", "", "export format (json, csv)")
 	analyzeCmd.Flags().StringVar(&analyzeExportTo, "export-to", "", "export file path (default: stdout)")
 	analyzeCmd.Flags().IntVarP(&analyzeLimit, "limit", "n", 0, "limit entries per file (0 = no limit)")
@@ -0,0 +1,555 @@
+package cmd
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const (
+	streamPath        = "/api/v1/logs/stream"
+	minStreamBackoff  = time.Second
+	maxStreamBackoff  = 30 * time.Second
+	maxStreamEventLen = 1024 * 1024 // SSE line limit; entries are capped well below
+)
+
+var (
+	logsServers       []string
+	logsToken         string
+	logsInsecure      bool
+	logsSince         time.Duration
+	logsReorderWindow time.Duration
+	logsNoColor       bool
+
+	// Log filters, shared by tail and query
+	logsLevel      string
+	logsLevels     string
+	logsType       string
+	logsSource     string
+	logsAgentID    string
+	logsProjectID  string
+	logsQuery      string
+	logsSearchMode string
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Read logs from BlazeLog servers",
+	Long: `Commands that read logs from running BlazeLog servers over the REST API.
+
+Authenticate with an access token from POST /api/v1/auth/login, passed with
+--token or the BLAZELOG_TOKEN environment variable.`,
+}
+
+var logsTailCmd = &cobra.Command{
+	Use:   "tail",
+	Short: "Stream logs from one or more servers",
+	Long: `Stream new logs from one or more BlazeLog servers and print them as one
+time-ordered stream.
+
+Each --server gets its own stream and reconnects on its own, resuming after
+the last entry it printed, so one unreachable server doesn't stop the others.
+A dropped stream is retried after the delay the server asks for, backing off
+while the server stays unreachable.
+Entries are held for --reorder-window before printing so that entries from
+different servers come out in timestamp order; a longer window tolerates more
+delay between servers at the cost of later output. Each line is tagged with
+the server it came from: the host, or the name given as name=url. Levels are
+colored on a terminal unless --no-color or NO_COLOR is set.
+
+Examples:
+  # Tail errors from two regions
+  blazectl logs tail --server eu=https://logs-eu.example.com \
+    --server us=https://logs-us.example.com --level error
+
+  # Nginx timeouts
+  blazectl logs tail --server https://logs.example.com --type nginx -q timeout
+
+  # Include the last 10 minutes, as JSON lines
+  blazectl logs tail --server https://logs.example.com --since 10m -o json`,
+	Args: cobra.NoArgs,
+	RunE: runLogsTail,
+}
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+	logsCmd.AddCommand(logsTailCmd)
+
+	logsTailCmd.Flags().StringArrayVar(&logsServers, "server", nil, "server base URL, optionally as name=url (repeat for several servers)")
+	logsTailCmd.Flags().StringVar(&logsToken, "token", os.Getenv("BLAZELOG_TOKEN"), "API access token (default: $BLAZELOG_TOKEN)")
+	logsTailCmd.Flags().BoolVar(&logsInsecure, "insecure-skip-verify", false, "skip TLS certificate verification (testing only)")
+	logsTailCmd.Flags().DurationVar(&logsSince, "since", 0, "also print entries from this long ago (0 = new entries only)")
+	logsTailCmd.Flags().DurationVar(&logsReorderWindow, "reorder-window", 2*time.Second, "how long entries are held to merge servers in timestamp order")
+	logsTailCmd.Flags().BoolVar(&logsNoColor, "no-color", false, "don't color levels (default: colored on a terminal)")
+	addLogFilterFlags(logsTailCmd)
+	logsTailCmd.MarkFlagRequired("server")
+}
+
+// addLogFilterFlags adds the flat log filters of the logs API to cmd.
+func addLogFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&logsLevel, "level", "", "only this level")
+	cmd.Flags().StringVar(&logsLevels, "levels", "", "only these comma-separated levels")
+	cmd.Flags().StringVar(&logsType, "type", "", "only this log type")
+	cmd.Flags().StringVar(&logsSource, "source", "", "only this source")
+	cmd.Flags().StringVar(&logsAgentID, "agent-id", "", "only this agent")
+	cmd.Flags().StringVar(&logsProjectID, "project-id", "", "only this project")
+	cmd.Flags().StringVarP(&logsQuery, "query", "q", "", "message search")
+	cmd.Flags().StringVar(&logsSearchMode, "search-mode", "", "token, substring or phrase")
+}
+
+// logFilterQuery returns the query parameters of the log filter flags.
+func logFilterQuery() url.Values {
+	query := url.Values{}
+	for key, value := range map[string]string{
+		"level": logsLevel, "levels": logsLevels, "type": logsType, "source": logsSource,
+		"agent_id": logsAgentID, "project_id": logsProjectID, "q": logsQuery, "search_mode": logsSearchMode,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	return query
+}
+
+// apiTransport returns the HTTP transport for --insecure-skip-verify.
+func apiTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if logsInsecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec // G402: opt-in, for testing
+	}
+	return transport
+}
+
+// tailEndpoint is one server streamed by logs tail.
+type tailEndpoint struct {
+	name string // origin tag printed with each line
+	url  url.URL
+}
+
+// parseTailEndpoint parses a --server value: a base URL, or name=url.
+func parseTailEndpoint(arg string) (tailEndpoint, error) {
+	name, raw, named := strings.Cut(arg, "=")
+	if !named || strings.Contains(name, "/") {
+		name, raw = "", arg
+	}
+	u, err := apiURL(raw, streamPath)
+	if err != nil {
+		return tailEndpoint{}, fmt.Errorf("server %q: %w", arg, err)
+	}
+	if name == "" {
+		name = u.Host
+	}
+	return tailEndpoint{name: name, url: *u}, nil
+}
+
+// apiURL returns the URL of an API path on the server at base URL raw.
+func apiURL(raw, path string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("want an http:// or https:// URL")
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	u.RawQuery = ""
+	return u, nil
+}
+
+// streamEntry is a log event from one server.
+type streamEntry struct {
+	origin    string
+	id        string
+	timestamp time.Time
+	level     string
+	message   string
+	raw       json.RawMessage
+	seq       uint64 // arrival order, breaks timestamp ties
+	arrived   time.Time
+}
+
+// entryHeap orders entries by timestamp, then arrival.
+type entryHeap []*streamEntry
+
+func (h entryHeap) Len() int { return len(h) }
+func (h entryHeap) Less(i, j int) bool {
+	if !h[i].timestamp.Equal(h[j].timestamp) {
+		return h[i].timestamp.Before(h[j].timestamp)
+	}
+	return h[i].seq < h[j].seq
+}
+func (h entryHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *entryHeap) Push(x any)   { *h = append(*h, x.(*streamEntry)) }
+func (h *entryHeap) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
+
+// reorderBuffer holds entries for a window after they arrive and releases
+// them in timestamp order, so entries from servers with different delays
+// interleave correctly. An entry arriving more than the window late is
+// still printed, just out of order.
+type reorderBuffer struct {
+	window  time.Duration
+	entries entryHeap
+	seq     uint64
+}
+
+func (b *reorderBuffer) push(e *streamEntry) {
+	b.seq++
+	e.seq = b.seq
+	heap.Push(&b.entries, e)
+}
+
+// due pops the entries whose earliest-timestamp head has been held for the
+// window by now.
+func (b *reorderBuffer) due(now time.Time) []*streamEntry {
+	var out []*streamEntry
+	for len(b.entries) > 0 && !b.entries[0].arrived.Add(b.window).After(now) {
+		out = append(out, heap.Pop(&b.entries).(*streamEntry))
+	}
+	return out
+}
+
+// drain pops all entries.
+func (b *reorderBuffer) drain() []*streamEntry {
+	var out []*streamEntry
+	for len(b.entries) > 0 {
+		out = append(out, heap.Pop(&b.entries).(*streamEntry))
+	}
+	return out
+}
+
+// streamResume tracks where a reconnect resumes. The stream start is
+// second-precision, so a reconnect replays the last second; entries seen in
+// it are skipped by ID.
+type streamResume struct {
+	last  time.Time
+	seen  map[string]time.Time
+	retry time.Duration // reconnect delay from the server's retry field; 0 = unset
+}
+
+func newStreamResume(start time.Time) *streamResume {
+	return &streamResume{last: start, seen: make(map[string]time.Time)}
+}
+
+// accept reports whether an entry is new, recording it if so.
+func (r *streamResume) accept(id string, ts time.Time) bool {
+	if id != "" {
+		if _, dup := r.seen[id]; dup {
+			return false
+		}
+		r.seen[id] = ts
+	}
+	if ts.After(r.last) {
+		r.last = ts
+		floor := r.last.Truncate(time.Second)
+		for seenID, seenTS := range r.seen {
+			if seenTS.Before(floor) {
+				delete(r.seen, seenID)
+			}
+		}
+	}
+	return true
+}
+
+// errStreamRejected is a response that retrying won't fix, e.g. a bad token.
+var errStreamRejected = errors.New("rejected")
+
+// tailStreamer streams one endpoint, reconnecting with backoff.
+type tailStreamer struct {
+	client  *http.Client
+	token   string
+	query   url.Values
+	backoff time.Duration // first reconnect delay
+	out     chan<- *streamEntry
+	warn    func(format string, args ...any)
+}
+
+// run streams ep until ctx is done or the server rejects the request.
+func (s *tailStreamer) run(ctx context.Context, ep tailEndpoint, start time.Time) {
+	resume := newStreamResume(start)
+	backoff := s.backoff
+	for {
+		received, err := s.stream(ctx, ep, resume)
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, errStreamRejected) {
+			s.warn("[%s] %v; not retrying", ep.name, err)
+			return
+		}
+		// Wait at least as long as the server asked, starting over once
+		// a connection delivered entries
+		reconnect := s.backoff
+		if resume.retry > 0 {
+			reconnect = resume.retry
+		}
+		if received || backoff < reconnect {
+			backoff = reconnect
+		}
+		if err == nil {
+			err = errors.New("stream closed")
+		}
+		s.warn("[%s] %v; reconnecting in %s", ep.name, err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxStreamBackoff)
+	}
+}
+
+// stream reads one SSE connection. It reports whether any entry arrived.
+func (s *tailStreamer) stream(ctx context.Context, ep tailEndpoint, resume *streamResume) (bool, error) {
+	u := ep.url
+	q := url.Values{}
+	for k, v := range s.query {
+		q[k] = v
+	}
+	q.Set("start", resume.last.UTC().Format(time.RFC3339))
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err := fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return false, fmt.Errorf("%w: %w", errStreamRejected, err)
+		}
+		return false, err
+	}
+
+	received := false
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), maxStreamEventLen)
+	var event, data string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " ")
+		case strings.HasPrefix(line, "retry:"):
+			if ms, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "retry:"))); err == nil && ms >= 0 {
+				resume.retry = time.Duration(ms) * time.Millisecond
+			}
+		case line == "":
+			switch event {
+			case "log":
+				if e, ok := parseStreamEntry(ep.name, data); ok && resume.accept(e.id, e.timestamp) {
+					received = true
+					select {
+					case s.out <- e:
+					case <-ctx.Done():
+						return received, ctx.Err()
+					}
+				}
+			case "error":
+				s.warn("[%s] server error: %s", ep.name, data)
+			case "close":
+				return received, fmt.Errorf("server closed the stream: %s", data)
+			}
+			event, data = "", ""
+		}
+	}
+	return received, scanner.Err()
+}
+
+// parseStreamEntry decodes a log event's data.
+func parseStreamEntry(origin, data string) (*streamEntry, bool) {
+	var v struct {
+		ID        string `json:"id"`
+		Timestamp string `json:"timestamp"`
+		Level     string `json:"level"`
+		Message   string `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		return nil, false
+	}
+	ts, err := time.Parse(time.RFC3339, v.Timestamp)
+	if err != nil {
+		return nil, false
+	}
+	return &streamEntry{
+		origin:    origin,
+		id:        v.ID,
+		timestamp: ts,
+		level:     v.Level,
+		message:   v.Message,
+		raw:       json.RawMessage(data),
+		arrived:   time.Now(),
+	}, true
+}
+
+func runLogsTail(cmd *cobra.Command, args []string) error {
+	endpoints := make([]tailEndpoint, 0, len(logsServers))
+	names := make(map[string]bool)
+	for _, arg := range logsServers {
+		ep, err := parseTailEndpoint(arg)
+		if err != nil {
+			return err
+		}
+		if names[ep.name] {
+			return fmt.Errorf("server name %q is used twice; name them with name=url", ep.name)
+		}
+		names[ep.name] = true
+		endpoints = append(endpoints, ep)
+	}
+	if logsReorderWindow < 0 {
+		return fmt.Errorf("--reorder-window must be >= 0")
+	}
+
+	query := logFilterQuery()
+	transport := apiTransport()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	entries := make(chan *streamEntry, 256)
+	streamer := &tailStreamer{
+		client:  &http.Client{Transport: transport}, // no timeout: streams are long-lived
+		token:   logsToken,
+		query:   query,
+		backoff: minStreamBackoff,
+		out:     entries,
+		warn: func(format string, args ...any) {
+			fmt.Fprintf(os.Stderr, format+"\n", args...)
+		},
+	}
+
+	start := time.Now().Add(-logsSince)
+	var wg sync.WaitGroup
+	for _, ep := range endpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			streamer.run(ctx, ep, start)
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	color := !logsNoColor && os.Getenv("NO_COLOR") == "" && term.IsTerminal(int(os.Stdout.Fd()))
+	buf := &reorderBuffer{window: logsReorderWindow}
+	ticker := time.NewTicker(max(min(logsReorderWindow/4, 250*time.Millisecond), 10*time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case e := <-entries:
+			buf.push(e)
+		case now := <-ticker.C:
+			for _, e := range buf.due(now) {
+				outputStreamEntry(e, color)
+			}
+		case <-done:
+			// Every stream stopped; print what arrived
+			for len(entries) > 0 {
+				buf.push(<-entries)
+			}
+			for _, e := range buf.drain() {
+				outputStreamEntry(e, color)
+			}
+			if ctx.Err() == nil {
+				return fmt.Errorf("no server streams left")
+			}
+			return nil
+		}
+	}
+}
+
+// outputStreamEntry prints an entry tagged with its origin server, with
+// its level colored in table output if color is set.
+func outputStreamEntry(e *streamEntry, color bool) {
+	switch GetOutput() {
+	case "json":
+		var data map[string]any
+		if err := json.Unmarshal(e.raw, &data); err != nil {
+			return
+		}
+		data["server"] = e.origin
+		jsonData, _ := json.Marshal(data)
+		fmt.Println(string(jsonData))
+	case "plain":
+		fmt.Printf("[%s] %s [%s] %s\n", e.origin, e.timestamp.Format(time.RFC3339), strings.ToUpper(e.level), e.message)
+	default:
+		message := e.message
+		if len(message) > 100 {
+			message = message[:97] + "..."
+		}
+		level := fmt.Sprintf("%-7s", e.level)
+		if color {
+			level = colorLevel(e.level, level)
+		}
+		fmt.Printf("%s [%s] [%s] %s\n", e.timestamp.Local().Format("2006-01-02 15:04:05"), level, e.origin, message)
+	}
+}
+
+// ANSI colors for levels
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorYellow = "\033[33m"
+	colorCyan   = "\033[36m"
+	colorGray   = "\033[90m"
+	colorBold   = "\033[1m"
+)
+
+// colorLevel wraps text in the color of level; unknown levels stay plain.
+func colorLevel(level, text string) string {
+	var c string
+	switch strings.ToLower(level) {
+	case "fatal":
+		c = colorBold + colorRed
+	case "error":
+		c = colorRed
+	case "warning":
+		c = colorYellow
+	case "info":
+		c = colorCyan
+	case "debug":
+		c = colorGray
+	default:
+		return text
+	}
+	return c + text + colorReset
+}
@@ -0,0 +1,319 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	logsPath        = "/api/v1/logs"
+	maxQueryPerPage = 1000 // the API's per_page limit
+	queryTimeout    = time.Minute
+)
+
+var (
+	logsQueryServer string
+	logsQueryStart  string
+	logsQueryEnd    string
+	logsQueryFilter string
+	logsQueryFormat string
+	logsQueryLimit  int
+)
+
+var logsQueryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "Query stored logs from a server",
+	Long: `Query the logs stored on a BlazeLog server and print them as they are
+fetched, following the API's pages until the end or --limit rows.
+
+--start and --end take an RFC3339 time or a duration meaning that long ago.
+--end defaults to when the command started, so logs arriving meanwhile don't
+shift the pages. --filter takes a DSL expression and overrides the flat
+filters (--level, --type, ...).
+
+Formats: table and plain for reading, json (one object per line) and csv
+for scripts. The default follows -o.
+
+Examples:
+  # Nginx errors since the start of the year, as CSV
+  blazectl logs query --server https://logs.example.com \
+    --start 2024-01-01T00:00:00Z --filter 'level == "error" && type == "nginx"' --format csv
+
+  # Fail a CI job if the last 15 minutes had fatal logs
+  test -z "$(blazectl logs query --server https://logs.example.com --start 15m --level fatal --limit 1 --format json)"`,
+	Args: cobra.NoArgs,
+	RunE: runLogsQuery,
+}
+
+func init() {
+	logsCmd.AddCommand(logsQueryCmd)
+
+	logsQueryCmd.Flags().StringVar(&logsQueryServer, "server", "", "server base URL")
+	logsQueryCmd.Flags().StringVar(&logsToken, "token", os.Getenv("BLAZELOG_TOKEN"), "API access token (default: $BLAZELOG_TOKEN)")
+	logsQueryCmd.Flags().BoolVar(&logsInsecure, "insecure-skip-verify", false, "skip TLS certificate verification (testing only)")
+	logsQueryCmd.Flags().StringVar(&logsQueryStart, "start", "", "start time, RFC3339 or a duration ago such as 1h")
+	logsQueryCmd.Flags().StringVar(&logsQueryEnd, "end", "", "end time, RFC3339 or a duration ago (default: now)")
+	logsQueryCmd.Flags().StringVar(&logsQueryFilter, "filter", "", "DSL filter expression (overrides the flat filters)")
+	logsQueryCmd.Flags().StringVar(&logsQueryFormat, "format", "", "output format: table, plain, json or csv (default: -o)")
+	logsQueryCmd.Flags().IntVar(&logsQueryLimit, "limit", 0, "max rows to print (0 = all)")
+	addLogFilterFlags(logsQueryCmd)
+	logsQueryCmd.MarkFlagRequired("server")
+	logsQueryCmd.MarkFlagRequired("start")
+}
+
+// parseQueryTime parses a --start or --end value relative to now.
+func parseQueryTime(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("%q is not an RFC3339 time or a duration ago", s)
+	}
+	return now.Add(-d), nil
+}
+
+// logsPage is one page of GET /api/v1/logs.
+type logsPage struct {
+	Data struct {
+		Items      []json.RawMessage `json:"items"`
+		TotalPages int               `json:"total_pages"`
+		Truncated  bool              `json:"truncated"`
+	} `json:"data"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// logPager fetches the pages of a log query.
+type logPager struct {
+	client *http.Client
+	token  string
+	url    url.URL // with the query parameters, but no page
+	limit  int     // max rows; 0 = all
+}
+
+// run calls emit with each entry in order until the last page or limit
+// rows. It reports whether the server cut the results off at its max
+// result window.
+func (p *logPager) run(ctx context.Context, emit func(json.RawMessage) error) (truncated bool, err error) {
+	perPage := maxQueryPerPage
+	if p.limit > 0 && p.limit < perPage {
+		perPage = p.limit
+	}
+	rows := 0
+	for page := 1; ; page++ {
+		resp, err := p.fetch(ctx, page, perPage)
+		if err != nil {
+			return false, err
+		}
+		for _, item := range resp.Data.Items {
+			if err := emit(item); err != nil {
+				return false, err
+			}
+			rows++
+			if p.limit > 0 && rows >= p.limit {
+				return false, nil
+			}
+		}
+		if page >= resp.Data.TotalPages || len(resp.Data.Items) == 0 {
+			return resp.Data.Truncated, nil
+		}
+	}
+}
+
+// fetch requests one page.
+func (p *logPager) fetch(ctx context.Context, page, perPage int) (*logsPage, error) {
+	u := p.url
+	q := u.Query()
+	q.Set("page", strconv.Itoa(page))
+	q.Set("per_page", strconv.Itoa(perPage))
+	u.RawQuery = q.Encode()
+
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body logsPage
+	decodeErr := json.NewDecoder(resp.Body).Decode(&body)
+	if resp.StatusCode != http.StatusOK {
+		if decodeErr == nil && body.Error != nil {
+			return nil, fmt.Errorf("page %d: HTTP %d: %s", page, resp.StatusCode, body.Error.Message)
+		}
+		return nil, fmt.Errorf("page %d: HTTP %d", page, resp.StatusCode)
+	}
+	if decodeErr != nil {
+		return nil, fmt.Errorf("page %d: decode response: %w", page, decodeErr)
+	}
+	return &body, nil
+}
+
+// queryEntry holds the fields of an entry printed as a table or CSV.
+type queryEntry struct {
+	ID        string `json:"id"`
+	Timestamp string `json:"timestamp"`
+	Level     string `json:"level"`
+	Type      string `json:"type"`
+	Source    string `json:"source"`
+	AgentID   string `json:"agent_id"`
+	FilePath  string `json:"file_path"`
+	Message   string `json:"message"`
+}
+
+// queryCSVHeader is the header row of CSV output.
+var queryCSVHeader = []string{"id", "timestamp", "level", "type", "source", "agent_id", "file_path", "message"}
+
+// queryPrinter prints query results in one format.
+type queryPrinter struct {
+	format string
+	w      io.Writer
+	csv    *csv.Writer // csv format only
+}
+
+func newQueryPrinter(format string, w io.Writer) (*queryPrinter, error) {
+	p := &queryPrinter{format: format, w: w}
+	switch format {
+	case "table", "plain", "json":
+	case "csv":
+		p.csv = csv.NewWriter(w)
+		if err := p.csv.Write(queryCSVHeader); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("invalid format: %s (use table, plain, json or csv)", format)
+	}
+	return p, nil
+}
+
+// print prints one entry of the API's log objects.
+func (p *queryPrinter) print(raw json.RawMessage) error {
+	if p.format == "json" {
+		_, err := fmt.Fprintf(p.w, "%s\n", raw)
+		return err
+	}
+
+	var e queryEntry
+	if err := json.Unmarshal(raw, &e); err != nil {
+		return fmt.Errorf("decode entry: %w", err)
+	}
+	switch p.format {
+	case "csv":
+		if err := p.csv.Write([]string{e.ID, e.Timestamp, e.Level, e.Type, e.Source, e.AgentID, e.FilePath, e.Message}); err != nil {
+			return err
+		}
+		// Rows go out as they arrive
+		p.csv.Flush()
+		return p.csv.Error()
+	case "plain":
+		_, err := fmt.Fprintf(p.w, "%s [%s] %s\n", e.Timestamp, strings.ToUpper(e.Level), e.Message)
+		return err
+	default:
+		timestamp := e.Timestamp
+		if ts, err := time.Parse(time.RFC3339, e.Timestamp); err == nil {
+			timestamp = ts.Local().Format("2006-01-02 15:04:05")
+		}
+		message := e.Message
+		if len(message) > 100 {
+			message = message[:97] + "..."
+		}
+		_, err := fmt.Fprintf(p.w, "%s [%-7s] [%s] %s\n", timestamp, e.Level, e.Source, message)
+		return err
+	}
+}
+
+// flush writes out buffered output.
+func (p *queryPrinter) flush() error {
+	if p.csv == nil {
+		return nil
+	}
+	p.csv.Flush()
+	return p.csv.Error()
+}
+
+func runLogsQuery(cmd *cobra.Command, args []string) error {
+	if logsQueryLimit < 0 {
+		return errors.New("--limit must be >= 0")
+	}
+	base, err := apiURL(logsQueryServer, logsPath)
+	if err != nil {
+		return fmt.Errorf("server %q: %w", logsQueryServer, err)
+	}
+
+	now := time.Now()
+	start, err := parseQueryTime(logsQueryStart, now)
+	if err != nil {
+		return fmt.Errorf("--start: %w", err)
+	}
+	end := now
+	if logsQueryEnd != "" {
+		if end, err = parseQueryTime(logsQueryEnd, now); err != nil {
+			return fmt.Errorf("--end: %w", err)
+		}
+	}
+
+	query := logFilterQuery()
+	query.Set("start", start.UTC().Format(time.RFC3339))
+	query.Set("end", end.UTC().Format(time.RFC3339))
+	if logsQueryFilter != "" {
+		query.Set("filter", logsQueryFilter)
+	}
+	base.RawQuery = query.Encode()
+
+	format := logsQueryFormat
+	if format == "" {
+		format = GetOutput()
+	}
+	printer, err := newQueryPrinter(format, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	pager := &logPager{
+		client: &http.Client{Transport: apiTransport()},
+		token:  logsToken,
+		url:    *base,
+		limit:  logsQueryLimit,
+	}
+	truncated, err := pager.run(ctx, printer.print)
+	if flushErr := printer.flush(); err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		return err
+	}
+	if truncated {
+		fmt.Fprintln(os.Stderr, "warning: results were cut off at the server's max result window; narrow --start/--end or the filter")
+	}
+	return nil
+}
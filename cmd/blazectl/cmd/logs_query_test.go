@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseQueryTime(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "2024-01-01T00:00:00Z", want: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{in: "1h", want: now.Add(-time.Hour)},
+		{in: "90m", want: now.Add(-90 * time.Minute)},
+		{in: "-1h", wantErr: true},
+		{in: "yesterday", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseQueryTime(tt.in, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseQueryTime() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !got.Equal(tt.want) {
+				t.Errorf("parseQueryTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// pagedLogServer serves total entries over pages of the requested size.
+func pagedLogServer(t *testing.T, total int, requests *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/logs" || r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":{"code":"UNAUTHORIZED","message":"invalid token"}}`)
+			return
+		}
+		*requests = append(*requests, r.URL.RawQuery)
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		var items []string
+		for i := (page - 1) * perPage; i < min(page*perPage, total); i++ {
+			items = append(items, fmt.Sprintf(`{"id":"%d","timestamp":"2024-01-15T10:00:00Z","level":"error","message":"entry %d"}`, i, i))
+		}
+		fmt.Fprintf(w, `{"data":{"items":[%s],"total":%d,"total_pages":%d}}`, strings.Join(items, ","), total, (total+perPage-1)/perPage)
+	}))
+}
+
+func TestLogPager(t *testing.T) {
+	tests := []struct {
+		name      string
+		total     int
+		limit     int
+		wantRows  int
+		wantPages int
+	}{
+		{name: "all pages", total: 2500, wantRows: 2500, wantPages: 3},
+		{name: "limit within a page", total: 2500, limit: 10, wantRows: 10, wantPages: 1},
+		{name: "limit over several pages", total: 2500, limit: 1500, wantRows: 1500, wantPages: 2},
+		{name: "no results", total: 0, wantRows: 0, wantPages: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []string
+			srv := pagedLogServer(t, tt.total, &requests)
+			defer srv.Close()
+
+			base, err := apiURL(srv.URL, logsPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			base.RawQuery = "level=error&start=2024-01-15T00%3A00%3A00Z"
+			p := &logPager{client: srv.Client(), token: "secret", url: *base, limit: tt.limit}
+
+			var ids []string
+			if _, err := p.run(context.Background(), func(raw json.RawMessage) error {
+				var e queryEntry
+				if err := json.Unmarshal(raw, &e); err != nil {
+					return err
+				}
+				ids = append(ids, e.ID)
+				return nil
+			}); err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+
+			if len(ids) != tt.wantRows {
+				t.Errorf("rows = %d, want %d", len(ids), tt.wantRows)
+			}
+			for i, id := range ids {
+				if id != strconv.Itoa(i) {
+					t.Fatalf("row %d has id %s, want rows in order", i, id)
+				}
+			}
+			if len(requests) != tt.wantPages {
+				t.Errorf("requests = %d, want %d", len(requests), tt.wantPages)
+			}
+			if len(requests) > 0 && !strings.Contains(requests[0], "level=error") {
+				t.Errorf("request %q lost the filters", requests[0])
+			}
+		})
+	}
+}
+
+func TestLogPagerError(t *testing.T) {
+	var requests []string
+	srv := pagedLogServer(t, 10, &requests)
+	defer srv.Close()
+
+	base, err := apiURL(srv.URL, logsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &logPager{client: srv.Client(), token: "wrong", url: *base}
+	_, err = p.run(context.Background(), func(json.RawMessage) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "HTTP 401: invalid token") {
+		t.Errorf("run() error = %v, want the server's message", err)
+	}
+}
+
+func TestQueryPrinter(t *testing.T) {
+	entry := json.RawMessage(`{"id":"1","timestamp":"2024-01-15T10:00:00Z","level":"error","type":"nginx","source":"web","message":"upstream timed out, retrying"}`)
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"json", string(entry) + "\n"},
+		{"csv", "id,timestamp,level,type,source,agent_id,file_path,message\n1,2024-01-15T10:00:00Z,error,nginx,web,,,\"upstream timed out, retrying\"\n"},
+		{"plain", "2024-01-15T10:00:00Z [ERROR] upstream timed out, retrying\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			p, err := newQueryPrinter(tt.format, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := p.print(entry); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.flush(); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("output = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+
+	if _, err := newQueryPrinter("xml", &bytes.Buffer{}); err == nil {
+		t.Error("newQueryPrinter(xml) should fail")
+	}
+}
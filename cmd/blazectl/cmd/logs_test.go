@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseTailEndpoint(t *testing.T) {
+	tests := []struct {
+		arg      string
+		wantName string
+		wantURL  string
+		wantErr  bool
+	}{
+		{"https://logs-eu.example.com", "logs-eu.example.com", "https://logs-eu.example.com/api/v1/logs/stream", false},
+		{"eu=https://logs-eu.example.com/", "eu", "https://logs-eu.example.com/api/v1/logs/stream", false},
+		{"us=http://10.0.0.5:8080/blazelog", "us", "http://10.0.0.5:8080/blazelog/api/v1/logs/stream", false},
+		{"https://logs.example.com/?token=x", "logs.example.com", "https://logs.example.com/api/v1/logs/stream", false},
+		{"logs.example.com", "", "", true},
+		{"eu=ftp://logs.example.com", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			ep, err := parseTailEndpoint(tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTailEndpoint() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if ep.name != tt.wantName || ep.url.String() != tt.wantURL {
+				t.Errorf("endpoint = %s %s, want %s %s", ep.name, ep.url.String(), tt.wantName, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestReorderBuffer(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	arrived := base.Add(time.Hour)
+	buf := &reorderBuffer{window: 2 * time.Second}
+
+	// The us entry arrives later but happened first
+	buf.push(&streamEntry{origin: "eu", id: "eu-1", timestamp: base.Add(2 * time.Second), arrived: arrived})
+	buf.push(&streamEntry{origin: "us", id: "us-1", timestamp: base.Add(time.Second), arrived: arrived.Add(500 * time.Millisecond)})
+	buf.push(&streamEntry{origin: "eu", id: "eu-2", timestamp: base.Add(time.Second), arrived: arrived.Add(time.Second)})
+
+	if got := buf.due(arrived.Add(time.Second)); len(got) != 0 {
+		t.Fatalf("due before the window = %d entries, want 0", len(got))
+	}
+
+	var ids []string
+	for _, e := range buf.due(arrived.Add(2500 * time.Millisecond)) {
+		ids = append(ids, e.id)
+	}
+	// us-1 is the earliest and due. eu-2 is next in order but not due yet,
+	// so eu-1 waits behind it even though it has been held long enough
+	if fmt.Sprint(ids) != "[us-1]" {
+		t.Errorf("due = %v, want [us-1]", ids)
+	}
+
+	ids = nil
+	for _, e := range buf.drain() {
+		ids = append(ids, e.id)
+	}
+	if fmt.Sprint(ids) != "[eu-2 eu-1]" {
+		t.Errorf("drain = %v, want [eu-2 eu-1]", ids)
+	}
+}
+
+func TestStreamResume(t *testing.T) {
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	r := newStreamResume(start)
+
+	if !r.accept("a", start.Add(time.Second)) || !r.accept("b", start.Add(time.Second)) {
+		t.Fatal("new entries should be accepted")
+	}
+	if r.accept("a", start.Add(time.Second)) {
+		t.Error("an entry replayed after reconnecting should be skipped")
+	}
+	if !r.accept("c", start.Add(3*time.Second)) {
+		t.Fatal("later entry should be accepted")
+	}
+	if len(r.seen) != 1 {
+		t.Errorf("seen = %v, want only the last second's IDs", r.seen)
+	}
+	if !r.last.Equal(start.Add(3 * time.Second)) {
+		t.Errorf("last = %v, want %v", r.last, start.Add(3*time.Second))
+	}
+}
+
+func TestTailStreamerReconnects(t *testing.T) {
+	var mu sync.Mutex
+	var starts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		starts = append(starts, r.URL.Query().Get("start"))
+		n := len(starts)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		switch n {
+		case 1:
+			fmt.Fprint(w, "event: log\ndata: {\"id\":\"1\",\"timestamp\":\"2024-01-15T10:00:01Z\",\"level\":\"error\",\"message\":\"first\"}\n\n")
+			fmt.Fprint(w, "event: close\ndata: {\"reason\":\"timeout\"}\n\n")
+		default:
+			// The last second is replayed after reconnecting
+			fmt.Fprint(w, "event: log\ndata: {\"id\":\"1\",\"timestamp\":\"2024-01-15T10:00:01Z\",\"level\":\"error\",\"message\":\"first\"}\n\n")
+			fmt.Fprint(w, "event: heartbeat\ndata: {}\n\n")
+			fmt.Fprint(w, "event: log\ndata: {\"id\":\"2\",\"timestamp\":\"2024-01-15T10:00:02Z\",\"level\":\"info\",\"message\":\"second\"}\n\n")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}
+	}))
+	defer srv.Close()
+
+	ep, err := parseTailEndpoint("test=" + srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make(chan *streamEntry, 10)
+	s := &tailStreamer{
+		client:  srv.Client(),
+		token:   "secret",
+		backoff: 10 * time.Millisecond,
+		out:     out,
+		warn:    func(string, ...any) {},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.run(ctx, ep, time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC))
+
+	var got []string
+	for len(got) < 2 {
+		select {
+		case e := <-out:
+			if e.origin != "test" {
+				t.Errorf("origin = %q, want test", e.origin)
+			}
+			got = append(got, e.message)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("got %v before timing out", got)
+		}
+	}
+	if fmt.Sprint(got) != "[first second]" {
+		t.Errorf("messages = %v, want [first second]", got)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(starts) < 2 || starts[0] != "2024-01-15T10:00:00Z" || starts[1] != "2024-01-15T10:00:01Z" {
+		t.Errorf("stream starts = %v, want the initial start then the last entry's", starts)
+	}
+}
+
+func TestTailStreamerHonorsRetry(t *testing.T) {
+	var mu sync.Mutex
+	var conns []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		conns = append(conns, time.Now())
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "retry: 200\n\n")
+		fmt.Fprint(w, "event: close\ndata: {\"reason\":\"timeout\"}\n\n")
+	}))
+	defer srv.Close()
+
+	ep, err := parseTailEndpoint(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &tailStreamer{client: srv.Client(), backoff: time.Millisecond, out: make(chan *streamEntry), warn: func(string, ...any) {}}
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	s.run(ctx, ep, time.Now())
+
+	mu.Lock()
+	defer mu.Unlock()
+	// Without the retry field, a 1ms backoff would reconnect many times
+	if len(conns) != 2 {
+		t.Fatalf("connections = %d, want 2", len(conns))
+	}
+	if gap := conns[1].Sub(conns[0]); gap < 200*time.Millisecond {
+		t.Errorf("reconnected after %s, want at least the server's 200ms", gap)
+	}
+}
+
+func TestColorLevel(t *testing.T) {
+	if got := colorLevel("error", "error  "); got != "\033[31merror  \033[0m" {
+		t.Errorf("colorLevel(error) = %q", got)
+	}
+	if got := colorLevel("FATAL", "FATAL"); got != "\033[1m\033[31mFATAL\033[0m" {
+		t.Errorf("colorLevel(FATAL) = %q", got)
+	}
+	if got := colorLevel("unknown", "unknown"); got != "unknown" {
+		t.Errorf("colorLevel(unknown) = %q, want it plain", got)
+	}
+}
+
+func TestTailStreamerStopsWhenRejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	ep, err := parseTailEndpoint(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &tailStreamer{client: srv.Client(), backoff: time.Millisecond, out: make(chan *streamEntry), warn: func(string, ...any) {}}
+
+	done := make(chan struct{})
+	go func() {
+		s.run(context.Background(), ep, time.Now())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("run() should give up on a 401")
+	}
+}
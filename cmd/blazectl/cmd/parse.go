@@ -16,6 +16,9 @@ var (
 	parseLimit    int
 	parseShowRaw  bool
 	parseShowLine bool
+
+	parseDetect     bool
+	parseDetectJSON bool
 )
 
 var parseCmd = &cobra.Command{
@@ -29,6 +32,10 @@ Supported types:
   magento    - Magento system, exception, debug logs
   prestashop - PrestaShop application logs
   wordpress  - WordPress debug.log and PHP errors
+  syslog     - BSD syslog (RFC 3164)
+  postgres   - PostgreSQL stderr and csvlog logs
+  mysql      - MySQL and MariaDB error logs
+  json       - JSON lines (one object per line, "msg" key)
   auto       - Auto-detect log format
 
 Examples:
@@ -42,7 +49,13 @@ Examples:
   blazelog parse nginx /var/log/nginx/access.log --limit 10
 
   # Auto-detect log format
-  blazelog parse auto /var/log/myapp.log`,
+  blazelog parse auto /var/log/myapp.log
+
+  # Explain which parsers match the first 20 lines and which one auto picks
+  blazelog parse auto /var/log/myapp.log --detect --limit 20
+
+  # Same, as JSON for a bug report
+  blazelog parse auto /var/log/myapp.log --detect --json`,
 	Args: cobra.MinimumNArgs(2),
 	Run:  runParse,
 }
@@ -53,12 +66,19 @@ func init() {
 	parseCmd.Flags().IntVarP(&parseLimit, "limit", "n", 0, "limit number of entries to parse (0 = no limit)")
 	parseCmd.Flags().BoolVar(&parseShowRaw, "raw", false, "show raw log line")
 	parseCmd.Flags().BoolVar(&parseShowLine, "line-numbers", true, "show line numbers")
+	parseCmd.Flags().BoolVar(&parseDetect, "detect", false, "explain format auto-detection instead of parsing (samples --limit lines, default 10)")
+	parseCmd.Flags().BoolVar(&parseDetectJSON, "json", false, "print --detect output as JSON (same as -o json)")
 }
 
 func runParse(cmd *cobra.Command, args []string) {
 	logType := args[0]
 	filePath := args[1]
 
+	if parseDetect {
+		runDetect(filePath)
+		return
+	}
+
 	// Open the file
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -137,13 +157,12 @@ func runParse(cmd *cobra.Command, args []string) {
 			if multiParser.IsStartOfEntry(line) {
 				// Process previous entry if exists
 				if len(currentLines) > 0 {
-					entry, err := multiParser.ParseMultiLine(currentLines)
+					entry, err := parser.ParseMultiLineAt(multiParser, currentLines, startLineNum)
 					if err != nil {
 						if IsVerbose() {
 							PrintVerbose("Line %d: parse error: %v", startLineNum, err)
 						}
 					} else {
-						entry.LineNumber = startLineNum
 						entry.FilePath = filePath
 						entries = append(entries, entry)
 
@@ -164,13 +183,12 @@ func runParse(cmd *cobra.Command, args []string) {
 
 		// Process last entry
 		if len(currentLines) > 0 && (parseLimit == 0 || len(entries) < parseLimit) {
-			entry, err := multiParser.ParseMultiLine(currentLines)
+			entry, err := parser.ParseMultiLineAt(multiParser, currentLines, startLineNum)
 			if err != nil {
 				if IsVerbose() {
 					PrintVerbose("Line %d: parse error: %v", startLineNum, err)
 				}
 			} else {
-				entry.LineNumber = startLineNum
 				entry.FilePath = filePath
 				entries = append(entries, entry)
 			}
@@ -228,6 +246,14 @@ func getParser(logType string) (parser.Parser, bool) {
 		return parser.NewPrestaShopParser(nil), true
 	case "wordpress":
 		return parser.NewWordPressParser(nil), true
+	case "syslog":
+		return parser.NewSyslogParser(nil), true
+	case "json":
+		return parser.NewJSONParser(nil), true
+	case "postgres":
+		return parser.NewPostgresParser(nil), true
+	case "mysql":
+		return parser.NewMySQLParser(nil), true
 	default:
 		return nil, false
 	}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/good-yellow-bee/blazelog/internal/parser"
+)
+
+// detectSampleLines is how many lines --detect examines without --limit.
+const detectSampleLines = 10
+
+// detectLine is the detection result for one sampled line.
+type detectLine struct {
+	Line       int64              `json:"line"`
+	Text       string             `json:"text"`
+	Selected   string             `json:"selected,omitempty"` // first match, as auto-detection picks
+	Detections []parser.Detection `json:"detections"`
+}
+
+// detectParser summarizes one parser across the sample.
+type detectParser struct {
+	Parser     string  `json:"parser"`
+	Matched    int     `json:"matched"`
+	Confidence float64 `json:"confidence"` // share of sampled lines matched
+}
+
+// detectReport explains auto-detection for a file.
+type detectReport struct {
+	File       string         `json:"file"`
+	Sampled    int            `json:"sampled"`
+	Selected   string         `json:"selected,omitempty"` // what "parse auto" uses (picked from the first line)
+	Confidence float64        `json:"confidence"`         // selected parser's share of sampled lines
+	Parsers    []detectParser `json:"parsers"`
+	Lines      []detectLine   `json:"lines"`
+}
+
+// buildDetectReport runs every registered parser's CanParse on the first
+// limit non-empty lines of r.
+func buildDetectReport(registry *parser.Registry, file string, r io.Reader, limit int) (*detectReport, error) {
+	report := &detectReport{File: file}
+	matched := make(map[string]int)
+
+	scanner := bufio.NewScanner(r)
+	var lineNum int64
+	for scanner.Scan() && len(report.Lines) < limit {
+		lineNum++
+		text := scanner.Text()
+		if text == "" {
+			continue
+		}
+
+		dl := detectLine{Line: lineNum, Text: text, Detections: registry.Explain(text)}
+		for _, d := range dl.Detections {
+			if !d.Matched {
+				continue
+			}
+			matched[d.Parser]++
+			if dl.Selected == "" {
+				dl.Selected = d.Parser
+			}
+		}
+		report.Lines = append(report.Lines, dl)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	report.Sampled = len(report.Lines)
+	for _, p := range registry.All() {
+		dp := detectParser{Parser: p.Name(), Matched: matched[p.Name()]}
+		if report.Sampled > 0 {
+			dp.Confidence = float64(dp.Matched) / float64(report.Sampled)
+		}
+		report.Parsers = append(report.Parsers, dp)
+	}
+	if report.Sampled > 0 {
+		report.Selected = report.Lines[0].Selected
+		for _, dp := range report.Parsers {
+			if dp.Parser == report.Selected {
+				report.Confidence = dp.Confidence
+			}
+		}
+	}
+	return report, nil
+}
+
+// runDetect prints why auto-detection picks a parser for filePath.
+func runDetect(filePath string) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		PrintError(fmt.Sprintf("failed to open file: %v", err), true)
+		return
+	}
+	defer file.Close()
+
+	limit := parseLimit
+	if limit <= 0 {
+		limit = detectSampleLines
+	}
+	report, err := buildDetectReport(parser.DefaultRegistry, filePath, file, limit)
+	if err != nil {
+		PrintError(fmt.Sprintf("error reading file: %v", err), true)
+		return
+	}
+	if report.Sampled == 0 {
+		PrintError("file is empty or contains only blank lines", true)
+		return
+	}
+
+	if parseDetectJSON || GetOutput() == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			PrintError(fmt.Sprintf("failed to marshal JSON: %v", err), true)
+			return
+		}
+		fmt.Println(string(data))
+		return
+	}
+	printDetectReport(os.Stdout, report)
+}
+
+// printDetectReport writes the report as tables.
+func printDetectReport(out io.Writer, report *detectReport) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "LINE\tSELECTED\tMATCHED\tTEXT\n")
+	fmt.Fprintf(w, "----\t--------\t-------\t----\n")
+	for _, dl := range report.Lines {
+		var names []string
+		for _, d := range dl.Detections {
+			if d.Matched {
+				names = append(names, d.Parser)
+			}
+		}
+		selected, matchedBy := dl.Selected, strings.Join(names, ", ")
+		if selected == "" {
+			selected, matchedBy = "-", "-"
+		}
+		text := dl.Text
+		if len(text) > 60 {
+			text = text[:57] + "..."
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", dl.Line, selected, matchedBy, text)
+	}
+	w.Flush()
+
+	fmt.Fprintln(out)
+	w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "PARSER\tMATCHED\tCONFIDENCE\n")
+	fmt.Fprintf(w, "------\t-------\t----------\n")
+	for _, dp := range report.Parsers {
+		fmt.Fprintf(w, "%s\t%d/%d\t%.0f%%\n", dp.Parser, dp.Matched, report.Sampled, dp.Confidence*100)
+	}
+	w.Flush()
+
+	fmt.Fprintln(out)
+	if report.Selected == "" {
+		fmt.Fprintf(out, "Auto-detect picks: none (no parser matches line %d)\n", report.Lines[0].Line)
+		return
+	}
+	fmt.Fprintf(out, "Auto-detect picks: %s (first match for line %d, parsers tried in the order above), confidence %.0f%%\n",
+		report.Selected, report.Lines[0].Line, report.Confidence*100)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 
+	"github.com/good-yellow-bee/blazelog/internal/alerting"
 	"github.com/good-yellow-bee/blazelog/internal/models"
 	"github.com/good-yellow-bee/blazelog/internal/storage"
 )
@@ -500,19 +501,55 @@ func init() {
 
 // openProjectDB opens the SQLite database with default path.
 func openProjectDB() (*storage.SQLiteStorage, error) {
+	return openDB(projectDBPath)
+}
+
+// openDB opens the SQLite database at path with the keys from the
+// environment.
+func openDB(path string) (*storage.SQLiteStorage, error) {
 	dbKey := os.Getenv("BLAZELOG_DB_KEY")
 	if dbKey == "" {
 		return nil, fmt.Errorf("BLAZELOG_DB_KEY environment variable is required")
 	}
 	masterKey := []byte(os.Getenv("BLAZELOG_MASTER_KEY"))
 
-	store := storage.NewSQLiteStorage(projectDBPath, masterKey, []byte(dbKey))
+	store := storage.NewSQLiteStorage(path, masterKey, []byte(dbKey))
 	if err := store.Open(); err != nil {
-		return nil, fmt.Errorf("open database at %s: %w", projectDBPath, err)
+		return nil, fmt.Errorf("open database at %s: %w", path, err)
 	}
 	return store, nil
 }
 
+// openProjectNotify opens the database at path to resolve the default
+// notification channels of alert rules that set project_id but not notify.
+// An empty path resolves nothing. Callers must call close when done.
+func openProjectNotify(path string) (resolve alerting.ProjectNotifyResolver, close func(), err error) {
+	if path == "" {
+		return nil, func() {}, nil
+	}
+	store, err := openDB(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return projectNotifyResolver(store.Projects()), func() { store.Close() }, nil
+}
+
+// projectNotifyResolver returns the default notification channels of a
+// project from the repository. Unknown projects have none.
+func projectNotifyResolver(projects storage.ProjectRepository) alerting.ProjectNotifyResolver {
+	return func(projectID string) []string {
+		project, err := projects.GetByID(context.Background(), projectID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not fetch project %s: %v\n", projectID, err)
+			return nil
+		}
+		if project == nil {
+			return nil
+		}
+		return project.DefaultNotify
+	}
+}
+
 // resolveProject finds a project by name or ID (ID takes precedence).
 func resolveProject(ctx context.Context, repo storage.ProjectRepository, name, id string) (*models.Project, error) {
 	if id == "" && name == "" {
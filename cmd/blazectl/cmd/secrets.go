@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/good-yellow-bee/blazelog/internal/security"
+)
+
+var (
+	secretsJWTEnv         string
+	secretsJWTPreviousEnv string
+)
+
+// serverSecretEnvs are the env vars holding the server's secrets, in the
+// order they are printed.
+var serverSecretEnvs = []string{
+	"BLAZELOG_MASTER_KEY",
+	"BLAZELOG_DB_KEY",
+	"BLAZELOG_JWT_SECRET",
+	"BLAZELOG_CSRF_SECRET",
+}
+
+// secretsCmd represents the secrets command group
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Server secret management",
+	Long: `Commands for generating and rotating the secrets the server reads
+from its environment.
+
+Examples:
+  # Generate all server secrets
+  blazectl secrets generate > blazelog.env
+
+  # Rotate the JWT signing secret
+  blazectl secrets rotate-jwt`,
+}
+
+// secretsGenerateCmd generates fresh server secrets
+var secretsGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate server secrets",
+	Long: `Generate strong random values for BLAZELOG_MASTER_KEY, BLAZELOG_DB_KEY,
+BLAZELOG_JWT_SECRET and BLAZELOG_CSRF_SECRET, printed as shell exports
+(or as JSON with --output json).
+
+Only use this for a new installation: changing BLAZELOG_MASTER_KEY or
+BLAZELOG_DB_KEY makes existing encrypted data unreadable.
+
+Example:
+  blazectl secrets generate > /etc/blazelog/secrets.env`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		secrets := make(map[string]string, len(serverSecretEnvs))
+		for _, name := range serverSecretEnvs {
+			secret, err := security.GenerateSecret()
+			if err != nil {
+				return err
+			}
+			secrets[name] = secret
+		}
+		return printSecrets(serverSecretEnvs, secrets)
+	},
+}
+
+// secretsRotateJWTCmd rotates the JWT signing secret
+var secretsRotateJWTCmd = &cobra.Command{
+	Use:   "rotate-jwt",
+	Short: "Rotate the JWT signing secret",
+	Long: `Generate a new JWT signing secret and keep the current one as the
+previous secret.
+
+The current secret is read from the environment. Deploy both printed values
+and restart the server: it signs new tokens with the new secret and still
+accepts tokens signed with the previous one, so active sessions keep working.
+Once the access token TTL (auth.access_token_ttl) has passed, the previous
+secret can be removed.
+
+Example:
+  blazectl secrets rotate-jwt`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		current := os.Getenv(secretsJWTEnv)
+		if current == "" {
+			return fmt.Errorf("current JWT secret not found: %s is not set", secretsJWTEnv)
+		}
+		secret, err := security.GenerateSecret()
+		if err != nil {
+			return err
+		}
+
+		names := []string{secretsJWTEnv, secretsJWTPreviousEnv}
+		if err := printSecrets(names, map[string]string{
+			secretsJWTEnv:         secret,
+			secretsJWTPreviousEnv: current,
+		}); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Unset %s once the access token TTL has passed after restarting the server.\n", secretsJWTPreviousEnv)
+		return nil
+	},
+}
+
+// printSecrets prints secrets as shell exports, or as JSON with --output json.
+func printSecrets(names []string, secrets map[string]string) error {
+	if GetOutput() == "json" {
+		data, err := json.MarshalIndent(secrets, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	for _, name := range names {
+		fmt.Printf("export %s=%s\n", name, shellQuote(secrets[name]))
+	}
+	return nil
+}
+
+// shellQuote single-quotes s for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func init() {
+	rootCmd.AddCommand(secretsCmd)
+	secretsCmd.AddCommand(secretsGenerateCmd)
+	secretsCmd.AddCommand(secretsRotateJWTCmd)
+
+	secretsRotateJWTCmd.Flags().StringVar(&secretsJWTEnv, "jwt-env", "BLAZELOG_JWT_SECRET", "env var holding the current JWT secret")
+	secretsRotateJWTCmd.Flags().StringVar(&secretsJWTPreviousEnv, "previous-env", "BLAZELOG_JWT_SECRET_PREVIOUS", "env var the server reads previous JWT secrets from")
+}
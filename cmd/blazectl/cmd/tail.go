@@ -24,7 +24,14 @@ var (
 	tailShowFile   bool
 
 	// Alert flags
-	tailAlertRules string
+	tailAlertRules      string
+	tailMaxWindowEvents int
+	tailAlertWorkers    int
+	tailAlertQueueSize  int
+	tailMuteAfter       int
+	tailMuteWindow      time.Duration
+	tailDBPath          string
+	tailControlAddr     string
 
 	// Email notification flags
 	tailNotifyEmail []string
@@ -89,11 +96,18 @@ func init() {
 	rootCmd.AddCommand(tailCmd)
 
 	tailCmd.Flags().BoolVarP(&tailFollow, "follow", "f", true, "follow the file(s) and output new lines as they're written")
-	tailCmd.Flags().StringVarP(&tailParserType, "parser", "p", "", "parser type to use (nginx, apache, magento, prestashop, wordpress, auto)")
+	tailCmd.Flags().StringVarP(&tailParserType, "parser", "p", "", "parser type to use (nginx, apache, magento, prestashop, wordpress, syslog, postgres, mysql, json, auto)")
 	tailCmd.Flags().BoolVar(&tailShowFile, "show-file", true, "show file path for each line (useful with multiple files)")
 
 	// Alert flags
 	tailCmd.Flags().StringVar(&tailAlertRules, "alert-rules", "", "path to alert rules YAML file")
+	tailCmd.Flags().IntVar(&tailMaxWindowEvents, "max-window-events", 10000, "events kept per threshold window before counting approximately")
+	tailCmd.Flags().IntVar(&tailAlertWorkers, "alert-workers", 1, "goroutines evaluating alert rules off the tail path (0 = evaluate inline)")
+	tailCmd.Flags().IntVar(&tailAlertQueueSize, "alert-queue-size", 1024, "entries waiting for alert evaluation before new ones are skipped")
+	tailCmd.Flags().IntVar(&tailMuteAfter, "mute-after", alerting.DefaultMuteAfter, "auto-mute a rule after this many notifications per --mute-window (rules' mute_after wins, -1 = never)")
+	tailCmd.Flags().DurationVar(&tailMuteWindow, "mute-window", alerting.DefaultMuteWindow, "window for --mute-after")
+	tailCmd.Flags().StringVar(&tailDBPath, "db", "", "SQLite database with the projects of rules that set project_id, for their default channels")
+	tailCmd.Flags().StringVar(&tailControlAddr, "control-addr", "", "loopback address serving the rules' mute state to blazectl alerts list/unmute (empty = disabled)")
 
 	// Email notification flags
 	tailCmd.Flags().StringSliceVar(&tailNotifyEmail, "notify-email", nil, "email addresses for notifications (can be specified multiple times)")
@@ -157,8 +171,31 @@ func runTail(cmd *cobra.Command, args []string) {
 			PrintError(fmt.Sprintf("failed to load alert rules: %v", err), true)
 			return
 		}
-		engine = alerting.NewEngine(rules, nil)
+		opts := alerting.DefaultEngineOptions()
+		opts.MaxWindowEvents = tailMaxWindowEvents
+		opts.EvalWorkers = tailAlertWorkers
+		opts.EvalQueueSize = tailAlertQueueSize
+		opts.MuteAfter = tailMuteAfter
+		opts.MuteWindow = tailMuteWindow
+		projectNotify, closeDB, err := openProjectNotify(tailDBPath)
+		if err != nil {
+			PrintError(fmt.Sprintf("failed to open project database: %v", err), true)
+			return
+		}
+		defer closeDB()
+		opts.ProjectNotify = projectNotify
+		engine = alerting.NewEngine(rules, opts)
 		PrintVerbose("Loaded %d alert rule(s)", len(rules))
+
+		if tailControlAddr != "" {
+			control, err := startTailControl(tailControlAddr, engine)
+			if err != nil {
+				PrintError(fmt.Sprintf("failed to start control endpoint: %v", err), true)
+				return
+			}
+			defer control.Close()
+			PrintVerbose("Alert control endpoint listening on %s", tailControlAddr)
+		}
 	}
 
 	// Set up notification dispatcher
@@ -287,9 +324,23 @@ func runTail(cmd *cobra.Command, args []string) {
 		// Process for output and alerting
 		entry := processLine(line, p, multiFile && tailShowFile)
 
-		// Evaluate against alert rules if engine is configured
+		// Queue for alert evaluation if engine is configured
 		if engine != nil && entry != nil {
-			engine.Evaluate(entry)
+			engine.Submit(entry)
+		}
+		// Feed parse results to parse_errors rules
+		if engine != nil && (p != nil || tailParserType == "auto") {
+			engine.RecordParse(line.FilePath, entry != nil)
+		}
+	}
+
+	if engine != nil {
+		engine.Close() // evaluates entries still queued
+		if skipped := engine.Stats().EntriesSkipped; skipped > 0 {
+			fmt.Fprintf(os.Stderr, "Alert evaluation skipped %d entries under load.\n", skipped)
+		}
+		if muted := engine.Stats().AlertsMuted; muted > 0 {
+			fmt.Fprintf(os.Stderr, "Withheld %d notifications from auto-muted rules.\n", muted)
 		}
 	}
 }
@@ -352,14 +403,23 @@ func consumeAlerts(ctx context.Context, engine *alerting.Engine, dispatcher *not
 			// Log the alert
 			PrintVerbose("Alert triggered: %s (severity: %s)", alert.RuleName, alert.Severity)
 
-			// Dispatch to all registered notifiers
-			if err := dispatcher.DispatchAll(ctx, alert); err != nil {
+			if err := dispatchAlert(ctx, dispatcher, alert); err != nil {
 				PrintVerbose("Notification error: %v", err)
 			}
 		}
 	}
 }
 
+// dispatchAlert sends an alert to the channels of its rule, its own notify
+// list or its project's defaults. Alerts of rules with neither go to every
+// notifier set up by flags; an empty notify list sends nothing.
+func dispatchAlert(ctx context.Context, dispatcher *notifier.Dispatcher, alert *alerting.Alert) error {
+	if alert.Notify != nil {
+		return dispatcher.Dispatch(ctx, alert)
+	}
+	return dispatcher.DispatchAll(ctx, alert)
+}
+
 // getSMTPPassword returns the SMTP password from environment variable.
 func getSMTPPassword() string {
 	return os.Getenv("BLAZELOG_SMTP_PASS")
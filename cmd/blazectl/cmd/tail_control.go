@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/alerting"
+)
+
+// tailControlPath is where a running tail serves its alert rules.
+const tailControlPath = "/api/v1/alerts"
+
+// controlRule is a rule as listed by a tail's control endpoint.
+type controlRule struct {
+	Name       string     `json:"name"`
+	Type       string     `json:"type"`
+	Severity   string     `json:"severity"`
+	Enabled    bool       `json:"enabled"`
+	Muted      bool       `json:"muted"`
+	MutedUntil *time.Time `json:"muted_until,omitempty"`
+}
+
+// unmuteResponse reports whether an unmute lifted a mute.
+type unmuteResponse struct {
+	Rule    string `json:"rule"`
+	Unmuted bool   `json:"unmuted"`
+}
+
+// newTailControlHandler serves the engine's rules with their mute state and
+// lets operators lift auto-mutes.
+func newTailControlHandler(engine *alerting.Engine) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+tailControlPath, func(w http.ResponseWriter, r *http.Request) {
+		rules := []controlRule{}
+		for _, rule := range engine.Rules() {
+			cr := controlRule{
+				Name:     rule.Name,
+				Type:     string(rule.Type),
+				Severity: string(rule.Severity),
+				Enabled:  rule.IsEnabled(),
+			}
+			if until, muted := engine.MutedUntil(rule.Name); muted {
+				cr.Muted = true
+				cr.MutedUntil = &until
+			}
+			rules = append(rules, cr)
+		}
+		writeControlJSON(w, http.StatusOK, map[string]any{"rules": rules})
+	})
+	mux.HandleFunc("POST "+tailControlPath+"/{name}/unmute", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if engine.GetRule(name) == nil {
+			writeControlJSON(w, http.StatusNotFound, map[string]string{"error": fmt.Sprintf("rule %q not found", name)})
+			return
+		}
+		writeControlJSON(w, http.StatusOK, unmuteResponse{Rule: name, Unmuted: engine.Unmute(name)})
+	})
+	return mux
+}
+
+func writeControlJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// listenControl listens on a loopback address for the control endpoint,
+// which has no authentication.
+func listenControl(addr string) (net.Listener, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("--control-addr %q: %w", addr, err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, errors.New("--control-addr must be a loopback address (e.g. 127.0.0.1:9477)")
+	}
+	return net.Listen("tcp", addr)
+}
+
+// startTailControl serves the control endpoint on addr until the returned
+// server is closed.
+func startTailControl(addr string, engine *alerting.Engine) (*http.Server, error) {
+	lis, err := listenControl(addr)
+	if err != nil {
+		return nil, err
+	}
+	srv := &http.Server{Handler: newTailControlHandler(engine), ReadHeaderTimeout: 5 * time.Second}
+	go srv.Serve(lis)
+	return srv, nil
+}
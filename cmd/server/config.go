@@ -4,21 +4,58 @@ package main
 import (
 	"fmt"
 	"os"
+	"path"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/good-yellow-bee/blazelog/internal/api/middleware"
+	"github.com/good-yellow-bee/blazelog/internal/logging"
+	"github.com/good-yellow-bee/blazelog/internal/security"
+	"github.com/good-yellow-bee/blazelog/internal/server"
+	"github.com/good-yellow-bee/blazelog/internal/storage"
 )
 
 // Config represents the server configuration.
 type Config struct {
 	Server         ServerConfig     `yaml:"server"`
 	API            APIConfig        `yaml:"api"`             // API performance/safety limits
+	Ingest         IngestConfig     `yaml:"ingest"`          // Ingest-side record processing
 	Metrics        MetricsConfig    `yaml:"metrics"`         // Metrics configuration
 	Database       DatabaseConfig   `yaml:"database"`        // Database configuration
 	ClickHouse     ClickHouseConfig `yaml:"clickhouse"`      // ClickHouse log storage configuration
+	Postgres       PostgresConfig   `yaml:"postgres"`        // PostgreSQL log storage, instead of ClickHouse
 	SSHConnections []SSHConnection  `yaml:"ssh_connections"` // SSH connections for remote log collection
 	Auth           AuthConfig       `yaml:"auth"`            // Authentication configuration
 	Verbose        bool             `yaml:"-"`               // set via CLI flag
+
+	Notifications  NotificationsConfig  `yaml:"notifications"`   // Channels for server-sent alert notifications
+	SelfMonitoring SelfMonitoringConfig `yaml:"self_monitoring"` // Alerts on BlazeLog's own health
+	Logging        LoggingConfig        `yaml:"logging"`         // Server log output
+}
+
+// SelfMonitoringConfig contains alerts on BlazeLog's own degradation, sent
+// through the notification channels.
+type SelfMonitoringConfig struct {
+	QueryLatency QueryLatencyAlertConfig `yaml:"query_latency"` // Sustained slow storage queries
+}
+
+// QueryLatencyAlertConfig configures the alert on sustained high p95
+// storage query latency.
+type QueryLatencyAlertConfig struct {
+	Enabled   bool     `yaml:"enabled"`   // default: false
+	Threshold string   `yaml:"threshold"` // p95 latency considered slow (default: 2s)
+	Window    string   `yaml:"window"`    // how long p95 must stay above threshold (default: 5m)
+	Interval  string   `yaml:"interval"`  // how often p95 is evaluated (default: 30s)
+	Notify    []string `yaml:"notify"`    // slack, teams and/or email (default: every configured channel)
+	Severity  string   `yaml:"severity"`  // low, medium, high or critical (default: high)
+}
+
+// LoggingConfig contains server log output settings.
+type LoggingConfig struct {
+	Level  string `yaml:"level"`  // debug, info, warn or error (default: info; --verbose forces debug)
+	Format string `yaml:"format"` // text or json (default: text)
 }
 
 // MetricsConfig contains Prometheus metrics settings.
@@ -58,6 +95,40 @@ type AuthConfig struct {
 	RateLimitPerUser int      `yaml:"rate_limit_per_user"` // API rate limit per user (default: 100/min)
 	LockoutThreshold int      `yaml:"lockout_threshold"`   // Failed attempts before lockout (default: 5)
 	LockoutDuration  string   `yaml:"lockout_duration"`    // Lockout duration (default: 30m)
+
+	// EndpointRateLimits maps route patterns ("[METHOD ]/path") to per-minute
+	// limits layered over rate_limit_per_ip/rate_limit_per_user.
+	EndpointRateLimits map[string]int `yaml:"endpoint_rate_limits"`
+
+	// JWTPreviousSecretEnv names the env var holding retired JWT secrets,
+	// comma-separated, that are still accepted while tokens signed with them
+	// expire (default: BLAZELOG_JWT_SECRET_PREVIOUS).
+	JWTPreviousSecretEnv string `yaml:"jwt_previous_secret_env"`
+}
+
+// NotificationsConfig contains the channels the server sends alert
+// notifications through. Channels left unset are disabled.
+type NotificationsConfig struct {
+	RateLimit int                  `yaml:"rate_limit"` // Max notifications per minute (default: 10)
+	Slack     WebhookChannelConfig `yaml:"slack"`      // Slack incoming webhook
+	Teams     WebhookChannelConfig `yaml:"teams"`      // Microsoft Teams webhook
+	Email     EmailChannelConfig   `yaml:"email"`      // SMTP email
+}
+
+// WebhookChannelConfig contains settings for a webhook-based channel.
+type WebhookChannelConfig struct {
+	WebhookURL    string `yaml:"webhook_url"`     // Webhook URL (use webhook_url_env for security)
+	WebhookURLEnv string `yaml:"webhook_url_env"` // Environment variable name for webhook URL
+}
+
+// EmailChannelConfig contains SMTP settings for email notifications.
+type EmailChannelConfig struct {
+	SMTPHost    string   `yaml:"smtp_host"`    // SMTP server host (empty = disabled)
+	SMTPPort    int      `yaml:"smtp_port"`    // SMTP server port (default: 587)
+	Username    string   `yaml:"username"`     // SMTP username (optional)
+	PasswordEnv string   `yaml:"password_env"` // Environment variable name for SMTP password
+	From        string   `yaml:"from"`         // From address
+	Recipients  []string `yaml:"recipients"`   // Email recipients
 }
 
 // ClickHouseConfig contains ClickHouse settings.
@@ -72,37 +143,145 @@ type ClickHouseConfig struct {
 	BatchSize        int            `yaml:"batch_size"`         // Batch size for inserts (default: 1000)
 	FlushInterval    string         `yaml:"flush_interval"`     // Flush interval (default: 5s)
 	MaxBufferSize    int            `yaml:"max_buffer_size"`    // Max buffer size before dropping (default: 100000)
+	OnFull           string         `yaml:"on_full"`            // When the buffer is full: block, drop_oldest or drop_new (default: drop_oldest)
 	RetentionDays    int            `yaml:"retention_days"`     // Log retention in days (default: 30)
 	RetentionByLevel map[string]int `yaml:"retention_by_level"` // Per-level retention days (e.g., error: 90, debug: 7)
+
+	// Per-type, project or label retention; the first matching rule wins
+	// over retention_days.
+	RetentionRules []ClickHouseRetentionRuleConfig `yaml:"retention_rules"`
+
+	PoolStatsInterval     string `yaml:"pool_stats_interval"`      // Connection pool metrics sampling interval (default: 15s, 0 = off)
+	PoolWaitWarnThreshold string `yaml:"pool_wait_warn_threshold"` // Warn when pool waits per interval reach this (default: 1s, 0 = off)
+
+	// Retries for indexes and materialized views missing after migration.
+	SchemaRetries    int    `yaml:"schema_retries"`     // Extra attempts to create missing schema objects (default: 3, -1 = none)
+	SchemaRetryDelay string `yaml:"schema_retry_delay"` // Wait between schema retries (default: 2s)
+
+	// Logs table engine, applied only when the table is created.
+	Engine string `yaml:"engine"` // MergeTree or ReplacingMergeTree (collapses equal record IDs) (default: MergeTree)
+
+	// Extra data skipping indexes on the logs table, added at startup.
+	Indexes []ClickHouseIndexConfig `yaml:"indexes"`
+
+	// Sharded cluster: ON CLUSTER DDL, Replicated tables and a Distributed logs table.
+	Cluster string `yaml:"cluster"` // Cluster name from remote_servers (default: "" = single node)
+
+	// Server-side insert buffering, for many small batches.
+	AsyncInsert            bool   `yaml:"async_insert"`              // Use ClickHouse async inserts (default: false)
+	AsyncInsertBusyTimeout string `yaml:"async_insert_busy_timeout"` // Max time ClickHouse buffers async inserts (default: server setting)
+
+	// Retention worker: deletes expired logs on a schedule, on top of the TTL.
+	RetentionInterval      string `yaml:"retention_interval"`       // How often to delete logs older than retention_days (default: 24h, 0 = TTL only)
+	RetentionVerifyTimeout string `yaml:"retention_verify_timeout"` // Wait this long for each delete mutation to finish (default: "" = don't wait)
+}
+
+// PostgresConfig contains PostgreSQL (or TimescaleDB) log storage settings,
+// for deployments without ClickHouse.
+type PostgresConfig struct {
+	Enabled       bool   `yaml:"enabled"`        // Store logs in PostgreSQL (excludes clickhouse.enabled)
+	DSN           string `yaml:"dsn"`            // Connection string (use dsn_env for security)
+	DSNEnv        string `yaml:"dsn_env"`        // Environment variable name for the connection string
+	MaxOpenConns  int    `yaml:"max_open_conns"` // Max open connections (default: 10)
+	RetentionDays int    `yaml:"retention_days"` // Drop daily partitions older than this (default: 30, 0 = keep)
+	TimescaleDB   bool   `yaml:"timescaledb"`    // Use a TimescaleDB hypertable instead of native partitions
+
+	// Insert buffering, as for ClickHouse.
+	BatchSize     int    `yaml:"batch_size"`      // Batch size for inserts (default: 1000)
+	FlushInterval string `yaml:"flush_interval"`  // Flush interval (default: 5s)
+	MaxBufferSize int    `yaml:"max_buffer_size"` // Max buffer size before on_full applies (default: 100000)
+	OnFull        string `yaml:"on_full"`         // block, drop_oldest or drop_new (default: drop_oldest)
+}
+
+// ClickHouseIndexConfig is an extra data skipping index on the logs table.
+type ClickHouseIndexConfig struct {
+	Column      string `yaml:"column"`      // Logs column, labels.<key> or fields.<key>
+	Type        string `yaml:"type"`        // bloom_filter, bloom_filter(0.01), minmax or set(N)
+	Granularity int    `yaml:"granularity"` // Index granularity (default: 4)
+}
+
+// IndexHints converts the configured indexes to storage index hints.
+func (c ClickHouseConfig) IndexHints() []storage.IndexHint {
+	hints := make([]storage.IndexHint, len(c.Indexes))
+	for i, idx := range c.Indexes {
+		hints[i] = storage.IndexHint{Column: idx.Column, Type: idx.Type, Granularity: idx.Granularity}
+	}
+	return hints
+}
+
+// ClickHouseRetentionRuleConfig keeps the logs it matches for its own
+// number of days.
+type ClickHouseRetentionRuleConfig struct {
+	Match ClickHouseRetentionMatchConfig `yaml:"match"` // Conditions a log must all meet
+	Days  int                            `yaml:"days"`  // Days to keep matching logs
+}
+
+// ClickHouseRetentionMatchConfig selects the logs of a retention rule.
+type ClickHouseRetentionMatchConfig struct {
+	Type      string            `yaml:"type"`       // Log type (e.g. nginx)
+	ProjectID string            `yaml:"project_id"` // Project ID
+	Label     map[string]string `yaml:"label"`      // Label values (e.g. team: security)
+}
+
+// StorageRetentionRules converts the configured retention rules to storage rules.
+func (c ClickHouseConfig) StorageRetentionRules() []storage.RetentionRule {
+	rules := make([]storage.RetentionRule, len(c.RetentionRules))
+	for i, r := range c.RetentionRules {
+		rules[i] = storage.RetentionRule{Type: r.Match.Type, ProjectID: r.Match.ProjectID, Labels: r.Match.Label, Days: r.Days}
+	}
+	return rules
 }
 
 // DatabaseConfig contains database settings.
 type DatabaseConfig struct {
 	Path string `yaml:"path"` // SQLite database file path (default: ./data/blazelog.db)
+
+	// Alert history retention; both limits are off by default.
+	AlertHistoryRetentionDays int    `yaml:"alert_history_retention_days"` // Delete alert history older than this many days (default: 0 = keep)
+	AlertHistoryMaxRows       int    `yaml:"alert_history_max_rows"`       // Keep at most this many newest entries (default: 0 = no cap)
+	RetentionInterval         string `yaml:"retention_interval"`           // How often retention runs (default: 1h)
 }
 
 // ServerConfig contains server settings.
 type ServerConfig struct {
-	GRPCAddress   string        `yaml:"grpc_address"`   // gRPC listen address (default: :9443)
-	HTTPAddress   string        `yaml:"http_address"`   // HTTP listen address (default: :8080)
-	AllowInsecure bool          `yaml:"allow_insecure"` // Explicitly allow non-TLS operation (development only)
-	TLS           TLSConfig     `yaml:"tls"`            // TLS configuration for mTLS
-	HTTPTLS       HTTPTLSConfig `yaml:"http_tls"`       // TLS configuration for HTTP API
+	GRPCAddress        string        `yaml:"grpc_address"`          // gRPC listen address (default: :9443)
+	GRPCMaxMessageSize int           `yaml:"grpc_max_message_size"` // Max gRPC message size in bytes (default: 4MB)
+	HTTPAddress        string        `yaml:"http_address"`          // HTTP listen address (default: :8080)
+	AllowInsecure      bool          `yaml:"allow_insecure"`        // Explicitly allow non-TLS operation (development only)
+	TLS                TLSConfig     `yaml:"tls"`                   // TLS configuration for mTLS
+	HTTPTLS            HTTPTLSConfig `yaml:"http_tls"`              // TLS configuration for HTTP API
+	ShutdownStatusFile string        `yaml:"shutdown_status_file"`  // JSON shutdown summary written on exit (default: none)
+
+	GRPCTransferReportInterval time.Duration `yaml:"grpc_transfer_report_interval"` // log bytes received and compression ratio this often (default: 0 = off)
 }
 
 // TLSConfig contains TLS settings for the server.
 type TLSConfig struct {
-	Enabled      bool   `yaml:"enabled"`        // Enable mTLS
-	CertFile     string `yaml:"cert_file"`      // Server certificate file
-	KeyFile      string `yaml:"key_file"`       // Server private key file
-	ClientCAFile string `yaml:"client_ca_file"` // CA certificate for verifying client certs
+	Enabled      bool     `yaml:"enabled"`        // Enable mTLS
+	CertFile     string   `yaml:"cert_file"`      // Server certificate file
+	KeyFile      string   `yaml:"key_file"`       // Server private key file
+	ClientCAFile string   `yaml:"client_ca_file"` // CA certificate for verifying client certs
+	MinVersion   string   `yaml:"min_version"`    // Lowest accepted TLS version: "1.2" or "1.3" (default: 1.3)
+	CipherSuites []string `yaml:"cipher_suites"`  // Allowed TLS 1.2 cipher suites (default: Go's secure set)
+}
+
+// Policy returns the version and cipher suite policy for the gRPC listener.
+func (c TLSConfig) Policy() security.TLSPolicy {
+	return security.TLSPolicy{MinVersion: c.MinVersion, CipherSuites: c.CipherSuites}
 }
 
 // HTTPTLSConfig contains TLS settings for the HTTP API.
 type HTTPTLSConfig struct {
-	Enabled  bool   `yaml:"enabled"`   // Enable HTTPS
-	CertFile string `yaml:"cert_file"` // Server certificate file
-	KeyFile  string `yaml:"key_file"`  // Server private key file
+	Enabled      bool     `yaml:"enabled"`       // Enable HTTPS
+	CertFile     string   `yaml:"cert_file"`     // Server certificate file
+	KeyFile      string   `yaml:"key_file"`      // Server private key file
+	MinVersion   string   `yaml:"min_version"`   // Lowest accepted TLS version: "1.2" or "1.3" (default: 1.3)
+	CipherSuites []string `yaml:"cipher_suites"` // Allowed TLS 1.2 cipher suites (default: Go's secure set)
+}
+
+// Policy returns the version and cipher suite policy for the HTTP listener.
+func (c HTTPTLSConfig) Policy() security.TLSPolicy {
+	return security.TLSPolicy{MinVersion: c.MinVersion, CipherSuites: c.CipherSuites}
 }
 
 // APIConfig contains API query and streaming safety limits.
@@ -111,6 +290,94 @@ type APIConfig struct {
 	QueryTimeout       string `yaml:"query_timeout"`        // Per-request storage timeout (default: 10s)
 	StreamMaxDuration  string `yaml:"stream_max_duration"`  // SSE stream max duration (default: 30m)
 	StreamPollInterval string `yaml:"stream_poll_interval"` // SSE polling interval (default: 1s)
+	ShareLinkTTL       string `yaml:"share_link_ttl"`       // Shared filter code lifetime (default: 168h)
+	ShareRateLimit     int    `yaml:"share_rate_limit"`     // Shared filter creations per user per minute (default: 10, -1 = unlimited)
+	MaxResultRows      int    `yaml:"max_result_rows"`      // Max rows a query window or export can return (default: 100000)
+	RawTailEnabled     bool   `yaml:"raw_tail_enabled"`     // Admin-only raw source tail endpoint (default: false)
+	RawTailRateLimit   int    `yaml:"raw_tail_rate_limit"`  // Raw tail requests per admin per minute (default: 2)
+	RawTailAuditLog    string `yaml:"raw_tail_audit_log"`   // JSON lines audit log of raw tail sessions (default: none)
+
+	// IndexedLabels are label keys advertised by /api/v1/logs/schema as
+	// first-class filters (default: none).
+	IndexedLabels []string `yaml:"indexed_labels"`
+
+	// StreamReorderWindow is how far behind the newest streamed entry the
+	// SSE stream re-queries for late-arriving logs (default: 0s, disabled).
+	StreamReorderWindow string `yaml:"stream_reorder_window"`
+}
+
+// IngestConfig contains ingest-side record processing settings.
+type IngestConfig struct {
+	Dedup     DedupConfig `yaml:"dedup"`      // Content-hash deduplication (opt-in)
+	MaxFields int         `yaml:"max_fields"` // Max fields kept per record (default: 0 = unlimited)
+	MaxLabels int         `yaml:"max_labels"` // Max labels kept per record (default: 0 = unlimited)
+
+	FieldRenames map[string]map[string]string `yaml:"field_renames"` // Log type ("*" = all) -> from -> to field name
+	IDFields     map[string]string            `yaml:"id_fields"`     // Log type ("*" = all) -> field holding a unique event ID
+
+	ProjectAssignment ProjectAssignmentConfig `yaml:"project_assignment"` // Project for agents that report none
+
+	Validation IngestValidationConfig `yaml:"validation"` // Record checks before storage (opt-in)
+
+	Quota QuotaConfig `yaml:"agent_quota"` // Per-agent lines/sec cap (opt-in)
+
+	GeoIP GeoIPConfig `yaml:"geoip"` // Client IP geolocation (opt-in)
+}
+
+// GeoIPConfig adds geo_country, geo_city and geo_asn fields to records with
+// a public client IP, from MaxMind mmdb databases reloaded when they change.
+type GeoIPConfig struct {
+	Enabled     bool   `yaml:"enabled"`      // Enrich records (default: false)
+	Database    string `yaml:"database"`     // GeoLite2/GeoIP2 City or Country mmdb path
+	ASNDatabase string `yaml:"asn_database"` // Optional GeoLite2/GeoIP2 ASN mmdb path
+	IPField     string `yaml:"ip_field"`     // Field holding the client IP (default: client_ip)
+}
+
+// IngestValidationConfig checks records for an empty message, a missing,
+// future or too old timestamp and, optionally, an unaccepted level before
+// they are stored.
+type IngestValidationConfig struct {
+	Enabled       bool     `yaml:"enabled"`         // Validate records (default: false)
+	Policy        string   `yaml:"policy"`          // drop, coerce or reject (default: drop)
+	MaxFutureSkew string   `yaml:"max_future_skew"` // Max timestamp lead over receipt (default: 1h, 0 = unchecked)
+	MaxAge        string   `yaml:"max_age"`         // Max timestamp lag behind receipt (default: 0 = unchecked)
+	Levels        []string `yaml:"levels"`          // Accepted levels (default: any)
+}
+
+// ProjectAssignmentConfig assigns a project to records from agents that did
+// not report one. Rules are evaluated in order; the first match wins.
+type ProjectAssignmentConfig struct {
+	Rules          []ProjectRuleConfig `yaml:"rules"`           // Ordered agent/label match rules
+	DefaultProject string              `yaml:"default_project"` // Project for unmatched records (default: none)
+}
+
+// ProjectRuleConfig maps agents to a project by agent ID pattern and/or labels.
+type ProjectRuleConfig struct {
+	Project string            `yaml:"project"`  // Project ID to assign
+	AgentID string            `yaml:"agent_id"` // Agent ID glob pattern, e.g. "shop-*"
+	Labels  map[string]string `yaml:"labels"`   // Labels that must all match
+}
+
+// QuotaConfig caps the log lines per second each agent may send. Agents are
+// identified by their mTLS certificate common name, or else by the agent ID
+// in their batches.
+type QuotaConfig struct {
+	LinesPerSecond float64                     `yaml:"lines_per_second"` // Default per-agent rate (default: 0 = unlimited)
+	Burst          int                         `yaml:"burst"`            // Lines allowed at once (default: one second's worth, min 100)
+	Overrides      map[string]AgentQuotaConfig `yaml:"overrides"`        // Agent ID -> own quota
+}
+
+// AgentQuotaConfig is one agent's quota override.
+type AgentQuotaConfig struct {
+	LinesPerSecond float64 `yaml:"lines_per_second"` // Agent's rate (0 = unlimited)
+	Burst          int     `yaml:"burst"`            // Lines allowed at once (default: one second's worth, min 100)
+}
+
+// DedupConfig contains ingest deduplication settings.
+type DedupConfig struct {
+	Enabled    bool   `yaml:"enabled"`     // Skip records whose content hash was seen recently (default: false)
+	Window     string `yaml:"window"`      // How long a content hash is remembered (default: 1m)
+	MaxEntries int    `yaml:"max_entries"` // Max remembered hashes (default: 100000)
 }
 
 // SSHConnection defines a remote server connection for log collection.
@@ -164,6 +431,9 @@ func (c *Config) setDefaults() {
 	if c.Server.GRPCAddress == "" {
 		c.Server.GRPCAddress = ":9443"
 	}
+	if c.Server.GRPCMaxMessageSize == 0 {
+		c.Server.GRPCMaxMessageSize = 4 * 1024 * 1024
+	}
 	if c.Server.HTTPAddress == "" {
 		c.Server.HTTPAddress = ":8080"
 	}
@@ -179,6 +449,39 @@ func (c *Config) setDefaults() {
 	if c.API.StreamPollInterval == "" {
 		c.API.StreamPollInterval = "1s"
 	}
+	if c.API.StreamReorderWindow == "" {
+		c.API.StreamReorderWindow = "0s"
+	}
+	if c.API.ShareLinkTTL == "" {
+		c.API.ShareLinkTTL = "168h"
+	}
+	if c.API.ShareRateLimit == 0 {
+		c.API.ShareRateLimit = 10
+	}
+	if c.API.RawTailRateLimit == 0 {
+		c.API.RawTailRateLimit = 2
+	}
+	if c.API.MaxResultRows == 0 {
+		c.API.MaxResultRows = 100000
+	}
+	if c.Ingest.Dedup.Window == "" {
+		c.Ingest.Dedup.Window = "1m"
+	}
+	if c.Ingest.Dedup.MaxEntries == 0 {
+		c.Ingest.Dedup.MaxEntries = 100000
+	}
+	if c.Ingest.Validation.Policy == "" {
+		c.Ingest.Validation.Policy = "drop"
+	}
+	if c.Ingest.Validation.MaxFutureSkew == "" {
+		c.Ingest.Validation.MaxFutureSkew = "1h"
+	}
+	if c.Ingest.Validation.MaxAge == "" {
+		c.Ingest.Validation.MaxAge = "0s"
+	}
+	if c.Ingest.GeoIP.IPField == "" {
+		c.Ingest.GeoIP.IPField = server.DefaultGeoIPField
+	}
 	if !c.Metrics.enabledSet {
 		c.Metrics.Enabled = true
 	}
@@ -189,6 +492,9 @@ func (c *Config) setDefaults() {
 	if c.Database.Path == "" {
 		c.Database.Path = "./data/blazelog.db"
 	}
+	if c.Database.RetentionInterval == "" {
+		c.Database.RetentionInterval = "1h"
+	}
 	// ClickHouse defaults
 	if len(c.ClickHouse.Addresses) == 0 {
 		c.ClickHouse.Addresses = []string{"localhost:9000"}
@@ -211,13 +517,56 @@ func (c *Config) setDefaults() {
 	if c.ClickHouse.MaxBufferSize == 0 {
 		c.ClickHouse.MaxBufferSize = 100000
 	}
+	if c.ClickHouse.OnFull == "" {
+		c.ClickHouse.OnFull = storage.OnFullDropOldest
+	}
 	if c.ClickHouse.RetentionDays == 0 {
 		c.ClickHouse.RetentionDays = 30
 	}
+	if c.ClickHouse.RetentionInterval == "" {
+		c.ClickHouse.RetentionInterval = "24h"
+	}
+	if c.ClickHouse.PoolStatsInterval == "" {
+		c.ClickHouse.PoolStatsInterval = "15s"
+	}
+	if c.ClickHouse.PoolWaitWarnThreshold == "" {
+		c.ClickHouse.PoolWaitWarnThreshold = "1s"
+	}
+	if c.ClickHouse.SchemaRetries == 0 {
+		c.ClickHouse.SchemaRetries = 3
+	}
+	if c.ClickHouse.SchemaRetryDelay == "" {
+		c.ClickHouse.SchemaRetryDelay = "2s"
+	}
+	if c.ClickHouse.Engine == "" {
+		c.ClickHouse.Engine = storage.EngineMergeTree
+	}
+	// Postgres log storage defaults
+	if c.Postgres.MaxOpenConns == 0 {
+		c.Postgres.MaxOpenConns = 10
+	}
+	if c.Postgres.RetentionDays == 0 {
+		c.Postgres.RetentionDays = 30
+	}
+	if c.Postgres.BatchSize == 0 {
+		c.Postgres.BatchSize = 1000
+	}
+	if c.Postgres.FlushInterval == "" {
+		c.Postgres.FlushInterval = "5s"
+	}
+	if c.Postgres.MaxBufferSize == 0 {
+		c.Postgres.MaxBufferSize = 100000
+	}
+	if c.Postgres.OnFull == "" {
+		c.Postgres.OnFull = storage.OnFullDropOldest
+	}
 	// Auth defaults
 	if c.Auth.JWTSecretEnv == "" {
 		c.Auth.JWTSecretEnv = "BLAZELOG_JWT_SECRET"
 	}
+	if c.Auth.JWTPreviousSecretEnv == "" {
+		c.Auth.JWTPreviousSecretEnv = "BLAZELOG_JWT_SECRET_PREVIOUS"
+	}
 	if c.Auth.CSRFSecretEnv == "" {
 		c.Auth.CSRFSecretEnv = "BLAZELOG_CSRF_SECRET"
 	}
@@ -242,6 +591,33 @@ func (c *Config) setDefaults() {
 	if c.Auth.LockoutDuration == "" {
 		c.Auth.LockoutDuration = "30m"
 	}
+	// Notification defaults
+	if c.Notifications.RateLimit == 0 {
+		c.Notifications.RateLimit = 10
+	}
+	if c.Notifications.Email.SMTPPort == 0 {
+		c.Notifications.Email.SMTPPort = 587
+	}
+	// Self-monitoring defaults
+	if c.SelfMonitoring.QueryLatency.Threshold == "" {
+		c.SelfMonitoring.QueryLatency.Threshold = "2s"
+	}
+	if c.SelfMonitoring.QueryLatency.Window == "" {
+		c.SelfMonitoring.QueryLatency.Window = "5m"
+	}
+	if c.SelfMonitoring.QueryLatency.Interval == "" {
+		c.SelfMonitoring.QueryLatency.Interval = "30s"
+	}
+	if c.SelfMonitoring.QueryLatency.Severity == "" {
+		c.SelfMonitoring.QueryLatency.Severity = "high"
+	}
+	// Logging defaults
+	if c.Logging.Level == "" {
+		c.Logging.Level = "info"
+	}
+	if c.Logging.Format == "" {
+		c.Logging.Format = logging.FormatText
+	}
 }
 
 // Validate checks the configuration for errors.
@@ -249,6 +625,12 @@ func (c *Config) Validate() error {
 	if c.Server.GRPCAddress == "" {
 		return fmt.Errorf("server.grpc_address is required")
 	}
+	if c.Server.GRPCMaxMessageSize <= 0 {
+		return fmt.Errorf("server.grpc_max_message_size must be > 0")
+	}
+	if c.Server.GRPCTransferReportInterval < 0 {
+		return fmt.Errorf("server.grpc_transfer_report_interval must be >= 0")
+	}
 	if c.Server.TLS.Enabled {
 		if c.Server.TLS.CertFile == "" {
 			return fmt.Errorf("server.tls.cert_file is required when TLS is enabled")
@@ -259,6 +641,9 @@ func (c *Config) Validate() error {
 		if c.Server.TLS.ClientCAFile == "" {
 			return fmt.Errorf("server.tls.client_ca_file is required when TLS is enabled")
 		}
+		if err := c.Server.TLS.Policy().Validate(); err != nil {
+			return fmt.Errorf("server.tls: %w", err)
+		}
 	}
 	if c.Server.HTTPTLS.Enabled {
 		if c.Server.HTTPTLS.CertFile == "" {
@@ -267,6 +652,9 @@ func (c *Config) Validate() error {
 		if c.Server.HTTPTLS.KeyFile == "" {
 			return fmt.Errorf("server.http_tls.key_file is required when HTTP TLS is enabled")
 		}
+		if err := c.Server.HTTPTLS.Policy().Validate(); err != nil {
+			return fmt.Errorf("server.http_tls: %w", err)
+		}
 	}
 	if !c.Server.AllowInsecure {
 		if !c.Server.TLS.Enabled {
@@ -277,6 +665,15 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	for pattern, limit := range c.Auth.EndpointRateLimits {
+		if _, _, err := middleware.ParseEndpointPattern(pattern); err != nil {
+			return fmt.Errorf("auth.endpoint_rate_limits: %w", err)
+		}
+		if limit <= 0 {
+			return fmt.Errorf("auth.endpoint_rate_limits[%q] must be > 0", pattern)
+		}
+	}
+
 	maxQueryRange, err := time.ParseDuration(c.API.MaxQueryRange)
 	if err != nil {
 		return fmt.Errorf("api.max_query_range: %w", err)
@@ -308,6 +705,274 @@ func (c *Config) Validate() error {
 	if streamPollInterval > streamMaxDuration {
 		return fmt.Errorf("api.stream_poll_interval must be <= api.stream_max_duration")
 	}
+	streamReorderWindow, err := time.ParseDuration(c.API.StreamReorderWindow)
+	if err != nil {
+		return fmt.Errorf("api.stream_reorder_window: %w", err)
+	}
+	if streamReorderWindow < 0 {
+		return fmt.Errorf("api.stream_reorder_window must be >= 0")
+	}
+	shareLinkTTL, err := time.ParseDuration(c.API.ShareLinkTTL)
+	if err != nil {
+		return fmt.Errorf("api.share_link_ttl: %w", err)
+	}
+	if shareLinkTTL <= 0 {
+		return fmt.Errorf("api.share_link_ttl must be > 0")
+	}
+	if c.API.ShareRateLimit < -1 {
+		return fmt.Errorf("api.share_rate_limit must be >= -1")
+	}
+	if c.API.RawTailRateLimit < 0 {
+		return fmt.Errorf("api.raw_tail_rate_limit must be >= 0")
+	}
+	if c.API.MaxResultRows <= 0 {
+		return fmt.Errorf("api.max_result_rows must be > 0")
+	}
+	for i, label := range c.API.IndexedLabels {
+		if strings.TrimSpace(label) == "" {
+			return fmt.Errorf("api.indexed_labels[%d] must not be empty", i)
+		}
+	}
+
+	poolStatsInterval, err := time.ParseDuration(c.ClickHouse.PoolStatsInterval)
+	if err != nil {
+		return fmt.Errorf("clickhouse.pool_stats_interval: %w", err)
+	}
+	if poolStatsInterval < 0 {
+		return fmt.Errorf("clickhouse.pool_stats_interval must be >= 0")
+	}
+	poolWaitWarn, err := time.ParseDuration(c.ClickHouse.PoolWaitWarnThreshold)
+	if err != nil {
+		return fmt.Errorf("clickhouse.pool_wait_warn_threshold: %w", err)
+	}
+	if poolWaitWarn < 0 {
+		return fmt.Errorf("clickhouse.pool_wait_warn_threshold must be >= 0")
+	}
+	if c.ClickHouse.SchemaRetries < -1 {
+		return fmt.Errorf("clickhouse.schema_retries must be >= -1")
+	}
+	schemaRetryDelay, err := time.ParseDuration(c.ClickHouse.SchemaRetryDelay)
+	if err != nil {
+		return fmt.Errorf("clickhouse.schema_retry_delay: %w", err)
+	}
+	if schemaRetryDelay <= 0 {
+		return fmt.Errorf("clickhouse.schema_retry_delay must be > 0")
+	}
+	if c.ClickHouse.AsyncInsertBusyTimeout != "" {
+		busyTimeout, err := time.ParseDuration(c.ClickHouse.AsyncInsertBusyTimeout)
+		if err != nil {
+			return fmt.Errorf("clickhouse.async_insert_busy_timeout: %w", err)
+		}
+		if busyTimeout < 0 {
+			return fmt.Errorf("clickhouse.async_insert_busy_timeout must be >= 0")
+		}
+	}
+	logRetentionInterval, err := time.ParseDuration(c.ClickHouse.RetentionInterval)
+	if err != nil {
+		return fmt.Errorf("clickhouse.retention_interval: %w", err)
+	}
+	if logRetentionInterval < 0 {
+		return fmt.Errorf("clickhouse.retention_interval must be >= 0")
+	}
+	if c.ClickHouse.RetentionVerifyTimeout != "" {
+		verifyTimeout, err := time.ParseDuration(c.ClickHouse.RetentionVerifyTimeout)
+		if err != nil {
+			return fmt.Errorf("clickhouse.retention_verify_timeout: %w", err)
+		}
+		if verifyTimeout < 0 {
+			return fmt.Errorf("clickhouse.retention_verify_timeout must be >= 0")
+		}
+	}
+	switch c.ClickHouse.OnFull {
+	case storage.OnFullBlock, storage.OnFullDropOldest, storage.OnFullDropNew:
+	default:
+		return fmt.Errorf("clickhouse.on_full must be %s, %s or %s", storage.OnFullBlock, storage.OnFullDropOldest, storage.OnFullDropNew)
+	}
+	if c.ClickHouse.Engine != storage.EngineMergeTree && c.ClickHouse.Engine != storage.EngineReplacingMergeTree {
+		return fmt.Errorf("clickhouse.engine must be %s or %s", storage.EngineMergeTree, storage.EngineReplacingMergeTree)
+	}
+	if err := storage.ValidateClusterName(c.ClickHouse.Cluster); err != nil {
+		return fmt.Errorf("clickhouse.cluster: %w", err)
+	}
+	if c.Postgres.Enabled {
+		if c.ClickHouse.Enabled {
+			return fmt.Errorf("clickhouse.enabled and postgres.enabled are exclusive; choose one log storage")
+		}
+		if c.Postgres.DSN == "" && c.Postgres.DSNEnv == "" {
+			return fmt.Errorf("postgres.dsn or postgres.dsn_env is required")
+		}
+		if _, err := time.ParseDuration(c.Postgres.FlushInterval); err != nil {
+			return fmt.Errorf("postgres.flush_interval: %w", err)
+		}
+		switch c.Postgres.OnFull {
+		case storage.OnFullBlock, storage.OnFullDropOldest, storage.OnFullDropNew:
+		default:
+			return fmt.Errorf("postgres.on_full must be %s, %s or %s", storage.OnFullBlock, storage.OnFullDropOldest, storage.OnFullDropNew)
+		}
+	}
+	if c.Postgres.RetentionDays < 0 {
+		return fmt.Errorf("postgres.retention_days must be >= 0")
+	}
+	indexNames := make(map[string]int)
+	for i, hint := range c.ClickHouse.IndexHints() {
+		if err := hint.Validate(); err != nil {
+			return fmt.Errorf("clickhouse.indexes[%d]: %w", i, err)
+		}
+		if j, dup := indexNames[hint.Name()]; dup {
+			return fmt.Errorf("clickhouse.indexes[%d] duplicates clickhouse.indexes[%d]", i, j)
+		}
+		indexNames[hint.Name()] = i
+	}
+	for i, rule := range c.ClickHouse.StorageRetentionRules() {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("clickhouse.retention_rules[%d]: %w", i, err)
+		}
+	}
+
+	if c.Database.AlertHistoryRetentionDays < 0 {
+		return fmt.Errorf("database.alert_history_retention_days must be >= 0")
+	}
+	if c.Database.AlertHistoryMaxRows < 0 {
+		return fmt.Errorf("database.alert_history_max_rows must be >= 0")
+	}
+	retentionInterval, err := time.ParseDuration(c.Database.RetentionInterval)
+	if err != nil {
+		return fmt.Errorf("database.retention_interval: %w", err)
+	}
+	if retentionInterval <= 0 {
+		return fmt.Errorf("database.retention_interval must be > 0")
+	}
+
+	dedupWindow, err := time.ParseDuration(c.Ingest.Dedup.Window)
+	if err != nil {
+		return fmt.Errorf("ingest.dedup.window: %w", err)
+	}
+	if dedupWindow <= 0 {
+		return fmt.Errorf("ingest.dedup.window must be > 0")
+	}
+	if c.Ingest.Dedup.MaxEntries <= 0 {
+		return fmt.Errorf("ingest.dedup.max_entries must be > 0")
+	}
+	if c.Ingest.Quota.LinesPerSecond < 0 || c.Ingest.Quota.Burst < 0 {
+		return fmt.Errorf("ingest.agent_quota.lines_per_second and burst must be >= 0")
+	}
+	if b := c.Ingest.Quota.Burst; b > 0 && b < server.MinQuotaBurst {
+		return fmt.Errorf("ingest.agent_quota.burst must be 0 or >= %d, the max batch size", server.MinQuotaBurst)
+	}
+	for agentID, q := range c.Ingest.Quota.Overrides {
+		if q.LinesPerSecond < 0 || q.Burst < 0 {
+			return fmt.Errorf("ingest.agent_quota.overrides.%s: lines_per_second and burst must be >= 0", agentID)
+		}
+		if q.Burst > 0 && q.Burst < server.MinQuotaBurst {
+			return fmt.Errorf("ingest.agent_quota.overrides.%s: burst must be 0 or >= %d, the max batch size", agentID, server.MinQuotaBurst)
+		}
+	}
+	for logType, renames := range c.Ingest.FieldRenames {
+		for from, to := range renames {
+			if from == "" || to == "" {
+				return fmt.Errorf("ingest.field_renames.%s: field names must not be empty", logType)
+			}
+		}
+	}
+	for logType, field := range c.Ingest.IDFields {
+		if field == "" {
+			return fmt.Errorf("ingest.id_fields.%s: field name must not be empty", logType)
+		}
+	}
+	for i, rule := range c.Ingest.ProjectAssignment.Rules {
+		if rule.Project == "" {
+			return fmt.Errorf("ingest.project_assignment.rules[%d].project is required", i)
+		}
+		if rule.AgentID == "" && len(rule.Labels) == 0 {
+			return fmt.Errorf("ingest.project_assignment.rules[%d] requires agent_id or labels", i)
+		}
+		if _, err := path.Match(rule.AgentID, ""); err != nil {
+			return fmt.Errorf("ingest.project_assignment.rules[%d].agent_id: %w", i, err)
+		}
+	}
+
+	if c.Ingest.MaxFields < 0 {
+		return fmt.Errorf("ingest.max_fields must be >= 0")
+	}
+	if c.Ingest.MaxLabels < 0 {
+		return fmt.Errorf("ingest.max_labels must be >= 0")
+	}
+	switch c.Ingest.Validation.Policy {
+	case "drop", "coerce", "reject":
+	default:
+		return fmt.Errorf("ingest.validation.policy must be drop, coerce or reject")
+	}
+	maxFutureSkew, err := time.ParseDuration(c.Ingest.Validation.MaxFutureSkew)
+	if err != nil {
+		return fmt.Errorf("ingest.validation.max_future_skew: %w", err)
+	}
+	if maxFutureSkew < 0 {
+		return fmt.Errorf("ingest.validation.max_future_skew must be >= 0")
+	}
+	maxAge, err := time.ParseDuration(c.Ingest.Validation.MaxAge)
+	if err != nil {
+		return fmt.Errorf("ingest.validation.max_age: %w", err)
+	}
+	if maxAge < 0 {
+		return fmt.Errorf("ingest.validation.max_age must be >= 0")
+	}
+	for _, level := range c.Ingest.Validation.Levels {
+		switch strings.ToLower(level) {
+		case "debug", "info", "warning", "error", "fatal", "unknown":
+		default:
+			return fmt.Errorf("ingest.validation.levels: unknown level %q", level)
+		}
+	}
+	if c.Ingest.GeoIP.Enabled && c.Ingest.GeoIP.Database == "" {
+		return fmt.Errorf("ingest.geoip.database is required when geoip is enabled")
+	}
+
+	if c.Notifications.RateLimit < 0 {
+		return fmt.Errorf("notifications.rate_limit must be > 0")
+	}
+	if url := c.Notifications.Slack.WebhookURL; url != "" && !strings.HasPrefix(url, "https://") {
+		return fmt.Errorf("notifications.slack.webhook_url must use https")
+	}
+	if url := c.Notifications.Teams.WebhookURL; url != "" && !strings.HasPrefix(url, "https://") {
+		return fmt.Errorf("notifications.teams.webhook_url must use https")
+	}
+	if email := c.Notifications.Email; email.SMTPHost != "" {
+		if email.From == "" {
+			return fmt.Errorf("notifications.email.from is required when smtp_host is set")
+		}
+		if len(email.Recipients) == 0 {
+			return fmt.Errorf("notifications.email.recipients is required when smtp_host is set")
+		}
+	}
+
+	if ql := c.SelfMonitoring.QueryLatency; ql.Enabled {
+		for name, value := range map[string]string{"threshold": ql.Threshold, "window": ql.Window, "interval": ql.Interval} {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("self_monitoring.query_latency.%s: %w", name, err)
+			}
+			if d <= 0 {
+				return fmt.Errorf("self_monitoring.query_latency.%s must be > 0", name)
+			}
+		}
+		for _, ch := range ql.Notify {
+			if ch != "slack" && ch != "teams" && ch != "email" {
+				return fmt.Errorf("self_monitoring.query_latency.notify: unknown channel %q (use slack, teams or email)", ch)
+			}
+		}
+		switch ql.Severity {
+		case "low", "medium", "high", "critical":
+		default:
+			return fmt.Errorf("self_monitoring.query_latency.severity must be low, medium, high or critical")
+		}
+	}
+
+	if _, err := logging.ParseLevel(c.Logging.Level); err != nil {
+		return fmt.Errorf("logging.level: %w", err)
+	}
+	if c.Logging.Format != logging.FormatText && c.Logging.Format != logging.FormatJSON {
+		return fmt.Errorf("logging.format must be \"text\" or \"json\"")
+	}
 
 	// Validate SSH connections
 	names := make(map[string]bool)
@@ -353,6 +1018,14 @@ func (c *Config) WarnSecurityIssues(logger func(format string, args ...any)) {
 		logger("SECURITY WARNING: clickhouse.password is set in plaintext in config file. Use clickhouse.password_env instead.")
 	}
 
+	// Warn about plaintext webhook URLs, which embed their credentials
+	if c.Notifications.Slack.WebhookURL != "" {
+		logger("SECURITY WARNING: notifications.slack.webhook_url is set in plaintext in config file. Use notifications.slack.webhook_url_env instead.")
+	}
+	if c.Notifications.Teams.WebhookURL != "" {
+		logger("SECURITY WARNING: notifications.teams.webhook_url is set in plaintext in config file. Use notifications.teams.webhook_url_env instead.")
+	}
+
 	// Warn about plaintext SSH passwords
 	for i, conn := range c.SSHConnections {
 		if conn.Password != "" {
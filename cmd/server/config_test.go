@@ -1,6 +1,9 @@
 package main
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestConfigValidate_AllowsExplicitInsecureMode(t *testing.T) {
 	cfg := DefaultConfig()
@@ -33,3 +36,567 @@ func TestConfigValidate_RejectsInvalidAPIDurations(t *testing.T) {
 		t.Fatal("expected validation error for invalid api.max_query_range")
 	}
 }
+
+func TestConfigValidate_StreamReorderWindow(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  string
+		wantErr bool
+	}{
+		{"disabled", "0s", false},
+		{"enabled", "30s", false},
+		{"negative", "-1s", true},
+		{"invalid", "soon", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Server.AllowInsecure = true
+			cfg.API.StreamReorderWindow = tt.window
+
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidate_ShareRateLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   int
+		wantErr bool
+	}{
+		{"limited", 10, false},
+		{"unlimited", -1, false},
+		{"too low", -2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Server.AllowInsecure = true
+			cfg.API.ShareRateLimit = tt.limit
+
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidate_ClickHouseSchemaRetries(t *testing.T) {
+	tests := []struct {
+		name    string
+		retries int
+		delay   string
+		wantErr bool
+	}{
+		{"defaults", 3, "2s", false},
+		{"no retries", -1, "2s", false},
+		{"retries too low", -2, "2s", true},
+		{"zero delay", 3, "0s", true},
+		{"invalid delay", 3, "later", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Server.AllowInsecure = true
+			cfg.ClickHouse.SchemaRetries = tt.retries
+			cfg.ClickHouse.SchemaRetryDelay = tt.delay
+
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidate_ClickHouseOnFull(t *testing.T) {
+	for _, onFull := range []string{"block", "drop_oldest", "drop_new", "drop_all"} {
+		t.Run(onFull, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Server.AllowInsecure = true
+			cfg.ClickHouse.OnFull = onFull
+
+			err := cfg.Validate()
+			if wantErr := onFull == "drop_all"; (err != nil) != wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidate_ClickHouseAsyncInsert(t *testing.T) {
+	tests := []struct {
+		name        string
+		busyTimeout string
+		wantErr     bool
+	}{
+		{"server default", "", false},
+		{"explicit timeout", "200ms", false},
+		{"negative timeout", "-1s", true},
+		{"invalid timeout", "soon", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Server.AllowInsecure = true
+			cfg.ClickHouse.AsyncInsert = true
+			cfg.ClickHouse.AsyncInsertBusyTimeout = tt.busyTimeout
+
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidate_ClickHouseLogRetention(t *testing.T) {
+	tests := []struct {
+		name          string
+		interval      string
+		verifyTimeout string
+		wantErr       bool
+	}{
+		{"defaults", "", "", false},
+		{"TTL only", "0", "", false},
+		{"verified", "12h", "30m", false},
+		{"negative interval", "-1h", "", true},
+		{"invalid interval", "daily", "", true},
+		{"negative verify timeout", "24h", "-1m", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Server.AllowInsecure = true
+			cfg.ClickHouse.RetentionInterval = tt.interval
+			cfg.ClickHouse.RetentionVerifyTimeout = tt.verifyTimeout
+			cfg.setDefaults()
+
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidate_ClickHouseRetentionRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    ClickHouseRetentionRuleConfig
+		wantErr bool
+	}{
+		{"type", ClickHouseRetentionRuleConfig{Match: ClickHouseRetentionMatchConfig{Type: "security"}, Days: 365}, false},
+		{"label", ClickHouseRetentionRuleConfig{Match: ClickHouseRetentionMatchConfig{Label: map[string]string{"tier": "debug"}}, Days: 7}, false},
+		{"empty match", ClickHouseRetentionRuleConfig{Days: 7}, true},
+		{"zero days", ClickHouseRetentionRuleConfig{Match: ClickHouseRetentionMatchConfig{Type: "debug"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Server.AllowInsecure = true
+			cfg.ClickHouse.RetentionRules = []ClickHouseRetentionRuleConfig{tt.rule}
+
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidate_ClickHouseCluster(t *testing.T) {
+	for cluster, wantErr := range map[string]bool{"": false, "logs_cluster": false, "logs cluster": true} {
+		cfg := DefaultConfig()
+		cfg.Server.AllowInsecure = true
+		cfg.ClickHouse.Cluster = cluster
+
+		if err := cfg.Validate(); (err != nil) != wantErr {
+			t.Errorf("cluster %q: Validate() error = %v, wantErr %v", cluster, err, wantErr)
+		}
+	}
+}
+
+func TestConfigValidate_Postgres(t *testing.T) {
+	tests := []struct {
+		name       string
+		clickhouse bool
+		dsn        string
+		onFull     string
+		wantErr    bool
+	}{
+		{"postgres", false, "postgres://localhost/blazelog", "", false},
+		{"both storages", true, "postgres://localhost/blazelog", "", true},
+		{"no dsn", false, "", "", true},
+		{"bad on_full", false, "postgres://localhost/blazelog", "drop_all", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Server.AllowInsecure = true
+			cfg.ClickHouse.Enabled = tt.clickhouse
+			cfg.Postgres.Enabled = true
+			cfg.Postgres.DSN = tt.dsn
+			if tt.onFull != "" {
+				cfg.Postgres.OnFull = tt.onFull
+			}
+
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidate_ClickHouseIndexes(t *testing.T) {
+	tests := []struct {
+		name    string
+		indexes []ClickHouseIndexConfig
+		wantErr bool
+	}{
+		{"none", nil, false},
+		{"label and column", []ClickHouseIndexConfig{
+			{Column: "labels.tenant", Type: "bloom_filter(0.01)"},
+			{Column: "http_method", Type: "set(16)", Granularity: 2},
+		}, false},
+		{"unknown column", []ClickHouseIndexConfig{{Column: "tenant", Type: "bloom_filter"}}, true},
+		{"unknown type", []ClickHouseIndexConfig{{Column: "source", Type: "btree"}}, true},
+		{"duplicate", []ClickHouseIndexConfig{
+			{Column: "source", Type: "bloom_filter"},
+			{Column: "source", Type: "set(100)"},
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Server.AllowInsecure = true
+			cfg.ClickHouse.Indexes = tt.indexes
+
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidate_RecordIDs(t *testing.T) {
+	tests := []struct {
+		name     string
+		engine   string
+		idFields map[string]string
+		wantErr  bool
+	}{
+		{"defaults", "MergeTree", nil, false},
+		{"replacing engine with id field", "ReplacingMergeTree", map[string]string{"*": "event_id"}, false},
+		{"unknown engine", "SummingMergeTree", nil, true},
+		{"empty id field", "MergeTree", map[string]string{"nginx": ""}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Server.AllowInsecure = true
+			cfg.ClickHouse.Engine = tt.engine
+			cfg.Ingest.IDFields = tt.idFields
+
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidate_TLSPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		minVersion string
+		suites     []string
+		wantErr    bool
+	}{
+		{"defaults", "", nil, false},
+		{"tls 1.3 only", "1.3", nil, false},
+		{"tls 1.2 with aead suites", "1.2", []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}, false},
+		{"tls 1.0", "1.0", nil, true},
+		{"suites with tls 1.3", "1.3", []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}, true},
+		{"cbc suite", "1.2", []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Server.TLS = TLSConfig{
+				Enabled:      true,
+				CertFile:     "server.crt",
+				KeyFile:      "server.key",
+				ClientCAFile: "ca.crt",
+				MinVersion:   tt.minVersion,
+				CipherSuites: tt.suites,
+			}
+			cfg.Server.HTTPTLS = HTTPTLSConfig{
+				Enabled:      true,
+				CertFile:     "http.crt",
+				KeyFile:      "http.key",
+				MinVersion:   tt.minVersion,
+				CipherSuites: tt.suites,
+			}
+
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidate_AlertHistoryRetention(t *testing.T) {
+	tests := []struct {
+		name     string
+		days     int
+		maxRows  int
+		interval string
+		wantErr  bool
+	}{
+		{"off", 0, 0, "1h", false},
+		{"both limits", 90, 100000, "30m", false},
+		{"negative days", -1, 0, "1h", true},
+		{"negative max rows", 0, -1, "1h", true},
+		{"zero interval", 90, 0, "0s", true},
+		{"invalid interval", 90, 0, "hourly", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Server.AllowInsecure = true
+			cfg.Database.AlertHistoryRetentionDays = tt.days
+			cfg.Database.AlertHistoryMaxRows = tt.maxRows
+			cfg.Database.RetentionInterval = tt.interval
+
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidate_IngestValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*IngestValidationConfig)
+		wantErr string
+	}{
+		{"defaults", func(v *IngestValidationConfig) {}, ""},
+		{"coerce with levels", func(v *IngestValidationConfig) {
+			v.Policy = "coerce"
+			v.Levels = []string{"warning", "ERROR"}
+		}, ""},
+		{"unknown policy", func(v *IngestValidationConfig) { v.Policy = "ignore" }, "ingest.validation.policy"},
+		{"negative skew", func(v *IngestValidationConfig) { v.MaxFutureSkew = "-1m" }, "ingest.validation.max_future_skew"},
+		{"max age", func(v *IngestValidationConfig) { v.MaxAge = "720h" }, ""},
+		{"negative max age", func(v *IngestValidationConfig) { v.MaxAge = "-1h" }, "ingest.validation.max_age"},
+		{"unknown level", func(v *IngestValidationConfig) { v.Levels = []string{"notice"} }, "ingest.validation.levels"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Server.AllowInsecure = true
+			cfg.Ingest.Validation.Enabled = true
+			tt.modify(&cfg.Ingest.Validation)
+
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidate_EndpointRateLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  map[string]int
+		wantErr bool
+	}{
+		{"valid", map[string]int{"GET /api/v1/logs/stats": 10, "/api/v1/logs/{id}/context": 30}, false},
+		{"relative path", map[string]int{"api/v1/logs": 10}, true},
+		{"zero limit", map[string]int{"/api/v1/logs": 0}, true},
+		{"bad pattern", map[string]int{"/api/v1/[logs": 10}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Server.AllowInsecure = true
+			cfg.Auth.EndpointRateLimits = tt.limits
+
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidate_Notifications(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     NotificationsConfig
+		wantErr bool
+	}{
+		{"none", NotificationsConfig{}, false},
+		{"slack https", NotificationsConfig{Slack: WebhookChannelConfig{WebhookURL: "https://hooks.slack.com/services/x"}}, false},
+		{"slack http", NotificationsConfig{Slack: WebhookChannelConfig{WebhookURL: "http://hooks.slack.com/services/x"}}, true},
+		{"teams env", NotificationsConfig{Teams: WebhookChannelConfig{WebhookURLEnv: "TEAMS_WEBHOOK"}}, false},
+		{"email", NotificationsConfig{Email: EmailChannelConfig{SMTPHost: "smtp.example.com", From: "a@example.com", Recipients: []string{"b@example.com"}}}, false},
+		{"email without recipients", NotificationsConfig{Email: EmailChannelConfig{SMTPHost: "smtp.example.com", From: "a@example.com"}}, true},
+		{"negative rate limit", NotificationsConfig{RateLimit: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Notifications: tt.cfg}
+			cfg.setDefaults()
+			cfg.Server.AllowInsecure = true
+
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidate_SelfMonitoring(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     QueryLatencyAlertConfig
+		wantErr bool
+	}{
+		{"disabled", QueryLatencyAlertConfig{Threshold: "soon"}, false},
+		{"defaults", QueryLatencyAlertConfig{Enabled: true}, false},
+		{"custom", QueryLatencyAlertConfig{Enabled: true, Threshold: "500ms", Window: "10m", Notify: []string{"slack", "email"}, Severity: "critical"}, false},
+		{"bad threshold", QueryLatencyAlertConfig{Enabled: true, Threshold: "soon"}, true},
+		{"zero window", QueryLatencyAlertConfig{Enabled: true, Window: "0s"}, true},
+		{"unknown channel", QueryLatencyAlertConfig{Enabled: true, Notify: []string{"pager"}}, true},
+		{"unknown severity", QueryLatencyAlertConfig{Enabled: true, Severity: "urgent"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{SelfMonitoring: SelfMonitoringConfig{QueryLatency: tt.cfg}}
+			cfg.setDefaults()
+			cfg.Server.AllowInsecure = true
+
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidate_Logging(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     LoggingConfig
+		wantErr bool
+	}{
+		{"defaults", LoggingConfig{}, false},
+		{"debug json", LoggingConfig{Level: "debug", Format: "json"}, false},
+		{"warning alias", LoggingConfig{Level: "WARNING"}, false},
+		{"unknown level", LoggingConfig{Level: "trace"}, true},
+		{"unknown format", LoggingConfig{Format: "logfmt"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Logging: tt.cfg}
+			cfg.setDefaults()
+			cfg.Server.AllowInsecure = true
+
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidate_ProjectAssignment(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    ProjectRuleConfig
+		wantErr bool
+	}{
+		{"agent pattern", ProjectRuleConfig{Project: "shop", AgentID: "shop-*"}, false},
+		{"labels", ProjectRuleConfig{Project: "shop", Labels: map[string]string{"app": "shop"}}, false},
+		{"missing project", ProjectRuleConfig{AgentID: "shop-*"}, true},
+		{"no criteria", ProjectRuleConfig{Project: "shop"}, true},
+		{"bad pattern", ProjectRuleConfig{Project: "shop", AgentID: "shop-["}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Server.AllowInsecure = true
+			cfg.Ingest.ProjectAssignment.Rules = []ProjectRuleConfig{tt.rule}
+
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidate_AgentQuota(t *testing.T) {
+	tests := []struct {
+		name    string
+		quota   QuotaConfig
+		wantErr bool
+	}{
+		{"unlimited", QuotaConfig{}, false},
+		{"default burst", QuotaConfig{LinesPerSecond: 10}, false},
+		{"batch-sized burst", QuotaConfig{LinesPerSecond: 10, Burst: 100}, false},
+		{"burst below batch size", QuotaConfig{LinesPerSecond: 10, Burst: 50}, true},
+		{"override burst below batch size", QuotaConfig{Overrides: map[string]AgentQuotaConfig{"a": {LinesPerSecond: 10, Burst: 10}}}, true},
+		{"negative rate", QuotaConfig{LinesPerSecond: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Server.AllowInsecure = true
+			cfg.Ingest.Quota = tt.quota
+
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -3,19 +3,24 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/good-yellow-bee/blazelog/internal/alerting"
 	"github.com/good-yellow-bee/blazelog/internal/api"
 	"github.com/good-yellow-bee/blazelog/internal/api/health"
+	"github.com/good-yellow-bee/blazelog/internal/logging"
 	"github.com/good-yellow-bee/blazelog/internal/metrics"
+	"github.com/good-yellow-bee/blazelog/internal/notifier"
 	"github.com/good-yellow-bee/blazelog/internal/server"
+	"github.com/good-yellow-bee/blazelog/internal/ssh"
 	"github.com/good-yellow-bee/blazelog/internal/storage"
 	"github.com/good-yellow-bee/blazelog/pkg/config"
 	"github.com/spf13/cobra"
@@ -26,6 +31,7 @@ var (
 	profile    string
 	grpcAddr   string
 	verbose    bool
+	demo       bool
 )
 
 var rootCmd = &cobra.Command{
@@ -56,7 +62,8 @@ func init() {
 	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "", "config file path (optional)")
 	rootCmd.PersistentFlags().StringVarP(&profile, "profile", "p", "", "config profile (dev, prod) - loads configs/server-{profile}.yaml")
 	rootCmd.PersistentFlags().StringVarP(&grpcAddr, "address", "a", ":9443", "gRPC listen address")
-	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output (forces debug logging)")
+	rootCmd.PersistentFlags().BoolVar(&demo, "demo", false, "keep logs in memory instead of ClickHouse/PostgreSQL (lost on restart)")
 
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(healthCmd)
@@ -129,11 +136,6 @@ func runServer(cmd *cobra.Command, args []string) error {
 			}
 			return fmt.Errorf("load config: %w", err)
 		}
-		if profile != "" {
-			log.Printf("loaded config from %s (profile: %s)", cfgPath, profile)
-		} else {
-			log.Printf("loaded config from %s", cfgPath)
-		}
 	} else {
 		cfg = DefaultConfig()
 	}
@@ -142,15 +144,35 @@ func runServer(cmd *cobra.Command, args []string) error {
 	if grpcAddr != "" {
 		cfg.Server.GRPCAddress = grpcAddr
 	}
-	cfg.Verbose = verbose
+	if verbose {
+		cfg.Logging.Level = "debug"
+	}
+	cfg.Verbose = verbose || strings.EqualFold(cfg.Logging.Level, "debug")
+	if demo {
+		cfg.ClickHouse.Enabled = false
+		cfg.Postgres.Enabled = false
+	}
 
 	// Validate the effective configuration (including defaults and CLI overrides).
 	if err := cfg.Validate(); err != nil {
 		return fmt.Errorf("validate config: %w", err)
 	}
 
+	if err := logging.Setup(os.Stderr, cfg.Logging.Level, cfg.Logging.Format); err != nil {
+		return fmt.Errorf("setup logging: %w", err)
+	}
+	if cfgPath != "" {
+		if profile != "" {
+			slog.Info("loaded config", "path", cfgPath, "profile", profile)
+		} else {
+			slog.Info("loaded config", "path", cfgPath)
+		}
+	}
+
 	// Log security warnings for insecure configuration
-	cfg.WarnSecurityIssues(log.Printf)
+	cfg.WarnSecurityIssues(func(format string, args ...any) {
+		slog.Warn(fmt.Sprintf(format, args...))
+	})
 
 	// Get master key from environment
 	masterKey := os.Getenv("BLAZELOG_MASTER_KEY")
@@ -184,11 +206,27 @@ func runServer(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("ensure admin user: %w", err)
 	}
 
-	log.Printf("database initialized at %s", cfg.Database.Path)
+	slog.Info("database initialized", "path", cfg.Database.Path)
 
-	// Initialize ClickHouse storage (if enabled)
+	retentionInterval, err := time.ParseDuration(cfg.Database.RetentionInterval)
+	if err != nil {
+		return fmt.Errorf("parse database.retention_interval: %w", err)
+	}
+	retention := storage.AlertHistoryRetention{
+		MaxAge:   time.Duration(cfg.Database.AlertHistoryRetentionDays) * 24 * time.Hour,
+		MaxRows:  cfg.Database.AlertHistoryMaxRows,
+		Interval: retentionInterval,
+	}
+	if retention.Enabled() {
+		slog.Info("alert history retention enabled", "days", cfg.Database.AlertHistoryRetentionDays,
+			"max_rows", retention.MaxRows, "interval", retentionInterval)
+	}
+
+	// Initialize log storage: ClickHouse or PostgreSQL (if enabled), or in
+	// memory for --demo
 	var logBuffer *storage.LogBuffer
 	var logStore storage.LogStorage
+	var logRetention storage.LogRetention
 	if cfg.ClickHouse.Enabled {
 		var chErr error
 		logBuffer, logStore, chErr = initClickHouse(cfg)
@@ -197,25 +235,119 @@ func runServer(cmd *cobra.Command, args []string) error {
 		}
 		defer logBuffer.Close()
 		defer logStore.Close()
+		if logRetention, err = clickHouseLogRetention(cfg); err != nil {
+			return err
+		}
+	} else if cfg.Postgres.Enabled {
+		var pgErr error
+		logBuffer, logStore, pgErr = initPostgres(cfg)
+		if pgErr != nil {
+			return fmt.Errorf("init postgres: %w", pgErr)
+		}
+		defer logBuffer.Close()
+		defer logStore.Close()
+	} else if demo {
+		logBuffer, logStore = initMemory()
+		defer logBuffer.Close()
 	}
 
 	// Build server config
 	serverCfg := &server.Config{
-		GRPCAddress: cfg.Server.GRPCAddress,
-		Verbose:     cfg.Verbose,
+		GRPCAddress:    cfg.Server.GRPCAddress,
+		Verbose:        cfg.Verbose,
+		MaxFields:      cfg.Ingest.MaxFields,
+		MaxLabels:      cfg.Ingest.MaxLabels,
+		MaxMessageSize: cfg.Server.GRPCMaxMessageSize,
+		FieldRenames:   cfg.Ingest.FieldRenames,
+		RecordIDFields: cfg.Ingest.IDFields,
+
+		TransferReportInterval: cfg.Server.GRPCTransferReportInterval,
 	}
 
-	// Pass LogBuffer to server if ClickHouse enabled
+	// Pass LogBuffer to server if log storage enabled
 	if logBuffer != nil {
 		serverCfg.LogBuffer = &logBufferAdapter{logBuffer}
 	}
 
+	// Assign projects to records from agents that report none
+	if pa := cfg.Ingest.ProjectAssignment; len(pa.Rules) > 0 || pa.DefaultProject != "" {
+		serverCfg.Projects = &server.ProjectAssignment{Default: pa.DefaultProject}
+		for _, rule := range pa.Rules {
+			serverCfg.Projects.Rules = append(serverCfg.Projects.Rules, server.ProjectRule{
+				Project: rule.Project,
+				AgentID: rule.AgentID,
+				Labels:  rule.Labels,
+			})
+		}
+	}
+
+	// Enable ingest deduplication if configured
+	if cfg.Ingest.Dedup.Enabled {
+		dedupWindow, err := time.ParseDuration(cfg.Ingest.Dedup.Window)
+		if err != nil {
+			return fmt.Errorf("parse ingest.dedup.window: %w", err)
+		}
+		serverCfg.Dedup = &server.DedupConfig{
+			Window:     dedupWindow,
+			MaxEntries: cfg.Ingest.Dedup.MaxEntries,
+		}
+		slog.Info("ingest deduplication enabled", "window", dedupWindow, "max_entries", cfg.Ingest.Dedup.MaxEntries)
+	}
+
+	// Cap lines per second per agent if configured
+	if q := cfg.Ingest.Quota; q.LinesPerSecond > 0 || len(q.Overrides) > 0 {
+		serverCfg.Quota = &server.QuotaConfig{
+			Default:   server.AgentQuota{LinesPerSecond: q.LinesPerSecond, Burst: q.Burst},
+			Overrides: make(map[string]server.AgentQuota, len(q.Overrides)),
+		}
+		for agentID, o := range q.Overrides {
+			serverCfg.Quota.Overrides[agentID] = server.AgentQuota{LinesPerSecond: o.LinesPerSecond, Burst: o.Burst}
+		}
+		slog.Info("agent quotas enabled", "lines_per_second", q.LinesPerSecond, "burst", q.Burst, "overrides", len(q.Overrides))
+	}
+
+	// Validate records before storing them if configured
+	if v := cfg.Ingest.Validation; v.Enabled {
+		maxFutureSkew, err := time.ParseDuration(v.MaxFutureSkew)
+		if err != nil {
+			return fmt.Errorf("parse ingest.validation.max_future_skew: %w", err)
+		}
+		maxAge, err := time.ParseDuration(v.MaxAge)
+		if err != nil {
+			return fmt.Errorf("parse ingest.validation.max_age: %w", err)
+		}
+		serverCfg.Validation = &server.ValidationConfig{
+			Policy:        v.Policy,
+			MaxFutureSkew: maxFutureSkew,
+			MaxAge:        maxAge,
+			Levels:        v.Levels,
+		}
+		slog.Info("ingest validation enabled", "policy", v.Policy, "max_future_skew", maxFutureSkew, "max_age", maxAge, "levels", v.Levels)
+	}
+
+	// Enrich records with client IP geolocation if configured
+	if g := cfg.Ingest.GeoIP; g.Enabled {
+		geoIP, err := server.NewGeoIP(server.GeoIPConfig{
+			Database:    g.Database,
+			ASNDatabase: g.ASNDatabase,
+			IPField:     g.IPField,
+		})
+		if err != nil {
+			return fmt.Errorf("init geoip: %w", err)
+		}
+		defer geoIP.Close()
+		serverCfg.GeoIP = geoIP
+		slog.Info("ingest geoip enrichment enabled", "database", g.Database, "asn_database", g.ASNDatabase, "ip_field", g.IPField)
+	}
+
 	// Configure TLS if enabled
 	if cfg.Server.TLS.Enabled {
 		serverCfg.TLS = &server.TLSConfig{
 			CertFile:     cfg.Server.TLS.CertFile,
 			KeyFile:      cfg.Server.TLS.KeyFile,
 			ClientCAFile: cfg.Server.TLS.ClientCAFile,
+			MinVersion:   cfg.Server.TLS.MinVersion,
+			CipherSuites: cfg.Server.TLS.CipherSuites,
 		}
 	}
 
@@ -225,16 +357,33 @@ func runServer(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("create server: %w", err)
 	}
 
+	dispatcher, err := initNotifications(cfg.Notifications)
+	if err != nil {
+		return fmt.Errorf("init notifications: %w", err)
+	}
+
+	// Self-monitoring alert on slow storage queries (if enabled)
+	var latencyMonitor *alerting.QueryLatencyMonitor
+	if ql := cfg.SelfMonitoring.QueryLatency; ql.Enabled {
+		latencyMonitor, err = initQueryLatencyMonitor(ql, cfg.Notifications, dispatcher)
+		if err != nil {
+			return fmt.Errorf("init self_monitoring.query_latency: %w", err)
+		}
+		slog.Info("query latency self-monitoring enabled", "threshold", ql.Threshold, "window", ql.Window)
+	}
+
 	// Initialize HTTP API server
-	apiServer, err := initAPIServer(cfg, store, logStore)
+	apiServer, err := initAPIServer(cfg, store, logStore, srv, dispatcher)
 	if err != nil {
 		return fmt.Errorf("init api server: %w", err)
 	}
 
 	// Register health checkers
 	apiServer.RegisterHealthChecker(health.NewSQLiteChecker(store.DB()))
-	if logStore != nil {
+	if cfg.ClickHouse.Enabled {
 		apiServer.RegisterHealthChecker(health.NewClickHouseChecker(logStore))
+	} else if cfg.Postgres.Enabled {
+		apiServer.RegisterHealthChecker(health.NewPostgresChecker(logStore))
 	}
 
 	// Initialize metrics server (if enabled)
@@ -253,18 +402,20 @@ func runServer(cmd *cobra.Command, args []string) error {
 
 	go func() {
 		sig := <-sigChan
-		log.Printf("received signal %v, shutting down...", sig)
+		slog.Info("received signal, shutting down", "signal", sig.String())
 		cancel()
 	}()
 
 	// Run servers
-	log.Printf("starting blazelog-server %s", config.Version)
-	log.Printf("gRPC listening on %s", cfg.Server.GRPCAddress)
+	slog.Info("starting blazelog-server", "version", config.Version)
+	slog.Info("gRPC listening", "address", cfg.Server.GRPCAddress)
 
 	errChan := make(chan error, 3)
 
 	// Start gRPC server
+	grpcDone := make(chan struct{})
 	go func() {
+		defer close(grpcDone)
 		if err := srv.Run(ctx); err != nil {
 			errChan <- fmt.Errorf("grpc server: %w", err)
 		}
@@ -277,6 +428,21 @@ func runServer(cmd *cobra.Command, args []string) error {
 		}
 	}()
 
+	// Start alert history retention (if configured)
+	if retention.Enabled() {
+		go storage.RunAlertHistoryRetention(ctx, store.AlertHistory(), retention)
+	}
+
+	// Start log retention (ClickHouse only; PostgreSQL drops partitions itself)
+	if logRetention.Interval > 0 {
+		go storage.RunLogRetention(ctx, logStore.Logs(), logRetention)
+	}
+
+	// Start query latency self-monitoring (if configured)
+	if latencyMonitor != nil {
+		go latencyMonitor.Run(ctx)
+	}
+
 	// Start metrics server (if enabled)
 	if metricsServer != nil {
 		go func() {
@@ -289,15 +455,39 @@ func runServer(cmd *cobra.Command, args []string) error {
 	// Wait for shutdown or error
 	select {
 	case <-ctx.Done():
+		shutdownStart := time.Now()
+
+		// Wait for agent streams to drain so no batch arrives after the
+		// log buffer is closed
+		drained := true
+		select {
+		case <-grpcDone:
+			drained = srv.Drained()
+		case <-time.After(grpcStopTimeout):
+			slog.Warn("gRPC server did not stop in time", "timeout", grpcStopTimeout)
+			drained = false
+		}
+
+		_, received, _, _ := srv.Stats()
+		flushCtx, flushCancel := context.WithTimeout(context.Background(), finalFlushTimeout)
+		summary := summarizeShutdown(flushCtx, logBuffer, shutdownStart, drained, received)
+		flushCancel()
+		logShutdownSummary(summary)
+		if path := cfg.Server.ShutdownStatusFile; path != "" {
+			if err := writeShutdownStatus(path, summary); err != nil {
+				slog.Error("write shutdown status", "error", err)
+			}
+		}
+
 		// Gracefully shutdown metrics server
 		if metricsServer != nil {
 			shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
 			defer shutdownCancel()
 			if err := metricsServer.Shutdown(shutdownCtx); err != nil {
-				log.Printf("metrics server shutdown error: %v", err)
+				slog.Error("metrics server shutdown", "error", err)
 			}
 		}
-		log.Printf("server stopped")
+		slog.Info("server stopped")
 	case err := <-errChan:
 		cancel()
 		return err
@@ -307,12 +497,16 @@ func runServer(cmd *cobra.Command, args []string) error {
 }
 
 // initAPIServer initializes the HTTP API server.
-func initAPIServer(cfg *Config, store storage.Storage, logStore storage.LogStorage) (*api.Server, error) {
+func initAPIServer(cfg *Config, store storage.Storage, logStore storage.LogStorage, srv *server.Server, dispatcher *notifier.Dispatcher) (*api.Server, error) {
 	// Get JWT secret
 	jwtSecret := os.Getenv(cfg.Auth.JWTSecretEnv)
 	if jwtSecret == "" {
 		return nil, fmt.Errorf("%s environment variable is required", cfg.Auth.JWTSecretEnv)
 	}
+	jwtPrevious := previousJWTSecrets(os.Getenv(cfg.Auth.JWTPreviousSecretEnv), jwtSecret)
+	if len(jwtPrevious) > 0 {
+		slog.Info("accepting previous JWT secrets during rotation", "count", len(jwtPrevious))
+	}
 
 	// Get CSRF secret (optional, for web UI)
 	csrfSecret := ""
@@ -352,10 +546,18 @@ func initAPIServer(cfg *Config, store storage.Storage, logStore storage.LogStora
 	if err != nil {
 		return nil, fmt.Errorf("parse api.stream_poll_interval: %w", err)
 	}
-
+	streamReorderWindow, err := time.ParseDuration(cfg.API.StreamReorderWindow)
+	if err != nil {
+		return nil, fmt.Errorf("parse api.stream_reorder_window: %w", err)
+	}
+	shareLinkTTL, err := time.ParseDuration(cfg.API.ShareLinkTTL)
+	if err != nil {
+		return nil, fmt.Errorf("parse api.share_link_ttl: %w", err)
+	}
 	apiConfig := &api.Config{
 		Address:            cfg.Server.HTTPAddress,
 		JWTSecret:          []byte(jwtSecret),
+		JWTPreviousSecrets: jwtPrevious,
 		CSRFSecret:         csrfSecret,
 		TrustedOrigins:     cfg.Auth.TrustedOrigins,
 		TrustedProxies:     cfg.Auth.TrustedProxies,
@@ -364,6 +566,7 @@ func initAPIServer(cfg *Config, store storage.Storage, logStore storage.LogStora
 		HTTPTLSEnabled:     cfg.Server.HTTPTLS.Enabled,
 		HTTPTLSCertFile:    cfg.Server.HTTPTLS.CertFile,
 		HTTPTLSKeyFile:     cfg.Server.HTTPTLS.KeyFile,
+		HTTPTLSPolicy:      cfg.Server.HTTPTLS.Policy(),
 		AccessTokenTTL:     accessTTL,
 		RefreshTokenTTL:    refreshTTL,
 		RateLimitPerIP:     cfg.Auth.RateLimitPerIP,
@@ -374,7 +577,29 @@ func initAPIServer(cfg *Config, store storage.Storage, logStore storage.LogStora
 		QueryTimeout:       queryTimeout,
 		StreamMaxDuration:  streamMaxDuration,
 		StreamPollInterval: streamPollInterval,
+		ShareLinkTTL:       shareLinkTTL,
+		ShareRateLimit:     cfg.API.ShareRateLimit,
+		MaxResultRows:      cfg.API.MaxResultRows,
 		Verbose:            cfg.Verbose,
+		EndpointRateLimits: cfg.Auth.EndpointRateLimits,
+		IndexedLabels:      cfg.API.IndexedLabels,
+
+		StreamReorderWindow: streamReorderWindow,
+	}
+	if dispatcher != nil {
+		apiConfig.Notifier = dispatcher
+	}
+	if cfg.API.RawTailEnabled {
+		apiConfig.RawTailer = srv
+		apiConfig.RawTailRateLimit = cfg.API.RawTailRateLimit
+		if cfg.API.RawTailAuditLog != "" {
+			audit, err := ssh.NewJSONAuditLogger(cfg.API.RawTailAuditLog)
+			if err != nil {
+				return nil, fmt.Errorf("raw tail audit log: %w", err)
+			}
+			apiConfig.RawTailAudit = audit
+		}
+		slog.Warn("raw tail endpoint enabled; admins can read unredacted source bytes from agents that allow it")
 	}
 
 	return api.New(apiConfig, store, logStore)
@@ -387,6 +612,25 @@ func initClickHouse(cfg *Config) (*storage.LogBuffer, storage.LogStorage, error)
 	if err != nil {
 		return nil, nil, fmt.Errorf("parse flush_interval: %w", err)
 	}
+	poolStatsInterval, err := time.ParseDuration(cfg.ClickHouse.PoolStatsInterval)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse pool_stats_interval: %w", err)
+	}
+	poolWaitWarn, err := time.ParseDuration(cfg.ClickHouse.PoolWaitWarnThreshold)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse pool_wait_warn_threshold: %w", err)
+	}
+	var asyncInsertBusyTimeout time.Duration
+	if cfg.ClickHouse.AsyncInsertBusyTimeout != "" {
+		asyncInsertBusyTimeout, err = time.ParseDuration(cfg.ClickHouse.AsyncInsertBusyTimeout)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse async_insert_busy_timeout: %w", err)
+		}
+	}
+	schemaRetryDelay, err := time.ParseDuration(cfg.ClickHouse.SchemaRetryDelay)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse schema_retry_delay: %w", err)
+	}
 
 	// Get password from env if specified
 	password := cfg.ClickHouse.Password
@@ -405,6 +649,21 @@ func initClickHouse(cfg *Config) (*storage.LogBuffer, storage.LogStorage, error)
 		DialTimeout:   5 * time.Second,
 		Compression:   true,
 		RetentionDays: cfg.ClickHouse.RetentionDays,
+
+		RetentionRules: cfg.ClickHouse.StorageRetentionRules(),
+
+		PoolStatsInterval:     poolStatsInterval,
+		PoolWaitWarnThreshold: poolWaitWarn,
+
+		SchemaRetries:    cfg.ClickHouse.SchemaRetries,
+		SchemaRetryDelay: schemaRetryDelay,
+
+		Engine:     cfg.ClickHouse.Engine,
+		IndexHints: cfg.ClickHouse.IndexHints(),
+		Cluster:    cfg.ClickHouse.Cluster,
+
+		AsyncInsert:            cfg.ClickHouse.AsyncInsert,
+		AsyncInsertBusyTimeout: asyncInsertBusyTimeout,
 	}
 
 	// Initialize ClickHouse storage
@@ -418,19 +677,94 @@ func initClickHouse(cfg *Config) (*storage.LogBuffer, storage.LogStorage, error)
 		return nil, nil, fmt.Errorf("migrate clickhouse: %w", err)
 	}
 
-	log.Printf("clickhouse initialized at %v (database: %s)", cfg.ClickHouse.Addresses, cfg.ClickHouse.Database)
+	slog.Info("clickhouse initialized", "addresses", cfg.ClickHouse.Addresses, "database", cfg.ClickHouse.Database)
 
 	// Create LogBuffer
 	bufferConfig := &storage.LogBufferConfig{
 		BatchSize:     cfg.ClickHouse.BatchSize,
 		FlushInterval: flushInterval,
 		MaxSize:       cfg.ClickHouse.MaxBufferSize,
+		OnFull:        cfg.ClickHouse.OnFull,
 	}
 	logBuffer := storage.NewLogBuffer(logStorage.Logs(), bufferConfig)
 
 	return logBuffer, logStorage, nil
 }
 
+// clickHouseLogRetention returns the log retention worker settings.
+func clickHouseLogRetention(cfg *Config) (storage.LogRetention, error) {
+	r := storage.LogRetention{MaxAge: time.Duration(cfg.ClickHouse.RetentionDays) * 24 * time.Hour}
+	var err error
+	if r.Interval, err = time.ParseDuration(cfg.ClickHouse.RetentionInterval); err != nil {
+		return r, fmt.Errorf("parse clickhouse.retention_interval: %w", err)
+	}
+	if cfg.ClickHouse.RetentionVerifyTimeout != "" {
+		if r.VerifyTimeout, err = time.ParseDuration(cfg.ClickHouse.RetentionVerifyTimeout); err != nil {
+			return r, fmt.Errorf("parse clickhouse.retention_verify_timeout: %w", err)
+		}
+	}
+	if r.Interval > 0 {
+		slog.Info("log retention enabled", "days", cfg.ClickHouse.RetentionDays, "rules", len(cfg.ClickHouse.RetentionRules),
+			"interval", r.Interval, "verify_timeout", r.VerifyTimeout)
+	}
+	return r, nil
+}
+
+// initPostgres initializes PostgreSQL log storage and returns a LogBuffer and LogStorage.
+func initPostgres(cfg *Config) (*storage.LogBuffer, storage.LogStorage, error) {
+	flushInterval, err := time.ParseDuration(cfg.Postgres.FlushInterval)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse flush_interval: %w", err)
+	}
+
+	// Get DSN from env if specified
+	dsn := cfg.Postgres.DSN
+	if cfg.Postgres.DSNEnv != "" {
+		dsn = os.Getenv(cfg.Postgres.DSNEnv)
+	}
+
+	logStorage := storage.NewPostgresLogStorage(&storage.PostgresConfig{
+		DSN:           dsn,
+		MaxOpenConns:  cfg.Postgres.MaxOpenConns,
+		DialTimeout:   5 * time.Second,
+		RetentionDays: cfg.Postgres.RetentionDays,
+		TimescaleDB:   cfg.Postgres.TimescaleDB,
+	})
+	if err := logStorage.Open(); err != nil {
+		return nil, nil, fmt.Errorf("open postgres: %w", err)
+	}
+
+	if err := logStorage.Migrate(); err != nil {
+		logStorage.Close()
+		return nil, nil, fmt.Errorf("migrate postgres: %w", err)
+	}
+
+	slog.Info("postgres log storage initialized", "timescaledb", cfg.Postgres.TimescaleDB, "retention_days", cfg.Postgres.RetentionDays)
+
+	logBuffer := storage.NewLogBuffer(logStorage.Logs(), &storage.LogBufferConfig{
+		BatchSize:     cfg.Postgres.BatchSize,
+		FlushInterval: flushInterval,
+		MaxSize:       cfg.Postgres.MaxBufferSize,
+		OnFull:        cfg.Postgres.OnFull,
+	})
+
+	return logBuffer, logStorage, nil
+}
+
+// initMemory initializes in-memory log storage for --demo and returns a
+// LogBuffer and LogStorage.
+func initMemory() (*storage.LogBuffer, storage.LogStorage) {
+	logStorage := storage.NewMemoryLogStorage()
+	slog.Warn("demo mode: logs are kept in memory and lost on restart", "max_entries", storage.MemoryMaxEntries)
+
+	// Flush often so ingested logs show up in the UI promptly
+	logBuffer := storage.NewLogBuffer(logStorage.Logs(), &storage.LogBufferConfig{
+		FlushInterval: time.Second,
+	})
+
+	return logBuffer, logStorage
+}
+
 // logBufferAdapter adapts storage.LogBuffer to server.LogBuffer interface.
 type logBufferAdapter struct {
 	buffer *storage.LogBuffer
@@ -465,3 +799,17 @@ func (a *logBufferAdapter) AddBatch(entries []*server.LogRecord) error {
 func (a *logBufferAdapter) Close() error {
 	return a.buffer.Close()
 }
+
+// previousJWTSecrets splits a comma-separated list of retired JWT secrets,
+// skipping blanks and the current secret.
+func previousJWTSecrets(list, current string) [][]byte {
+	var secrets [][]byte
+	for _, secret := range strings.Split(list, ",") {
+		secret = strings.TrimSpace(secret)
+		if secret == "" || secret == current {
+			continue
+		}
+		secrets = append(secrets, []byte(secret))
+	}
+	return secrets
+}
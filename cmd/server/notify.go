@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/alerting"
+	"github.com/good-yellow-bee/blazelog/internal/metrics"
+	"github.com/good-yellow-bee/blazelog/internal/notifier"
+)
+
+// webhookURL returns the configured webhook URL, preferring the env var.
+func (c WebhookChannelConfig) webhookURL() string {
+	if c.WebhookURLEnv != "" {
+		return os.Getenv(c.WebhookURLEnv)
+	}
+	return c.WebhookURL
+}
+
+// initNotifications builds a dispatcher for the configured notification
+// channels. It returns nil when no channel is configured.
+func initNotifications(cfg NotificationsConfig) (*notifier.Dispatcher, error) {
+	var notifiers []notifier.Notifier
+
+	if url := cfg.Slack.webhookURL(); url != "" {
+		n, err := notifier.NewSlackNotifier(notifier.SlackConfig{WebhookURL: url})
+		if err != nil {
+			return nil, fmt.Errorf("slack: %w", err)
+		}
+		notifiers = append(notifiers, n)
+	}
+	if url := cfg.Teams.webhookURL(); url != "" {
+		n, err := notifier.NewTeamsNotifier(notifier.TeamsConfig{WebhookURL: url})
+		if err != nil {
+			return nil, fmt.Errorf("teams: %w", err)
+		}
+		notifiers = append(notifiers, n)
+	}
+	if cfg.Email.SMTPHost != "" {
+		password := ""
+		if cfg.Email.PasswordEnv != "" {
+			password = os.Getenv(cfg.Email.PasswordEnv)
+		}
+		n, err := notifier.NewEmailNotifier(&notifier.EmailConfig{
+			Host:       cfg.Email.SMTPHost,
+			Port:       cfg.Email.SMTPPort,
+			Username:   cfg.Email.Username,
+			Password:   password,
+			From:       cfg.Email.From,
+			Recipients: cfg.Email.Recipients,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("email: %w", err)
+		}
+		notifiers = append(notifiers, n)
+	}
+
+	if len(notifiers) == 0 {
+		return nil, nil
+	}
+
+	dispatcher := notifier.NewDispatcherWithRateLimit(notifier.RateLimitConfig{
+		MaxPerWindow: cfg.RateLimit,
+		Window:       time.Minute,
+		Enabled:      true,
+	})
+	for _, n := range notifiers {
+		dispatcher.Register(n)
+		slog.Info("notification channel enabled", "channel", n.Name())
+	}
+	return dispatcher, nil
+}
+
+// channels returns the names of the configured notification channels.
+func (c NotificationsConfig) channels() []string {
+	var names []string
+	if c.Slack.webhookURL() != "" {
+		names = append(names, "slack")
+	}
+	if c.Teams.webhookURL() != "" {
+		names = append(names, "teams")
+	}
+	if c.Email.SMTPHost != "" {
+		names = append(names, "email")
+	}
+	return names
+}
+
+// initQueryLatencyMonitor builds the self-monitoring alert on slow storage
+// queries, sent through dispatcher to the configured channels.
+func initQueryLatencyMonitor(cfg QueryLatencyAlertConfig, channels NotificationsConfig, dispatcher *notifier.Dispatcher) (*alerting.QueryLatencyMonitor, error) {
+	if dispatcher == nil {
+		return nil, fmt.Errorf("no notification channel is configured")
+	}
+	notify := cfg.Notify
+	if len(notify) == 0 {
+		notify = channels.channels()
+	}
+	for _, name := range notify {
+		if _, ok := dispatcher.Get(name); !ok {
+			return nil, fmt.Errorf("notification channel %s is not configured", name)
+		}
+	}
+
+	// Durations were checked by Validate
+	threshold, _ := time.ParseDuration(cfg.Threshold)
+	window, _ := time.ParseDuration(cfg.Window)
+	interval, _ := time.ParseDuration(cfg.Interval)
+	return alerting.NewQueryLatencyMonitor(alerting.QueryLatencyConfig{
+		Threshold: threshold,
+		Window:    window,
+		Interval:  interval,
+		Notify:    notify,
+		Severity:  alerting.ParseSeverity(cfg.Severity),
+	}, metrics.StorageQueryDuration, dispatcher.Dispatch), nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/storage"
+)
+
+// grpcStopTimeout bounds how long shutdown waits for the gRPC server to stop
+// before closing the log buffer anyway. The server force-closes streams
+// after its own drain timeout, so this is only a safety net.
+const grpcStopTimeout = 30 * time.Second
+
+// finalFlushTimeout bounds the flush of the last partial batch on shutdown.
+const finalFlushTimeout = 15 * time.Second
+
+// shutdownSummary reports what happened to ingested records on shutdown.
+type shutdownSummary struct {
+	StoppedAt       time.Time `json:"stopped_at"`
+	DurationMS      int64     `json:"duration_ms"`
+	GRPCDrained     bool      `json:"grpc_drained"`     // false if agent streams were force-closed
+	EntriesReceived uint64    `json:"entries_received"` // entries received from agents this run
+	FinalFlushed    int64     `json:"final_flushed"`    // records written by the final flush
+	TotalInserted   int64     `json:"total_inserted"`   // records written this run
+	Dropped         int64     `json:"dropped"`          // records dropped by backpressure this run
+	Remaining       int       `json:"remaining"`        // records still buffered (lost) at exit
+	FlushError      string    `json:"flush_error,omitempty"`
+	Clean           bool      `json:"clean"` // no records were lost on the server
+}
+
+// summarizeShutdown flushes and closes the log buffer and builds the
+// shutdown summary. The flush is bounded by ctx; if it fails, Close retries
+// once more. buf may be nil when ClickHouse is disabled.
+func summarizeShutdown(ctx context.Context, buf *storage.LogBuffer, start time.Time, drained bool, received uint64) shutdownSummary {
+	s := shutdownSummary{
+		GRPCDrained:     drained,
+		EntriesReceived: received,
+	}
+
+	if buf != nil {
+		before := buf.Stats()
+		if err := buf.Flush(ctx); err != nil {
+			slog.Warn("flush log buffer on shutdown", "pending", buf.Len(), "error", err)
+		}
+		if err := buf.Close(); err != nil {
+			s.FlushError = err.Error()
+		}
+		after := buf.Stats()
+
+		s.FinalFlushed = after.Inserted - before.Inserted
+		s.TotalInserted = after.Inserted
+		s.Dropped = after.Dropped
+		s.Remaining = after.Pending
+	}
+
+	s.StoppedAt = time.Now().UTC()
+	s.DurationMS = time.Since(start).Milliseconds()
+	// Force-closed streams are not loss on the server side: agents
+	// re-buffer batches that were not sent.
+	s.Clean = s.FlushError == "" && s.Dropped == 0 && s.Remaining == 0
+	return s
+}
+
+// logShutdownSummary logs the summary as a single line.
+func logShutdownSummary(s shutdownSummary) {
+	status := "clean"
+	if !s.Clean {
+		status = "DATA LOSS POSSIBLE"
+	}
+	level := slog.LevelInfo
+	if !s.Clean {
+		level = slog.LevelWarn
+	}
+	slog.Log(context.Background(), level, "shutdown summary",
+		"status", status,
+		"received", s.EntriesReceived,
+		"final_flushed", s.FinalFlushed,
+		"inserted", s.TotalInserted,
+		"dropped", s.Dropped,
+		"remaining", s.Remaining,
+		"grpc_drained", s.GRPCDrained,
+		"duration_ms", s.DurationMS)
+	if s.FlushError != "" {
+		slog.Error("shutdown final flush", "error", s.FlushError)
+	}
+}
+
+// writeShutdownStatus atomically writes the summary as JSON to path.
+func writeShutdownStatus(path string, s shutdownSummary) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode shutdown status: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".shutdown-status-*")
+	if err != nil {
+		return fmt.Errorf("create shutdown status: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write shutdown status: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write shutdown status: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("write shutdown status: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/storage"
+)
+
+// insertOnlyRepo implements the InsertBatch part of storage.LogRepository.
+type insertOnlyRepo struct {
+	storage.LogRepository
+	err error
+}
+
+func (r *insertOnlyRepo) InsertBatch(ctx context.Context, entries []*storage.LogRecord) error {
+	return r.err
+}
+
+func TestSummarizeShutdown(t *testing.T) {
+	tests := []struct {
+		name          string
+		insertErr     error
+		wantFlushed   int64
+		wantRemaining int
+		wantClean     bool
+	}{
+		{name: "final flush succeeds", wantFlushed: 3, wantClean: true},
+		{name: "final flush fails", insertErr: errors.New("clickhouse down"), wantRemaining: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := storage.NewLogBuffer(&insertOnlyRepo{err: tt.insertErr}, &storage.LogBufferConfig{
+				BatchSize:     100,
+				FlushInterval: time.Hour,
+			})
+			if err := buf.AddBatch([]*storage.LogRecord{{}, {}, {}}); err != nil {
+				t.Fatalf("AddBatch: %v", err)
+			}
+
+			s := summarizeShutdown(context.Background(), buf, time.Now(), true, 3)
+
+			if s.FinalFlushed != tt.wantFlushed || s.Remaining != tt.wantRemaining {
+				t.Errorf("flushed/remaining = %d/%d, want %d/%d", s.FinalFlushed, s.Remaining, tt.wantFlushed, tt.wantRemaining)
+			}
+			if s.Clean != tt.wantClean {
+				t.Errorf("clean = %v, want %v", s.Clean, tt.wantClean)
+			}
+			if (s.FlushError != "") != (tt.insertErr != nil) {
+				t.Errorf("flush_error = %q", s.FlushError)
+			}
+		})
+	}
+}
+
+func TestSummarizeShutdown_NoLogBuffer(t *testing.T) {
+	s := summarizeShutdown(context.Background(), nil, time.Now(), false, 7)
+	if !s.Clean || s.GRPCDrained || s.EntriesReceived != 7 {
+		t.Errorf("summary = %+v, want clean, not drained, 7 received", s)
+	}
+}
+
+func TestWriteShutdownStatus(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shutdown.json")
+	want := shutdownSummary{EntriesReceived: 10, TotalInserted: 9, Dropped: 1}
+
+	if err := writeShutdownStatus(path, want); err != nil {
+		t.Fatalf("writeShutdownStatus: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read status: %v", err)
+	}
+	var got shutdownSummary
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("decode status: %v", err)
+	}
+	if got.EntriesReceived != 10 || got.TotalInserted != 9 || got.Dropped != 1 {
+		t.Errorf("status = %+v, want %+v", got, want)
+	}
+}
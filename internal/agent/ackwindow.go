@@ -0,0 +1,165 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/good-yellow-bee/blazelog/internal/metrics"
+	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
+)
+
+// errWindowClosed is returned by acquire once the stream's window is closed.
+var errWindowClosed = errors.New("ack window closed")
+
+// ackWindow caps how many sent batches may be awaiting a server ack on one
+// stream. It keeps each unacked batch's entries so they can be re-buffered
+// if the stream drops, and tracks the highest sequence up to which every
+// batch has been acked. Acks may arrive out of order; the acked sequence
+// only advances over a contiguous run.
+type ackWindow struct {
+	slots chan struct{}
+	done  chan struct{}
+
+	mu      sync.Mutex
+	pending map[uint64][]*blazelogv1.LogEntry
+	early   map[uint64]bool // acked, but above a still-pending sequence
+	acked   uint64
+	closed  bool
+}
+
+// newAckWindow creates a window allowing size unacked batches, or returns
+// nil (no ack tracking) when size <= 0.
+func newAckWindow(size int) *ackWindow {
+	if size <= 0 {
+		return nil
+	}
+	return &ackWindow{
+		slots:   make(chan struct{}, size),
+		done:    make(chan struct{}),
+		pending: make(map[uint64][]*blazelogv1.LogEntry),
+		early:   make(map[uint64]bool),
+	}
+}
+
+// acquire takes a slot for the next batch, waiting while the window is
+// full. A nil window always succeeds.
+func (w *ackWindow) acquire(ctx context.Context) error {
+	if w == nil {
+		return nil
+	}
+	select {
+	case w.slots <- struct{}{}:
+		return nil
+	case <-w.done:
+		return errWindowClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sent records the batch with sequence seq as awaiting an ack. The caller
+// must hold a slot from acquire.
+func (w *ackWindow) sent(seq uint64, entries []*blazelogv1.LogEntry) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	w.pending[seq] = entries
+	metrics.AgentBatchesInFlight.Set(float64(len(w.pending)))
+}
+
+// failed gives back the slot for a batch that could not be sent. Its
+// sequence is never acked, so the acked sequence stops below it; the stream
+// is reconnected after a send error anyway.
+func (w *ackWindow) failed(seq uint64) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.pending[seq]; ok {
+		delete(w.pending, seq)
+		metrics.AgentBatchesInFlight.Set(float64(len(w.pending)))
+	}
+	if !w.closed {
+		<-w.slots
+	}
+}
+
+// ack marks the batch with sequence seq as acknowledged, freeing its slot.
+// Unknown or repeated sequences are ignored.
+func (w *ackWindow) ack(seq uint64) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.pending[seq]; !ok || w.closed {
+		return
+	}
+	delete(w.pending, seq)
+	metrics.AgentBatchesInFlight.Set(float64(len(w.pending)))
+	<-w.slots
+
+	w.early[seq] = true
+	for w.early[w.acked+1] {
+		delete(w.early, w.acked+1)
+		w.acked++
+	}
+}
+
+// ackedSequence returns the highest sequence such that it and every earlier
+// batch have been acked.
+func (w *ackWindow) ackedSequence() uint64 {
+	if w == nil {
+		return 0
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.acked
+}
+
+// inFlight returns the number of batches awaiting an ack.
+func (w *ackWindow) inFlight() int {
+	if w == nil {
+		return 0
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.pending)
+}
+
+// close stops tracking and returns the entries of every unacked batch in
+// sequence order. Senders waiting in acquire get errWindowClosed.
+func (w *ackWindow) close() []*blazelogv1.LogEntry {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	close(w.done)
+
+	seqs := make([]uint64, 0, len(w.pending))
+	for seq := range w.pending {
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+
+	var entries []*blazelogv1.LogEntry
+	for _, seq := range seqs {
+		entries = append(entries, w.pending[seq]...)
+	}
+	w.pending = nil
+	metrics.AgentBatchesInFlight.Set(0)
+	return entries
+}
@@ -0,0 +1,214 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
+)
+
+func testEntries(messages ...string) []*blazelogv1.LogEntry {
+	entries := make([]*blazelogv1.LogEntry, len(messages))
+	for i, m := range messages {
+		entries[i] = &blazelogv1.LogEntry{Message: m}
+	}
+	return entries
+}
+
+func TestAckWindowNilIsUnlimited(t *testing.T) {
+	w := newAckWindow(0)
+	if w != nil {
+		t.Fatal("newAckWindow(0) should return nil")
+	}
+	for i := 0; i < 10; i++ {
+		if err := w.acquire(context.Background()); err != nil {
+			t.Fatalf("acquire on nil window: %v", err)
+		}
+		w.sent(uint64(i+1), nil)
+	}
+	w.ack(1)
+	if got := w.ackedSequence(); got != 0 {
+		t.Errorf("ackedSequence = %d, want 0", got)
+	}
+	if got := w.close(); got != nil {
+		t.Errorf("close = %v, want nil", got)
+	}
+}
+
+func TestAckWindowAckedSequenceContiguous(t *testing.T) {
+	w := newAckWindow(4)
+	for seq := uint64(1); seq <= 4; seq++ {
+		if err := w.acquire(context.Background()); err != nil {
+			t.Fatalf("acquire: %v", err)
+		}
+		w.sent(seq, testEntries("m"))
+	}
+
+	steps := []struct {
+		ack  uint64
+		want uint64
+	}{
+		{2, 0}, // 1 still pending
+		{3, 0},
+		{1, 3}, // closes the gap
+		{1, 3}, // repeated ack ignored
+		{9, 3}, // unknown sequence ignored
+		{4, 4},
+	}
+	for _, s := range steps {
+		w.ack(s.ack)
+		if got := w.ackedSequence(); got != s.want {
+			t.Errorf("after ack(%d): ackedSequence = %d, want %d", s.ack, got, s.want)
+		}
+	}
+	if got := w.inFlight(); got != 0 {
+		t.Errorf("inFlight = %d, want 0", got)
+	}
+}
+
+func TestAckWindowBlocksWhenFull(t *testing.T) {
+	w := newAckWindow(2)
+	for seq := uint64(1); seq <= 2; seq++ {
+		if err := w.acquire(context.Background()); err != nil {
+			t.Fatalf("acquire: %v", err)
+		}
+		w.sent(seq, testEntries("m"))
+	}
+
+	acquired := make(chan error, 1)
+	go func() { acquired <- w.acquire(context.Background()) }()
+
+	select {
+	case <-acquired:
+		t.Fatal("acquire should wait while the window is full")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	w.ack(1)
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Errorf("acquire after ack: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("acquire did not proceed after an ack")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := w.acquire(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("acquire with canceled ctx = %v, want context.Canceled", err)
+	}
+}
+
+func TestAckWindowCloseReturnsUnacked(t *testing.T) {
+	w := newAckWindow(3)
+	batches := map[uint64][]*blazelogv1.LogEntry{
+		1: testEntries("a", "b"),
+		2: testEntries("c"),
+		3: testEntries("d"),
+	}
+	for seq := uint64(1); seq <= 3; seq++ {
+		if err := w.acquire(context.Background()); err != nil {
+			t.Fatalf("acquire: %v", err)
+		}
+		w.sent(seq, batches[seq])
+	}
+	w.ack(2)
+	if err := w.acquire(context.Background()); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	waiting := make(chan error, 1)
+	go func() { waiting <- w.acquire(context.Background()) }()
+
+	got := w.close()
+	want := []string{"a", "b", "d"}
+	if len(got) != len(want) {
+		t.Fatalf("close returned %d entries, want %d", len(got), len(want))
+	}
+	for i, e := range got {
+		if e.Message != want[i] {
+			t.Errorf("entry %d = %q, want %q", i, e.Message, want[i])
+		}
+	}
+
+	select {
+	case err := <-waiting:
+		if !errors.Is(err, errWindowClosed) {
+			t.Errorf("waiting acquire = %v, want errWindowClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("close did not wake a waiting acquire")
+	}
+	if got := w.close(); got != nil {
+		t.Errorf("second close = %v, want nil", got)
+	}
+}
+
+func TestAckWindowFailedFreesSlot(t *testing.T) {
+	w := newAckWindow(1)
+	if err := w.acquire(context.Background()); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	w.sent(1, testEntries("m"))
+	w.failed(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := w.acquire(ctx); err != nil {
+		t.Errorf("acquire after failed send: %v", err)
+	}
+	if got := w.inFlight(); got != 0 {
+		t.Errorf("inFlight = %d, want 0", got)
+	}
+}
+
+// TestClientPipelinedAcks sends more batches than the window holds and
+// checks they all go through as the server acks them.
+func TestClientPipelinedAcks(t *testing.T) {
+	server := newChaosServer(t)
+	defer server.stop()
+
+	cm := NewConnManager(ConnManagerConfig{
+		ServerAddress:  server.addr,
+		MaxInFlight:    2,
+		AgentInfo:      &blazelogv1.AgentInfo{AgentId: "test-agent", Name: "test"},
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     500 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := cm.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer cm.Close()
+
+	client := cm.Client()
+	responses, _ := client.ReceiveResponses(ctx)
+	go func() {
+		for resp := range responses {
+			client.Ack(resp.AckedSequence)
+		}
+	}()
+
+	for i := 0; i < 6; i++ {
+		if err := client.SendBatch(ctx, testEntries("m")); err != nil {
+			t.Fatalf("SendBatch %d: %v", i, err)
+		}
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for client.AckedSequence() < 6 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := client.AckedSequence(); got != 6 {
+		t.Errorf("AckedSequence = %d, want 6", got)
+	}
+	if got := client.InFlight(); got != 0 {
+		t.Errorf("InFlight = %d, want 0", got)
+	}
+}
@@ -11,9 +11,13 @@ import (
 	"time"
 
 	"github.com/good-yellow-bee/blazelog/internal/agent/buffer"
+	"github.com/good-yellow-bee/blazelog/internal/metrics"
 	"github.com/good-yellow-bee/blazelog/internal/models"
+	"github.com/good-yellow-bee/blazelog/internal/parser"
 	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
 	"github.com/good-yellow-bee/blazelog/pkg/config"
+	"golang.org/x/time/rate"
+	"google.golang.org/protobuf/proto"
 )
 
 // Config contains agent configuration.
@@ -23,6 +27,7 @@ type Config struct {
 	ProjectID     string // Project this agent belongs to
 	ServerAddress string
 	BatchSize     int
+	MaxBatchBytes int // Max serialized bytes per batch (0 = count only)
 	FlushInterval time.Duration
 	Sources       []SourceConfig
 	Labels        map[string]string
@@ -35,6 +40,56 @@ type Config struct {
 	HeartbeatInterval time.Duration // Heartbeat interval (default: 15s)
 	ReconnectInitial  time.Duration // Initial reconnect delay (default: 1s)
 	ReconnectMax      time.Duration // Max reconnect delay (default: 30s)
+	RetryWindow       time.Duration // Exit after failing to reconnect for this long (0 = retry forever)
+	MaxMessageSize    int           // Max gRPC message size; larger batches are split (default: 4MB)
+	MaxInFlight       int           // Unacked batches allowed in flight (0 = send without waiting for acks)
+	BackfillRate      int           // Max buffered entries replayed per second (0 = unlimited)
+
+	// CheckpointFile is the state file that keeps file read positions
+	// across restarts; files resume where the last run stopped rather
+	// than at their start position. Empty disables it.
+	CheckpointFile     string
+	CheckpointInterval time.Duration // How often positions are saved (default: 5s)
+
+	// KubernetesPathLabels is the path label template applied to sources
+	// without their own, so pod logs get namespace, pod, uid and container
+	// labels. Files that don't match it get none. Empty disables it.
+	KubernetesPathLabels string
+
+	// ParseWorkers caps concurrent line parsing across all sources; when
+	// every worker is busy, sources stop reading until one frees up.
+	// Zero means no cap (each source parses independently).
+	ParseWorkers int
+
+	// RawTail lets admins stream raw, unredacted bytes of a source through
+	// the server for debugging. Off by default.
+	RawTail bool
+
+	// LevelInference sets levels from message keywords on entries whose
+	// parser leaves them unknown. Nil disables it.
+	LevelInference *parser.LevelInferencer
+
+	// PathNormalization rewrites file paths (FilePath, php_file, ...) to
+	// forward slashes, so Windows and Unix sources filter alike. Nil
+	// leaves paths as parsed.
+	PathNormalization *parser.PathNormalizer
+
+	// Compression is the stream compression: CompressionGzip,
+	// CompressionZstd, or CompressionNone / empty to send uncompressed.
+	// Servers that don't accept zstd get gzip, or no compression.
+	Compression string
+
+	// TransferReportInterval is how often bytes sent before and after
+	// compression and the effective throughput are logged. Zero disables
+	// the log; the byte counters are always exported as metrics.
+	TransferReportInterval time.Duration
+}
+
+// BackfillProgress reports the state of buffered entry replay.
+type BackfillProgress struct {
+	Active    bool   // a throttled replay is in progress
+	Replayed  uint64 // entries replayed since the agent started
+	Remaining int    // entries still in the disk buffer
 }
 
 // Agent is the main BlazeLog agent with reliability features.
@@ -43,16 +98,33 @@ type Agent struct {
 	connMgr     *ConnManager
 	heartbeater *Heartbeater
 	buffer      buffer.Buffer
+	checkpoints *CheckpointStore // nil = positions aren't kept
 	collectors  []*Collector
+	parseLimit  *parseLimiter
 
 	entriesChan chan *models.LogEntry
 	batchBuffer []*blazelogv1.LogEntry
+	batchBytes  int // serialized size of batchBuffer
+
+	// File positions reached by the entries of batchBuffer
+	batchCheckpoints map[string]Checkpoint
+
+	// File positions of sent batches awaiting a server ack, oldest first
+	sentMu          sync.Mutex
+	sentCheckpoints []sentCheckpoints
 
 	// Metrics for heartbeat status
 	entriesProcessed uint64
 	entriesSent      uint64
 	errorCount       uint64
 
+	// Backfill progress
+	backfilling      atomic.Bool
+	entriesReplayed  uint64
+	lastBackfillNote time.Time
+
+	rawTailing atomic.Bool // a raw tail is running
+
 	mu     sync.Mutex
 	closed bool
 }
@@ -75,6 +147,9 @@ func New(cfg *Config) (*Agent, error) {
 	if cfg.ReconnectMax <= 0 {
 		cfg.ReconnectMax = 30 * time.Second
 	}
+	if cfg.CheckpointInterval <= 0 {
+		cfg.CheckpointInterval = DefaultCheckpointInterval
+	}
 
 	// Initialize buffer
 	bufCfg := buffer.DefaultConfig()
@@ -90,11 +165,24 @@ func New(cfg *Config) (*Agent, error) {
 		return nil, fmt.Errorf("create buffer: %w", err)
 	}
 
+	var checkpoints *CheckpointStore
+	if cfg.CheckpointFile != "" {
+		checkpoints, err = OpenCheckpointStore(cfg.CheckpointFile)
+		if err != nil {
+			buf.Close()
+			return nil, fmt.Errorf("open checkpoints: %w", err)
+		}
+	}
+
 	return &Agent{
 		config:      cfg,
 		buffer:      buf,
+		checkpoints: checkpoints,
+		parseLimit:  newParseLimiter(cfg.ParseWorkers),
 		entriesChan: make(chan *models.LogEntry, 1000),
 		batchBuffer: make([]*blazelogv1.LogEntry, 0, cfg.BatchSize),
+
+		batchCheckpoints: make(map[string]Checkpoint),
 	}, nil
 }
 
@@ -110,13 +198,24 @@ func (a *Agent) Run(ctx context.Context) error {
 	// Build agent info for registration
 	agentInfo := a.buildAgentInfo()
 
+	// Count payload bytes across reconnects
+	transfer := metrics.NewTransferCounter(false, metrics.AgentSentBytesTotal)
+	if a.config.TransferReportInterval > 0 {
+		go metrics.ReportTransfers(ctx, transfer, a.config.TransferReportInterval, metrics.AgentCompressionRatio, a.logTransferReport)
+	}
+
 	// Create connection manager
 	connCfg := ConnManagerConfig{
 		ServerAddress:  a.config.ServerAddress,
+		MaxMessageSize: a.config.MaxMessageSize,
+		MaxInFlight:    a.config.MaxInFlight,
 		TLS:            a.config.TLS,
 		AgentInfo:      agentInfo,
+		Compression:    a.config.Compression,
+		Transfer:       transfer,
 		InitialBackoff: a.config.ReconnectInitial,
 		MaxBackoff:     a.config.ReconnectMax,
+		RetryWindow:    a.config.RetryWindow,
 	}
 	a.connMgr = NewConnManager(connCfg)
 	a.connMgr.SetVerbose(a.config.Verbose)
@@ -125,6 +224,7 @@ func (a *Agent) Run(ctx context.Context) error {
 		func(err error) { a.onDisconnected(err) },
 		func(state ConnState) { a.logf("connection state: %s", state) },
 	)
+	a.connMgr.SetUnackedHandler(a.requeueUnacked)
 
 	// Initial connect with retry
 	if err := a.connMgr.Connect(ctx); err != nil {
@@ -140,6 +240,7 @@ func (a *Agent) Run(ctx context.Context) error {
 	}
 	a.heartbeater = NewHeartbeater(a.connMgr, hbCfg, a.buildStatus)
 	a.heartbeater.SetVerbose(a.config.Verbose)
+	a.heartbeater.SetCommandHandler(func(cmd *blazelogv1.ServerCommand) { a.handleCommand(ctx, cmd) })
 	hbReconnectCh := a.heartbeater.Start(ctx)
 
 	// Start reconnection loop
@@ -174,8 +275,13 @@ func (a *Agent) Run(ctx context.Context) error {
 	// Merge collector entries into single channel
 	go a.mergeEntries(ctx)
 
-	// Wait for context cancellation
-	<-ctx.Done()
+	// Wait for context cancellation, or for reconnecting to give up
+	select {
+	case <-ctx.Done():
+	case err := <-a.connMgr.Failed():
+		a.Stop()
+		return fmt.Errorf("reconnect: %w", err)
+	}
 
 	a.logf("shutting down...")
 	return a.Stop()
@@ -223,6 +329,15 @@ func (a *Agent) buildStatus() *blazelogv1.AgentStatus {
 
 // onConnected is called when connection is established.
 func (a *Agent) onConnected(ctx context.Context) {
+	if a.config.BackfillRate > 0 {
+		// Throttled replay runs in the background so live entries keep
+		// flowing; only one replay runs at a time across reconnects.
+		if a.buffer.Len() > 0 && a.backfilling.CompareAndSwap(false, true) {
+			go a.replayThrottled(ctx)
+		}
+		return
+	}
+
 	a.logf("connected, replaying %d buffered entries...", a.buffer.Len())
 
 	// Replay buffered entries with mutex protection to prevent races with batchSender
@@ -251,13 +366,15 @@ func (a *Agent) onConnected(ctx context.Context) {
 		}
 
 		if err := client.SendBatch(ctx, entries); err != nil {
-			a.logf("replay failed, re-buffering: %v", err)
-			a.buffer.Write(entries)
+			unsent := unsentEntries(err, entries)
+			a.logf("replay failed, re-buffering %d entries: %v", len(unsent), err)
+			a.buffer.Write(unsent)
 			break
 		}
 
 		replayed += len(entries)
 		atomic.AddUint64(&a.entriesSent, uint64(len(entries)))
+		atomic.AddUint64(&a.entriesReplayed, uint64(len(entries)))
 	}
 
 	if replayed > 0 {
@@ -265,19 +382,114 @@ func (a *Agent) onConnected(ctx context.Context) {
 	}
 }
 
+// replayThrottled replays buffered entries at no more than BackfillRate
+// entries per second. The agent lock is only held while a single chunk is
+// sent, so live batches are interleaved with (and take priority over) the
+// backlog instead of queuing behind it.
+func (a *Agent) replayThrottled(ctx context.Context) {
+	defer a.backfilling.Store(false)
+
+	chunk := a.config.BatchSize
+	if chunk > a.config.BackfillRate {
+		chunk = a.config.BackfillRate
+	}
+	limiter := rate.NewLimiter(rate.Limit(a.config.BackfillRate), chunk)
+
+	a.logf("connected, replaying %d buffered entries at up to %d/s...", a.buffer.Len(), a.config.BackfillRate)
+
+	replayed := 0
+	for {
+		if err := limiter.WaitN(ctx, chunk); err != nil {
+			return
+		}
+
+		n, more := a.replayChunk(ctx, chunk)
+		replayed += n
+		if !more {
+			break
+		}
+		a.logBackfillProgress(replayed)
+	}
+
+	if replayed > 0 {
+		a.logf("replayed %d buffered entries", replayed)
+	}
+}
+
+// replayChunk sends up to n buffered entries. It reports how many were sent
+// and whether replay should continue.
+func (a *Agent) replayChunk(ctx context.Context, n int) (int, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed || a.buffer.Len() == 0 {
+		return 0, false
+	}
+
+	entries, err := a.buffer.Read(n)
+	if err != nil || len(entries) == 0 {
+		return 0, false
+	}
+
+	client := a.connMgr.Client()
+	if client == nil {
+		// Re-buffer if no client
+		a.buffer.Write(entries)
+		return 0, false
+	}
+
+	if err := client.SendBatch(ctx, entries); err != nil {
+		unsent := unsentEntries(err, entries)
+		a.logf("replay failed, re-buffering %d entries: %v", len(unsent), err)
+		a.buffer.Write(unsent)
+		return 0, false
+	}
+
+	atomic.AddUint64(&a.entriesSent, uint64(len(entries)))
+	atomic.AddUint64(&a.entriesReplayed, uint64(len(entries)))
+	return len(entries), a.buffer.Len() > 0
+}
+
+// logBackfillProgress logs replay progress at most every 10 seconds.
+func (a *Agent) logBackfillProgress(replayed int) {
+	if time.Since(a.lastBackfillNote) < 10*time.Second {
+		return
+	}
+	a.lastBackfillNote = time.Now()
+	a.logf("backfill progress: replayed %d, %d remaining", replayed, a.buffer.Len())
+}
+
 // onDisconnected is called when connection is lost.
 func (a *Agent) onDisconnected(err error) {
 	atomic.AddUint64(&a.errorCount, 1)
 	a.logf("disconnected: %v, buffering logs...", err)
 }
 
+// requeueUnacked buffers entries from batches the server never acked, so
+// they are replayed once the stream is back.
+func (a *Agent) requeueUnacked(entries []*blazelogv1.LogEntry) {
+	if err := a.buffer.Write(entries); err != nil {
+		a.logf("buffer write failed for %d unacked entries: %v", len(entries), err)
+		return
+	}
+	a.logf("re-buffered %d unacked entries", len(entries))
+	a.releaseCheckpoints()
+}
+
 // startCollectors creates and starts all log collectors.
 func (a *Agent) startCollectors(ctx context.Context) error {
 	for _, src := range a.config.Sources {
+		if src.PathLabels == "" {
+			src.PathLabels = a.config.KubernetesPathLabels
+		}
+		src.Checkpoints = a.checkpoints
 		collector, err := NewCollector(src, a.config.Labels)
 		if err != nil {
 			return fmt.Errorf("create collector for %s: %w", src.Name, err)
 		}
+		collector.limiter = a.parseLimit
+		collector.levels = a.config.LevelInference
+		collector.paths = a.config.PathNormalization
 
 		if err := collector.Start(ctx); err != nil {
 			return fmt.Errorf("start collector for %s: %w", src.Name, err)
@@ -318,6 +530,15 @@ func (a *Agent) batchSender(ctx context.Context) {
 	ticker := time.NewTicker(a.config.FlushInterval)
 	defer ticker.Stop()
 
+	// Saves file positions; a nil channel when they aren't kept
+	var saveTick <-chan time.Time
+	if a.checkpoints != nil {
+		saveTicker := time.NewTicker(a.config.CheckpointInterval)
+		defer saveTicker.Stop()
+		saveTick = saveTicker.C
+		defer a.saveCheckpoints()
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -331,21 +552,55 @@ func (a *Agent) batchSender(ctx context.Context) {
 				return
 			}
 
-			protoEntry := ToProtoLogEntry(entry)
-			a.batchBuffer = append(a.batchBuffer, protoEntry)
-
-			if len(a.batchBuffer) >= a.config.BatchSize {
-				a.flushBatch(ctx)
+			var cp *Checkpoint
+			if a.checkpoints != nil {
+				if c, ok := a.checkpoints.take(entry); ok {
+					cp = &c
+				}
 			}
+			a.addToBatch(ctx, ToProtoLogEntry(entry), cp)
 
 		case <-ticker.C:
 			if len(a.batchBuffer) > 0 {
 				a.flushBatch(ctx)
 			}
+
+		case <-saveTick:
+			a.saveCheckpoints()
 		}
 	}
 }
 
+// saveCheckpoints writes the file positions handed off so far.
+func (a *Agent) saveCheckpoints() {
+	if err := a.checkpoints.Save(); err != nil {
+		a.logf("save checkpoints: %v", err)
+	}
+}
+
+// addToBatch appends an entry to the current batch, flushing when either the
+// entry count or the serialized byte cap is reached. cp, if not nil, is the
+// file position the entry was read up to.
+func (a *Agent) addToBatch(ctx context.Context, entry *blazelogv1.LogEntry, cp *Checkpoint) {
+	size := proto.Size(entry)
+
+	// Flush first if this entry would push the batch past the byte cap
+	if a.config.MaxBatchBytes > 0 && len(a.batchBuffer) > 0 && a.batchBytes+size > a.config.MaxBatchBytes {
+		a.flushBatch(ctx)
+	}
+
+	a.batchBuffer = append(a.batchBuffer, entry)
+	a.batchBytes += size
+	if cp != nil {
+		a.batchCheckpoints[cp.FilePath] = *cp
+	}
+
+	if len(a.batchBuffer) >= a.config.BatchSize ||
+		(a.config.MaxBatchBytes > 0 && a.batchBytes >= a.config.MaxBatchBytes) {
+		a.flushBatch(ctx)
+	}
+}
+
 // flushBatch sends the current batch to the server or buffers on failure.
 func (a *Agent) flushBatch(ctx context.Context) {
 	if len(a.batchBuffer) == 0 {
@@ -354,6 +609,9 @@ func (a *Agent) flushBatch(ctx context.Context) {
 
 	batch := a.batchBuffer
 	a.batchBuffer = make([]*blazelogv1.LogEntry, 0, a.config.BatchSize)
+	a.batchBytes = 0
+	checkpoints := a.batchCheckpoints
+	a.batchCheckpoints = make(map[string]Checkpoint)
 
 	// Acquire lock to prevent race with buffer replay in onConnected
 	a.mu.Lock()
@@ -364,11 +622,15 @@ func (a *Agent) flushBatch(ctx context.Context) {
 		client := a.connMgr.Client()
 		if client != nil {
 			if err := client.SendBatch(ctx, batch); err != nil {
-				a.logf("send failed, buffering %d entries: %v", len(batch), err)
+				unsent := unsentEntries(err, batch)
+				a.logf("send failed, buffering %d entries: %v", len(unsent), err)
 				atomic.AddUint64(&a.errorCount, 1)
-				// Buffer on failure
-				if err := a.buffer.Write(batch); err != nil {
+				// Buffer on failure; sub-batches already sent are re-sent
+				// from the ack window if they go unacked
+				if err := a.buffer.Write(unsent); err != nil {
 					a.logf("buffer write failed: %v", err)
+				} else {
+					a.commitCheckpoints(checkpoints)
 				}
 				// Trigger reconnect
 				a.connMgr.TriggerReconnect()
@@ -377,6 +639,7 @@ func (a *Agent) flushBatch(ctx context.Context) {
 
 			atomic.AddUint64(&a.entriesSent, uint64(len(batch)))
 			a.logf("sent batch of %d entries", len(batch))
+			a.holdCheckpoints(client, client.Sequence(), checkpoints)
 			return
 		}
 	}
@@ -386,9 +649,75 @@ func (a *Agent) flushBatch(ctx context.Context) {
 		a.logf("buffer write failed: %v", err)
 	} else {
 		a.logf("buffered %d entries (disconnected)", len(batch))
+		a.commitCheckpoints(checkpoints)
+	}
+}
+
+// commitCheckpoints records the file positions of a batch that was acked or
+// buffered.
+func (a *Agent) commitCheckpoints(checkpoints map[string]Checkpoint) {
+	if a.checkpoints != nil {
+		a.checkpoints.commit(checkpoints)
 	}
 }
 
+// sentCheckpoints are the file positions of a batch sent on client whose
+// last message has sequence seq.
+type sentCheckpoints struct {
+	client      *Client
+	seq         uint64
+	checkpoints map[string]Checkpoint
+}
+
+// holdCheckpoints keeps the file positions of a sent batch until the server
+// acks it, so a batch lost with the stream is read again after a restart.
+// Without ack tracking there is nothing to wait for and they are committed
+// right away.
+func (a *Agent) holdCheckpoints(client *Client, seq uint64, checkpoints map[string]Checkpoint) {
+	if a.checkpoints == nil || len(checkpoints) == 0 {
+		return
+	}
+	if !client.TracksAcks() {
+		a.commitCheckpoints(checkpoints)
+		return
+	}
+	a.sentMu.Lock()
+	defer a.sentMu.Unlock()
+	a.sentCheckpoints = append(a.sentCheckpoints, sentCheckpoints{client: client, seq: seq, checkpoints: checkpoints})
+}
+
+// ackCheckpoints commits the file positions of the batches sent on client
+// up to its acked sequence. An ack that arrives ahead of an earlier batch's
+// commits nothing, since a position covers that batch's entries too.
+// Batches of an earlier stream left in front are dropped: their entries
+// were re-buffered or will be read again.
+func (a *Agent) ackCheckpoints(client *Client) {
+	acked := client.AckedSequence()
+	a.sentMu.Lock()
+	defer a.sentMu.Unlock()
+	for len(a.sentCheckpoints) > 0 {
+		sent := a.sentCheckpoints[0]
+		if sent.client == client {
+			if sent.seq > acked {
+				return
+			}
+			a.commitCheckpoints(sent.checkpoints)
+		}
+		a.sentCheckpoints = a.sentCheckpoints[1:]
+	}
+}
+
+// releaseCheckpoints commits the file positions of every sent batch still
+// awaiting an ack, once their entries were re-buffered to disk.
+func (a *Agent) releaseCheckpoints() {
+	a.sentMu.Lock()
+	defer a.sentMu.Unlock()
+	for _, sent := range a.sentCheckpoints {
+		a.commitCheckpoints(sent.checkpoints)
+	}
+	a.sentCheckpoints = nil
+}
+
 // handleResponses processes responses from the server.
 func (a *Agent) handleResponses(ctx context.Context) {
 	for {
@@ -414,13 +743,13 @@ func (a *Agent) handleResponses(ctx context.Context) {
 					// Channel closed, reconnect
 					goto reconnect
 				}
-				a.handleResponse(resp)
+				a.handleResponse(ctx, client, resp)
 			case err, ok := <-errs:
 				if !ok {
 					goto reconnect
 				}
 				a.logf("stream error: %v", err)
-				a.connMgr.TriggerReconnect()
+				a.connMgr.StreamFailed(err)
 				goto reconnect
 			}
 		}
@@ -436,14 +765,22 @@ func (a *Agent) handleResponses(ctx context.Context) {
 }
 
 // handleResponse processes a single response from the server.
-func (a *Agent) handleResponse(resp *blazelogv1.StreamResponse) {
+func (a *Agent) handleResponse(ctx context.Context, client *Client, resp *blazelogv1.StreamResponse) {
+	if resp.Error != "" {
+		a.logf("server failed to process batch %d: %s", resp.AckedSequence, resp.Error)
+	}
+	// Error responses still ack: the server has given up on the batch, and
+	// re-sending it would fail the same way.
+	client.Ack(resp.AckedSequence)
+	a.ackCheckpoints(client)
+
 	if resp.Command != nil {
-		a.handleCommand(resp.Command)
+		a.handleCommand(ctx, resp.Command)
 	}
 }
 
 // handleCommand handles server commands.
-func (a *Agent) handleCommand(cmd *blazelogv1.ServerCommand) {
+func (a *Agent) handleCommand(ctx context.Context, cmd *blazelogv1.ServerCommand) {
 	switch cmd.Type {
 	case blazelogv1.CommandType_COMMAND_TYPE_UNSPECIFIED:
 		a.logf("received unspecified command")
@@ -459,6 +796,8 @@ func (a *Agent) handleCommand(cmd *blazelogv1.ServerCommand) {
 	case blazelogv1.CommandType_COMMAND_TYPE_RELOAD_CONFIG:
 		a.logf("received reload config command")
 		// TODO: Reload configuration
+	case blazelogv1.CommandType_COMMAND_TYPE_RAW_TAIL:
+		a.startRawTail(ctx, cmd.Parameters)
 	default:
 		a.logf("received unknown command: %v", cmd.Type)
 	}
@@ -479,8 +818,13 @@ func (a *Agent) Stop() error {
 		c.Stop()
 	}
 
-	// Close connection manager
+	// Close connection manager, keeping unacked batches for the next run
 	if a.connMgr != nil {
+		if client := a.connMgr.Client(); client != nil {
+			if unacked := client.TakeUnacked(); len(unacked) > 0 {
+				a.requeueUnacked(unacked)
+			}
+		}
 		a.connMgr.Close()
 	}
 
@@ -500,6 +844,20 @@ func (a *Agent) BufferLen() int {
 	return a.buffer.Len()
 }
 
+// Backfill returns the progress of buffered entry replay.
+func (a *Agent) Backfill() BackfillProgress {
+	return BackfillProgress{
+		Active:    a.backfilling.Load(),
+		Replayed:  atomic.LoadUint64(&a.entriesReplayed),
+		Remaining: a.BufferLen(),
+	}
+}
+
+// ParseQueueDepth returns the number of sources waiting for a parse worker.
+func (a *Agent) ParseQueueDepth() int {
+	return a.parseLimit.depth()
+}
+
 // Stats returns current agent statistics.
 func (a *Agent) Stats() (processed, sent, errors uint64) {
 	return atomic.LoadUint64(&a.entriesProcessed),
@@ -508,6 +866,16 @@ func (a *Agent) Stats() (processed, sent, errors uint64) {
 }
 
 // logf logs a message if verbose mode is enabled.
+// logTransferReport logs the bytes sent in one report interval, warning
+// when compression is enabled but barely shrinks the payloads.
+func (a *Agent) logTransferReport(r metrics.TransferReport) {
+	log.Printf("[agent] sent %d bytes (%d on the wire, ratio %.2f) in %s, %.1f KB/s effective, %.1f KB/s on the wire",
+		r.Uncompressed, r.Compressed, r.Ratio(), r.Elapsed.Round(time.Second), r.Throughput()/1024, r.WireThroughput()/1024)
+	if a.config.Compression != "" && a.config.Compression != CompressionNone && r.Ratio() < metrics.MinUsefulCompressionRatio {
+		log.Printf("[agent] WARNING: %s compression ratio is %.2f; the log content is already compact, consider server.compression: none to save CPU", a.config.Compression, r.Ratio())
+	}
+}
+
 func (a *Agent) logf(format string, args ...interface{}) {
 	if a.config.Verbose {
 		log.Printf("[agent] "+format, args...)
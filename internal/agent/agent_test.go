@@ -1,17 +1,29 @@
 package agent
 
 import (
+	"bytes"
+	stdgzip "compress/gzip"
 	"context"
+	"errors"
+	"fmt"
 	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/good-yellow-bee/blazelog/internal/encoding"
+	"github.com/good-yellow-bee/blazelog/internal/metrics"
 	"github.com/good-yellow-bee/blazelog/internal/models"
+	"github.com/good-yellow-bee/blazelog/internal/parser"
 	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestToProtoLogLevel(t *testing.T) {
@@ -47,6 +59,12 @@ func TestToProtoLogType(t *testing.T) {
 		{models.LogTypeMagento, blazelogv1.LogType_LOG_TYPE_MAGENTO},
 		{models.LogTypePrestaShop, blazelogv1.LogType_LOG_TYPE_PRESTASHOP},
 		{models.LogTypeWordPress, blazelogv1.LogType_LOG_TYPE_WORDPRESS},
+		{models.LogTypeSyslog, blazelogv1.LogType_LOG_TYPE_SYSLOG},
+		{models.LogTypeJSON, blazelogv1.LogType_LOG_TYPE_JSON},
+		{models.LogTypePostgres, blazelogv1.LogType_LOG_TYPE_POSTGRES},
+		{models.LogTypeMySQL, blazelogv1.LogType_LOG_TYPE_MYSQL},
+		{models.LogTypeCustom, blazelogv1.LogType_LOG_TYPE_CUSTOM},
+		{models.LogTypeUnparsed, blazelogv1.LogType_LOG_TYPE_UNPARSED},
 		{models.LogTypeUnknown, blazelogv1.LogType_LOG_TYPE_UNSPECIFIED},
 	}
 
@@ -119,6 +137,10 @@ func TestStringToLogType(t *testing.T) {
 		{"magento", models.LogTypeMagento},
 		{"prestashop", models.LogTypePrestaShop},
 		{"wordpress", models.LogTypeWordPress},
+		{"syslog", models.LogTypeSyslog},
+		{"json", models.LogTypeJSON},
+		{"postgres", models.LogTypePostgres},
+		{"mysql", models.LogTypeMySQL},
 		{"unknown", models.LogTypeUnknown},
 	}
 
@@ -181,6 +203,229 @@ func TestCollector(t *testing.T) {
 	}
 }
 
+func TestCollectorGlobPathLabels(t *testing.T) {
+	tmpDir := t.TempDir()
+	logLine := `192.168.1.1 - - [14/Dec/2024:10:00:00 +0000] "GET /index.html HTTP/1.1" 200 1234 "-" "Mozilla/5.0"`
+	for _, dir := range []string{"shop/prod", "blog/staging"} {
+		if err := os.MkdirAll(filepath.Join(tmpDir, dir), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(tmpDir, dir, "app.log"), []byte(logLine+"\n"), 0644); err != nil {
+			t.Fatalf("write log file: %v", err)
+		}
+	}
+
+	src := SourceConfig{
+		Name:       "apps",
+		Type:       "nginx",
+		Path:       filepath.Join(tmpDir, "*", "*", "app.log"),
+		PathLabels: filepath.ToSlash(tmpDir) + "/{service}/{env}/",
+	}
+	collector, err := NewCollector(src, map[string]string{"env": "global", "dc": "eu"})
+	if err != nil {
+		t.Fatalf("NewCollector: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := collector.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer collector.Stop()
+
+	got := make(map[string]string)
+	for len(got) < 2 {
+		select {
+		case entry := <-collector.Entries():
+			if entry.Labels["dc"] != "eu" || entry.Labels["source"] != "apps" {
+				t.Errorf("Labels = %v, want dc=eu source=apps", entry.Labels)
+			}
+			got[entry.Labels["service"]] = entry.Labels["env"]
+		case <-ctx.Done():
+			t.Fatalf("timeout waiting for entries, got %v", got)
+		}
+	}
+
+	want := map[string]string{"shop": "prod", "blog": "staging"}
+	for service, env := range want {
+		if got[service] != env {
+			t.Errorf("service %s: env = %q, want %q", service, got[service], env)
+		}
+	}
+}
+
+func TestCollectorGlobRescan(t *testing.T) {
+	tmpDir := t.TempDir()
+	logLine := `192.168.1.1 - - [14/Dec/2024:10:00:00 +0000] "GET /index.html HTTP/1.1" 200 1234 "-" "Mozilla/5.0"`
+
+	// A "**" pattern finds new files only by rescanning
+	src := SourceConfig{
+		Name:           "workers",
+		Type:           "nginx",
+		Path:           filepath.Join(tmpDir, "**", "*.log"),
+		Follow:         true,
+		RescanInterval: 50 * time.Millisecond,
+	}
+	collector, err := NewCollector(src, map[string]string{"dc": "eu"})
+	if err != nil {
+		t.Fatalf("NewCollector: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := collector.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer collector.Stop()
+
+	worker := filepath.Join(tmpDir, "w1", "worker.log")
+	if err := os.MkdirAll(filepath.Dir(worker), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(worker, []byte(logLine+"\n"), 0644); err != nil {
+		t.Fatalf("write log file: %v", err)
+	}
+
+	select {
+	case entry := <-collector.Entries():
+		if entry.FilePath != worker || entry.Source != "workers" || entry.Type != models.LogTypeNginx || entry.Labels["dc"] != "eu" {
+			t.Errorf("entry = %s %s %s %v, want the new file's line with the source's name, type and labels",
+				entry.FilePath, entry.Source, entry.Type, entry.Labels)
+		}
+	case <-ctx.Done():
+		t.Fatal("timeout waiting for the new file's entry")
+	}
+}
+
+func TestCollectorTruncatesLongLines(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "test.log")
+	// The cut line no longer parses as an nginx access line
+	logLine := `192.168.1.1 - - [14/Dec/2024:10:00:00 +0000] "GET /index.html?q=` + strings.Repeat("x", 200) + ` HTTP/1.1" 200 1234 "-" "Mozilla/5.0"`
+	if err := os.WriteFile(logFile, []byte(logLine+"\n"), 0644); err != nil {
+		t.Fatalf("write log file: %v", err)
+	}
+
+	src := SourceConfig{Name: "test-nginx", Type: "nginx", Path: logFile, MaxLineLength: 100}
+	collector, err := NewCollector(src, nil)
+	if err != nil {
+		t.Fatalf("NewCollector: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := collector.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer collector.Stop()
+
+	select {
+	case entry := <-collector.Entries():
+		want := fmt.Sprintf("%s...[truncated %d bytes]", logLine[:100], len(logLine)-100)
+		if entry.Raw != want || entry.Message != want {
+			t.Errorf("Raw = %q, want the first 100 bytes of the line and the marker", entry.Raw)
+		}
+		if entry.Fields["truncated"] != true || entry.Fields["line_size"] != len(logLine) {
+			t.Errorf("Fields = %v, want truncated and line_size %d", entry.Fields, len(logLine))
+		}
+	case <-ctx.Done():
+		t.Fatal("timeout waiting for the truncated entry")
+	}
+}
+
+func TestCollectorCompressedSource(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "access.log.1.gz")
+	logLine := `192.168.1.1 - - [14/Dec/2024:10:00:00 +0000] "GET /index.html HTTP/1.1" 200 1234 "-" "Mozilla/5.0"`
+	var buf bytes.Buffer
+	zw := stdgzip.NewWriter(&buf)
+	zw.Write([]byte(logLine + "\n" + logLine + "\n"))
+	zw.Close()
+	if err := os.WriteFile(logFile, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("write log file: %v", err)
+	}
+
+	// Read once to the end even though the source follows
+	src := SourceConfig{Name: "backfill", Type: "nginx", Path: logFile, Follow: true}
+	collector, err := NewCollector(src, nil)
+	if err != nil {
+		t.Fatalf("NewCollector: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := collector.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer collector.Stop()
+
+	var n int
+	for entry := range collector.Entries() {
+		if entry.GetFieldInt("status") != 200 {
+			t.Errorf("entry %d: status = %v, want 200", n, entry.Fields["status"])
+		}
+		n++
+	}
+	if ctx.Err() != nil {
+		t.Fatal("collector didn't stop at the end of the archive")
+	}
+	if n != 2 {
+		t.Errorf("got %d entries, want 2", n)
+	}
+}
+
+func TestCollectorParseErrors(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "access.log")
+	badLine := "not an access log line"
+	goodLine := `192.168.1.1 - - [14/Dec/2024:10:00:00 +0000] "GET /index.html HTTP/1.1" 200 1234 "-" "Mozilla/5.0"`
+	if err := os.WriteFile(logFile, []byte(badLine+"\n"+goodLine+"\n"), 0644); err != nil {
+		t.Fatalf("write log file: %v", err)
+	}
+
+	tests := []struct {
+		policy   string
+		wantRaws []string
+	}{
+		{policy: "", wantRaws: []string{goodLine}},
+		{policy: ParseErrorDrop, wantRaws: []string{goodLine}},
+		{policy: ParseErrorForwardRaw, wantRaws: []string{badLine, goodLine}},
+	}
+
+	for _, tt := range tests {
+		t.Run("policy "+tt.policy, func(t *testing.T) {
+			src := SourceConfig{Name: "parse-errors-" + tt.policy, Type: "nginx", Path: logFile, OnParseError: tt.policy}
+			collector, err := NewCollector(src, map[string]string{"env": "test"})
+			if err != nil {
+				t.Fatalf("NewCollector: %v", err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			if err := collector.Start(ctx); err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+			defer collector.Stop()
+
+			var raws []string
+			for entry := range collector.Entries() {
+				raws = append(raws, entry.Raw)
+				if entry.Raw != badLine {
+					continue
+				}
+				if entry.Level != models.LevelUnknown || entry.Type != models.LogTypeUnparsed {
+					t.Errorf("unparsed entry level, type = %s, %s, want unknown, unparsed", entry.Level, entry.Type)
+				}
+				if entry.Message != badLine || entry.GetFieldString("parse_error") == "" {
+					t.Errorf("unparsed entry Message = %q, Fields = %v", entry.Message, entry.Fields)
+				}
+				if entry.Labels["env"] != "test" || entry.Labels["source"] != src.Name {
+					t.Errorf("unparsed entry Labels = %v", entry.Labels)
+				}
+			}
+			if strings.Join(raws, "\n") != strings.Join(tt.wantRaws, "\n") {
+				t.Errorf("entries = %q, want %q", raws, tt.wantRaws)
+			}
+			if got := testutil.ToFloat64(metrics.AgentParseErrorsTotal.WithLabelValues(src.Name)); got != 1 {
+				t.Errorf("parse errors = %v, want 1", got)
+			}
+		})
+	}
+}
+
 func TestCollectorUnknownParser(t *testing.T) {
 	src := SourceConfig{
 		Name: "test",
@@ -193,11 +438,96 @@ func TestCollectorUnknownParser(t *testing.T) {
 	}
 }
 
+func TestSourceParser(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      SourceConfig
+		line     string
+		wantName string
+		wantMsg  string
+		wantErr  bool
+	}{
+		{
+			name:     "registered parser",
+			src:      SourceConfig{Type: "json"},
+			line:     `{"msg":"hello"}`,
+			wantName: "json",
+			wantMsg:  "hello",
+		},
+		{
+			name:     "json with custom keys",
+			src:      SourceConfig{Type: "json", MessageKey: "message"},
+			line:     `{"message":"hello"}`,
+			wantName: "json",
+			wantMsg:  "hello",
+		},
+		{
+			name:     "regex",
+			src:      SourceConfig{Type: "regex", Pattern: `^\[(?P<level>\w+)\] (?P<message>.*)$`},
+			line:     "[ERROR] disk full",
+			wantName: "regex",
+			wantMsg:  "disk full",
+		},
+		{
+			name:    "invalid regex",
+			src:     SourceConfig{Type: "regex", Pattern: `(?P<message>.*`},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := sourceParser(tt.src)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("sourceParser() error = %v", err)
+			}
+			if p.Name() != tt.wantName {
+				t.Errorf("parser = %s, want %s", p.Name(), tt.wantName)
+			}
+			entry, err := p.Parse(tt.line)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if entry.Message != tt.wantMsg {
+				t.Errorf("message = %q, want %q", entry.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestSourceParserThrownOrigin(t *testing.T) {
+	for _, typ := range []string{"magento", "prestashop", "wordpress"} {
+		t.Run(typ, func(t *testing.T) {
+			p, err := sourceParser(SourceConfig{Type: typ, ThrownOrigin: parser.ThrownOriginFill})
+			if err != nil {
+				t.Fatalf("sourceParser() error = %v", err)
+			}
+			opts, ok := p.(interface{ Options() *parser.Options })
+			if !ok {
+				t.Fatalf("parser %T has no options", p)
+			}
+			if got := opts.Options().ThrownOrigin; got != parser.ThrownOriginFill {
+				t.Errorf("ThrownOrigin = %q, want %q", got, parser.ThrownOriginFill)
+			}
+			if !opts.Options().IncludeRaw {
+				t.Error("parser lost the default options")
+			}
+		})
+	}
+}
+
 // mockLogServer implements LogServiceServer for testing.
 type mockLogServer struct {
 	blazelogv1.UnimplementedLogServiceServer
 	registrations chan *blazelogv1.RegisterRequest
 	batches       chan *blazelogv1.LogBatch
+	compressors   string // advertised in the Register response header when set
 }
 
 func newMockLogServer() *mockLogServer {
@@ -209,6 +539,9 @@ func newMockLogServer() *mockLogServer {
 
 func (s *mockLogServer) Register(ctx context.Context, req *blazelogv1.RegisterRequest) (*blazelogv1.RegisterResponse, error) {
 	s.registrations <- req
+	if s.compressors != "" {
+		_ = grpc.SetHeader(ctx, metadata.Pairs(encoding.CompressorsHeader, s.compressors))
+	}
 	return &blazelogv1.RegisterResponse{
 		Success: true,
 		AgentId: "test-agent-123",
@@ -347,6 +680,297 @@ func TestClientSendBatch(t *testing.T) {
 	}
 }
 
+func TestClientSendBatchSplitsOversized(t *testing.T) {
+	ctx := context.Background()
+	const maxMsgSize = 64 * 1024
+
+	var lc net.ListenConfig
+	lis, err := lc.Listen(ctx, "tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer lis.Close()
+
+	// Server enforces the same limit the client is configured with
+	server := grpc.NewServer(grpc.MaxRecvMsgSize(maxMsgSize))
+	mockServer := newMockLogServer()
+	blazelogv1.RegisterLogServiceServer(server, mockServer)
+
+	go server.Serve(lis)
+	defer server.Stop()
+
+	client, err := NewClient(lis.Addr().String(), nil)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer client.Close()
+	client.agentID = "test-agent"
+	client.SetMaxMessageSize(maxMsgSize)
+
+	if err := client.StartStream(ctx); err != nil {
+		t.Fatalf("StartStream: %v", err)
+	}
+
+	// 10 stack-trace sized records (~200KB) plus one that can never fit
+	var entries []*blazelogv1.LogEntry
+	for i := 0; i < 10; i++ {
+		entries = append(entries, &blazelogv1.LogEntry{Message: strings.Repeat("#", 20*1024), LineNumber: int64(i)})
+	}
+	entries = append(entries, &blazelogv1.LogEntry{Message: strings.Repeat("x", 2*maxMsgSize)})
+
+	if err := client.SendBatch(ctx, entries); err != nil {
+		t.Fatalf("SendBatch: %v", err)
+	}
+
+	received := 0
+	batches := 0
+	for received < 10 {
+		select {
+		case batch := <-mockServer.batches:
+			if size := proto.Size(batch); size > maxMsgSize {
+				t.Errorf("batch size = %d bytes, exceeds limit %d", size, maxMsgSize)
+			}
+			for _, e := range batch.Entries {
+				if e.LineNumber != int64(received) {
+					t.Errorf("entry line = %d, want %d (order preserved)", e.LineNumber, received)
+				}
+				received++
+			}
+			batches++
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timeout: received %d of 10 entries", received)
+		}
+	}
+	if batches < 2 {
+		t.Errorf("batches = %d, want batch to be split", batches)
+	}
+}
+
+// failingStream accepts a number of sends and then fails every one after.
+type failingStream struct {
+	blazelogv1.LogService_StreamLogsClient
+	accept int
+	sent   []*blazelogv1.LogBatch
+}
+
+func (s *failingStream) Send(batch *blazelogv1.LogBatch) error {
+	if len(s.sent) >= s.accept {
+		return errors.New("stream broken")
+	}
+	s.sent = append(s.sent, batch)
+	return nil
+}
+
+func TestClientSendBatchPartialFailure(t *testing.T) {
+	const maxMsgSize = 64 * 1024
+	stream := &failingStream{accept: 1}
+	client := &Client{agentID: "test-agent", stream: stream}
+	client.SetMaxMessageSize(maxMsgSize)
+
+	var entries []*blazelogv1.LogEntry
+	for i := 0; i < 10; i++ {
+		entries = append(entries, &blazelogv1.LogEntry{Message: strings.Repeat("#", 20*1024), LineNumber: int64(i)})
+	}
+	entries = append(entries, &blazelogv1.LogEntry{Message: strings.Repeat("x", 2*maxMsgSize)})
+
+	dropped := testutil.ToFloat64(metrics.AgentOversizedEntriesDroppedTotal)
+	err := client.SendBatch(context.Background(), entries)
+
+	var sendErr *SendError
+	if !errors.As(err, &sendErr) {
+		t.Fatalf("SendBatch error = %v, want *SendError", err)
+	}
+	if len(stream.sent) != 1 {
+		t.Fatalf("sent %d batches, want 1", len(stream.sent))
+	}
+	sent := len(stream.sent[0].Entries)
+	if got, want := len(sendErr.Unsent), 10-sent; got != want {
+		t.Errorf("unsent = %d entries, want %d (sent chunk excluded, oversized dropped)", got, want)
+	}
+	if sendErr.Unsent[0].LineNumber != int64(sent) {
+		t.Errorf("first unsent line = %d, want %d", sendErr.Unsent[0].LineNumber, sent)
+	}
+	if got := unsentEntries(err, entries); len(got) != len(sendErr.Unsent) {
+		t.Errorf("unsentEntries = %d entries, want %d", len(got), len(sendErr.Unsent))
+	}
+	if got := testutil.ToFloat64(metrics.AgentOversizedEntriesDroppedTotal) - dropped; got != 1 {
+		t.Errorf("oversized entries dropped = %v, want 1", got)
+	}
+}
+
+func TestClientSendBatchGzipCountsBytes(t *testing.T) {
+	ctx := context.Background()
+
+	var lc net.ListenConfig
+	lis, err := lc.Listen(ctx, "tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer lis.Close()
+
+	received := metrics.NewTransferCounter(true, nil)
+	server := grpc.NewServer(grpc.StatsHandler(received))
+	mockServer := newMockLogServer()
+	blazelogv1.RegisterLogServiceServer(server, mockServer)
+
+	go server.Serve(lis)
+	defer server.Stop()
+
+	sent := metrics.NewTransferCounter(false, nil)
+	client, err := NewClient(lis.Addr().String(), nil,
+		grpc.WithDefaultCallOptions(grpc.UseCompressor(gzip.Name)),
+		grpc.WithStatsHandler(sent))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer client.Close()
+	client.agentID = "test-agent"
+
+	if err := client.StartStream(ctx); err != nil {
+		t.Fatalf("StartStream: %v", err)
+	}
+
+	var entries []*blazelogv1.LogEntry
+	for i := 0; i < 50; i++ {
+		entries = append(entries, &blazelogv1.LogEntry{Message: "GET /index.html 200 " + strings.Repeat("a", 200)})
+	}
+	if err := client.SendBatch(ctx, entries); err != nil {
+		t.Fatalf("SendBatch: %v", err)
+	}
+	select {
+	case <-mockServer.batches:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for batch")
+	}
+
+	got := sent.Totals()
+	if got.Uncompressed == 0 || got.Ratio() < 2 {
+		t.Errorf("sent totals = %+v (ratio %.2f), want repetitive batch compressed at least 2x", got, got.Ratio())
+	}
+	// Stats for a received message are reported before it is handed to the
+	// server handler, so they are final once the batch arrived
+	if in := received.Totals(); in != got {
+		t.Errorf("received totals = %+v, want %+v", in, got)
+	}
+}
+
+func TestClientNegotiatesZstd(t *testing.T) {
+	ctx := context.Background()
+
+	var lc net.ListenConfig
+	lis, err := lc.Listen(ctx, "tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer lis.Close()
+
+	server := grpc.NewServer()
+	mockServer := newMockLogServer()
+	mockServer.compressors = "gzip,zstd"
+	blazelogv1.RegisterLogServiceServer(server, mockServer)
+
+	go server.Serve(lis)
+	defer server.Stop()
+
+	sent := metrics.NewTransferCounter(false, nil)
+	client, err := NewClient(lis.Addr().String(), nil, grpc.WithStatsHandler(sent))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer client.Close()
+	client.SetCompression(CompressionZstd)
+
+	if _, err := client.Register(ctx, &blazelogv1.AgentInfo{Name: "test"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if got := client.Compression(); got != CompressionZstd {
+		t.Fatalf("Compression() = %q, want zstd", got)
+	}
+	if err := client.StartStream(ctx); err != nil {
+		t.Fatalf("StartStream: %v", err)
+	}
+
+	var entries []*blazelogv1.LogEntry
+	for i := 0; i < 50; i++ {
+		entries = append(entries, &blazelogv1.LogEntry{Message: "GET /index.html 200 " + strings.Repeat("a", 200)})
+	}
+	if err := client.SendBatch(ctx, entries); err != nil {
+		t.Fatalf("SendBatch: %v", err)
+	}
+	select {
+	case batch := <-mockServer.batches:
+		if len(batch.Entries) != len(entries) {
+			t.Errorf("received %d entries, want %d", len(batch.Entries), len(entries))
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for batch")
+	}
+
+	if got := sent.Totals(); got.Ratio() < 2 {
+		t.Errorf("sent totals = %+v (ratio %.2f), want repetitive batch compressed at least 2x", got, got.Ratio())
+	}
+}
+
+func TestClientCompressionFallback(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   string
+		server []string
+		expect string
+	}{
+		{"accepted", CompressionZstd, []string{CompressionGzip, CompressionZstd}, CompressionZstd},
+		{"gzip fallback", CompressionZstd, []string{CompressionGzip}, CompressionGzip},
+		{"not accepted", CompressionGzip, nil, CompressionNone},
+		{"none requested", CompressionNone, []string{CompressionGzip}, CompressionNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{serverCompressors: tt.server}
+			c.SetCompression(tt.want)
+			if got := c.Compression(); got != tt.expect {
+				t.Errorf("Compression() = %q, want %q", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestTransferReport(t *testing.T) {
+	prev := metrics.TransferTotals{Uncompressed: 1000, Compressed: 400}
+	cur := metrics.TransferTotals{Uncompressed: 11000, Compressed: 2400}
+	r := metrics.TransferReport{TransferTotals: cur.Sub(prev), Elapsed: 10 * time.Second}
+
+	if r.Uncompressed != 10000 || r.Compressed != 2000 {
+		t.Fatalf("delta = %+v, want 10000/2000", r.TransferTotals)
+	}
+	if r.Ratio() != 5 {
+		t.Errorf("Ratio() = %v, want 5", r.Ratio())
+	}
+	if r.Throughput() != 1000 || r.WireThroughput() != 200 {
+		t.Errorf("throughput = %v / %v, want 1000 / 200", r.Throughput(), r.WireThroughput())
+	}
+	if (metrics.TransferTotals{}).Ratio() != 0 {
+		t.Error("Ratio() of an idle interval should be 0")
+	}
+}
+
+func TestSplitBatch(t *testing.T) {
+	small := &blazelogv1.LogEntry{Message: "ok"}
+	size := entryWireSize(small)
+	big := &blazelogv1.LogEntry{Message: strings.Repeat("x", 100)}
+
+	chunks, oversized := splitBatch([]*blazelogv1.LogEntry{small, small, big, small}, 2*size)
+	if len(chunks) != 2 || len(chunks[0]) != 2 || len(chunks[1]) != 1 {
+		t.Errorf("chunks = %v, want [2 1]", chunks)
+	}
+	if len(oversized) != 1 || oversized[0] != big {
+		t.Errorf("oversized = %v, want big entry", oversized)
+	}
+
+	if chunks, _ := splitBatch(nil, size); len(chunks) != 0 {
+		t.Errorf("chunks = %v, want none for empty batch", chunks)
+	}
+}
+
 func TestAgentConfig(t *testing.T) {
 	cfg := &Config{
 		ID:            "test-id",
@@ -370,3 +994,48 @@ func TestAgentConfig(t *testing.T) {
 		t.Errorf("FlushInterval = %v, want 1s", agent.config.FlushInterval)
 	}
 }
+
+func TestAgentBatchByteCap(t *testing.T) {
+	entry := &blazelogv1.LogEntry{Message: strings.Repeat("x", 100)}
+	size := proto.Size(entry)
+
+	tests := []struct {
+		name          string
+		maxBatchBytes int
+		wantPending   int // entries left in the current batch after 5 adds
+		wantBuffered  int // entries flushed to the disk buffer
+	}{
+		{name: "count only", maxBatchBytes: 0, wantPending: 5, wantBuffered: 0},
+		{name: "flush at cap", maxBatchBytes: 2 * size, wantPending: 1, wantBuffered: 4},
+		{name: "flush before overflow", maxBatchBytes: 2*size + 1, wantPending: 1, wantBuffered: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent, err := New(&Config{
+				ServerAddress: "localhost:9443",
+				BatchSize:     10,
+				MaxBatchBytes: tt.maxBatchBytes,
+				BufferDir:     t.TempDir(),
+			})
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			defer agent.buffer.Close()
+
+			for i := 0; i < 5; i++ {
+				agent.addToBatch(context.Background(), entry, nil)
+			}
+
+			if len(agent.batchBuffer) != tt.wantPending {
+				t.Errorf("pending = %d, want %d", len(agent.batchBuffer), tt.wantPending)
+			}
+			if agent.batchBytes != tt.wantPending*size {
+				t.Errorf("batchBytes = %d, want %d", agent.batchBytes, tt.wantPending*size)
+			}
+			if got := agent.buffer.Len(); got != tt.wantBuffered {
+				t.Errorf("buffered = %d, want %d", got, tt.wantBuffered)
+			}
+		})
+	}
+}
@@ -53,6 +53,21 @@ func (b *Backoff) Next() time.Duration {
 		delay = float64(b.Max)
 	}
 
+	b.attempt++
+	return b.jitter(delay)
+}
+
+// MaxDelay returns the maximum delay with jitter applied, for failures that
+// call for backing off as far as allowed straight away. It does not change
+// the attempt counter.
+func (b *Backoff) MaxDelay() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.jitter(float64(b.Max))
+}
+
+// jitter applies the jitter factor to delay.
+func (b *Backoff) jitter(delay float64) time.Duration {
 	// Apply jitter: delay * (1 + random(-jitter, +jitter))
 	if b.Jitter > 0 {
 		jitterRange := delay * b.Jitter
@@ -64,7 +79,6 @@ func (b *Backoff) Next() time.Duration {
 		delay = float64(b.Initial)
 	}
 
-	b.attempt++
 	return time.Duration(delay)
 }
 
@@ -83,3 +83,16 @@ func TestBackoff_NoJitter(t *testing.T) {
 		}
 	}
 }
+
+func TestBackoff_MaxDelay(t *testing.T) {
+	b := NewBackoffWithConfig(100*time.Millisecond, time.Second, 2.0, 0.5)
+	for i := 0; i < 20; i++ {
+		d := b.MaxDelay()
+		if d < 500*time.Millisecond || d > 1500*time.Millisecond {
+			t.Fatalf("MaxDelay() = %v, want within [500ms, 1.5s]", d)
+		}
+	}
+	if b.Attempt() != 0 {
+		t.Errorf("MaxDelay changed attempt to %d", b.Attempt())
+	}
+}
@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	_ "github.com/good-yellow-bee/blazelog/internal/encoding/zstd" // register zstd
+	"github.com/good-yellow-bee/blazelog/internal/parser"
+	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
+	"google.golang.org/grpc/encoding"
+	_ "google.golang.org/grpc/encoding/gzip" // register gzip
+	"google.golang.org/protobuf/proto"
+)
+
+// nginxBatch builds a serialized batch of n parsed nginx access lines, as
+// the agent sends them.
+func nginxBatch(b *testing.B, n int) []byte {
+	b.Helper()
+
+	p := parser.NewNginxAccessParser(nil)
+	baseTime := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	batch := &blazelogv1.LogBatch{AgentId: "bench-agent", ProjectId: "bench-project"}
+	for i := 0; i < n; i++ {
+		line := fmt.Sprintf("10.0.%d.%d - - [%s] \"GET %s HTTP/1.1\" %d %d \"https://example.com/\" \"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36\"",
+			i/254%254, i%254+1,
+			baseTime.Add(time.Duration(i)*time.Second).Format("02/Jan/2006:15:04:05 -0700"),
+			[]string{"/", "/index.html", "/api/users?id=" + fmt.Sprint(i), "/static/app.js", "/checkout"}[i%5],
+			[]int{200, 200, 200, 304, 404, 500}[i%6],
+			512+i%4096,
+		)
+		entry, err := p.Parse(line)
+		if err != nil {
+			b.Fatalf("parse: %v", err)
+		}
+		entry.Source = "nginx"
+		entry.FilePath = "/var/log/nginx/access.log"
+		entry.LineNumber = int64(i)
+		entry.Labels = map[string]string{"env": "production", "host": "web-1"}
+		batch.Entries = append(batch.Entries, ToProtoLogEntry(entry))
+	}
+
+	data, err := proto.Marshal(batch)
+	if err != nil {
+		b.Fatalf("marshal: %v", err)
+	}
+	return data
+}
+
+// BenchmarkStreamCompression compresses a 500-line nginx batch with each
+// stream compressor and reports the compression ratio.
+func BenchmarkStreamCompression(b *testing.B) {
+	data := nginxBatch(b, 500)
+
+	for _, name := range []string{CompressionGzip, CompressionZstd} {
+		b.Run(name, func(b *testing.B) {
+			c := encoding.GetCompressor(name)
+			var buf bytes.Buffer
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				buf.Reset()
+				w, err := c.Compress(&buf)
+				if err != nil {
+					b.Fatalf("compress: %v", err)
+				}
+				if _, err := w.Write(data); err != nil {
+					b.Fatalf("write: %v", err)
+				}
+				if err := w.Close(); err != nil {
+					b.Fatalf("close: %v", err)
+				}
+			}
+			b.ReportMetric(float64(len(data))/float64(buf.Len()), "ratio")
+			b.ReportMetric(float64(buf.Len()), "wire_bytes")
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"sync"
 
+	"github.com/good-yellow-bee/blazelog/internal/metrics"
 	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
 	"google.golang.org/protobuf/proto"
 )
@@ -156,7 +157,9 @@ func (b *DiskBuffer) Write(entries []*blazelogv1.LogEntry) error {
 
 		entrySize := int64(4 + len(data))
 
-		// Check if buffer would exceed max size (accounting for consumed space)
+		// Check if buffer would exceed max size (accounting for consumed space).
+		// A full buffer drops its oldest entries, so the newest logs survive
+		// a long outage.
 		activeSize := b.size - b.readOffset
 		if activeSize+entrySize > b.config.MaxSize {
 			// Drop oldest entries to make room
@@ -362,6 +365,7 @@ func (b *DiskBuffer) dropOldest(needed int64) error {
 	// Update offset instead of rewriting file
 	b.readOffset += bytesToDrop
 	b.count -= entriesToDrop
+	metrics.AgentBufferDroppedEntriesTotal.Add(float64(entriesToDrop))
 
 	// Compact if threshold exceeded
 	if b.size > 0 && float64(b.readOffset)/float64(b.size) > b.config.CompactThreshold {
@@ -1,11 +1,14 @@
 package buffer
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/good-yellow-bee/blazelog/internal/metrics"
 	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -147,10 +150,11 @@ func TestDiskBuffer_MaxSize(t *testing.T) {
 		t.Fatalf("NewDiskBuffer: %v", err)
 	}
 	defer buf.Close()
+	droppedBefore := testutil.ToFloat64(metrics.AgentBufferDroppedEntriesTotal)
 
 	// Write many entries - should drop oldest
 	for i := 0; i < 20; i++ {
-		entry := createTestEntry("overflow test entry")
+		entry := createTestEntry(fmt.Sprintf("overflow test entry %02d", i))
 		if err := buf.Write([]*blazelogv1.LogEntry{entry}); err != nil {
 			t.Fatalf("Write %d: %v", i, err)
 		}
@@ -168,6 +172,22 @@ func TestDiskBuffer_MaxSize(t *testing.T) {
 	if buf.Len() == 20 {
 		t.Error("expected fewer than 20 entries due to size limit")
 	}
+
+	// Every dropped entry is counted, and the newest entries are kept
+	dropped := testutil.ToFloat64(metrics.AgentBufferDroppedEntriesTotal) - droppedBefore
+	if int(dropped) != 20-buf.Len() {
+		t.Errorf("dropped metric = %v, want %d", dropped, 20-buf.Len())
+	}
+	entries, err := buf.Read(buf.Len())
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if got := entries[len(entries)-1].Message; got != "overflow test entry 19" {
+		t.Errorf("last entry = %q, want the newest", got)
+	}
+	if got, want := entries[0].Message, fmt.Sprintf("overflow test entry %02d", int(dropped)); got != want {
+		t.Errorf("first entry = %q, want %q", got, want)
+	}
 }
 
 func TestDiskBuffer_Clear(t *testing.T) {
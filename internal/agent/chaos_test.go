@@ -11,6 +11,8 @@ import (
 	"github.com/good-yellow-bee/blazelog/internal/agent/buffer"
 	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // chaosServer wraps a mock gRPC server with chaos injection capabilities.
@@ -26,6 +28,7 @@ type chaosServer struct {
 	failStream    bool
 	failHeartbeat bool
 	failAfter     int   // Fail after N batches
+	registerErr   error // Returned by Register when set
 	batchCount    int32 // Atomic counter
 
 	// Tracking
@@ -62,6 +65,9 @@ func (s *chaosServer) Register(ctx context.Context, req *blazelogv1.RegisterRequ
 
 	s.registrations++ // Count all attempts
 
+	if s.registerErr != nil {
+		return nil, s.registerErr
+	}
 	if s.failRegister {
 		return &blazelogv1.RegisterResponse{
 			Success:      false,
@@ -139,6 +145,12 @@ func (s *chaosServer) setFailRegister(fail bool) {
 	s.failRegister = fail
 }
 
+func (s *chaosServer) setRegisterErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.registerErr = err
+}
+
 func (s *chaosServer) setFailStream(fail bool) { //nolint:unused // kept for future chaos testing
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -243,6 +255,67 @@ func TestConnManagerRetry(t *testing.T) {
 	}
 }
 
+// TestConnManagerNotRetryable tests that a status retrying can't fix fails
+// without retries.
+func TestConnManagerNotRetryable(t *testing.T) {
+	server := newChaosServer(t)
+	server.setRegisterErr(status.Error(codes.Unauthenticated, "bad token"))
+	defer server.stop()
+
+	cfg := ConnManagerConfig{
+		ServerAddress:  server.addr,
+		AgentInfo:      &blazelogv1.AgentInfo{AgentId: "test-agent", Name: "test"},
+		InitialBackoff: 50 * time.Millisecond,
+		MaxBackoff:     200 * time.Millisecond,
+	}
+
+	cm := NewConnManager(cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	err := cm.Connect(ctx)
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated error, got %v", err)
+	}
+	if regs, _, _ := server.stats(); regs != 1 {
+		t.Errorf("expected 1 registration attempt, got %d", regs)
+	}
+}
+
+// TestConnManagerRetryWindow tests that Connect gives up once it has been
+// failing for longer than the retry window.
+func TestConnManagerRetryWindow(t *testing.T) {
+	server := newChaosServer(t)
+	server.setRegisterErr(status.Error(codes.Unavailable, "restarting"))
+	defer server.stop()
+
+	cfg := ConnManagerConfig{
+		ServerAddress:  server.addr,
+		AgentInfo:      &blazelogv1.AgentInfo{AgentId: "test-agent", Name: "test"},
+		InitialBackoff: 20 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+		RetryWindow:    200 * time.Millisecond,
+	}
+
+	cm := NewConnManager(cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	err := cm.Connect(ctx)
+	if err == nil || ctx.Err() != nil {
+		t.Fatalf("expected retry window error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < cfg.RetryWindow {
+		t.Errorf("gave up after %v, before the %v window", elapsed, cfg.RetryWindow)
+	}
+	if regs, _, _ := server.stats(); regs < 2 {
+		t.Errorf("expected retries within the window, got %d attempts", regs)
+	}
+}
+
 // TestBackoffProgression tests that backoff delays increase exponentially.
 func TestBackoffProgression(t *testing.T) {
 	b := NewBackoffWithConfig(100*time.Millisecond, 1*time.Second, 2.0, 0)
@@ -506,3 +579,69 @@ func TestStreamDisconnectTriggersBuffer(t *testing.T) {
 		t.Errorf("expected at least 1 batch, got %d", batches)
 	}
 }
+
+// TestBackfillRateThrottlesReplay tests that buffered entries are replayed in
+// the background at no more than the configured rate.
+func TestBackfillRateThrottlesReplay(t *testing.T) {
+	server := newChaosServer(t)
+	defer server.stop()
+
+	agent, err := New(&Config{
+		ServerAddress: server.addr,
+		BatchSize:     10,
+		BufferDir:     t.TempDir(),
+		BackfillRate:  20,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer agent.buffer.Close()
+
+	entries := make([]*blazelogv1.LogEntry, 40)
+	for i := range entries {
+		entries[i] = &blazelogv1.LogEntry{Message: "backlog"}
+	}
+	if err := agent.buffer.Write(entries); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	agent.connMgr = NewConnManager(ConnManagerConfig{
+		ServerAddress:  server.addr,
+		AgentInfo:      &blazelogv1.AgentInfo{AgentId: "test-agent"},
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     500 * time.Millisecond,
+	})
+	defer agent.connMgr.Close()
+	if err := agent.connMgr.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	start := time.Now()
+	agent.onConnected(ctx)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("onConnected blocked for %v, want background replay", elapsed)
+	}
+	if !agent.Backfill().Active {
+		t.Error("expected backfill to be active")
+	}
+
+	for agent.Backfill().Active {
+		select {
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for backfill")
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+
+	// 10 entries of burst, then 30 more at 20/s
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("replay took %v, want throttling to >= 1s", elapsed)
+	}
+	progress := agent.Backfill()
+	if progress.Replayed != 40 || progress.Remaining != 0 {
+		t.Errorf("progress = %+v, want 40 replayed, 0 remaining", progress)
+	}
+}
@@ -0,0 +1,164 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/models"
+	"github.com/good-yellow-bee/blazelog/internal/tailer"
+)
+
+// DefaultCheckpointInterval is how often read positions are saved when
+// Config.CheckpointInterval is unset.
+const DefaultCheckpointInterval = 5 * time.Second
+
+// Checkpoint is how far a file has been read.
+type Checkpoint struct {
+	FilePath string `json:"file_path"`
+	Inode    uint64 `json:"inode"`  // 0 if unknown
+	Offset   int64  `json:"offset"` // just past the last line handed off
+}
+
+// CheckpointStore keeps file read positions in a local state file, so that
+// after a restart tailing resumes where it stopped. An entry's position is
+// committed once its batch has been acked by the server (or sent, without
+// ack tracking) or written to the disk buffer; entries still in flight at
+// shutdown are read again on the next start.
+type CheckpointStore struct {
+	path string
+
+	mu      sync.Mutex
+	saved   map[string]Checkpoint // committed, by file path
+	pending map[*models.LogEntry]Checkpoint
+	dirty   bool
+}
+
+// OpenCheckpointStore loads the positions saved in the state file at path.
+// A missing file is an empty store.
+func OpenCheckpointStore(path string) (*CheckpointStore, error) {
+	s := &CheckpointStore{
+		path:    path,
+		saved:   make(map[string]Checkpoint),
+		pending: make(map[*models.LogEntry]Checkpoint),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read state file: %w", err)
+	}
+	var checkpoints []Checkpoint
+	if err := json.Unmarshal(data, &checkpoints); err != nil {
+		return nil, fmt.Errorf("parse state file %s: %w", path, err)
+	}
+	for _, cp := range checkpoints {
+		s.saved[cp.FilePath] = cp
+	}
+	return s, nil
+}
+
+// Resume returns the saved offset of a file, for tailer.Options.Resume.
+// A file whose inode changed was rotated since and is read from its
+// beginning.
+func (s *CheckpointStore) Resume(path string, info os.FileInfo) (int64, bool) {
+	s.mu.Lock()
+	cp, ok := s.saved[path]
+	s.mu.Unlock()
+	if !ok {
+		return 0, false
+	}
+	if inode := tailer.Inode(info); cp.Inode != 0 && inode != 0 && inode != cp.Inode {
+		return 0, true
+	}
+	return cp.Offset, true
+}
+
+// Get returns the committed position of a file.
+func (s *CheckpointStore) Get(path string) (Checkpoint, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp, ok := s.saved[path]
+	return cp, ok
+}
+
+// track records the position reached by the line(s) of entry.
+func (s *CheckpointStore) track(entry *models.LogEntry, cp Checkpoint) {
+	s.mu.Lock()
+	s.pending[entry] = cp
+	s.mu.Unlock()
+}
+
+// take returns and forgets the position tracked for entry.
+func (s *CheckpointStore) take(entry *models.LogEntry) (Checkpoint, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp, ok := s.pending[entry]
+	delete(s.pending, entry)
+	return cp, ok
+}
+
+// commit marks positions as handed off, to be written by the next Save.
+func (s *CheckpointStore) commit(checkpoints map[string]Checkpoint) {
+	if len(checkpoints) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for path, cp := range checkpoints {
+		s.saved[path] = cp
+	}
+	s.dirty = true
+}
+
+// Save writes the committed positions to the state file if they changed
+// since the last save. The file is replaced atomically.
+func (s *CheckpointStore) Save() error {
+	s.mu.Lock()
+	if !s.dirty {
+		s.mu.Unlock()
+		return nil
+	}
+	checkpoints := make([]Checkpoint, 0, len(s.saved))
+	for _, cp := range s.saved {
+		checkpoints = append(checkpoints, cp)
+	}
+	s.dirty = false
+	s.mu.Unlock()
+
+	if err := s.write(checkpoints); err != nil {
+		// Retry at the next save
+		s.mu.Lock()
+		s.dirty = true
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// write replaces the state file with checkpoints.
+func (s *CheckpointStore) write(checkpoints []Checkpoint) error {
+	sort.Slice(checkpoints, func(i, j int) bool { return checkpoints[i].FilePath < checkpoints[j].FilePath })
+	data, err := json.MarshalIndent(checkpoints, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode checkpoints: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("create state directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("write state file: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("replace state file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
+	"github.com/good-yellow-bee/blazelog/internal/tailer"
+)
+
+func TestCheckpointStoreSaveAndResume(t *testing.T) {
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(logFile, []byte("line 1\nline 2\n"), 0644); err != nil {
+		t.Fatalf("write log file: %v", err)
+	}
+	info, err := os.Stat(logFile)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	stateFile := filepath.Join(dir, "state", "checkpoints.json")
+
+	store, err := OpenCheckpointStore(stateFile)
+	if err != nil {
+		t.Fatalf("OpenCheckpointStore: %v", err)
+	}
+	if _, ok := store.Resume(logFile, info); ok {
+		t.Error("Resume() ok in an empty store")
+	}
+	store.commit(map[string]Checkpoint{logFile: {FilePath: logFile, Inode: tailer.Inode(info), Offset: 7}})
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	reopened, err := OpenCheckpointStore(stateFile)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if offset, ok := reopened.Resume(logFile, info); !ok || offset != 7 {
+		t.Errorf("Resume() = %d, %v, want 7, true", offset, ok)
+	}
+
+	// A new file at the same path, as after a rename rotation
+	if tailer.Inode(info) != 0 {
+		rotated := filepath.Join(dir, "app.log.1")
+		if err := os.Rename(logFile, rotated); err != nil {
+			t.Fatalf("rename: %v", err)
+		}
+		if err := os.WriteFile(logFile, []byte("line 3\n"), 0644); err != nil {
+			t.Fatalf("write log file: %v", err)
+		}
+		info, err := os.Stat(logFile)
+		if err != nil {
+			t.Fatalf("stat: %v", err)
+		}
+		if offset, ok := reopened.Resume(logFile, info); !ok || offset != 0 {
+			t.Errorf("Resume() after rotation = %d, %v, want 0, true", offset, ok)
+		}
+	}
+
+	if err := os.WriteFile(stateFile, []byte("{"), 0600); err != nil {
+		t.Fatalf("write state file: %v", err)
+	}
+	if _, err := OpenCheckpointStore(stateFile); err == nil {
+		t.Error("OpenCheckpointStore() with a corrupt state file: want error")
+	}
+}
+
+func TestAgentCommitsCheckpointsOnFlush(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "checkpoints.json")
+	agent, err := New(&Config{
+		ServerAddress:  "localhost:9443",
+		BatchSize:      2,
+		BufferDir:      t.TempDir(),
+		CheckpointFile: stateFile,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer agent.buffer.Close()
+
+	entry := &blazelogv1.LogEntry{Message: "test"}
+	for offset := int64(1); offset <= 3; offset++ {
+		agent.addToBatch(context.Background(), entry, &Checkpoint{FilePath: "/var/log/app.log", Offset: offset})
+	}
+
+	// The first batch of two was buffered; the third entry is pending
+	if cp, ok := agent.checkpoints.Get("/var/log/app.log"); !ok || cp.Offset != 2 {
+		t.Errorf("committed = %+v, %v, want offset 2", cp, ok)
+	}
+	agent.flushBatch(context.Background())
+	if cp, _ := agent.checkpoints.Get("/var/log/app.log"); cp.Offset != 3 {
+		t.Errorf("committed after flush = %+v, want offset 3", cp)
+	}
+}
+
+func TestCollectorResumesAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "access.log")
+	stateFile := filepath.Join(dir, "checkpoints.json")
+	writeLines := func(from, to int) {
+		f, err := os.OpenFile(logFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatalf("open log file: %v", err)
+		}
+		defer f.Close()
+		for i := from; i <= to; i++ {
+			fmt.Fprintf(f, "192.168.1.1 - - [14/Dec/2024:10:00:00 +0000] \"GET /%d HTTP/1.1\" 200 1234 \"-\" \"Mozilla/5.0\"\n", i)
+		}
+	}
+	// run collects the entries of one agent run, handing off the first
+	// handOff of them, and returns their request paths.
+	run := func(start string, handOff int) []string {
+		store, err := OpenCheckpointStore(stateFile)
+		if err != nil {
+			t.Fatalf("OpenCheckpointStore: %v", err)
+		}
+		src := SourceConfig{Name: "nginx", Type: "nginx", Path: logFile, StartPosition: start, Checkpoints: store}
+		collector, err := NewCollector(src, nil)
+		if err != nil {
+			t.Fatalf("NewCollector: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if err := collector.Start(ctx); err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+		defer collector.Stop()
+
+		var paths []string
+		for entry := range collector.Entries() {
+			if cp, ok := store.take(entry); ok && len(paths) < handOff {
+				store.commit(map[string]Checkpoint{cp.FilePath: cp})
+			}
+			paths = append(paths, entry.GetFieldString("request_uri"))
+		}
+		if err := store.Save(); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		return paths
+	}
+
+	writeLines(1, 3)
+	if got := strings.Join(run(tailer.StartBeginning, 2), " "); got != "/1 /2 /3" {
+		t.Fatalf("first run = %q, want /1 /2 /3", got)
+	}
+
+	// The saved position wins over start_position end. /3 was never
+	// handed off, so it is read again.
+	writeLines(4, 5)
+	if got := strings.Join(run(tailer.StartEnd, 3), " "); got != "/3 /4 /5" {
+		t.Fatalf("second run = %q, want /3 /4 /5", got)
+	}
+
+	writeLines(6, 6)
+	if got := strings.Join(run(tailer.StartEnd, 1), " "); got != "/6" {
+		t.Errorf("third run = %q, want /6", got)
+	}
+}
+
+// sendTracked records a batch as sent on client without a stream, as
+// SendBatch does, and returns its sequence.
+func sendTracked(t *testing.T, client *Client) uint64 {
+	t.Helper()
+	if err := client.window.acquire(context.Background()); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	seq := client.sequence + 1
+	client.sequence = seq
+	client.window.sent(seq, []*blazelogv1.LogEntry{{Message: "test"}})
+	return seq
+}
+
+func TestAgentCommitsCheckpointsOnAck(t *testing.T) {
+	agent, err := New(&Config{
+		ServerAddress:  "localhost:9443",
+		BufferDir:      t.TempDir(),
+		CheckpointFile: filepath.Join(t.TempDir(), "checkpoints.json"),
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer agent.buffer.Close()
+
+	const path = "/var/log/app.log"
+	client := &Client{window: newAckWindow(4)}
+	seq := sendTracked(t, client)
+	agent.holdCheckpoints(client, seq, map[string]Checkpoint{path: {FilePath: path, Offset: 10}})
+
+	if _, ok := agent.checkpoints.Get(path); ok {
+		t.Fatal("checkpoint committed before the ack")
+	}
+	agent.handleResponse(context.Background(), client, &blazelogv1.StreamResponse{AckedSequence: seq})
+	if cp, ok := agent.checkpoints.Get(path); !ok || cp.Offset != 10 {
+		t.Errorf("committed after ack = %+v, %v, want offset 10", cp, ok)
+	}
+
+	// Batches lost with the stream are committed once re-buffered
+	seq = sendTracked(t, client)
+	agent.holdCheckpoints(client, seq, map[string]Checkpoint{path: {FilePath: path, Offset: 20}})
+	agent.requeueUnacked(client.TakeUnacked())
+	if cp, _ := agent.checkpoints.Get(path); cp.Offset != 20 {
+		t.Errorf("committed after requeue = %+v, want offset 20", cp)
+	}
+}
+
+func TestAgentOutOfOrderAckKeepsCheckpoint(t *testing.T) {
+	agent, err := New(&Config{
+		ServerAddress:  "localhost:9443",
+		BufferDir:      t.TempDir(),
+		CheckpointFile: filepath.Join(t.TempDir(), "checkpoints.json"),
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer agent.buffer.Close()
+
+	const path = "/var/log/app.log"
+	client := &Client{window: newAckWindow(4)}
+	first := sendTracked(t, client)
+	agent.holdCheckpoints(client, first, map[string]Checkpoint{path: {FilePath: path, Offset: 10}})
+	second := sendTracked(t, client)
+	agent.holdCheckpoints(client, second, map[string]Checkpoint{path: {FilePath: path, Offset: 20}})
+
+	// The second batch is acked first: the first may still be lost
+	agent.handleResponse(context.Background(), client, &blazelogv1.StreamResponse{AckedSequence: second})
+	if cp, ok := agent.checkpoints.Get(path); ok {
+		t.Fatalf("committed after an out-of-order ack = %+v, want none", cp)
+	}
+
+	agent.handleResponse(context.Background(), client, &blazelogv1.StreamResponse{AckedSequence: first})
+	if cp, ok := agent.checkpoints.Get(path); !ok || cp.Offset != 20 {
+		t.Errorf("committed once both are acked = %+v, %v, want offset 20", cp, ok)
+	}
+}
@@ -2,15 +2,21 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
 	"sync/atomic"
 
+	"github.com/good-yellow-bee/blazelog/internal/encoding"
+	"github.com/good-yellow-bee/blazelog/internal/encoding/zstd"
+	"github.com/good-yellow-bee/blazelog/internal/metrics"
 	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
 	"github.com/good-yellow-bee/blazelog/internal/security"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/metadata"
 )
 
 // TLSConfig holds TLS configuration for the agent client.
@@ -30,13 +36,33 @@ type Client struct {
 	projectID string
 	sequence  uint64
 
+	maxMsgSize int        // max serialized batch size (0 = DefaultMaxMessageSize)
+	window     *ackWindow // unacked batches in flight; nil = no ack tracking
+
+	compression       string   // requested stream compression ("" = none)
+	serverCompressors []string // compressors the server accepts, learned on Register
+
+	sendMu sync.Mutex // serializes stream.Send between batches and raw tail chunks
+
 	mu     sync.Mutex
 	closed bool
 }
 
+// Stream compression settings.
+const (
+	CompressionNone = "none"
+	CompressionGzip = gzip.Name
+	CompressionZstd = zstd.Name
+)
+
+// DefaultMaxMessageSize is the default gRPC message size limit, matching the
+// server's default receive limit.
+const DefaultMaxMessageSize = 4 * 1024 * 1024 // 4MB
+
 // NewClient creates a new gRPC client for the given server address.
-// If tlsConfig is nil, uses insecure connection.
-func NewClient(address string, tlsConfig *TLSConfig) (*Client, error) {
+// If tlsConfig is nil, uses insecure connection. Extra dial options (e.g.
+// compression, stats handlers) are applied after the transport credentials.
+func NewClient(address string, tlsConfig *TLSConfig, extra ...grpc.DialOption) (*Client, error) {
 	var opts []grpc.DialOption
 
 	// Configure TLS if enabled
@@ -58,6 +84,8 @@ func NewClient(address string, tlsConfig *TLSConfig) (*Client, error) {
 		log.Printf("WARNING: gRPC client running in insecure mode (no TLS)")
 	}
 
+	opts = append(opts, extra...)
+
 	conn, err := grpc.NewClient(address, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("connect to server: %w", err)
@@ -69,13 +97,65 @@ func NewClient(address string, tlsConfig *TLSConfig) (*Client, error) {
 	}, nil
 }
 
-// Register registers the agent with the server.
+// SetMaxMessageSize sets the largest batch message the client will send.
+// Batches above the limit are split before sending. Must be called before
+// StartStream; zero or negative values restore the default.
+func (c *Client) SetMaxMessageSize(n int) {
+	c.maxMsgSize = n
+}
+
+// maxMessageSize returns the effective message size limit.
+func (c *Client) maxMessageSize() int {
+	if c.maxMsgSize <= 0 {
+		return DefaultMaxMessageSize
+	}
+	return c.maxMsgSize
+}
+
+// SetMaxInFlight enables ack tracking with up to n batches awaiting a
+// server ack; SendBatch waits while the window is full. Must be called
+// before StartStream; zero or negative values disable tracking, so batches
+// are sent without waiting for acks.
+func (c *Client) SetMaxInFlight(n int) {
+	c.window = newAckWindow(n)
+}
+
+// SetCompression sets the compression requested for the log stream:
+// CompressionGzip, CompressionZstd, or CompressionNone / empty. Must be
+// called before StartStream.
+func (c *Client) SetCompression(name string) {
+	if name == CompressionNone {
+		name = ""
+	}
+	c.compression = name
+}
+
+// Compression returns the compression the log stream uses: the requested
+// one if the server accepts it, else gzip if the server accepts that, else
+// none. Only meaningful after Register.
+func (c *Client) Compression() string {
+	if c.compression == "" {
+		return CompressionNone
+	}
+	for _, want := range []string{c.compression, CompressionGzip} {
+		for _, name := range c.serverCompressors {
+			if name == want {
+				return want
+			}
+		}
+	}
+	return CompressionNone
+}
+
+// Register registers the agent with the server. The request itself is sent
+// uncompressed; its response tells which compressors the stream may use.
 func (c *Client) Register(ctx context.Context, info *blazelogv1.AgentInfo) (*blazelogv1.RegisterResponse, error) {
 	req := &blazelogv1.RegisterRequest{
 		Agent: info,
 	}
 
-	resp, err := c.client.Register(ctx, req)
+	var header metadata.MD
+	resp, err := c.client.Register(ctx, req, grpc.Header(&header))
 	if err != nil {
 		return nil, fmt.Errorf("register agent: %w", err)
 	}
@@ -84,6 +164,12 @@ func (c *Client) Register(ctx context.Context, info *blazelogv1.AgentInfo) (*bla
 		return nil, fmt.Errorf("registration failed: %s", resp.ErrorMessage)
 	}
 
+	c.serverCompressors = encoding.SplitCompressors(header.Get(encoding.CompressorsHeader))
+	if len(c.serverCompressors) == 0 && resp.GetConfig().GetCompressionEnabled() {
+		// Older servers only advertise gzip
+		c.serverCompressors = []string{CompressionGzip}
+	}
+
 	c.agentID = resp.AgentId
 	c.projectID = info.ProjectId
 	return resp, nil
@@ -94,7 +180,17 @@ func (c *Client) StartStream(ctx context.Context) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	stream, err := c.client.StreamLogs(ctx)
+	maxSize := c.maxMessageSize()
+	opts := []grpc.CallOption{
+		grpc.MaxCallSendMsgSize(maxSize),
+		grpc.MaxCallRecvMsgSize(maxSize),
+	}
+	if compression := c.Compression(); compression != CompressionNone {
+		opts = append(opts, grpc.UseCompressor(compression))
+	} else if c.compression != "" {
+		log.Printf("server does not accept %s compression, streaming uncompressed", c.compression)
+	}
+	stream, err := c.client.StreamLogs(ctx, opts...)
 	if err != nil {
 		return fmt.Errorf("start stream: %w", err)
 	}
@@ -103,7 +199,33 @@ func (c *Client) StartStream(ctx context.Context) error {
 	return nil
 }
 
+// SendError is returned by SendBatch when a sub-batch couldn't be sent.
+// Sub-batches before it were sent and are tracked by the ack window, so
+// only Unsent needs to be buffered for retry.
+type SendError struct {
+	Unsent []*blazelogv1.LogEntry
+	Err    error
+}
+
+func (e *SendError) Error() string { return e.Err.Error() }
+
+func (e *SendError) Unwrap() error { return e.Err }
+
+// unsentEntries returns the entries of a failed SendBatch call that still
+// need to be sent: the SendError's remainder, or all of entries.
+func unsentEntries(err error, entries []*blazelogv1.LogEntry) []*blazelogv1.LogEntry {
+	var sendErr *SendError
+	if errors.As(err, &sendErr) {
+		return sendErr.Unsent
+	}
+	return entries
+}
+
 // SendBatch sends a batch of log entries to the server.
+// Batches that would exceed the max message size are split into several
+// sub-batches. Entries too large to fit in any message are dropped, logged
+// and counted, since the server would reject them on every retry. If a
+// sub-batch fails, the returned *SendError holds it and those after it.
 func (c *Client) SendBatch(ctx context.Context, entries []*blazelogv1.LogEntry) error {
 	c.mu.Lock()
 	stream := c.stream
@@ -113,21 +235,60 @@ func (c *Client) SendBatch(ctx context.Context, entries []*blazelogv1.LogEntry)
 		return fmt.Errorf("stream not started")
 	}
 
-	seq := atomic.AddUint64(&c.sequence, 1)
-	batch := &blazelogv1.LogBatch{
-		Entries:   entries,
-		AgentId:   c.agentID,
-		ProjectId: c.projectID,
-		Sequence:  seq,
+	chunks, oversized := splitBatch(entries, c.maxMessageSize()-batchOverhead(c.agentID, c.projectID))
+	if len(oversized) > 0 {
+		log.Printf("dropping %d log entries larger than the %d byte message limit", len(oversized), c.maxMessageSize())
+		metrics.AgentOversizedEntriesDroppedTotal.Add(float64(len(oversized)))
 	}
 
-	if err := stream.Send(batch); err != nil {
-		return fmt.Errorf("send batch: %w", err)
+	for i, chunk := range chunks {
+		if err := c.window.acquire(ctx); err != nil {
+			return &SendError{Unsent: flattenChunks(chunks[i:]), Err: fmt.Errorf("wait for ack window: %w", err)}
+		}
+		seq := atomic.AddUint64(&c.sequence, 1)
+		batch := &blazelogv1.LogBatch{
+			Entries:   chunk,
+			AgentId:   c.agentID,
+			ProjectId: c.projectID,
+			Sequence:  seq,
+		}
+
+		c.window.sent(seq, chunk)
+		c.sendMu.Lock()
+		err := stream.Send(batch)
+		c.sendMu.Unlock()
+		if err != nil {
+			c.window.failed(seq)
+			return &SendError{Unsent: flattenChunks(chunks[i:]), Err: fmt.Errorf("send batch: %w", err)}
+		}
 	}
 
 	return nil
 }
 
+// SendRawTail sends a raw tail chunk on the log stream. Chunks bypass the
+// ack window; the server does not acknowledge them.
+func (c *Client) SendRawTail(chunk *blazelogv1.RawTailChunk) error {
+	c.mu.Lock()
+	stream := c.stream
+	c.mu.Unlock()
+
+	if stream == nil {
+		return fmt.Errorf("stream not started")
+	}
+
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if err := stream.Send(&blazelogv1.LogBatch{
+		AgentId:   c.agentID,
+		ProjectId: c.projectID,
+		RawTail:   chunk,
+	}); err != nil {
+		return fmt.Errorf("send raw tail: %w", err)
+	}
+	return nil
+}
+
 // ReceiveResponses starts receiving responses from the server.
 // Returns a channel for StreamResponse messages.
 func (c *Client) ReceiveResponses(ctx context.Context) (<-chan *blazelogv1.StreamResponse, <-chan error) {
@@ -169,6 +330,40 @@ func (c *Client) ReceiveResponses(ctx context.Context) (<-chan *blazelogv1.Strea
 	return responses, errs
 }
 
+// Ack records the server's acknowledgement of the batch with sequence seq.
+// It is a no-op unless ack tracking is enabled.
+func (c *Client) Ack(seq uint64) {
+	c.window.ack(seq)
+}
+
+// AckedSequence returns the sequence up to which every batch sent on this
+// stream has been acked (0 if none, or if ack tracking is disabled).
+func (c *Client) AckedSequence() uint64 {
+	return c.window.ackedSequence()
+}
+
+// Sequence returns the sequence of the last batch sent on this stream.
+func (c *Client) Sequence() uint64 {
+	return atomic.LoadUint64(&c.sequence)
+}
+
+// TracksAcks reports whether ack tracking is enabled.
+func (c *Client) TracksAcks() bool {
+	return c.window != nil
+}
+
+// InFlight returns the number of sent batches awaiting an ack.
+func (c *Client) InFlight() int {
+	return c.window.inFlight()
+}
+
+// TakeUnacked stops ack tracking and returns the entries of every batch
+// not yet acked, oldest first, so they can be re-sent on a new stream.
+// Pending SendBatch calls fail. Returns nil if ack tracking is disabled.
+func (c *Client) TakeUnacked() []*blazelogv1.LogEntry {
+	return c.window.close()
+}
+
 // Heartbeat sends a heartbeat to the server.
 func (c *Client) Heartbeat(ctx context.Context, status *blazelogv1.AgentStatus) (*blazelogv1.HeartbeatResponse, error) {
 	req := &blazelogv1.HeartbeatRequest{
@@ -3,9 +3,13 @@ package agent
 import (
 	"context"
 	"fmt"
+	"log"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
+	"github.com/good-yellow-bee/blazelog/internal/metrics"
 	"github.com/good-yellow-bee/blazelog/internal/models"
 	"github.com/good-yellow-bee/blazelog/internal/parser"
 	"github.com/good-yellow-bee/blazelog/internal/tailer"
@@ -13,66 +17,163 @@ import (
 
 // SourceConfig defines a log source to collect.
 type SourceConfig struct {
-	Name   string
-	Type   string
-	Path   string
-	Follow bool
+	Name       string
+	Type       string
+	Path       string // file path or glob pattern, "**" matches any number of directories; *.gz files are read once
+	Follow     bool
+	PathLabels string // optional template deriving labels from each file's path
+
+	// RescanInterval is how often a glob Path is re-expanded to tail new
+	// files and drop deleted ones; 0 = the tailer default (10s).
+	RescanInterval time.Duration
+
+	StartPosition string // tailer.StartBeginning or tailer.StartEnd; empty = end when following
+	ReadRotated   bool   // on rotation, read unread lines from the newest rotated copy (may be gzipped)
+
+	ReadBufferSize int // file read buffer in bytes; 0 = tailer.DefaultReadBufferSize
+	MaxLineLength  int // longer lines are truncated and flagged; 0 = tailer.DefaultMaxLineLength
+
+	// Keys read by the json parser; empty = parser.DefaultJSONTimeKey etc.
+	TimeKey    string
+	LevelKey   string
+	MessageKey string
+
+	// ThrownOrigin is how PHP parsers use an exception's "thrown in" tail;
+	// empty = parser.ThrownOriginOverride.
+	ThrownOrigin parser.ThrownOriginMode
+
+	// Pattern and TimeLayout configure "regex" sources; see parser.NewRegexParser.
+	Pattern    string
+	TimeLayout string
+
+	// Multiline entries of parsers implementing parser.MultiLineParser are
+	// sent after MultiLineTimeout without continuation lines, or at
+	// MultiLineMaxLines lines; 0 = DefaultMultiLineTimeout, DefaultMultiLineMaxLines.
+	MultiLineTimeout  time.Duration
+	MultiLineMaxLines int
+
+	// OnParseError is what happens to a line its parser rejects:
+	// ParseErrorDrop (the default when empty) or ParseErrorForwardRaw.
+	OnParseError string
+
+	// Checkpoints, if set, resumes files at their saved positions and
+	// tracks the position of each entry sent.
+	Checkpoints *CheckpointStore
+}
+
+// Parse error policies for SourceConfig.OnParseError. Either way, the line
+// is counted in blazelog_agent_parse_errors_total.
+const (
+	ParseErrorDrop       = "drop"        // discard the line
+	ParseErrorForwardRaw = "forward_raw" // send it as is with level and type unknown
+)
+
+// lineTailer is the part of tailer.Tailer and tailer.MultiTailer the
+// collector reads from.
+type lineTailer interface {
+	Lines() <-chan tailer.Line
+	Stop()
 }
 
 // Collector collects log entries from a single source.
 type Collector struct {
 	source     SourceConfig
-	tailer     *tailer.Tailer
+	tailer     lineTailer
+	start      func(ctx context.Context) error
 	parser     parser.Parser
 	entries    chan *models.LogEntry
 	labels     map[string]string
 	lineNumber int64
 
+	pathLabels *PathTemplate
+	fileLabels map[string]map[string]string // per-file cache, used only by collect
+
+	multiParser parser.MultiLineParser // nil = every line is an entry
+	multi       *lineAggregator        // used only by collect
+
+	limiter *parseLimiter           // shared across collectors; nil = unlimited
+	levels  *parser.LevelInferencer // nil = keep parsed levels as they are
+	paths   *parser.PathNormalizer  // nil = keep file paths as parsed
+
+	warnedOversized   bool // an oversized line was logged, used only by collect
+	warnedParseErrors bool // a parse error was logged, used only by collect
+
 	mu     sync.Mutex
 	closed bool
 }
 
 // NewCollector creates a new collector for the given source.
 func NewCollector(source SourceConfig, labels map[string]string) (*Collector, error) {
-	// Find parser by type name
-	p, ok := parser.DefaultRegistry.GetByName(source.Type)
-	if !ok {
-		// Try to find by log type
-		logType := stringToLogType(source.Type)
-		p, ok = parser.Get(logType)
-		if !ok {
-			return nil, fmt.Errorf("unknown parser type: %s", source.Type)
+	p, err := sourceParser(source)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Collector{
+		source:     source,
+		parser:     p,
+		entries:    make(chan *models.LogEntry, 100),
+		labels:     labels,
+		fileLabels: make(map[string]map[string]string),
+	}
+
+	if mp, ok := p.(parser.MultiLineParser); ok {
+		c.multiParser = mp
+		c.multi = newLineAggregator(mp.IsStartOfEntry, source.MultiLineTimeout, source.MultiLineMaxLines)
+	}
+
+	if source.PathLabels != "" {
+		tmpl, err := ParsePathTemplate(source.PathLabels)
+		if err != nil {
+			return nil, fmt.Errorf("path labels for %s: %w", source.Name, err)
 		}
+		c.pathLabels = tmpl
 	}
 
 	// Create tailer
 	opts := tailer.DefaultOptions()
 	opts.Follow = source.Follow
 	opts.MustExist = true
+	opts.StartPosition = source.StartPosition
+	opts.RescanInterval = source.RescanInterval
+	opts.ReadRotated = source.ReadRotated
+	opts.ReadBufferSize = source.ReadBufferSize
+	opts.MaxLineLength = source.MaxLineLength
+	if source.Checkpoints != nil {
+		opts.Resume = source.Checkpoints.Resume
+	}
+	if isGlob(source.Path) {
+		// Files matching a followed pattern may all appear later
+		opts.MustExist = !source.Follow
+		mt, err := tailer.NewMultiTailer([]string{source.Path}, opts)
+		if err != nil {
+			return nil, fmt.Errorf("create tailer for %s: %w", source.Path, err)
+		}
+		// MultiTailer applies opts.StartPosition itself.
+		c.tailer, c.start = mt, mt.Start
+		return c, nil
+	}
+
 	t, err := tailer.NewTailer(source.Path, opts)
 	if err != nil {
 		return nil, fmt.Errorf("create tailer for %s: %w", source.Path, err)
 	}
+	c.tailer, c.start = t, t.Start
+	// For follow mode, start from end by default to avoid reading huge backlogs
+	if opts.FromEnd() {
+		c.start = t.StartFromEnd
+	}
+	return c, nil
+}
 
-	return &Collector{
-		source:  source,
-		tailer:  t,
-		parser:  p,
-		entries: make(chan *models.LogEntry, 100),
-		labels:  labels,
-	}, nil
+// isGlob reports whether path contains glob metacharacters.
+func isGlob(path string) bool {
+	return strings.ContainsAny(path, "*?[")
 }
 
 // Start begins collecting log entries.
 func (c *Collector) Start(ctx context.Context) error {
-	// For follow mode, start from end to avoid reading huge backlogs
-	var err error
-	if c.source.Follow {
-		err = c.tailer.StartFromEnd(ctx)
-	} else {
-		err = c.tailer.Start(ctx)
-	}
-	if err != nil {
+	if err := c.start(ctx); err != nil {
 		return fmt.Errorf("start tailer: %w", err)
 	}
 
@@ -81,15 +182,41 @@ func (c *Collector) Start(ctx context.Context) error {
 }
 
 // collect reads lines from the tailer, parses them, and sends entries.
+// For multiline parsers, continuation lines are gathered into their
+// entry first, which is sent once complete.
 func (c *Collector) collect(ctx context.Context) {
 	defer close(c.entries)
 
+	// Fires when the oldest pending multiline entry times out
+	var timer *time.Timer
+	var expired <-chan time.Time
+	rearm := func() {
+		expired = nil
+		if d, ok := c.multi.deadline(); ok {
+			timer.Reset(time.Until(d))
+			expired = timer.C
+		}
+	}
+	if c.multi != nil {
+		timer = time.NewTimer(time.Hour)
+		timer.Stop()
+		defer timer.Stop()
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
+		case now := <-expired:
+			if !c.sendAll(ctx, c.multi.expire(now)) {
+				return
+			}
+			rearm()
 		case line, ok := <-c.tailer.Lines():
 			if !ok {
+				if c.multi != nil {
+					c.sendAll(ctx, c.multi.flush())
+				}
 				return
 			}
 			if line.Err != nil {
@@ -99,34 +226,208 @@ func (c *Collector) collect(ctx context.Context) {
 				continue
 			}
 
-			entry, err := c.parser.Parse(line.Text)
-			if err != nil {
+			if c.multi != nil {
+				if !c.sendAll(ctx, c.multi.add(line, time.Now())) {
+					return
+				}
+				rearm()
 				continue
 			}
+			if !c.send(ctx, []tailer.Line{line}) {
+				return
+			}
+		}
+	}
+}
 
-			// Enrich entry with source info
-			atomic.AddInt64(&c.lineNumber, 1)
-			entry.Source = c.source.Name
-			entry.FilePath = line.FilePath
-			entry.LineNumber = atomic.LoadInt64(&c.lineNumber)
-			entry.Raw = line.Text
+// sendAll sends the entries of several line groups. It returns false once
+// ctx is done.
+func (c *Collector) sendAll(ctx context.Context, groups [][]tailer.Line) bool {
+	for _, lines := range groups {
+		if !c.send(ctx, lines) {
+			return false
+		}
+	}
+	return true
+}
 
-			// Add labels
-			if entry.Labels == nil {
-				entry.Labels = make(map[string]string)
-			}
-			for k, v := range c.labels {
-				entry.Labels[k] = v
-			}
-			entry.Labels["source"] = c.source.Name
+// send parses the lines of one entry and sends it. Lines that don't parse
+// are handled by the source's OnParseError policy. It returns false once
+// ctx is done.
+func (c *Collector) send(ctx context.Context, lines []tailer.Line) bool {
+	if !c.limiter.acquire(ctx) {
+		return false
+	}
+	var entry *models.LogEntry
+	var err error
+	if len(lines) == 1 {
+		entry, err = c.parser.Parse(lines[0].Text)
+	} else {
+		entry, err = c.multiParser.ParseMultiLine(lineTexts(lines))
+	}
+	c.limiter.release()
 
-			select {
-			case c.entries <- entry:
-			case <-ctx.Done():
-				return
-			}
+	line := lines[0]
+	for _, l := range lines {
+		if l.Truncated {
+			line = l
+			break
+		}
+	}
+	if line.Truncated {
+		// A cut line often no longer parses; keep it as is rather
+		// than drop it
+		entry = c.truncatedEntry(line, entry, err)
+	} else if err != nil {
+		if entry = c.unparsedEntry(lines, err); entry == nil {
+			return true
+		}
+	}
+	// A forwarded raw line keeps level unknown
+	if c.levels != nil && (err == nil || line.Truncated) {
+		c.levels.Apply(entry)
+	}
+
+	// Enrich entry with source info
+	atomic.AddInt64(&c.lineNumber, 1)
+	entry.Source = c.source.Name
+	entry.FilePath = lines[0].FilePath
+	entry.LineNumber = atomic.LoadInt64(&c.lineNumber)
+	entry.Raw = joinLines(lines)
+	if c.paths != nil {
+		c.paths.Apply(entry)
+	}
+
+	// Add labels
+	if entry.Labels == nil {
+		entry.Labels = make(map[string]string)
+	}
+	for k, v := range c.labels {
+		entry.Labels[k] = v
+	}
+	for k, v := range c.labelsForFile(lines[0].FilePath) {
+		entry.Labels[k] = v
+	}
+	entry.Labels["source"] = c.source.Name
+
+	if last := lines[len(lines)-1]; c.source.Checkpoints != nil && last.Offset > 0 {
+		c.source.Checkpoints.track(entry, Checkpoint{FilePath: last.FilePath, Inode: last.Inode, Offset: last.Offset})
+	}
+
+	select {
+	case c.entries <- entry:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// truncatedEntry flags the entry for a line cut to the source's maximum
+// length, building a plain one from the text if it didn't parse, and counts
+// the line.
+func (c *Collector) truncatedEntry(line tailer.Line, entry *models.LogEntry, err error) *models.LogEntry {
+	metrics.AgentOversizedLinesTotal.WithLabelValues(c.source.Name).Inc()
+	if !c.warnedOversized {
+		c.warnedOversized = true
+		log.Printf("[agent] source %s: line of %d bytes in %s truncated to max_line_length (further ones are counted in blazelog_agent_oversized_lines_total)",
+			c.source.Name, line.Size, line.FilePath)
+	}
+
+	if err != nil {
+		entry = models.NewLogEntry()
+		entry.Timestamp = line.Time
+		entry.Message = line.Text
+		entry.Type = c.parser.Type()
+	}
+	entry.SetField("truncated", true)
+	entry.SetField("line_size", line.Size)
+	return entry
+}
+
+// unparsedEntry counts lines their parser rejected and, with
+// ParseErrorForwardRaw, builds a plain entry from them tagged with the
+// error. It returns nil if the lines are dropped.
+func (c *Collector) unparsedEntry(lines []tailer.Line, err error) *models.LogEntry {
+	metrics.AgentParseErrorsTotal.WithLabelValues(c.source.Name).Inc()
+	if !c.warnedParseErrors {
+		c.warnedParseErrors = true
+		log.Printf("[agent] source %s: line in %s does not parse as %s: %v (further ones are counted in blazelog_agent_parse_errors_total)",
+			c.source.Name, lines[0].FilePath, c.source.Type, err)
+	}
+	if c.source.OnParseError != ParseErrorForwardRaw {
+		return nil
+	}
+
+	entry := models.NewLogEntry()
+	entry.Timestamp = lines[0].Time
+	entry.Message = joinLines(lines)
+	entry.Type = models.LogTypeUnparsed
+	entry.SetField("parse_error", err.Error())
+	return entry
+}
+
+// labelsForFile returns the path-derived labels for a file, or nil when the
+// source has no path template or the file does not match it.
+func (c *Collector) labelsForFile(path string) map[string]string {
+	if c.pathLabels == nil {
+		return nil
+	}
+	labels, ok := c.fileLabels[path]
+	if !ok {
+		labels = c.pathLabels.Labels(path)
+		c.fileLabels[path] = labels
+	}
+	return labels
+}
+
+// regexSourceType is the source type parsed with the source's own Pattern.
+const regexSourceType = "regex"
+
+// sourceParser returns the parser for a source: a registered parser found
+// by name or log type, or one built from the source's own settings.
+func sourceParser(source SourceConfig) (parser.Parser, error) {
+	if source.Type == regexSourceType {
+		p, err := parser.NewRegexParser(source.Pattern, &parser.Options{TimeFormat: source.TimeLayout})
+		if err != nil {
+			return nil, fmt.Errorf("regex parser for %s: %w", source.Name, err)
+		}
+		return p, nil
+	}
+
+	// Find parser by type name
+	p, ok := parser.DefaultRegistry.GetByName(source.Type)
+	if !ok {
+		// Try to find by log type
+		logType := stringToLogType(source.Type)
+		p, ok = parser.Get(logType)
+		if !ok {
+			return nil, fmt.Errorf("unknown parser type: %s", source.Type)
+		}
+	}
+
+	// JSON keys other than the defaults need a parser of their own
+	if p.Type() == models.LogTypeJSON && (source.TimeKey != "" || source.LevelKey != "" || source.MessageKey != "") {
+		p = parser.NewJSONParser(&parser.Options{
+			TimeKey:    source.TimeKey,
+			LevelKey:   source.LevelKey,
+			MessageKey: source.MessageKey,
+		})
+	}
+
+	// So does a thrown_origin other than the PHP parsers' default
+	if source.ThrownOrigin != "" {
+		opts := parser.DefaultParserOptions()
+		opts.ThrownOrigin = source.ThrownOrigin
+		switch p.Type() {
+		case models.LogTypeMagento:
+			p = parser.NewMagentoParser(opts)
+		case models.LogTypePrestaShop:
+			p = parser.NewPrestaShopParser(opts)
+		case models.LogTypeWordPress:
+			p = parser.NewWordPressParser(opts)
 		}
 	}
+	return p, nil
 }
 
 // Entries returns the channel for reading parsed log entries.
@@ -165,6 +466,14 @@ func stringToLogType(s string) models.LogType {
 		return models.LogTypePrestaShop
 	case "wordpress":
 		return models.LogTypeWordPress
+	case "syslog":
+		return models.LogTypeSyslog
+	case "json":
+		return models.LogTypeJSON
+	case "postgres":
+		return models.LogTypePostgres
+	case "mysql":
+		return models.LogTypeMySQL
 	default:
 		return models.LogTypeUnknown
 	}
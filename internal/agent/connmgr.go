@@ -8,7 +8,10 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/good-yellow-bee/blazelog/internal/metrics"
 	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/stats"
 )
 
 // ConnState represents the connection state.
@@ -41,14 +44,19 @@ func (s ConnState) String() string {
 
 // ConnManagerConfig configures the connection manager.
 type ConnManagerConfig struct {
-	ServerAddress string
-	TLS           *TLSConfig
-	AgentInfo     *blazelogv1.AgentInfo
+	ServerAddress  string
+	MaxMessageSize int // 0 = DefaultMaxMessageSize
+	MaxInFlight    int // unacked batches allowed in flight (0 = no ack tracking)
+	TLS            *TLSConfig
+	AgentInfo      *blazelogv1.AgentInfo
+	Compression    string        // "gzip", "zstd" or "" (none)
+	Transfer       stats.Handler // counts bytes sent across reconnects; nil = none
 
 	// Retry settings
 	InitialBackoff time.Duration
 	MaxBackoff     time.Duration
-	MaxRetries     int // 0 = infinite retries
+	MaxRetries     int           // 0 = infinite retries
+	RetryWindow    time.Duration // give up after failing for this long (0 = no limit)
 }
 
 // DefaultConnManagerConfig returns default configuration.
@@ -60,6 +68,10 @@ func DefaultConnManagerConfig() ConnManagerConfig {
 	}
 }
 
+// reconnectJitter spreads reconnect delays by +/-50%, so agents dropped by
+// the same server restart don't all come back at the same moment.
+const reconnectJitter = 0.5
+
 // ConnManager manages the connection lifecycle with automatic reconnection.
 type ConnManager struct {
 	config  ConnManagerConfig
@@ -70,15 +82,19 @@ type ConnManager struct {
 	agentID string
 	verbose bool
 
+	throttled atomic.Bool // the last stream failed with RESOURCE_EXHAUSTED
+
 	// Callbacks
 	onConnected    func()
 	onDisconnected func(error)
 	onStateChange  func(ConnState)
+	onUnacked      func([]*blazelogv1.LogEntry)
 
 	// Internal
 	mu          sync.Mutex
 	reconnectCh chan struct{}
 	stopCh      chan struct{}
+	failedCh    chan error
 }
 
 // NewConnManager creates a new connection manager.
@@ -89,10 +105,11 @@ func NewConnManager(config ConnManagerConfig) *ConnManager {
 			config.InitialBackoff,
 			config.MaxBackoff,
 			2.0,
-			0.1,
+			reconnectJitter,
 		),
 		reconnectCh: make(chan struct{}, 1),
 		stopCh:      make(chan struct{}),
+		failedCh:    make(chan error, 1),
 	}
 	cm.state.Store(int32(ConnStateDisconnected))
 	return cm
@@ -112,6 +129,14 @@ func (cm *ConnManager) SetCallbacks(onConnected func(), onDisconnected func(erro
 	cm.onStateChange = onStateChange
 }
 
+// SetUnackedHandler sets the callback that receives the entries of batches
+// still unacked when a stream is torn down for reconnecting.
+func (cm *ConnManager) SetUnackedHandler(onUnacked func([]*blazelogv1.LogEntry)) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.onUnacked = onUnacked
+}
+
 // State returns the current connection state.
 func (cm *ConnManager) State() ConnState {
 	return ConnState(cm.state.Load())
@@ -136,12 +161,22 @@ func (cm *ConnManager) AgentID() string {
 	return cm.agentID
 }
 
-// Connect establishes the initial connection with retry.
+// Failed returns a channel that receives the error once reconnecting
+// gives up, on a non-retryable status or after RetryWindow.
+func (cm *ConnManager) Failed() <-chan error {
+	return cm.failedCh
+}
+
+// Connect establishes the initial connection with retry. Failures are
+// retried with exponential backoff and jitter; RESOURCE_EXHAUSTED waits the
+// max backoff, and statuses retrying can't fix (e.g. UNAUTHENTICATED) fail
+// at once.
 func (cm *ConnManager) Connect(ctx context.Context) error {
 	cm.logf("connecting to %s", cm.config.ServerAddress)
 	cm.setState(ConnStateConnecting)
 
 	attempts := 0
+	var failingSince time.Time
 	for {
 		select {
 		case <-ctx.Done():
@@ -161,13 +196,31 @@ func (cm *ConnManager) Connect(ctx context.Context) error {
 			return nil
 		}
 
+		if ctx.Err() != nil {
+			cm.setState(ConnStateDisconnected)
+			return ctx.Err()
+		}
+
 		attempts++
+		if attempts == 1 {
+			failingSince = time.Now()
+		}
+		decision := classifyRetry(err)
+		if decision == retryNever {
+			return cm.giveUp(fmt.Errorf("not retryable: %w", err))
+		}
 		if cm.config.MaxRetries > 0 && attempts >= cm.config.MaxRetries {
-			cm.setState(ConnStateDisconnected)
-			return fmt.Errorf("max retries (%d) exceeded: %w", cm.config.MaxRetries, err)
+			return cm.giveUp(fmt.Errorf("max retries (%d) exceeded: %w", cm.config.MaxRetries, err))
+		}
+		if cm.config.RetryWindow > 0 && time.Since(failingSince) >= cm.config.RetryWindow {
+			return cm.giveUp(fmt.Errorf("retry window (%v) exceeded after %d attempts: %w", cm.config.RetryWindow, attempts, err))
 		}
 
 		delay := cm.backoff.Next()
+		if decision == retryThrottled {
+			delay = cm.backoff.MaxDelay()
+		}
+		metrics.AgentSendRetriesTotal.WithLabelValues(retryCode(err)).Inc()
 		cm.logf("connect failed (attempt %d): %v, retrying in %v", attempts, err, delay)
 
 		select {
@@ -188,6 +241,16 @@ func (cm *ConnManager) TriggerReconnect() {
 	}
 }
 
+// StreamFailed signals a reconnect after the log stream failed with err.
+// A RESOURCE_EXHAUSTED status, from a server enforcing the agent's quota,
+// makes the reconnect wait the max backoff.
+func (cm *ConnManager) StreamFailed(err error) {
+	if classifyRetry(err) == retryThrottled {
+		cm.throttled.Store(true)
+	}
+	cm.TriggerReconnect()
+}
+
 // RunReconnectLoop runs the reconnection loop until context is canceled.
 func (cm *ConnManager) RunReconnectLoop(ctx context.Context) {
 	for {
@@ -207,26 +270,60 @@ func (cm *ConnManager) handleReconnect(ctx context.Context) {
 	cm.logf("reconnecting...")
 	cm.setState(ConnStateReconnecting)
 
-	// Close existing client
+	// Close existing client, handing back batches it never got acks for
 	cm.mu.Lock()
+	var unacked []*blazelogv1.LogEntry
 	if cm.client != nil {
+		unacked = cm.client.TakeUnacked()
 		cm.client.Close()
 		cm.client = nil
 	}
+	onUnacked := cm.onUnacked
 	cm.mu.Unlock()
 
+	if len(unacked) > 0 && onUnacked != nil {
+		onUnacked(unacked)
+	}
+
 	// Notify disconnected
 	if cm.onDisconnected != nil {
 		cm.onDisconnected(fmt.Errorf("reconnecting"))
 	}
 
+	// Wait a jittered delay first, so agents dropped together don't all
+	// reconnect at once
+	delay := cm.backoff.Next()
+	if cm.throttled.Swap(false) {
+		delay = cm.backoff.MaxDelay()
+		cm.logf("server is throttling this agent, waiting %v", delay)
+	}
+	select {
+	case <-ctx.Done():
+		return
+	case <-cm.stopCh:
+		return
+	case <-time.After(delay):
+	}
+
 	// Reconnect
 	if err := cm.Connect(ctx); err != nil {
 		cm.logf("reconnect failed: %v", err)
-		// Will retry on next trigger
+		if ctx.Err() == nil {
+			select {
+			case cm.failedCh <- err:
+			default:
+			}
+		}
 	}
 }
 
+// giveUp records that connecting failed for good and returns err.
+func (cm *ConnManager) giveUp(err error) error {
+	metrics.AgentSendFailuresTotal.WithLabelValues(retryCode(err)).Inc()
+	cm.setState(ConnStateDisconnected)
+	return err
+}
+
 // doConnect performs the actual connection and registration.
 func (cm *ConnManager) doConnect(ctx context.Context) error {
 	// Check context before starting to avoid unnecessary work
@@ -237,10 +334,17 @@ func (cm *ConnManager) doConnect(ctx context.Context) error {
 	}
 
 	// Create new client
-	client, err := NewClient(cm.config.ServerAddress, cm.config.TLS)
+	var dialOpts []grpc.DialOption
+	if cm.config.Transfer != nil {
+		dialOpts = append(dialOpts, grpc.WithStatsHandler(cm.config.Transfer))
+	}
+	client, err := NewClient(cm.config.ServerAddress, cm.config.TLS, dialOpts...)
 	if err != nil {
 		return fmt.Errorf("create client: %w", err)
 	}
+	client.SetMaxMessageSize(cm.config.MaxMessageSize)
+	client.SetMaxInFlight(cm.config.MaxInFlight)
+	client.SetCompression(cm.config.Compression)
 
 	// Use defer to ensure client is closed on any error or context cancellation
 	success := false
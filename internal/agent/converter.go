@@ -68,6 +68,18 @@ func ToProtoLogType(logType models.LogType) blazelogv1.LogType {
 		return blazelogv1.LogType_LOG_TYPE_PRESTASHOP
 	case models.LogTypeWordPress:
 		return blazelogv1.LogType_LOG_TYPE_WORDPRESS
+	case models.LogTypeSyslog:
+		return blazelogv1.LogType_LOG_TYPE_SYSLOG
+	case models.LogTypeJSON:
+		return blazelogv1.LogType_LOG_TYPE_JSON
+	case models.LogTypePostgres:
+		return blazelogv1.LogType_LOG_TYPE_POSTGRES
+	case models.LogTypeMySQL:
+		return blazelogv1.LogType_LOG_TYPE_MYSQL
+	case models.LogTypeCustom:
+		return blazelogv1.LogType_LOG_TYPE_CUSTOM
+	case models.LogTypeUnparsed:
+		return blazelogv1.LogType_LOG_TYPE_UNPARSED
 	default:
 		return blazelogv1.LogType_LOG_TYPE_UNSPECIFIED
 	}
@@ -34,6 +34,7 @@ type Heartbeater struct {
 	config         HeartbeatConfig
 	connMgr        *ConnManager
 	statusProvider StatusProvider
+	onCommand      func(*blazelogv1.ServerCommand)
 	verbose        bool
 
 	missedCount atomic.Int32
@@ -58,6 +59,12 @@ func (h *Heartbeater) SetVerbose(v bool) {
 	h.verbose = v
 }
 
+// SetCommandHandler sets the function that handles server commands
+// returned in heartbeat responses. Without one, commands are only logged.
+func (h *Heartbeater) SetCommandHandler(fn func(*blazelogv1.ServerCommand)) {
+	h.onCommand = fn
+}
+
 // Start begins the heartbeat loop.
 // Returns a channel that signals when reconnection is needed (too many missed heartbeats).
 func (h *Heartbeater) Start(ctx context.Context) <-chan struct{} {
@@ -152,6 +159,10 @@ func (h *Heartbeater) sendHeartbeat(ctx context.Context, reconnectCh chan<- stru
 // handleCommand processes a server command from heartbeat response.
 func (h *Heartbeater) handleCommand(cmd *blazelogv1.ServerCommand) {
 	h.logf("received server command: %v", cmd.Type)
+	if h.onCommand != nil {
+		h.onCommand(cmd)
+		return
+	}
 
 	switch cmd.Type {
 	case blazelogv1.CommandType_COMMAND_TYPE_UNSPECIFIED:
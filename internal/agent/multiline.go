@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"strings"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/tailer"
+)
+
+// Multiline aggregation defaults, used when SourceConfig.MultiLineTimeout
+// or SourceConfig.MultiLineMaxLines is unset.
+const (
+	DefaultMultiLineTimeout  = time.Second
+	DefaultMultiLineMaxLines = 500
+)
+
+// pendingEntry is an entry whose continuation lines may still arrive.
+type pendingEntry struct {
+	lines []tailer.Line
+	last  time.Time // when the last line was added
+}
+
+// lineAggregator groups tailed lines into multiline entries for parsers
+// that implement parser.MultiLineParser. A start line opens an entry and
+// the lines after it that aren't start lines join it. The entry is done at
+// the next start line, after timeout without new lines, or at maxLines.
+// Files are aggregated separately, since a glob source interleaves them.
+type lineAggregator struct {
+	isStart  func(string) bool
+	timeout  time.Duration
+	maxLines int
+	pending  map[string]*pendingEntry // by file path
+}
+
+func newLineAggregator(isStart func(string) bool, timeout time.Duration, maxLines int) *lineAggregator {
+	if timeout <= 0 {
+		timeout = DefaultMultiLineTimeout
+	}
+	if maxLines <= 0 {
+		maxLines = DefaultMultiLineMaxLines
+	}
+	return &lineAggregator{
+		isStart:  isStart,
+		timeout:  timeout,
+		maxLines: maxLines,
+		pending:  make(map[string]*pendingEntry),
+	}
+}
+
+// add adds a line and returns the entries it completes: the file's pending
+// entry when line starts a new one or fills it to maxLines. A continuation
+// line with no entry to join (the file was read from its middle) is
+// returned alone.
+func (a *lineAggregator) add(line tailer.Line, now time.Time) [][]tailer.Line {
+	var done [][]tailer.Line
+	p := a.pending[line.FilePath]
+
+	if a.isStart(line.Text) {
+		if p != nil {
+			done = append(done, p.lines)
+		}
+		p = &pendingEntry{lines: []tailer.Line{line}, last: now}
+		a.pending[line.FilePath] = p
+	} else if p != nil {
+		p.lines = append(p.lines, line)
+		p.last = now
+	} else {
+		return [][]tailer.Line{{line}}
+	}
+
+	if len(p.lines) >= a.maxLines {
+		done = append(done, p.lines)
+		delete(a.pending, line.FilePath)
+	}
+	return done
+}
+
+// expire returns the entries that got no line within the timeout before now.
+func (a *lineAggregator) expire(now time.Time) [][]tailer.Line {
+	var done [][]tailer.Line
+	for path, p := range a.pending {
+		if now.Sub(p.last) >= a.timeout {
+			done = append(done, p.lines)
+			delete(a.pending, path)
+		}
+	}
+	return done
+}
+
+// flush returns all pending entries.
+func (a *lineAggregator) flush() [][]tailer.Line {
+	var done [][]tailer.Line
+	for path, p := range a.pending {
+		done = append(done, p.lines)
+		delete(a.pending, path)
+	}
+	return done
+}
+
+// deadline returns when the next pending entry expires, or false if none
+// is pending.
+func (a *lineAggregator) deadline() (time.Time, bool) {
+	var next time.Time
+	for _, p := range a.pending {
+		if d := p.last.Add(a.timeout); next.IsZero() || d.Before(next) {
+			next = d
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// lineTexts returns the texts of lines.
+func lineTexts(lines []tailer.Line) []string {
+	texts := make([]string, len(lines))
+	for i, l := range lines {
+		texts[i] = l.Text
+	}
+	return texts
+}
+
+// joinLines returns the raw text of a multiline entry.
+func joinLines(lines []tailer.Line) string {
+	if len(lines) == 1 {
+		return lines[0].Text
+	}
+	return strings.Join(lineTexts(lines), "\n")
+}
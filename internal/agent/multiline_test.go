@@ -0,0 +1,183 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/tailer"
+)
+
+func isTestStart(text string) bool {
+	return strings.HasPrefix(text, "[")
+}
+
+// groupTexts returns the texts of each group, for comparison.
+func groupTexts(groups [][]tailer.Line) [][]string {
+	texts := make([][]string, len(groups))
+	for i, g := range groups {
+		texts[i] = lineTexts(g)
+	}
+	return texts
+}
+
+func TestLineAggregatorAdd(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		maxLines int
+		lines    []tailer.Line
+		want     [][]string // completed entries, in order
+		pending  int        // files with a pending entry
+	}{
+		{
+			name: "start line completes the previous entry",
+			lines: []tailer.Line{
+				{Text: "[1] error", FilePath: "a.log"},
+				{Text: "#0 frame", FilePath: "a.log"},
+				{Text: "#1 frame", FilePath: "a.log"},
+				{Text: "[2] info", FilePath: "a.log"},
+			},
+			want:    [][]string{{"[1] error", "#0 frame", "#1 frame"}},
+			pending: 1,
+		},
+		{
+			name: "orphan continuation is sent alone",
+			lines: []tailer.Line{
+				{Text: "#3 frame", FilePath: "a.log"},
+				{Text: "[1] error", FilePath: "a.log"},
+			},
+			want:    [][]string{{"#3 frame"}},
+			pending: 1,
+		},
+		{
+			name:     "max lines completes an entry",
+			maxLines: 2,
+			lines: []tailer.Line{
+				{Text: "[1] error", FilePath: "a.log"},
+				{Text: "#0 frame", FilePath: "a.log"},
+				{Text: "#1 frame", FilePath: "a.log"},
+			},
+			want:    [][]string{{"[1] error", "#0 frame"}, {"#1 frame"}},
+			pending: 0,
+		},
+		{
+			name: "files are aggregated separately",
+			lines: []tailer.Line{
+				{Text: "[1] error", FilePath: "a.log"},
+				{Text: "[2] error", FilePath: "b.log"},
+				{Text: "#0 a frame", FilePath: "a.log"},
+				{Text: "#0 b frame", FilePath: "b.log"},
+				{Text: "[3] info", FilePath: "a.log"},
+			},
+			want:    [][]string{{"[1] error", "#0 a frame"}},
+			pending: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newLineAggregator(isTestStart, time.Second, tt.maxLines)
+			var done [][]tailer.Line
+			for _, line := range tt.lines {
+				done = append(done, a.add(line, now)...)
+			}
+			got := groupTexts(done)
+			if len(got) != len(tt.want) {
+				t.Fatalf("completed = %q, want %q", got, tt.want)
+			}
+			for i := range tt.want {
+				if strings.Join(got[i], "|") != strings.Join(tt.want[i], "|") {
+					t.Errorf("completed[%d] = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+			if len(a.pending) != tt.pending {
+				t.Errorf("pending files = %d, want %d", len(a.pending), tt.pending)
+			}
+		})
+	}
+}
+
+func TestLineAggregatorExpire(t *testing.T) {
+	start := time.Now()
+	a := newLineAggregator(isTestStart, time.Second, 0)
+
+	if _, ok := a.deadline(); ok {
+		t.Error("deadline() ok with nothing pending")
+	}
+
+	a.add(tailer.Line{Text: "[1] error", FilePath: "a.log"}, start)
+	a.add(tailer.Line{Text: "[2] error", FilePath: "b.log"}, start.Add(500*time.Millisecond))
+	if d, ok := a.deadline(); !ok || !d.Equal(start.Add(time.Second)) {
+		t.Errorf("deadline() = %v, %v, want %v", d, ok, start.Add(time.Second))
+	}
+
+	// A continuation line pushes its entry's deadline back
+	a.add(tailer.Line{Text: "#0 frame", FilePath: "a.log"}, start.Add(800*time.Millisecond))
+	if got := a.expire(start.Add(time.Second)); len(got) != 0 {
+		t.Errorf("expire() before the timeout = %q", groupTexts(got))
+	}
+
+	got := groupTexts(a.expire(start.Add(1500 * time.Millisecond)))
+	if len(got) != 1 || strings.Join(got[0], "|") != "[2] error" {
+		t.Errorf("expire() = %q, want the b.log entry", got)
+	}
+
+	got = groupTexts(a.flush())
+	if len(got) != 1 || strings.Join(got[0], "|") != "[1] error|#0 frame" {
+		t.Errorf("flush() = %q, want the a.log entry", got)
+	}
+	if _, ok := a.deadline(); ok {
+		t.Error("deadline() ok after flush")
+	}
+}
+
+func TestCollectorMultiLine(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "exception.log")
+	lines := []string{
+		`[2024-01-15T10:23:45.123456+00:00] main.CRITICAL: Exception: Order not found in /var/www/app/Order.php:42`,
+		`Stack trace:`,
+		`#0 /var/www/app/Controller.php(17): Order->load()`,
+		`#1 {main}`,
+		`[2024-01-15T10:23:46.000000+00:00] main.INFO: Order placed [] []`,
+	}
+	if err := os.WriteFile(logFile, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("write log file: %v", err)
+	}
+
+	src := SourceConfig{Name: "magento", Type: "magento", Path: logFile, MultiLineTimeout: 50 * time.Millisecond}
+	collector, err := NewCollector(src, nil)
+	if err != nil {
+		t.Fatalf("NewCollector: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := collector.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer collector.Stop()
+
+	// The INFO line has no continuation lines; it is sent at the timeout
+	for i, want := range []struct {
+		raw       string
+		lineCount int
+	}{
+		{raw: strings.Join(lines[:4], "\n"), lineCount: 4},
+		{raw: lines[4], lineCount: 1},
+	} {
+		select {
+		case entry := <-collector.Entries():
+			if entry.Raw != want.raw {
+				t.Errorf("entry %d: Raw = %q, want %q", i, entry.Raw, want.raw)
+			}
+			if want.lineCount > 1 && entry.GetFieldInt("line_count") != want.lineCount {
+				t.Errorf("entry %d: line_count = %v, want %d", i, entry.Fields["line_count"], want.lineCount)
+			}
+		case <-ctx.Done():
+			t.Fatalf("timeout waiting for entry %d", i)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/good-yellow-bee/blazelog/internal/metrics"
+)
+
+// parseLimiter caps how many lines are parsed at once across all sources.
+// A collector holds a slot while parsing; when none is free it waits, and
+// stops reading from its tailer, so a slow parser pushes back on the readers
+// instead of lines piling up in memory.
+type parseLimiter struct {
+	slots   chan struct{}
+	waiting atomic.Int64
+}
+
+// newParseLimiter creates a limiter allowing workers concurrent parses, or
+// returns nil (no limit) when workers <= 0.
+func newParseLimiter(workers int) *parseLimiter {
+	if workers <= 0 {
+		return nil
+	}
+	return &parseLimiter{slots: make(chan struct{}, workers)}
+}
+
+// acquire takes a parse slot, waiting for one if all are busy. It returns
+// false if ctx is done first. A nil limiter always succeeds.
+func (l *parseLimiter) acquire(ctx context.Context) bool {
+	if l == nil {
+		return true
+	}
+	select {
+	case l.slots <- struct{}{}:
+		metrics.AgentParseWorkersBusy.Inc()
+		return true
+	default:
+	}
+
+	metrics.AgentParseQueueDepth.Set(float64(l.waiting.Add(1)))
+	defer func() { metrics.AgentParseQueueDepth.Set(float64(l.waiting.Add(-1))) }()
+
+	select {
+	case l.slots <- struct{}{}:
+		metrics.AgentParseWorkersBusy.Inc()
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// release returns a slot taken by acquire.
+func (l *parseLimiter) release() {
+	if l == nil {
+		return
+	}
+	<-l.slots
+	metrics.AgentParseWorkersBusy.Dec()
+}
+
+// depth returns the number of collectors waiting for a slot.
+func (l *parseLimiter) depth() int {
+	if l == nil {
+		return 0
+	}
+	return int(l.waiting.Load())
+}
@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseLimiterCapsConcurrency(t *testing.T) {
+	l := newParseLimiter(2)
+
+	var active, peak atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !l.acquire(context.Background()) {
+				t.Error("acquire failed")
+				return
+			}
+			n := active.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			active.Add(-1)
+			l.release()
+		}()
+	}
+	wg.Wait()
+
+	if got := peak.Load(); got > 2 {
+		t.Errorf("peak concurrency = %d, want <= 2", got)
+	}
+	if got := l.depth(); got != 0 {
+		t.Errorf("depth after drain = %d, want 0", got)
+	}
+}
+
+func TestParseLimiterWaitsAndCancels(t *testing.T) {
+	l := newParseLimiter(1)
+	if !l.acquire(context.Background()) {
+		t.Fatal("first acquire failed")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan bool)
+	go func() { done <- l.acquire(ctx) }()
+
+	deadline := time.Now().Add(time.Second)
+	for l.depth() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("depth = %d, want 1 waiting", l.depth())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	if <-done {
+		t.Error("acquire succeeded after cancel, want false")
+	}
+	if got := l.depth(); got != 0 {
+		t.Errorf("depth after cancel = %d, want 0", got)
+	}
+	l.release()
+}
+
+func TestParseLimiterNilIsUnlimited(t *testing.T) {
+	l := newParseLimiter(0)
+	if l != nil {
+		t.Fatal("newParseLimiter(0) should return nil")
+	}
+	for i := 0; i < 3; i++ {
+		if !l.acquire(context.Background()) {
+			t.Fatal("nil limiter acquire failed")
+		}
+	}
+	l.release()
+	if l.depth() != 0 {
+		t.Error("nil limiter depth should be 0")
+	}
+}
@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// KubernetesPathLabels is the path label template for the kubelet's pod log
+// layout, /var/log/pods/<namespace>_<pod>_<uid>/<container>/<n>.log.
+// Namespace and pod names cannot contain "_", so the segment splits cleanly.
+const KubernetesPathLabels = "/var/log/pods/{namespace}_{pod}_{uid}/{container}/"
+
+// labelNamePattern validates placeholder names in path label templates.
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// PathTemplate extracts labels from file paths. In a template such as
+// "/var/log/{service}/{env}/", each {name} matches one path segment and
+// becomes a label, and * matches any text within a segment. A template
+// ending in "/" matches any file below that directory; otherwise it must
+// match the whole path.
+type PathTemplate struct {
+	template string
+	re       *regexp.Regexp
+	names    []string
+}
+
+// ParsePathTemplate compiles a path label template.
+func ParsePathTemplate(template string) (*PathTemplate, error) {
+	var expr strings.Builder
+	var names []string
+	seen := make(map[string]bool)
+
+	expr.WriteString("^")
+	rest := filepath.ToSlash(template)
+	for rest != "" {
+		i := strings.IndexAny(rest, "{}*")
+		if i < 0 {
+			expr.WriteString(regexp.QuoteMeta(rest))
+			break
+		}
+		expr.WriteString(regexp.QuoteMeta(rest[:i]))
+
+		switch rest[i] {
+		case '*':
+			expr.WriteString(`[^/]*`)
+			rest = rest[i+1:]
+		case '}':
+			return nil, fmt.Errorf("unexpected '}' in path template %q", template)
+		case '{':
+			end := strings.IndexByte(rest[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed '{' in path template %q", template)
+			}
+			name := rest[i+1 : i+end]
+			if !labelNamePattern.MatchString(name) {
+				return nil, fmt.Errorf("invalid label name %q in path template %q", name, template)
+			}
+			if name == "source" {
+				return nil, fmt.Errorf("label name %q is reserved", name)
+			}
+			if seen[name] {
+				return nil, fmt.Errorf("duplicate label name %q in path template %q", name, template)
+			}
+			seen[name] = true
+			names = append(names, name)
+			expr.WriteString(`([^/]+)`)
+			rest = rest[i+end+1:]
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("path template %q has no {label} placeholders", template)
+	}
+	if !strings.HasSuffix(template, "/") {
+		expr.WriteString("$")
+	}
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("compile path template %q: %w", template, err)
+	}
+	return &PathTemplate{template: template, re: re, names: names}, nil
+}
+
+// String returns the template text.
+func (t *PathTemplate) String() string {
+	return t.template
+}
+
+// Labels returns the labels extracted from path, or nil if the path does
+// not match the template.
+func (t *PathTemplate) Labels(path string) map[string]string {
+	m := t.re.FindStringSubmatch(filepath.ToSlash(path))
+	if m == nil {
+		return nil
+	}
+	labels := make(map[string]string, len(t.names))
+	for i, name := range t.names {
+		labels[name] = m[i+1]
+	}
+	return labels
+}
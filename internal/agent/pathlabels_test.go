@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPathTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		path     string
+		want     map[string]string
+	}{
+		{"directory prefix", "/var/log/{service}/{env}/", "/var/log/shop/prod/app.log", map[string]string{"service": "shop", "env": "prod"}},
+		{"nested below prefix", "/var/log/{service}/", "/var/log/shop/prod/app.log", map[string]string{"service": "shop"}},
+		{"full path", "/var/log/{service}/{env}.log", "/var/log/shop/prod.log", map[string]string{"service": "shop", "env": "prod"}},
+		{"full path too deep", "/var/log/{service}/{env}.log", "/var/log/shop/x/prod.log", nil},
+		{"wildcard segment", "/srv/*/logs/{app}/", "/srv/web01/logs/api/error.log", map[string]string{"app": "api"}},
+		{"partial segment", "/var/log/app-{env}/", "/var/log/app-staging/app.log", map[string]string{"env": "staging"}},
+		{"no match", "/var/log/{service}/{env}/", "/opt/shop/app.log", nil},
+		{"literal metacharacters", "/var/log/{service}.d/", "/var/log/shopxd/app.log", nil},
+		{"kubernetes pod log", KubernetesPathLabels, "/var/log/pods/shop_api-7d9f8-x2k4q_0b6f3c1e-8a2d-4f5b-9c7e-1d2a3b4c5d6e/api/0.log",
+			map[string]string{"namespace": "shop", "pod": "api-7d9f8-x2k4q", "uid": "0b6f3c1e-8a2d-4f5b-9c7e-1d2a3b4c5d6e", "container": "api"}},
+		{"kubernetes non-pod path", KubernetesPathLabels, "/var/log/nginx/access.log", nil},
+		{"kubernetes containers symlink", "/var/log/containers/{pod}_{namespace}_{container}-{container_id}.log",
+			"/var/log/containers/api-7d9f8-x2k4q_shop_api-sidecar-4e5f6a.log",
+			map[string]string{"pod": "api-7d9f8-x2k4q", "namespace": "shop", "container": "api-sidecar", "container_id": "4e5f6a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParsePathTemplate(tt.template)
+			if err != nil {
+				t.Fatalf("ParsePathTemplate(%q): %v", tt.template, err)
+			}
+			if got := tmpl.Labels(tt.path); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Labels(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePathTemplateErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+	}{
+		{"no placeholders", "/var/log/"},
+		{"unclosed", "/var/log/{service/"},
+		{"stray brace", "/var/log/service}/"},
+		{"empty name", "/var/log/{}/"},
+		{"invalid name", "/var/log/{my-service}/"},
+		{"duplicate", "/var/log/{env}/{env}/"},
+		{"reserved", "/var/log/{source}/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParsePathTemplate(tt.template); err == nil {
+				t.Errorf("ParsePathTemplate(%q) expected error", tt.template)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
+	"github.com/good-yellow-bee/blazelog/internal/tailer"
+)
+
+// Raw tail limits. The server caps requests the same way; the agent
+// enforces them too so it never streams more than an admin could ask for.
+const (
+	maxRawTailDuration = time.Minute
+	maxRawTailBytes    = 1 << 20 // 1MB
+
+	// rawTailBacklog is how far before the end of the file a tail starts,
+	// so the most recent lines show up immediately.
+	rawTailBacklog = 4 << 10 // 4KB
+
+	rawTailChunkSize    = 64 << 10 // 64KB
+	rawTailPollInterval = 250 * time.Millisecond
+)
+
+// rawTailSender sends raw tail chunks to the server. It must not retain
+// chunk.Data, which is reused for the next read.
+type rawTailSender func(*blazelogv1.RawTailChunk) error
+
+// startRawTail answers a raw tail command from the server. The tail runs
+// in the background; refusals are sent back as a final chunk carrying the
+// reason.
+func (a *Agent) startRawTail(ctx context.Context, params map[string]string) {
+	requestID := params["request_id"]
+	if requestID == "" {
+		a.logf("ignoring raw tail command without request_id")
+		return
+	}
+	send := func(chunk *blazelogv1.RawTailChunk) error {
+		client := a.connMgr.Client()
+		if client == nil {
+			return errors.New("not connected")
+		}
+		chunk.RequestId = requestID
+		return client.SendRawTail(chunk)
+	}
+	refuse := func(reason string) {
+		log.Printf("refused raw tail request %s: %s", requestID, reason)
+		if err := send(&blazelogv1.RawTailChunk{Done: true, Error: reason}); err != nil {
+			a.logf("send raw tail refusal: %v", err)
+		}
+	}
+
+	if !a.config.RawTail {
+		refuse("raw tail is disabled on this agent")
+		return
+	}
+	path, err := a.rawTailPath(params["source"])
+	if err != nil {
+		refuse(err.Error())
+		return
+	}
+	duration, maxBytes, err := parseRawTailLimits(params)
+	if err != nil {
+		refuse(err.Error())
+		return
+	}
+	if !a.rawTailing.CompareAndSwap(false, true) {
+		refuse("a raw tail is already running on this agent")
+		return
+	}
+
+	// Raw bytes bypass redaction, so always leave a trace in the log.
+	log.Printf("raw tail %s: streaming %s for %s (max %d bytes)", requestID, path, duration, maxBytes)
+	go func() {
+		defer a.rawTailing.Store(false)
+		ctx, cancel := context.WithTimeout(ctx, duration)
+		defer cancel()
+
+		sent, err := tailRaw(ctx, path, maxBytes, send)
+		final := &blazelogv1.RawTailChunk{Done: true}
+		if err != nil {
+			final.Error = err.Error()
+		}
+		if sendErr := send(final); sendErr != nil {
+			a.logf("send raw tail end: %v", sendErr)
+		}
+		log.Printf("raw tail %s: finished after %d bytes", requestID, sent)
+	}()
+}
+
+// rawTailPath returns the file to tail for the named source: its path, or
+// the most recently modified match if the path is a glob.
+func (a *Agent) rawTailPath(source string) (string, error) {
+	for _, src := range a.config.Sources {
+		if src.Name != source {
+			continue
+		}
+		if !isGlob(src.Path) {
+			return src.Path, nil
+		}
+		matches, err := tailer.Glob(src.Path)
+		if err != nil {
+			return "", fmt.Errorf("expand source path: %w", err)
+		}
+		var newest string
+		var newestMod time.Time
+		for _, m := range matches {
+			info, err := os.Stat(m)
+			if err == nil && (newest == "" || info.ModTime().After(newestMod)) {
+				newest, newestMod = m, info.ModTime()
+			}
+		}
+		if newest == "" {
+			return "", fmt.Errorf("source %q has no matching files", source)
+		}
+		return newest, nil
+	}
+	return "", fmt.Errorf("unknown source %q", source)
+}
+
+// parseRawTailLimits reads the duration and max_bytes command parameters,
+// capping them at the agent's limits.
+func parseRawTailLimits(params map[string]string) (time.Duration, int, error) {
+	duration, err := time.ParseDuration(params["duration"])
+	if err != nil || duration <= 0 {
+		return 0, 0, fmt.Errorf("invalid duration %q", params["duration"])
+	}
+	maxBytes, err := strconv.Atoi(params["max_bytes"])
+	if err != nil || maxBytes <= 0 {
+		return 0, 0, fmt.Errorf("invalid max_bytes %q", params["max_bytes"])
+	}
+	return min(duration, maxRawTailDuration), min(maxBytes, maxRawTailBytes), nil
+}
+
+// tailRaw sends bytes appended to path, starting rawTailBacklog bytes
+// before the current end, until ctx is done or maxBytes have been sent. It
+// returns the number of bytes sent. A truncated file is read again from the
+// start.
+func tailRaw(ctx context.Context, path string, maxBytes int, send rawTailSender) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("open source: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("stat source: %w", err)
+	}
+	offset := max(info.Size()-rawTailBacklog, 0)
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("seek source: %w", err)
+	}
+
+	ticker := time.NewTicker(rawTailPollInterval)
+	defer ticker.Stop()
+	buf := make([]byte, rawTailChunkSize)
+	sent := 0
+	for {
+		for sent < maxBytes {
+			n, err := f.Read(buf[:min(len(buf), maxBytes-sent)])
+			if n > 0 {
+				if err := send(&blazelogv1.RawTailChunk{Data: buf[:n]}); err != nil {
+					return sent, fmt.Errorf("send chunk: %w", err)
+				}
+				sent += n
+				offset += int64(n)
+			}
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return sent, fmt.Errorf("read source: %w", err)
+			}
+		}
+		if sent >= maxBytes {
+			return sent, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return sent, nil
+		case <-ticker.C:
+		}
+
+		if info, err := f.Stat(); err == nil && info.Size() < offset {
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return sent, fmt.Errorf("seek source: %w", err)
+			}
+			offset = 0
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
+)
+
+// chunkRecorder collects the bytes of sent raw tail chunks.
+type chunkRecorder struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+func (r *chunkRecorder) send(chunk *blazelogv1.RawTailChunk) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.data = append(r.data, chunk.Data...)
+	return nil
+}
+
+func (r *chunkRecorder) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return string(r.data)
+}
+
+func TestTailRaw(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	old := strings.Repeat("o", rawTailBacklog)
+	if err := os.WriteFile(path, []byte("too old\n"+old), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := &chunkRecorder{}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, err := tailRaw(ctx, path, len(old)+len("new line\n"), rec.send)
+		done <- err
+	}()
+
+	// Wait for the backlog, then append
+	deadline := time.Now().Add(time.Second)
+	for rec.String() != old && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString("new line\nnot sent\n"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("tailRaw() error = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("tailRaw() did not stop at max bytes")
+	}
+	if got, want := rec.String(), old+"new line\n"; got != want {
+		t.Errorf("sent %d bytes ending %q, want %d bytes ending %q", len(got), got[max(len(got)-20, 0):], len(want), want[len(want)-20:])
+	}
+}
+
+func TestTailRawStopsOnContext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("line\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := &chunkRecorder{}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	sent, err := tailRaw(ctx, path, maxRawTailBytes, rec.send)
+	if err != nil {
+		t.Fatalf("tailRaw() error = %v", err)
+	}
+	if sent != 5 || rec.String() != "line\n" {
+		t.Errorf("tailRaw() sent %d bytes %q, want the 5 existing bytes", sent, rec.String())
+	}
+}
+
+func TestParseRawTailLimits(t *testing.T) {
+	tests := []struct {
+		name         string
+		params       map[string]string
+		wantDuration time.Duration
+		wantBytes    int
+		wantErr      bool
+	}{
+		{"valid", map[string]string{"duration": "10s", "max_bytes": "1024"}, 10 * time.Second, 1024, false},
+		{"capped", map[string]string{"duration": "1h", "max_bytes": "1073741824"}, maxRawTailDuration, maxRawTailBytes, false},
+		{"missing duration", map[string]string{"max_bytes": "1024"}, 0, 0, true},
+		{"bad max bytes", map[string]string{"duration": "10s", "max_bytes": "-1"}, 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, n, err := parseRawTailLimits(tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRawTailLimits() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if d != tt.wantDuration || n != tt.wantBytes {
+				t.Errorf("parseRawTailLimits() = %s/%d, want %s/%d", d, n, tt.wantDuration, tt.wantBytes)
+			}
+		})
+	}
+}
+
+func TestRawTailPath(t *testing.T) {
+	dir := t.TempDir()
+	older := filepath.Join(dir, "a.log")
+	newer := filepath.Join(dir, "b.log")
+	for _, p := range []string{older, newer} {
+		if err := os.WriteFile(p, []byte("x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(older, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	a := &Agent{config: &Config{Sources: []SourceConfig{
+		{Name: "single", Path: older},
+		{Name: "glob", Path: filepath.Join(dir, "*.log")},
+		{Name: "empty", Path: filepath.Join(dir, "*.txt")},
+	}}}
+
+	tests := []struct {
+		source  string
+		want    string
+		wantErr bool
+	}{
+		{"single", older, false},
+		{"glob", newer, false},
+		{"empty", "", true},
+		{"unknown", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			got, err := a.rawTailPath(tt.source)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("rawTailPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("rawTailPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
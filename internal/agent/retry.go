@@ -0,0 +1,34 @@
+package agent
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// retryDecision is how a failed connect or stream attempt is handled.
+type retryDecision int
+
+const (
+	retryBackoff   retryDecision = iota // retry after the next backoff delay
+	retryThrottled                      // server is shedding load: retry after the max delay
+	retryNever                          // retrying can't succeed: give up
+)
+
+// classifyRetry decides how to handle err from a connect, register or
+// stream attempt, going by its gRPC status code. Errors that carry no
+// status (connection refused, registration rejected) are retried.
+func classifyRetry(err error) retryDecision {
+	switch status.Code(err) {
+	case codes.ResourceExhausted:
+		return retryThrottled
+	case codes.Unauthenticated, codes.PermissionDenied, codes.InvalidArgument, codes.Unimplemented:
+		return retryNever
+	default:
+		return retryBackoff
+	}
+}
+
+// retryCode returns the gRPC status code of err as a metric label.
+func retryCode(err error) string {
+	return status.Code(err).String()
+}
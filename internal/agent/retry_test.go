@@ -0,0 +1,53 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClassifyRetry(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want retryDecision
+	}{
+		{"plain error", errors.New("registration failed: nope"), retryBackoff},
+		{"unavailable", status.Error(codes.Unavailable, "down"), retryBackoff},
+		{"deadline", status.Error(codes.DeadlineExceeded, "slow"), retryBackoff},
+		{"resource exhausted", status.Error(codes.ResourceExhausted, "quota"), retryThrottled},
+		{"unauthenticated", status.Error(codes.Unauthenticated, "token"), retryNever},
+		{"permission denied", status.Error(codes.PermissionDenied, "project"), retryNever},
+		{"wrapped", fmt.Errorf("register: %w", status.Error(codes.ResourceExhausted, "quota")), retryThrottled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyRetry(tt.err); got != tt.want {
+				t.Errorf("classifyRetry(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnManagerStreamFailedThrottles(t *testing.T) {
+	cm := NewConnManager(DefaultConnManagerConfig())
+
+	cm.StreamFailed(errors.New("stream reset"))
+	if cm.throttled.Load() {
+		t.Error("plain stream error should not throttle")
+	}
+	<-cm.reconnectCh
+
+	cm.StreamFailed(status.Error(codes.ResourceExhausted, "quota"))
+	if !cm.throttled.Load() {
+		t.Error("RESOURCE_EXHAUSTED should throttle the next reconnect")
+	}
+	select {
+	case <-cm.reconnectCh:
+	default:
+		t.Error("expected a reconnect to be triggered")
+	}
+}
@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"math"
+
+	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// batchOverhead returns the serialized size of a LogBatch without entries,
+// using the largest possible sequence number.
+func batchOverhead(agentID, projectID string) int {
+	return proto.Size(&blazelogv1.LogBatch{
+		AgentId:   agentID,
+		ProjectId: projectID,
+		Sequence:  math.MaxUint64,
+	})
+}
+
+// entryWireSize returns the bytes an entry adds to a LogBatch message:
+// the entry itself plus its field tag and length prefix.
+func entryWireSize(entry *blazelogv1.LogEntry) int {
+	n := proto.Size(entry)
+	return protowire.SizeTag(1) + protowire.SizeBytes(n)
+}
+
+// splitBatch splits entries into chunks whose serialized entries fit in
+// maxBytes. Entries that cannot fit even on their own are returned
+// separately. Order is preserved.
+func splitBatch(entries []*blazelogv1.LogEntry, maxBytes int) (chunks [][]*blazelogv1.LogEntry, oversized []*blazelogv1.LogEntry) {
+	var current []*blazelogv1.LogEntry
+	size := 0
+
+	for _, entry := range entries {
+		n := entryWireSize(entry)
+		if n > maxBytes {
+			oversized = append(oversized, entry)
+			continue
+		}
+		if len(current) > 0 && size+n > maxBytes {
+			chunks = append(chunks, current)
+			current, size = nil, 0
+		}
+		current = append(current, entry)
+		size += n
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, current)
+	}
+
+	return chunks, oversized
+}
+
+// flattenChunks joins chunks back into one slice of entries.
+func flattenChunks(chunks [][]*blazelogv1.LogEntry) []*blazelogv1.LogEntry {
+	var entries []*blazelogv1.LogEntry
+	for _, chunk := range chunks {
+		entries = append(entries, chunk...)
+	}
+	return entries
+}
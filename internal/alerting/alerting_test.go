@@ -148,6 +148,28 @@ func TestRuleValidation(t *testing.T) {
 			wantErr: true,
 			errMsg:  "invalid cooldown",
 		},
+		{
+			name: "invalid notify template",
+			rule: Rule{
+				Name:           "test-rule",
+				Type:           RuleTypePattern,
+				Condition:      Condition{Pattern: "ERROR"},
+				NotifyTemplate: "{{.NoSuchField}}",
+			},
+			wantErr: true,
+			errMsg:  "invalid notify_template",
+		},
+		{
+			name: "notify template needs an entry",
+			rule: Rule{
+				Name:           "test-rule",
+				Type:           RuleTypePattern,
+				Condition:      Condition{Pattern: "ERROR"},
+				NotifyTemplate: "{{.TriggeringEntry.Source}}",
+			},
+			wantErr: true,
+			errMsg:  "without a triggering entry",
+		},
 	}
 
 	for _, tt := range tests {
@@ -428,6 +450,88 @@ func TestSlidingWindow(t *testing.T) {
 	}
 }
 
+func TestSlidingWindowMaxEvents(t *testing.T) {
+	// 100 events/s into a one-hour window is 360k events; the cap keeps 1000
+	const maxEvents = 1000
+	window := NewSlidingWindowWithMax(time.Hour, maxEvents)
+	baseTime := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 360000; i++ {
+		window.AddAt(baseTime.Add(time.Duration(i) * 10 * time.Millisecond))
+		if i%10000 == 0 {
+			window.mu.RLock()
+			stored, buckets := len(window.events), len(window.buckets)
+			window.mu.RUnlock()
+			if stored > maxEvents || buckets > windowBucketCount {
+				t.Fatalf("after %d events: %d timestamps, %d buckets stored", i+1, stored, buckets)
+			}
+		}
+	}
+	if !window.Approximate() {
+		t.Fatal("expected window to switch to bucketed counts past the cap")
+	}
+
+	// Exact count is 360000; bucketing may be off by one bucket (30s = 3000 events)
+	now := baseTime.Add(time.Hour)
+	count := window.CountAt(now)
+	if diff := count - 360000; diff < -3000 || diff > 3000 {
+		t.Errorf("count = %d, want 360000 within one bucket", count)
+	}
+
+	// Half the events age out
+	count = window.CountAt(now.Add(30 * time.Minute))
+	if diff := count - 180000; diff < -3000 || diff > 3000 {
+		t.Errorf("count after 30m = %d, want 180000 within one bucket", count)
+	}
+
+	// Once everything has expired the window counts exactly again
+	if count := window.CountAt(now.Add(2 * time.Hour)); count != 0 {
+		t.Errorf("count after expiry = %d, want 0", count)
+	}
+	if window.Approximate() {
+		t.Error("expected window to return to exact counting once empty")
+	}
+	window.AddAt(now.Add(2 * time.Hour))
+	if count := window.CountAt(now.Add(2 * time.Hour)); count != 1 {
+		t.Errorf("count = %d, want 1", count)
+	}
+}
+
+func TestSlidingWindowUnderCapIsExact(t *testing.T) {
+	window := NewSlidingWindowWithMax(time.Minute, 100)
+	baseTime := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 100; i++ {
+		window.AddAt(baseTime.Add(time.Duration(i) * 100 * time.Millisecond))
+	}
+	if window.Approximate() {
+		t.Fatal("window at its cap should count exactly")
+	}
+	if count := window.CountAt(baseTime.Add(10 * time.Second)); count != 100 {
+		t.Errorf("count = %d, want 100", count)
+	}
+}
+
+func TestWindowManagerMaxEvents(t *testing.T) {
+	wm := NewWindowManagerWithMax(10)
+	baseTime := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 50; i++ {
+		wm.AddEventAt("busy", time.Hour, baseTime.Add(time.Duration(i)*time.Second))
+	}
+	if total := wm.TotalEvents(); total != 0 {
+		t.Errorf("TotalEvents() = %d, want 0 once the window is bucketed", total)
+	}
+	if count := wm.Get("busy").CountAt(baseTime.Add(time.Minute)); count != 50 {
+		t.Errorf("count = %d, want 50", count)
+	}
+
+	wm.Reset("busy")
+	if wm.Get("busy").Approximate() {
+		t.Error("Reset should return the window to exact counting")
+	}
+}
+
 func TestCooldownManager(t *testing.T) {
 	cm := NewCooldownManager()
 	baseTime := time.Now()
@@ -510,6 +614,69 @@ func TestEnginePatternAlert(t *testing.T) {
 	}
 }
 
+func TestEngineProjectDefaultNotify(t *testing.T) {
+	yaml := `
+rules:
+  - name: "inherit"
+    type: "pattern"
+    condition:
+      pattern: "FATAL"
+    severity: "critical"
+    project_id: "proj-1"
+  - name: "opt-out"
+    type: "pattern"
+    condition:
+      pattern: "FATAL"
+    severity: "critical"
+    project_id: "proj-1"
+    notify: []
+  - name: "explicit"
+    type: "pattern"
+    condition:
+      pattern: "FATAL"
+    severity: "critical"
+    project_id: "proj-1"
+    notify:
+      - "email"
+`
+	rules, err := LoadRulesFromBytes([]byte(yaml))
+	if err != nil {
+		t.Fatalf("LoadRulesFromBytes: %v", err)
+	}
+
+	engine := NewEngine(rules, &EngineOptions{
+		AlertBufferSize: 10,
+		ProjectNotify: func(projectID string) []string {
+			if projectID == "proj-1" {
+				return []string{"slack", "teams"}
+			}
+			return nil
+		},
+	})
+	defer engine.Close()
+
+	entry := models.NewLogEntry()
+	entry.Message = "FATAL: system crash"
+
+	got := make(map[string][]string)
+	for _, alert := range engine.Evaluate(entry) {
+		got[alert.RuleName] = alert.Notify
+		if alert.ProjectID != "proj-1" {
+			t.Errorf("%s: project_id = %q, want proj-1", alert.RuleName, alert.ProjectID)
+		}
+	}
+
+	if strings.Join(got["inherit"], ",") != "slack,teams" {
+		t.Errorf("inherit: notify = %v, want project defaults", got["inherit"])
+	}
+	if got["opt-out"] == nil || len(got["opt-out"]) != 0 {
+		t.Errorf("opt-out: notify = %#v, want explicit empty list", got["opt-out"])
+	}
+	if strings.Join(got["explicit"], ",") != "email" {
+		t.Errorf("explicit: notify = %v, want [email]", got["explicit"])
+	}
+}
+
 func TestEngineThresholdAlert(t *testing.T) {
 	rule := &Rule{
 		Name:     "error-rate",
@@ -565,6 +732,41 @@ func TestEngineThresholdAlert(t *testing.T) {
 	}
 }
 
+// TestEngineThresholdAlertAt replays past entries, as a dry run does: the
+// window is counted at each entry's time, not the wall clock.
+func TestEngineThresholdAlertAt(t *testing.T) {
+	rule := &Rule{
+		Name:     "error-rate",
+		Type:     RuleTypeThreshold,
+		Severity: SeverityHigh,
+		Condition: Condition{
+			Field:     "level",
+			Value:     "error",
+			Threshold: 3,
+			Window:    "1m",
+		},
+	}
+	if err := rule.Validate(); err != nil {
+		t.Fatalf("rule validation failed: %v", err)
+	}
+	engine := NewEngine([]*Rule{rule}, nil)
+	defer engine.Close()
+
+	baseTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	var alerts []*Alert
+	for _, offset := range []time.Duration{0, 2 * time.Minute, 2*time.Minute + time.Second, 2*time.Minute + 2*time.Second} {
+		entry := models.NewLogEntry()
+		entry.Level = models.LevelError
+		alerts = append(alerts, engine.EvaluateAt(entry, baseTime.Add(offset))...)
+	}
+	// The first error is out of the window by the time of the others
+	if len(alerts) != 1 {
+		t.Fatalf("expected 1 alert, got %d", len(alerts))
+	}
+	if alerts[0].Count != 3 || !alerts[0].Timestamp.Equal(baseTime.Add(2*time.Minute+2*time.Second)) {
+		t.Errorf("alert = %d events at %v, want 3 at the last entry", alerts[0].Count, alerts[0].Timestamp)
+	}
+}
 func TestEngineCooldown(t *testing.T) {
 	rule := &Rule{
 		Name:     "error-alert",
@@ -647,6 +849,167 @@ func TestEngineStats(t *testing.T) {
 	}
 }
 
+func TestEngineSubmitAsync(t *testing.T) {
+	rule := &Rule{
+		Name:      "test-pattern",
+		Type:      RuleTypePattern,
+		Severity:  SeverityLow,
+		Condition: Condition{Pattern: "TEST"},
+	}
+	if err := rule.Validate(); err != nil {
+		t.Fatalf("rule validation failed: %v", err)
+	}
+
+	engine := NewEngine([]*Rule{rule}, &EngineOptions{AlertBufferSize: 100, EvalWorkers: 2, EvalQueueSize: 100})
+	for i := 0; i < 50; i++ {
+		entry := models.NewLogEntry()
+		entry.Message = "TEST message"
+		if !engine.Submit(entry) {
+			t.Fatalf("Submit() skipped entry %d with a non-full queue", i)
+		}
+	}
+	engine.Close() // evaluates everything still queued
+
+	stats := engine.Stats()
+	if stats.EntriesEvaluated != 50 || stats.EntriesSkipped != 0 {
+		t.Errorf("evaluated %d, skipped %d; want 50, 0", stats.EntriesEvaluated, stats.EntriesSkipped)
+	}
+	alerts := 0
+	for range engine.Alerts() {
+		alerts++
+	}
+	if alerts != 50 {
+		t.Errorf("got %d alerts, want 50", alerts)
+	}
+}
+
+func TestEngineSubmitSkipsWhenFull(t *testing.T) {
+	engine := NewEngine(nil, &EngineOptions{AlertBufferSize: 1, EvalWorkers: 1, EvalQueueSize: 1})
+
+	// Block the evaluator on the rules lock so the queue fills up
+	engine.mu.Lock()
+	accepted := 0
+	for i := 0; i < 10; i++ {
+		if engine.Submit(models.NewLogEntry()) {
+			accepted++
+		}
+	}
+	if engine.Stats().EvalQueueDepth != 1 {
+		t.Errorf("queue depth = %d, want 1", engine.Stats().EvalQueueDepth)
+	}
+	engine.mu.Unlock()
+	engine.Close()
+
+	stats := engine.Stats()
+	if accepted > 2 {
+		t.Errorf("accepted %d entries, want at most 2 (one evaluating, one queued)", accepted)
+	}
+	if stats.EntriesSkipped != int64(10-accepted) || stats.EntriesEvaluated != int64(accepted) {
+		t.Errorf("evaluated %d, skipped %d; want %d, %d", stats.EntriesEvaluated, stats.EntriesSkipped, accepted, 10-accepted)
+	}
+}
+
+func TestEngineFloodAutoMute(t *testing.T) {
+	rule := &Rule{
+		Name:       "runaway",
+		Type:       RuleTypePattern,
+		Severity:   SeverityHigh,
+		Condition:  Condition{Pattern: "boom"},
+		Notify:     []string{"slack"},
+		MuteAfter:  3,
+		MuteWindow: "1h",
+	}
+	if err := rule.Validate(); err != nil {
+		t.Fatalf("rule validation failed: %v", err)
+	}
+
+	engine := NewEngine([]*Rule{rule}, &EngineOptions{AlertBufferSize: 100})
+	entry := models.NewLogEntry()
+	entry.Message = "boom"
+
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	fired := 0
+	for i := 0; i < 10; i++ {
+		fired += len(engine.EvaluateAt(entry, start.Add(time.Duration(i)*time.Minute)))
+	}
+	if fired != 10 {
+		t.Errorf("firings recorded = %d, want 10 (muting must not hide firings)", fired)
+	}
+
+	// 3 notifications, then one flood alert, then silence
+	var sent []*Alert
+	for len(engine.Alerts()) > 0 {
+		sent = append(sent, <-engine.Alerts())
+	}
+	if len(sent) != 4 {
+		t.Fatalf("notifications = %d, want 4", len(sent))
+	}
+	flood := sent[3]
+	if !flood.AutoMuted || !strings.Contains(flood.Message, "flooding detected") || flood.Notify[0] != "slack" {
+		t.Errorf("flood alert = %+v", flood)
+	}
+	if stats := engine.Stats(); stats.AlertsMuted != 7 || stats.RulesAutoMuted != 1 {
+		t.Errorf("muted = %d, auto-muted rules = %d; want 7, 1", stats.AlertsMuted, stats.RulesAutoMuted)
+	}
+
+	// The mute lifts when the window resets
+	engine.EvaluateAt(entry, start.Add(time.Hour))
+	if len(engine.Alerts()) != 1 || (<-engine.Alerts()).AutoMuted {
+		t.Error("rule should notify again once the window resets")
+	}
+}
+
+func TestEngineUnmute(t *testing.T) {
+	rule := &Rule{Name: "runaway", Type: RuleTypePattern, Condition: Condition{Pattern: "boom"}}
+	if err := rule.Validate(); err != nil {
+		t.Fatalf("rule validation failed: %v", err)
+	}
+	engine := NewEngine([]*Rule{rule}, &EngineOptions{AlertBufferSize: 10, MuteAfter: 1})
+	entry := models.NewLogEntry()
+	entry.Message = "boom"
+
+	engine.Evaluate(entry)
+	engine.Evaluate(entry)
+	if got := engine.MutedRules(); len(got) != 1 || got[0] != "runaway" {
+		t.Fatalf("MutedRules() = %v, want [runaway]", got)
+	}
+	if until, muted := engine.MutedUntil("runaway"); !muted || time.Until(until) <= 0 {
+		t.Errorf("MutedUntil() = %v, %v; want a future time, true", until, muted)
+	}
+	if !engine.Unmute("runaway") || engine.Unmute("runaway") {
+		t.Error("Unmute() should report true once, then false")
+	}
+	if _, muted := engine.MutedUntil("runaway"); muted {
+		t.Error("MutedUntil() after Unmute: want not muted")
+	}
+	drain := len(engine.Alerts())
+	engine.Evaluate(entry)
+	if got := len(engine.Alerts()) - drain; got != 1 {
+		t.Errorf("notifications after unmute = %d, want 1", got)
+	}
+}
+
+func TestEngineFloodGuardDisabled(t *testing.T) {
+	rule := &Rule{Name: "chatty", Type: RuleTypePattern, Condition: Condition{Pattern: "boom"}, MuteAfter: -1}
+	if err := rule.Validate(); err != nil {
+		t.Fatalf("rule validation failed: %v", err)
+	}
+	engine := NewEngine([]*Rule{rule}, &EngineOptions{AlertBufferSize: 200, MuteAfter: 1})
+	entry := models.NewLogEntry()
+	entry.Message = "boom"
+	for i := 0; i < 150; i++ {
+		engine.Evaluate(entry)
+	}
+	if got := len(engine.Alerts()); got != 150 {
+		t.Errorf("notifications = %d, want 150 with mute_after: -1", got)
+	}
+
+	bad := &Rule{Name: "bad", Type: RuleTypePattern, Condition: Condition{Pattern: "x"}, MuteWindow: "soon"}
+	if err := bad.Validate(); err == nil {
+		t.Error("Validate() should reject an invalid mute_window")
+	}
+}
+
 func TestEngineDisabledRule(t *testing.T) {
 	enabled := false
 	rule := &Rule{
@@ -1060,3 +1423,250 @@ func TestEngineReloadRulesDeletesWindows(t *testing.T) {
 		t.Error("expected threshold2 window to be deleted after ReloadRules")
 	}
 }
+
+func TestParseErrorsRuleValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		cond    Condition
+		wantErr string
+	}{
+		{name: "valid rate", cond: Condition{ErrorRate: 0.5, Window: "5m"}},
+		{name: "valid count", cond: Condition{Threshold: 10, Window: "5m"}},
+		{name: "missing limits", cond: Condition{Window: "5m"}, wantErr: "threshold or error_rate is required"},
+		{name: "rate above one", cond: Condition{ErrorRate: 1.5, Window: "5m"}, wantErr: "error_rate must be between 0 and 1"},
+		{name: "missing window", cond: Condition{ErrorRate: 0.5}, wantErr: "window is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := &Rule{Name: "parse", Type: RuleTypeParseErrors, Condition: tt.cond}
+			err := rule.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEngineParseErrorAlert(t *testing.T) {
+	rule := &Rule{
+		Name:     "format-changed",
+		Type:     RuleTypeParseErrors,
+		Severity: SeverityHigh,
+		Condition: Condition{
+			Threshold: 5,
+			ErrorRate: 0.5,
+			Window:    "1m",
+		},
+	}
+	if err := rule.Validate(); err != nil {
+		t.Fatalf("rule validation failed: %v", err)
+	}
+
+	engine := NewEngine([]*Rule{rule}, nil)
+	defer engine.Close()
+
+	now := time.Now()
+
+	// Healthy source: many successes outweigh a few failures
+	for i := 0; i < 20; i++ {
+		engine.RecordParseAt("/var/log/ok.log", true, now)
+	}
+	for i := 0; i < 5; i++ {
+		if alerts := engine.RecordParseAt("/var/log/ok.log", false, now); len(alerts) != 0 {
+			t.Fatalf("unexpected alert for healthy source: %v", alerts[0].Message)
+		}
+	}
+
+	// Broken source: failures go unnoticed until the count threshold is met
+	var alerts []*Alert
+	for i := 0; i < 5; i++ {
+		alerts = engine.RecordParseAt("/var/log/magento.log", false, now)
+		if i < 4 && len(alerts) != 0 {
+			t.Fatalf("alert after %d failures, want threshold of 5", i+1)
+		}
+	}
+	if len(alerts) != 1 {
+		t.Fatalf("expected 1 alert, got %d", len(alerts))
+	}
+	if alerts[0].Count != 5 || !strings.Contains(alerts[0].Message, "/var/log/magento.log") {
+		t.Errorf("alert = %+v, want count 5 for magento source", alerts[0])
+	}
+
+	// Failures outside the window are forgotten
+	for i := 0; i < 4; i++ {
+		engine.RecordParseAt("/var/log/other.log", false, now)
+	}
+	if alerts := engine.RecordParseAt("/var/log/other.log", false, now.Add(2*time.Minute)); len(alerts) != 0 {
+		t.Error("expected failures outside the window to be forgotten")
+	}
+
+	if got := engine.Stats().ParseErrorTriggers; got != 1 {
+		t.Errorf("ParseErrorTriggers = %d, want 1", got)
+	}
+}
+
+func TestEngineParseErrorSourceFilter(t *testing.T) {
+	rule := &Rule{
+		Name:      "agent-parse-errors",
+		Type:      RuleTypeParseErrors,
+		Condition: Condition{Threshold: 1, Window: "1m", Source: "agent-1"},
+	}
+	if err := rule.Validate(); err != nil {
+		t.Fatalf("rule validation failed: %v", err)
+	}
+
+	engine := NewEngine([]*Rule{rule}, nil)
+	defer engine.Close()
+
+	if alerts := engine.RecordParse("agent-2", false); len(alerts) != 0 {
+		t.Error("rule should ignore other sources")
+	}
+	if alerts := engine.RecordParse("agent-1", false); len(alerts) != 1 {
+		t.Errorf("expected 1 alert for agent-1, got %d", len(alerts))
+	}
+	// Parse results never reach Evaluate-driven rules
+	entry := models.NewLogEntry()
+	if alerts := engine.Evaluate(entry); len(alerts) != 0 {
+		t.Error("parse_errors rule should not trigger on log entries")
+	}
+}
+
+func TestEngineParseErrorMinSamples(t *testing.T) {
+	rateOnly := &Rule{
+		Name:      "rate-only",
+		Type:      RuleTypeParseErrors,
+		Condition: Condition{ErrorRate: 0.5, Window: "1m"},
+	}
+	explicit := &Rule{
+		Name:      "explicit",
+		Type:      RuleTypeParseErrors,
+		Condition: Condition{ErrorRate: 0.5, MinSamples: 3, Window: "1m", Source: "b.log"},
+	}
+	for _, rule := range []*Rule{rateOnly, explicit} {
+		if err := rule.Validate(); err != nil {
+			t.Fatalf("rule validation failed: %v", err)
+		}
+	}
+
+	engine := NewEngine([]*Rule{rateOnly, explicit}, nil)
+	defer engine.Close()
+
+	now := time.Now()
+	var alerts []*Alert
+	for i := 0; i < defaultParseMinSamples; i++ {
+		alerts = engine.RecordParseAt("a.log", false, now)
+		if i < defaultParseMinSamples-1 && len(alerts) != 0 {
+			t.Fatalf("rate-only rule fired after %d lines, want %d", i+1, defaultParseMinSamples)
+		}
+	}
+	if len(alerts) != 1 {
+		t.Fatalf("expected 1 alert after %d failed lines, got %d", defaultParseMinSamples, len(alerts))
+	}
+
+	for i := 0; i < 3; i++ {
+		alerts = engine.RecordParseAt("b.log", false, now)
+	}
+	if len(alerts) != 1 || alerts[0].RuleName != explicit.Name {
+		t.Errorf("expected only %q to fire for b.log after min_samples, got %d alerts", explicit.Name, len(alerts))
+	}
+}
+
+func TestEngineParseErrorRemoveRuleClearsSources(t *testing.T) {
+	rule := &Rule{
+		Name:      "format-changed",
+		Type:      RuleTypeParseErrors,
+		Condition: Condition{Threshold: 1, Window: "1m"},
+		Cooldown:  "1h",
+	}
+	if err := rule.Validate(); err != nil {
+		t.Fatalf("rule validation failed: %v", err)
+	}
+
+	engine := NewEngine([]*Rule{rule}, nil)
+	defer engine.Close()
+
+	now := time.Now()
+	if alerts := engine.RecordParseAt("a.log", false, now); len(alerts) != 1 {
+		t.Fatalf("expected 1 alert, got %d", len(alerts))
+	}
+	engine.RecordParseAt("b.log", true, now)
+
+	engine.RemoveRule(rule.Name)
+	if n := len(engine.parseErrors.windows); n != 0 {
+		t.Errorf("windows after RemoveRule = %d, want 0", n)
+	}
+	if engine.cooldown.IsOnCooldown(rule.Name+parseKeySep+"a.log", now) {
+		t.Error("per-source cooldown survived RemoveRule")
+	}
+}
+
+func TestParseErrorTrackerEvictsSources(t *testing.T) {
+	tracker := newParseErrorTracker()
+	tracker.max = 3
+
+	now := time.Now()
+	tracker.record("idle", time.Minute, true, now.Add(-2*time.Minute))
+	tracker.record("old", time.Minute, true, now.Add(-30*time.Second))
+	tracker.record("recent", time.Minute, true, now)
+
+	// The idle window goes first
+	if _, _, evicted := tracker.record("new-1", time.Minute, true, now); !evicted {
+		t.Error("expected eviction when full")
+	}
+	if _, ok := tracker.windows["idle"]; ok {
+		t.Error("idle window was not evicted")
+	}
+	if len(tracker.windows) != 3 {
+		t.Errorf("windows = %d, want 3", len(tracker.windows))
+	}
+
+	// With nothing idle, the least recently seen window goes
+	tracker.record("new-2", time.Minute, true, now)
+	if _, ok := tracker.windows["old"]; ok {
+		t.Error("least recently seen window was not evicted")
+	}
+	if _, ok := tracker.windows["recent"]; !ok {
+		t.Error("recent window was evicted")
+	}
+}
+
+func TestAlertRenderNotification(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantOK   bool
+		wantErr  bool
+	}{
+		{"no template", "", "", false, false},
+		{"fields and labels", "{{upper .RuleName}} x{{.Count}} see {{.Labels.runbook}}", "DISK FULL x3 see https://runbooks/disk", true, false},
+		{"missing label renders empty", "[{{.Labels.owner}}]", "[]", true, false},
+		{"optional entry", "{{with .TriggeringEntry}}{{.Message}}{{else}}none{{end}}", "none", true, false},
+		{"nil entry field", "{{.TriggeringEntry.Message}}", "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert := &Alert{
+				RuleName:       "disk full",
+				Count:          3,
+				Labels:         map[string]string{"runbook": "https://runbooks/disk"},
+				NotifyTemplate: tt.template,
+			}
+			got, ok, err := alert.RenderNotification()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RenderNotification() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("RenderNotification() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -20,6 +21,12 @@ type Engine struct {
 	windows  *WindowManager
 	cooldown *CooldownManager
 
+	// parseErrors tracks parse results for parse_errors rules.
+	parseErrors *parseErrorTracker
+
+	// flood auto-mutes rules that send too many notifications.
+	flood *floodGuard
+
 	// alerts is the channel where triggered alerts are sent.
 	alerts chan *Alert
 
@@ -29,22 +36,59 @@ type Engine struct {
 
 	// stats tracks engine statistics.
 	stats *EngineStats
+
+	// projectNotify resolves project default notification channels.
+	projectNotify ProjectNotifyResolver
+
+	// evalQueue feeds entries from Submit to the evaluator goroutines; nil
+	// when evaluation runs inline.
+	evalQueue   chan *models.LogEntry
+	evalWorkers int
+	evalStart   sync.Once
+	evalClose   sync.Once
+	evalStop    chan struct{}
+	evalWG      sync.WaitGroup
 }
 
 // EngineStats tracks engine statistics using atomic operations for lock-free access.
 type EngineStats struct {
-	EntriesEvaluated  atomic.Int64
-	PatternMatches    atomic.Int64
-	ThresholdTriggers atomic.Int64
-	ExprTriggers      atomic.Int64
-	AlertsSuppressed  atomic.Int64
-	AlertsDropped     atomic.Int64
+	EntriesEvaluated   atomic.Int64
+	PatternMatches     atomic.Int64
+	ThresholdTriggers  atomic.Int64
+	ExprTriggers       atomic.Int64
+	ParseErrorTriggers atomic.Int64
+	AlertsSuppressed   atomic.Int64
+	AlertsDropped      atomic.Int64
+	EntriesSkipped     atomic.Int64
+	AlertsMuted        atomic.Int64
+	RulesAutoMuted     atomic.Int64
 }
 
+// ProjectNotifyResolver returns the default notification channels for a project.
+type ProjectNotifyResolver func(projectID string) []string
+
 // EngineOptions configures the alert engine.
 type EngineOptions struct {
 	// AlertBufferSize is the size of the alert channel buffer.
 	AlertBufferSize int
+	// ProjectNotify resolves project default channels for rules without
+	// their own Notify list. Optional.
+	ProjectNotify ProjectNotifyResolver
+	// MaxWindowEvents caps the event timestamps each threshold window keeps;
+	// beyond it the window counts per time bucket instead (0 = 10000).
+	MaxWindowEvents int
+	// EvalWorkers is the number of goroutines evaluating entries passed to
+	// Submit (0 = Submit evaluates inline).
+	EvalWorkers int
+	// EvalQueueSize bounds the entries waiting for an evaluator; Submit
+	// skips entries while it is full (0 = 1024).
+	EvalQueueSize int
+	// MuteAfter auto-mutes a rule after this many notifications within
+	// MuteWindow, for rules that don't set mute_after
+	// (0 = DefaultMuteAfter, negative = never mute).
+	MuteAfter int
+	// MuteWindow is the default flood window (0 = DefaultMuteWindow).
+	MuteWindow time.Duration
 }
 
 // DefaultEngineOptions returns default engine options.
@@ -60,14 +104,41 @@ func NewEngine(rules []*Rule, opts *EngineOptions) *Engine {
 		opts = DefaultEngineOptions()
 	}
 
-	return &Engine{
+	e := &Engine{
 		rules:    rules,
 		matcher:  NewMatcher(),
-		windows:  NewWindowManager(),
+		windows:  NewWindowManagerWithMax(opts.MaxWindowEvents),
 		cooldown: NewCooldownManager(),
 		alerts:   make(chan *Alert, opts.AlertBufferSize),
 		stats:    &EngineStats{},
+
+		parseErrors:   newParseErrorTracker(),
+		flood:         newFloodGuard(opts.MuteAfter, opts.MuteWindow),
+		projectNotify: opts.ProjectNotify,
 	}
+	if opts.EvalWorkers > 0 {
+		queueSize := opts.EvalQueueSize
+		if queueSize <= 0 {
+			queueSize = 1024
+		}
+		e.evalQueue = make(chan *models.LogEntry, queueSize)
+		e.evalWorkers = opts.EvalWorkers
+		e.evalStop = make(chan struct{})
+	}
+	return e
+}
+
+// resolveNotify returns the effective notification channels for a rule.
+// An explicit Notify list (even empty) wins; otherwise the project's
+// defaults are used.
+func (e *Engine) resolveNotify(rule *Rule) []string {
+	if rule.Notify != nil {
+		return rule.Notify
+	}
+	if rule.ProjectID == "" || e.projectNotify == nil {
+		return nil
+	}
+	return e.projectNotify(rule.ProjectID)
 }
 
 // Alerts returns the channel where triggered alerts are sent.
@@ -109,22 +180,114 @@ func (e *Engine) EvaluateAt(entry *models.LogEntry, now time.Time) []*Alert {
 
 		if alert != nil {
 			alerts = append(alerts, alert)
-			// Send to channel (non-blocking), guarded against closed channel
-			if !e.closed.Load() {
+			e.notify(rule, alert)
+		}
+	}
+
+	return alerts
+}
+
+// notify emits an alert unless its rule is auto-muted for flooding. The
+// notification that trips the flood guard is replaced by a single flood
+// alert; firings while muted are still returned and counted.
+func (e *Engine) notify(rule *Rule, alert *Alert) {
+	ok, tripped := e.flood.allow(rule, alert.Timestamp)
+	if ok {
+		e.emit(alert)
+		return
+	}
+	e.stats.AlertsMuted.Add(1)
+	if !tripped {
+		return
+	}
+
+	e.stats.RulesAutoMuted.Add(1)
+	limit, window := e.flood.limits(rule)
+	until := e.flood.mutedUntil(rule)
+	log.Printf("warning: rule %q auto-muted after %d notifications in %s, until %s or unmuted",
+		rule.Name, limit, window, until.Format(time.RFC3339))
+	e.emit(&Alert{
+		RuleName:    rule.Name,
+		Description: rule.Description,
+		Severity:    rule.Severity,
+		Message: fmt.Sprintf("Rule auto-muted, flooding detected: %d notifications in %s. Firings are still recorded; notifications resume at %s or when the rule is unmuted",
+			limit, window, until.Format(time.RFC3339)),
+		Timestamp: alert.Timestamp,
+		Count:     limit,
+		Window:    window.String(),
+		Notify:    alert.Notify,
+		ProjectID: alert.ProjectID,
+		Labels:    alert.Labels,
+		AutoMuted: true,
+	})
+}
+
+// emit sends an alert to the alerts channel without blocking.
+func (e *Engine) emit(alert *Alert) {
+	// Guarded against closed channel
+	if e.closed.Load() {
+		return
+	}
+	select {
+	case e.alerts <- alert:
+	default:
+		// Channel full, drop alert and track
+		dropped := e.stats.AlertsDropped.Add(1)
+		if dropped == 1 || dropped%100 == 0 {
+			log.Printf("warning: alert channel full, dropped %d alerts total", dropped)
+		}
+	}
+}
+
+// Submit queues an entry for the evaluator goroutines without blocking, so
+// a slow rule set never holds up the caller. While the queue is full the
+// entry is skipped for alerting and false is returned. Without EvalWorkers
+// the entry is evaluated inline.
+func (e *Engine) Submit(entry *models.LogEntry) bool {
+	if e.evalQueue == nil {
+		e.Evaluate(entry)
+		return true
+	}
+	if e.closed.Load() {
+		return false
+	}
+	e.evalStart.Do(e.startEvaluators)
+	select {
+	case e.evalQueue <- entry:
+		return true
+	default:
+		skipped := e.stats.EntriesSkipped.Add(1)
+		if skipped == 1 || skipped%1000 == 0 {
+			log.Printf("warning: alert evaluation queue full, skipped %d entries total", skipped)
+		}
+		return false
+	}
+}
+
+// startEvaluators starts the evaluator goroutines. They run until Close,
+// then finish the entries already queued.
+func (e *Engine) startEvaluators() {
+	for i := 0; i < e.evalWorkers; i++ {
+		e.evalWG.Add(1)
+		go func() {
+			defer e.evalWG.Done()
+			for {
 				select {
-				case e.alerts <- alert:
-				default:
-					// Channel full, drop alert and track
-					dropped := e.stats.AlertsDropped.Add(1)
-					if dropped == 1 || dropped%100 == 0 {
-						log.Printf("warning: alert channel full, dropped %d alerts total", dropped)
+				case entry := <-e.evalQueue:
+					e.Evaluate(entry)
+				case <-e.evalStop:
+					for {
+						select {
+						case entry := <-e.evalQueue:
+							e.Evaluate(entry)
+						default:
+							return
+						}
 					}
 				}
 			}
-		}
+		}()
 	}
-
-	return alerts
 }
 
 // EvaluateStream evaluates log entries from a channel.
@@ -168,8 +331,10 @@ func (e *Engine) evaluatePattern(rule *Rule, entry *models.LogEntry, now time.Ti
 		Message:         fmt.Sprintf("Pattern match: %s", rule.Condition.Pattern),
 		Timestamp:       now,
 		TriggeringEntry: entry,
-		Notify:          rule.Notify,
+		Notify:          e.resolveNotify(rule),
+		ProjectID:       rule.ProjectID,
 		Labels:          rule.Labels,
+		NotifyTemplate:  rule.NotifyTemplate,
 	}
 }
 
@@ -183,7 +348,7 @@ func (e *Engine) evaluateThreshold(rule *Rule, entry *models.LogEntry, now time.
 	e.windows.AddEventAt(rule.Name, rule.GetWindowDuration(), now)
 
 	// Check if threshold is exceeded
-	count := e.windows.Count(rule.Name)
+	count := e.windows.CountAt(rule.Name, now)
 	if count < rule.Condition.Threshold {
 		return nil
 	}
@@ -210,12 +375,14 @@ func (e *Engine) evaluateThreshold(rule *Rule, entry *models.LogEntry, now time.
 		Severity:    rule.Severity,
 		Message: fmt.Sprintf("Threshold exceeded: %d events in %s (threshold: %d)",
 			count, rule.Condition.Window, rule.Condition.Threshold),
-		Timestamp: now,
-		Count:     count,
-		Threshold: rule.Condition.Threshold,
-		Window:    rule.Condition.Window,
-		Notify:    rule.Notify,
-		Labels:    rule.Labels,
+		Timestamp:      now,
+		Count:          count,
+		Threshold:      rule.Condition.Threshold,
+		Window:         rule.Condition.Window,
+		Notify:         e.resolveNotify(rule),
+		ProjectID:      rule.ProjectID,
+		Labels:         rule.Labels,
+		NotifyTemplate: rule.NotifyTemplate,
 	}
 }
 
@@ -242,7 +409,7 @@ func (e *Engine) evaluateExpr(rule *Rule, entry *models.LogEntry, now time.Time)
 	e.windows.AddEventAt(rule.Name, rule.GetAggregationWindowDuration(), now)
 
 	// Get count and check threshold based on function
-	count := e.windows.Count(rule.Name)
+	count := e.windows.CountAt(rule.Name, now)
 	var value float64
 
 	switch agg.Function {
@@ -282,16 +449,18 @@ func (e *Engine) evaluateExpr(rule *Rule, entry *models.LogEntry, now time.Time)
 		rule.Condition.Expression, agg.Function, value, agg.Operator, agg.Threshold, agg.Window)
 
 	return &Alert{
-		RuleName:    rule.Name,
-		Description: rule.Description,
-		Severity:    rule.Severity,
-		Message:     message,
-		Timestamp:   now,
-		Count:       count,
-		Threshold:   int(agg.Threshold),
-		Window:      agg.Window,
-		Notify:      rule.Notify,
-		Labels:      rule.Labels,
+		RuleName:       rule.Name,
+		Description:    rule.Description,
+		Severity:       rule.Severity,
+		Message:        message,
+		Timestamp:      now,
+		Count:          count,
+		Threshold:      int(agg.Threshold),
+		Window:         agg.Window,
+		Notify:         e.resolveNotify(rule),
+		ProjectID:      rule.ProjectID,
+		Labels:         rule.Labels,
+		NotifyTemplate: rule.NotifyTemplate,
 	}
 }
 
@@ -349,7 +518,10 @@ func (e *Engine) RemoveRule(name string) bool {
 		if rule.Name == name {
 			e.rules = append(e.rules[:i], e.rules[i+1:]...)
 			e.windows.Delete(name) // Delete window to prevent memory leak
+			e.parseErrors.deleteRule(name)
 			e.cooldown.Clear(name)
+			e.cooldown.ClearPrefix(name + parseKeySep) // per-source parse_errors cooldowns
+			e.flood.delete(name)
 			return true
 		}
 	}
@@ -393,36 +565,82 @@ func (e *Engine) ReloadRules(rules []*Rule) error {
 
 	e.rules = rules
 	e.windows.DeleteAll() // Delete all windows to prevent memory leaks
+	e.parseErrors.deleteAll()
 	e.cooldown.ClearAll()
+	e.flood.deleteAll()
 
 	return nil
 }
 
+// Unmute lifts a rule's flood auto-mute so it notifies again, starting a
+// new flood window. It reports whether the rule was muted.
+func (e *Engine) Unmute(name string) bool {
+	if !e.flood.unmute(name) {
+		return false
+	}
+	log.Printf("rule %q unmuted", name)
+	return true
+}
+
+// MutedRules returns the names of rules currently auto-muted for flooding.
+func (e *Engine) MutedRules() []string {
+	return e.flood.muted(e.Rules(), time.Now())
+}
+
+// MutedUntil reports whether a rule is auto-muted and when the mute lifts
+// on its own.
+func (e *Engine) MutedUntil(name string) (time.Time, bool) {
+	rule := e.GetRule(name)
+	if rule == nil || !e.flood.isMuted(rule, time.Now()) {
+		return time.Time{}, false
+	}
+	return e.flood.mutedUntil(rule), true
+}
+
 // EngineStatsSnapshot is a snapshot of engine statistics for reporting.
 type EngineStatsSnapshot struct {
-	EntriesEvaluated  int64
-	PatternMatches    int64
-	ThresholdTriggers int64
-	ExprTriggers      int64
-	AlertsSuppressed  int64
-	AlertsDropped     int64
+	EntriesEvaluated   int64
+	PatternMatches     int64
+	ThresholdTriggers  int64
+	ExprTriggers       int64
+	ParseErrorTriggers int64
+	AlertsSuppressed   int64
+	AlertsDropped      int64
+	EntriesSkipped     int64
+	EvalQueueDepth     int
+	AlertsMuted        int64
+	RulesAutoMuted     int64
 }
 
 // Stats returns a snapshot of engine statistics.
 func (e *Engine) Stats() EngineStatsSnapshot {
 	return EngineStatsSnapshot{
-		EntriesEvaluated:  e.stats.EntriesEvaluated.Load(),
-		PatternMatches:    e.stats.PatternMatches.Load(),
-		ThresholdTriggers: e.stats.ThresholdTriggers.Load(),
-		ExprTriggers:      e.stats.ExprTriggers.Load(),
-		AlertsSuppressed:  e.stats.AlertsSuppressed.Load(),
-		AlertsDropped:     e.stats.AlertsDropped.Load(),
+		EntriesEvaluated:   e.stats.EntriesEvaluated.Load(),
+		PatternMatches:     e.stats.PatternMatches.Load(),
+		ThresholdTriggers:  e.stats.ThresholdTriggers.Load(),
+		ExprTriggers:       e.stats.ExprTriggers.Load(),
+		ParseErrorTriggers: e.stats.ParseErrorTriggers.Load(),
+		AlertsSuppressed:   e.stats.AlertsSuppressed.Load(),
+		AlertsDropped:      e.stats.AlertsDropped.Load(),
+		EntriesSkipped:     e.stats.EntriesSkipped.Load(),
+		EvalQueueDepth:     len(e.evalQueue),
+		AlertsMuted:        e.stats.AlertsMuted.Load(),
+		RulesAutoMuted:     e.stats.RulesAutoMuted.Load(),
 	}
 }
 
-// Close closes the engine and releases resources.
+// Close closes the engine and releases resources. Entries already queued by
+// Submit are evaluated first.
 // Safe to call concurrently with Evaluate.
 func (e *Engine) Close() {
+	// Stop the evaluators before marking closed so their alerts still go out
+	e.evalClose.Do(func() {
+		e.evalStart.Do(func() {}) // no evaluators start after this
+		if e.evalStop != nil {
+			close(e.evalStop)
+			e.evalWG.Wait()
+		}
+	})
 	if e.closed.Swap(true) {
 		return // Already closed
 	}
@@ -470,6 +688,30 @@ func (cm *CooldownManager) Clear(ruleName string) {
 	delete(cm.cooldowns, ruleName)
 }
 
+// ClearPrefix removes cooldowns whose key starts with prefix.
+func (cm *CooldownManager) ClearPrefix(prefix string) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	for key := range cm.cooldowns {
+		if strings.HasPrefix(key, prefix) {
+			delete(cm.cooldowns, key)
+		}
+	}
+}
+
+// ClearExpired removes cooldowns that have expired by now.
+func (cm *CooldownManager) ClearExpired(now time.Time) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	for key, expiresAt := range cm.cooldowns {
+		if !now.Before(expiresAt) {
+			delete(cm.cooldowns, key)
+		}
+	}
+}
+
 // ClearAll removes all cooldowns.
 func (cm *CooldownManager) ClearAll() {
 	cm.mu.Lock()
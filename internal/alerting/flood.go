@@ -0,0 +1,140 @@
+package alerting
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Flood guard defaults, used when neither the rule nor the engine options
+// set a limit.
+const (
+	DefaultMuteAfter  = 100
+	DefaultMuteWindow = time.Hour
+)
+
+// floodState counts one rule's notifications in its current window.
+type floodState struct {
+	start time.Time
+	sent  int
+	muted bool
+}
+
+// floodGuard auto-mutes rules that send too many notifications in a fixed
+// window. It is a last-resort guard against notification storms that
+// cooldowns don't prevent (e.g. a rule with no cooldown, or one whose
+// cooldown is shorter than the incident).
+type floodGuard struct {
+	mu     sync.Mutex
+	limit  int           // engine default (negative = never mute)
+	window time.Duration // engine default
+	rules  map[string]*floodState
+}
+
+func newFloodGuard(limit int, window time.Duration) *floodGuard {
+	if limit == 0 {
+		limit = DefaultMuteAfter
+	}
+	if window <= 0 {
+		window = DefaultMuteWindow
+	}
+	return &floodGuard{limit: limit, window: window, rules: make(map[string]*floodState)}
+}
+
+// limits returns the effective limit and window for a rule.
+func (g *floodGuard) limits(rule *Rule) (int, time.Duration) {
+	limit, window := g.limit, g.window
+	if rule.MuteAfter != 0 {
+		limit = rule.MuteAfter
+	}
+	if rule.muteWindowDuration > 0 {
+		window = rule.muteWindowDuration
+	}
+	return limit, window
+}
+
+// allow counts a notification for rule at now and reports whether it may be
+// sent. tripped is true for the one notification that mutes the rule.
+func (g *floodGuard) allow(rule *Rule, now time.Time) (ok, tripped bool) {
+	limit, window := g.limits(rule)
+	if limit < 0 {
+		return true, false
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	s, exists := g.rules[rule.Name]
+	if !exists || now.Sub(s.start) >= window {
+		s = &floodState{start: now}
+		g.rules[rule.Name] = s
+	}
+	if s.muted {
+		return false, false
+	}
+	if s.sent >= limit {
+		s.muted = true
+		return false, true
+	}
+	s.sent++
+	return true, false
+}
+
+// mutedUntil returns when a rule's window resets.
+func (g *floodGuard) mutedUntil(rule *Rule) time.Time {
+	_, window := g.limits(rule)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if s, ok := g.rules[rule.Name]; ok {
+		return s.start.Add(window)
+	}
+	return time.Time{}
+}
+
+// unmute lifts a rule's mute and starts a new window. It reports whether
+// the rule was muted.
+func (g *floodGuard) unmute(ruleName string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	s, ok := g.rules[ruleName]
+	if !ok || !s.muted {
+		return false
+	}
+	delete(g.rules, ruleName)
+	return true
+}
+
+// isMuted reports whether a rule is muted and its window has not reset by now.
+func (g *floodGuard) isMuted(rule *Rule, now time.Time) bool {
+	_, window := g.limits(rule)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	s, ok := g.rules[rule.Name]
+	return ok && s.muted && now.Sub(s.start) < window
+}
+
+// muted returns the names of muted rules whose window has not reset by now.
+func (g *floodGuard) muted(rules []*Rule, now time.Time) []string {
+	var names []string
+	for _, rule := range rules {
+		if g.isMuted(rule, now) {
+			names = append(names, rule.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// delete removes a rule's state.
+func (g *floodGuard) delete(ruleName string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.rules, ruleName)
+}
+
+// deleteAll removes all rule state.
+func (g *floodGuard) deleteAll() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.rules = make(map[string]*floodState)
+}
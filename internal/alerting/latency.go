@@ -0,0 +1,204 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// QueryLatencyRuleName names the alerts of the query latency monitor.
+const QueryLatencyRuleName = "blazelog-query-latency"
+
+// Query latency monitor defaults.
+const (
+	DefaultLatencyInterval = 30 * time.Second
+	latencyQuantile        = 0.95
+)
+
+// QueryLatencyConfig configures self-monitoring of storage query latency.
+type QueryLatencyConfig struct {
+	// Threshold is the p95 query latency considered slow.
+	Threshold time.Duration
+	// Window is how long p95 must stay above Threshold before alerting.
+	Window time.Duration
+	// Interval is how often p95 is evaluated, over the queries finished
+	// since the last evaluation (0 = DefaultLatencyInterval).
+	Interval time.Duration
+	// Notify lists the notification channels to alert.
+	Notify []string
+	// Severity of the alert (default: high).
+	Severity Severity
+}
+
+// QueryLatencyMonitor alerts when BlazeLog's own storage queries stay slow.
+// On every tick it estimates the p95 latency of the queries recorded in a
+// latency histogram since the previous tick. Once p95 has been above the
+// threshold for the whole window it sends one alert; after latency recovers
+// it logs the recovery and can fire again. Ticks without queries leave the
+// state unchanged.
+type QueryLatencyMonitor struct {
+	cfg       QueryLatencyConfig
+	histogram prometheus.Collector
+	send      func(ctx context.Context, alert *Alert) error
+
+	prev      histogramSnapshot
+	slowSince time.Time // zero while latency is fine
+	fired     bool      // alerted for the current slow period
+}
+
+// NewQueryLatencyMonitor creates a monitor reading histogram (for example
+// metrics.StorageQueryDuration, summed over all its label values) and
+// alerting through send.
+func NewQueryLatencyMonitor(cfg QueryLatencyConfig, histogram prometheus.Collector, send func(context.Context, *Alert) error) *QueryLatencyMonitor {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultLatencyInterval
+	}
+	if cfg.Severity == "" {
+		cfg.Severity = SeverityHigh
+	}
+	m := &QueryLatencyMonitor{cfg: cfg, histogram: histogram, send: send}
+	m.prev = m.snapshot()
+	return m
+}
+
+// Run evaluates latency every interval until ctx is done.
+func (m *QueryLatencyMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.tick(ctx, now)
+		}
+	}
+}
+
+// tick evaluates the queries finished since the previous tick.
+func (m *QueryLatencyMonitor) tick(ctx context.Context, now time.Time) {
+	cur := m.snapshot()
+	p95, ok := cur.sub(m.prev).quantile(latencyQuantile)
+	m.prev = cur
+	if !ok {
+		return
+	}
+
+	if p95 <= m.cfg.Threshold {
+		if m.fired {
+			log.Printf("query latency recovered: p95 %s is below %s", p95, m.cfg.Threshold)
+		}
+		m.slowSince, m.fired = time.Time{}, false
+		return
+	}
+
+	if m.slowSince.IsZero() {
+		// The interval just evaluated was already slow
+		m.slowSince = now.Add(-m.cfg.Interval)
+	}
+	if m.fired || now.Sub(m.slowSince) < m.cfg.Window {
+		return
+	}
+	m.fired = true
+
+	alert := &Alert{
+		RuleName:    QueryLatencyRuleName,
+		Description: "BlazeLog storage queries are slow",
+		Severity:    m.cfg.Severity,
+		Message: fmt.Sprintf("p95 storage query latency has been above %s for %s (now %s)",
+			m.cfg.Threshold, now.Sub(m.slowSince).Round(time.Second), p95.Round(time.Millisecond)),
+		Timestamp: now,
+		Window:    m.cfg.Window.String(),
+		Notify:    m.cfg.Notify,
+		Labels:    map[string]string{"self_monitoring": "true"},
+	}
+	if err := m.send(ctx, alert); err != nil {
+		log.Printf("warning: send query latency alert: %v", err)
+	}
+}
+
+// histogramSnapshot holds cumulative histogram counts: counts[i] is the
+// number of observations at most bounds[i], and total includes those above
+// the last bound.
+type histogramSnapshot struct {
+	bounds []float64
+	counts []uint64
+	total  uint64
+}
+
+// snapshot sums the histogram over all its label values.
+func (m *QueryLatencyMonitor) snapshot() histogramSnapshot {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		m.histogram.Collect(ch)
+		close(ch)
+	}()
+
+	var snap histogramSnapshot
+	for metric := range ch {
+		var pb dto.Metric
+		if err := metric.Write(&pb); err != nil || pb.Histogram == nil {
+			continue
+		}
+		h := pb.Histogram
+		if snap.bounds == nil {
+			for _, b := range h.Bucket {
+				snap.bounds = append(snap.bounds, b.GetUpperBound())
+			}
+			snap.counts = make([]uint64, len(snap.bounds))
+		}
+		for i, b := range h.Bucket {
+			if i < len(snap.counts) {
+				snap.counts[i] += b.GetCumulativeCount()
+			}
+		}
+		snap.total += h.GetSampleCount()
+	}
+	return snap
+}
+
+// sub returns the observations in s that aren't in the earlier prev.
+func (s histogramSnapshot) sub(prev histogramSnapshot) histogramSnapshot {
+	if len(prev.counts) != len(s.counts) {
+		return s
+	}
+	d := histogramSnapshot{bounds: s.bounds, counts: make([]uint64, len(s.counts)), total: s.total - prev.total}
+	for i := range s.counts {
+		d.counts[i] = s.counts[i] - prev.counts[i]
+	}
+	return d
+}
+
+// quantile estimates the q-quantile in the way of PromQL's
+// histogram_quantile: linear interpolation within the bucket holding it,
+// capped at the highest finite bound. It returns false without
+// observations.
+func (s histogramSnapshot) quantile(q float64) (time.Duration, bool) {
+	if s.total == 0 || len(s.bounds) == 0 {
+		return 0, false
+	}
+	rank := q * float64(s.total)
+	lower, below := 0.0, uint64(0)
+	for i, bound := range s.bounds {
+		if float64(s.counts[i]) >= rank {
+			inBucket := float64(s.counts[i] - below)
+			v := bound
+			if inBucket > 0 {
+				v = lower + (bound-lower)*(rank-float64(below))/inBucket
+			}
+			return secondsToDuration(v), true
+		}
+		lower, below = bound, s.counts[i]
+	}
+	return secondsToDuration(s.bounds[len(s.bounds)-1]), true
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(math.Round(s * float64(time.Second)))
+}
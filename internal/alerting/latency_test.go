@@ -0,0 +1,98 @@
+package alerting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestHistogramQuantile(t *testing.T) {
+	snap := histogramSnapshot{
+		bounds: []float64{0.1, 0.5, 1},
+		counts: []uint64{50, 90, 100},
+		total:  100,
+	}
+	// rank 95 lies halfway through the 0.5-1 bucket
+	if got, ok := snap.quantile(0.95); !ok || got != 750*time.Millisecond {
+		t.Errorf("quantile(0.95) = %s, %v, want 750ms", got, ok)
+	}
+	if got, _ := snap.quantile(0.5); got != 100*time.Millisecond {
+		t.Errorf("quantile(0.5) = %s, want 100ms", got)
+	}
+
+	// Observations above the last bound are capped at it
+	snap.total = 200
+	if got, _ := snap.quantile(0.95); got != time.Second {
+		t.Errorf("quantile(0.95) with +Inf observations = %s, want 1s", got)
+	}
+	if _, ok := (histogramSnapshot{}).quantile(0.95); ok {
+		t.Error("quantile() without observations should report no value")
+	}
+}
+
+func TestQueryLatencyMonitor(t *testing.T) {
+	hist := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "test_query_duration_seconds",
+		Buckets: []float64{.1, .5, 1, 2.5, 5},
+	}, []string{"operation"})
+
+	var sent []*Alert
+	m := NewQueryLatencyMonitor(QueryLatencyConfig{
+		Threshold: time.Second,
+		Window:    time.Minute,
+		Interval:  20 * time.Second,
+		Notify:    []string{"slack"},
+	}, hist, func(_ context.Context, a *Alert) error {
+		sent = append(sent, a)
+		return nil
+	})
+
+	observe := func(seconds float64) {
+		for i := 0; i < 10; i++ {
+			hist.WithLabelValues("query").Observe(seconds)
+			hist.WithLabelValues("count").Observe(seconds)
+		}
+	}
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	// Slow for two ticks, then an idle tick, which changes nothing
+	observe(3)
+	m.tick(ctx, start.Add(20*time.Second))
+	observe(3)
+	m.tick(ctx, start.Add(40*time.Second))
+	m.tick(ctx, start.Add(60*time.Second))
+	if len(sent) != 0 {
+		t.Fatalf("alerted after 40s of slow queries, want a 1m window")
+	}
+
+	observe(3)
+	m.tick(ctx, start.Add(80*time.Second))
+	if len(sent) != 1 {
+		t.Fatalf("sent %d alerts after a slow window, want 1", len(sent))
+	}
+	a := sent[0]
+	if a.RuleName != QueryLatencyRuleName || a.Severity != SeverityHigh || len(a.Notify) != 1 || a.Labels["self_monitoring"] != "true" {
+		t.Errorf("alert = %+v", a)
+	}
+
+	// Still slow: no repeat for the same slow period
+	observe(3)
+	m.tick(ctx, start.Add(100*time.Second))
+	if len(sent) != 1 {
+		t.Errorf("sent %d alerts while still slow, want 1", len(sent))
+	}
+
+	// Recovery resets, so a new slow period alerts again
+	observe(0.05)
+	m.tick(ctx, start.Add(120*time.Second))
+	for i := 1; i <= 3; i++ {
+		observe(3)
+		m.tick(ctx, start.Add(time.Duration(120+20*i)*time.Second))
+	}
+	if len(sent) != 2 {
+		t.Errorf("sent %d alerts after recovering and slowing again, want 2", len(sent))
+	}
+}
@@ -0,0 +1,69 @@
+package alerting
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/models"
+)
+
+// notifyTemplateFuncs are the functions available to notification templates.
+var notifyTemplateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// ParseNotifyTemplate parses a notification template. Templates are Go
+// text/template executed with the *Alert, e.g.
+// "{{.RuleName}}: {{.Count}} errors, runbook {{.Labels.runbook}}".
+// Missing label keys render as empty strings.
+func ParseNotifyTemplate(text string) (*template.Template, error) {
+	return template.New("notify").Funcs(notifyTemplateFuncs).Option("missingkey=zero").Parse(text)
+}
+
+// ValidateNotifyTemplate parses text and renders it against sample alerts,
+// catching references to fields an Alert does not have. Only pattern alerts
+// have a TriggeringEntry, so the template is rendered both with and without
+// one.
+func ValidateNotifyTemplate(text string) error {
+	tmpl, err := ParseNotifyTemplate(text)
+	if err != nil {
+		return err
+	}
+	sample := &Alert{
+		RuleName:        "sample",
+		Severity:        SeverityMedium,
+		Timestamp:       time.Now(),
+		TriggeringEntry: &models.LogEntry{},
+		Labels:          map[string]string{},
+	}
+	if err := tmpl.Execute(&bytes.Buffer{}, sample); err != nil {
+		return err
+	}
+	sample.TriggeringEntry = nil
+	if err := tmpl.Execute(&bytes.Buffer{}, sample); err != nil {
+		return fmt.Errorf("alerts without a triggering entry (only pattern alerts have one; guard it with {{with .TriggeringEntry}}): %w", err)
+	}
+	return nil
+}
+
+// RenderNotification renders the alert's notification template. It returns
+// ok=false when the alert has no template, in which case channels use their
+// default formatting.
+func (a *Alert) RenderNotification() (text string, ok bool, err error) {
+	if a.NotifyTemplate == "" {
+		return "", false, nil
+	}
+	tmpl, err := ParseNotifyTemplate(a.NotifyTemplate)
+	if err != nil {
+		return "", false, fmt.Errorf("parse notify template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, a); err != nil {
+		return "", false, fmt.Errorf("render notify template: %w", err)
+	}
+	return buf.String(), true, nil
+}
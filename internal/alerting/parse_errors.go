@@ -0,0 +1,249 @@
+package alerting
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// parseBucketCount is the number of buckets a parse error window is split
+// into. Counts are approximate to one bucket width at the window's edge.
+const parseBucketCount = 60
+
+// parseKeySep separates rule name and source in parse window keys.
+const parseKeySep = "\x00"
+
+// defaultParseMinSamples is the number of parse attempts a rate-only
+// parse_errors rule needs in its window before it can fire, so the first
+// failed line of a new source isn't a 100% error rate.
+const defaultParseMinSamples = 10
+
+// maxParseSources caps the windows tracked across parse_errors rules. When
+// full, idle windows are dropped first, then the least recently seen one.
+const maxParseSources = 10000
+
+// parseBucket counts parse results within one bucket of a window.
+type parseBucket struct {
+	start  time.Time
+	total  int
+	failed int
+}
+
+// parseErrorWindow counts parse attempts and failures over a sliding window.
+// Unlike SlidingWindow it keeps fixed-size buckets rather than timestamps, so
+// high-volume sources don't grow memory or skew the rate via eviction.
+type parseErrorWindow struct {
+	width   time.Duration
+	last    time.Time
+	buckets [parseBucketCount]parseBucket
+}
+
+func newParseErrorWindow(window time.Duration) *parseErrorWindow {
+	width := window / parseBucketCount
+	if width <= 0 {
+		width = time.Nanosecond
+	}
+	return &parseErrorWindow{width: width}
+}
+
+// add records one parse result at now.
+func (w *parseErrorWindow) add(failed bool, now time.Time) {
+	start := now.Truncate(w.width)
+	b := &w.buckets[(start.UnixNano()/int64(w.width))%parseBucketCount]
+	if !b.start.Equal(start) {
+		*b = parseBucket{start: start}
+	}
+	w.last = now
+	b.total++
+	if failed {
+		b.failed++
+	}
+}
+
+// counts returns the totals for buckets inside the window ending at now.
+func (w *parseErrorWindow) counts(now time.Time) (total, failed int) {
+	cutoff := now.Truncate(w.width).Add(-w.width * (parseBucketCount - 1))
+	for _, b := range w.buckets {
+		if b.start.IsZero() || b.start.Before(cutoff) {
+			continue
+		}
+		total += b.total
+		failed += b.failed
+	}
+	return total, failed
+}
+
+// idle reports whether the window has seen no results within its span.
+func (w *parseErrorWindow) idle(now time.Time) bool {
+	return now.Sub(w.last) >= w.width*parseBucketCount
+}
+
+// parseErrorTracker holds parse error windows per rule and source.
+type parseErrorTracker struct {
+	mu      sync.Mutex
+	windows map[string]*parseErrorWindow
+	max     int
+}
+
+func newParseErrorTracker() *parseErrorTracker {
+	return &parseErrorTracker{
+		windows: make(map[string]*parseErrorWindow),
+		max:     maxParseSources,
+	}
+}
+
+// record adds a parse result and returns the updated counts. evicted reports
+// whether other windows were dropped to make room for a new source.
+func (t *parseErrorTracker) record(key string, window time.Duration, failed bool, now time.Time) (total, failedCount int, evicted bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	w, ok := t.windows[key]
+	if !ok {
+		if len(t.windows) >= t.max {
+			t.evict(now)
+			evicted = true
+		}
+		w = newParseErrorWindow(window)
+		t.windows[key] = w
+	}
+	w.add(failed, now)
+	total, failedCount = w.counts(now)
+	return total, failedCount, evicted
+}
+
+// evict drops idle windows, or the least recently seen one if none are idle.
+// Caller must hold t.mu.
+func (t *parseErrorTracker) evict(now time.Time) {
+	var oldestKey string
+	var oldest time.Time
+	for key, w := range t.windows {
+		if w.idle(now) {
+			delete(t.windows, key)
+			continue
+		}
+		if oldestKey == "" || w.last.Before(oldest) {
+			oldestKey, oldest = key, w.last
+		}
+	}
+	if len(t.windows) >= t.max {
+		delete(t.windows, oldestKey)
+	}
+}
+
+// reset clears a window after it triggered.
+func (t *parseErrorTracker) reset(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.windows, key)
+}
+
+// deleteRule removes all windows belonging to a rule.
+func (t *parseErrorTracker) deleteRule(ruleName string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key := range t.windows {
+		if strings.HasPrefix(key, ruleName+parseKeySep) {
+			delete(t.windows, key)
+		}
+	}
+}
+
+// deleteAll removes all windows.
+func (t *parseErrorTracker) deleteAll() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.windows = make(map[string]*parseErrorWindow)
+}
+
+// minParseSamples returns the parse attempts a parse_errors rule needs in
+// its window before it can fire.
+func (r *Rule) minParseSamples() int {
+	if r.Condition.MinSamples > 0 {
+		return r.Condition.MinSamples
+	}
+	if r.Condition.Threshold == 0 {
+		return defaultParseMinSamples
+	}
+	return 0
+}
+
+// RecordParse feeds one parse attempt for a source (file, source name or
+// agent ID) into parse_errors rules. Returns any triggered alerts.
+func (e *Engine) RecordParse(source string, ok bool) []*Alert {
+	return e.RecordParseAt(source, ok, time.Now())
+}
+
+// RecordParseAt records a parse attempt at a specific time (useful for testing).
+func (e *Engine) RecordParseAt(source string, ok bool, now time.Time) []*Alert {
+	e.mu.RLock()
+	rules := e.rules
+	e.mu.RUnlock()
+
+	var alerts []*Alert
+	for _, rule := range rules {
+		if rule.Type != RuleTypeParseErrors || !rule.IsEnabled() {
+			continue
+		}
+		if rule.Condition.Source != "" && rule.Condition.Source != source {
+			continue
+		}
+		if alert := e.evaluateParseErrors(rule, source, ok, now); alert != nil {
+			alerts = append(alerts, alert)
+			e.notify(rule, alert)
+		}
+	}
+	return alerts
+}
+
+// evaluateParseErrors updates a parse_errors rule's window for a source.
+func (e *Engine) evaluateParseErrors(rule *Rule, source string, ok bool, now time.Time) *Alert {
+	key := rule.Name + parseKeySep + source
+	total, failed, evicted := e.parseErrors.record(key, rule.GetWindowDuration(), !ok, now)
+	if evicted {
+		// Sources come and go; drop cooldowns that no longer matter with them
+		e.cooldown.ClearExpired(now)
+	}
+
+	if failed == 0 || failed < rule.Condition.Threshold {
+		return nil
+	}
+	if total < rule.minParseSamples() {
+		return nil
+	}
+	rate := float64(failed) / float64(total)
+	if rate < rule.Condition.ErrorRate {
+		return nil
+	}
+
+	e.stats.ParseErrorTriggers.Add(1)
+
+	// Cooldown is tracked per source so one noisy source can't mute others
+	if e.cooldown.IsOnCooldown(key, now) {
+		e.stats.AlertsSuppressed.Add(1)
+		return nil
+	}
+	if rule.GetCooldownDuration() > 0 {
+		e.cooldown.SetCooldown(key, rule.GetCooldownDuration(), now)
+	}
+
+	// Reset window after alert (prevents repeated alerts for same failures)
+	e.parseErrors.reset(key)
+
+	return &Alert{
+		RuleName:    rule.Name,
+		Description: rule.Description,
+		Severity:    rule.Severity,
+		Message: fmt.Sprintf("Parse errors for %s: %d of %d lines failed (%.1f%%) in %s",
+			source, failed, total, rate*100, rule.Condition.Window),
+		Timestamp:      now,
+		Count:          failed,
+		Threshold:      rule.Condition.Threshold,
+		Window:         rule.Condition.Window,
+		Notify:         e.resolveNotify(rule),
+		ProjectID:      rule.ProjectID,
+		Labels:         rule.Labels,
+		NotifyTemplate: rule.NotifyTemplate,
+	}
+}
@@ -21,6 +21,9 @@ const (
 	RuleTypeThreshold RuleType = "threshold"
 	// RuleTypeExpr triggers based on expr-lang expression with aggregation.
 	RuleTypeExpr RuleType = "expr"
+	// RuleTypeParseErrors triggers when a source's parse-error rate exceeds
+	// a threshold in window. Fed by Engine.RecordParse, not Evaluate.
+	RuleTypeParseErrors RuleType = "parse_errors"
 )
 
 // Severity represents the severity level of an alert.
@@ -83,6 +86,17 @@ type Condition struct {
 	// LogType filters by log type (e.g., "nginx", "magento").
 	LogType string `yaml:"log_type,omitempty"`
 
+	// ErrorRate is the fraction of failed parses (0-1] that triggers a
+	// parse_errors rule. Zero means only Threshold (failure count) applies.
+	ErrorRate float64 `yaml:"error_rate,omitempty"`
+	// MinSamples is the number of parse attempts a parse_errors rule needs
+	// in its window before it can fire. Zero defaults to 10 for rules
+	// without a Threshold.
+	MinSamples int `yaml:"min_samples,omitempty"`
+	// Source limits a parse_errors rule to one source or agent. Empty
+	// tracks every source separately.
+	Source string `yaml:"source,omitempty"`
+
 	// Expression is the expr-lang filter expression for expr-based rules.
 	Expression string `yaml:"expression,omitempty"`
 	// Aggregation defines how matching entries are aggregated for expr rules.
@@ -102,23 +116,37 @@ type Rule struct {
 	Name string `yaml:"name"`
 	// Description provides details about what the rule detects.
 	Description string `yaml:"description,omitempty"`
-	// Type is either "pattern" or "threshold".
+	// Type is "pattern", "threshold", "expr" or "parse_errors".
 	Type RuleType `yaml:"type"`
 	// Condition defines when the rule triggers.
 	Condition Condition `yaml:"condition"`
 	// Severity indicates the importance of the alert.
 	Severity Severity `yaml:"severity"`
-	// Notify lists the notification channels to use.
+	// Notify lists the notification channels to use. When unset, the rule
+	// inherits its project's default channels; an explicit empty list
+	// (notify: []) opts out of notifications.
 	Notify []string `yaml:"notify,omitempty"`
+	// ProjectID is the project the rule belongs to (used for notify defaults).
+	ProjectID string `yaml:"project_id,omitempty"`
 	// Cooldown is the minimum time between repeated alerts.
 	Cooldown string `yaml:"cooldown,omitempty"`
 	// Labels filter which logs this rule applies to.
 	Labels map[string]string `yaml:"labels,omitempty"`
 	// Enabled controls whether the rule is active.
 	Enabled *bool `yaml:"enabled,omitempty"`
+	// NotifyTemplate overrides the channels' default message formatting.
+	// See ParseNotifyTemplate.
+	NotifyTemplate string `yaml:"notify_template,omitempty"`
+	// MuteAfter auto-mutes the rule after this many notifications within
+	// MuteWindow (0 = engine default, -1 = never mute).
+	MuteAfter int `yaml:"mute_after,omitempty"`
+	// MuteWindow is the window MuteAfter counts over (default: engine's, 1h).
+	MuteWindow string `yaml:"mute_window,omitempty"`
 
 	// cooldownDuration is the parsed cooldown duration (internal use).
 	cooldownDuration time.Duration
+	// muteWindowDuration is the parsed mute window (internal use).
+	muteWindowDuration time.Duration
 }
 
 // IsEnabled returns whether the rule is enabled.
@@ -139,7 +167,7 @@ func (r *Rule) Validate() error {
 		return fmt.Errorf("rule type is required for rule %q", r.Name)
 	}
 
-	if r.Type != RuleTypePattern && r.Type != RuleTypeThreshold && r.Type != RuleTypeExpr {
+	if r.Type != RuleTypePattern && r.Type != RuleTypeThreshold && r.Type != RuleTypeExpr && r.Type != RuleTypeParseErrors {
 		return fmt.Errorf("invalid rule type %q for rule %q", r.Type, r.Name)
 	}
 
@@ -187,6 +215,33 @@ func (r *Rule) Validate() error {
 		}
 	}
 
+	// Validate parse error rules
+	if r.Type == RuleTypeParseErrors {
+		if r.Condition.Threshold < 0 {
+			return fmt.Errorf("threshold must not be negative for rule %q", r.Name)
+		}
+		if r.Condition.ErrorRate < 0 || r.Condition.ErrorRate > 1 {
+			return fmt.Errorf("error_rate must be between 0 and 1 for rule %q", r.Name)
+		}
+		if r.Condition.MinSamples < 0 {
+			return fmt.Errorf("min_samples must not be negative for rule %q", r.Name)
+		}
+		if r.Condition.Threshold == 0 && r.Condition.ErrorRate == 0 {
+			return fmt.Errorf("threshold or error_rate is required for parse_errors rule %q", r.Name)
+		}
+		if r.Condition.Window == "" {
+			return fmt.Errorf("window is required for parse_errors rule %q", r.Name)
+		}
+		windowDur, err := time.ParseDuration(r.Condition.Window)
+		if err != nil {
+			return fmt.Errorf("invalid window %q for rule %q: %w", r.Condition.Window, r.Name, err)
+		}
+		if windowDur <= 0 {
+			return fmt.Errorf("window must be positive for rule %q", r.Name)
+		}
+		r.Condition.windowDuration = windowDur
+	}
+
 	// Validate expr rules
 	if r.Type == RuleTypeExpr {
 		if r.Condition.Expression == "" {
@@ -250,6 +305,26 @@ func (r *Rule) Validate() error {
 		r.cooldownDuration = cooldownDur
 	}
 
+	if r.MuteAfter < -1 {
+		return fmt.Errorf("mute_after must be -1 (never), 0 (default) or positive for rule %q", r.Name)
+	}
+	if r.MuteWindow != "" {
+		muteDur, err := time.ParseDuration(r.MuteWindow)
+		if err != nil {
+			return fmt.Errorf("invalid mute_window %q for rule %q: %w", r.MuteWindow, r.Name, err)
+		}
+		if muteDur <= 0 {
+			return fmt.Errorf("mute_window must be positive for rule %q", r.Name)
+		}
+		r.muteWindowDuration = muteDur
+	}
+
+	if r.NotifyTemplate != "" {
+		if err := ValidateNotifyTemplate(r.NotifyTemplate); err != nil {
+			return fmt.Errorf("invalid notify_template for rule %q: %w", r.Name, err)
+		}
+	}
+
 	// Default severity
 	if r.Severity == "" {
 		r.Severity = SeverityMedium
@@ -333,8 +408,15 @@ type Alert struct {
 	TriggeringEntry *models.LogEntry `json:"triggering_entry,omitempty"`
 	// Notify is the list of notification channels.
 	Notify []string `json:"notify,omitempty"`
+	// ProjectID is the project of the rule that triggered.
+	ProjectID string `json:"project_id,omitempty"`
 	// Labels from the rule.
 	Labels map[string]string `json:"labels,omitempty"`
+	// NotifyTemplate is the rule's notification template, if any.
+	NotifyTemplate string `json:"-"`
+	// AutoMuted marks the alert sent when the rule was auto-muted for
+	// flooding; the rule sends no further notifications until unmuted.
+	AutoMuted bool `json:"auto_muted,omitempty"`
 }
 
 // RulesConfig represents the top-level YAML configuration.
@@ -10,20 +10,53 @@ const (
 	maxTotalEvents   = 100000
 )
 
+// windowBucketCount is the number of buckets a window is split into once it
+// exceeds its event cap. Counts are then approximate to one bucket width at
+// the window's edge.
+const windowBucketCount = 120
+
+// windowBucket counts events within one bucket of a window.
+type windowBucket struct {
+	start time.Time
+	count int
+}
+
 // SlidingWindow maintains a count of events within a sliding time window.
+// Events are kept as timestamps, giving exact counts, until the window holds
+// maxSize of them; it then switches to counting per bucket so memory stays
+// bounded however high the event rate. It returns to exact counting once the
+// bucketed events have aged out.
 type SlidingWindow struct {
-	mu       sync.RWMutex
-	window   time.Duration
-	events   []time.Time
-	maxSize  int
+	mu      sync.RWMutex
+	window  time.Duration
+	events  []time.Time
+	maxSize int
+
+	width   time.Duration  // bucket width
+	buckets []windowBucket // non-nil while counting per bucket
 }
 
 // NewSlidingWindow creates a new sliding window with the given duration.
 func NewSlidingWindow(window time.Duration) *SlidingWindow {
+	return NewSlidingWindowWithMax(window, maxEventsPerRule)
+}
+
+// NewSlidingWindowWithMax creates a sliding window that keeps at most
+// maxEvents timestamps before switching to bucketed counts. A maxEvents of
+// zero or less uses the default.
+func NewSlidingWindowWithMax(window time.Duration, maxEvents int) *SlidingWindow {
+	if maxEvents <= 0 {
+		maxEvents = maxEventsPerRule
+	}
+	width := window / windowBucketCount
+	if width <= 0 {
+		width = time.Nanosecond
+	}
 	return &SlidingWindow{
 		window:  window,
-		events:  make([]time.Time, 0, 1000),
-		maxSize: maxEventsPerRule,
+		events:  make([]time.Time, 0, min(maxEvents, 1000)),
+		maxSize: maxEvents,
+		width:   width,
 	}
 }
 
@@ -37,17 +70,54 @@ func (w *SlidingWindow) AddAt(t time.Time) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
+	w.addLocked(t)
+}
+
+// addLocked records an event at t. Must be called with lock held.
+func (w *SlidingWindow) addLocked(t time.Time) {
 	// Prune old events first
 	w.pruneOldLocked(t)
 
+	if w.buckets != nil {
+		w.addBucketLocked(t)
+		return
+	}
+
 	// Add the new event
 	w.events = append(w.events, t)
 
-	// If we exceed max size, drop oldest events
+	// Past the cap, trade per-event timestamps for bucketed counts
 	if len(w.events) > w.maxSize {
-		// Keep only the most recent half
-		w.events = w.events[len(w.events)/2:]
+		w.buckets = make([]windowBucket, windowBucketCount)
+		for _, ev := range w.events {
+			w.addBucketLocked(ev)
+		}
+		w.events = nil
+	}
+}
+
+// addBucketLocked counts an event at t in its bucket. Must be called with
+// lock held.
+func (w *SlidingWindow) addBucketLocked(t time.Time) {
+	start := t.Truncate(w.width)
+	b := &w.buckets[(start.UnixNano()/int64(w.width))%windowBucketCount]
+	if b.start.After(start) {
+		// Older than anything the bucket still covers
+		return
 	}
+	if !b.start.Equal(start) {
+		*b = windowBucket{start: start}
+	}
+	b.count++
+}
+
+// Approximate reports whether the window is counting per bucket rather than
+// per event.
+func (w *SlidingWindow) Approximate() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.buckets != nil
 }
 
 // Count returns the number of events within the window.
@@ -61,12 +131,37 @@ func (w *SlidingWindow) CountAt(t time.Time) int {
 	defer w.mu.Unlock()
 
 	w.pruneOldLocked(t)
+	if w.buckets != nil {
+		return w.bucketCountLocked(t)
+	}
 	return len(w.events)
 }
 
-// pruneOldLocked removes events older than the window.
+// bucketCountLocked sums the buckets inside the window ending at now.
+// Must be called with lock held.
+func (w *SlidingWindow) bucketCountLocked(now time.Time) int {
+	cutoff := now.Truncate(w.width).Add(-w.width * (windowBucketCount - 1))
+	count := 0
+	for _, b := range w.buckets {
+		if b.start.IsZero() || b.start.Before(cutoff) {
+			continue
+		}
+		count += b.count
+	}
+	return count
+}
+
+// pruneOldLocked removes events older than the window, and leaves bucketed
+// counting once every bucket has aged out.
 // Must be called with lock held.
 func (w *SlidingWindow) pruneOldLocked(now time.Time) {
+	if w.buckets != nil {
+		if w.bucketCountLocked(now) == 0 {
+			w.buckets = nil
+		}
+		return
+	}
+
 	cutoff := now.Add(-w.window)
 
 	// Binary search for the first event after cutoff
@@ -92,6 +187,7 @@ func (w *SlidingWindow) Reset() {
 	defer w.mu.Unlock()
 
 	w.events = w.events[:0]
+	w.buckets = nil
 }
 
 // WindowDuration returns the configured window duration.
@@ -104,12 +200,23 @@ type WindowManager struct {
 	mu          sync.RWMutex
 	windows     map[string]*SlidingWindow
 	totalEvents int
+	maxEvents   int // per-window event cap
 }
 
 // NewWindowManager creates a new window manager.
 func NewWindowManager() *WindowManager {
+	return NewWindowManagerWithMax(maxEventsPerRule)
+}
+
+// NewWindowManagerWithMax creates a window manager whose windows keep at
+// most maxEvents timestamps each before switching to bucketed counts.
+func NewWindowManagerWithMax(maxEvents int) *WindowManager {
+	if maxEvents <= 0 {
+		maxEvents = maxEventsPerRule
+	}
 	return &WindowManager{
-		windows: make(map[string]*SlidingWindow),
+		windows:   make(map[string]*SlidingWindow),
+		maxEvents: maxEvents,
 	}
 }
 
@@ -165,7 +272,7 @@ func (wm *WindowManager) GetOrCreate(ruleName string, windowDuration time.Durati
 		return w
 	}
 
-	w := NewSlidingWindow(windowDuration)
+	w := NewSlidingWindowWithMax(windowDuration, wm.maxEvents)
 	wm.windows[ruleName] = w
 	return w
 }
@@ -182,17 +289,14 @@ func (wm *WindowManager) AddEventAt(ruleName string, windowDuration time.Duratio
 
 	w, ok := wm.windows[ruleName]
 	if !ok {
-		w = NewSlidingWindow(windowDuration)
+		w = NewSlidingWindowWithMax(windowDuration, wm.maxEvents)
 		wm.windows[ruleName] = w
 	}
 
+	// totalEvents tracks stored timestamps; bucketed windows hold none
 	w.mu.Lock()
 	beforeCount := len(w.events)
-	w.pruneOldLocked(t)
-	w.events = append(w.events, t)
-	if len(w.events) > w.maxSize {
-		w.events = w.events[len(w.events)/2:]
-	}
+	w.addLocked(t)
 	afterCount := len(w.events)
 	w.mu.Unlock()
 
@@ -217,6 +321,7 @@ func (wm *WindowManager) Reset(ruleName string) {
 		w.mu.Lock()
 		wm.totalEvents -= len(w.events)
 		w.events = w.events[:0]
+		w.buckets = nil
 		w.mu.Unlock()
 	}
 }
@@ -243,6 +348,7 @@ func (wm *WindowManager) ResetAll() {
 	for _, w := range wm.windows {
 		w.mu.Lock()
 		w.events = w.events[:0]
+		w.buckets = nil
 		w.mu.Unlock()
 	}
 	wm.totalEvents = 0
@@ -260,9 +366,14 @@ func (wm *WindowManager) DeleteAll() {
 
 // Count returns the event count for a rule.
 func (wm *WindowManager) Count(ruleName string) int {
+	return wm.CountAt(ruleName, time.Now())
+}
+
+// CountAt returns the event count for a rule at a specific time.
+func (wm *WindowManager) CountAt(ruleName string, t time.Time) int {
 	w := wm.Get(ruleName)
 	if w == nil {
 		return 0
 	}
-	return w.Count()
+	return w.CountAt(t)
 }
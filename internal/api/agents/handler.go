@@ -0,0 +1,172 @@
+// Package agents provides HTTP handlers for operating on connected agents.
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/good-yellow-bee/blazelog/internal/api/middleware"
+	"github.com/good-yellow-bee/blazelog/internal/server"
+)
+
+// Response helpers (same pattern as projects)
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+type errorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+const (
+	errCodeBadRequest       = "BAD_REQUEST"
+	errCodeValidationFailed = "VALIDATION_FAILED"
+	errCodeNotFound         = "NOT_FOUND"
+	errCodeConflict         = "CONFLICT"
+	errCodeAgentError       = "AGENT_ERROR"
+	errCodeTimeout          = "TIMEOUT"
+	errCodeInternalError    = "INTERNAL_ERROR"
+)
+
+func jsonError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(errorResponse{Error: errorBody{Code: code, Message: message}}); err != nil {
+		slog.Error("json encode", "error", err)
+	}
+}
+
+// RawTailer streams raw source bytes from a connected agent (implemented
+// by server.Server).
+type RawTailer interface {
+	RawTail(ctx context.Context, req server.RawTailRequest, w io.Writer) (int, error)
+}
+
+// AuditLogger records raw tail sessions (implemented by
+// ssh.JSONAuditLogger).
+type AuditLogger interface {
+	LogRawTailStart(user, agentID, source string)
+	LogRawTailEnd(user, agentID, source string, bytes int64, duration time.Duration, err error)
+}
+
+// Handler handles agent endpoints.
+type Handler struct {
+	rawTailer RawTailer
+	audit     AuditLogger
+}
+
+// NewHandler creates an agent handler.
+func NewHandler(rawTailer RawTailer) *Handler {
+	return &Handler{rawTailer: rawTailer}
+}
+
+// SetAuditLogger records raw tail sessions in an audit log.
+func (h *Handler) SetAuditLogger(audit AuditLogger) {
+	h.audit = audit
+}
+
+// RawTail handles GET /api/v1/agents/{id}/sources/{source}/raw - a short
+// live tail of the unparsed bytes an agent reads from one of its sources.
+// Query parameters: duration (default 10s, max 1m) and max_bytes (default
+// 64KB, max 1MB). The body is streamed as application/octet-stream.
+//
+// The bytes bypass ingest redaction, so every request is logged with the
+// requesting user, and recorded in the audit log when one is set.
+func (h *Handler) RawTail(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	agentID := chi.URLParam(r, "id")
+	source := chi.URLParam(r, "source")
+	if strings.TrimSpace(source) == "" {
+		jsonError(w, http.StatusBadRequest, errCodeBadRequest, "source is required")
+		return
+	}
+
+	req := server.RawTailRequest{AgentID: agentID, Source: source}
+	q := r.URL.Query()
+	if v := q.Get("duration"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 || d > server.MaxRawTailDuration {
+			jsonError(w, http.StatusBadRequest, errCodeValidationFailed,
+				fmt.Sprintf("duration must be a positive duration up to %s", server.MaxRawTailDuration))
+			return
+		}
+		req.Duration = d
+	}
+	if v := q.Get("max_bytes"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > server.MaxRawTailBytes {
+			jsonError(w, http.StatusBadRequest, errCodeValidationFailed,
+				fmt.Sprintf("max_bytes must be between 1 and %d", server.MaxRawTailBytes))
+			return
+		}
+		req.MaxBytes = n
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "streaming not supported")
+		return
+	}
+
+	user := middleware.GetUsername(ctx)
+	slog.Info("raw tail requested", "by", user, "agent", agentID, "source", source,
+		"duration", req.Duration, "max_bytes", req.MaxBytes)
+	if h.audit != nil {
+		h.audit.LogRawTailStart(user, agentID, source)
+	}
+
+	start := time.Now()
+	out := &streamWriter{w: w, flusher: flusher}
+	n, err := h.rawTailer.RawTail(ctx, req, out)
+	slog.Info("raw tail finished", "by", user, "agent", agentID, "source", source, "bytes", n, "error", err)
+	if h.audit != nil {
+		h.audit.LogRawTailEnd(user, agentID, source, int64(n), time.Since(start), err)
+	}
+	if err == nil || out.started {
+		// Once bytes are out the status is sent; the stream just ends.
+		return
+	}
+
+	switch {
+	case errors.Is(err, server.ErrAgentNotConnected):
+		jsonError(w, http.StatusNotFound, errCodeNotFound, "agent not connected")
+	case errors.Is(err, server.ErrRawTailBusy):
+		jsonError(w, http.StatusConflict, errCodeConflict, err.Error())
+	case errors.Is(err, server.ErrRawTailTimeout):
+		jsonError(w, http.StatusGatewayTimeout, errCodeTimeout, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		// Client went away
+	default:
+		jsonError(w, http.StatusBadGateway, errCodeAgentError, err.Error())
+	}
+}
+
+// streamWriter sends the response headers on the first write and flushes
+// after each one, so bytes reach the operator as the agent sends them.
+type streamWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	started bool
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	if !s.started {
+		s.started = true
+		s.w.Header().Set("Content-Type", "application/octet-stream")
+		s.w.Header().Set("Cache-Control", "no-store")
+		s.w.WriteHeader(http.StatusOK)
+	}
+	n, err := s.w.Write(p)
+	s.flusher.Flush()
+	return n, err
+}
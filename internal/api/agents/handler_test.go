@@ -0,0 +1,154 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/good-yellow-bee/blazelog/internal/api/middleware"
+	"github.com/good-yellow-bee/blazelog/internal/models"
+	"github.com/good-yellow-bee/blazelog/internal/server"
+)
+
+type mockRawTailer struct {
+	data []byte
+	err  error
+	got  server.RawTailRequest
+}
+
+func (m *mockRawTailer) RawTail(ctx context.Context, req server.RawTailRequest, w io.Writer) (int, error) {
+	m.got = req
+	n := 0
+	if len(m.data) > 0 {
+		var err error
+		if n, err = w.Write(m.data); err != nil {
+			return n, err
+		}
+	}
+	return n, m.err
+}
+
+type auditCall struct {
+	event, user, agent, source string
+	bytes                      int64
+	err                        error
+}
+
+type mockAuditLogger struct {
+	calls []auditCall
+}
+
+func (m *mockAuditLogger) LogRawTailStart(user, agentID, source string) {
+	m.calls = append(m.calls, auditCall{event: "start", user: user, agent: agentID, source: source})
+}
+
+func (m *mockAuditLogger) LogRawTailEnd(user, agentID, source string, bytes int64, duration time.Duration, err error) {
+	m.calls = append(m.calls, auditCall{event: "end", user: user, agent: agentID, source: source, bytes: bytes, err: err})
+}
+
+func doRawTail(tailer *mockRawTailer, query string) *httptest.ResponseRecorder {
+	return serveRawTail(NewHandler(tailer), query)
+}
+
+func serveRawTail(handler *Handler, query string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/agents/agent-1/sources/nginx/raw"+query, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "agent-1")
+	rctx.URLParams.Add("source", "nginx")
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = middleware.WithUserContext(ctx, "admin-user", "admin", models.RoleAdmin)
+
+	rr := httptest.NewRecorder()
+	handler.RawTail(rr, req.WithContext(ctx))
+	return rr
+}
+
+func TestRawTail_Success(t *testing.T) {
+	tailer := &mockRawTailer{data: []byte("raw \x00 bytes\n")}
+	rr := doRawTail(tailer, "?duration=30s&max_bytes=2048")
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rr.Code, rr.Body.String())
+	}
+	if got := rr.Header().Get("Content-Type"); got != "application/octet-stream" {
+		t.Errorf("Content-Type = %q, want application/octet-stream", got)
+	}
+	if got := rr.Body.String(); got != "raw \x00 bytes\n" {
+		t.Errorf("body = %q", got)
+	}
+	want := server.RawTailRequest{AgentID: "agent-1", Source: "nginx", Duration: 30 * time.Second, MaxBytes: 2048}
+	if tailer.got != want {
+		t.Errorf("request = %+v, want %+v", tailer.got, want)
+	}
+}
+
+func TestRawTail_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"bad duration", "?duration=soon", nil, http.StatusBadRequest, errCodeValidationFailed},
+		{"duration too long", "?duration=5m", nil, http.StatusBadRequest, errCodeValidationFailed},
+		{"max bytes too large", "?max_bytes=999999999", nil, http.StatusBadRequest, errCodeValidationFailed},
+		{"agent not connected", "", server.ErrAgentNotConnected, http.StatusNotFound, errCodeNotFound},
+		{"busy", "", server.ErrRawTailBusy, http.StatusConflict, errCodeConflict},
+		{"timeout", "", server.ErrRawTailTimeout, http.StatusGatewayTimeout, errCodeTimeout},
+		{"agent refused", "", errors.New("agent: raw tail is disabled on this agent"), http.StatusBadGateway, errCodeAgentError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := doRawTail(&mockRawTailer{err: tt.err}, tt.query)
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			var resp errorResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if resp.Error.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", resp.Error.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestRawTail_ErrorAfterBytesKeepsStream(t *testing.T) {
+	rr := doRawTail(&mockRawTailer{data: []byte("partial"), err: errors.New("agent: read source: EOF")}, "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rr.Code)
+	}
+	if got := rr.Body.String(); got != "partial" {
+		t.Errorf("body = %q, want only the streamed bytes", got)
+	}
+}
+
+func TestRawTail_AuditLog(t *testing.T) {
+	tailErr := errors.New("agent: read source: EOF")
+	audit := &mockAuditLogger{}
+	handler := NewHandler(&mockRawTailer{data: []byte("partial"), err: tailErr})
+	handler.SetAuditLogger(audit)
+
+	serveRawTail(handler, "")
+
+	if len(audit.calls) != 2 {
+		t.Fatalf("audit calls = %+v, want start and end", audit.calls)
+	}
+	start, end := audit.calls[0], audit.calls[1]
+	if start.event != "start" || start.user != "admin" || start.agent != "agent-1" || start.source != "nginx" {
+		t.Errorf("start = %+v", start)
+	}
+	if end.event != "end" || end.user != "admin" || end.bytes != int64(len("partial")) || !errors.Is(end.err, tailErr) {
+		t.Errorf("end = %+v", end)
+	}
+}
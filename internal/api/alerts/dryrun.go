@@ -0,0 +1,410 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/alerting"
+	"github.com/good-yellow-bee/blazelog/internal/api/logs"
+	"github.com/good-yellow-bee/blazelog/internal/api/middleware"
+	"github.com/good-yellow-bee/blazelog/internal/models"
+	"github.com/good-yellow-bee/blazelog/internal/query"
+	"github.com/good-yellow-bee/blazelog/internal/storage"
+)
+
+// Dry-run bounds. A request may lower them, or raise them up to the max.
+const (
+	defaultTestMaxEntries  = 100000
+	maxTestEntries         = 1000000
+	defaultTestMaxDuration = 30 * time.Second
+	maxTestDuration        = 5 * time.Minute
+	testPageSize           = 1000
+	maxTestFilterLength    = 1000
+)
+
+// Why a dry run stopped before the end of its logs (TestResponse.PartialReason).
+const (
+	testPartialMaxEntries  = "max_entries"
+	testPartialMaxDuration = "max_duration"
+)
+
+// testProgressInterval is how often a streamed dry run sends a progress event.
+var testProgressInterval = 2 * time.Second
+
+// SetLogStorage sets the log storage dry runs replay. Test is unavailable
+// until one is set.
+func (h *Handler) SetLogStorage(ls storage.LogStorage) {
+	h.logStorage = ls
+}
+
+// TestRequest is the request for POST /api/v1/alerts/test: alert rules in
+// the YAML format of rule files, replayed over the stored logs of a time
+// range.
+type TestRequest struct {
+	Rules       string `json:"rules"`
+	Start       string `json:"start"`                  // RFC3339
+	End         string `json:"end,omitempty"`          // RFC3339, default now
+	Filter      string `json:"filter,omitempty"`       // DSL filter for the replayed logs
+	ProjectID   string `json:"project_id,omitempty"`   // only this project's logs
+	MaxEntries  int    `json:"max_entries,omitempty"`  // default 100000, max 1000000
+	MaxDuration string `json:"max_duration,omitempty"` // default 30s, max 5m
+}
+
+// TestResponse reports when each rule of a dry run would have fired.
+type TestResponse struct {
+	Entries       int            `json:"entries"`
+	Partial       bool           `json:"partial"`                  // stopped before the end of the logs
+	PartialReason string         `json:"partial_reason,omitempty"` // max_entries or max_duration
+	ReplayedTo    *time.Time     `json:"replayed_to,omitempty"`    // last replayed entry of a partial run
+	Skipped       []string       `json:"skipped_rules,omitempty"`  // parse_errors rules can't be replayed
+	Alerts        []*TestAlert   `json:"alerts"`
+	Counts        map[string]int `json:"counts"` // by rule name
+}
+
+// TestAlert is an alert a dry run would have fired.
+type TestAlert struct {
+	Rule      string    `json:"rule"`
+	Severity  string    `json:"severity"`
+	Timestamp time.Time `json:"timestamp"`
+	Message   string    `json:"message"`
+	Notify    []string  `json:"notify"` // channels it would have notified
+}
+
+// TestProgress is the progress event of a streamed dry run.
+type TestProgress struct {
+	Entries    int        `json:"entries"`
+	Alerts     int        `json:"alerts"`
+	ReplayedTo *time.Time `json:"replayed_to,omitempty"`
+	Elapsed    string     `json:"elapsed"`
+}
+
+// Test replays stored logs through alert rules, oldest first, and reports
+// when each rule would have fired. Nothing is notified and rules are never
+// auto-muted. The replay stops at max_entries entries or after
+// max_duration, and the report is then marked partial. A client accepting
+// text/event-stream gets progress events while it runs and the report as
+// the final result event.
+func (h *Handler) Test(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if h.logStorage == nil {
+		jsonError(w, http.StatusServiceUnavailable, errCodeInternalError, "log storage not configured")
+		return
+	}
+
+	var req TestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, http.StatusBadRequest, errCodeBadRequest, "invalid request body")
+		return
+	}
+
+	rules, err := alerting.LoadRulesFromBytes([]byte(req.Rules))
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, errCodeValidationFailed, err.Error())
+		return
+	}
+	if len(rules) == 0 {
+		jsonError(w, http.StatusBadRequest, errCodeValidationFailed, "no rules given")
+		return
+	}
+	filter, msg := testLogFilter(&req)
+	if msg != "" {
+		jsonError(w, http.StatusBadRequest, errCodeValidationFailed, msg)
+		return
+	}
+	maxEntries, maxDuration, msg := testLimits(&req)
+	if msg != "" {
+		jsonError(w, http.StatusBadRequest, errCodeValidationFailed, msg)
+		return
+	}
+
+	access, err := middleware.GetProjectAccess(ctx, middleware.GetUserID(ctx), middleware.GetRole(ctx), h.storage)
+	if err != nil {
+		slog.Error("test alerts: get access", "error", err)
+		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
+		return
+	}
+	if err := access.ApplyToLogFilter(filter, req.ProjectID); err != nil {
+		if errors.Is(err, middleware.ErrProjectAccessDenied) {
+			jsonError(w, http.StatusForbidden, errCodeForbidden, "no access to project")
+			return
+		}
+		slog.Error("test alerts: project filter", "error", err)
+		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
+		return
+	}
+
+	var progress func(*TestProgress)
+	var sse *logs.SSEWriter
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			jsonError(w, http.StatusInternalServerError, errCodeInternalError, "streaming not supported")
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		sse = logs.NewSSEWriter(w, flusher)
+		progress = func(p *TestProgress) {
+			if data, err := json.Marshal(p); err == nil {
+				_ = sse.SendEvent("progress", string(data))
+			}
+		}
+	}
+
+	run := newTestRun(rules, newProjectNotify(ctx, h.storage))
+	defer run.engine.Close()
+	err = run.replay(ctx, h.logStorage.Logs(), filter, maxEntries, maxDuration, progress)
+
+	if sse != nil {
+		if err != nil {
+			slog.Error("test alerts: replay", "error", err)
+			data, _ := json.Marshal(errorBody{Code: errCodeInternalError, Message: "log query failed"})
+			_ = sse.SendEvent("error", string(data))
+			return
+		}
+		data, err := json.Marshal(run.resp)
+		if err != nil {
+			slog.Error("json encode", "error", err)
+			return
+		}
+		_ = sse.SendEvent("result", string(data))
+		return
+	}
+	if err != nil {
+		slog.Error("test alerts: replay", "error", err)
+		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
+		return
+	}
+	jsonOK(w, run.resp)
+}
+
+// testLogFilter builds the filter of the logs a dry run replays, oldest
+// first. It returns a validation message if the request is invalid.
+func testLogFilter(req *TestRequest) (*storage.LogFilter, string) {
+	if req.Start == "" {
+		return nil, "start is required"
+	}
+	start, err := time.Parse(time.RFC3339, req.Start)
+	if err != nil {
+		return nil, "invalid start time format (use RFC3339)"
+	}
+	end := time.Now()
+	if req.End != "" {
+		if end, err = time.Parse(time.RFC3339, req.End); err != nil {
+			return nil, "invalid end time format (use RFC3339)"
+		}
+	}
+	if !end.After(start) {
+		return nil, "end must be after start"
+	}
+
+	// Windows and cooldowns need the entries in time order
+	filter := &storage.LogFilter{
+		StartTime: start,
+		EndTime:   end,
+		OrderBy:   "timestamp",
+		Limit:     testPageSize,
+		SkipTotal: true,
+	}
+	if req.Filter != "" {
+		if len(req.Filter) > maxTestFilterLength {
+			return nil, fmt.Sprintf("filter expression too long (max %d chars)", maxTestFilterLength)
+		}
+		parsed, err := query.NewQueryDSL(query.DefaultFields).Parse(req.Filter)
+		if err != nil {
+			return nil, fmt.Sprintf("invalid filter expression: %v", err)
+		}
+		result, err := query.NewSQLBuilder(query.DefaultFields).Build(parsed)
+		if err != nil {
+			return nil, fmt.Sprintf("filter conversion error: %v", err)
+		}
+		filter.FilterExpr, filter.FilterSQL, filter.FilterArgs = req.Filter, result.SQL, result.Args
+	}
+	return filter, ""
+}
+
+// testLimits returns the bounds of a dry run, or a validation message.
+func testLimits(req *TestRequest) (int, time.Duration, string) {
+	maxEntries := req.MaxEntries
+	if maxEntries == 0 {
+		maxEntries = defaultTestMaxEntries
+	}
+	if maxEntries < 0 || maxEntries > maxTestEntries {
+		return 0, 0, fmt.Sprintf("max_entries must be between 1 and %d", maxTestEntries)
+	}
+	maxDuration := defaultTestMaxDuration
+	if req.MaxDuration != "" {
+		d, err := time.ParseDuration(req.MaxDuration)
+		if err != nil || d <= 0 || d > maxTestDuration {
+			return 0, 0, fmt.Sprintf("max_duration must be a duration up to %s", maxTestDuration)
+		}
+		maxDuration = d
+	}
+	return maxEntries, maxDuration, ""
+}
+
+// newProjectNotify resolves the default channels of rules inheriting their
+// project's, caching each project for the dry run.
+func newProjectNotify(ctx context.Context, store storage.Storage) alerting.ProjectNotifyResolver {
+	projects := make(map[string][]string)
+	return func(projectID string) []string {
+		notify, ok := projects[projectID]
+		if !ok {
+			project, err := store.Projects().GetByID(ctx, projectID)
+			if err != nil {
+				slog.Error("test alerts: get project", "project_id", projectID, "error", err)
+			} else if project != nil {
+				notify = project.DefaultNotify
+			}
+			projects[projectID] = notify
+		}
+		return notify
+	}
+}
+
+// testRun is a dry run of alert rules and the engine evaluating it.
+type testRun struct {
+	resp   *TestResponse
+	engine *alerting.Engine
+	last   time.Time // timestamp of the last replayed entry
+}
+
+// newTestRun returns a dry run of rules. Disabled rules are replayed too,
+// since testing them is the point.
+func newTestRun(rules []*alerting.Rule, projectNotify alerting.ProjectNotifyResolver) *testRun {
+	resp := &TestResponse{Alerts: []*TestAlert{}, Counts: make(map[string]int)}
+	enabled := true
+	var replayed []*alerting.Rule
+	for _, rule := range rules {
+		if rule.Type == alerting.RuleTypeParseErrors {
+			resp.Skipped = append(resp.Skipped, rule.Name)
+			continue
+		}
+		rule.Enabled = &enabled
+		replayed = append(replayed, rule)
+		resp.Counts[rule.Name] = 0
+	}
+
+	opts := alerting.DefaultEngineOptions()
+	opts.MuteAfter = -1
+	opts.ProjectNotify = projectNotify
+	engine := alerting.NewEngine(replayed, opts)
+	go func() {
+		for range engine.Alerts() {
+		}
+	}()
+	return &testRun{resp: resp, engine: engine}
+}
+
+// replay pages the logs matching filter through the engine with the keyset
+// cursor, until the last page, maxEntries entries or maxDuration. progress,
+// if not nil, is called every testProgressInterval.
+func (t *testRun) replay(ctx context.Context, repo storage.LogRepository, filter *storage.LogFilter,
+	maxEntries int, maxDuration time.Duration, progress func(*TestProgress)) error {
+	replayCtx, cancel := context.WithTimeout(ctx, maxDuration)
+	defer cancel()
+
+	started := time.Now()
+	reported := started
+	for {
+		result, err := repo.Query(replayCtx, filter)
+		if err != nil {
+			if replayCtx.Err() != nil && ctx.Err() == nil {
+				// The page in flight when max_duration ran out
+				t.stop(testPartialMaxDuration)
+				return nil
+			}
+			return err
+		}
+		for _, record := range result.Entries {
+			if t.resp.Entries >= maxEntries {
+				t.stop(testPartialMaxEntries)
+				return nil
+			}
+			if replayCtx.Err() != nil && ctx.Err() == nil {
+				t.stop(testPartialMaxDuration)
+				return nil
+			}
+			t.evaluate(recordToEntry(record))
+			if now := time.Now(); progress != nil && now.Sub(reported) >= testProgressInterval {
+				reported = now
+				last := t.last
+				progress(&TestProgress{
+					Entries:    t.resp.Entries,
+					Alerts:     len(t.resp.Alerts),
+					ReplayedTo: &last,
+					Elapsed:    now.Sub(started).Round(time.Millisecond).String(),
+				})
+			}
+		}
+		if !result.HasMore || len(result.Entries) == 0 {
+			return ctx.Err()
+		}
+		last := result.Entries[len(result.Entries)-1]
+		filter.AfterTime, filter.AfterID = last.Timestamp, last.ID
+	}
+}
+
+// evaluate runs one entry through the engine at its own timestamp.
+func (t *testRun) evaluate(entry *models.LogEntry) {
+	t.resp.Entries++
+	t.last = entry.Timestamp
+	for _, alert := range t.engine.EvaluateAt(entry, entry.Timestamp) {
+		t.resp.Counts[alert.RuleName]++
+		notify := alert.Notify
+		if notify == nil {
+			notify = []string{}
+		}
+		t.resp.Alerts = append(t.resp.Alerts, &TestAlert{
+			Rule:      alert.RuleName,
+			Severity:  string(alert.Severity),
+			Timestamp: alert.Timestamp,
+			Message:   alert.Message,
+			Notify:    notify,
+		})
+	}
+}
+
+// stop marks the run partial for reason.
+func (t *testRun) stop(reason string) {
+	t.resp.Partial, t.resp.PartialReason = true, reason
+	if t.resp.Entries > 0 {
+		last := t.last
+		t.resp.ReplayedTo = &last
+	}
+}
+
+// recordToEntry converts a stored log to the entry rules are evaluated
+// against.
+func recordToEntry(r *storage.LogRecord) *models.LogEntry {
+	entry := models.NewLogEntry()
+	entry.Timestamp = r.Timestamp
+	entry.Level = models.ParseLogLevel(r.Level)
+	entry.Message = r.Message
+	entry.Source = r.Source
+	entry.Type = models.LogType(r.Type)
+	entry.Raw = r.Raw
+	entry.FilePath = r.FilePath
+	entry.LineNumber = r.LineNumber
+	for k, v := range r.Fields {
+		entry.SetField(k, v)
+	}
+	for k, v := range r.Labels {
+		entry.SetLabel(k, v)
+	}
+	// Stored as a column; parsers put it in the status field
+	if _, ok := entry.Fields["status"]; !ok && r.HTTPStatus > 0 {
+		entry.SetField("status", r.HTTPStatus)
+	}
+	return entry
+}
@@ -2,7 +2,7 @@ package alerts
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
@@ -41,7 +41,7 @@ func jsonError(w http.ResponseWriter, status int, code, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(errorResponse{Error: errorBody{Code: code, Message: message}}); err != nil {
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 	}
 }
 
@@ -49,7 +49,7 @@ func jsonOK(w http.ResponseWriter, data any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(dataResponse{Data: data}); err != nil {
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 	}
 }
 
@@ -57,7 +57,7 @@ func jsonCreated(w http.ResponseWriter, data any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(dataResponse{Data: data}); err != nil {
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 	}
 }
 
@@ -75,11 +75,16 @@ type AlertResponse struct {
 	Severity    string   `json:"severity"`
 	Window      string   `json:"window"`
 	Cooldown    string   `json:"cooldown"`
-	Notify      []string `json:"notify"`
+	Notify      []string `json:"notify"` // never null; [] when none are set
 	Enabled     bool     `json:"enabled"`
 	ProjectID   string   `json:"project_id,omitempty"`
 	CreatedAt   string   `json:"created_at"`
 	UpdatedAt   string   `json:"updated_at"`
+
+	NotifyTemplate string `json:"notify_template,omitempty"`
+	// InheritNotify is true when the rule sets no channels of its own, so
+	// it uses its project's default_notify (none without a project).
+	InheritNotify bool `json:"inherit_notify"`
 }
 
 type AlertHistoryResponse struct {
@@ -91,6 +96,7 @@ type AlertHistoryResponse struct {
 	MatchedLogs int    `json:"matched_logs"`
 	NotifiedAt  string `json:"notified_at"`
 	ProjectID   string `json:"project_id,omitempty"`
+	ReplayOf    string `json:"replay_of,omitempty"`
 	CreatedAt   string `json:"created_at"`
 }
 
@@ -103,7 +109,9 @@ type HistoryListResponse struct {
 
 // Handler handles alert endpoints.
 type Handler struct {
-	storage storage.Storage
+	storage    storage.Storage
+	logStorage storage.LogStorage // logs dry runs replay
+	dispatcher Dispatcher
 }
 
 func NewHandler(store storage.Storage) *Handler {
@@ -122,6 +130,8 @@ type CreateRequest struct {
 	Notify      []string `json:"notify"`
 	Enabled     bool     `json:"enabled"`
 	ProjectID   string   `json:"project_id"`
+
+	NotifyTemplate string `json:"notify_template"` // overrides channel formatting
 }
 
 type UpdateRequest struct {
@@ -135,6 +145,9 @@ type UpdateRequest struct {
 	Notify      []string `json:"notify,omitempty"`
 	Enabled     *bool    `json:"enabled,omitempty"`
 	ProjectID   string   `json:"project_id,omitempty"`
+
+	NotifyTemplate *string `json:"notify_template,omitempty"` // "" clears the template
+	InheritNotify  bool    `json:"inherit_notify,omitempty"`  // drops notify for the project defaults
 }
 
 // List returns all alerts.
@@ -146,7 +159,7 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 
 	access, err := middleware.GetProjectAccess(ctx, userID, role, h.storage)
 	if err != nil {
-		log.Printf("list alerts error: get access: %v", err)
+		slog.Error("list alerts: get access", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -168,7 +181,7 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 		for _, pid := range access.ProjectIDs {
 			projectAlerts, pErr := h.storage.Alerts().ListByProject(ctx, pid)
 			if pErr != nil {
-				log.Printf("error listing alerts for project %s: %v", pid, pErr)
+				slog.Error("list alerts for project", "project", pid, "error", pErr)
 				err = pErr
 				break
 			}
@@ -185,7 +198,7 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err != nil {
-		log.Printf("list alerts error: %v", err)
+		slog.Error("list alerts", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -223,6 +236,10 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		jsonError(w, http.StatusBadRequest, errCodeValidationFailed, err.Error())
 		return
 	}
+	if err := ValidateNotifyTemplate(req.NotifyTemplate); err != nil {
+		jsonError(w, http.StatusBadRequest, errCodeValidationFailed, err.Error())
+		return
+	}
 
 	window, err := time.ParseDuration(req.Window)
 	if err != nil {
@@ -241,7 +258,7 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	if req.ProjectID != "" {
 		project, err := h.storage.Projects().GetByID(ctx, req.ProjectID)
 		if err != nil {
-			log.Printf("create alert error: check project: %v", err)
+			slog.Error("create alert: check project", "error", err)
 			jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 			return
 		}
@@ -266,18 +283,19 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		ProjectID:   req.ProjectID,
 		CreatedAt:   now,
 		UpdatedAt:   now,
+
+		NotifyTemplate: req.NotifyTemplate,
 	}
-	if alert.Notify == nil {
-		alert.Notify = []string{}
-	}
+	// A nil Notify is kept so the rule inherits its project's default channels;
+	// an explicit empty list opts out of notifications.
 
 	if err := h.storage.Alerts().Create(ctx, alert); err != nil {
-		log.Printf("create alert error: %v", err)
+		slog.Error("create alert", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
 
-	log.Printf("alert created: %s (%s)", alert.Name, alert.ID)
+	slog.Info("alert created", "name", alert.Name, "id", alert.ID)
 	jsonCreated(w, alertToResponse(alert))
 }
 
@@ -292,7 +310,7 @@ func (h *Handler) GetByID(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	alert, err := h.storage.Alerts().GetByID(ctx, id)
 	if err != nil {
-		log.Printf("get alert error: %v", err)
+		slog.Error("get alert", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -305,7 +323,7 @@ func (h *Handler) GetByID(w http.ResponseWriter, r *http.Request) {
 	role := middleware.GetRole(ctx)
 	access, err := middleware.GetProjectAccess(ctx, userID, role, h.storage)
 	if err != nil {
-		log.Printf("get alert error: get access: %v", err)
+		slog.Error("get alert: get access", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -334,7 +352,7 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	alert, err := h.storage.Alerts().GetByID(ctx, id)
 	if err != nil {
-		log.Printf("update alert error: get: %v", err)
+		slog.Error("update alert: get", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -347,7 +365,7 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	role := middleware.GetRole(ctx)
 	access, err := middleware.GetProjectAccess(ctx, userID, role, h.storage)
 	if err != nil {
-		log.Printf("update alert error: get access: %v", err)
+		slog.Error("update alert: get access", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -406,12 +424,21 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		}
 		alert.Cooldown = cooldown
 	}
-	if req.Notify != nil {
+	if req.InheritNotify {
+		alert.Notify = nil
+	} else if req.Notify != nil {
 		alert.Notify = req.Notify
 	}
 	if req.Enabled != nil {
 		alert.Enabled = *req.Enabled
 	}
+	if req.NotifyTemplate != nil {
+		if err := ValidateNotifyTemplate(*req.NotifyTemplate); err != nil {
+			jsonError(w, http.StatusBadRequest, errCodeValidationFailed, err.Error())
+			return
+		}
+		alert.NotifyTemplate = *req.NotifyTemplate
+	}
 	if req.ProjectID != "" {
 		alert.ProjectID = req.ProjectID
 	}
@@ -419,12 +446,12 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	alert.UpdatedAt = time.Now()
 
 	if err := h.storage.Alerts().Update(ctx, alert); err != nil {
-		log.Printf("update alert error: %v", err)
+		slog.Error("update alert", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
 
-	log.Printf("alert updated: %s (%s)", alert.Name, alert.ID)
+	slog.Info("alert updated", "name", alert.Name, "id", alert.ID)
 	jsonOK(w, alertToResponse(alert))
 }
 
@@ -439,7 +466,7 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	alert, err := h.storage.Alerts().GetByID(ctx, id)
 	if err != nil {
-		log.Printf("delete alert error: get: %v", err)
+		slog.Error("delete alert: get", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -452,7 +479,7 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	role := middleware.GetRole(ctx)
 	access, err := middleware.GetProjectAccess(ctx, userID, role, h.storage)
 	if err != nil {
-		log.Printf("delete alert error: get access: %v", err)
+		slog.Error("delete alert: get access", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -462,12 +489,12 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.storage.Alerts().Delete(ctx, id); err != nil {
-		log.Printf("delete alert error: %v", err)
+		slog.Error("delete alert", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
 
-	log.Printf("alert deleted: %s (%s)", alert.Name, alert.ID)
+	slog.Info("alert deleted", "name", alert.Name, "id", alert.ID)
 	jsonNoContent(w)
 }
 
@@ -481,7 +508,7 @@ func (h *Handler) History(w http.ResponseWriter, r *http.Request) {
 
 	access, err := middleware.GetProjectAccess(ctx, userID, role, h.storage)
 	if err != nil {
-		log.Printf("list alert history error: get access: %v", err)
+		slog.Error("list alert history: get access", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -495,7 +522,7 @@ func (h *Handler) History(w http.ResponseWriter, r *http.Request) {
 	if alertID != "" {
 		alert, aErr := h.storage.Alerts().GetByID(ctx, alertID)
 		if aErr != nil {
-			log.Printf("list alert history error: get alert: %v", aErr)
+			slog.Error("list alert history: get alert", "error", aErr)
 			jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 			return
 		}
@@ -540,7 +567,7 @@ func (h *Handler) History(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err != nil {
-		log.Printf("list alert history error: %v", err)
+		slog.Error("list alert history", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -559,6 +586,10 @@ func (h *Handler) History(w http.ResponseWriter, r *http.Request) {
 }
 
 func alertToResponse(a *models.AlertRule) *AlertResponse {
+	notify := a.Notify
+	if notify == nil {
+		notify = []string{}
+	}
 	return &AlertResponse{
 		ID:          a.ID,
 		Name:        a.Name,
@@ -568,11 +599,14 @@ func alertToResponse(a *models.AlertRule) *AlertResponse {
 		Severity:    string(a.Severity),
 		Window:      a.Window.String(),
 		Cooldown:    a.Cooldown.String(),
-		Notify:      a.Notify,
+		Notify:      notify,
 		Enabled:     a.Enabled,
 		ProjectID:   a.ProjectID,
 		CreatedAt:   a.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:   a.UpdatedAt.Format(time.RFC3339),
+
+		NotifyTemplate: a.NotifyTemplate,
+		InheritNotify:  a.Notify == nil,
 	}
 }
 
@@ -586,6 +620,7 @@ func historyToResponse(h *models.AlertHistory) *AlertHistoryResponse {
 		MatchedLogs: h.MatchedLogs,
 		NotifiedAt:  h.NotifiedAt.Format(time.RFC3339),
 		ProjectID:   h.ProjectID,
+		ReplayOf:    h.ReplayOf,
 		CreatedAt:   h.CreatedAt.Format(time.RFC3339),
 	}
 }
@@ -1,8 +1,10 @@
 package alerts
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -10,9 +12,12 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 
+	"github.com/good-yellow-bee/blazelog/internal/alerting"
 	"github.com/good-yellow-bee/blazelog/internal/api/middleware"
 	"github.com/good-yellow-bee/blazelog/internal/models"
+	"github.com/good-yellow-bee/blazelog/internal/notifier"
 	"github.com/good-yellow-bee/blazelog/internal/storage"
 )
 
@@ -107,9 +112,40 @@ type mockAlertHistoryRepository struct {
 }
 
 func (m *mockAlertHistoryRepository) Create(ctx context.Context, history *models.AlertHistory) error {
+	m.histories = append(m.histories, history)
 	return nil
 }
 
+func (m *mockAlertHistoryRepository) GetByID(ctx context.Context, id string) (*models.AlertHistory, error) {
+	for _, h := range m.histories {
+		if h.ID == id {
+			return h, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockAlertHistoryRepository) ListBetween(ctx context.Context, alertID string, start, end time.Time, limit int) ([]*models.AlertHistory, int64, error) {
+	if m.listError != nil {
+		return nil, 0, m.listError
+	}
+	var result []*models.AlertHistory
+	for _, h := range m.histories {
+		if h.ReplayOf != "" || h.NotifiedAt.Before(start) || !h.NotifiedAt.Before(end) {
+			continue
+		}
+		if alertID != "" && h.AlertID != alertID {
+			continue
+		}
+		result = append(result, h)
+	}
+	total := int64(len(result))
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, total, nil
+}
+
 func (m *mockAlertHistoryRepository) List(ctx context.Context, limit, offset int) ([]*models.AlertHistory, int64, error) {
 	if m.listError != nil {
 		return nil, 0, m.listError
@@ -147,6 +183,10 @@ func (m *mockAlertHistoryRepository) DeleteBefore(ctx context.Context, before ti
 	return 0, nil
 }
 
+func (m *mockAlertHistoryRepository) DeleteExcess(ctx context.Context, keep int) (int64, error) {
+	return 0, nil
+}
+
 type mockProjectRepository struct{}
 
 func (m *mockProjectRepository) Create(ctx context.Context, project *models.Project) error { return nil }
@@ -191,6 +231,8 @@ func (m *mockStorage) Alerts() storage.AlertRepository                { return m
 func (m *mockStorage) Connections() storage.ConnectionRepository      { return nil }
 func (m *mockStorage) Tokens() storage.TokenRepository                { return nil }
 func (m *mockStorage) AlertHistory() storage.AlertHistoryRepository   { return m.alertHistoryRepo }
+func (m *mockStorage) SharedFilters() storage.SharedFilterRepository { return nil }
+func (m *mockStorage) SavedSearches() storage.SavedSearchRepository { return nil }
 
 func newMockStorage() (*mockStorage, *mockAlertRepository, *mockAlertHistoryRepository) {
 	alertRepo := &mockAlertRepository{}
@@ -349,6 +391,88 @@ func TestCreate_Success(t *testing.T) {
 	}
 }
 
+func TestCreate_NotifyShape(t *testing.T) {
+	tests := []struct {
+		name        string
+		notify      string
+		wantNotify  string
+		wantInherit bool
+	}{
+		{name: "omitted inherits", notify: "", wantNotify: `[]`, wantInherit: true},
+		{name: "empty opts out", notify: `"notify": [],`, wantNotify: `[]`},
+		{name: "explicit", notify: `"notify": ["slack"],`, wantNotify: `["slack"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore, _, _ := newMockStorage()
+			handler := NewHandler(mockStore)
+
+			body := `{"name": "Test Alert", "type": "threshold", "condition": "error_rate > 10",
+				"severity": "medium", "window": "5m", "cooldown": "10m", ` + tt.notify + ` "enabled": true}`
+			req := httptest.NewRequest("POST", "/api/v1/alerts", strings.NewReader(body))
+			rec := httptest.NewRecorder()
+			handler.Create(rec, req)
+			if rec.Code != http.StatusCreated {
+				t.Fatalf("status = %d, want %d; body: %s", rec.Code, http.StatusCreated, rec.Body.String())
+			}
+
+			var resp struct {
+				Data struct {
+					Notify        json.RawMessage `json:"notify"`
+					InheritNotify bool            `json:"inherit_notify"`
+				} `json:"data"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if string(resp.Data.Notify) != tt.wantNotify {
+				t.Errorf("notify = %s, want %s", resp.Data.Notify, tt.wantNotify)
+			}
+			if resp.Data.InheritNotify != tt.wantInherit {
+				t.Errorf("inherit_notify = %v, want %v", resp.Data.InheritNotify, tt.wantInherit)
+			}
+		})
+	}
+}
+
+func TestUpdate_InheritNotify(t *testing.T) {
+	mockStore, mockRepo, _ := newMockStorage()
+	now := time.Now()
+	mockRepo.alerts = []*models.AlertRule{
+		{
+			ID:        "alert-1",
+			Name:      "Original Name",
+			Type:      models.AlertTypeThreshold,
+			Severity:  models.SeverityMedium,
+			Window:    5 * time.Minute,
+			Cooldown:  10 * time.Minute,
+			Notify:    []string{"email"},
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+	}
+
+	handler := NewHandler(mockStore)
+	req := httptest.NewRequest("PUT", "/api/v1/alerts/alert-1", strings.NewReader(`{"inherit_notify": true}`))
+	req = withAdminContext(req)
+	rec := httptest.NewRecorder()
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "alert-1")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	handler.Update(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if mockRepo.alerts[0].Notify != nil {
+		t.Errorf("stored notify = %v, want nil", mockRepo.alerts[0].Notify)
+	}
+	if !strings.Contains(rec.Body.String(), `"notify":[],`) || !strings.Contains(rec.Body.String(), `"inherit_notify":true`) {
+		t.Errorf("body = %s, want notify [] and inherit_notify true", rec.Body.String())
+	}
+}
+
 func TestCreate_MissingName(t *testing.T) {
 	mockStore, _, _ := newMockStorage()
 	handler := NewHandler(mockStore)
@@ -417,6 +541,53 @@ func TestCreate_InvalidWindow(t *testing.T) {
 	}
 }
 
+func TestCreate_NotifyTemplate(t *testing.T) {
+	tests := []struct {
+		name       string
+		template   string
+		wantStatus int
+	}{
+		{"valid", `{{.RuleName}}: {{.Count}} errors, runbook {{.Labels.runbook}}`, http.StatusCreated},
+		{"parse error", `{{.RuleName`, http.StatusBadRequest},
+		{"unknown field", `{{.Runbook}}`, http.StatusBadRequest},
+		{"unguarded entry", `{{.TriggeringEntry.Message}}`, http.StatusBadRequest},
+		{"guarded entry", `{{with .TriggeringEntry}}{{.Message}}{{else}}{{.Count}} hits{{end}}`, http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore, mockRepo, _ := newMockStorage()
+			handler := NewHandler(mockStore)
+
+			body, _ := json.Marshal(map[string]any{
+				"name":            "Test Alert",
+				"type":            "threshold",
+				"condition":       "error_rate > 10",
+				"severity":        "medium",
+				"window":          "5m",
+				"cooldown":        "10m",
+				"notify_template": tt.template,
+			})
+			req := httptest.NewRequest("POST", "/api/v1/alerts", bytes.NewReader(body))
+			rec := httptest.NewRecorder()
+
+			handler.Create(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+			for _, a := range mockRepo.alerts {
+				if a.NotifyTemplate != tt.template {
+					t.Errorf("stored template = %q, want %q", a.NotifyTemplate, tt.template)
+				}
+			}
+		})
+	}
+}
+
 func TestGetByID_Found(t *testing.T) {
 	mockStore, mockRepo, _ := newMockStorage()
 	now := time.Now()
@@ -737,3 +908,364 @@ func TestHistory_ProjectFilter(t *testing.T) {
 		t.Errorf("project_id = %q, want 'proj-1'", resp.Data.Items[0].ProjectID)
 	}
 }
+
+type mockDispatcher struct {
+	sent     []*alerting.Alert
+	err      error
+	channels map[string]bool // configured channels (nil: all)
+}
+
+func (m *mockDispatcher) Dispatch(ctx context.Context, alert *alerting.Alert) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, alert)
+	return nil
+}
+
+func (m *mockDispatcher) Get(name string) (notifier.Notifier, bool) {
+	return nil, m.channels == nil || m.channels[name]
+}
+
+func newReplayFixture(t *testing.T) (*Handler, *mockAlertHistoryRepository, *mockDispatcher) {
+	t.Helper()
+	mockStore, mockRepo, mockHistoryRepo := newMockStorage()
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	mockRepo.alerts = []*models.AlertRule{
+		{ID: "alert-1", Name: "Alert 1", Type: models.AlertTypePattern, Severity: models.SeverityHigh, Notify: []string{"slack"}, CreatedAt: base, UpdatedAt: base},
+	}
+	mockHistoryRepo.histories = []*models.AlertHistory{
+		{ID: "h1", AlertID: "alert-1", AlertName: "Alert 1", Severity: models.SeverityHigh, Message: "Test", MatchedLogs: 3, NotifiedAt: base, CreatedAt: base},
+		{ID: "h2", AlertID: "alert-1", AlertName: "Alert 1", Severity: models.SeverityHigh, Message: "Test", MatchedLogs: 5, NotifiedAt: base.Add(time.Hour), CreatedAt: base.Add(time.Hour)},
+	}
+
+	dispatcher := &mockDispatcher{}
+	handler := NewHandler(mockStore)
+	handler.SetDispatcher(dispatcher)
+	return handler, mockHistoryRepo, dispatcher
+}
+
+func doReplay(handler *Handler, body string) (*httptest.ResponseRecorder, *ReplayResponse) {
+	req := httptest.NewRequest("POST", "/api/v1/alerts/history/replay", strings.NewReader(body))
+	req = withAdminContext(req)
+	rec := httptest.NewRecorder()
+
+	handler.Replay(rec, req)
+
+	var resp struct {
+		Data *ReplayResponse `json:"data"`
+	}
+	_ = json.NewDecoder(rec.Body).Decode(&resp)
+	return rec, resp.Data
+}
+
+func TestReplay_NoDispatcher(t *testing.T) {
+	mockStore, _, _ := newMockStorage()
+	handler := NewHandler(mockStore)
+
+	rec, _ := doReplay(handler, `{"history_id": "h1"}`)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestReplay_PreviewThenConfirm(t *testing.T) {
+	handler, historyRepo, dispatcher := newReplayFixture(t)
+	rangeBody := `"start": "2024-01-01T00:00:00Z", "end": "2024-01-02T00:00:00Z"`
+
+	rec, resp := doReplay(handler, `{`+rangeBody+`}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("preview status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if resp.Count != 2 || len(resp.Items) != 2 || resp.Confirmed {
+		t.Errorf("preview = %+v, want 2 unconfirmed items", resp)
+	}
+	if len(dispatcher.sent) != 0 {
+		t.Fatalf("preview dispatched %d notifications", len(dispatcher.sent))
+	}
+
+	rec, _ = doReplay(handler, `{`+rangeBody+`, "confirm": true, "expected_count": 1}`)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("mismatched count status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	if len(dispatcher.sent) != 0 {
+		t.Fatalf("mismatched count dispatched %d notifications", len(dispatcher.sent))
+	}
+
+	rec, resp = doReplay(handler, `{`+rangeBody+`, "confirm": true, "expected_count": 2}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("confirm status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if resp.Sent != 2 || resp.Failed != 0 {
+		t.Errorf("sent = %d, failed = %d, want 2, 0", resp.Sent, resp.Failed)
+	}
+	if len(dispatcher.sent) != 2 {
+		t.Fatalf("dispatched = %d, want 2", len(dispatcher.sent))
+	}
+	if got := dispatcher.sent[0]; got.Labels[replayLabel] != "h1" || got.Count != 3 || len(got.Notify) != 1 {
+		t.Errorf("replayed alert = %+v, want h1 to slack", got)
+	}
+
+	var replays []*models.AlertHistory
+	for _, h := range historyRepo.histories {
+		if h.ReplayOf != "" {
+			replays = append(replays, h)
+		}
+	}
+	if len(replays) != 2 || replays[0].ReplayOf != "h1" || resp.Results[0].ReplayID != replays[0].ID {
+		t.Errorf("replay history = %+v, want entries for h1 and h2", replays)
+	}
+}
+
+func TestReplay_DispatchFailure(t *testing.T) {
+	handler, historyRepo, dispatcher := newReplayFixture(t)
+	dispatcher.err = errors.New("webhook down")
+
+	rec, resp := doReplay(handler, `{"history_id": "h1", "confirm": true, "expected_count": 1}`)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if resp.Failed != 1 || resp.Results[0].Error == "" {
+		t.Errorf("response = %+v, want one failed result", resp)
+	}
+	if len(historyRepo.histories) != 2 {
+		t.Errorf("history entries = %d, want 2 (failed replay not recorded)", len(historyRepo.histories))
+	}
+}
+
+func TestReplay_UnknownChannel(t *testing.T) {
+	handler, historyRepo, dispatcher := newReplayFixture(t)
+	dispatcher.channels = map[string]bool{"email": true}
+
+	rec, resp := doReplay(handler, `{"history_id": "h1", "confirm": true, "expected_count": 1}`)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if resp.Sent != 0 || resp.Failed != 1 || len(resp.Results[0].Unknown) != 1 || resp.Results[0].Unknown[0] != "slack" {
+		t.Errorf("response = %+v, want one failed result for slack", resp)
+	}
+	if len(dispatcher.sent) != 0 || len(historyRepo.histories) != 2 {
+		t.Errorf("dispatched = %d, history entries = %d, want nothing sent or recorded", len(dispatcher.sent), len(historyRepo.histories))
+	}
+}
+
+func TestReplay_RateLimited(t *testing.T) {
+	handler, historyRepo, dispatcher := newReplayFixture(t)
+	dispatcher.err = notifier.ErrRateLimited
+
+	rec, resp := doReplay(handler, `{"history_id": "h1", "confirm": true, "expected_count": 1}`)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if resp.Sent != 0 || resp.Failed != 0 || resp.Skipped != 1 || !resp.Results[0].Skipped {
+		t.Errorf("response = %+v, want one skipped result", resp)
+	}
+	if len(historyRepo.histories) != 2 {
+		t.Errorf("history entries = %d, want 2 (skipped replay not recorded)", len(historyRepo.histories))
+	}
+}
+
+func TestReplay_InvalidRequests(t *testing.T) {
+	handler, _, _ := newReplayFixture(t)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"invalid json", `{`, http.StatusBadRequest},
+		{"no selector", `{}`, http.StatusBadRequest},
+		{"history_id with range", `{"history_id": "h1", "start": "2024-01-01T00:00:00Z"}`, http.StatusBadRequest},
+		{"invalid start", `{"start": "yesterday", "end": "2024-01-02T00:00:00Z"}`, http.StatusBadRequest},
+		{"end before start", `{"start": "2024-01-02T00:00:00Z", "end": "2024-01-01T00:00:00Z"}`, http.StatusBadRequest},
+		{"unknown history", `{"history_id": "missing"}`, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, _ := doReplay(handler, tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+const testRulesYAML = `
+rules:
+  - name: "error-burst"
+    type: "threshold"
+    condition:
+      field: "level"
+      value: "error"
+      threshold: 3
+      window: "1m"
+    severity: "high"
+    cooldown: "10m"
+  - name: "parse-failures"
+    type: "parse_errors"
+    condition:
+      threshold: 5
+      window: "5m"
+    severity: "medium"
+`
+
+// newTestLogStorage stores n error logs, one a second from base.
+func newTestLogStorage(t *testing.T, base time.Time, n int) storage.LogStorage {
+	t.Helper()
+	ls := storage.NewMemoryLogStorage()
+	records := make([]*storage.LogRecord, n)
+	for i := range records {
+		records[i] = &storage.LogRecord{
+			ID:        uuid.New().String(),
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+			Level:     "error",
+			Message:   "db timeout",
+		}
+	}
+	if err := ls.Logs().InsertBatch(context.Background(), records); err != nil {
+		t.Fatal(err)
+	}
+	return ls
+}
+
+func doTest(handler *Handler, body map[string]any, accept string) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest("POST", "/api/v1/alerts/test", bytes.NewReader(data))
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	req = withAdminContext(req)
+	rec := httptest.NewRecorder()
+	handler.Test(rec, req)
+	return rec
+}
+
+func TestTest_Report(t *testing.T) {
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	tests := []struct {
+		name        string
+		maxEntries  int
+		wantEntries int
+		wantPartial string
+	}{
+		{"all logs", 0, 2500, ""},
+		{"stopped at max entries", 1200, 1200, testPartialMaxEntries},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore, _, _ := newMockStorage()
+			handler := NewHandler(mockStore)
+			handler.SetLogStorage(newTestLogStorage(t, base, 2500))
+
+			rec := doTest(handler, map[string]any{
+				"rules":       testRulesYAML,
+				"start":       base.Format(time.RFC3339),
+				"max_entries": tt.maxEntries,
+			}, "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200; body: %s", rec.Code, rec.Body.String())
+			}
+			var resp struct {
+				Data TestResponse `json:"data"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			got := resp.Data
+			if got.Entries != tt.wantEntries || got.PartialReason != tt.wantPartial || got.Partial != (tt.wantPartial != "") {
+				t.Errorf("entries = %d, partial = %v %q, want %d, %q", got.Entries, got.Partial, got.PartialReason, tt.wantEntries, tt.wantPartial)
+			}
+			if tt.wantPartial != "" && (got.ReplayedTo == nil || !got.ReplayedTo.Equal(base.Add(time.Duration(tt.wantEntries-1)*time.Second))) {
+				t.Errorf("replayed_to = %v, want the last replayed entry", got.ReplayedTo)
+			}
+			// One burst every 10m cooldown, from the first three entries on
+			wantAlerts := (tt.wantEntries-3)/600 + 1
+			if got.Counts["error-burst"] != wantAlerts || len(got.Alerts) != wantAlerts {
+				t.Errorf("counts = %v, alerts = %d, want error-burst %d", got.Counts, len(got.Alerts), wantAlerts)
+			}
+			if len(got.Skipped) != 1 || got.Skipped[0] != "parse-failures" {
+				t.Errorf("skipped_rules = %v, want [parse-failures]", got.Skipped)
+			}
+		})
+	}
+}
+
+func TestTest_StreamsProgress(t *testing.T) {
+	defer func(d time.Duration) { testProgressInterval = d }(testProgressInterval)
+	testProgressInterval = 0
+
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	mockStore, _, _ := newMockStorage()
+	handler := NewHandler(mockStore)
+	handler.SetLogStorage(newTestLogStorage(t, base, 5))
+
+	rec := doTest(handler, map[string]any{"rules": testRulesYAML, "start": base.Format(time.RFC3339)}, "text/event-stream")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+	events := strings.Split(strings.TrimSpace(rec.Body.String()), "\n\n")
+	if len(events) != 6 {
+		t.Fatalf("events = %d, want 5 progress and a result:\n%s", len(events), rec.Body.String())
+	}
+	if !strings.HasPrefix(events[0], "event: progress\ndata: ") {
+		t.Errorf("first event = %q, want progress", events[0])
+	}
+	last := events[len(events)-1]
+	data, ok := strings.CutPrefix(last, "event: result\ndata: ")
+	if !ok {
+		t.Fatalf("last event = %q, want result", last)
+	}
+	var result TestResponse
+	if err := json.Unmarshal([]byte(data), &result); err != nil {
+		t.Fatalf("decode result: %v", err)
+	}
+	if result.Entries != 5 || result.Partial || result.Counts["error-burst"] != 1 {
+		t.Errorf("result = %+v, want 5 entries and one alert", result)
+	}
+}
+
+func TestTest_InvalidRequests(t *testing.T) {
+	start := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	tests := []struct {
+		name       string
+		body       map[string]any
+		wantStatus int
+	}{
+		{"invalid rules", map[string]any{"rules": "rules: [{name: x, type: nope}]", "start": start}, http.StatusBadRequest},
+		{"no rules", map[string]any{"rules": "rules: []", "start": start}, http.StatusBadRequest},
+		{"missing start", map[string]any{"rules": testRulesYAML}, http.StatusBadRequest},
+		{"end before start", map[string]any{"rules": testRulesYAML, "start": start, "end": "2000-01-01T00:00:00Z"}, http.StatusBadRequest},
+		{"invalid filter", map[string]any{"rules": testRulesYAML, "start": start, "filter": "level =="}, http.StatusBadRequest},
+		{"too many entries", map[string]any{"rules": testRulesYAML, "start": start, "max_entries": maxTestEntries + 1}, http.StatusBadRequest},
+		{"too long", map[string]any{"rules": testRulesYAML, "start": start, "max_duration": "1h"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore, _, _ := newMockStorage()
+			handler := NewHandler(mockStore)
+			handler.SetLogStorage(storage.NewMemoryLogStorage())
+
+			rec := doTest(handler, tt.body, "")
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+
+	t.Run("no log storage", func(t *testing.T) {
+		mockStore, _, _ := newMockStorage()
+		rec := doTest(NewHandler(mockStore), map[string]any{"rules": testRulesYAML, "start": start}, "")
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("status = %d, want 503", rec.Code)
+		}
+	})
+}
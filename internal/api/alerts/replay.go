@@ -0,0 +1,305 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/good-yellow-bee/blazelog/internal/alerting"
+	"github.com/good-yellow-bee/blazelog/internal/api/middleware"
+	"github.com/good-yellow-bee/blazelog/internal/models"
+	"github.com/good-yellow-bee/blazelog/internal/notifier"
+)
+
+// maxReplayEntries bounds how many history entries a single replay can
+// re-notify. Larger ranges must be split.
+const maxReplayEntries = 500
+
+// replayLabel marks re-sent notifications with the original history ID.
+const replayLabel = "replay_of"
+
+// Dispatcher delivers alert notifications to their channels. Get reports
+// whether a channel is configured; Dispatch skips channels that aren't.
+type Dispatcher interface {
+	Dispatch(ctx context.Context, alert *alerting.Alert) error
+	Get(name string) (notifier.Notifier, bool)
+}
+
+// SetDispatcher sets the dispatcher used to replay notifications.
+// Replay is unavailable until one is set.
+func (h *Handler) SetDispatcher(d Dispatcher) {
+	h.dispatcher = d
+}
+
+// ReplayRequest selects the history entries to re-notify: either a single
+// entry by ID, or every original entry of a time range, optionally for one
+// alert. Without Confirm only a preview is returned; with it, ExpectedCount
+// must match the preview count so a changed range cannot fan out.
+type ReplayRequest struct {
+	HistoryID     string `json:"history_id,omitempty"`
+	AlertID       string `json:"alert_id,omitempty"`
+	Start         string `json:"start,omitempty"` // RFC3339, inclusive
+	End           string `json:"end,omitempty"`   // RFC3339, exclusive
+	Confirm       bool   `json:"confirm"`
+	ExpectedCount int64  `json:"expected_count"`
+}
+
+// ReplayResult is the outcome of re-notifying one history entry.
+type ReplayResult struct {
+	HistoryID string   `json:"history_id"`
+	ReplayID  string   `json:"replay_id,omitempty"` // history entry recording the re-delivery
+	Channels  []string `json:"channels"`
+	Unknown   []string `json:"unknown_channels,omitempty"` // channels not in the notifications config
+	Skipped   bool     `json:"skipped,omitempty"`          // held back by notifications.rate_limit
+	Error     string   `json:"error,omitempty"`
+}
+
+// ReplayResponse is the response for POST /api/v1/alerts/history/replay.
+type ReplayResponse struct {
+	Count     int64                   `json:"count"`
+	Confirmed bool                    `json:"confirmed"`
+	Items     []*AlertHistoryResponse `json:"items,omitempty"`   // preview only
+	Results   []*ReplayResult         `json:"results,omitempty"` // confirmed only
+	Sent      int                     `json:"sent"`
+	Failed    int                     `json:"failed"`
+	Skipped   int                     `json:"skipped"` // rate limited, not sent
+}
+
+// Replay re-sends notifications for past alert history entries through the
+// current channel configuration and records each re-delivery in history.
+func (h *Handler) Replay(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if h.dispatcher == nil {
+		jsonError(w, http.StatusServiceUnavailable, errCodeInternalError, "notification channels not configured")
+		return
+	}
+
+	var req ReplayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, http.StatusBadRequest, errCodeBadRequest, "invalid request body")
+		return
+	}
+
+	entries, total, ok := h.selectReplay(ctx, w, &req)
+	if !ok {
+		return
+	}
+	if total > maxReplayEntries {
+		jsonError(w, http.StatusBadRequest, errCodeValidationFailed,
+			fmt.Sprintf("range matches %d entries, more than the %d allowed per replay; narrow the range", total, maxReplayEntries))
+		return
+	}
+
+	if !req.Confirm {
+		items := make([]*AlertHistoryResponse, len(entries))
+		for i, entry := range entries {
+			items[i] = historyToResponse(entry)
+		}
+		jsonOK(w, ReplayResponse{Count: total, Items: items})
+		return
+	}
+
+	if req.ExpectedCount != total {
+		jsonError(w, http.StatusConflict, errCodeConflict,
+			fmt.Sprintf("replay matches %d entries, expected %d; preview again", total, req.ExpectedCount))
+		return
+	}
+
+	resp := ReplayResponse{Count: total, Confirmed: true, Results: make([]*ReplayResult, 0, len(entries))}
+	channels := newChannelResolver(h)
+	for _, entry := range entries {
+		result := h.replayEntry(ctx, entry, channels)
+		switch {
+		case result.Skipped:
+			resp.Skipped++
+		case result.Error != "":
+			resp.Failed++
+		default:
+			resp.Sent++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	slog.Info("alert notifications replayed", "by", middleware.GetUsername(ctx),
+		"sent", resp.Sent, "failed", resp.Failed, "skipped", resp.Skipped)
+	jsonOK(w, resp)
+}
+
+// selectReplay loads the entries a replay request targets. It writes the
+// error response and returns false if the request is invalid.
+func (h *Handler) selectReplay(ctx context.Context, w http.ResponseWriter, req *ReplayRequest) ([]*models.AlertHistory, int64, bool) {
+	if req.HistoryID != "" {
+		if req.Start != "" || req.End != "" || req.AlertID != "" {
+			jsonError(w, http.StatusBadRequest, errCodeValidationFailed, "history_id cannot be combined with alert_id, start or end")
+			return nil, 0, false
+		}
+		entry, err := h.storage.AlertHistory().GetByID(ctx, req.HistoryID)
+		if err != nil {
+			slog.Error("replay alert history: get history", "error", err)
+			jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
+			return nil, 0, false
+		}
+		if entry == nil {
+			jsonError(w, http.StatusNotFound, errCodeNotFound, "alert history entry not found")
+			return nil, 0, false
+		}
+		return []*models.AlertHistory{entry}, 1, true
+	}
+
+	if req.Start == "" || req.End == "" {
+		jsonError(w, http.StatusBadRequest, errCodeValidationFailed, "history_id or start and end are required")
+		return nil, 0, false
+	}
+	start, err := time.Parse(time.RFC3339, req.Start)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, errCodeValidationFailed, "invalid start time format, use RFC3339")
+		return nil, 0, false
+	}
+	end, err := time.Parse(time.RFC3339, req.End)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, errCodeValidationFailed, "invalid end time format, use RFC3339")
+		return nil, 0, false
+	}
+	if !end.After(start) {
+		jsonError(w, http.StatusBadRequest, errCodeValidationFailed, "end must be after start")
+		return nil, 0, false
+	}
+
+	entries, total, err := h.storage.AlertHistory().ListBetween(ctx, req.AlertID, start, end, maxReplayEntries)
+	if err != nil {
+		slog.Error("replay alert history: list history", "error", err)
+		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
+		return nil, 0, false
+	}
+	return entries, total, true
+}
+
+// replayEntry re-sends one history entry and records the re-delivery.
+func (h *Handler) replayEntry(ctx context.Context, entry *models.AlertHistory, channels *channelResolver) *ReplayResult {
+	result := &ReplayResult{HistoryID: entry.ID}
+
+	rule, notify, err := channels.resolve(ctx, entry.AlertID)
+	if err != nil {
+		slog.Error("replay alert history: resolve channels", "history_id", entry.ID, "error", err)
+		result.Error = "failed to resolve notification channels"
+		return result
+	}
+	if rule == nil {
+		result.Error = "alert rule no longer exists"
+		return result
+	}
+	result.Channels = notify
+	if len(notify) == 0 {
+		result.Error = "alert has no notification channels"
+		return result
+	}
+	// Dispatch would skip these and report success; fail the entry instead,
+	// so that it is replayed once the channels are configured
+	for _, name := range notify {
+		if _, ok := h.dispatcher.Get(name); !ok {
+			result.Unknown = append(result.Unknown, name)
+		}
+	}
+	if len(result.Unknown) > 0 {
+		result.Error = fmt.Sprintf("notification channels not configured: %s", strings.Join(result.Unknown, ", "))
+		return result
+	}
+
+	alert := &alerting.Alert{
+		RuleName:    entry.AlertName,
+		Description: rule.Description,
+		Severity:    alerting.ParseSeverity(string(entry.Severity)),
+		Message:     entry.Message,
+		Timestamp:   entry.NotifiedAt,
+		Count:       entry.MatchedLogs,
+		Notify:      notify,
+		ProjectID:   entry.ProjectID,
+		Labels:      map[string]string{replayLabel: entry.ID},
+
+		NotifyTemplate: rule.NotifyTemplate,
+	}
+	if err := h.dispatcher.Dispatch(ctx, alert); err != nil {
+		result.Skipped = errors.Is(err, notifier.ErrRateLimited)
+		result.Error = err.Error()
+		return result
+	}
+
+	now := time.Now()
+	replay := &models.AlertHistory{
+		ID:          uuid.New().String(),
+		AlertID:     entry.AlertID,
+		AlertName:   entry.AlertName,
+		Severity:    entry.Severity,
+		Message:     entry.Message,
+		MatchedLogs: entry.MatchedLogs,
+		NotifiedAt:  now,
+		ProjectID:   entry.ProjectID,
+		ReplayOf:    entry.ID,
+		CreatedAt:   now,
+	}
+	if err := h.storage.AlertHistory().Create(ctx, replay); err != nil {
+		// The notification went out; only the record is missing.
+		slog.Error("replay alert history: record replay", "history_id", entry.ID, "error", err)
+		return result
+	}
+	result.ReplayID = replay.ID
+	return result
+}
+
+// channelResolver resolves the current channels of alert rules, caching
+// rules and project defaults for the duration of one replay.
+type channelResolver struct {
+	h        *Handler
+	rules    map[string]*models.AlertRule
+	projects map[string][]string
+}
+
+func newChannelResolver(h *Handler) *channelResolver {
+	return &channelResolver{
+		h:        h,
+		rules:    make(map[string]*models.AlertRule),
+		projects: make(map[string][]string),
+	}
+}
+
+// resolve returns the rule and its effective channels. An explicit Notify
+// list (even empty) wins; otherwise the project's defaults apply. A nil
+// rule means it was deleted.
+func (c *channelResolver) resolve(ctx context.Context, alertID string) (*models.AlertRule, []string, error) {
+	rule, ok := c.rules[alertID]
+	if !ok {
+		var err error
+		rule, err = c.h.storage.Alerts().GetByID(ctx, alertID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("get alert: %w", err)
+		}
+		c.rules[alertID] = rule
+	}
+	if rule == nil {
+		return nil, nil, nil
+	}
+	if rule.Notify != nil || rule.ProjectID == "" {
+		return rule, rule.Notify, nil
+	}
+
+	notify, ok := c.projects[rule.ProjectID]
+	if !ok {
+		project, err := c.h.storage.Projects().GetByID(ctx, rule.ProjectID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("get project: %w", err)
+		}
+		if project != nil {
+			notify = project.DefaultNotify
+		}
+		c.projects[rule.ProjectID] = notify
+	}
+	return rule, notify, nil
+}
@@ -2,11 +2,16 @@ package alerts
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 
+	"github.com/good-yellow-bee/blazelog/internal/alerting"
 	"github.com/good-yellow-bee/blazelog/internal/models"
 )
 
+// maxNotifyTemplateLength bounds the size of a rule's notification template.
+const maxNotifyTemplateLength = 4000
+
 func ValidateName(name string) error {
 	name = strings.TrimSpace(name)
 	if name == "" {
@@ -42,3 +47,18 @@ func ValidateCondition(condition string) error {
 	}
 	return nil
 }
+
+// ValidateNotifyTemplate checks that a notification template parses and
+// renders against an alert. An empty template is valid.
+func ValidateNotifyTemplate(tmpl string) error {
+	if tmpl == "" {
+		return nil
+	}
+	if len(tmpl) > maxNotifyTemplateLength {
+		return fmt.Errorf("notify_template must be %d characters or less", maxNotifyTemplateLength)
+	}
+	if err := alerting.ValidateNotifyTemplate(tmpl); err != nil {
+		return fmt.Errorf("invalid notify_template: %v", err)
+	}
+	return nil
+}
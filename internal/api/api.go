@@ -5,11 +5,15 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
 
+	"github.com/good-yellow-bee/blazelog/internal/api/agents"
+	"github.com/good-yellow-bee/blazelog/internal/api/alerts"
 	"github.com/good-yellow-bee/blazelog/internal/api/health"
+	"github.com/good-yellow-bee/blazelog/internal/api/middleware"
+	"github.com/good-yellow-bee/blazelog/internal/security"
 	"github.com/good-yellow-bee/blazelog/internal/storage"
 	"github.com/good-yellow-bee/blazelog/internal/web/session"
 )
@@ -18,14 +22,16 @@ import (
 type Config struct {
 	Address            string
 	JWTSecret          []byte
-	CSRFSecret         string   // For web UI CSRF protection
-	TrustedOrigins     []string // Trusted origins for CSRF (e.g., "localhost:8080")
-	TrustedProxies     []string // Trusted proxy IPs/CIDRs for X-Forwarded-For
-	WebUIEnabled       bool     // Enable web UI (default: true)
-	UseSecureCookies   bool     // Use Secure flag for cookies (true in production with HTTPS)
-	HTTPTLSEnabled     bool     // Enable HTTPS for API server
-	HTTPTLSCertFile    string   // HTTPS certificate file
-	HTTPTLSKeyFile     string   // HTTPS private key file
+	JWTPreviousSecrets [][]byte           // Retired secrets still accepted during rotation
+	CSRFSecret         string             // For web UI CSRF protection
+	TrustedOrigins     []string           // Trusted origins for CSRF (e.g., "localhost:8080")
+	TrustedProxies     []string           // Trusted proxy IPs/CIDRs for X-Forwarded-For
+	WebUIEnabled       bool               // Enable web UI (default: true)
+	UseSecureCookies   bool               // Use Secure flag for cookies (true in production with HTTPS)
+	HTTPTLSEnabled     bool               // Enable HTTPS for API server
+	HTTPTLSCertFile    string             // HTTPS certificate file
+	HTTPTLSKeyFile     string             // HTTPS private key file
+	HTTPTLSPolicy      security.TLSPolicy // HTTPS versions and cipher suites (zero = TLS 1.3 only)
 	AccessTokenTTL     time.Duration
 	RefreshTokenTTL    time.Duration
 	RateLimitPerIP     int
@@ -36,7 +42,31 @@ type Config struct {
 	QueryTimeout       time.Duration // Timeout for storage-backed API calls
 	StreamMaxDuration  time.Duration // Max lifetime for log stream connections
 	StreamPollInterval time.Duration // Poll interval for stream query loop
+	ShareLinkTTL       time.Duration // Lifetime of shared filter codes
+	ShareRateLimit     int           // Shared filter creations per user per minute (negative = unlimited)
+	MaxResultRows      int           // Max rows a query window or export can return
 	Verbose            bool
+
+	// EndpointRateLimits maps route patterns ("[METHOD ]/path") to
+	// per-minute limits applied on top of RateLimitPerIP/RateLimitPerUser.
+	EndpointRateLimits map[string]int
+
+	// IndexedLabels are label keys advertised by GET /logs/schema as
+	// first-class filters.
+	IndexedLabels []string
+	// StreamReorderWindow is the overlap the log stream re-queries for
+	// late-arriving entries (0 disables).
+	StreamReorderWindow time.Duration
+	// Notifier delivers replayed alert notifications (nil disables replay).
+	Notifier alerts.Dispatcher
+	// RawTailer streams raw bytes of agent sources (nil disables the
+	// admin-only raw tail endpoint).
+	RawTailer agents.RawTailer
+	// RawTailRateLimit is raw tail requests per user per minute.
+	RawTailRateLimit int
+	// RawTailAudit records raw tail sessions (nil logs them only to the
+	// server log).
+	RawTailAudit agents.AuditLogger
 }
 
 // SetDefaults applies default values for missing configuration.
@@ -74,6 +104,18 @@ func (c *Config) SetDefaults() {
 	if c.StreamPollInterval == 0 {
 		c.StreamPollInterval = time.Second
 	}
+	if c.ShareLinkTTL == 0 {
+		c.ShareLinkTTL = 7 * 24 * time.Hour // 7 days
+	}
+	if c.ShareRateLimit == 0 {
+		c.ShareRateLimit = 10 // 10 links per minute
+	}
+	if c.MaxResultRows == 0 {
+		c.MaxResultRows = 100000
+	}
+	if c.RawTailRateLimit == 0 {
+		c.RawTailRateLimit = 2 // 2 tails per minute
+	}
 }
 
 // Server is the HTTP API server.
@@ -84,6 +126,7 @@ type Server struct {
 	sessions      *session.Store
 	server        *http.Server
 	healthHandler *health.Handler
+	endpoints     *middleware.EndpointLimiter
 }
 
 // New creates a new API server.
@@ -101,6 +144,11 @@ func New(cfg *Config, store storage.Storage, logStore storage.LogStorage) (*Serv
 
 	cfg.SetDefaults()
 
+	endpoints, err := middleware.NewEndpointLimiter(cfg.EndpointRateLimits)
+	if err != nil {
+		return nil, fmt.Errorf("endpoint rate limits: %w", err)
+	}
+
 	// Create session store for web UI authentication (24 hour TTL)
 	sessions := session.NewStore(24 * time.Hour)
 
@@ -110,6 +158,7 @@ func New(cfg *Config, store storage.Storage, logStore storage.LogStorage) (*Serv
 		logStorage:    logStore,
 		sessions:      sessions,
 		healthHandler: health.NewHandler(),
+		endpoints:     endpoints,
 	}
 
 	router := s.setupRouter()
@@ -127,8 +176,9 @@ func New(cfg *Config, store storage.Storage, logStore storage.LogStorage) (*Serv
 		IdleTimeout:  60 * time.Second,
 	}
 	if cfg.HTTPTLSEnabled {
-		s.server.TLSConfig = &tls.Config{
-			MinVersion: tls.VersionTLS13,
+		s.server.TLSConfig = &tls.Config{}
+		if err := cfg.HTTPTLSPolicy.Apply(s.server.TLSConfig); err != nil {
+			return nil, fmt.Errorf("http tls policy: %w", err)
 		}
 	}
 
@@ -145,7 +195,7 @@ func (s *Server) Run(ctx context.Context) error {
 	errChan := make(chan error, 1)
 
 	go func() {
-		log.Printf("HTTP API listening on %s", s.config.Address)
+		slog.Info("HTTP API listening", "address", s.config.Address)
 		var err error
 		if s.config.HTTPTLSEnabled {
 			err = s.server.ListenAndServeTLS(s.config.HTTPTLSCertFile, s.config.HTTPTLSKeyFile)
@@ -159,7 +209,7 @@ func (s *Server) Run(ctx context.Context) error {
 
 	select {
 	case <-ctx.Done():
-		log.Printf("shutting down HTTP API server...")
+		slog.Info("shutting down HTTP API server")
 		s.sessions.Close()
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
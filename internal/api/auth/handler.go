@@ -2,7 +2,7 @@ package auth
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -48,7 +48,7 @@ func jsonError(w http.ResponseWriter, status int, code, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(errorResponse{Error: errorBody{Code: code, Message: message}}); err != nil {
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 	}
 }
 
@@ -56,7 +56,7 @@ func jsonOK(w http.ResponseWriter, data any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(dataResponse{Data: data}); err != nil {
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 	}
 }
 
@@ -112,7 +112,7 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	// Check lockout
 	if h.lockoutTracker.IsLocked(req.Username) {
 		remaining := h.lockoutTracker.RemainingLockoutTime(req.Username)
-		log.Printf("login blocked: account %s locked for %v", req.Username, remaining)
+		slog.Warn("login blocked: account locked", "username", req.Username, "remaining", remaining)
 		jsonError(w, http.StatusTooManyRequests, errCodeAccountLocked, "account temporarily locked due to too many failed attempts")
 		return
 	}
@@ -121,13 +121,13 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	user, err := h.storage.Users().GetByUsername(ctx, req.Username)
 	if err != nil {
-		log.Printf("login error: get user: %v", err)
+		slog.Error("login: get user", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
 	if user == nil {
 		h.lockoutTracker.RecordFailure(req.Username)
-		log.Printf("login failed: user %s not found", req.Username)
+		slog.Warn("login failed: user not found", "username", req.Username)
 		jsonError(w, http.StatusUnauthorized, errCodeUnauthorized, "invalid credentials")
 		return
 	}
@@ -135,7 +135,7 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	// Verify password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
 		h.lockoutTracker.RecordFailure(req.Username)
-		log.Printf("login failed: invalid password for user %s", req.Username)
+		slog.Warn("login failed: invalid password", "username", req.Username)
 		jsonError(w, http.StatusUnauthorized, errCodeUnauthorized, "invalid credentials")
 		return
 	}
@@ -146,7 +146,7 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	// Generate access token
 	accessToken, err := h.jwtService.GenerateToken(user)
 	if err != nil {
-		log.Printf("login error: generate access token: %v", err)
+		slog.Error("login: generate access token", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -154,12 +154,12 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	// Generate refresh token
 	refreshToken, err := h.tokenService.CreateRefreshToken(ctx, user.ID)
 	if err != nil {
-		log.Printf("login error: generate refresh token: %v", err)
+		slog.Error("login: generate refresh token", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
 
-	log.Printf("login success: user %s", req.Username)
+	slog.Info("login success", "username", req.Username)
 
 	jsonOK(w, &LoginResponse{
 		AccessToken:  accessToken,
@@ -187,7 +187,7 @@ func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
 	// Validate refresh token and get user
 	user, err := h.tokenService.ValidateRefreshToken(ctx, req.RefreshToken)
 	if err != nil {
-		log.Printf("refresh failed: %v", err)
+		slog.Warn("refresh failed", "error", err)
 		jsonError(w, http.StatusUnauthorized, errCodeUnauthorized, "invalid or expired token")
 		return
 	}
@@ -195,7 +195,7 @@ func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
 	// Generate new access token
 	accessToken, err := h.jwtService.GenerateToken(user)
 	if err != nil {
-		log.Printf("refresh error: generate access token: %v", err)
+		slog.Error("refresh: generate access token", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -203,12 +203,12 @@ func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
 	// Rotate refresh token (revoke old, create new)
 	newRefreshToken, err := h.tokenService.RotateRefreshToken(ctx, req.RefreshToken, user.ID)
 	if err != nil {
-		log.Printf("refresh error: rotate refresh token: %v", err)
+		slog.Error("refresh: rotate refresh token", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
 
-	log.Printf("token refresh success: user %s", user.Username)
+	slog.Debug("token refresh success", "username", user.Username)
 
 	jsonOK(w, &LoginResponse{
 		AccessToken:  accessToken,
@@ -235,11 +235,11 @@ func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 
 	// Revoke the refresh token
 	if err := h.tokenService.RevokeRefreshToken(ctx, req.RefreshToken); err != nil {
-		log.Printf("logout error: revoke token: %v", err)
+		slog.Error("logout: revoke token", "error", err)
 		// Don't return error - token might already be revoked
 	}
 
-	log.Printf("logout success")
+	slog.Info("logout success")
 
 	jsonNoContent(w)
 }
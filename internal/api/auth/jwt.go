@@ -2,6 +2,7 @@
 package auth
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -20,17 +21,26 @@ type Claims struct {
 
 // JWTService handles JWT token generation and validation.
 type JWTService struct {
-	secret []byte
-	ttl    time.Duration
-	issuer string
+	secret   []byte
+	previous [][]byte // retired secrets still accepted for validation
+	ttl      time.Duration
+	issuer   string
 }
 
 // NewJWTService creates a new JWT service.
 func NewJWTService(secret []byte, ttl time.Duration) *JWTService {
+	return NewJWTServiceWithPrevious(secret, nil, ttl)
+}
+
+// NewJWTServiceWithPrevious creates a JWT service that signs with secret and
+// also accepts tokens signed with any of the previous secrets. This lets the
+// secret be rotated without invalidating tokens issued before the rotation.
+func NewJWTServiceWithPrevious(secret []byte, previous [][]byte, ttl time.Duration) *JWTService {
 	return &JWTService{
-		secret: secret,
-		ttl:    ttl,
-		issuer: "blazelog",
+		secret:   secret,
+		previous: previous,
+		ttl:      ttl,
+		issuer:   "blazelog",
 	}
 }
 
@@ -55,16 +65,16 @@ func (s *JWTService) GenerateToken(user *models.User) (string, error) {
 	return token.SignedString(s.secret)
 }
 
-// ValidateToken validates a JWT token and returns the claims.
+// ValidateToken validates a JWT token and returns the claims. Tokens signed
+// with the current secret or a previous one are accepted.
 func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (any, error) {
-		// Validate signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	token, err := s.parse(tokenString, s.secret)
+	for _, secret := range s.previous {
+		if !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+			break
 		}
-		return s.secret, nil
-	})
-
+		token, err = s.parse(tokenString, secret)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("parse token: %w", err)
 	}
@@ -82,6 +92,17 @@ func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
 	return claims, nil
 }
 
+// parse parses and verifies a token signed with secret.
+func (s *JWTService) parse(tokenString string, secret []byte) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (any, error) {
+		// Validate signing method
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return secret, nil
+	})
+}
+
 // TTL returns the token time-to-live duration.
 func (s *JWTService) TTL() time.Duration {
 	return s.ttl
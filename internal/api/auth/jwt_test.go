@@ -128,3 +128,43 @@ func TestJWTService_TTLSeconds(t *testing.T) {
 		t.Errorf("TTLSeconds() = %d, want %d", got, want)
 	}
 }
+
+func TestJWTService_PreviousSecrets(t *testing.T) {
+	oldSecret := []byte("old-secret-key-32-bytes-long!!!")
+	newSecret := []byte("new-secret-key-32-bytes-long!!!")
+	ttl := 15 * time.Minute
+	user := &models.User{ID: "user-123", Username: "testuser", Role: models.RoleViewer}
+
+	oldToken, err := NewJWTService(oldSecret, ttl).GenerateToken(user)
+	if err != nil {
+		t.Fatalf("GenerateToken failed: %v", err)
+	}
+
+	rotated := NewJWTServiceWithPrevious(newSecret, [][]byte{oldSecret}, ttl)
+	if _, err := rotated.ValidateToken(oldToken); err != nil {
+		t.Errorf("token signed with previous secret rejected: %v", err)
+	}
+
+	// New tokens are signed with the current secret only
+	newToken, err := rotated.GenerateToken(user)
+	if err != nil {
+		t.Fatalf("GenerateToken failed: %v", err)
+	}
+	if _, err := NewJWTService(newSecret, ttl).ValidateToken(newToken); err != nil {
+		t.Errorf("token not signed with current secret: %v", err)
+	}
+
+	// Once the previous secret is dropped, old tokens stop validating
+	if _, err := NewJWTService(newSecret, ttl).ValidateToken(oldToken); err == nil {
+		t.Error("token signed with retired secret accepted")
+	}
+
+	// Expired tokens are not retried against previous secrets
+	expired, err := NewJWTService(oldSecret, -time.Minute).GenerateToken(user)
+	if err != nil {
+		t.Fatalf("GenerateToken failed: %v", err)
+	}
+	if _, err := rotated.ValidateToken(expired); err == nil {
+		t.Error("expired token accepted")
+	}
+}
@@ -3,7 +3,7 @@ package auth
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/good-yellow-bee/blazelog/internal/models"
@@ -83,7 +83,7 @@ func (s *TokenService) RevokeAllUserTokens(ctx context.Context, userID string) e
 func (s *TokenService) RotateRefreshToken(ctx context.Context, oldPlainToken string, userID string) (string, error) {
 	// Revoke old token (log error but continue - we still want to issue new token)
 	if err := s.RevokeRefreshToken(ctx, oldPlainToken); err != nil {
-		log.Printf("token rotation: failed to revoke old token for user %s: %v", userID, err)
+		slog.Error("token rotation: revoke old token", "user", userID, "error", err)
 	}
 
 	// Create new token
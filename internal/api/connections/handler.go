@@ -2,7 +2,7 @@ package connections
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
@@ -40,7 +40,7 @@ func jsonError(w http.ResponseWriter, status int, code, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(errorResponse{Error: errorBody{Code: code, Message: message}}); err != nil {
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 	}
 }
 
@@ -48,7 +48,7 @@ func jsonOK(w http.ResponseWriter, data any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(dataResponse{Data: data}); err != nil {
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 	}
 }
 
@@ -56,7 +56,7 @@ func jsonCreated(w http.ResponseWriter, data any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(dataResponse{Data: data}); err != nil {
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 	}
 }
 
@@ -119,7 +119,7 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 
 	access, err := middleware.GetProjectAccess(ctx, userID, role, h.storage)
 	if err != nil {
-		log.Printf("list connections error: get access: %v", err)
+		slog.Error("list connections: get access", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -157,7 +157,7 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err != nil {
-		log.Printf("list connections error: %v", err)
+		slog.Error("list connections", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -214,7 +214,7 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		role := middleware.GetRole(ctx)
 		access, err := middleware.GetProjectAccess(ctx, userID, role, h.storage)
 		if err != nil {
-			log.Printf("create connection error: get access: %v", err)
+			slog.Error("create connection: get access", "error", err)
 			jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 			return
 		}
@@ -228,7 +228,7 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	if req.ProjectID != "" {
 		project, err := h.storage.Projects().GetByID(ctx, req.ProjectID)
 		if err != nil {
-			log.Printf("create connection error: check project: %v", err)
+			slog.Error("create connection: check project", "error", err)
 			jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 			return
 		}
@@ -241,7 +241,7 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	// Check name uniqueness
 	existing, err := h.storage.Connections().GetByName(ctx, req.Name)
 	if err != nil {
-		log.Printf("create connection error: check name: %v", err)
+		slog.Error("create connection: check name", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -265,12 +265,12 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.storage.Connections().Create(ctx, conn); err != nil {
-		log.Printf("create connection error: %v", err)
+		slog.Error("create connection", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
 
-	log.Printf("connection created: %s (%s)", conn.Name, conn.ID)
+	slog.Info("connection created", "name", conn.Name, "id", conn.ID)
 	jsonCreated(w, connectionToResponse(conn))
 }
 
@@ -285,7 +285,7 @@ func (h *Handler) GetByID(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	conn, err := h.storage.Connections().GetByID(ctx, id)
 	if err != nil {
-		log.Printf("get connection error: %v", err)
+		slog.Error("get connection", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -298,7 +298,7 @@ func (h *Handler) GetByID(w http.ResponseWriter, r *http.Request) {
 	role := middleware.GetRole(ctx)
 	access, err := middleware.GetProjectAccess(ctx, userID, role, h.storage)
 	if err != nil {
-		log.Printf("get connection error: get access: %v", err)
+		slog.Error("get connection: get access", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -327,7 +327,7 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	conn, err := h.storage.Connections().GetByID(ctx, id)
 	if err != nil {
-		log.Printf("update connection error: get: %v", err)
+		slog.Error("update connection: get", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -340,7 +340,7 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	role := middleware.GetRole(ctx)
 	access, err := middleware.GetProjectAccess(ctx, userID, role, h.storage)
 	if err != nil {
-		log.Printf("update connection error: get access: %v", err)
+		slog.Error("update connection: get access", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -356,7 +356,7 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		}
 		existing, err := h.storage.Connections().GetByName(ctx, req.Name)
 		if err != nil {
-			log.Printf("update connection error: check name: %v", err)
+			slog.Error("update connection: check name", "error", err)
 			jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 			return
 		}
@@ -383,7 +383,7 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		// Validate project exists
 		project, err := h.storage.Projects().GetByID(ctx, req.ProjectID)
 		if err != nil {
-			log.Printf("update connection error: check project: %v", err)
+			slog.Error("update connection: check project", "error", err)
 			jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 			return
 		}
@@ -413,12 +413,12 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	conn.UpdatedAt = time.Now()
 
 	if err := h.storage.Connections().Update(ctx, conn); err != nil {
-		log.Printf("update connection error: %v", err)
+		slog.Error("update connection", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
 
-	log.Printf("connection updated: %s (%s)", conn.Name, conn.ID)
+	slog.Info("connection updated", "name", conn.Name, "id", conn.ID)
 	jsonOK(w, connectionToResponse(conn))
 }
 
@@ -433,7 +433,7 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	conn, err := h.storage.Connections().GetByID(ctx, id)
 	if err != nil {
-		log.Printf("delete connection error: get: %v", err)
+		slog.Error("delete connection: get", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -443,12 +443,12 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.storage.Connections().Delete(ctx, id); err != nil {
-		log.Printf("delete connection error: %v", err)
+		slog.Error("delete connection", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
 
-	log.Printf("connection deleted: %s (%s)", conn.Name, conn.ID)
+	slog.Info("connection deleted", "name", conn.Name, "id", conn.ID)
 	jsonNoContent(w)
 }
 
@@ -463,7 +463,7 @@ func (h *Handler) Test(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	conn, err := h.storage.Connections().GetByID(ctx, id)
 	if err != nil {
-		log.Printf("test connection error: get: %v", err)
+		slog.Error("test connection: get", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -479,12 +479,12 @@ func (h *Handler) Test(w http.ResponseWriter, r *http.Request) {
 	message := "Connection test successful"
 
 	if err := h.storage.Connections().UpdateStatus(ctx, id, status, now); err != nil {
-		log.Printf("test connection error: update status: %v", err)
+		slog.Error("test connection: update status", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
 
-	log.Printf("connection tested: %s (%s) - %s", conn.Name, conn.ID, status)
+	slog.Info("connection tested", "name", conn.Name, "id", conn.ID, "status", status)
 	jsonOK(w, TestResponse{
 		Success: status == models.ConnectionStatusConnected,
 		Message: message,
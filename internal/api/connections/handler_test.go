@@ -171,6 +171,8 @@ func (m *mockStorage) Alerts() storage.AlertRepository              { return nil
 func (m *mockStorage) Connections() storage.ConnectionRepository    { return m.connRepo }
 func (m *mockStorage) Tokens() storage.TokenRepository              { return nil }
 func (m *mockStorage) AlertHistory() storage.AlertHistoryRepository { return nil }
+func (m *mockStorage) SharedFilters() storage.SharedFilterRepository { return nil }
+func (m *mockStorage) SavedSearches() storage.SavedSearchRepository { return nil }
 
 func newMockStorage() (*mockStorage, *mockConnectionRepository) {
 	connRepo := &mockConnectionRepository{}
@@ -57,6 +57,29 @@ func (c *ClickHouseChecker) Check(ctx context.Context) error {
 	return c.pinger.Ping(ctx)
 }
 
+// PostgresChecker checks PostgreSQL log storage connectivity.
+type PostgresChecker struct {
+	pinger Pinger
+}
+
+// NewPostgresChecker creates a new PostgreSQL health checker.
+func NewPostgresChecker(p Pinger) *PostgresChecker {
+	return &PostgresChecker{pinger: p}
+}
+
+// Name returns the checker name.
+func (c *PostgresChecker) Name() string {
+	return "postgres"
+}
+
+// Check verifies PostgreSQL is accessible.
+func (c *PostgresChecker) Check(ctx context.Context) error {
+	if c.pinger == nil {
+		return fmt.Errorf("postgres not configured")
+	}
+	return c.pinger.Ping(ctx)
+}
+
 // GRPCChecker checks if the gRPC server is accepting connections.
 type GRPCChecker struct {
 	isRunning func() bool
@@ -4,7 +4,7 @@ package health
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 	"sync"
 	"time"
@@ -48,7 +48,7 @@ func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(Response{Status: "ok"}); err != nil {
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 	}
 }
 
@@ -59,7 +59,7 @@ func (h *Handler) Live(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(Response{Status: "live"}); err != nil {
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 	}
 }
 
@@ -102,6 +102,6 @@ func (h *Handler) Ready(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 	}
 }
@@ -0,0 +1,173 @@
+package logs
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/storage"
+)
+
+// exportChunkSize is how many rows an export reads from storage at a time.
+const exportChunkSize = 1000
+
+// exportCSVHeader is the header row of CSV exports.
+var exportCSVHeader = []string{
+	"id", "timestamp", "project_id", "level", "type", "source", "agent_id",
+	"file_path", "line_number", "http_status", "http_method", "uri", "message",
+	"fields", "labels",
+}
+
+// exportWriter writes the rows of an export in one format.
+type exportWriter interface {
+	write(r *storage.LogRecord) error
+	flush() error
+}
+
+// csvExport writes an export as CSV, fields and labels as JSON objects.
+type csvExport struct {
+	w *csv.Writer
+}
+
+func (e *csvExport) write(r *storage.LogRecord) error {
+	var status, line string
+	if r.HTTPStatus != 0 {
+		status = strconv.Itoa(r.HTTPStatus)
+	}
+	if r.LineNumber != 0 {
+		line = strconv.FormatInt(r.LineNumber, 10)
+	}
+	var fields, labels string
+	if len(r.Fields) > 0 {
+		data, err := json.Marshal(r.Fields)
+		if err != nil {
+			return fmt.Errorf("encode fields: %w", err)
+		}
+		fields = string(data)
+	}
+	if len(r.Labels) > 0 {
+		data, err := json.Marshal(r.Labels)
+		if err != nil {
+			return fmt.Errorf("encode labels: %w", err)
+		}
+		labels = string(data)
+	}
+	return e.w.Write([]string{
+		r.ID, r.Timestamp.Format(time.RFC3339Nano), r.ProjectID, r.Level, r.Type, r.Source, r.AgentID,
+		r.FilePath, line, status, r.HTTPMethod, r.URI, r.Message,
+		fields, labels,
+	})
+}
+
+func (e *csvExport) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// ndjsonExport writes an export as flat NDJSON, as flat=true queries do.
+type ndjsonExport struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonExport) write(r *storage.LogRecord) error {
+	return e.enc.Encode(flattenLog(recordToResponse(r)))
+}
+
+func (e *ndjsonExport) flush() error { return nil }
+
+// Export handles GET /api/v1/logs/export - download every log matching a
+// query as CSV or NDJSON. It takes the filter parameters of Query and reads
+// the results from storage in chunks, writing each as it arrives. Chunks
+// are paged with the keyset cursor, so exports aren't held to the max
+// result window and no chunk rescans the rows before it.
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	if h.logStorage == nil {
+		jsonError(w, http.StatusServiceUnavailable, errCodeInternalError, "log storage not configured")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "ndjson" {
+		jsonError(w, http.StatusBadRequest, errCodeBadRequest, "format must be csv or ndjson")
+		return
+	}
+
+	filter, ok := h.logFilter(w, r)
+	if !ok {
+		return
+	}
+	if filter.OrderBy != "timestamp" {
+		jsonError(w, http.StatusBadRequest, errCodeBadRequest, "export requires order=timestamp")
+		return
+	}
+	filter.Limit = exportChunkSize
+	filter.SkipTotal = true
+
+	// The first chunk is read before the headers go out, so that a failing
+	// query still gets an error response
+	result, err := h.queryChunk(r, filter)
+	if err != nil {
+		handleStorageError(w, err, "log export error")
+		return
+	}
+
+	var out exportWriter
+	filename := fmt.Sprintf("logs-export-%s.%s", time.Now().UTC().Format("20060102T150405Z"), format)
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		out = &csvExport{w: csv.NewWriter(w)}
+	} else {
+		w.Header().Set("Content-Type", contentTypeNDJSON)
+		out = &ndjsonExport{enc: json.NewEncoder(w)}
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	if csvOut, ok := out.(*csvExport); ok {
+		if err := csvOut.w.Write(exportCSVHeader); err != nil {
+			slog.Error("export write", "error", err)
+			return
+		}
+	}
+	for {
+		for _, record := range result.Entries {
+			if err := out.write(record); err != nil {
+				slog.Error("export write", "error", err)
+				return
+			}
+		}
+		if err := out.flush(); err != nil {
+			slog.Error("export write", "error", err)
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		if !result.HasMore || len(result.Entries) == 0 {
+			return
+		}
+		last := result.Entries[len(result.Entries)-1]
+		filter.AfterTime, filter.AfterID = last.Timestamp, last.ID
+		if result, err = h.queryChunk(r, filter); err != nil {
+			// The response is under way; the client gets a short file
+			slog.Error("log export error", "error", err, "after", filter.AfterID)
+			return
+		}
+	}
+}
+
+// queryChunk reads one chunk of an export, each under its own query timeout.
+func (h *Handler) queryChunk(r *http.Request, filter *storage.LogFilter) (*storage.LogQueryResult, error) {
+	ctx, cancel := h.newQueryContext(r.Context())
+	defer cancel()
+	return h.logStorage.Logs().Query(ctx, filter)
+}
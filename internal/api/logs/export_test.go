@@ -0,0 +1,212 @@
+package logs
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/storage"
+)
+
+// pagedLogRepository serves its entries, newest first, by the filter's
+// keyset cursor and limit, recording the chunks read.
+type pagedLogRepository struct {
+	mockLogRepository
+	chunks []string // cursor ID, or "" for the first chunk
+}
+
+func (m *pagedLogRepository) Query(ctx context.Context, filter *storage.LogFilter) (*storage.LogQueryResult, error) {
+	m.lastFilter = filter
+	m.chunks = append(m.chunks, filter.AfterID)
+	if m.queryError != nil {
+		return nil, m.queryError
+	}
+	if filter.Offset != 0 || !filter.SkipTotal {
+		return nil, fmt.Errorf("chunk read with offset %d, skip total %v", filter.Offset, filter.SkipTotal)
+	}
+	start := 0
+	if filter.AfterID != "" {
+		for i, e := range m.entries {
+			if e.ID == filter.AfterID {
+				start = i + 1
+			}
+		}
+	}
+	end := min(start+filter.Limit, len(m.entries))
+	return &storage.LogQueryResult{
+		Entries: m.entries[start:end],
+		Total:   int64(end),
+		HasMore: end < len(m.entries),
+	}, nil
+}
+
+type pagedLogStorage struct {
+	mockLogStorage
+	paged *pagedLogRepository
+}
+
+func (m *pagedLogStorage) Logs() storage.LogRepository { return m.paged }
+
+func newPagedLogStorage(n int) (*pagedLogStorage, *pagedLogRepository) {
+	repo := &pagedLogRepository{}
+	now := time.Now().UTC()
+	for i := 0; i < n; i++ {
+		repo.entries = append(repo.entries, &storage.LogRecord{
+			ID:        fmt.Sprintf("log-%d", i),
+			Timestamp: now.Add(-time.Duration(i) * time.Second),
+			Level:     "info",
+			Message:   fmt.Sprintf("message, %d", i),
+		})
+	}
+	return &pagedLogStorage{paged: repo}, repo
+}
+
+func exportRequest(params string) *http.Request {
+	startTime := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	return httptest.NewRequest("GET", "/api/v1/logs/export?start="+url.QueryEscape(startTime)+params, nil)
+}
+
+func TestExport_CSV(t *testing.T) {
+	tests := []struct {
+		name       string
+		entries    int
+		maxRows    int
+		wantRows   int
+		wantChunks []string
+	}{
+		{"single chunk", 3, 0, 3, []string{""}},
+		{"several chunks", 2500, 0, 2500, []string{"", "log-999", "log-1999"}},
+		{"past max result rows", 2500, 1500, 2500, []string{"", "log-999", "log-1999"}},
+		{"empty", 0, 0, 0, []string{""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, repo := newPagedLogStorage(tt.entries)
+			handler := NewHandlerWithStorageAndConfig(store, nil, HandlerConfig{MaxResultRows: tt.maxRows})
+			rec := httptest.NewRecorder()
+
+			handler.Export(rec, exportRequest("&format=csv&level=info"))
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200; body: %s", rec.Code, rec.Body.String())
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "text/csv" {
+				t.Errorf("Content-Type = %q, want text/csv", ct)
+			}
+			if cd := rec.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, `attachment; filename="logs-export-`) || !strings.HasSuffix(cd, `.csv"`) {
+				t.Errorf("Content-Disposition = %q", cd)
+			}
+			if rec.Header().Get("X-Result-Truncated") != "" {
+				t.Error("export has an X-Result-Truncated header")
+			}
+			if repo.lastFilter.Level != "info" {
+				t.Errorf("filter.Level = %q, want info", repo.lastFilter.Level)
+			}
+			if fmt.Sprint(repo.chunks) != fmt.Sprint(tt.wantChunks) {
+				t.Errorf("chunks = %v, want %v", repo.chunks, tt.wantChunks)
+			}
+
+			rows, err := csv.NewReader(rec.Body).ReadAll()
+			if err != nil {
+				t.Fatalf("read csv: %v", err)
+			}
+			if len(rows) != tt.wantRows+1 {
+				t.Fatalf("rows = %d, want %d plus the header", len(rows)-1, tt.wantRows)
+			}
+			if strings.Join(rows[0], ",") != strings.Join(exportCSVHeader, ",") {
+				t.Errorf("header = %v", rows[0])
+			}
+			if tt.wantRows > 0 && (rows[1][0] != "log-0" || rows[1][12] != "message, 0") {
+				t.Errorf("first row = %v", rows[1])
+			}
+		})
+	}
+}
+
+func TestExport_NDJSON(t *testing.T) {
+	store, repo := newPagedLogStorage(2)
+	repo.entries[0].Fields = map[string]interface{}{"status": float64(504)}
+	repo.entries[0].Labels = map[string]string{"env": "prod"}
+	handler := NewHandler(store)
+	rec := httptest.NewRecorder()
+
+	handler.Export(rec, exportRequest("&format=ndjson"))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != contentTypeNDJSON {
+		t.Errorf("Content-Type = %q, want %s", ct, contentTypeNDJSON)
+	}
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("decode line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("lines = %d, want 2", len(lines))
+	}
+	if lines[0]["id"] != "log-0" || lines[0]["fields.status"] != float64(504) || lines[0]["labels.env"] != "prod" {
+		t.Errorf("first line = %v", lines[0])
+	}
+}
+
+func TestExport_Errors(t *testing.T) {
+	tooLate := time.Now().Add(48 * time.Hour).Format(time.RFC3339)
+	tests := []struct {
+		name       string
+		params     string
+		queryErr   error
+		wantStatus int
+	}{
+		{"invalid format", "&format=xml", nil, http.StatusBadRequest},
+		{"range exceeds max", "&end=" + url.QueryEscape(tooLate), nil, http.StatusBadRequest},
+		{"invalid filter", "&filter=" + url.QueryEscape("level =="), nil, http.StatusBadRequest},
+		{"not by timestamp", "&order=level", nil, http.StatusBadRequest},
+		{"storage error", "", errors.New("connection refused"), http.StatusInternalServerError},
+		{"timeout", "", context.DeadlineExceeded, http.StatusGatewayTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, repo := newPagedLogStorage(1)
+			repo.queryError = tt.queryErr
+			handler := NewHandler(store)
+			rec := httptest.NewRecorder()
+
+			handler.Export(rec, exportRequest(tt.params))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+			if rec.Header().Get("Content-Disposition") != "" {
+				t.Error("error response has a Content-Disposition header")
+			}
+		})
+	}
+
+	t.Run("no log storage", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		NewHandler(nil).Export(rec, exportRequest(""))
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("status = %d, want 503", rec.Code)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package logs
+
+import (
+	"encoding/json"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// contentTypeNDJSON is the media type of newline-delimited JSON responses.
+const contentTypeNDJSON = "application/x-ndjson"
+
+// wantsFlat reports whether a query asked for flat NDJSON output, either
+// with flat=true or by accepting application/x-ndjson. An explicit flat
+// parameter wins over the Accept header.
+func wantsFlat(r *http.Request) (bool, error) {
+	if v := r.URL.Query().Get("flat"); v != "" {
+		return strconv.ParseBool(v)
+	}
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part)); err == nil && mediaType == contentTypeNDJSON {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// flattenLog returns a log entry as a single-level object: fields and
+// labels become top-level "fields.<key>" and "labels.<key>" keys, with
+// nested field objects flattened the same way ("fields.user.id"). Empty
+// optional attributes are left out, as in the envelope format.
+func flattenLog(resp *LogResponse) map[string]interface{} {
+	flat := map[string]interface{}{
+		"id":        resp.ID,
+		"timestamp": resp.Timestamp,
+		"level":     resp.Level,
+		"message":   resp.Message,
+	}
+	setString := func(key, value string) {
+		if value != "" {
+			flat[key] = value
+		}
+	}
+	setString("project_id", resp.ProjectID)
+	setString("source", resp.Source)
+	setString("type", resp.Type)
+	setString("agent_id", resp.AgentID)
+	setString("file_path", resp.FilePath)
+	setString("http_method", resp.HTTPMethod)
+	setString("uri", resp.URI)
+	if resp.LineNumber != 0 {
+		flat["line_number"] = resp.LineNumber
+	}
+	if resp.HTTPStatus != 0 {
+		flat["http_status"] = resp.HTTPStatus
+	}
+
+	flattenFields(flat, "fields", resp.Fields)
+	for k, v := range resp.Labels {
+		flat["labels."+k] = v
+	}
+	return flat
+}
+
+// flattenFields copies fields into flat under prefix, descending into
+// nested objects.
+func flattenFields(flat map[string]interface{}, prefix string, fields map[string]interface{}) {
+	for k, v := range fields {
+		key := prefix + "." + k
+		if nested, ok := v.(map[string]interface{}); ok && len(nested) > 0 {
+			flattenFields(flat, key, nested)
+			continue
+		}
+		flat[key] = v
+	}
+}
+
+// writeNDJSON writes a page of query results as one flat JSON object per
+// line. The pagination totals and cursor of the envelope format go in
+// headers.
+func writeNDJSON(w http.ResponseWriter, items []*LogResponse, total int64, totalPages int, truncated bool, nextCursor string) {
+	w.Header().Set("Content-Type", contentTypeNDJSON)
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	w.Header().Set("X-Total-Pages", strconv.Itoa(totalPages))
+	if truncated {
+		w.Header().Set("X-Result-Truncated", "true")
+	}
+	if nextCursor != "" {
+		w.Header().Set("X-Next-Cursor", nextCursor)
+	}
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+	for _, item := range items {
+		if err := enc.Encode(flattenLog(item)); err != nil {
+			slog.Error("ndjson encode", "error", err)
+			return
+		}
+	}
+}
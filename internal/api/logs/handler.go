@@ -6,7 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"math"
 	"net/http"
 	"strconv"
@@ -43,13 +43,15 @@ const (
 	defaultQueryTimeout  = 10 * time.Second
 	defaultStreamMaxDur  = 30 * time.Minute
 	defaultStreamPoll    = time.Second
+	streamRetry          = 3 * time.Second // reconnect delay suggested to stream clients
+	defaultMaxResultRows = 100000
 )
 
 func jsonError(w http.ResponseWriter, status int, code, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(apiResponse{Error: &apiError{Code: code, Message: message}}); err != nil {
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 	}
 }
 
@@ -57,7 +59,7 @@ func jsonOK(w http.ResponseWriter, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(apiResponse{Data: data}); err != nil {
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 	}
 }
 
@@ -69,6 +71,10 @@ type Handler struct {
 	queryTimeout       time.Duration
 	streamMaxDuration  time.Duration
 	streamPollInterval time.Duration
+	streamReorder      time.Duration // overlap re-queried for late-arriving entries
+	maxResultRows      int           // absolute cap on rows reachable by paging
+	schemaBody         []byte        // pre-encoded GET /logs/schema response
+	schemaETag         string
 }
 
 // HandlerConfig configures API safety limits for logs handlers.
@@ -77,6 +83,12 @@ type HandlerConfig struct {
 	QueryTimeout       time.Duration
 	StreamMaxDuration  time.Duration
 	StreamPollInterval time.Duration
+	IndexedLabels      []string // Labels advertised as first-class filters in the schema
+	MaxResultRows      int      // Max rows a query can reach across pages
+
+	// StreamReorderWindow is how far behind the newest streamed entry the
+	// stream re-queries for late arrivals (0 disables).
+	StreamReorderWindow time.Duration
 }
 
 // NewHandler creates a new logs handler.
@@ -103,6 +115,10 @@ func NewHandlerWithStorageAndConfig(logStore storage.LogStorage, store storage.S
 	if cfg.StreamPollInterval <= 0 {
 		cfg.StreamPollInterval = defaultStreamPoll
 	}
+	if cfg.MaxResultRows <= 0 {
+		cfg.MaxResultRows = defaultMaxResultRows
+	}
+	schemaBody, schemaETag := encodeSchema(buildSchema(query.DefaultFields, cfg.IndexedLabels))
 	return &Handler{
 		logStorage:         logStore,
 		store:              store,
@@ -110,6 +126,10 @@ func NewHandlerWithStorageAndConfig(logStore storage.LogStorage, store storage.S
 		queryTimeout:       cfg.QueryTimeout,
 		streamMaxDuration:  cfg.StreamMaxDuration,
 		streamPollInterval: cfg.StreamPollInterval,
+		streamReorder:      cfg.StreamReorderWindow,
+		maxResultRows:      cfg.MaxResultRows,
+		schemaBody:         schemaBody,
+		schemaETag:         schemaETag,
 	}
 }
 
@@ -123,6 +143,21 @@ func (h *Handler) validateRange(startTime, endTime time.Time) error {
 	return nil
 }
 
+// resultWindow returns the offset and limit for a page, clamped so that no
+// row past maxResultRows is ever returned. ok is false when the page starts
+// beyond the cap.
+func (h *Handler) resultWindow(page, perPage int) (offset, limit int, ok bool) {
+	offset = (page - 1) * perPage
+	if offset >= h.maxResultRows {
+		return 0, 0, false
+	}
+	limit = perPage
+	if offset+limit > h.maxResultRows {
+		limit = h.maxResultRows - offset
+	}
+	return offset, limit, true
+}
+
 func (h *Handler) newQueryContext(ctx context.Context) (context.Context, context.CancelFunc) {
 	if h.queryTimeout <= 0 {
 		return ctx, func() {}
@@ -139,7 +174,11 @@ func handleStorageError(w http.ResponseWriter, err error, contextMsg string) {
 		jsonError(w, http.StatusGatewayTimeout, errCodeTimeout, "request timed out")
 		return
 	}
-	log.Printf("%s: %v", contextMsg, err)
+	if errors.Is(err, storage.ErrFilterUnsupported) {
+		jsonError(w, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+	slog.Error(contextMsg, "error", err)
 	jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 }
 
@@ -169,14 +208,18 @@ type ListResponse struct {
 	Page       int            `json:"page"`
 	PerPage    int            `json:"per_page"`
 	TotalPages int            `json:"total_pages"`
+	Truncated  bool           `json:"truncated,omitempty"`   // Total exceeds the server's max result rows
+	NextCursor string         `json:"next_cursor,omitempty"` // after value for the next page
 }
 
 // StatsResponse contains aggregated log statistics.
 type StatsResponse struct {
-	ErrorRates *ErrorRatesResponse `json:"error_rates"`
-	TopSources []*SourceResponse   `json:"top_sources"`
-	Volume     []*VolumeResponse   `json:"volume"`
-	HTTPStats  *HTTPStatsResponse  `json:"http_stats,omitempty"`
+	ErrorRates *ErrorRatesResponse   `json:"error_rates"`
+	TopSources []*SourceResponse     `json:"top_sources"`
+	TopErrors  []*ErrorGroupResponse `json:"top_errors"`
+	Volume     []*VolumeResponse     `json:"volume"`
+	HTTPStats  *HTTPStatsResponse    `json:"http_stats,omitempty"`
+	Latency    *LatencyResponse      `json:"latency,omitempty"` // nil when no log in range has the latency field
 }
 
 // ErrorRatesResponse contains error rate statistics.
@@ -195,6 +238,15 @@ type SourceResponse struct {
 	ErrorCount int64  `json:"error_count"`
 }
 
+// ErrorGroupResponse represents error and fatal logs grouped by their
+// normalized message.
+type ErrorGroupResponse struct {
+	Pattern  string `json:"pattern"`
+	Count    int64  `json:"count"`
+	Example  string `json:"example"`
+	LastSeen string `json:"last_seen"`
+}
+
 // VolumeResponse represents a time-series data point.
 type VolumeResponse struct {
 	Timestamp  string `json:"timestamp"`
@@ -211,6 +263,29 @@ type HTTPStatsResponse struct {
 	TopURIs  []*URIResponse `json:"top_uris,omitempty"`
 }
 
+// LatencyResponse contains the quantiles of a numeric latency field, in
+// the field's unit, overall and per interval.
+type LatencyResponse struct {
+	Field string `json:"field"`
+	LatencyQuantilesResponse
+	Series []*LatencyPointResponse `json:"series"`
+}
+
+// LatencyQuantilesResponse contains latency quantiles.
+type LatencyQuantilesResponse struct {
+	Samples int64   `json:"samples"`
+	P50     float64 `json:"p50"`
+	P90     float64 `json:"p90"`
+	P95     float64 `json:"p95"`
+	P99     float64 `json:"p99"`
+}
+
+// LatencyPointResponse represents the latency quantiles of one interval.
+type LatencyPointResponse struct {
+	Timestamp string `json:"timestamp"`
+	LatencyQuantilesResponse
+}
+
 // URIResponse represents request count per URI.
 type URIResponse struct {
 	URI   string `json:"uri"`
@@ -238,16 +313,184 @@ func (h *Handler) Query(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	q := r.URL.Query()
 
+	filter, ok := h.logFilter(w, r)
+	if !ok {
+		return
+	}
+
+	// Parse pagination
+	var err error
+	page := 1
+	if pageStr := q.Get("page"); pageStr != "" {
+		page, err = strconv.Atoi(pageStr)
+		if err != nil || page < 1 {
+			jsonError(w, http.StatusBadRequest, errCodeBadRequest, "invalid page number")
+			return
+		}
+	}
+
+	perPage := 50
+	if perPageStr := q.Get("per_page"); perPageStr != "" {
+		perPage, err = strconv.Atoi(perPageStr)
+		if err != nil || perPage < 1 || perPage > 1000 {
+			jsonError(w, http.StatusBadRequest, errCodeBadRequest, "per_page must be between 1 and 1000")
+			return
+		}
+	}
+
+	// Stack traces are summarized in lists unless asked for
+	collapseTraces := true
+	if ct := q.Get("collapse_traces"); ct != "" {
+		collapseTraces, err = strconv.ParseBool(ct)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, errCodeBadRequest, "collapse_traces must be true or false")
+			return
+		}
+	}
+
+	// One flat object per line for line-oriented tooling
+	flat, err := wantsFlat(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, errCodeBadRequest, "flat must be true or false")
+		return
+	}
+
+	// Lean lists leave out the fields and labels JSON; no list selects
+	// raw, which LogResponse doesn't show
+	filter.Projection = listProjection
+	if l := q.Get("lean"); l != "" {
+		lean, err := strconv.ParseBool(l)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, errCodeBadRequest, "lean must be true or false")
+			return
+		}
+		if lean {
+			filter.Projection = storage.LogSummaryProjection
+		}
+	}
+
+	// A cursor pages past the last row seen instead of skipping rows, so
+	// it isn't held to the max result window
+	if after := q.Get("after"); after != "" {
+		if q.Get("page") != "" {
+			jsonError(w, http.StatusBadRequest, errCodeBadRequest, "use page or after, not both")
+			return
+		}
+		if filter.OrderBy != "timestamp" {
+			jsonError(w, http.StatusBadRequest, errCodeBadRequest, "after requires order=timestamp")
+			return
+		}
+		filter.AfterTime, filter.AfterID, ok = parseQueryCursor(after)
+		if !ok {
+			jsonError(w, http.StatusBadRequest, errCodeBadRequest, "invalid after cursor")
+			return
+		}
+		filter.Limit = perPage
+	} else {
+		offset, limit, ok := h.resultWindow(page, perPage)
+		if !ok {
+			jsonError(w, http.StatusBadRequest, errCodeBadRequest,
+				fmt.Sprintf("page is beyond the max result window of %d rows; narrow the time range or filter, or page with after", h.maxResultRows))
+			return
+		}
+		filter.Limit = limit
+		filter.Offset = offset
+	}
+
+	// Execute query
+	queryCtx, cancel := h.newQueryContext(ctx)
+	defer cancel()
+	result, err := h.logStorage.Logs().Query(queryCtx, filter)
+	if err != nil {
+		handleStorageError(w, err, "log query error")
+		return
+	}
+
+	// Convert to response
+	items := make([]*LogResponse, len(result.Entries))
+	for i, entry := range result.Entries {
+		items[i] = recordToResponse(entry)
+		if collapseTraces {
+			collapseTrace(items[i])
+		}
+	}
+
+	var nextCursor string
+	if result.HasMore && filter.OrderBy == "timestamp" && len(result.Entries) > 0 {
+		nextCursor = formatQueryCursor(result.Entries[len(result.Entries)-1])
+	}
+
+	// Calculate total pages, counting only rows within the result window.
+	// With a cursor Total counts the rows past it.
+	reachable := result.Total
+	truncated := filter.AfterID == "" && reachable > int64(h.maxResultRows)
+	if truncated {
+		reachable = int64(h.maxResultRows)
+	}
+	totalPages := 0
+	if reachable > 0 {
+		totalPages = int(math.Ceil(float64(reachable) / float64(perPage)))
+	}
+
+	if flat {
+		writeNDJSON(w, items, result.Total, totalPages, truncated, nextCursor)
+		return
+	}
+	jsonOK(w, &ListResponse{
+		Items:      items,
+		Total:      result.Total,
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: totalPages,
+		Truncated:  truncated,
+		NextCursor: nextCursor,
+	})
+}
+
+// listProjection are the columns Query lists by default: all but raw.
+var listProjection = []string{
+	"id", "project_id", "timestamp", "level", "message", "source", "type",
+	"agent_id", "file_path", "line_number", "fields", "labels",
+	"http_status", "http_method", "uri",
+}
+
+// formatQueryCursor returns the after cursor for the page following r:
+// its timestamp and ID.
+func formatQueryCursor(r *storage.LogRecord) string {
+	return r.Timestamp.UTC().Format(time.RFC3339Nano) + ":" + r.ID
+}
+
+// parseQueryCursor extracts the timestamp and ID of an after cursor.
+func parseQueryCursor(cursor string) (time.Time, string, bool) {
+	idx := strings.LastIndex(cursor, ":")
+	if idx == -1 || idx == len(cursor)-1 {
+		return time.Time{}, "", false
+	}
+	ts, err := time.Parse(time.RFC3339Nano, cursor[:idx])
+	if err != nil {
+		return time.Time{}, "", false
+	}
+	return ts, cursor[idx+1:], true
+}
+
+// logFilter builds the filter for a log listing endpoint from the start,
+// end, search_mode, order, order_dir, filter and flat filter query
+// parameters, applying the caller's project access. Limit and Offset are
+// left to the caller. On failure it writes the error response.
+func (h *Handler) logFilter(w http.ResponseWriter, r *http.Request) (*storage.LogFilter, bool) {
+	ctx := r.Context()
+	q := r.URL.Query()
+
 	// Parse required start time
 	startStr := q.Get("start")
 	if startStr == "" {
 		jsonError(w, http.StatusBadRequest, errCodeBadRequest, "start time is required")
-		return
+		return nil, false
 	}
 	startTime, err := time.Parse(time.RFC3339, startStr)
 	if err != nil {
 		jsonError(w, http.StatusBadRequest, errCodeBadRequest, "invalid start time format (use RFC3339)")
-		return
+		return nil, false
 	}
 
 	// Parse end time (default: now)
@@ -256,33 +499,14 @@ func (h *Handler) Query(w http.ResponseWriter, r *http.Request) {
 		endTime, err = time.Parse(time.RFC3339, endStr)
 		if err != nil {
 			jsonError(w, http.StatusBadRequest, errCodeBadRequest, "invalid end time format (use RFC3339)")
-			return
+			return nil, false
 		}
 	}
 
 	// Validate time range
 	if err := h.validateRange(startTime, endTime); err != nil {
 		jsonError(w, http.StatusBadRequest, errCodeBadRequest, err.Error())
-		return
-	}
-
-	// Parse pagination
-	page := 1
-	if pageStr := q.Get("page"); pageStr != "" {
-		page, err = strconv.Atoi(pageStr)
-		if err != nil || page < 1 {
-			jsonError(w, http.StatusBadRequest, errCodeBadRequest, "invalid page number")
-			return
-		}
-	}
-
-	perPage := 50
-	if perPageStr := q.Get("per_page"); perPageStr != "" {
-		perPage, err = strconv.Atoi(perPageStr)
-		if err != nil || perPage < 1 || perPage > 1000 {
-			jsonError(w, http.StatusBadRequest, errCodeBadRequest, "per_page must be between 1 and 1000")
-			return
-		}
+		return nil, false
 	}
 
 	// Parse search mode
@@ -297,7 +521,7 @@ func (h *Handler) Query(w http.ResponseWriter, r *http.Request) {
 			searchMode = storage.SearchModePhrase
 		default:
 			jsonError(w, http.StatusBadRequest, errCodeBadRequest, "search_mode must be token, substring, or phrase")
-			return
+			return nil, false
 		}
 	}
 
@@ -306,7 +530,7 @@ func (h *Handler) Query(w http.ResponseWriter, r *http.Request) {
 	if ob := q.Get("order"); ob != "" {
 		if ob != "timestamp" && ob != "level" {
 			jsonError(w, http.StatusBadRequest, errCodeBadRequest, "order must be timestamp or level")
-			return
+			return nil, false
 		}
 		orderBy = ob
 	}
@@ -320,7 +544,7 @@ func (h *Handler) Query(w http.ResponseWriter, r *http.Request) {
 			orderDesc = false
 		default:
 			jsonError(w, http.StatusBadRequest, errCodeBadRequest, "order_dir must be asc or desc")
-			return
+			return nil, false
 		}
 	}
 
@@ -334,29 +558,10 @@ func (h *Handler) Query(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Parse DSL filter expression (takes precedence over flat filters)
-	var filterSQL string
-	var filterArgs []any
 	filterExpr := q.Get("filter")
-	if len(filterExpr) > maxFilterLength {
-		jsonError(w, http.StatusBadRequest, errCodeBadRequest, fmt.Sprintf("filter expression too long (max %d chars)", maxFilterLength))
-		return
-	}
-	if filterExpr != "" {
-		dsl := query.NewQueryDSL(query.DefaultFields)
-		parsed, err := dsl.Parse(filterExpr)
-		if err != nil {
-			jsonError(w, http.StatusBadRequest, errCodeBadRequest, fmt.Sprintf("invalid filter expression: %v", err))
-			return
-		}
-
-		builder := query.NewSQLBuilder(query.DefaultFields)
-		result, err := builder.Build(parsed)
-		if err != nil {
-			jsonError(w, http.StatusBadRequest, errCodeBadRequest, fmt.Sprintf("filter conversion error: %v", err))
-			return
-		}
-		filterSQL = result.SQL
-		filterArgs = result.Args
+	filterSQL, filterArgs, ok := parseFilterExpr(w, filterExpr)
+	if !ok {
+		return nil, false
 	}
 
 	// Build filter - DSL takes precedence over flat filters
@@ -388,8 +593,6 @@ func (h *Handler) Query(w http.ResponseWriter, r *http.Request) {
 		FilePath:        filePath,
 		MessageContains: messageContains,
 		SearchMode:      searchMode,
-		Limit:           perPage,
-		Offset:          (page - 1) * perPage,
 		OrderBy:         orderBy,
 		OrderDesc:       orderDesc,
 		FilterExpr:      filterExpr,
@@ -404,61 +607,67 @@ func (h *Handler) Query(w http.ResponseWriter, r *http.Request) {
 		role := middleware.GetRole(ctx)
 		access, err := middleware.GetProjectAccess(ctx, userID, role, h.store)
 		if err != nil {
-			log.Printf("project access error: %v", err)
+			slog.Error("project access", "error", err)
 			jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
-			return
+			return nil, false
 		}
 		if err := access.ApplyToLogFilter(filter, projectID); err != nil {
 			if errors.Is(err, middleware.ErrProjectAccessDenied) {
 				jsonError(w, http.StatusForbidden, errCodeForbidden, "no access to project")
-				return
+				return nil, false
 			}
-			log.Printf("project filter error: %v", err)
+			slog.Error("project filter", "error", err)
 			jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
-			return
+			return nil, false
 		}
 	} else if projectID != "" {
 		// Legacy mode: just apply the filter without access check
 		filter.ProjectID = projectID
 	}
 
-	// Execute query
-	queryCtx, cancel := h.newQueryContext(ctx)
-	defer cancel()
-	result, err := h.logStorage.Logs().Query(queryCtx, filter)
+	return filter, true
+}
+
+// parseFilterExpr converts a DSL filter expression to its SQL WHERE clause
+// and parameters; an empty expression gives none. On failure it writes the
+// error response.
+func parseFilterExpr(w http.ResponseWriter, expr string) (string, []any, bool) {
+	filterSQL, filterArgs, err := buildFilterExpr(expr)
 	if err != nil {
-		handleStorageError(w, err, "log query error")
-		return
+		jsonError(w, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return "", nil, false
 	}
+	return filterSQL, filterArgs, true
+}
 
-	// Convert to response
-	items := make([]*LogResponse, len(result.Entries))
-	for i, entry := range result.Entries {
-		items[i] = recordToResponse(entry)
+// buildFilterExpr is parseFilterExpr for callers without a response to
+// write, returning the error.
+func buildFilterExpr(expr string) (string, []any, error) {
+	if len(expr) > maxFilterLength {
+		return "", nil, fmt.Errorf("filter expression too long (max %d chars)", maxFilterLength)
 	}
-
-	// Calculate total pages
-	totalPages := 0
-	if result.Total > 0 {
-		totalPages = int(math.Ceil(float64(result.Total) / float64(perPage)))
+	if expr == "" {
+		return "", nil, nil
 	}
 
-	jsonOK(w, &ListResponse{
-		Items:      items,
-		Total:      result.Total,
-		Page:       page,
-		PerPage:    perPage,
-		TotalPages: totalPages,
-	})
-}
+	dsl := query.NewQueryDSL(query.DefaultFields)
+	parsed, err := dsl.Parse(expr)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid filter expression: %w", err)
+	}
 
-// Stats handles GET /api/v1/logs/stats - aggregated statistics.
-func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
-	if h.logStorage == nil {
-		jsonError(w, http.StatusServiceUnavailable, errCodeInternalError, "log storage not configured")
-		return
+	builder := query.NewSQLBuilder(query.DefaultFields)
+	result, err := builder.Build(parsed)
+	if err != nil {
+		return "", nil, fmt.Errorf("filter conversion error: %w", err)
 	}
+	return result.SQL, result.Args, nil
+}
 
+// aggregationFilter builds the filter for an aggregation endpoint from the
+// start, end, agent_id, type and project_id query parameters, applying the
+// caller's project access. On failure it writes the error response.
+func (h *Handler) aggregationFilter(w http.ResponseWriter, r *http.Request) (*storage.AggregationFilter, bool) {
 	ctx := r.Context()
 	q := r.URL.Query()
 
@@ -466,12 +675,12 @@ func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
 	startStr := q.Get("start")
 	if startStr == "" {
 		jsonError(w, http.StatusBadRequest, errCodeBadRequest, "start time is required")
-		return
+		return nil, false
 	}
 	startTime, err := time.Parse(time.RFC3339, startStr)
 	if err != nil {
 		jsonError(w, http.StatusBadRequest, errCodeBadRequest, "invalid start time format (use RFC3339)")
-		return
+		return nil, false
 	}
 
 	// Parse end time (default: now)
@@ -480,22 +689,12 @@ func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
 		endTime, err = time.Parse(time.RFC3339, endStr)
 		if err != nil {
 			jsonError(w, http.StatusBadRequest, errCodeBadRequest, "invalid end time format (use RFC3339)")
-			return
+			return nil, false
 		}
 	}
 	if err := h.validateRange(startTime, endTime); err != nil {
 		jsonError(w, http.StatusBadRequest, errCodeBadRequest, err.Error())
-		return
-	}
-
-	// Parse interval
-	interval := "hour"
-	if iv := q.Get("interval"); iv != "" {
-		if iv != "minute" && iv != "hour" && iv != "day" {
-			jsonError(w, http.StatusBadRequest, errCodeBadRequest, "interval must be minute, hour, or day")
-			return
-		}
-		interval = iv
+		return nil, false
 	}
 
 	// Build aggregation filter
@@ -513,29 +712,66 @@ func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
 		role := middleware.GetRole(ctx)
 		access, err := middleware.GetProjectAccess(ctx, userID, role, h.store)
 		if err != nil {
-			log.Printf("project access error: %v", err)
+			slog.Error("project access", "error", err)
 			jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
-			return
+			return nil, false
 		}
 		if err := access.ApplyToAggregationFilter(aggFilter, projectID); err != nil {
 			if errors.Is(err, middleware.ErrProjectAccessDenied) {
 				jsonError(w, http.StatusForbidden, errCodeForbidden, "no access to project")
-				return
+				return nil, false
 			}
-			log.Printf("project filter error: %v", err)
+			slog.Error("project filter", "error", err)
 			jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
-			return
+			return nil, false
 		}
 	} else if projectID != "" {
 		aggFilter.ProjectID = projectID
 	}
 
-	// Execute all 4 queries in parallel for ~4x latency improvement
+	return aggFilter, true
+}
+
+// Stats handles GET /api/v1/logs/stats - aggregated statistics.
+func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
+	if h.logStorage == nil {
+		jsonError(w, http.StatusServiceUnavailable, errCodeInternalError, "log storage not configured")
+		return
+	}
+
+	ctx := r.Context()
+	aggFilter, ok := h.aggregationFilter(w, r)
+	if !ok {
+		return
+	}
+
+	// Parse interval
+	interval := "hour"
+	if iv := r.URL.Query().Get("interval"); iv != "" {
+		if iv != "minute" && iv != "hour" && iv != "day" {
+			jsonError(w, http.StatusBadRequest, errCodeBadRequest, "interval must be minute, hour, or day")
+			return
+		}
+		interval = iv
+	}
+
+	latencyField := defaultLatencyField
+	if f := r.URL.Query().Get("latency_field"); f != "" {
+		if !latencyFieldPattern.MatchString(f) {
+			jsonError(w, http.StatusBadRequest, errCodeBadRequest, "latency_field must be a field name (letters, digits, _ . -)")
+			return
+		}
+		latencyField = f
+	}
+
+	// Execute all 6 queries in parallel for ~6x latency improvement
 	var (
 		errorRates *storage.ErrorRateResult
 		topSources []*storage.SourceCount
+		topErrors  []*storage.ErrorGroup
 		volume     []*storage.VolumePoint
 		httpStats  *storage.HTTPStatsResult
+		latency    *storage.LatencyStats
 	)
 
 	queryCtx, cancel := h.newQueryContext(ctx)
@@ -546,7 +782,7 @@ func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
 		var err error
 		errorRates, err = h.logStorage.Logs().GetErrorRates(gCtx, aggFilter)
 		if err != nil {
-			log.Printf("error rates query error: %v", err)
+			slog.Error("error rates query", "error", err)
 		}
 		return err
 	})
@@ -555,7 +791,16 @@ func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
 		var err error
 		topSources, err = h.logStorage.Logs().GetTopSources(gCtx, aggFilter, 10)
 		if err != nil {
-			log.Printf("top sources query error: %v", err)
+			slog.Error("top sources query", "error", err)
+		}
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		topErrors, err = h.logStorage.Logs().GetTopErrors(gCtx, aggFilter, 10)
+		if err != nil {
+			slog.Error("top errors query", "error", err)
 		}
 		return err
 	})
@@ -564,7 +809,7 @@ func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
 		var err error
 		volume, err = h.logStorage.Logs().GetLogVolume(gCtx, aggFilter, interval)
 		if err != nil {
-			log.Printf("log volume query error: %v", err)
+			slog.Error("log volume query", "error", err)
 		}
 		return err
 	})
@@ -573,7 +818,16 @@ func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
 		var err error
 		httpStats, err = h.logStorage.Logs().GetHTTPStats(gCtx, aggFilter)
 		if err != nil {
-			log.Printf("http stats query error: %v", err)
+			slog.Error("http stats query", "error", err)
+		}
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		latency, err = h.logStorage.Logs().GetLatencyStats(gCtx, aggFilter, latencyField, interval)
+		if err != nil {
+			slog.Error("latency stats query", "error", err)
 		}
 		return err
 	})
@@ -593,6 +847,7 @@ func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
 			ErrorRate:    errorRates.ErrorRate,
 		},
 		TopSources: make([]*SourceResponse, len(topSources)),
+		TopErrors:  make([]*ErrorGroupResponse, len(topErrors)),
 		Volume:     make([]*VolumeResponse, len(volume)),
 	}
 
@@ -604,6 +859,15 @@ func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	for i, eg := range topErrors {
+		resp.TopErrors[i] = &ErrorGroupResponse{
+			Pattern:  eg.Pattern,
+			Count:    eg.Count,
+			Example:  eg.Example,
+			LastSeen: eg.LastSeen.Format(time.RFC3339),
+		}
+	}
+
 	for i, vol := range volume {
 		resp.Volume[i] = &VolumeResponse{
 			Timestamp:  vol.Timestamp.Format(time.RFC3339),
@@ -631,9 +895,34 @@ func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	resp.Latency = buildLatency(latency, latencyField)
+
 	jsonOK(w, resp)
 }
 
+// buildLatency converts latency stats to their response, nil without
+// samples.
+func buildLatency(latency *storage.LatencyStats, field string) *LatencyResponse {
+	if latency == nil || latency.Samples == 0 {
+		return nil
+	}
+	quantiles := func(q storage.LatencyQuantiles) LatencyQuantilesResponse {
+		return LatencyQuantilesResponse{Samples: q.Samples, P50: q.P50, P90: q.P90, P95: q.P95, P99: q.P99}
+	}
+	resp := &LatencyResponse{
+		Field:                    field,
+		LatencyQuantilesResponse: quantiles(latency.LatencyQuantiles),
+		Series:                   make([]*LatencyPointResponse, len(latency.Series)),
+	}
+	for i, point := range latency.Series {
+		resp.Series[i] = &LatencyPointResponse{
+			Timestamp:                point.Timestamp.Format(time.RFC3339),
+			LatencyQuantilesResponse: quantiles(point.LatencyQuantiles),
+		}
+	}
+	return resp
+}
+
 // Stream handles GET /api/v1/logs/stream - SSE streaming of logs.
 func (h *Handler) Stream(w http.ResponseWriter, r *http.Request) {
 	if h.logStorage == nil {
@@ -648,6 +937,33 @@ func (h *Handler) Stream(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	startTime, baseFilter, ok := h.streamFilter(w, r)
+	if !ok {
+		return
+	}
+
+	// Set SSE headers
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// Create SSE writer
+	sse := NewSSEWriter(w, flusher)
+	if err := sse.SendRetry(int(streamRetry / time.Millisecond)); err != nil {
+		return
+	}
+
+	h.runStream(r.Context(), startTime, baseFilter, sseSink{sse}, nil)
+}
+
+// streamFilter builds the base filter of a log stream and its start time
+// from the start, search_mode, filter and flat filter query parameters,
+// applying the caller's project access. On failure it writes the error
+// response.
+func (h *Handler) streamFilter(w http.ResponseWriter, r *http.Request) (time.Time, *storage.LogFilter, bool) {
 	ctx := r.Context()
 	q := r.URL.Query()
 
@@ -658,12 +974,12 @@ func (h *Handler) Stream(w http.ResponseWriter, r *http.Request) {
 		startTime, err = time.Parse(time.RFC3339, startStr)
 		if err != nil {
 			jsonError(w, http.StatusBadRequest, errCodeBadRequest, "invalid start time format (use RFC3339)")
-			return
+			return time.Time{}, nil, false
 		}
 	}
 	if h.maxQueryRange > 0 && time.Since(startTime) > h.maxQueryRange {
 		jsonError(w, http.StatusBadRequest, errCodeBadRequest, fmt.Sprintf("start time too old (max lookback %s)", h.maxQueryRange))
-		return
+		return time.Time{}, nil, false
 	}
 
 	// Parse search mode
@@ -678,7 +994,7 @@ func (h *Handler) Stream(w http.ResponseWriter, r *http.Request) {
 			searchMode = storage.SearchModePhrase
 		default:
 			jsonError(w, http.StatusBadRequest, errCodeBadRequest, "search_mode must be token, substring, or phrase")
-			return
+			return time.Time{}, nil, false
 		}
 	}
 
@@ -691,6 +1007,13 @@ func (h *Handler) Stream(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Parse DSL filter expression (takes precedence over flat filters)
+	filterExpr := q.Get("filter")
+	filterSQL, filterArgs, ok := parseFilterExpr(w, filterExpr)
+	if !ok {
+		return time.Time{}, nil, false
+	}
+
 	// Build base filter
 	baseFilter := &storage.LogFilter{
 		AgentID:         q.Get("agent_id"),
@@ -703,6 +1026,9 @@ func (h *Handler) Stream(w http.ResponseWriter, r *http.Request) {
 		Limit:           100,
 		OrderBy:         "timestamp",
 		OrderDesc:       false, // ASC for streaming
+		FilterExpr:      filterExpr,
+		FilterSQL:       filterSQL,
+		FilterArgs:      filterArgs,
 	}
 
 	// Apply project access filtering
@@ -712,104 +1038,78 @@ func (h *Handler) Stream(w http.ResponseWriter, r *http.Request) {
 		role := middleware.GetRole(ctx)
 		access, err := middleware.GetProjectAccess(ctx, userID, role, h.store)
 		if err != nil {
-			log.Printf("project access error: %v", err)
+			slog.Error("project access", "error", err)
 			jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
-			return
+			return time.Time{}, nil, false
 		}
 		if err := access.ApplyToLogFilter(baseFilter, projectID); err != nil {
 			if errors.Is(err, middleware.ErrProjectAccessDenied) {
 				jsonError(w, http.StatusForbidden, errCodeForbidden, "no access to project")
-				return
+				return time.Time{}, nil, false
 			}
-			log.Printf("project filter error: %v", err)
+			slog.Error("project filter", "error", err)
 			jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
-			return
+			return time.Time{}, nil, false
 		}
 	} else if projectID != "" {
 		baseFilter.ProjectID = projectID
 	}
 
-	// Set SSE headers
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("X-Accel-Buffering", "no")
-	w.WriteHeader(http.StatusOK)
-	flusher.Flush()
-
-	// Create SSE writer
-	sse := NewSSEWriter(w, flusher)
-
-	// Track last seen timestamp
-	lastTimestamp := startTime
-
-	// Heartbeat interval
-	heartbeatInterval := 15 * time.Second
-	lastHeartbeat := time.Now()
-
-	// Stream timeout
-	deadline := time.Now().Add(h.streamMaxDuration)
-
-	// Main loop
-	ticker := time.NewTicker(h.streamPollInterval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			// Client disconnected
-			return
-
-		case <-ticker.C:
-			// Check timeout
-			if time.Now().After(deadline) {
-				sse.SendEvent("close", `{"reason":"timeout"}`)
-				return
-			}
+	return startTime, baseFilter, true
+}
 
-			// Build filter with time range
-			filter := *baseFilter
-			filter.StartTime = lastTimestamp
-			filter.EndTime = time.Now()
-
-			// Query for new logs
-			queryCtx, cancel := h.newQueryContext(ctx)
-			result, err := h.logStorage.Logs().Query(queryCtx, &filter)
-			cancel()
-			if err != nil {
-				if isTimeoutError(err) {
-					sse.SendEvent("error", `{"code":"TIMEOUT","message":"stream query timed out"}`)
-				}
-				log.Printf("stream query error: %v", err)
-				continue
-			}
+// Get handles GET /api/v1/logs/{id} - a single log, for permalinks.
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	if h.logStorage == nil {
+		jsonError(w, http.StatusServiceUnavailable, errCodeInternalError, "log storage not configured")
+		return
+	}
 
-			// Send new logs
-			for _, entry := range result.Entries {
-				// Skip entries at or before the last sent timestamp
-				if !entry.Timestamp.After(lastTimestamp) {
-					continue
-				}
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		jsonError(w, http.StatusBadRequest, errCodeBadRequest, "log id is required")
+		return
+	}
 
-				resp := recordToResponse(entry)
-				data, _ := json.Marshal(resp)
-				if err := sse.SendEvent("log", string(data)); err != nil {
-					return // Client disconnected
-				}
+	queryCtx, cancel := h.newQueryContext(r.Context())
+	defer cancel()
+	record, ok := h.accessibleLog(queryCtx, w, id)
+	if !ok {
+		return
+	}
+	jsonOK(w, recordToResponse(record))
+}
 
-				// Update last timestamp
-				if entry.Timestamp.After(lastTimestamp) {
-					lastTimestamp = entry.Timestamp
-				}
-			}
+// accessibleLog returns the log with id if the caller may see its
+// project. A log in another project is reported as not found, so as not
+// to leak its existence. On failure it writes the error response.
+func (h *Handler) accessibleLog(ctx context.Context, w http.ResponseWriter, id string) (*storage.LogRecord, bool) {
+	record, err := h.logStorage.Logs().GetByID(ctx, id)
+	if err != nil {
+		handleStorageError(w, err, "get log by id error")
+		return nil, false
+	}
+	if record == nil {
+		jsonError(w, http.StatusNotFound, "NOT_FOUND", "log not found")
+		return nil, false
+	}
 
-			// Send heartbeat if needed
-			if time.Since(lastHeartbeat) >= heartbeatInterval {
-				sse.SendEvent("heartbeat", `{"timestamp":"`+time.Now().Format(time.RFC3339)+`"}`)
-				lastHeartbeat = time.Now()
-			}
+	// Check project access
+	if h.store != nil {
+		userID := middleware.GetUserID(ctx)
+		role := middleware.GetRole(ctx)
+		access, err := middleware.GetProjectAccess(ctx, userID, role, h.store)
+		if err != nil {
+			slog.Error("project access", "error", err)
+			jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
+			return nil, false
+		}
+		if !access.CanAccessProject(record.ProjectID) {
+			jsonError(w, http.StatusNotFound, "NOT_FOUND", "log not found")
+			return nil, false
 		}
 	}
+	return record, true
 }
 
 // Context handles GET /api/v1/logs/{id}/context - surrounding logs.
@@ -841,47 +1141,41 @@ func (h *Handler) Context(w http.ResponseWriter, r *http.Request) {
 	beforeCursor := q.Get("before_cursor")
 	afterCursor := q.Get("after_cursor")
 
+	// Surrounding lines of the same file, or everything the agent sent
+	sameFile := true
+	switch q.Get("scope") {
+	case "", "file":
+	case "agent":
+		sameFile = false
+	default:
+		jsonError(w, http.StatusBadRequest, errCodeBadRequest, "scope must be file or agent")
+		return
+	}
+
 	// Get anchor log first
 	queryCtx, cancel := h.newQueryContext(ctx)
 	defer cancel()
-	anchor, err := h.logStorage.Logs().GetByID(queryCtx, id)
-	if err != nil {
-		handleStorageError(w, err, "get log by id error")
-		return
-	}
-	if anchor == nil {
-		jsonError(w, http.StatusNotFound, "NOT_FOUND", "log not found")
+	anchor, ok := h.accessibleLog(queryCtx, w, id)
+	if !ok {
 		return
 	}
 
-	// Check project access
-	if h.store != nil {
-		userID := middleware.GetUserID(ctx)
-		role := middleware.GetRole(ctx)
-		access, err := middleware.GetProjectAccess(ctx, userID, role, h.store)
-		if err != nil {
-			log.Printf("project access error: %v", err)
-			jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
-			return
-		}
-		if !access.CanAccessProject(anchor.ProjectID) {
-			// Don't leak existence - return 404
-			jsonError(w, http.StatusNotFound, "NOT_FOUND", "log not found")
-			return
-		}
-	}
-
 	// Fetch context
-	result, err := h.logStorage.Logs().GetContext(queryCtx, &storage.ContextFilter{
+	contextFilter := &storage.ContextFilter{
 		TargetID:     id,
 		ProjectID:    anchor.ProjectID,
 		AgentID:      anchor.AgentID,
 		Timestamp:    anchor.Timestamp,
+		LineNumber:   anchor.LineNumber,
 		Before:       before,
 		After:        after,
 		BeforeCursor: beforeCursor,
 		AfterCursor:  afterCursor,
-	})
+	}
+	if sameFile {
+		contextFilter.FilePath = anchor.FilePath
+	}
+	result, err := h.logStorage.Logs().GetContext(queryCtx, contextFilter)
 	if err != nil {
 		handleStorageError(w, err, "get context error")
 		return
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -12,6 +13,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/good-yellow-bee/blazelog/internal/storage"
 )
 
@@ -23,6 +25,13 @@ type mockLogRepository struct {
 	topSources    []*storage.SourceCount
 	volume        []*storage.VolumePoint
 	httpStats     *storage.HTTPStatsResult
+	percentile    *storage.PercentileResult
+	latency       *storage.LatencyStats
+	topErrors     []*storage.ErrorGroup
+	byID          *storage.LogRecord
+	contextResult *storage.ContextResult
+	lastContext   *storage.ContextFilter
+	lastField     string
 	queryError    error
 	countError    error
 	statsError    error
@@ -101,12 +110,57 @@ func (m *mockLogRepository) GetHTTPStats(ctx context.Context, filter *storage.Ag
 	return m.httpStats, nil
 }
 
+func (m *mockLogRepository) GetFieldPercentile(ctx context.Context, filter *storage.AggregationFilter, field string, percentile float64) (*storage.PercentileResult, error) {
+	m.mu.Lock()
+	m.lastAggFilter = filter
+	m.lastField = field
+	m.mu.Unlock()
+	if m.statsError != nil {
+		return nil, m.statsError
+	}
+	if m.percentile == nil {
+		return &storage.PercentileResult{}, nil
+	}
+	return m.percentile, nil
+}
+
+func (m *mockLogRepository) GetLatencyStats(ctx context.Context, filter *storage.AggregationFilter, field, interval string) (*storage.LatencyStats, error) {
+	m.mu.Lock()
+	m.lastAggFilter = filter
+	m.lastField = field
+	m.mu.Unlock()
+	if m.statsError != nil {
+		return nil, m.statsError
+	}
+	if m.latency == nil {
+		return &storage.LatencyStats{}, nil
+	}
+	return m.latency, nil
+}
+
+func (m *mockLogRepository) GetTopErrors(ctx context.Context, filter *storage.AggregationFilter, limit int) ([]*storage.ErrorGroup, error) {
+	m.mu.Lock()
+	m.lastAggFilter = filter
+	m.mu.Unlock()
+	if m.statsError != nil {
+		return nil, m.statsError
+	}
+	return m.topErrors, nil
+}
+
 func (m *mockLogRepository) GetByID(ctx context.Context, id string) (*storage.LogRecord, error) {
+	if m.byID != nil && m.byID.ID == id {
+		return m.byID, nil
+	}
 	return nil, nil
 }
 
 func (m *mockLogRepository) GetContext(ctx context.Context, filter *storage.ContextFilter) (*storage.ContextResult, error) {
-	return &storage.ContextResult{}, nil
+	m.lastContext = filter
+	if m.contextResult == nil {
+		return &storage.ContextResult{}, nil
+	}
+	return m.contextResult, nil
 }
 
 // mockLogStorage implements storage.LogStorage for testing.
@@ -324,6 +378,319 @@ func TestQuery_InvalidPagination(t *testing.T) {
 	}
 }
 
+func TestQuery_MaxResultRows(t *testing.T) {
+	startTime := time.Now().Add(-time.Hour).Format(time.RFC3339)
+
+	tests := []struct {
+		name          string
+		query         string
+		total         int64
+		wantStatus    int
+		wantLimit     int
+		wantTruncated bool
+	}{
+		{"within window", "page=1&per_page=50", 80, http.StatusOK, 50, false},
+		{"last page clamped", "page=2&per_page=60", 500, http.StatusOK, 40, true},
+		{"beyond window", "page=3&per_page=50", 500, http.StatusBadRequest, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage, mockRepo := newMockLogStorage()
+			mockRepo.total = tt.total
+			handler := NewHandlerWithStorageAndConfig(mockStorage, nil, HandlerConfig{MaxResultRows: 100})
+
+			req := httptest.NewRequest("GET", "/api/v1/logs?start="+url.QueryEscape(startTime)+"&"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			handler.Query(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if mockRepo.lastFilter.Limit != tt.wantLimit {
+				t.Errorf("filter.Limit = %d, want %d", mockRepo.lastFilter.Limit, tt.wantLimit)
+			}
+
+			var resp struct {
+				Data *ListResponse `json:"data"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Data.Truncated != tt.wantTruncated {
+				t.Errorf("truncated = %v, want %v", resp.Data.Truncated, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestQuery_Cursor(t *testing.T) {
+	startTime := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	last := time.Date(2024, 1, 15, 10, 0, 0, 123000000, time.UTC)
+	cursor := formatQueryCursor(&storage.LogRecord{Timestamp: last, ID: "log-2"})
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantAfter  string
+	}{
+		{"first page", "per_page=2", http.StatusOK, ""},
+		{"after cursor", "per_page=2&after=" + url.QueryEscape(cursor), http.StatusOK, "log-2"},
+		{"oldest first", "per_page=2&order_dir=asc&after=" + url.QueryEscape(cursor), http.StatusOK, "log-2"},
+		{"with page", "page=2&after=" + url.QueryEscape(cursor), http.StatusBadRequest, ""},
+		{"ordered by level", "order=level&after=" + url.QueryEscape(cursor), http.StatusBadRequest, ""},
+		{"invalid cursor", "after=yesterday", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage, mockRepo := newMockLogStorage()
+			mockRepo.entries = []*storage.LogRecord{
+				{ID: "log-1", Timestamp: last.Add(time.Second)},
+				{ID: "log-2", Timestamp: last},
+			}
+			mockRepo.total = 500
+			handler := NewHandlerWithStorageAndConfig(mockStorage, nil, HandlerConfig{MaxResultRows: 100})
+
+			req := httptest.NewRequest("GET", "/api/v1/logs?start="+url.QueryEscape(startTime)+"&"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			handler.Query(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			filter := mockRepo.lastFilter
+			if filter.AfterID != tt.wantAfter || filter.Offset != 0 || filter.Limit != 2 {
+				t.Errorf("filter = after %q, offset %d, limit %d", filter.AfterID, filter.Offset, filter.Limit)
+			}
+			if tt.wantAfter != "" && !filter.AfterTime.Equal(last) {
+				t.Errorf("filter.AfterTime = %v, want %v", filter.AfterTime, last)
+			}
+
+			var resp struct {
+				Data *ListResponse `json:"data"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Data.NextCursor != cursor {
+				t.Errorf("next_cursor = %q, want %q", resp.Data.NextCursor, cursor)
+			}
+			if tt.wantAfter != "" && resp.Data.Truncated {
+				t.Error("cursor page reported as truncated")
+			}
+		})
+	}
+}
+
+func TestQuery_Lean(t *testing.T) {
+	tests := []struct {
+		name      string
+		param     string
+		wantCode  int
+		wantLabel bool
+	}{
+		{"default", "", http.StatusOK, true},
+		{"lean", "&lean=true", http.StatusOK, false},
+		{"explicit false", "&lean=false", http.StatusOK, true},
+		{"invalid", "&lean=maybe", http.StatusBadRequest, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage, mockRepo := newMockLogStorage()
+			startTime := time.Now().Add(-time.Hour).Format(time.RFC3339)
+			req := httptest.NewRequest("GET", "/api/v1/logs?start="+url.QueryEscape(startTime)+tt.param, nil)
+			rec := httptest.NewRecorder()
+			NewHandler(mockStorage).Query(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d; body: %s", rec.Code, tt.wantCode, rec.Body.String())
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			projection := strings.Join(mockRepo.lastFilter.Projection, ",")
+			if strings.Contains(projection, "raw") {
+				t.Errorf("projection %q selects raw", projection)
+			}
+			if strings.Contains(projection, "labels") != tt.wantLabel {
+				t.Errorf("projection %q: labels selected = %v, want %v", projection, !tt.wantLabel, tt.wantLabel)
+			}
+		})
+	}
+}
+
+func TestQuery_CollapseTraces(t *testing.T) {
+	trace := "Stack trace:\n#0 /app/src/Foo.php(12): bar()\n#1 {main}"
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		param       string
+		wantCode    int
+		wantTrace   bool
+		wantMessage string
+	}{
+		{"default collapses", "", http.StatusOK, false, "Uncaught exception"},
+		{"explicit true", "&collapse_traces=true", http.StatusOK, false, "Uncaught exception"},
+		{"disabled", "&collapse_traces=false", http.StatusOK, true, "Uncaught exception\n" + trace},
+		{"invalid", "&collapse_traces=maybe", http.StatusBadRequest, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage, mockRepo := newMockLogStorage()
+			record := &storage.LogRecord{
+				ID:        "log-1",
+				Timestamp: now.Add(-time.Minute),
+				Level:     "error",
+				Message:   "Uncaught exception\n" + trace,
+				Fields:    map[string]interface{}{"stack_trace": trace, "stack_frame_count": float64(2)},
+			}
+			mockRepo.entries = []*storage.LogRecord{record, {ID: "log-2", Timestamp: now, Message: "plain"}}
+			mockRepo.total = 2
+
+			startTime := now.Add(-time.Hour).Format(time.RFC3339)
+			req := httptest.NewRequest("GET", "/api/v1/logs?start="+url.QueryEscape(startTime)+tt.param, nil)
+			rec := httptest.NewRecorder()
+			NewHandler(mockStorage).Query(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d; body: %s", rec.Code, tt.wantCode, rec.Body.String())
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				Data *ListResponse `json:"data"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			item := resp.Data.Items[0]
+			if _, ok := item.Fields["stack_trace"]; ok != tt.wantTrace {
+				t.Errorf("stack_trace present = %v, want %v", ok, tt.wantTrace)
+			}
+			if item.Message != tt.wantMessage {
+				t.Errorf("message = %q, want %q", item.Message, tt.wantMessage)
+			}
+			if item.Fields["stack_frame_count"] != float64(2) {
+				t.Errorf("stack_frame_count = %v, want 2", item.Fields["stack_frame_count"])
+			}
+			if !tt.wantTrace {
+				if item.Fields["stack_top_frame"] != "#0 /app/src/Foo.php(12): bar()" || item.Fields["stack_trace_collapsed"] != true {
+					t.Errorf("summary fields = %v", item.Fields)
+				}
+			}
+			if _, ok := record.Fields["stack_trace"]; !ok {
+				t.Error("collapsing must not modify the storage record")
+			}
+			if resp.Data.Items[1].Fields != nil {
+				t.Errorf("entry without a trace got fields %v", resp.Data.Items[1].Fields)
+			}
+		})
+	}
+}
+
+func TestQuery_Flat(t *testing.T) {
+	now := time.Now().UTC()
+	tests := []struct {
+		name     string
+		param    string
+		accept   string
+		wantCode int
+		wantFlat bool
+	}{
+		{"default envelope", "", "", http.StatusOK, false},
+		{"flat param", "&flat=true", "", http.StatusOK, true},
+		{"accept ndjson", "", "application/json;q=0.5, application/x-ndjson", http.StatusOK, true},
+		{"flat=false wins over accept", "&flat=false", "application/x-ndjson", http.StatusOK, false},
+		{"invalid", "&flat=maybe", "", http.StatusBadRequest, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage, mockRepo := newMockLogStorage()
+			mockRepo.entries = []*storage.LogRecord{
+				{
+					ID:        "log-1",
+					Timestamp: now,
+					Level:     "error",
+					Message:   "upstream timed out",
+					Source:    "nginx",
+					Fields:    map[string]interface{}{"status": float64(504), "user": map[string]interface{}{"id": "u1"}},
+					Labels:    map[string]string{"env": "prod"},
+				},
+				{ID: "log-2", Timestamp: now, Level: "info", Message: "plain"},
+			}
+			mockRepo.total = 2
+
+			startTime := now.Add(-time.Hour).Format(time.RFC3339)
+			req := httptest.NewRequest("GET", "/api/v1/logs?start="+url.QueryEscape(startTime)+tt.param, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			NewHandler(mockStorage).Query(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d; body: %s", rec.Code, tt.wantCode, rec.Body.String())
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			if !tt.wantFlat {
+				if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+					t.Errorf("Content-Type = %q, want application/json", ct)
+				}
+				return
+			}
+
+			if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+				t.Errorf("Content-Type = %q, want application/x-ndjson", ct)
+			}
+			if got := rec.Header().Get("X-Total-Count"); got != "2" {
+				t.Errorf("X-Total-Count = %q, want 2", got)
+			}
+			lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+			if len(lines) != 2 {
+				t.Fatalf("got %d lines, want 2: %s", len(lines), rec.Body.String())
+			}
+			var first map[string]interface{}
+			if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+				t.Fatalf("decode line: %v", err)
+			}
+			want := map[string]interface{}{
+				"id":             "log-1",
+				"level":          "error",
+				"source":         "nginx",
+				"fields.status":  float64(504),
+				"fields.user.id": "u1",
+				"labels.env":     "prod",
+			}
+			for k, v := range want {
+				if first[k] != v {
+					t.Errorf("%s = %v, want %v", k, first[k], v)
+				}
+			}
+			if _, ok := first["fields"]; ok {
+				t.Error("flat line should not have a nested fields object")
+			}
+		})
+	}
+}
+
 func TestQuery_InvalidSearchMode(t *testing.T) {
 	mockStorage, _ := newMockLogStorage()
 	handler := NewHandler(mockStorage)
@@ -368,6 +735,9 @@ func TestStats_Success(t *testing.T) {
 		{Source: "nginx-access", Count: 500, ErrorCount: 20},
 		{Source: "app-logs", Count: 300, ErrorCount: 30},
 	}
+	mockRepo.topErrors = []*storage.ErrorGroup{
+		{Pattern: "Order <n> not found in <file>", Count: 42, Example: "Order 17 not found in /app/Order.php:42", LastSeen: now},
+	}
 	mockRepo.volume = []*storage.VolumePoint{
 		{Timestamp: now.Add(-2 * time.Hour), TotalCount: 400, ErrorCount: 15},
 		{Timestamp: now.Add(-time.Hour), TotalCount: 600, ErrorCount: 35},
@@ -414,6 +784,9 @@ func TestStats_Success(t *testing.T) {
 	if len(resp.Data.Volume) != 2 {
 		t.Errorf("volume count = %d, want 2", len(resp.Data.Volume))
 	}
+	if len(resp.Data.TopErrors) != 1 || resp.Data.TopErrors[0].Count != 42 || resp.Data.TopErrors[0].Example == "" {
+		t.Errorf("top_errors = %+v, want the one group", resp.Data.TopErrors)
+	}
 	if resp.Data.HTTPStats == nil {
 		t.Fatal("http_stats is nil")
 	}
@@ -422,6 +795,81 @@ func TestStats_Success(t *testing.T) {
 	}
 }
 
+func TestStats_Latency(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Hour)
+	withSamples := &storage.LatencyStats{
+		LatencyQuantiles: storage.LatencyQuantiles{Samples: 90, P50: 12, P90: 80, P95: 120, P99: 450},
+		Series: []*storage.LatencyPoint{
+			{Timestamp: now.Add(-time.Hour), LatencyQuantiles: storage.LatencyQuantiles{Samples: 40, P50: 10, P90: 60, P95: 90, P99: 200}},
+			{Timestamp: now, LatencyQuantiles: storage.LatencyQuantiles{Samples: 50, P50: 14, P90: 95, P95: 150, P99: 500}},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		param      string
+		latency    *storage.LatencyStats
+		wantStatus int
+		wantField  string
+		wantNil    bool
+	}{
+		{"default field", "", withSamples, http.StatusOK, "request_time_ms", false},
+		{"custom field", "&latency_field=upstream_time_ms", withSamples, http.StatusOK, "upstream_time_ms", false},
+		{"no samples", "", &storage.LatencyStats{}, http.StatusOK, "request_time_ms", true},
+		{"invalid field", "&latency_field=" + url.QueryEscape("a'b"), nil, http.StatusBadRequest, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage, mockRepo := newMockLogStorage()
+			mockRepo.latency = tt.latency
+			handler := NewHandler(mockStorage)
+
+			startTime := now.Add(-2 * time.Hour).Format(time.RFC3339)
+			req := httptest.NewRequest("GET", "/api/v1/logs/stats?start="+url.QueryEscape(startTime)+tt.param, nil)
+			rec := httptest.NewRecorder()
+
+			handler.Stats(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if mockRepo.lastField != tt.wantField {
+				t.Errorf("latency field = %q, want %q", mockRepo.lastField, tt.wantField)
+			}
+
+			var resp struct {
+				Data *StatsResponse `json:"data"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			latency := resp.Data.Latency
+			if tt.wantNil {
+				if latency != nil {
+					t.Errorf("latency = %+v, want nil", latency)
+				}
+				return
+			}
+			if latency == nil {
+				t.Fatal("latency is nil")
+			}
+			if latency.Field != tt.wantField || latency.Samples != 90 || latency.P50 != 12 || latency.P99 != 450 {
+				t.Errorf("latency = %+v", latency)
+			}
+			if len(latency.Series) != 2 {
+				t.Fatalf("series = %d points, want 2", len(latency.Series))
+			}
+			if got := latency.Series[1]; got.Timestamp != now.Format(time.RFC3339) || got.P95 != 150 || got.Samples != 50 {
+				t.Errorf("series[1] = %+v", got)
+			}
+		})
+	}
+}
+
 func TestStats_MissingStartTime(t *testing.T) {
 	mockStorage, _ := newMockLogStorage()
 	handler := NewHandler(mockStorage)
@@ -914,6 +1362,77 @@ func TestStream_WithStartTime(t *testing.T) {
 	if rec.Header().Get("Content-Type") != "text/event-stream" {
 		t.Errorf("Content-Type = %q, want text/event-stream", rec.Header().Get("Content-Type"))
 	}
+	// The reconnect delay comes before any event
+	if body := rec.Body.String(); !strings.HasPrefix(body, "retry: 3000\n\n") {
+		t.Errorf("body = %q, want it to start with the retry delay", body)
+	}
+}
+
+// keysetLogRepository returns its entries, in order, past the filter's
+// cursor, as storage does.
+type keysetLogRepository struct {
+	mockLogRepository
+}
+
+func (m *keysetLogRepository) Query(ctx context.Context, filter *storage.LogFilter) (*storage.LogQueryResult, error) {
+	var entries []*storage.LogRecord
+	for _, e := range m.entries {
+		if e.Timestamp.Before(filter.StartTime) {
+			continue
+		}
+		if filter.AfterID != "" && !e.Timestamp.After(filter.AfterTime) &&
+			(!e.Timestamp.Equal(filter.AfterTime) || e.ID <= filter.AfterID) {
+			continue
+		}
+		if len(entries) < filter.Limit {
+			entries = append(entries, e)
+		}
+	}
+	return &storage.LogQueryResult{Entries: entries}, nil
+}
+
+type keysetLogStorage struct {
+	mockLogStorage
+	keyset *keysetLogRepository
+}
+
+func (m *keysetLogStorage) Logs() storage.LogRepository { return m.keyset }
+
+func TestStream_SharedTimestamp(t *testing.T) {
+	// More entries share one timestamp than a poll returns
+	ts := time.Now().Add(-time.Minute).UTC()
+	repo := &keysetLogRepository{}
+	for i := 0; i < 250; i++ {
+		repo.entries = append(repo.entries, &storage.LogRecord{ID: fmt.Sprintf("log-%03d", i), Timestamp: ts})
+	}
+	handler := NewHandlerWithStorageAndConfig(&keysetLogStorage{keyset: repo}, nil,
+		HandlerConfig{StreamPollInterval: time.Millisecond})
+
+	req := httptest.NewRequest("GET", "/api/v1/logs/stream?start="+url.QueryEscape(ts.Add(-time.Second).Format(time.RFC3339)), nil)
+	ctx, cancel := context.WithTimeout(req.Context(), 100*time.Millisecond)
+	defer cancel()
+	rec := httptest.NewRecorder()
+
+	handler.Stream(rec, req.WithContext(ctx))
+
+	var ids []string
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			var entry LogResponse
+			if err := json.Unmarshal([]byte(data), &entry); err != nil {
+				t.Fatalf("decode event %q: %v", data, err)
+			}
+			ids = append(ids, entry.ID)
+		}
+	}
+	if len(ids) != len(repo.entries) {
+		t.Fatalf("streamed %d entries, want %d", len(ids), len(repo.entries))
+	}
+	for i, id := range ids {
+		if id != repo.entries[i].ID {
+			t.Fatalf("entry %d = %s, want %s", i, id, repo.entries[i].ID)
+		}
+	}
 }
 
 func TestStream_WithFilters(t *testing.T) {
@@ -940,6 +1459,48 @@ func TestStream_WithFilters(t *testing.T) {
 	}
 }
 
+func TestStream_Filter(t *testing.T) {
+	startTime := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	tests := []struct {
+		name       string
+		filter     string
+		wantStatus int
+	}{
+		{"valid", `http_status >= 500 and uri startsWith "/api/"`, http.StatusOK},
+		{"invalid", "level ==", http.StatusBadRequest},
+		{"too long", strings.Repeat("a", maxFilterLength+1), http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage, mockRepo := newMockLogStorage()
+			handler := NewHandlerWithStorageAndConfig(mockStorage, nil, HandlerConfig{StreamPollInterval: time.Millisecond})
+
+			req := httptest.NewRequest("GET", "/api/v1/logs/stream?start="+url.QueryEscape(startTime)+
+				"&level=debug&filter="+url.QueryEscape(tt.filter), nil)
+			ctx, cancel := context.WithTimeout(req.Context(), 50*time.Millisecond)
+			defer cancel()
+			rec := httptest.NewRecorder()
+
+			handler.Stream(rec, req.WithContext(ctx))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			filter := mockRepo.lastFilter
+			if filter == nil {
+				t.Fatal("stream never polled")
+			}
+			if filter.FilterExpr != tt.filter || !strings.Contains(filter.FilterSQL, "http_status") || len(filter.FilterArgs) != 2 {
+				t.Errorf("poll filter = %q, %v", filter.FilterSQL, filter.FilterArgs)
+			}
+		})
+	}
+}
+
 func TestStream_SearchModes(t *testing.T) {
 	tests := []struct {
 		name string
@@ -1075,3 +1636,119 @@ func TestQuery_MultipleLevels(t *testing.T) {
 		t.Errorf("Levels count = %d, want 3", len(mockRepo.lastFilter.Levels))
 	}
 }
+
+func TestGet(t *testing.T) {
+	record := &storage.LogRecord{ID: "log-2", Timestamp: time.Now().UTC(), Level: "error", Message: "boom",
+		Fields: map[string]interface{}{"status": float64(502)}}
+
+	tests := []struct {
+		name       string
+		id         string
+		wantStatus int
+	}{
+		{"found", "log-2", http.StatusOK},
+		{"unknown log", "log-9", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage, mockRepo := newMockLogStorage()
+			mockRepo.byID = record
+			handler := NewHandler(mockStorage)
+
+			req := httptest.NewRequest("GET", "/api/v1/logs/"+tt.id, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.id)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rec := httptest.NewRecorder()
+
+			handler.Get(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp struct {
+				Data *LogResponse `json:"data"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Data.ID != "log-2" || resp.Data.Message != "boom" || resp.Data.Fields["status"] != float64(502) {
+				t.Errorf("log = %+v", resp.Data)
+			}
+		})
+	}
+
+	t.Run("no log storage", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		NewHandler(nil).Get(rec, httptest.NewRequest("GET", "/api/v1/logs/log-2", nil))
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("status = %d, want 503", rec.Code)
+		}
+	})
+}
+
+func TestContext(t *testing.T) {
+	now := time.Now().UTC()
+	anchor := &storage.LogRecord{ID: "log-2", Timestamp: now, Level: "error", Message: "boom",
+		AgentID: "agent-1", FilePath: "/var/log/app.log", LineNumber: 42}
+
+	tests := []struct {
+		name         string
+		id           string
+		query        string
+		wantStatus   int
+		wantFilePath string
+	}{
+		{"same file by default", "log-2", "", http.StatusOK, "/var/log/app.log"},
+		{"file scope", "log-2", "?scope=file&before=20&after=20", http.StatusOK, "/var/log/app.log"},
+		{"agent scope", "log-2", "?scope=agent", http.StatusOK, ""},
+		{"invalid scope", "log-2", "?scope=host", http.StatusBadRequest, ""},
+		{"unknown log", "log-9", "", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage, mockRepo := newMockLogStorage()
+			mockRepo.byID = anchor
+			mockRepo.contextResult = &storage.ContextResult{
+				Target: anchor,
+				Before: []*storage.LogRecord{{ID: "log-1", Timestamp: now, LineNumber: 41}},
+				After:  []*storage.LogRecord{{ID: "log-3", Timestamp: now, LineNumber: 43}},
+			}
+			handler := NewHandler(mockStorage)
+
+			req := httptest.NewRequest("GET", "/api/v1/logs/"+tt.id+"/context"+tt.query, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.id)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rec := httptest.NewRecorder()
+
+			handler.Context(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			filter := mockRepo.lastContext
+			if filter.FilePath != tt.wantFilePath || filter.LineNumber != 42 || filter.AgentID != "agent-1" {
+				t.Errorf("context filter = %+v", filter)
+			}
+
+			var resp struct {
+				Data *ContextResponse `json:"data"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Data.Target.ID != "log-2" || len(resp.Data.Before) != 1 || len(resp.Data.After) != 1 {
+				t.Errorf("context = %+v", resp.Data)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package logs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/good-yellow-bee/blazelog/internal/query"
+)
+
+// schemaCacheControl allows clients to cache the schema for five minutes.
+// The schema only changes with a server upgrade or config reload.
+const schemaCacheControl = "private, max-age=300"
+
+// SchemaField describes a single queryable field.
+type SchemaField struct {
+	Name      string   `json:"name"`
+	Type      string   `json:"type"` // string, numeric, time or map
+	Operators []string `json:"operators"`
+	FullText  bool     `json:"full_text"`
+	// Label is true for indexed labels promoted to first-class filters.
+	Label bool `json:"label,omitempty"`
+}
+
+// SchemaResponse is the response for GET /api/v1/logs/schema.
+type SchemaResponse struct {
+	Fields []SchemaField `json:"fields"`
+}
+
+// buildSchema builds the schema from the query field registry and the
+// configured indexed labels. Fields are sorted by name.
+func buildSchema(fields map[string]query.FieldDef, indexedLabels []string) *SchemaResponse {
+	resp := &SchemaResponse{Fields: make([]SchemaField, 0, len(fields)+len(indexedLabels))}
+	for _, def := range fields {
+		resp.Fields = append(resp.Fields, SchemaField{
+			Name:      def.Name,
+			Type:      def.Type.String(),
+			Operators: def.Operators,
+			FullText:  def.FullText,
+		})
+	}
+
+	labels := fields["labels"]
+	seen := make(map[string]bool, len(indexedLabels))
+	for _, name := range indexedLabels {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		resp.Fields = append(resp.Fields, SchemaField{
+			Name:      "labels." + name,
+			Type:      query.FieldTypeString.String(),
+			Operators: labels.Operators,
+			Label:     true,
+		})
+	}
+
+	sort.Slice(resp.Fields, func(i, j int) bool {
+		return resp.Fields[i].Name < resp.Fields[j].Name
+	})
+	return resp
+}
+
+// encodeSchema renders the schema response body and its ETag once, since
+// the schema does not change for the lifetime of the handler.
+func encodeSchema(schema *SchemaResponse) ([]byte, string) {
+	body, err := json.Marshal(apiResponse{Data: schema})
+	if err != nil {
+		slog.Error("schema encode", "error", err)
+		return nil, ""
+	}
+	body = append(body, '\n')
+	sum := sha256.Sum256(body)
+	return body, `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// Schema handles GET /api/v1/logs/schema - describe queryable fields.
+func (h *Handler) Schema(w http.ResponseWriter, r *http.Request) {
+	if h.schemaBody == nil {
+		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "failed to build schema")
+		return
+	}
+
+	w.Header().Set("Cache-Control", schemaCacheControl)
+	w.Header().Set("ETag", h.schemaETag)
+	if match := r.Header.Get("If-None-Match"); match != "" && match == h.schemaETag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(h.schemaBody); err != nil {
+		slog.Error("schema write", "error", err)
+	}
+}
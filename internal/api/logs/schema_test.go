@@ -0,0 +1,90 @@
+package logs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/good-yellow-bee/blazelog/internal/query"
+)
+
+func TestSchema_Fields(t *testing.T) {
+	handler := NewHandlerWithStorageAndConfig(nil, nil, HandlerConfig{
+		IndexedLabels: []string{"env", " env ", "region", ""},
+	})
+
+	req := httptest.NewRequest("GET", "/api/v1/logs/schema", nil)
+	rec := httptest.NewRecorder()
+	handler.Schema(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if rec.Header().Get("Cache-Control") == "" || rec.Header().Get("ETag") == "" {
+		t.Error("expected Cache-Control and ETag headers")
+	}
+
+	var resp struct {
+		Data *SchemaResponse `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	byName := make(map[string]SchemaField)
+	for _, f := range resp.Data.Fields {
+		byName[f.Name] = f
+	}
+	if got, want := len(byName), len(query.DefaultFields)+2; got != want {
+		t.Errorf("fields = %d, want %d", got, want)
+	}
+
+	tests := []struct {
+		name     string
+		typ      string
+		fullText bool
+		label    bool
+	}{
+		{"message", "string", true, false},
+		{"level", "string", false, false},
+		{"http_status", "numeric", false, false},
+		{"timestamp", "time", false, false},
+		{"labels", "map", false, false},
+		{"labels.env", "string", false, true},
+		{"labels.region", "string", false, true},
+	}
+	for _, tt := range tests {
+		f, ok := byName[tt.name]
+		if !ok {
+			t.Errorf("field %q missing", tt.name)
+			continue
+		}
+		if f.Type != tt.typ || f.FullText != tt.fullText || f.Label != tt.label {
+			t.Errorf("field %q = %+v, want type=%s full_text=%v label=%v", tt.name, f, tt.typ, tt.fullText, tt.label)
+		}
+		if len(f.Operators) == 0 {
+			t.Errorf("field %q has no operators", tt.name)
+		}
+	}
+}
+
+func TestSchema_NotModified(t *testing.T) {
+	handler := NewHandler(nil)
+
+	rec := httptest.NewRecorder()
+	handler.Schema(rec, httptest.NewRequest("GET", "/api/v1/logs/schema", nil))
+	etag := rec.Header().Get("ETag")
+
+	req := httptest.NewRequest("GET", "/api/v1/logs/schema", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	handler.Schema(rec, req)
+
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotModified)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("body = %q, want empty", rec.Body.String())
+	}
+}
@@ -0,0 +1,170 @@
+package logs
+
+import (
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/storage"
+	"golang.org/x/sync/errgroup"
+)
+
+// defaultLatencyField is the Fields entry read for the latency signal and
+// Stats' latency quantiles when the request does not name one
+// (milliseconds, as the nginx parser records $request_time).
+const defaultLatencyField = "request_time_ms"
+
+// latencyPercentile is the percentile reported as the latency signal.
+const latencyPercentile = 0.95
+
+// latencyFieldPattern restricts latency_field to plain field names.
+var latencyFieldPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// SignalsResponse contains the four golden signals for a time range.
+type SignalsResponse struct {
+	Start      string            `json:"start"`
+	End        string            `json:"end"`
+	Traffic    *TrafficSignal    `json:"traffic"`
+	Errors     *ErrorSignal      `json:"errors"`
+	Latency    *LatencySignal    `json:"latency"` // nil when no log in range has the latency field
+	Saturation *SaturationSignal `json:"saturation"`
+}
+
+// TrafficSignal is the request rate. Basis is "http" when logs in range
+// carry an HTTP status, otherwise "logs" and every log counts as a request.
+type TrafficSignal struct {
+	Requests      int64   `json:"requests"`
+	RatePerSecond float64 `json:"rate_per_second"`
+	Basis         string  `json:"basis"`
+}
+
+// ErrorSignal is the share of error and fatal logs.
+type ErrorSignal struct {
+	Count int64   `json:"count"`
+	Rate  float64 `json:"rate"`
+}
+
+// LatencySignal is the p95 of a numeric latency field, in the field's unit.
+type LatencySignal struct {
+	Field   string  `json:"field"`
+	P95     float64 `json:"p95"`
+	Samples int64   `json:"samples"`
+}
+
+// SaturationSignal approximates saturation by the share of 5xx responses.
+type SaturationSignal struct {
+	Total5xx int64   `json:"total_5xx"`
+	Rate5xx  float64 `json:"rate_5xx"`
+}
+
+// Signals handles GET /api/v1/logs/signals - the four golden signals
+// (traffic, errors, latency, saturation) for one status panel. Takes the
+// same filters as Stats plus latency_field (default: request_time).
+func (h *Handler) Signals(w http.ResponseWriter, r *http.Request) {
+	if h.logStorage == nil {
+		jsonError(w, http.StatusServiceUnavailable, errCodeInternalError, "log storage not configured")
+		return
+	}
+
+	ctx := r.Context()
+	aggFilter, ok := h.aggregationFilter(w, r)
+	if !ok {
+		return
+	}
+
+	latencyField := defaultLatencyField
+	if f := r.URL.Query().Get("latency_field"); f != "" {
+		if !latencyFieldPattern.MatchString(f) {
+			jsonError(w, http.StatusBadRequest, errCodeBadRequest, "latency_field must be a field name (letters, digits, _ . -)")
+			return
+		}
+		latencyField = f
+	}
+
+	var (
+		errorRates *storage.ErrorRateResult
+		httpStats  *storage.HTTPStatsResult
+		latency    *storage.PercentileResult
+	)
+
+	queryCtx, cancel := h.newQueryContext(ctx)
+	defer cancel()
+	g, gCtx := errgroup.WithContext(queryCtx)
+
+	g.Go(func() error {
+		var err error
+		errorRates, err = h.logStorage.Logs().GetErrorRates(gCtx, aggFilter)
+		if err != nil {
+			slog.Error("error rates query", "error", err)
+		}
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		httpStats, err = h.logStorage.Logs().GetHTTPStats(gCtx, aggFilter)
+		if err != nil {
+			slog.Error("http stats query", "error", err)
+		}
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		latency, err = h.logStorage.Logs().GetFieldPercentile(gCtx, aggFilter, latencyField, latencyPercentile)
+		if err != nil {
+			slog.Error("latency percentile query", "error", err)
+		}
+		return err
+	})
+
+	if err := g.Wait(); err != nil {
+		handleStorageError(w, err, "signals query error")
+		return
+	}
+
+	jsonOK(w, buildSignals(aggFilter.StartTime, aggFilter.EndTime, errorRates, httpStats, latency, latencyField))
+}
+
+// buildSignals composes the aggregation results into a SignalsResponse.
+func buildSignals(start, end time.Time, errorRates *storage.ErrorRateResult, httpStats *storage.HTTPStatsResult, latency *storage.PercentileResult, latencyField string) *SignalsResponse {
+	if errorRates == nil {
+		errorRates = &storage.ErrorRateResult{}
+	}
+	if httpStats == nil {
+		httpStats = &storage.HTTPStatsResult{}
+	}
+
+	traffic := &TrafficSignal{
+		Requests: httpStats.Total2xx + httpStats.Total3xx + httpStats.Total4xx + httpStats.Total5xx,
+		Basis:    "http",
+	}
+	if traffic.Requests == 0 {
+		traffic.Requests = errorRates.TotalLogs
+		traffic.Basis = "logs"
+	}
+	if secs := end.Sub(start).Seconds(); secs > 0 {
+		traffic.RatePerSecond = float64(traffic.Requests) / secs
+	}
+
+	saturation := &SaturationSignal{Total5xx: httpStats.Total5xx}
+	if traffic.Basis == "http" {
+		saturation.Rate5xx = float64(httpStats.Total5xx) / float64(traffic.Requests)
+	}
+
+	resp := &SignalsResponse{
+		Start:   start.Format(time.RFC3339),
+		End:     end.Format(time.RFC3339),
+		Traffic: traffic,
+		Errors: &ErrorSignal{
+			Count: errorRates.ErrorCount + errorRates.FatalCount,
+			Rate:  errorRates.ErrorRate,
+		},
+		Saturation: saturation,
+	}
+	if latency != nil && latency.Samples > 0 {
+		resp.Latency = &LatencySignal{Field: latencyField, P95: latency.Value, Samples: latency.Samples}
+	}
+	return resp
+}
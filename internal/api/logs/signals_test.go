@@ -0,0 +1,147 @@
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/parser"
+	"github.com/good-yellow-bee/blazelog/internal/storage"
+)
+
+func getSignals(t *testing.T, handler *Handler, query string) (*httptest.ResponseRecorder, *SignalsResponse) {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/api/v1/logs/signals?"+query, nil)
+	rec := httptest.NewRecorder()
+	handler.Signals(rec, req)
+	if rec.Code != http.StatusOK {
+		return rec, nil
+	}
+	var resp struct {
+		Data *SignalsResponse `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return rec, resp.Data
+}
+
+func TestSignals_HTTPLogs(t *testing.T) {
+	mockStorage, mockRepo := newMockLogStorage()
+	mockRepo.errorRates = &storage.ErrorRateResult{TotalLogs: 1200, ErrorCount: 45, FatalCount: 5, ErrorRate: 50.0 / 1200}
+	mockRepo.httpStats = &storage.HTTPStatsResult{Total2xx: 800, Total3xx: 50, Total4xx: 100, Total5xx: 50}
+	mockRepo.percentile = &storage.PercentileResult{Value: 0.42, Samples: 1000}
+
+	end := time.Now().Truncate(time.Second)
+	start := end.Add(-100 * time.Second)
+	q := url.Values{"start": {start.Format(time.RFC3339)}, "end": {end.Format(time.RFC3339)}, "latency_field": {"upstream_time"}}
+	rec, resp := getSignals(t, NewHandler(mockStorage), q.Encode())
+	if resp == nil {
+		t.Fatalf("status = %d, want 200; body: %s", rec.Code, rec.Body.String())
+	}
+
+	if resp.Traffic.Requests != 1000 || resp.Traffic.Basis != "http" || resp.Traffic.RatePerSecond != 10 {
+		t.Errorf("traffic = %+v, want 1000 http requests at 10/s", resp.Traffic)
+	}
+	if resp.Errors.Count != 50 {
+		t.Errorf("errors.count = %d, want 50", resp.Errors.Count)
+	}
+	if resp.Latency == nil || resp.Latency.P95 != 0.42 || resp.Latency.Field != "upstream_time" {
+		t.Errorf("latency = %+v, want p95 0.42 of upstream_time", resp.Latency)
+	}
+	if mockRepo.lastField != "upstream_time" {
+		t.Errorf("percentile field = %q, want upstream_time", mockRepo.lastField)
+	}
+	if resp.Saturation.Total5xx != 50 || math.Abs(resp.Saturation.Rate5xx-0.05) > 1e-9 {
+		t.Errorf("saturation = %+v, want 50 / 0.05", resp.Saturation)
+	}
+}
+
+func TestSignals_NoHTTPOrLatency(t *testing.T) {
+	mockStorage, mockRepo := newMockLogStorage()
+	mockRepo.errorRates = &storage.ErrorRateResult{TotalLogs: 300, ErrorCount: 3, ErrorRate: 0.01}
+	mockRepo.httpStats = &storage.HTTPStatsResult{}
+
+	start := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	rec, resp := getSignals(t, NewHandler(mockStorage), "start="+url.QueryEscape(start))
+	if resp == nil {
+		t.Fatalf("status = %d, want 200; body: %s", rec.Code, rec.Body.String())
+	}
+
+	if resp.Traffic.Requests != 300 || resp.Traffic.Basis != "logs" {
+		t.Errorf("traffic = %+v, want 300 logs", resp.Traffic)
+	}
+	if resp.Latency != nil {
+		t.Errorf("latency = %+v, want nil without samples", resp.Latency)
+	}
+	if mockRepo.lastField != defaultLatencyField {
+		t.Errorf("percentile field = %q, want %q", mockRepo.lastField, defaultLatencyField)
+	}
+	if resp.Saturation.Rate5xx != 0 {
+		t.Errorf("saturation.rate_5xx = %v, want 0", resp.Saturation.Rate5xx)
+	}
+}
+
+// The default latency field is the one the nginx parser records.
+func TestSignals_NginxLatency(t *testing.T) {
+	p := parser.NewNginxAccessParser(nil)
+	ls := storage.NewMemoryLogStorage()
+	now := time.Now().UTC()
+	var records []*storage.LogRecord
+	for i, rt := range []string{"0.100", "0.200", "0.300", "0.400"} {
+		line := fmt.Sprintf(`10.0.0.1 - - [%s] "GET /api HTTP/1.1" 200 512 "-" "curl/8.0" rt=%s`,
+			now.Add(-time.Duration(i)*time.Minute).Format("02/Jan/2006:15:04:05 -0700"), rt)
+		entry, err := p.Parse(line)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", line, err)
+		}
+		records = append(records, &storage.LogRecord{
+			ID:         fmt.Sprintf("log-%d", i),
+			Timestamp:  entry.Timestamp,
+			Level:      string(entry.Level),
+			Type:       string(entry.Type),
+			Fields:     entry.Fields,
+			HTTPStatus: 200,
+		})
+	}
+	if err := ls.Logs().InsertBatch(context.Background(), records); err != nil {
+		t.Fatal(err)
+	}
+
+	start := now.Add(-time.Hour).Format(time.RFC3339)
+	rec, resp := getSignals(t, NewHandler(ls), "start="+url.QueryEscape(start))
+	if resp == nil {
+		t.Fatalf("status = %d, want 200; body: %s", rec.Code, rec.Body.String())
+	}
+	if resp.Latency == nil || resp.Latency.Field != "request_time_ms" || resp.Latency.Samples != 4 || math.Abs(resp.Latency.P95-385) > 1e-6 {
+		t.Errorf("latency = %+v, want p95 385ms of 4 request_time_ms samples", resp.Latency)
+	}
+}
+
+func TestSignals_BadRequest(t *testing.T) {
+	mockStorage, _ := newMockLogStorage()
+	handler := NewHandler(mockStorage)
+	start := url.QueryEscape(time.Now().Add(-time.Hour).Format(time.RFC3339))
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"missing start", ""},
+		{"bad latency field", "start=" + start + "&latency_field=" + url.QueryEscape("x') OR 1=1 --")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, _ := getSignals(t, handler, tt.query)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
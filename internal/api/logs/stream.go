@@ -0,0 +1,222 @@
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/storage"
+)
+
+const (
+	// streamReorderLimit caps the rows fetched by each reorder-window re-query.
+	streamReorderLimit = 1000
+
+	// streamHeartbeat is how often an idle stream sends a heartbeat event.
+	streamHeartbeat = 15 * time.Second
+)
+
+// streamSink sends the events of a log stream over one transport: log,
+// heartbeat, error and close, each with a JSON payload.
+type streamSink interface {
+	send(event string, data any) error
+}
+
+// streamError is the payload of a stream's error events.
+type streamError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// sseSink sends stream events as Server-Sent Events.
+type sseSink struct {
+	sse *SSEWriter
+}
+
+func (s sseSink) send(event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return s.sse.SendEvent(event, string(payload))
+}
+
+// runStream polls storage for logs matching base from start on and sends
+// them to sink until ctx is done, sending fails or the stream reaches its
+// max duration. A filter received on filters replaces base from the next
+// poll; the stream carries on from the entries already sent.
+func (h *Handler) runStream(ctx context.Context, start time.Time, base *storage.LogFilter, sink streamSink, filters <-chan *storage.LogFilter) {
+	// Track what has been sent
+	cursor := newStreamCursor(start, h.streamReorder)
+	send := func(entries []*storage.LogRecord) error {
+		for _, entry := range entries {
+			if !cursor.accept(entry) {
+				continue
+			}
+			if err := sink.send("log", recordToResponse(entry)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	lastHeartbeat := time.Now()
+
+	// Stream timeout
+	deadline := time.Now().Add(h.streamMaxDuration)
+
+	// Main loop
+	ticker := time.NewTicker(h.streamPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Client disconnected
+			return
+
+		case filter := <-filters:
+			base = filter
+
+		case <-ticker.C:
+			// Check timeout
+			if time.Now().After(deadline) {
+				sink.send("close", map[string]string{"reason": "timeout"})
+				return
+			}
+
+			// Re-query the reorder window for late arrivals
+			if late := cursor.lateFilter(base); late != nil {
+				queryCtx, cancel := h.newQueryContext(ctx)
+				result, err := h.logStorage.Logs().Query(queryCtx, late)
+				cancel()
+				if err != nil {
+					slog.Error("stream reorder query", "error", err)
+				} else if err := send(result.Entries); err != nil {
+					return // Client disconnected
+				}
+			}
+
+			// Query for new logs
+			queryCtx, cancel := h.newQueryContext(ctx)
+			result, err := h.logStorage.Logs().Query(queryCtx, cursor.nextFilter(base, time.Now()))
+			cancel()
+			if err != nil {
+				if isTimeoutError(err) {
+					sink.send("error", streamError{Code: errCodeTimeout, Message: "stream query timed out"})
+				}
+				slog.Error("stream query", "error", err)
+				continue
+			}
+
+			// Send new logs
+			if err := send(result.Entries); err != nil {
+				return // Client disconnected
+			}
+			cursor.prune()
+
+			// Send heartbeat if needed
+			if time.Since(lastHeartbeat) >= streamHeartbeat {
+				sink.send("heartbeat", map[string]string{"timestamp": time.Now().Format(time.RFC3339)})
+				lastHeartbeat = time.Now()
+			}
+		}
+	}
+}
+
+// streamCursor tracks what a log stream has already sent. Each poll asks
+// storage for the entries past the newest (timestamp, ID) sent, so entries
+// sharing a timestamp go out once each, in storage order. Without a reorder
+// window entries that arrive late are never seen. With a window the stream
+// also re-queries the window behind the newest timestamp, and the cursor
+// remembers the IDs sent within it so late arrivals go out once and nothing
+// is sent twice.
+type streamCursor struct {
+	start  time.Time // stream start; nothing older is sent
+	last   time.Time // newest timestamp sent
+	lastID string    // ID of the entry sent last at last
+	window time.Duration
+	seen   map[string]time.Time // IDs sent within the window, by timestamp
+}
+
+func newStreamCursor(start time.Time, window time.Duration) *streamCursor {
+	return &streamCursor{
+		start:  start,
+		last:   start,
+		window: window,
+		seen:   make(map[string]time.Time),
+	}
+}
+
+// floor returns the oldest timestamp the stream still sends.
+func (c *streamCursor) floor() time.Time {
+	floor := c.last.Add(-c.window)
+	if floor.Before(c.start) {
+		return c.start
+	}
+	return floor
+}
+
+// nextFilter returns the query for entries up to end that are newer than
+// everything sent.
+func (c *streamCursor) nextFilter(base *storage.LogFilter, end time.Time) *storage.LogFilter {
+	filter := *base
+	filter.StartTime = c.last
+	filter.EndTime = end
+	filter.AfterTime = c.last
+	filter.AfterID = c.lastID
+	return &filter
+}
+
+// lateFilter returns the re-query for entries that arrived behind the
+// cursor, or nil when there is no window to re-query.
+func (c *streamCursor) lateFilter(base *storage.LogFilter) *storage.LogFilter {
+	if c.window <= 0 || !c.floor().Before(c.last) {
+		return nil
+	}
+	filter := *base
+	filter.StartTime = c.floor()
+	filter.EndTime = c.last
+	filter.Limit = streamReorderLimit
+	return &filter
+}
+
+// accept reports whether entry should be sent, recording it if so. Entries
+// must come in storage order, as the queries return them.
+func (c *streamCursor) accept(entry *storage.LogRecord) bool {
+	switch {
+	case entry.ID == "":
+		// Without an ID to dedup on, only entries past the cursor are new
+		if !entry.Timestamp.After(c.last) {
+			return false
+		}
+	case c.window <= 0:
+		// The query only returns entries past the cursor; older ones are late
+		if entry.Timestamp.Before(c.last) {
+			return false
+		}
+	default:
+		if entry.Timestamp.Before(c.floor()) {
+			return false
+		}
+		if _, ok := c.seen[entry.ID]; ok {
+			return false
+		}
+		c.seen[entry.ID] = entry.Timestamp
+	}
+	if !entry.Timestamp.Before(c.last) {
+		c.last, c.lastID = entry.Timestamp, entry.ID
+	}
+	return true
+}
+
+// prune forgets IDs that have fallen out of the window.
+func (c *streamCursor) prune() {
+	floor := c.floor()
+	for id, ts := range c.seen {
+		if ts.Before(floor) {
+			delete(c.seen, id)
+		}
+	}
+}
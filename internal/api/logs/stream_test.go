@@ -0,0 +1,151 @@
+package logs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/storage"
+)
+
+func TestStreamCursor_Accept(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(id string, sec int) *storage.LogRecord {
+		return &storage.LogRecord{ID: id, Timestamp: start.Add(time.Duration(sec) * time.Second)}
+	}
+
+	tests := []struct {
+		name    string
+		window  time.Duration
+		entries []*storage.LogRecord
+		want    []string
+	}{
+		{
+			name:    "no window skips late entries",
+			entries: []*storage.LogRecord{at("a", 10), at("late", 5), at("b", 11)},
+			want:    []string{"a", "b"},
+		},
+		{
+			name:    "no window sends entries sharing a timestamp",
+			entries: []*storage.LogRecord{at("a", 10), at("b", 10), at("c", 10), at("d", 11)},
+			want:    []string{"a", "b", "c", "d"},
+		},
+		{
+			name:    "window sends late entries once",
+			window:  10 * time.Second,
+			entries: []*storage.LogRecord{at("a", 10), at("late", 5), at("a", 10), at("late", 5), at("b", 11)},
+			want:    []string{"a", "late", "b"},
+		},
+		{
+			name:    "window sends entries sharing the newest timestamp",
+			window:  10 * time.Second,
+			entries: []*storage.LogRecord{at("a", 10), at("b", 10)},
+			want:    []string{"a", "b"},
+		},
+		{
+			name:    "window drops entries older than the window",
+			window:  10 * time.Second,
+			entries: []*storage.LogRecord{at("a", 30), at("old", 15)},
+			want:    []string{"a"},
+		},
+		{
+			name:    "window never goes before stream start",
+			window:  time.Minute,
+			entries: []*storage.LogRecord{at("a", 10), at("early", -5)},
+			want:    []string{"a"},
+		},
+		{
+			name:    "entries without ID fall back to timestamp order",
+			window:  10 * time.Second,
+			entries: []*storage.LogRecord{at("", 10), at("", 10), at("", 5)},
+			want:    []string{""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newStreamCursor(start, tt.window)
+			var got []string
+			for _, e := range tt.entries {
+				if c.accept(e) {
+					got = append(got, e.ID)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("sent %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("sent %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestStreamCursor_NextFilter(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	end := start.Add(time.Minute)
+	base := &storage.LogFilter{Level: "error", Limit: 100}
+
+	c := newStreamCursor(start, 0)
+	f := c.nextFilter(base, end)
+	if !f.StartTime.Equal(start) || !f.EndTime.Equal(end) || f.AfterID != "" {
+		t.Errorf("first filter = %+v, want from stream start", f)
+	}
+
+	for _, id := range []string{"a", "b"} {
+		c.accept(&storage.LogRecord{ID: id, Timestamp: start.Add(10 * time.Second)})
+	}
+	f = c.nextFilter(base, end)
+	if !f.AfterTime.Equal(start.Add(10*time.Second)) || f.AfterID != "b" {
+		t.Errorf("next filter = after %v %q, want the last entry sent", f.AfterTime, f.AfterID)
+	}
+	if f.Level != "error" || base.AfterID != "" {
+		t.Errorf("next filter = %+v, want a copy of the base filter", f)
+	}
+}
+
+func TestStreamCursor_LateFilter(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	base := &storage.LogFilter{Level: "error", Limit: 100}
+
+	c := newStreamCursor(start, 10*time.Second)
+	if f := c.lateFilter(base); f != nil {
+		t.Fatalf("lateFilter before any entries = %+v, want nil", f)
+	}
+
+	c.accept(&storage.LogRecord{ID: "a", Timestamp: start.Add(30 * time.Second)})
+	f := c.lateFilter(base)
+	if f == nil {
+		t.Fatal("lateFilter = nil, want re-query")
+	}
+	if !f.StartTime.Equal(start.Add(20*time.Second)) || !f.EndTime.Equal(start.Add(30*time.Second)) {
+		t.Errorf("late range = %v..%v", f.StartTime, f.EndTime)
+	}
+	if f.Level != "error" || f.Limit != streamReorderLimit {
+		t.Errorf("late filter = %+v, want base filter with reorder limit", f)
+	}
+	if base.Limit != 100 {
+		t.Error("lateFilter modified the base filter")
+	}
+
+	if f := newStreamCursor(start, 0).lateFilter(base); f != nil {
+		t.Errorf("lateFilter without window = %+v, want nil", f)
+	}
+}
+
+func TestStreamCursor_Prune(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := newStreamCursor(start, 10*time.Second)
+
+	c.accept(&storage.LogRecord{ID: "a", Timestamp: start.Add(5 * time.Second)})
+	c.accept(&storage.LogRecord{ID: "b", Timestamp: start.Add(30 * time.Second)})
+	c.prune()
+
+	if _, ok := c.seen["a"]; ok {
+		t.Error("entry outside the window was not pruned")
+	}
+	if _, ok := c.seen["b"]; !ok {
+		t.Error("entry inside the window was pruned")
+	}
+}
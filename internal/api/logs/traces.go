@@ -0,0 +1,57 @@
+package logs
+
+import "strings"
+
+// Stack trace fields set by the multi-line parsers.
+const (
+	fieldStackTrace     = "stack_trace"
+	fieldStackTopFrame  = "stack_top_frame"
+	fieldStackCollapsed = "stack_trace_collapsed"
+)
+
+// collapseTrace drops the stack trace of a stack-bearing entry for list
+// views, keeping a summary: the parser's stack_frame_count and the top
+// frame. The full trace is left to the detail endpoints. Entries without a
+// trace are unchanged.
+func collapseTrace(resp *LogResponse) {
+	trace, ok := resp.Fields[fieldStackTrace].(string)
+	if !ok || trace == "" {
+		return
+	}
+
+	// Copy: the fields map belongs to the storage record
+	fields := make(map[string]interface{}, len(resp.Fields)+1)
+	for k, v := range resp.Fields {
+		if k != fieldStackTrace {
+			fields[k] = v
+		}
+	}
+	fields[fieldStackCollapsed] = true
+	if top := topFrame(trace); top != "" {
+		fields[fieldStackTopFrame] = top
+	}
+	resp.Fields = fields
+
+	// Merge mode appends the trace to the message
+	resp.Message = strings.TrimSuffix(resp.Message, "\n"+trace)
+}
+
+// topFrame returns the first line of trace that looks like a stack frame
+// (PHP "#0 ...", Java "at ...", Python "File ..."), or else its first
+// non-empty line.
+func topFrame(trace string) string {
+	var first string
+	for _, line := range strings.Split(trace, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "at ") || strings.HasPrefix(line, "File ") {
+			return line
+		}
+		if first == "" {
+			first = line
+		}
+	}
+	return first
+}
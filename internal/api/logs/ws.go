@@ -0,0 +1,125 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+
+	"github.com/good-yellow-bee/blazelog/internal/storage"
+)
+
+const (
+	// wsReadLimit caps the size of a client's control messages.
+	wsReadLimit = 4 * maxFilterLength
+
+	// wsWriteTimeout bounds each message written to a WebSocket client.
+	wsWriteTimeout = 10 * time.Second
+)
+
+// wsMessage is a message sent to a WebSocket client: a stream event and
+// its payload.
+type wsMessage struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// wsControl is a control message from a WebSocket client.
+type wsControl struct {
+	Action string `json:"action"`
+	Filter string `json:"filter"`
+}
+
+// wsSink sends stream events as WebSocket JSON messages.
+type wsSink struct {
+	ctx  context.Context
+	conn *websocket.Conn
+}
+
+func (s wsSink) send(event string, data any) error {
+	ctx, cancel := context.WithTimeout(s.ctx, wsWriteTimeout)
+	defer cancel()
+	return wsjson.Write(ctx, s.conn, wsMessage{Type: event, Data: data})
+}
+
+// WebSocket handles GET /api/v1/logs/ws - log streaming over a WebSocket,
+// for clients behind proxies that buffer SSE. It takes the parameters of
+// Stream and sends the same events, each as {"type": ..., "data": ...}.
+// The client can replace the DSL filter mid-stream by sending
+// {"action": "set_filter", "filter": "..."}.
+func (h *Handler) WebSocket(w http.ResponseWriter, r *http.Request) {
+	if h.logStorage == nil {
+		jsonError(w, http.StatusServiceUnavailable, errCodeInternalError, "log storage not configured")
+		return
+	}
+
+	startTime, baseFilter, ok := h.streamFilter(w, r)
+	if !ok {
+		return
+	}
+
+	// The hijacked connection keeps the server's read timeout; control
+	// messages may come at any time
+	_ = http.NewResponseController(w).SetReadDeadline(time.Time{})
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		return // Accept wrote the error response
+	}
+	defer conn.CloseNow()
+	conn.SetReadLimit(wsReadLimit)
+
+	// The request context isn't cancelled when a hijacked client goes
+	// away; a failed read is how the stream learns of it
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	sink := wsSink{ctx: ctx, conn: conn}
+	filters := make(chan *storage.LogFilter)
+	go func() {
+		defer cancel()
+		readControl(ctx, conn, baseFilter, sink, filters)
+	}()
+
+	h.runStream(ctx, startTime, baseFilter, sink, filters)
+	conn.Close(websocket.StatusNormalClosure, "")
+}
+
+// readControl reads a client's control messages until the connection
+// fails, sending each filter the client sets on filters. A set_filter
+// replaces the DSL filter of base, keeping its flat and project filters;
+// an empty filter goes back to the flat filters. Invalid messages get an
+// error event and change nothing.
+func readControl(ctx context.Context, conn *websocket.Conn, base *storage.LogFilter, sink streamSink, filters chan<- *storage.LogFilter) {
+	for {
+		var msg wsControl
+		if err := wsjson.Read(ctx, conn, &msg); err != nil {
+			return
+		}
+		if msg.Action != "set_filter" {
+			if sink.send("error", streamError{Code: errCodeBadRequest, Message: fmt.Sprintf("unknown action %q", msg.Action)}) != nil {
+				return
+			}
+			continue
+		}
+
+		filterSQL, filterArgs, err := buildFilterExpr(msg.Filter)
+		if err != nil {
+			if sink.send("error", streamError{Code: errCodeBadRequest, Message: err.Error()}) != nil {
+				return
+			}
+			continue
+		}
+		filter := *base
+		filter.FilterExpr, filter.FilterSQL, filter.FilterArgs = msg.Filter, filterSQL, filterArgs
+		select {
+		case filters <- &filter:
+		case <-ctx.Done():
+			return
+		}
+		if sink.send("filter", map[string]string{"filter": msg.Filter}) != nil {
+			return
+		}
+	}
+}
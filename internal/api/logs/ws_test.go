@@ -0,0 +1,149 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+
+	"github.com/good-yellow-bee/blazelog/internal/api/middleware"
+	"github.com/good-yellow-bee/blazelog/internal/storage"
+)
+
+// recordingLogRepository records the filters it is queried with, from the
+// stream's goroutine.
+type recordingLogRepository struct {
+	keysetLogRepository
+	mu      sync.Mutex
+	filters []*storage.LogFilter
+}
+
+func (m *recordingLogRepository) Query(ctx context.Context, filter *storage.LogFilter) (*storage.LogQueryResult, error) {
+	m.mu.Lock()
+	m.filters = append(m.filters, filter)
+	m.mu.Unlock()
+	return m.keysetLogRepository.Query(ctx, filter)
+}
+
+func (m *recordingLogRepository) lastFilter() *storage.LogFilter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.filters) == 0 {
+		return nil
+	}
+	return m.filters[len(m.filters)-1]
+}
+
+type recordingLogStorage struct {
+	mockLogStorage
+	recording *recordingLogRepository
+}
+
+func (m *recordingLogStorage) Logs() storage.LogRepository { return m.recording }
+
+// wsReceived is a message received from the WebSocket endpoint.
+type wsReceived struct {
+	Type string         `json:"type"`
+	Data map[string]any `json:"data"`
+}
+
+func TestWebSocket(t *testing.T) {
+	ts := time.Now().Add(-time.Minute).UTC()
+	repo := &recordingLogRepository{}
+	for i := 0; i < 3; i++ {
+		repo.entries = append(repo.entries, &storage.LogRecord{ID: fmt.Sprintf("log-%d", i), Timestamp: ts, Level: "error"})
+	}
+	handler := NewHandlerWithStorageAndConfig(&recordingLogStorage{recording: repo}, nil,
+		HandlerConfig{StreamPollInterval: time.Millisecond})
+
+	// Through the writers the router's middleware wraps responses in
+	srv := httptest.NewServer(middleware.PrometheusMiddleware(middleware.RequestLogger(false)(http.HandlerFunc(handler.WebSocket))))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "?level=error&start=" + url.QueryEscape(ts.Add(-time.Second).Format(time.RFC3339))
+	conn, _, err := websocket.Dial(ctx, wsURL, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.CloseNow()
+
+	read := func() wsReceived {
+		t.Helper()
+		var msg wsReceived
+		if err := wsjson.Read(ctx, conn, &msg); err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		return msg
+	}
+
+	for i := range repo.entries {
+		if msg := read(); msg.Type != "log" || msg.Data["id"] != repo.entries[i].ID {
+			t.Fatalf("message %d = %+v, want log %s", i, msg, repo.entries[i].ID)
+		}
+	}
+
+	// Invalid control messages get an error and change nothing
+	for _, control := range []wsControl{
+		{Action: "pause"},
+		{Action: "set_filter", Filter: "level =="},
+	} {
+		if err := wsjson.Write(ctx, conn, control); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		if msg := read(); msg.Type != "error" || msg.Data["code"] != errCodeBadRequest {
+			t.Errorf("reply to %+v = %+v, want a bad request error", control, msg)
+		}
+	}
+
+	filter := `http_status >= 500`
+	if err := wsjson.Write(ctx, conn, wsControl{Action: "set_filter", Filter: filter}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if msg := read(); msg.Type != "filter" || msg.Data["filter"] != filter {
+		t.Fatalf("reply to set_filter = %+v", msg)
+	}
+	for {
+		f := repo.lastFilter()
+		if f.FilterExpr == filter {
+			if !strings.Contains(f.FilterSQL, "http_status") || f.Level != "error" || f.AfterID != "log-2" {
+				t.Errorf("poll filter = %+v, want the new DSL filter past the last entry sent", f)
+			}
+			break
+		}
+		if ctx.Err() != nil {
+			t.Fatal("stream never polled with the new filter")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	conn.Close(websocket.StatusNormalClosure, "")
+}
+
+func TestWebSocket_Errors(t *testing.T) {
+	t.Run("invalid parameters before the upgrade", func(t *testing.T) {
+		mockStorage, _ := newMockLogStorage()
+		rec := httptest.NewRecorder()
+		NewHandler(mockStorage).WebSocket(rec, httptest.NewRequest("GET", "/api/v1/logs/ws?search_mode=fuzzy", nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400", rec.Code)
+		}
+	})
+
+	t.Run("no log storage", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		NewHandler(nil).WebSocket(rec, httptest.NewRequest("GET", "/api/v1/logs/ws", nil))
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("status = %d, want 503", rec.Code)
+		}
+	})
+}
@@ -3,7 +3,7 @@ package middleware
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 
@@ -32,7 +32,7 @@ func jsonUnauthorized(w http.ResponseWriter) {
 			"message": "invalid or expired token",
 		},
 	}); err != nil {
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 	}
 }
 
@@ -46,7 +46,7 @@ func jsonForbidden(w http.ResponseWriter) {
 			"message": "access denied",
 		},
 	}); err != nil {
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 	}
 }
 
@@ -73,7 +73,7 @@ func JWTAuth(jwtService *auth.JWTService) func(http.Handler) http.Handler {
 			// Validate token
 			claims, err := jwtService.ValidateToken(tokenString)
 			if err != nil {
-				log.Printf("JWT auth failed for %s: %v", r.RemoteAddr, err)
+				slog.Debug("JWT auth failed", "remote_addr", r.RemoteAddr, "error", err)
 				jsonUnauthorized(w)
 				return
 			}
@@ -112,7 +112,7 @@ func JWTOrSessionAuth(jwtService *auth.JWTService, sessions *session.Store) func
 						next.ServeHTTP(w, r.WithContext(ctx))
 						return
 					}
-					log.Printf("JWT validation failed for %s: %v", r.RemoteAddr, err)
+					slog.Debug("JWT validation failed", "remote_addr", r.RemoteAddr, "error", err)
 				}
 			}
 
@@ -133,7 +133,7 @@ func JWTOrSessionAuth(jwtService *auth.JWTService, sessions *session.Store) func
 					if len(cookiePreview) > 8 {
 						cookiePreview = cookiePreview[:8] + "..."
 					}
-					log.Printf("Session not found or expired for %s: %s", r.RemoteAddr, cookiePreview)
+					slog.Debug("session not found or expired", "remote_addr", r.RemoteAddr, "session", cookiePreview)
 				}
 			}
 
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// paramSegment matches chi-style route parameters such as {id}.
+var paramSegment = regexp.MustCompile(`\{[^/}]*\}`)
+
+// EndpointLimiter applies per-endpoint rate limits on top of the global
+// limiters. Each rule maps a route pattern to its own per-minute limit.
+type EndpointLimiter struct {
+	rules []endpointRule
+}
+
+// endpointRule is a single compiled route pattern with its limiter.
+type endpointRule struct {
+	key     string // original pattern, for deterministic ordering
+	method  string // empty matches any method
+	pattern string // path.Match pattern
+	limiter *RateLimiter
+}
+
+// ParseEndpointPattern parses a route pattern of the form "[METHOD ]/path".
+// Path segments may be "*" or a chi-style "{param}" to match any single
+// segment. The returned pattern is in path.Match syntax.
+func ParseEndpointPattern(s string) (method, pattern string, err error) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, ' '); i >= 0 {
+		method = strings.ToUpper(s[:i])
+		s = strings.TrimSpace(s[i+1:])
+	}
+	if !strings.HasPrefix(s, "/") {
+		return "", "", fmt.Errorf("route pattern %q must start with /", s)
+	}
+
+	pattern = cleanRoutePath(paramSegment.ReplaceAllString(s, "*"))
+	if _, err := path.Match(pattern, "/"); err != nil {
+		return "", "", fmt.Errorf("route pattern %q: %w", s, err)
+	}
+	return method, pattern, nil
+}
+
+// NewEndpointLimiter creates an endpoint limiter from a map of route
+// pattern to requests per minute. When several patterns match a request,
+// the most specific one applies: method-qualified patterns win, then the
+// pattern with the most path segments, then the one with fewer wildcards.
+func NewEndpointLimiter(limits map[string]int) (*EndpointLimiter, error) {
+	el := &EndpointLimiter{}
+	for key, limit := range limits {
+		if limit <= 0 {
+			return nil, fmt.Errorf("route pattern %q: limit must be > 0", key)
+		}
+		method, pattern, err := ParseEndpointPattern(key)
+		if err != nil {
+			return nil, err
+		}
+		el.rules = append(el.rules, endpointRule{
+			key:     key,
+			method:  method,
+			pattern: pattern,
+			limiter: NewRateLimiter(limit),
+		})
+	}
+
+	sort.Slice(el.rules, func(i, j int) bool {
+		a, b := el.rules[i], el.rules[j]
+		if (a.method != "") != (b.method != "") {
+			return a.method != ""
+		}
+		if sa, sb := strings.Count(a.pattern, "/"), strings.Count(b.pattern, "/"); sa != sb {
+			return sa > sb
+		}
+		if wa, wb := strings.Count(a.pattern, "*"), strings.Count(b.pattern, "*"); wa != wb {
+			return wa < wb
+		}
+		return a.key < b.key
+	})
+
+	return el, nil
+}
+
+// match returns the most specific rule for a request, or nil.
+func (el *EndpointLimiter) match(r *http.Request) *endpointRule {
+	if el == nil {
+		return nil
+	}
+	p := cleanRoutePath(r.URL.Path)
+	for i := range el.rules {
+		rule := &el.rules[i]
+		if rule.method != "" && rule.method != r.Method {
+			continue
+		}
+		if ok, _ := path.Match(rule.pattern, p); ok {
+			return rule
+		}
+	}
+	return nil
+}
+
+// cleanRoutePath drops a trailing slash so "/logs/" and "/logs" match alike.
+func cleanRoutePath(p string) string {
+	if len(p) > 1 {
+		p = strings.TrimSuffix(p, "/")
+	}
+	return p
+}
+
+// RateLimitByEndpoint returns middleware that applies per-endpoint limits.
+// Requests are keyed by authenticated user, falling back to client IP, and
+// requests that match no pattern pass through untouched. A nil limiter
+// disables the middleware.
+func RateLimitByEndpoint(el *EndpointLimiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rule := el.match(r)
+			if rule == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := GetUserID(r.Context())
+			if key == "" {
+				key = getClientIP(r)
+			}
+
+			if allowed, retryAfter := rule.limiter.Reserve(key); !allowed {
+				jsonRateLimited(w, retryAfter)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestParseEndpointPattern(t *testing.T) {
+	tests := []struct {
+		in          string
+		wantMethod  string
+		wantPattern string
+		wantErr     bool
+	}{
+		{in: "/api/v1/logs", wantPattern: "/api/v1/logs"},
+		{in: "get /api/v1/logs/", wantMethod: "GET", wantPattern: "/api/v1/logs"},
+		{in: "/api/v1/logs/{id}/context", wantPattern: "/api/v1/logs/*/context"},
+		{in: "POST api/v1/share", wantErr: true},
+		{in: "/api/[v1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			method, pattern, err := ParseEndpointPattern(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseEndpointPattern: %v", err)
+			}
+			if method != tt.wantMethod || pattern != tt.wantPattern {
+				t.Errorf("got (%q, %q), want (%q, %q)", method, pattern, tt.wantMethod, tt.wantPattern)
+			}
+		})
+	}
+}
+
+func TestRateLimitByEndpoint(t *testing.T) {
+	el, err := NewEndpointLimiter(map[string]int{
+		"/api/v1/logs/*":         5,
+		"GET /api/v1/logs/stats": 1,
+	})
+	if err != nil {
+		t.Fatalf("NewEndpointLimiter: %v", err)
+	}
+
+	handler := RateLimitByEndpoint(el)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	do := func(method, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// The method-qualified pattern is the most specific match for stats
+	if rec := do("GET", "/api/v1/logs/stats"); rec.Code != http.StatusOK {
+		t.Fatalf("first stats request = %d, want 200", rec.Code)
+	}
+	rec := do("GET", "/api/v1/logs/stats")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second stats request = %d, want 429", rec.Code)
+	}
+	if s, err := strconv.Atoi(rec.Header().Get("Retry-After")); err != nil || s < 1 {
+		t.Errorf("Retry-After = %q, want positive seconds", rec.Header().Get("Retry-After"))
+	}
+
+	// Other endpoints under the wildcard keep their own budget
+	if rec := do("GET", "/api/v1/logs/stream"); rec.Code != http.StatusOK {
+		t.Errorf("stream request = %d, want 200", rec.Code)
+	}
+
+	// Unmatched endpoints are not limited
+	for i := 0; i < 10; i++ {
+		if rec := do("GET", "/api/v1/alerts"); rec.Code != http.StatusOK {
+			t.Fatalf("unmatched request %d = %d, want 200", i, rec.Code)
+		}
+	}
+}
+
+func TestRateLimitByEndpoint_NilLimiter(t *testing.T) {
+	handler := RateLimitByEndpoint(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/logs", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", rec.Code)
+	}
+}
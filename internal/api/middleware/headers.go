@@ -4,7 +4,8 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"runtime/debug"
 	"strings"
@@ -56,7 +57,7 @@ func SecurityHeaders(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		nonce, err := generateCSPNonce()
 		if err != nil {
-			log.Printf("warning: failed to generate CSP nonce: %v", err)
+			slog.Warn("failed to generate CSP nonce", "error", err)
 		} else {
 			r = r.WithContext(context.WithValue(r.Context(), cspNonceKey, nonce))
 		}
@@ -93,12 +94,12 @@ func Recoverer(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				log.Printf("PANIC recovered: %v\nRequest: %s %s\nStack:\n%s",
-					err, r.Method, r.URL.Path, debug.Stack())
+				slog.Error(fmt.Sprintf("PANIC recovered: %v\nRequest: %s %s\nStack:\n%s",
+					err, r.Method, r.URL.Path, debug.Stack()))
 				w.Header().Set("Content-Type", "application/json")
 				w.WriteHeader(http.StatusInternalServerError)
 				if _, writeErr := w.Write([]byte(`{"error":{"code":"INTERNAL_ERROR","message":"Internal server error"}}`)); writeErr != nil {
-					log.Printf("Failed to write error response: %v", writeErr)
+					slog.Error("write error response", "error", writeErr)
 				}
 			}
 		}()
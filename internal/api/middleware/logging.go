@@ -2,7 +2,7 @@
 package middleware
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -27,6 +27,12 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return n, err
 }
 
+// Unwrap lets http.ResponseController and WebSocket upgrades reach the
+// underlying writer.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // RequestLogger returns a middleware that logs HTTP requests.
 func RequestLogger(verbose bool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -45,16 +51,23 @@ func RequestLogger(verbose bool) func(http.Handler) http.Handler {
 
 			// Log request
 			duration := time.Since(start)
-			if verbose || wrapped.status >= 400 {
-				log.Printf("[%s] %s %s %d %d %v",
-					requestID,
-					r.Method,
-					r.URL.Path,
-					wrapped.status,
-					wrapped.size,
-					duration,
-				)
+			level := slog.LevelDebug
+			switch {
+			case wrapped.status >= 500:
+				level = slog.LevelError
+			case wrapped.status >= 400:
+				level = slog.LevelWarn
+			case verbose:
+				level = slog.LevelInfo
 			}
+			slog.Log(r.Context(), level, "http request",
+				"request_id", requestID,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", wrapped.status,
+				"size", wrapped.size,
+				"duration", duration,
+			)
 		})
 	}
 }
@@ -2,9 +2,10 @@ package middleware
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -86,18 +87,22 @@ type rateLimiterEntry struct {
 }
 
 // NewRateLimiter creates a new rate limiter with a 1 minute window.
+// A negative limit allows every request.
 func NewRateLimiter(limit int) *RateLimiter {
 	return NewRateLimiterWithWindow(limit, time.Minute)
 }
 
 // NewRateLimiterWithWindow creates a new rate limiter.
-// limit is requests per window.
+// limit is requests per window; a negative limit allows every request.
 func NewRateLimiterWithWindow(limit int, window time.Duration) *RateLimiter {
 	rl := &RateLimiter{
 		limit:  rate.Limit(float64(limit) / window.Seconds()),
 		burst:  limit,
 		window: window,
 	}
+	if limit < 0 {
+		rl.limit, rl.burst = rate.Inf, 0
+	}
 
 	// Start cleanup goroutine
 	go rl.cleanupLoop()
@@ -108,22 +113,38 @@ func NewRateLimiterWithWindow(limit int, window time.Duration) *RateLimiter {
 // Allow checks if a request is allowed for the given key.
 // O(1) operation using token bucket algorithm.
 func (rl *RateLimiter) Allow(key string) bool {
-	now := time.Now().UnixNano()
+	allowed, _ := rl.Reserve(key)
+	return allowed
+}
+
+// Reserve checks if a request is allowed for the given key. When it is not,
+// it also returns how long until the next request would be allowed.
+func (rl *RateLimiter) Reserve(key string) (bool, time.Duration) {
+	now := time.Now()
 
 	// Load or create limiter for this key
 	entry, loaded := rl.limiters.Load(key)
 	if !loaded {
 		newEntry := &rateLimiterEntry{
 			limiter:    rate.NewLimiter(rl.limit, rl.burst),
-			lastAccess: now,
+			lastAccess: now.UnixNano(),
 		}
 		entry, _ = rl.limiters.LoadOrStore(key, newEntry)
 	}
 
 	e := entry.(*rateLimiterEntry)
-	e.lastAccess = now // Update access time (benign race, approximate is fine)
+	e.lastAccess = now.UnixNano() // Update access time (benign race, approximate is fine)
 
-	return e.limiter.Allow()
+	r := e.limiter.ReserveN(now, 1)
+	if !r.OK() {
+		return false, rl.window
+	}
+	if delay := r.DelayFrom(now); delay > 0 {
+		// Give the token back; the request is rejected, not queued
+		r.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
 }
 
 // cleanupLoop periodically removes stale entries.
@@ -149,8 +170,14 @@ func (rl *RateLimiter) cleanup() {
 	})
 }
 
-// jsonRateLimited writes a rate limited error response.
-func jsonRateLimited(w http.ResponseWriter) {
+// jsonRateLimited writes a rate limited error response with a Retry-After
+// header in whole seconds (at least 1).
+func jsonRateLimited(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int((retryAfter + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusTooManyRequests)
 	if err := json.NewEncoder(w).Encode(map[string]any{
@@ -159,7 +186,7 @@ func jsonRateLimited(w http.ResponseWriter) {
 			"message": "too many requests",
 		},
 	}); err != nil {
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 	}
 }
 
@@ -169,8 +196,8 @@ func RateLimitByIP(limiter *RateLimiter) func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ip := getClientIP(r)
 
-			if !limiter.Allow(ip) {
-				jsonRateLimited(w)
+			if allowed, retryAfter := limiter.Reserve(ip); !allowed {
+				jsonRateLimited(w, retryAfter)
 				return
 			}
 
@@ -189,8 +216,8 @@ func RateLimitByUser(limiter *RateLimiter) func(http.Handler) http.Handler {
 				userID = getClientIP(r)
 			}
 
-			if !limiter.Allow(userID) {
-				jsonRateLimited(w)
+			if allowed, retryAfter := limiter.Reserve(userID); !allowed {
+				jsonRateLimited(w, retryAfter)
 				return
 			}
 
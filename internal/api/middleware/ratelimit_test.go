@@ -0,0 +1,25 @@
+package middleware
+
+import "testing"
+
+func TestRateLimiter_Limit(t *testing.T) {
+	rl := NewRateLimiter(2)
+	if !rl.Allow("alice") || !rl.Allow("alice") {
+		t.Fatal("requests within the limit should be allowed")
+	}
+	if rl.Allow("alice") {
+		t.Error("request over the limit should be denied")
+	}
+	if !rl.Allow("bob") {
+		t.Error("limits should be per key")
+	}
+}
+
+func TestRateLimiter_Unlimited(t *testing.T) {
+	rl := NewRateLimiter(-1)
+	for i := 0; i < 100; i++ {
+		if ok, _ := rl.Reserve("alice"); !ok {
+			t.Fatalf("request %d denied by an unlimited limiter", i+1)
+		}
+	}
+}
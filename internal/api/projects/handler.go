@@ -2,7 +2,7 @@ package projects
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
@@ -40,7 +40,7 @@ func jsonError(w http.ResponseWriter, status int, code, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(errorResponse{Error: errorBody{Code: code, Message: message}}); err != nil {
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 	}
 }
 
@@ -48,7 +48,7 @@ func jsonOK(w http.ResponseWriter, data any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(dataResponse{Data: data}); err != nil {
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 	}
 }
 
@@ -56,7 +56,7 @@ func jsonCreated(w http.ResponseWriter, data any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(dataResponse{Data: data}); err != nil {
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 	}
 }
 
@@ -66,11 +66,12 @@ func jsonNoContent(w http.ResponseWriter) {
 
 // Response types
 type ProjectResponse struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description,omitempty"`
-	CreatedAt   string `json:"created_at"`
-	UpdatedAt   string `json:"updated_at"`
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	Description   string   `json:"description,omitempty"`
+	DefaultNotify []string `json:"default_notify,omitempty"`
+	CreatedAt     string   `json:"created_at"`
+	UpdatedAt     string   `json:"updated_at"`
 }
 
 type ProjectUserResponse struct {
@@ -90,13 +91,15 @@ func NewHandler(store storage.Storage) *Handler {
 
 // Request types
 type CreateRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name          string   `json:"name"`
+	Description   string   `json:"description"`
+	DefaultNotify []string `json:"default_notify"`
 }
 
 type UpdateRequest struct {
-	Name        string `json:"name,omitempty"`
-	Description string `json:"description,omitempty"`
+	Name          string   `json:"name,omitempty"`
+	Description   string   `json:"description,omitempty"`
+	DefaultNotify []string `json:"default_notify,omitempty"` // empty list clears defaults
 }
 
 type AddUserRequest struct {
@@ -120,7 +123,7 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err != nil {
-		log.Printf("list projects error: %v", err)
+		slog.Error("list projects", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -144,13 +147,17 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		jsonError(w, http.StatusBadRequest, errCodeValidationFailed, err.Error())
 		return
 	}
+	if err := ValidateNotify(req.DefaultNotify); err != nil {
+		jsonError(w, http.StatusBadRequest, errCodeValidationFailed, err.Error())
+		return
+	}
 
 	ctx := r.Context()
 
 	// Check name uniqueness
 	existing, err := h.storage.Projects().GetByName(ctx, req.Name)
 	if err != nil {
-		log.Printf("create project error: check name: %v", err)
+		slog.Error("create project: check name", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -167,14 +174,17 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
+	if len(req.DefaultNotify) > 0 {
+		project.DefaultNotify = req.DefaultNotify
+	}
 
 	if err := h.storage.Projects().Create(ctx, project); err != nil {
-		log.Printf("create project error: %v", err)
+		slog.Error("create project", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
 
-	log.Printf("project created: %s (%s)", project.Name, project.ID)
+	slog.Info("project created", "name", project.Name, "id", project.ID)
 	jsonCreated(w, projectToResponse(project))
 }
 
@@ -192,7 +202,7 @@ func (h *Handler) GetByID(w http.ResponseWriter, r *http.Request) {
 
 	access, err := middleware.GetProjectAccess(ctx, userID, role, h.storage)
 	if err != nil {
-		log.Printf("get project error: get access: %v", err)
+		slog.Error("get project: get access", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -203,7 +213,7 @@ func (h *Handler) GetByID(w http.ResponseWriter, r *http.Request) {
 
 	project, err := h.storage.Projects().GetByID(ctx, id)
 	if err != nil {
-		log.Printf("get project error: %v", err)
+		slog.Error("get project", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -232,7 +242,7 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	project, err := h.storage.Projects().GetByID(ctx, id)
 	if err != nil {
-		log.Printf("update project error: get: %v", err)
+		slog.Error("update project: get", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -249,7 +259,7 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		// Check uniqueness
 		existing, err := h.storage.Projects().GetByName(ctx, req.Name)
 		if err != nil {
-			log.Printf("update project error: check name: %v", err)
+			slog.Error("update project: check name", "error", err)
 			jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 			return
 		}
@@ -262,16 +272,26 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	if req.Description != "" {
 		project.Description = strings.TrimSpace(req.Description)
 	}
+	if req.DefaultNotify != nil {
+		if err := ValidateNotify(req.DefaultNotify); err != nil {
+			jsonError(w, http.StatusBadRequest, errCodeValidationFailed, err.Error())
+			return
+		}
+		project.DefaultNotify = req.DefaultNotify
+		if len(project.DefaultNotify) == 0 {
+			project.DefaultNotify = nil
+		}
+	}
 
 	project.UpdatedAt = time.Now()
 
 	if err := h.storage.Projects().Update(ctx, project); err != nil {
-		log.Printf("update project error: %v", err)
+		slog.Error("update project", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
 
-	log.Printf("project updated: %s (%s)", project.Name, project.ID)
+	slog.Info("project updated", "name", project.Name, "id", project.ID)
 	jsonOK(w, projectToResponse(project))
 }
 
@@ -286,7 +306,7 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	project, err := h.storage.Projects().GetByID(ctx, id)
 	if err != nil {
-		log.Printf("delete project error: get: %v", err)
+		slog.Error("delete project: get", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -296,12 +316,12 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.storage.Projects().Delete(ctx, id); err != nil {
-		log.Printf("delete project error: %v", err)
+		slog.Error("delete project", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
 
-	log.Printf("project deleted: %s (%s)", project.Name, project.ID)
+	slog.Info("project deleted", "name", project.Name, "id", project.ID)
 	jsonNoContent(w)
 }
 
@@ -319,7 +339,7 @@ func (h *Handler) GetUsers(w http.ResponseWriter, r *http.Request) {
 
 	access, err := middleware.GetProjectAccess(ctx, userID, role, h.storage)
 	if err != nil {
-		log.Printf("get project users error: get access: %v", err)
+		slog.Error("get project users: get access", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -330,7 +350,7 @@ func (h *Handler) GetUsers(w http.ResponseWriter, r *http.Request) {
 
 	project, err := h.storage.Projects().GetByID(ctx, id)
 	if err != nil {
-		log.Printf("get project users error: get project: %v", err)
+		slog.Error("get project users: get project", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -341,7 +361,7 @@ func (h *Handler) GetUsers(w http.ResponseWriter, r *http.Request) {
 
 	members, err := h.storage.Projects().GetProjectMembers(ctx, id)
 	if err != nil {
-		log.Printf("get project users error: %v", err)
+		slog.Error("get project users", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -392,7 +412,7 @@ func (h *Handler) AddUser(w http.ResponseWriter, r *http.Request) {
 	// Verify project exists
 	project, err := h.storage.Projects().GetByID(ctx, id)
 	if err != nil {
-		log.Printf("add user to project error: get project: %v", err)
+		slog.Error("add user to project: get project", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -404,7 +424,7 @@ func (h *Handler) AddUser(w http.ResponseWriter, r *http.Request) {
 	// Verify user exists
 	user, err := h.storage.Users().GetByID(ctx, req.UserID)
 	if err != nil {
-		log.Printf("add user to project error: get user: %v", err)
+		slog.Error("add user to project: get user", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -414,12 +434,12 @@ func (h *Handler) AddUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.storage.Projects().AddUser(ctx, id, req.UserID, role); err != nil {
-		log.Printf("add user to project error: %v", err)
+		slog.Error("add user to project", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
 
-	log.Printf("user %s added to project %s with role %s", req.UserID, id, role)
+	slog.Info("project member added", "user", req.UserID, "project", id, "role", role)
 	jsonNoContent(w)
 }
 
@@ -437,7 +457,7 @@ func (h *Handler) RemoveUser(w http.ResponseWriter, r *http.Request) {
 	// Verify project exists
 	project, err := h.storage.Projects().GetByID(ctx, projectID)
 	if err != nil {
-		log.Printf("remove user from project error: get project: %v", err)
+		slog.Error("remove user from project: get project", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -447,21 +467,22 @@ func (h *Handler) RemoveUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.storage.Projects().RemoveUser(ctx, projectID, userID); err != nil {
-		log.Printf("remove user from project error: %v", err)
+		slog.Error("remove user from project", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
 
-	log.Printf("user %s removed from project %s", userID, projectID)
+	slog.Info("project member removed", "user", userID, "project", projectID)
 	jsonNoContent(w)
 }
 
 func projectToResponse(p *models.Project) *ProjectResponse {
 	return &ProjectResponse{
-		ID:          p.ID,
-		Name:        p.Name,
-		Description: p.Description,
-		CreatedAt:   p.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:   p.UpdatedAt.Format(time.RFC3339),
+		ID:            p.ID,
+		Name:          p.Name,
+		Description:   p.Description,
+		DefaultNotify: p.DefaultNotify,
+		CreatedAt:     p.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:     p.UpdatedAt.Format(time.RFC3339),
 	}
 }
@@ -15,3 +15,19 @@ func ValidateName(name string) error {
 	}
 	return nil
 }
+
+// maxNotifyChannels bounds the number of default notification channels.
+const maxNotifyChannels = 20
+
+// ValidateNotify checks a project's default notification channels.
+func ValidateNotify(channels []string) error {
+	if len(channels) > maxNotifyChannels {
+		return errors.New("default_notify must have 20 channels or less")
+	}
+	for _, c := range channels {
+		if strings.TrimSpace(c) == "" {
+			return errors.New("default_notify channels must not be empty")
+		}
+	}
+	return nil
+}
@@ -2,7 +2,7 @@ package api
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 )
 
@@ -20,7 +20,7 @@ func JSON(w http.ResponseWriter, status int, data any) {
 	resp := Response{Data: data}
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		// Log but don't attempt to write - headers already sent
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 	}
 }
 
@@ -31,7 +31,7 @@ func JSONError(w http.ResponseWriter, err *Error) {
 
 	resp := Response{Error: err}
 	if encErr := json.NewEncoder(w).Encode(resp); encErr != nil {
-		log.Printf("json encode error: %v", encErr)
+		slog.Error("json encode", "error", encErr)
 	}
 }
 
@@ -1,17 +1,20 @@
 package api
 
 import (
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 
+	"github.com/good-yellow-bee/blazelog/internal/api/agents"
 	"github.com/good-yellow-bee/blazelog/internal/api/alerts"
 	"github.com/good-yellow-bee/blazelog/internal/api/auth"
 	"github.com/good-yellow-bee/blazelog/internal/api/connections"
 	"github.com/good-yellow-bee/blazelog/internal/api/logs"
 	"github.com/good-yellow-bee/blazelog/internal/api/middleware"
 	"github.com/good-yellow-bee/blazelog/internal/api/projects"
+	"github.com/good-yellow-bee/blazelog/internal/api/searches"
+	"github.com/good-yellow-bee/blazelog/internal/api/share"
 	"github.com/good-yellow-bee/blazelog/internal/api/users"
 	"github.com/good-yellow-bee/blazelog/internal/models"
 	"github.com/good-yellow-bee/blazelog/internal/web"
@@ -25,12 +28,12 @@ func (s *Server) setupRouter() *chi.Mux {
 	if len(s.config.TrustedProxies) > 0 {
 		if err := middleware.SetTrustedProxies(s.config.TrustedProxies); err != nil {
 			// Log warning but continue - will fall back to direct IP
-			log.Printf("warning: failed to configure trusted proxies: %v", err)
+			slog.Warn("failed to configure trusted proxies", "error", err)
 		}
 	}
 
 	// Create JWT service
-	jwtService := auth.NewJWTService(s.config.JWTSecret, s.config.AccessTokenTTL)
+	jwtService := auth.NewJWTServiceWithPrevious(s.config.JWTSecret, s.config.JWTPreviousSecrets, s.config.AccessTokenTTL)
 
 	// Create lockout tracker
 	lockoutTracker := auth.NewLockoutTracker(s.config.LockoutThreshold, s.config.LockoutDuration)
@@ -38,6 +41,8 @@ func (s *Server) setupRouter() *chi.Mux {
 	// Create rate limiters
 	ipLimiter := middleware.NewRateLimiterWithWindow(s.config.RateLimitPerIP, 15*time.Minute)
 	userLimiter := middleware.NewRateLimiter(s.config.RateLimitPerUser)
+	shareLimiter := middleware.NewRateLimiter(s.config.ShareRateLimit)
+	rawTailLimiter := middleware.NewRateLimiter(s.config.RawTailRateLimit)
 
 	// Global middleware
 	r.Use(middleware.PrometheusMiddleware)
@@ -59,6 +64,7 @@ func (s *Server) setupRouter() *chi.Mux {
 			// Public routes with IP rate limiting
 			r.Group(func(r chi.Router) {
 				r.Use(middleware.RateLimitByIP(ipLimiter))
+				r.Use(middleware.RateLimitByEndpoint(s.endpoints))
 				r.Post("/login", authHandler.Login)
 				r.Post("/refresh", authHandler.Refresh)
 			})
@@ -77,6 +83,7 @@ func (s *Server) setupRouter() *chi.Mux {
 		r.Route("/users", func(r chi.Router) {
 			r.Use(hybridAuth)
 			r.Use(middleware.RateLimitByUser(userLimiter))
+			r.Use(middleware.RateLimitByEndpoint(s.endpoints))
 
 			userHandler := users.NewHandler(s.storage, s.sessions)
 
@@ -110,34 +117,85 @@ func (s *Server) setupRouter() *chi.Mux {
 		r.Route("/logs", func(r chi.Router) {
 			r.Use(hybridAuth)
 			r.Use(middleware.RateLimitByUser(userLimiter))
+			r.Use(middleware.RateLimitByEndpoint(s.endpoints))
 
 			logsHandler := logs.NewHandlerWithStorageAndConfig(s.logStorage, s.storage, logs.HandlerConfig{
 				MaxQueryRange:      s.config.MaxQueryRange,
 				QueryTimeout:       s.config.QueryTimeout,
 				StreamMaxDuration:  s.config.StreamMaxDuration,
 				StreamPollInterval: s.config.StreamPollInterval,
+				IndexedLabels:      s.config.IndexedLabels,
+				MaxResultRows:      s.config.MaxResultRows,
+
+				StreamReorderWindow: s.config.StreamReorderWindow,
 			})
 
 			r.Get("/", logsHandler.Query)
 			r.Get("/stats", logsHandler.Stats)
+			r.Get("/signals", logsHandler.Signals)
+			r.Get("/schema", logsHandler.Schema)
 			r.Get("/stream", logsHandler.Stream)
+			r.Get("/ws", logsHandler.WebSocket)
+			r.Get("/export", logsHandler.Export)
+			r.Get("/{id}", logsHandler.Get)
 			r.Get("/{id}/context", logsHandler.Context)
 		})
 
+		// Shared filter routes (protected - any authenticated user can share/resolve)
+		r.Route("/share", func(r chi.Router) {
+			r.Use(hybridAuth)
+			r.Use(middleware.RateLimitByUser(userLimiter))
+			r.Use(middleware.RateLimitByEndpoint(s.endpoints))
+
+			shareHandler := share.NewHandler(s.storage, s.config.ShareLinkTTL, s.config.MaxQueryRange)
+
+			// Creation has its own, tighter limit
+			r.With(middleware.RateLimitByUser(shareLimiter)).Post("/", shareHandler.Create)
+			r.Get("/{code}", shareHandler.Resolve)
+		})
+
+		// Saved search routes (protected - each user manages their own)
+		r.Route("/searches", func(r chi.Router) {
+			r.Use(hybridAuth)
+			r.Use(middleware.RateLimitByUser(userLimiter))
+			r.Use(middleware.RateLimitByEndpoint(s.endpoints))
+
+			searchesHandler := searches.NewHandler(s.storage, s.config.MaxQueryRange)
+
+			r.Get("/", searchesHandler.List)
+			r.Post("/", searchesHandler.Create)
+			r.Route("/{id}", func(r chi.Router) {
+				r.Get("/", searchesHandler.GetByID)
+				r.Put("/", searchesHandler.Update)
+				r.Delete("/", searchesHandler.Delete)
+			})
+		})
+
 		// Alert routes (protected)
 		r.Route("/alerts", func(r chi.Router) {
 			r.Use(hybridAuth)
 			r.Use(middleware.RateLimitByUser(userLimiter))
+			r.Use(middleware.RateLimitByEndpoint(s.endpoints))
 
 			alertsHandler := alerts.NewHandler(s.storage)
+			if s.config.Notifier != nil {
+				alertsHandler.SetDispatcher(s.config.Notifier)
+			}
+			if s.logStorage != nil {
+				alertsHandler.SetLogStorage(s.logStorage)
+			}
 
 			r.Get("/", alertsHandler.List)
 			r.Get("/history", alertsHandler.History)
 
+			// Admin only can re-send past notifications
+			r.With(middleware.RequireRole(models.RoleAdmin)).Post("/history/replay", alertsHandler.Replay)
+
 			// Admin/Operator can create
 			r.Group(func(r chi.Router) {
 				r.Use(middleware.RequireRole(models.RoleAdmin, models.RoleOperator))
 				r.Post("/", alertsHandler.Create)
+				r.Post("/test", alertsHandler.Test)
 			})
 
 			r.Route("/{id}", func(r chi.Router) {
@@ -161,6 +219,7 @@ func (s *Server) setupRouter() *chi.Mux {
 		r.Route("/projects", func(r chi.Router) {
 			r.Use(hybridAuth)
 			r.Use(middleware.RateLimitByUser(userLimiter))
+			r.Use(middleware.RateLimitByEndpoint(s.endpoints))
 
 			projectsHandler := projects.NewHandler(s.storage)
 
@@ -191,6 +250,7 @@ func (s *Server) setupRouter() *chi.Mux {
 		r.Route("/connections", func(r chi.Router) {
 			r.Use(hybridAuth)
 			r.Use(middleware.RateLimitByUser(userLimiter))
+			r.Use(middleware.RateLimitByEndpoint(s.endpoints))
 
 			connectionsHandler := connections.NewHandler(s.storage)
 
@@ -214,6 +274,24 @@ func (s *Server) setupRouter() *chi.Mux {
 				})
 			})
 		})
+
+		// Agent routes (admin only, opt-in)
+		if s.config.RawTailer != nil {
+			r.Route("/agents", func(r chi.Router) {
+				r.Use(hybridAuth)
+				r.Use(middleware.RequireRole(models.RoleAdmin))
+				r.Use(middleware.RateLimitByUser(userLimiter))
+				r.Use(middleware.RateLimitByEndpoint(s.endpoints))
+
+				agentsHandler := agents.NewHandler(s.config.RawTailer)
+				if s.config.RawTailAudit != nil {
+					agentsHandler.SetAuditLogger(s.config.RawTailAudit)
+				}
+
+				// Raw bytes bypass redaction; keep them scarce
+				r.With(middleware.RateLimitByUser(rawTailLimiter)).Get("/{id}/sources/{source}/raw", agentsHandler.RawTail)
+			})
+		}
 	})
 
 	// Health check endpoints (public, no rate limit)
@@ -225,6 +303,7 @@ func (s *Server) setupRouter() *chi.Mux {
 	// Share the session store with the web server so sessions work across both
 	if s.config.WebUIEnabled && s.config.CSRFSecret != "" {
 		webServer := web.NewServerWithSessions(s.storage, s.logStorage, s.config.CSRFSecret, s.config.TrustedOrigins, s.sessions, s.config.UseSecureCookies)
+		webServer.Handler().SetMaxResultRows(s.config.MaxResultRows)
 		r.Mount("/", webServer.Routes())
 	}
 
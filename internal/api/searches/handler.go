@@ -0,0 +1,400 @@
+// Package searches provides HTTP handlers for users' saved log searches.
+package searches
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/good-yellow-bee/blazelog/internal/api/middleware"
+	"github.com/good-yellow-bee/blazelog/internal/models"
+	"github.com/good-yellow-bee/blazelog/internal/storage"
+)
+
+// Response helpers (same pattern as projects)
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+type errorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+type dataResponse struct {
+	Data any `json:"data"`
+}
+
+const (
+	errCodeBadRequest       = "BAD_REQUEST"
+	errCodeValidationFailed = "VALIDATION_FAILED"
+	errCodeNotFound         = "NOT_FOUND"
+	errCodeConflict         = "CONFLICT"
+	errCodeForbidden        = "FORBIDDEN"
+	errCodeInternalError    = "INTERNAL_ERROR"
+)
+
+const (
+	maxFilterLength      = 1000
+	maxSearchesPerUser   = 100
+	defaultMaxQueryRange = 24 * time.Hour
+)
+
+func jsonError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(errorResponse{Error: errorBody{Code: code, Message: message}}); err != nil {
+		slog.Error("json encode", "error", err)
+	}
+}
+
+func jsonOK(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(dataResponse{Data: data}); err != nil {
+		slog.Error("json encode", "error", err)
+	}
+}
+
+func jsonCreated(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(dataResponse{Data: data}); err != nil {
+		slog.Error("json encode", "error", err)
+	}
+}
+
+func jsonNoContent(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Handler handles saved search endpoints. Searches belong to the user who
+// saved them: other users, admins included, don't see them.
+type Handler struct {
+	storage       storage.Storage
+	maxQueryRange time.Duration
+}
+
+// NewHandler creates a saved search handler. maxQueryRange caps the default
+// range a search may carry so that running it stays within query limits.
+func NewHandler(store storage.Storage, maxQueryRange time.Duration) *Handler {
+	if maxQueryRange <= 0 {
+		maxQueryRange = defaultMaxQueryRange
+	}
+	return &Handler{storage: store, maxQueryRange: maxQueryRange}
+}
+
+// SavedSearchResponse describes a saved search.
+type SavedSearchResponse struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Filter     string `json:"filter,omitempty"`
+	Range      string `json:"range"`
+	SearchMode string `json:"search_mode,omitempty"`
+	ProjectID  string `json:"project_id,omitempty"`
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
+}
+
+// Request types
+type CreateRequest struct {
+	Name       string `json:"name"`
+	Filter     string `json:"filter"`
+	Range      string `json:"range"`
+	SearchMode string `json:"search_mode"`
+	ProjectID  string `json:"project_id"`
+}
+
+type UpdateRequest struct {
+	Name       string  `json:"name,omitempty"`
+	Filter     *string `json:"filter,omitempty"` // "" clears the filter
+	Range      string  `json:"range,omitempty"`
+	SearchMode *string `json:"search_mode,omitempty"`
+	ProjectID  *string `json:"project_id,omitempty"` // "" clears the project
+}
+
+// List returns the caller's saved searches, optionally for one project.
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	projectID := r.URL.Query().Get("project_id")
+
+	access, ok := h.projectAccess(w, r)
+	if !ok {
+		return
+	}
+	if projectID != "" && !access.CanAccessProject(projectID) {
+		jsonError(w, http.StatusForbidden, errCodeForbidden, "no access to project")
+		return
+	}
+
+	searches, err := h.storage.SavedSearches().ListByOwner(ctx, middleware.GetUserID(ctx))
+	if err != nil {
+		slog.Error("list saved searches", "error", err)
+		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
+		return
+	}
+
+	resp := make([]*SavedSearchResponse, 0, len(searches))
+	for _, s := range searches {
+		if projectID != "" && s.ProjectID != projectID {
+			continue
+		}
+		// Searches of projects the user has since left are kept but hidden
+		if s.ProjectID != "" && !access.CanAccessProject(s.ProjectID) {
+			continue
+		}
+		resp = append(resp, savedSearchToResponse(s))
+	}
+	jsonOK(w, resp)
+}
+
+// Create saves a new search for the caller.
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, http.StatusBadRequest, errCodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := ValidateName(req.Name); err != nil {
+		jsonError(w, http.StatusBadRequest, errCodeValidationFailed, err.Error())
+		return
+	}
+	if err := ValidateFilter(req.Filter); err != nil {
+		jsonError(w, http.StatusBadRequest, errCodeValidationFailed, err.Error())
+		return
+	}
+	rng, err := ValidateRange(req.Range, h.maxQueryRange)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, errCodeValidationFailed, err.Error())
+		return
+	}
+	searchMode, err := ValidateSearchMode(req.SearchMode)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, errCodeValidationFailed, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+	if req.ProjectID != "" && !h.checkProject(w, r, req.ProjectID) {
+		return
+	}
+
+	existing, err := h.storage.SavedSearches().ListByOwner(ctx, userID)
+	if err != nil {
+		slog.Error("create saved search: list", "error", err)
+		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
+		return
+	}
+	if len(existing) >= maxSearchesPerUser {
+		jsonError(w, http.StatusBadRequest, errCodeValidationFailed, "saved search limit reached; delete some first")
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	for _, s := range existing {
+		if s.Name == name {
+			jsonError(w, http.StatusConflict, errCodeConflict, "saved search name already exists")
+			return
+		}
+	}
+
+	search := models.NewSavedSearch(name, userID)
+	search.ID = uuid.New().String()
+	search.Filter = req.Filter
+	search.Range = rng
+	search.SearchMode = searchMode
+	search.ProjectID = req.ProjectID
+
+	if err := h.storage.SavedSearches().Create(ctx, search); err != nil {
+		slog.Error("create saved search", "error", err)
+		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
+		return
+	}
+
+	jsonCreated(w, savedSearchToResponse(search))
+}
+
+// GetByID returns one of the caller's saved searches.
+func (h *Handler) GetByID(w http.ResponseWriter, r *http.Request) {
+	search, ok := h.ownSearch(w, r)
+	if !ok {
+		return
+	}
+	jsonOK(w, savedSearchToResponse(search))
+}
+
+// Update changes one of the caller's saved searches.
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	var req UpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, http.StatusBadRequest, errCodeBadRequest, "invalid request body")
+		return
+	}
+
+	search, ok := h.ownSearch(w, r)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	if req.Name != "" {
+		if err := ValidateName(req.Name); err != nil {
+			jsonError(w, http.StatusBadRequest, errCodeValidationFailed, err.Error())
+			return
+		}
+		name := strings.TrimSpace(req.Name)
+		existing, err := h.storage.SavedSearches().GetByName(ctx, search.OwnerID, name)
+		if err != nil {
+			slog.Error("update saved search: check name", "error", err)
+			jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
+			return
+		}
+		if existing != nil && existing.ID != search.ID {
+			jsonError(w, http.StatusConflict, errCodeConflict, "saved search name already exists")
+			return
+		}
+		search.Name = name
+	}
+	if req.Filter != nil {
+		if err := ValidateFilter(*req.Filter); err != nil {
+			jsonError(w, http.StatusBadRequest, errCodeValidationFailed, err.Error())
+			return
+		}
+		search.Filter = *req.Filter
+	}
+	if req.Range != "" {
+		rng, err := ValidateRange(req.Range, h.maxQueryRange)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, errCodeValidationFailed, err.Error())
+			return
+		}
+		search.Range = rng
+	}
+	if req.SearchMode != nil {
+		mode, err := ValidateSearchMode(*req.SearchMode)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, errCodeValidationFailed, err.Error())
+			return
+		}
+		search.SearchMode = mode
+	}
+	if req.ProjectID != nil {
+		if *req.ProjectID != "" && !h.checkProject(w, r, *req.ProjectID) {
+			return
+		}
+		search.ProjectID = *req.ProjectID
+	}
+
+	search.UpdatedAt = time.Now()
+	if err := h.storage.SavedSearches().Update(ctx, search); err != nil {
+		slog.Error("update saved search", "error", err)
+		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
+		return
+	}
+
+	jsonOK(w, savedSearchToResponse(search))
+}
+
+// Delete deletes one of the caller's saved searches.
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	search, ok := h.ownSearch(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.storage.SavedSearches().Delete(r.Context(), search.ID); err != nil {
+		slog.Error("delete saved search", "error", err)
+		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
+		return
+	}
+
+	jsonNoContent(w)
+}
+
+// ownSearch loads the search named by the URL's id. Other users' searches
+// are indistinguishable from unknown ones, as are searches of projects
+// the caller can no longer access.
+func (h *Handler) ownSearch(w http.ResponseWriter, r *http.Request) (*models.SavedSearch, bool) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		jsonError(w, http.StatusBadRequest, errCodeBadRequest, "saved search id required")
+		return nil, false
+	}
+
+	ctx := r.Context()
+	search, err := h.storage.SavedSearches().GetByID(ctx, id)
+	if err != nil {
+		slog.Error("get saved search", "error", err)
+		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
+		return nil, false
+	}
+	if search == nil || search.OwnerID != middleware.GetUserID(ctx) {
+		jsonError(w, http.StatusNotFound, errCodeNotFound, "saved search not found")
+		return nil, false
+	}
+
+	if search.ProjectID != "" {
+		access, ok := h.projectAccess(w, r)
+		if !ok {
+			return nil, false
+		}
+		if !access.CanAccessProject(search.ProjectID) {
+			jsonError(w, http.StatusNotFound, errCodeNotFound, "saved search not found")
+			return nil, false
+		}
+	}
+	return search, true
+}
+
+// checkProject reports whether the caller can save searches for projectID,
+// an existing project they have access to.
+func (h *Handler) checkProject(w http.ResponseWriter, r *http.Request, projectID string) bool {
+	access, ok := h.projectAccess(w, r)
+	if !ok {
+		return false
+	}
+	if !access.CanAccessProject(projectID) {
+		jsonError(w, http.StatusForbidden, errCodeForbidden, "no access to project")
+		return false
+	}
+
+	project, err := h.storage.Projects().GetByID(r.Context(), projectID)
+	if err != nil {
+		slog.Error("saved search: check project", "error", err)
+		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
+		return false
+	}
+	if project == nil {
+		jsonError(w, http.StatusBadRequest, errCodeValidationFailed, "project does not exist")
+		return false
+	}
+	return true
+}
+
+func (h *Handler) projectAccess(w http.ResponseWriter, r *http.Request) (*middleware.ProjectAccess, bool) {
+	ctx := r.Context()
+	access, err := middleware.GetProjectAccess(ctx, middleware.GetUserID(ctx), middleware.GetRole(ctx), h.storage)
+	if err != nil {
+		slog.Error("saved search: get access", "error", err)
+		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
+		return nil, false
+	}
+	return access, true
+}
+
+func savedSearchToResponse(s *models.SavedSearch) *SavedSearchResponse {
+	return &SavedSearchResponse{
+		ID:         s.ID,
+		Name:       s.Name,
+		Filter:     s.Filter,
+		Range:      s.Range.String(),
+		SearchMode: s.SearchMode,
+		ProjectID:  s.ProjectID,
+		CreatedAt:  s.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  s.UpdatedAt.Format(time.RFC3339),
+	}
+}
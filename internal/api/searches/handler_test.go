@@ -0,0 +1,399 @@
+package searches
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/good-yellow-bee/blazelog/internal/api/middleware"
+	"github.com/good-yellow-bee/blazelog/internal/models"
+	"github.com/good-yellow-bee/blazelog/internal/storage"
+)
+
+type mockSavedSearchRepository struct {
+	searches map[string]*models.SavedSearch
+}
+
+func (m *mockSavedSearchRepository) Create(ctx context.Context, s *models.SavedSearch) error {
+	m.searches[s.ID] = s
+	return nil
+}
+
+func (m *mockSavedSearchRepository) GetByID(ctx context.Context, id string) (*models.SavedSearch, error) {
+	return m.searches[id], nil
+}
+
+func (m *mockSavedSearchRepository) GetByName(ctx context.Context, ownerID, name string) (*models.SavedSearch, error) {
+	for _, s := range m.searches {
+		if s.OwnerID == ownerID && s.Name == name {
+			return s, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockSavedSearchRepository) Update(ctx context.Context, s *models.SavedSearch) error {
+	m.searches[s.ID] = s
+	return nil
+}
+
+func (m *mockSavedSearchRepository) Delete(ctx context.Context, id string) error {
+	delete(m.searches, id)
+	return nil
+}
+
+func (m *mockSavedSearchRepository) ListByOwner(ctx context.Context, ownerID string) ([]*models.SavedSearch, error) {
+	var searches []*models.SavedSearch
+	for _, s := range m.searches {
+		if s.OwnerID == ownerID {
+			searches = append(searches, s)
+		}
+	}
+	return searches, nil
+}
+
+// mockProjectRepository knows the projects and each user's memberships.
+type mockProjectRepository struct {
+	storage.ProjectRepository
+	projects map[string]*models.Project
+	members  map[string][]string // user ID -> project IDs
+}
+
+func (m *mockProjectRepository) GetByID(ctx context.Context, id string) (*models.Project, error) {
+	return m.projects[id], nil
+}
+
+func (m *mockProjectRepository) GetProjectsForUser(ctx context.Context, userID string) ([]*models.Project, error) {
+	var projects []*models.Project
+	for _, id := range m.members[userID] {
+		projects = append(projects, m.projects[id])
+	}
+	return projects, nil
+}
+
+type mockStorage struct {
+	searchRepo  *mockSavedSearchRepository
+	projectRepo *mockProjectRepository
+}
+
+func (m *mockStorage) Open() error                                   { return nil }
+func (m *mockStorage) Close() error                                  { return nil }
+func (m *mockStorage) Migrate() error                                { return nil }
+func (m *mockStorage) EnsureAdminUser() error                        { return nil }
+func (m *mockStorage) Users() storage.UserRepository                 { return nil }
+func (m *mockStorage) Projects() storage.ProjectRepository           { return m.projectRepo }
+func (m *mockStorage) Alerts() storage.AlertRepository               { return nil }
+func (m *mockStorage) Connections() storage.ConnectionRepository     { return nil }
+func (m *mockStorage) Tokens() storage.TokenRepository               { return nil }
+func (m *mockStorage) AlertHistory() storage.AlertHistoryRepository  { return nil }
+func (m *mockStorage) SharedFilters() storage.SharedFilterRepository { return nil }
+func (m *mockStorage) SavedSearches() storage.SavedSearchRepository  { return m.searchRepo }
+
+func newMockStorage() (*mockStorage, *mockSavedSearchRepository) {
+	repo := &mockSavedSearchRepository{searches: make(map[string]*models.SavedSearch)}
+	projects := &mockProjectRepository{
+		projects: map[string]*models.Project{
+			"proj-1": {ID: "proj-1", Name: "Shop"},
+			"proj-2": {ID: "proj-2", Name: "Blog"},
+		},
+		members: map[string][]string{"viewer": {"proj-1"}},
+	}
+	return &mockStorage{searchRepo: repo, projectRepo: projects}, repo
+}
+
+func withUser(r *http.Request, userID string, role models.Role) *http.Request {
+	ctx := middleware.WithUserContext(r.Context(), userID, userID, role)
+	return r.WithContext(ctx)
+}
+
+func withID(r *http.Request, id string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+func addSearch(repo *mockSavedSearchRepository, id, owner, projectID string) *models.SavedSearch {
+	s := models.NewSavedSearch("search "+id, owner)
+	s.ID = id
+	s.Filter = `level == "error"`
+	s.Range = time.Hour
+	s.ProjectID = projectID
+	repo.searches[id] = s
+	return s
+}
+
+func TestCreate_Success(t *testing.T) {
+	mockStore, repo := newMockStorage()
+	handler := NewHandler(mockStore, 24*time.Hour)
+
+	body := `{"name": " 5xx on shop ", "filter": "http_status >= 500", "range": "1h", "search_mode": "Phrase", "project_id": "proj-1"}`
+	req := withUser(httptest.NewRequest("POST", "/api/v1/searches", strings.NewReader(body)), "viewer", models.RoleViewer)
+	rec := httptest.NewRecorder()
+
+	handler.Create(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d; body: %s", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	var resp struct {
+		Data *SavedSearchResponse `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	stored := repo.searches[resp.Data.ID]
+	if stored == nil {
+		t.Fatal("saved search not stored")
+	}
+	if stored.Name != "5xx on shop" || stored.OwnerID != "viewer" || stored.Range != time.Hour ||
+		stored.SearchMode != "phrase" || stored.ProjectID != "proj-1" {
+		t.Errorf("stored = %+v", stored)
+	}
+	if resp.Data.Range != "1h0m0s" || resp.Data.Filter != "http_status >= 500" {
+		t.Errorf("response = %+v", resp.Data)
+	}
+}
+
+func TestCreate_Validation(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"invalid body", `{`, http.StatusBadRequest},
+		{"missing name", `{"range": "1h"}`, http.StatusBadRequest},
+		{"name too long", `{"name": "` + strings.Repeat("a", 101) + `", "range": "1h"}`, http.StatusBadRequest},
+		{"missing range", `{"name": "x"}`, http.StatusBadRequest},
+		{"invalid range", `{"name": "x", "range": "soon"}`, http.StatusBadRequest},
+		{"range too large", `{"name": "x", "range": "48h"}`, http.StatusBadRequest},
+		{"invalid filter", `{"name": "x", "range": "1h", "filter": "level =="}`, http.StatusBadRequest},
+		{"filter too long", `{"name": "x", "range": "1h", "filter": "` + strings.Repeat("a", maxFilterLength+1) + `"}`, http.StatusBadRequest},
+		{"invalid search mode", `{"name": "x", "range": "1h", "search_mode": "fuzzy"}`, http.StatusBadRequest},
+		{"unknown project", `{"name": "x", "range": "1h", "project_id": "proj-9"}`, http.StatusForbidden},
+		{"project without access", `{"name": "x", "range": "1h", "project_id": "proj-2"}`, http.StatusForbidden},
+		{"duplicate name", `{"name": "search s1", "range": "1h"}`, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore, repo := newMockStorage()
+			addSearch(repo, "s1", "viewer", "")
+			handler := NewHandler(mockStore, 24*time.Hour)
+			req := withUser(httptest.NewRequest("POST", "/api/v1/searches", strings.NewReader(tt.body)), "viewer", models.RoleViewer)
+			rec := httptest.NewRecorder()
+
+			handler.Create(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if len(repo.searches) != 1 {
+				t.Errorf("searches = %d, want only the existing one", len(repo.searches))
+			}
+		})
+	}
+
+	t.Run("admin and missing project", func(t *testing.T) {
+		mockStore, _ := newMockStorage()
+		handler := NewHandler(mockStore, 24*time.Hour)
+		body := `{"name": "x", "range": "1h", "project_id": "proj-9"}`
+		req := withUser(httptest.NewRequest("POST", "/api/v1/searches", strings.NewReader(body)), "admin", models.RoleAdmin)
+		rec := httptest.NewRecorder()
+
+		handler.Create(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400", rec.Code)
+		}
+	})
+
+	t.Run("limit per user", func(t *testing.T) {
+		mockStore, repo := newMockStorage()
+		for i := 0; i < maxSearchesPerUser; i++ {
+			addSearch(repo, fmt.Sprintf("s%d", i), "viewer", "")
+		}
+		handler := NewHandler(mockStore, 24*time.Hour)
+		req := withUser(httptest.NewRequest("POST", "/api/v1/searches", strings.NewReader(`{"name": "one more", "range": "1h"}`)), "viewer", models.RoleViewer)
+		rec := httptest.NewRecorder()
+
+		handler.Create(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400", rec.Code)
+		}
+	})
+}
+
+func TestList(t *testing.T) {
+	mockStore, repo := newMockStorage()
+	addSearch(repo, "mine", "viewer", "")
+	addSearch(repo, "mine-shop", "viewer", "proj-1")
+	addSearch(repo, "mine-left", "viewer", "proj-2") // project the viewer has left
+	addSearch(repo, "theirs", "admin", "")
+	handler := NewHandler(mockStore, 24*time.Hour)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantCount  int
+	}{
+		{"all of the caller's", "", http.StatusOK, 2},
+		{"one project", "?project_id=proj-1", http.StatusOK, 1},
+		{"project without access", "?project_id=proj-2", http.StatusForbidden, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := withUser(httptest.NewRequest("GET", "/api/v1/searches"+tt.query, nil), "viewer", models.RoleViewer)
+			rec := httptest.NewRecorder()
+
+			handler.List(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp struct {
+				Data []*SavedSearchResponse `json:"data"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if len(resp.Data) != tt.wantCount {
+				t.Errorf("searches = %d, want %d", len(resp.Data), tt.wantCount)
+			}
+			for _, s := range resp.Data {
+				if !strings.HasPrefix(s.ID, "mine") {
+					t.Errorf("listed %s", s.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestGetByID(t *testing.T) {
+	mockStore, repo := newMockStorage()
+	addSearch(repo, "mine", "viewer", "proj-1")
+	addSearch(repo, "mine-left", "viewer", "proj-2")
+	addSearch(repo, "theirs", "other", "")
+	handler := NewHandler(mockStore, 24*time.Hour)
+
+	tests := []struct {
+		id         string
+		userID     string
+		role       models.Role
+		wantStatus int
+	}{
+		{"mine", "viewer", models.RoleViewer, http.StatusOK},
+		{"missing", "viewer", models.RoleViewer, http.StatusNotFound},
+		{"theirs", "viewer", models.RoleViewer, http.StatusNotFound},
+		{"theirs", "admin", models.RoleAdmin, http.StatusNotFound},
+		{"mine-left", "viewer", models.RoleViewer, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id+" as "+tt.userID, func(t *testing.T) {
+			req := withID(withUser(httptest.NewRequest("GET", "/api/v1/searches/"+tt.id, nil), tt.userID, tt.role), tt.id)
+			rec := httptest.NewRecorder()
+
+			handler.GetByID(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Run("changes the given fields", func(t *testing.T) {
+		mockStore, repo := newMockStorage()
+		search := addSearch(repo, "mine", "viewer", "proj-1")
+		handler := NewHandler(mockStore, 24*time.Hour)
+
+		body := `{"name": "renamed", "filter": "", "range": "15m", "project_id": ""}`
+		req := withID(withUser(httptest.NewRequest("PUT", "/api/v1/searches/mine", strings.NewReader(body)), "viewer", models.RoleViewer), "mine")
+		rec := httptest.NewRecorder()
+
+		handler.Update(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200; body: %s", rec.Code, rec.Body.String())
+		}
+		if search.Name != "renamed" || search.Filter != "" || search.Range != 15*time.Minute || search.ProjectID != "" {
+			t.Errorf("search = %+v", search)
+		}
+	})
+
+	tests := []struct {
+		name       string
+		id         string
+		body       string
+		wantStatus int
+	}{
+		{"another user's", "theirs", `{"name": "mine now"}`, http.StatusNotFound},
+		{"name taken", "mine", `{"name": "search other"}`, http.StatusConflict},
+		{"invalid filter", "mine", `{"filter": "level =="}`, http.StatusBadRequest},
+		{"invalid range", "mine", `{"range": "-1h"}`, http.StatusBadRequest},
+		{"invalid search mode", "mine", `{"search_mode": "fuzzy"}`, http.StatusBadRequest},
+		{"project without access", "mine", `{"project_id": "proj-2"}`, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore, repo := newMockStorage()
+			search := addSearch(repo, "mine", "viewer", "")
+			addSearch(repo, "other", "viewer", "")
+			addSearch(repo, "theirs", "someone", "")
+			handler := NewHandler(mockStore, 24*time.Hour)
+			req := withID(withUser(httptest.NewRequest("PUT", "/api/v1/searches/"+tt.id, strings.NewReader(tt.body)), "viewer", models.RoleViewer), tt.id)
+			rec := httptest.NewRecorder()
+
+			handler.Update(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if search.Name != "search mine" || search.Filter != `level == "error"` || search.Range != time.Hour {
+				t.Errorf("search changed: %+v", search)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	mockStore, repo := newMockStorage()
+	addSearch(repo, "mine", "viewer", "")
+	addSearch(repo, "theirs", "someone", "")
+	handler := NewHandler(mockStore, 24*time.Hour)
+
+	req := withID(withUser(httptest.NewRequest("DELETE", "/api/v1/searches/theirs", nil), "viewer", models.RoleViewer), "theirs")
+	rec := httptest.NewRecorder()
+	handler.Delete(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("deleting another user's search: status = %d, want 404", rec.Code)
+	}
+
+	req = withID(withUser(httptest.NewRequest("DELETE", "/api/v1/searches/mine", nil), "viewer", models.RoleViewer), "mine")
+	rec = httptest.NewRecorder()
+	handler.Delete(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("status = %d, want 204", rec.Code)
+	}
+	if _, ok := repo.searches["mine"]; ok {
+		t.Error("saved search not deleted")
+	}
+	if _, ok := repo.searches["theirs"]; !ok {
+		t.Error("another user's search deleted")
+	}
+}
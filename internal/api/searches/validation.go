@@ -0,0 +1,60 @@
+package searches
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/query"
+)
+
+func ValidateName(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("name is required")
+	}
+	if len(name) > 100 {
+		return errors.New("name must be 100 characters or less")
+	}
+	return nil
+}
+
+// ValidateFilter checks a DSL filter expression; empty means no filter.
+func ValidateFilter(filter string) error {
+	if len(filter) > maxFilterLength {
+		return fmt.Errorf("filter expression too long (max %d chars)", maxFilterLength)
+	}
+	if filter == "" {
+		return nil
+	}
+	if _, err := query.NewQueryDSL(query.DefaultFields).Parse(filter); err != nil {
+		return fmt.Errorf("invalid filter expression: %w", err)
+	}
+	return nil
+}
+
+// ValidateRange parses a search's default time range, at most maxRange.
+func ValidateRange(s string, maxRange time.Duration) (time.Duration, error) {
+	if s == "" {
+		return 0, errors.New("range is required")
+	}
+	rng, err := time.ParseDuration(s)
+	if err != nil || rng <= 0 {
+		return 0, errors.New("range must be a positive duration (e.g. 15m, 1h)")
+	}
+	if rng > maxRange {
+		return 0, fmt.Errorf("range exceeds max query range of %s", maxRange)
+	}
+	return rng, nil
+}
+
+func ValidateSearchMode(mode string) (string, error) {
+	mode = strings.ToLower(mode)
+	switch mode {
+	case "", "token", "substring", "phrase":
+		return mode, nil
+	default:
+		return "", errors.New("search_mode must be token, substring, or phrase")
+	}
+}
@@ -0,0 +1,271 @@
+// Package share provides HTTP handlers for shareable short-code log filters.
+package share
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/good-yellow-bee/blazelog/internal/api/middleware"
+	"github.com/good-yellow-bee/blazelog/internal/models"
+	"github.com/good-yellow-bee/blazelog/internal/query"
+	"github.com/good-yellow-bee/blazelog/internal/storage"
+)
+
+// Response helpers (same pattern as projects)
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+type errorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+type dataResponse struct {
+	Data any `json:"data"`
+}
+
+const (
+	errCodeBadRequest       = "BAD_REQUEST"
+	errCodeValidationFailed = "VALIDATION_FAILED"
+	errCodeNotFound         = "NOT_FOUND"
+	errCodeForbidden        = "FORBIDDEN"
+	errCodeInternalError    = "INTERNAL_ERROR"
+)
+
+const (
+	maxFilterLength      = 1000
+	defaultTTL           = 7 * 24 * time.Hour
+	defaultMaxQueryRange = 24 * time.Hour
+	// webLogsPath is the web UI page resolved links redirect to.
+	webLogsPath = "/logs"
+)
+
+func jsonError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(errorResponse{Error: errorBody{Code: code, Message: message}}); err != nil {
+		slog.Error("json encode", "error", err)
+	}
+}
+
+func jsonOK(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(dataResponse{Data: data}); err != nil {
+		slog.Error("json encode", "error", err)
+	}
+}
+
+func jsonCreated(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(dataResponse{Data: data}); err != nil {
+		slog.Error("json encode", "error", err)
+	}
+}
+
+// Handler handles shared filter endpoints.
+type Handler struct {
+	storage       storage.Storage
+	ttl           time.Duration
+	maxQueryRange time.Duration
+}
+
+// NewHandler creates a shared filter handler.
+// ttl controls how long created codes stay resolvable; maxQueryRange caps the
+// relative range a code may carry so resolved links stay queryable.
+func NewHandler(store storage.Storage, ttl, maxQueryRange time.Duration) *Handler {
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	if maxQueryRange <= 0 {
+		maxQueryRange = defaultMaxQueryRange
+	}
+	return &Handler{
+		storage:       store,
+		ttl:           ttl,
+		maxQueryRange: maxQueryRange,
+	}
+}
+
+// CreateRequest is the body for creating a shared filter.
+type CreateRequest struct {
+	Filter     string `json:"filter"`
+	Range      string `json:"range"`
+	SearchMode string `json:"search_mode"`
+	ProjectID  string `json:"project_id"`
+}
+
+// SharedFilterResponse describes a stored shared filter.
+type SharedFilterResponse struct {
+	Code       string `json:"code"`
+	Filter     string `json:"filter,omitempty"`
+	Range      string `json:"range"`
+	SearchMode string `json:"search_mode,omitempty"`
+	ProjectID  string `json:"project_id,omitempty"`
+	ExpiresAt  string `json:"expires_at"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// ResolveResponse expands a code into concrete query parameters.
+type ResolveResponse struct {
+	SharedFilterResponse
+	Start string `json:"start"`
+	End   string `json:"end"`
+	Query string `json:"query"`
+}
+
+// Create stores a new shared filter and returns its code.
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, http.StatusBadRequest, errCodeBadRequest, "invalid request body")
+		return
+	}
+
+	if len(req.Filter) > maxFilterLength {
+		jsonError(w, http.StatusBadRequest, errCodeValidationFailed, fmt.Sprintf("filter expression too long (max %d chars)", maxFilterLength))
+		return
+	}
+	if req.Filter != "" {
+		if _, err := query.NewQueryDSL(query.DefaultFields).Parse(req.Filter); err != nil {
+			jsonError(w, http.StatusBadRequest, errCodeValidationFailed, fmt.Sprintf("invalid filter expression: %v", err))
+			return
+		}
+	}
+
+	if req.Range == "" {
+		jsonError(w, http.StatusBadRequest, errCodeValidationFailed, "range is required")
+		return
+	}
+	rng, err := time.ParseDuration(req.Range)
+	if err != nil || rng <= 0 {
+		jsonError(w, http.StatusBadRequest, errCodeValidationFailed, "range must be a positive duration (e.g. 15m, 1h)")
+		return
+	}
+	if rng > h.maxQueryRange {
+		jsonError(w, http.StatusBadRequest, errCodeValidationFailed, fmt.Sprintf("range exceeds max query range of %s", h.maxQueryRange))
+		return
+	}
+
+	searchMode := strings.ToLower(req.SearchMode)
+	switch searchMode {
+	case "", "token", "substring", "phrase":
+	default:
+		jsonError(w, http.StatusBadRequest, errCodeValidationFailed, "search_mode must be token, substring, or phrase")
+		return
+	}
+
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+
+	if req.ProjectID != "" {
+		access, err := middleware.GetProjectAccess(ctx, userID, middleware.GetRole(ctx), h.storage)
+		if err != nil {
+			slog.Error("get project access", "error", err)
+			jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
+			return
+		}
+		if !access.CanAccessProject(req.ProjectID) {
+			jsonError(w, http.StatusForbidden, errCodeForbidden, "access denied to project")
+			return
+		}
+	}
+
+	sf, err := models.NewSharedFilter(userID, h.ttl)
+	if err != nil {
+		slog.Error("generate share code", "error", err)
+		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
+		return
+	}
+	sf.Filter = req.Filter
+	sf.Range = rng
+	sf.SearchMode = searchMode
+	sf.ProjectID = req.ProjectID
+
+	if err := h.storage.SharedFilters().Create(ctx, sf); err != nil {
+		slog.Error("create shared filter", "error", err)
+		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
+		return
+	}
+
+	// Opportunistically purge expired codes so the table stays small
+	if _, err := h.storage.SharedFilters().DeleteExpired(ctx); err != nil {
+		slog.Error("delete expired shared filters", "error", err)
+	}
+
+	jsonCreated(w, sharedFilterToResponse(sf))
+}
+
+// Resolve expands a code into query parameters.
+// With ?redirect=true it redirects to the web UI logs page instead.
+func (h *Handler) Resolve(w http.ResponseWriter, r *http.Request) {
+	code := chi.URLParam(r, "code")
+	if code == "" {
+		jsonError(w, http.StatusBadRequest, errCodeBadRequest, "code is required")
+		return
+	}
+
+	sf, err := h.storage.SharedFilters().GetByCode(r.Context(), code)
+	if err != nil {
+		slog.Error("get shared filter", "error", err)
+		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
+		return
+	}
+	// Expired codes are indistinguishable from unknown ones
+	if sf == nil || sf.IsExpired() {
+		jsonError(w, http.StatusNotFound, errCodeNotFound, "shared filter not found")
+		return
+	}
+
+	end := time.Now().UTC()
+	start := end.Add(-sf.Range)
+	params := queryParams(sf, start, end)
+
+	if r.URL.Query().Get("redirect") == "true" {
+		http.Redirect(w, r, webLogsPath+"?"+params.Encode(), http.StatusFound)
+		return
+	}
+
+	jsonOK(w, &ResolveResponse{
+		SharedFilterResponse: *sharedFilterToResponse(sf),
+		Start:                start.Format(time.RFC3339),
+		End:                  end.Format(time.RFC3339),
+		Query:                params.Encode(),
+	})
+}
+
+// queryParams builds logs query parameters for a shared filter.
+func queryParams(sf *models.SharedFilter, start, end time.Time) url.Values {
+	params := url.Values{}
+	params.Set("start", start.Format(time.RFC3339))
+	params.Set("end", end.Format(time.RFC3339))
+	if sf.Filter != "" {
+		params.Set("filter", sf.Filter)
+	}
+	if sf.SearchMode != "" {
+		params.Set("search_mode", sf.SearchMode)
+	}
+	if sf.ProjectID != "" {
+		params.Set("project_id", sf.ProjectID)
+	}
+	return params
+}
+
+func sharedFilterToResponse(sf *models.SharedFilter) *SharedFilterResponse {
+	return &SharedFilterResponse{
+		Code:       sf.Code,
+		Filter:     sf.Filter,
+		Range:      sf.Range.String(),
+		SearchMode: sf.SearchMode,
+		ProjectID:  sf.ProjectID,
+		ExpiresAt:  sf.ExpiresAt.Format(time.RFC3339),
+		CreatedAt:  sf.CreatedAt.Format(time.RFC3339),
+	}
+}
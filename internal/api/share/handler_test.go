@@ -0,0 +1,238 @@
+package share
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/good-yellow-bee/blazelog/internal/api/middleware"
+	"github.com/good-yellow-bee/blazelog/internal/models"
+	"github.com/good-yellow-bee/blazelog/internal/storage"
+)
+
+type mockSharedFilterRepository struct {
+	filters map[string]*models.SharedFilter
+}
+
+func (m *mockSharedFilterRepository) Create(ctx context.Context, f *models.SharedFilter) error {
+	m.filters[f.Code] = f
+	return nil
+}
+
+func (m *mockSharedFilterRepository) GetByCode(ctx context.Context, code string) (*models.SharedFilter, error) {
+	return m.filters[code], nil
+}
+
+func (m *mockSharedFilterRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+type mockStorage struct {
+	sharedFilterRepo *mockSharedFilterRepository
+}
+
+func (m *mockStorage) Open() error                                   { return nil }
+func (m *mockStorage) Close() error                                  { return nil }
+func (m *mockStorage) Migrate() error                                { return nil }
+func (m *mockStorage) EnsureAdminUser() error                        { return nil }
+func (m *mockStorage) Users() storage.UserRepository                 { return nil }
+func (m *mockStorage) Projects() storage.ProjectRepository           { return nil }
+func (m *mockStorage) Alerts() storage.AlertRepository               { return nil }
+func (m *mockStorage) Connections() storage.ConnectionRepository     { return nil }
+func (m *mockStorage) Tokens() storage.TokenRepository               { return nil }
+func (m *mockStorage) AlertHistory() storage.AlertHistoryRepository  { return nil }
+func (m *mockStorage) SharedFilters() storage.SharedFilterRepository { return m.sharedFilterRepo }
+func (m *mockStorage) SavedSearches() storage.SavedSearchRepository  { return nil }
+
+func newMockStorage() (*mockStorage, *mockSharedFilterRepository) {
+	repo := &mockSharedFilterRepository{filters: make(map[string]*models.SharedFilter)}
+	return &mockStorage{sharedFilterRepo: repo}, repo
+}
+
+func withAdminContext(r *http.Request) *http.Request {
+	ctx := middleware.WithUserContext(r.Context(), "admin-user", "admin", models.RoleAdmin)
+	return r.WithContext(ctx)
+}
+
+func withCode(r *http.Request, code string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("code", code)
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestCreate_Success(t *testing.T) {
+	mockStore, repo := newMockStorage()
+	handler := NewHandler(mockStore, time.Hour, 24*time.Hour)
+
+	body := `{"filter": "level == \"error\"", "range": "1h", "search_mode": "phrase"}`
+	req := withAdminContext(httptest.NewRequest("POST", "/api/v1/share", strings.NewReader(body)))
+	rec := httptest.NewRecorder()
+
+	handler.Create(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d; body: %s", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+
+	var resp struct {
+		Data *SharedFilterResponse `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Data.Code == "" {
+		t.Fatal("expected code in response")
+	}
+	stored, ok := repo.filters[resp.Data.Code]
+	if !ok {
+		t.Fatal("shared filter not stored")
+	}
+	if stored.Range != time.Hour {
+		t.Errorf("range = %s, want 1h", stored.Range)
+	}
+	if stored.CreatedBy != "admin-user" {
+		t.Errorf("created_by = %q, want admin-user", stored.CreatedBy)
+	}
+}
+
+func TestCreate_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"missing range", `{"filter": "level == \"error\""}`},
+		{"invalid range", `{"range": "soon"}`},
+		{"range too large", `{"range": "48h"}`},
+		{"invalid filter", `{"filter": "nope ==", "range": "1h"}`},
+		{"unknown field", `{"filter": "secret == \"x\"", "range": "1h"}`},
+		{"invalid search mode", `{"range": "1h", "search_mode": "fuzzy"}`},
+		{"filter too long", `{"range": "1h", "filter": "` + strings.Repeat("a", maxFilterLength+1) + `"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore, repo := newMockStorage()
+			handler := NewHandler(mockStore, time.Hour, 24*time.Hour)
+
+			req := withAdminContext(httptest.NewRequest("POST", "/api/v1/share", strings.NewReader(tt.body)))
+			rec := httptest.NewRecorder()
+
+			handler.Create(rec, req)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d; body: %s", rec.Code, http.StatusBadRequest, rec.Body.String())
+			}
+			if len(repo.filters) != 0 {
+				t.Error("invalid request should not be stored")
+			}
+		})
+	}
+}
+
+func TestResolve_Success(t *testing.T) {
+	mockStore, repo := newMockStorage()
+	handler := NewHandler(mockStore, time.Hour, 24*time.Hour)
+
+	now := time.Now()
+	repo.filters["abc123"] = &models.SharedFilter{
+		Code:      "abc123",
+		Filter:    `level == "error"`,
+		Range:     15 * time.Minute,
+		ProjectID: "proj-1",
+		ExpiresAt: now.Add(time.Hour),
+		CreatedAt: now,
+	}
+
+	req := withCode(httptest.NewRequest("GET", "/api/v1/share/abc123", nil), "abc123")
+	rec := httptest.NewRecorder()
+
+	handler.Resolve(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var resp struct {
+		Data *ResolveResponse `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	start, err := time.Parse(time.RFC3339, resp.Data.Start)
+	if err != nil {
+		t.Fatalf("parse start: %v", err)
+	}
+	end, err := time.Parse(time.RFC3339, resp.Data.End)
+	if err != nil {
+		t.Fatalf("parse end: %v", err)
+	}
+	if end.Sub(start) != 15*time.Minute {
+		t.Errorf("resolved range = %s, want 15m", end.Sub(start))
+	}
+
+	params, err := url.ParseQuery(resp.Data.Query)
+	if err != nil {
+		t.Fatalf("parse query: %v", err)
+	}
+	if params.Get("filter") != `level == "error"` {
+		t.Errorf("filter = %q", params.Get("filter"))
+	}
+	if params.Get("project_id") != "proj-1" {
+		t.Errorf("project_id = %q, want proj-1", params.Get("project_id"))
+	}
+}
+
+func TestResolve_Redirect(t *testing.T) {
+	mockStore, repo := newMockStorage()
+	handler := NewHandler(mockStore, time.Hour, 24*time.Hour)
+
+	now := time.Now()
+	repo.filters["abc123"] = &models.SharedFilter{
+		Code:      "abc123",
+		Range:     time.Hour,
+		ExpiresAt: now.Add(time.Hour),
+		CreatedAt: now,
+	}
+
+	req := withCode(httptest.NewRequest("GET", "/api/v1/share/abc123?redirect=true", nil), "abc123")
+	rec := httptest.NewRecorder()
+
+	handler.Resolve(rec, req)
+
+	if rec.Code != http.StatusFound {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusFound)
+	}
+	if loc := rec.Header().Get("Location"); !strings.HasPrefix(loc, "/logs?") {
+		t.Errorf("location = %q, want /logs?...", loc)
+	}
+}
+
+func TestResolve_NotFoundOrExpired(t *testing.T) {
+	mockStore, repo := newMockStorage()
+	handler := NewHandler(mockStore, time.Hour, 24*time.Hour)
+
+	repo.filters["expired"] = &models.SharedFilter{
+		Code:      "expired",
+		Range:     time.Hour,
+		ExpiresAt: time.Now().Add(-time.Minute),
+	}
+
+	for _, code := range []string{"missing", "expired"} {
+		req := withCode(httptest.NewRequest("GET", "/api/v1/share/"+code, nil), code)
+		rec := httptest.NewRecorder()
+
+		handler.Resolve(rec, req)
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s: status = %d, want %d", code, rec.Code, http.StatusNotFound)
+		}
+	}
+}
@@ -2,7 +2,7 @@ package users
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
@@ -47,7 +47,7 @@ func jsonError(w http.ResponseWriter, status int, code, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(errorResponse{Error: errorBody{Code: code, Message: message}}); err != nil {
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 	}
 }
 
@@ -55,7 +55,7 @@ func jsonOK(w http.ResponseWriter, data any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(dataResponse{Data: data}); err != nil {
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 	}
 }
 
@@ -63,7 +63,7 @@ func jsonCreated(w http.ResponseWriter, data any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(dataResponse{Data: data}); err != nil {
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 	}
 }
 
@@ -118,7 +118,7 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 
 	users, err := h.storage.Users().List(ctx)
 	if err != nil {
-		log.Printf("list users error: %v", err)
+		slog.Error("list users", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -165,7 +165,7 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	// Check username uniqueness
 	existing, err := h.storage.Users().GetByUsername(ctx, req.Username)
 	if err != nil {
-		log.Printf("create user error: check username: %v", err)
+		slog.Error("create user: check username", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -177,7 +177,7 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	// Check email uniqueness
 	existing, err = h.storage.Users().GetByEmail(ctx, req.Email)
 	if err != nil {
-		log.Printf("create user error: check email: %v", err)
+		slog.Error("create user: check email", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -189,7 +189,7 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	// Hash password
 	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), auth.BcryptCost)
 	if err != nil {
-		log.Printf("create user error: hash password: %v", err)
+		slog.Error("create user: hash password", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -207,12 +207,12 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.storage.Users().Create(ctx, user); err != nil {
-		log.Printf("create user error: %v", err)
+		slog.Error("create user", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
 
-	log.Printf("user created: %s (%s)", user.Username, user.ID)
+	slog.Info("user created", "username", user.Username, "id", user.ID)
 
 	jsonCreated(w, userToResponse(user))
 }
@@ -229,7 +229,7 @@ func (h *Handler) GetByID(w http.ResponseWriter, r *http.Request) {
 
 	user, err := h.storage.Users().GetByID(ctx, userID)
 	if err != nil {
-		log.Printf("get user error: %v", err)
+		slog.Error("get user", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -262,7 +262,7 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	// Get existing user
 	user, err := h.storage.Users().GetByID(ctx, userID)
 	if err != nil {
-		log.Printf("update user error: get user: %v", err)
+		slog.Error("update user: get user", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -281,7 +281,7 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		// Check email uniqueness
 		existing, err := h.storage.Users().GetByEmail(ctx, req.Email)
 		if err != nil {
-			log.Printf("update user error: check email: %v", err)
+			slog.Error("update user: check email", "error", err)
 			jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 			return
 		}
@@ -318,12 +318,12 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	user.UpdatedAt = time.Now()
 
 	if err := h.storage.Users().Update(ctx, user); err != nil {
-		log.Printf("update user error: %v", err)
+		slog.Error("update user", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
 
-	log.Printf("user updated: %s (%s)", user.Username, user.ID)
+	slog.Info("user updated", "username", user.Username, "id", user.ID)
 
 	jsonOK(w, userToResponse(user))
 }
@@ -348,7 +348,7 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	// Check if user exists
 	user, err := h.storage.Users().GetByID(ctx, userID)
 	if err != nil {
-		log.Printf("delete user error: get user: %v", err)
+		slog.Error("delete user: get user", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -359,12 +359,12 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 
 	// Delete user
 	if err := h.storage.Users().Delete(ctx, userID); err != nil {
-		log.Printf("delete user error: %v", err)
+		slog.Error("delete user", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
 
-	log.Printf("user deleted: %s (%s)", user.Username, user.ID)
+	slog.Info("user deleted", "username", user.Username, "id", user.ID)
 
 	jsonNoContent(w)
 }
@@ -376,7 +376,7 @@ func (h *Handler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
 
 	user, err := h.storage.Users().GetByID(ctx, userID)
 	if err != nil {
-		log.Printf("get current user error: %v", err)
+		slog.Error("get current user", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -411,7 +411,7 @@ func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 	// Get user
 	user, err := h.storage.Users().GetByID(ctx, userID)
 	if err != nil {
-		log.Printf("change password error: get user: %v", err)
+		slog.Error("change password: get user", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -429,7 +429,7 @@ func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 	// Hash new password
 	hash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), auth.BcryptCost)
 	if err != nil {
-		log.Printf("change password error: hash password: %v", err)
+		slog.Error("change password: hash password", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -438,14 +438,14 @@ func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 	user.UpdatedAt = time.Now()
 
 	if err := h.storage.Users().Update(ctx, user); err != nil {
-		log.Printf("change password error: %v", err)
+		slog.Error("change password", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
 
 	// Revoke all refresh tokens and sessions (force re-login everywhere)
 	if err := h.storage.Tokens().RevokeAllForUser(ctx, userID); err != nil {
-		log.Printf("change password error: revoke tokens: %v", err)
+		slog.Error("change password: revoke tokens", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "password changed but failed to invalidate sessions")
 		return
 	}
@@ -453,7 +453,7 @@ func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 		h.sessions.DeleteByUserID(userID)
 	}
 
-	log.Printf("password changed: user %s", user.Username)
+	slog.Info("password changed", "username", user.Username)
 
 	jsonNoContent(w)
 }
@@ -496,7 +496,7 @@ func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 	// Get target user
 	user, err := h.storage.Users().GetByID(ctx, userID)
 	if err != nil {
-		log.Printf("reset password error: get user: %v", err)
+		slog.Error("reset password: get user", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -514,7 +514,7 @@ func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 	// Hash new password
 	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), auth.BcryptCost)
 	if err != nil {
-		log.Printf("reset password error: hash: %v", err)
+		slog.Error("reset password: hash", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
@@ -524,14 +524,14 @@ func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 	user.UpdatedAt = time.Now()
 
 	if err := h.storage.Users().Update(ctx, user); err != nil {
-		log.Printf("reset password error: update: %v", err)
+		slog.Error("reset password: update", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "internal server error")
 		return
 	}
 
 	// Invalidate all sessions/tokens for this user
 	if err := h.storage.Tokens().RevokeAllForUser(ctx, userID); err != nil {
-		log.Printf("reset password error: revoke tokens: %v", err)
+		slog.Error("reset password: revoke tokens", "error", err)
 		jsonError(w, http.StatusInternalServerError, errCodeInternalError, "password changed but failed to invalidate sessions")
 		return
 	}
@@ -539,7 +539,7 @@ func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 		h.sessions.DeleteByUserID(userID)
 	}
 
-	log.Printf("password reset by admin %s for user: %s (%s)", currentUserID, user.Username, user.ID)
+	slog.Info("password reset by admin", "admin", currentUserID, "username", user.Username, "id", user.ID)
 
 	jsonNoContent(w)
 }
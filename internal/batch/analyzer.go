@@ -270,13 +270,12 @@ func (a *Analyzer) parseMultiLine(ctx context.Context, scanner *bufio.Scanner, p
 			return
 		}
 
-		entry, err := p.ParseMultiLine(currentLines)
+		entry, err := parser.ParseMultiLineAt(p, currentLines, startLineNum)
 		if err != nil {
 			parseErrors++
 			return
 		}
 
-		entry.LineNumber = startLineNum
 		entry.FilePath = path
 
 		// Apply date filter
@@ -393,6 +392,14 @@ func GetParser(logType string) (parser.Parser, bool) {
 		return parser.NewPrestaShopParser(nil), true
 	case "wordpress":
 		return parser.NewWordPressParser(nil), true
+	case "syslog":
+		return parser.NewSyslogParser(nil), true
+	case "json":
+		return parser.NewJSONParser(nil), true
+	case "postgres":
+		return parser.NewPostgresParser(nil), true
+	case "mysql":
+		return parser.NewMySQLParser(nil), true
 	default:
 		return nil, false
 	}
@@ -0,0 +1,29 @@
+// Package encoding holds what the agent and server share about gRPC stream
+// compression. Compressors live in subpackages and register themselves with
+// gRPC when imported.
+package encoding
+
+import "strings"
+
+// CompressorsHeader is the Register response header in which the server
+// lists the compressors it accepts, comma-separated. Servers that predate
+// it only accept gzip, advertised through StreamConfig.compression_enabled.
+const CompressorsHeader = "blazelog-compressors"
+
+// JoinCompressors formats compressor names for CompressorsHeader.
+func JoinCompressors(names ...string) string {
+	return strings.Join(names, ",")
+}
+
+// SplitCompressors parses the values of CompressorsHeader.
+func SplitCompressors(values []string) []string {
+	var names []string
+	for _, v := range values {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,17 @@
+package encoding
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitCompressors(t *testing.T) {
+	got := SplitCompressors([]string{JoinCompressors("gzip", "zstd"), " snappy ,", ""})
+	want := []string{"gzip", "zstd", "snappy"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SplitCompressors() = %v, want %v", got, want)
+	}
+	if got := SplitCompressors(nil); got != nil {
+		t.Errorf("SplitCompressors(nil) = %v, want nil", got)
+	}
+}
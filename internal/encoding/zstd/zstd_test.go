@@ -0,0 +1,49 @@
+package zstd
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/encoding"
+)
+
+func TestRoundTrip(t *testing.T) {
+	c := encoding.GetCompressor(Name)
+	if c == nil {
+		t.Fatal("zstd compressor not registered")
+	}
+
+	// Twice, so the second pass reuses pooled encoders and decoders
+	for i := 0; i < 2; i++ {
+		msg := []byte(strings.Repeat("GET /index.html HTTP/1.1 200 ", 100))
+
+		var buf bytes.Buffer
+		w, err := c.Compress(&buf)
+		if err != nil {
+			t.Fatalf("Compress: %v", err)
+		}
+		if _, err := w.Write(msg); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+		if buf.Len() >= len(msg) {
+			t.Errorf("compressed %d bytes to %d, want smaller", len(msg), buf.Len())
+		}
+
+		r, err := c.Decompress(&buf)
+		if err != nil {
+			t.Fatalf("Decompress: %v", err)
+		}
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("ReadAll: %v", err)
+		}
+		if !bytes.Equal(got, msg) {
+			t.Errorf("round trip mismatch: got %d bytes, want %d", len(got), len(msg))
+		}
+	}
+}
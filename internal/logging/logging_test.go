@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    slog.Level
+		wantErr bool
+	}{
+		{"debug", slog.LevelDebug, false},
+		{"", slog.LevelInfo, false},
+		{"INFO", slog.LevelInfo, false},
+		{"warning", slog.LevelWarn, false},
+		{" error ", slog.LevelError, false},
+		{"trace", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseLevel(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLevel(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLevel(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		wantErr bool
+	}{
+		{"text", FormatText, false},
+		{"json", FormatJSON, false},
+		{"default", "", false},
+		{"unknown", "logfmt", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger, err := New(&buf, slog.LevelInfo, tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			logger.Debug("hidden")
+			logger.Info("server started", "address", ":9443")
+
+			out := buf.String()
+			if strings.Contains(out, "hidden") {
+				t.Errorf("debug record written at info level: %q", out)
+			}
+			if tt.format != FormatJSON {
+				if !strings.Contains(out, `msg="server started" address=:9443`) {
+					t.Errorf("unexpected text output: %q", out)
+				}
+				return
+			}
+			var rec map[string]any
+			if err := json.Unmarshal([]byte(out), &rec); err != nil {
+				t.Fatalf("output is not JSON: %v (%q)", err, out)
+			}
+			if rec["level"] != "INFO" || rec["msg"] != "server started" || rec["address"] != ":9443" {
+				t.Errorf("unexpected JSON record: %v", rec)
+			}
+		})
+	}
+}
@@ -97,30 +97,88 @@ var (
 			Help:      "Total batch processing errors",
 		},
 	)
+
+	// GRPCReceivedBytesTotal counts received message payload bytes, before
+	// and after compression.
+	GRPCReceivedBytesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "grpc",
+			Name:      "received_bytes_total",
+			Help:      "Total gRPC message payload bytes received",
+		},
+		[]string{"kind"}, // uncompressed, compressed
+	)
+
+	// GRPCAgentQuotaUsage tracks how much of each agent's quota burst is in
+	// use, from 0 (idle) to 1 (exhausted).
+	GRPCAgentQuotaUsage = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "grpc",
+			Name:      "agent_quota_usage",
+			Help:      "Fraction of the agent's lines-per-second quota burst in use",
+		},
+		[]string{"agent"},
+	)
+
+	// GRPCAgentQuotaRejectedTotal counts lines refused for exceeding their
+	// agent's quota.
+	GRPCAgentQuotaRejectedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "grpc",
+			Name:      "agent_quota_rejected_lines_total",
+			Help:      "Total log lines rejected for exceeding the agent's quota",
+		},
+		[]string{"agent"},
+	)
+
+	// GRPCCompressionRatio tracks the last reported receive compression ratio.
+	GRPCCompressionRatio = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "grpc",
+			Name:      "compression_ratio",
+			Help:      "Uncompressed over compressed bytes received in the last report interval",
+		},
+	)
 )
 
 // Buffer metrics
 var (
-	// BufferPending tracks entries waiting to be flushed.
-	BufferPending = promauto.NewGauge(
+	// BufferDepth tracks entries waiting to be flushed.
+	BufferDepth = promauto.NewGauge(
 		prometheus.GaugeOpts{
 			Namespace: namespace,
 			Subsystem: "buffer",
-			Name:      "pending_entries",
+			Name:      "depth",
 			Help:      "Log entries waiting to be flushed to storage",
 		},
 	)
 
-	// BufferDroppedTotal counts dropped entries due to backpressure.
-	BufferDroppedTotal = promauto.NewCounter(
+	// BufferDroppedOnFullTotal counts entries dropped because the buffer
+	// was full.
+	BufferDroppedOnFullTotal = promauto.NewCounter(
 		prometheus.CounterOpts{
 			Namespace: namespace,
 			Subsystem: "buffer",
-			Name:      "dropped_total",
+			Name:      "dropped_on_full_total",
 			Help:      "Total entries dropped due to buffer overflow",
 		},
 	)
 
+	// BufferFlushDuration tracks how long flushes to storage take.
+	BufferFlushDuration = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "buffer",
+			Name:      "flush_duration_seconds",
+			Help:      "Buffer flush duration in seconds",
+			Buckets:   prometheus.DefBuckets,
+		},
+	)
+
 	// BufferFlushesTotal counts flush operations.
 	BufferFlushesTotal = promauto.NewCounter(
 		prometheus.CounterOpts{
@@ -152,6 +210,52 @@ var (
 	)
 )
 
+// Ingest metrics
+var (
+	// IngestDeduplicatedTotal counts records skipped as duplicates at ingest.
+	IngestDeduplicatedTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "ingest",
+			Name:      "deduplicated_total",
+			Help:      "Total log records skipped as duplicates by content hash",
+		},
+	)
+
+	// IngestFieldsTruncatedTotal counts records whose fields or labels exceeded the cap.
+	IngestFieldsTruncatedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "ingest",
+			Name:      "fields_truncated_total",
+			Help:      "Total log records with fields or labels dropped by per-record caps",
+		},
+		[]string{"kind"}, // fields, labels
+	)
+
+	// IngestInvalidTotal counts records that failed ingest validation.
+	IngestInvalidTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "ingest",
+			Name:      "invalid_total",
+			Help:      "Total log records failing ingest validation, by reason and action taken",
+		},
+		[]string{"reason", "action"}, // action: dropped, coerced, rejected
+	)
+
+	// IngestGeoIPLookupsTotal counts GeoIP enrichment lookups by result.
+	IngestGeoIPLookupsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "ingest",
+			Name:      "geoip_lookups_total",
+			Help:      "Total GeoIP enrichment lookups of record client IPs, by result",
+		},
+		[]string{"result"}, // found, not_found, private, invalid, error
+	)
+)
+
 // Storage metrics
 var (
 	// StorageQueryDuration tracks query latency.
@@ -176,6 +280,70 @@ var (
 		},
 		[]string{"operation", "backend"},
 	)
+
+	// StoragePoolConnections tracks connection pool usage by state.
+	StoragePoolConnections = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "pool_connections",
+			Help:      "Connection pool connections by state",
+		},
+		[]string{"backend", "state"}, // open, in_use, idle, max_open
+	)
+
+	// StoragePoolWaitCount tracks connections waited for since startup.
+	StoragePoolWaitCount = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "pool_wait_count",
+			Help:      "Total connections waited for because the pool was exhausted",
+		},
+		[]string{"backend"},
+	)
+
+	// StoragePoolWaitSeconds tracks time spent waiting for connections since startup.
+	StoragePoolWaitSeconds = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "pool_wait_duration_seconds",
+			Help:      "Total time spent waiting for a pooled connection in seconds",
+		},
+		[]string{"backend"},
+	)
+
+	// StorageAlertHistoryPurged counts alert history entries removed by retention.
+	StorageAlertHistoryPurged = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "alert_history_purged_total",
+			Help:      "Total alert history entries deleted by retention",
+		},
+		[]string{"reason"}, // age, max_rows
+	)
+
+	// StorageLogsPurged counts logs deleted by the log retention worker.
+	StorageLogsPurged = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "logs_purged_total",
+			Help:      "Total logs deleted by the log retention worker",
+		},
+	)
+
+	// StorageLogRetentionLastSuccess tracks when log retention last completed.
+	StorageLogRetentionLastSuccess = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "log_retention_last_success_timestamp_seconds",
+			Help:      "Unix time the log retention worker last completed a purge",
+		},
+	)
 )
 
 // Auth metrics
@@ -203,6 +371,130 @@ var (
 	)
 )
 
+// Agent metrics
+var (
+	// AgentParseQueueDepth tracks sources waiting for a parse worker.
+	AgentParseQueueDepth = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "agent",
+			Name:      "parse_queue_depth",
+			Help:      "Number of sources waiting for a parse worker",
+		},
+	)
+
+	// AgentParseWorkersBusy tracks parse workers in use.
+	AgentParseWorkersBusy = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "agent",
+			Name:      "parse_workers_busy",
+			Help:      "Number of parse workers currently parsing",
+		},
+	)
+
+	// AgentBatchesInFlight tracks sent batches awaiting a server ack.
+	AgentBatchesInFlight = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "agent",
+			Name:      "batches_in_flight",
+			Help:      "Number of sent batches awaiting a server acknowledgement",
+		},
+	)
+
+	// AgentSentBytesTotal counts sent message payload bytes, before and
+	// after compression.
+	AgentSentBytesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "agent",
+			Name:      "sent_bytes_total",
+			Help:      "Total gRPC message payload bytes sent to the server",
+		},
+		[]string{"kind"}, // uncompressed, compressed
+	)
+
+	// AgentOversizedLinesTotal counts lines longer than their source's
+	// max_line_length, which are truncated.
+	AgentOversizedLinesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "agent",
+			Name:      "oversized_lines_total",
+			Help:      "Total lines truncated for exceeding the source's max line length",
+		},
+		[]string{"source"},
+	)
+
+	// AgentOversizedEntriesDroppedTotal counts entries dropped before
+	// sending because they alone exceed the gRPC max message size.
+	AgentOversizedEntriesDroppedTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "agent",
+			Name:      "oversized_entries_dropped_total",
+			Help:      "Total log entries dropped for exceeding the max gRPC message size",
+		},
+	)
+
+	// AgentParseErrorsTotal counts lines their source's parser rejected.
+	AgentParseErrorsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "agent",
+			Name:      "parse_errors_total",
+			Help:      "Total lines that failed to parse",
+		},
+		[]string{"source"},
+	)
+
+	// AgentBufferDroppedEntriesTotal counts buffered entries dropped, oldest
+	// first, to keep the disk buffer within buffer_max_size.
+	AgentBufferDroppedEntriesTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "agent",
+			Name:      "buffer_dropped_entries_total",
+			Help:      "Total buffered entries dropped because the disk buffer was full",
+		},
+	)
+
+	// AgentSendRetriesTotal counts reconnects scheduled after a retryable
+	// connect or stream failure.
+	AgentSendRetriesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "agent",
+			Name:      "send_retries_total",
+			Help:      "Total connect attempts retried after a retryable failure",
+		},
+		[]string{"code"}, // gRPC status code of the failure
+	)
+
+	// AgentSendFailuresTotal counts times the agent gave up connecting,
+	// on a non-retryable status or once the retry window ran out.
+	AgentSendFailuresTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "agent",
+			Name:      "send_failures_total",
+			Help:      "Total times the agent gave up connecting to the server",
+		},
+		[]string{"code"}, // gRPC status code of the last failure
+	)
+
+	// AgentCompressionRatio tracks the last reported send compression ratio.
+	AgentCompressionRatio = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "agent",
+			Name:      "compression_ratio",
+			Help:      "Uncompressed over compressed bytes sent in the last report interval",
+		},
+	)
+)
+
 // Info metric
 var (
 	// BuildInfo exposes build information.
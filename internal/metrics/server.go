@@ -3,7 +3,7 @@ package metrics
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -38,7 +38,7 @@ func NewServer(addr string) *Server {
 
 // Start starts the metrics server.
 func (s *Server) Start() error {
-	log.Printf("metrics server listening on %s", s.addr)
+	slog.Info("metrics server listening", "address", s.addr)
 	if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("metrics server: %w", err)
 	}
@@ -47,7 +47,7 @@ func (s *Server) Start() error {
 
 // Shutdown gracefully shuts down the metrics server.
 func (s *Server) Shutdown(ctx context.Context) error {
-	log.Printf("shutting down metrics server")
+	slog.Info("shutting down metrics server")
 	return s.server.Shutdown(ctx)
 }
 
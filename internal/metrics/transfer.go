@@ -0,0 +1,150 @@
+package metrics
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/stats"
+)
+
+// MinUsefulCompressionRatio is the ratio below which compression is
+// reported as not worth its CPU cost (less than ~5% of bytes saved).
+const MinUsefulCompressionRatio = 1.05
+
+// TransferCounter is a gRPC stats.Handler that counts message payload bytes
+// in one direction, before and after compression. Without compression both
+// counts are equal.
+type TransferCounter struct {
+	inbound      bool
+	bytes        *prometheus.CounterVec // kind = uncompressed, compressed; may be nil
+	uncompressed atomic.Uint64
+	compressed   atomic.Uint64
+}
+
+// NewTransferCounter creates a counter for received (inbound) or sent
+// payloads. If bytes is non-nil it is also incremented, by kind.
+func NewTransferCounter(inbound bool, bytes *prometheus.CounterVec) *TransferCounter {
+	return &TransferCounter{inbound: inbound, bytes: bytes}
+}
+
+// TagRPC implements stats.Handler.
+func (c *TransferCounter) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+// HandleRPC implements stats.Handler.
+func (c *TransferCounter) HandleRPC(_ context.Context, s stats.RPCStats) {
+	var length, compressed int
+	switch p := s.(type) {
+	case *stats.OutPayload:
+		if c.inbound {
+			return
+		}
+		length, compressed = p.Length, p.CompressedLength
+	case *stats.InPayload:
+		if !c.inbound {
+			return
+		}
+		length, compressed = p.Length, p.CompressedLength
+	default:
+		return
+	}
+	if compressed <= 0 {
+		compressed = length
+	}
+	c.add(length, compressed)
+}
+
+// TagConn implements stats.Handler.
+func (c *TransferCounter) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+// HandleConn implements stats.Handler.
+func (c *TransferCounter) HandleConn(context.Context, stats.ConnStats) {}
+
+func (c *TransferCounter) add(length, compressed int) {
+	c.uncompressed.Add(uint64(length))
+	c.compressed.Add(uint64(compressed))
+	if c.bytes != nil {
+		c.bytes.WithLabelValues("uncompressed").Add(float64(length))
+		c.bytes.WithLabelValues("compressed").Add(float64(compressed))
+	}
+}
+
+// Totals returns the bytes counted so far.
+func (c *TransferCounter) Totals() TransferTotals {
+	return TransferTotals{Uncompressed: c.uncompressed.Load(), Compressed: c.compressed.Load()}
+}
+
+// TransferTotals holds payload byte counts before and after compression.
+type TransferTotals struct {
+	Uncompressed uint64
+	Compressed   uint64
+}
+
+// Sub returns the bytes counted since prev.
+func (t TransferTotals) Sub(prev TransferTotals) TransferTotals {
+	return TransferTotals{Uncompressed: t.Uncompressed - prev.Uncompressed, Compressed: t.Compressed - prev.Compressed}
+}
+
+// Ratio returns uncompressed over compressed bytes (1 = no gain), or 0
+// when nothing was transferred.
+func (t TransferTotals) Ratio() float64 {
+	if t.Compressed == 0 {
+		return 0
+	}
+	return float64(t.Uncompressed) / float64(t.Compressed)
+}
+
+// TransferReport summarizes one reporting interval.
+type TransferReport struct {
+	TransferTotals
+	Elapsed time.Duration
+}
+
+// Throughput returns the uncompressed (effective) bytes per second.
+func (r TransferReport) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Uncompressed) / r.Elapsed.Seconds()
+}
+
+// WireThroughput returns the compressed bytes per second.
+func (r TransferReport) WireThroughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Compressed) / r.Elapsed.Seconds()
+}
+
+// ReportTransfers calls report every interval with the bytes c counted
+// since the previous report, until ctx is done. Idle intervals are skipped.
+// If ratio is non-nil it is set to each reported compression ratio.
+func ReportTransfers(ctx context.Context, c *TransferCounter, interval time.Duration, ratio prometheus.Gauge, report func(TransferReport)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	prev := c.Totals()
+	last := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			cur := c.Totals()
+			r := TransferReport{TransferTotals: cur.Sub(prev), Elapsed: now.Sub(last)}
+			prev, last = cur, now
+			if r.Uncompressed == 0 {
+				continue
+			}
+			if ratio != nil {
+				ratio.Set(r.Ratio())
+			}
+			report(r)
+		}
+	}
+}
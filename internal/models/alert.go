@@ -33,11 +33,13 @@ type AlertRule struct {
 	Severity    Severity      `json:"severity"`
 	Window      time.Duration `json:"window"`
 	Cooldown    time.Duration `json:"cooldown"`
-	Notify      []string      `json:"notify"`
+	Notify      []string      `json:"notify"` // nil inherits project defaults; empty opts out
 	Enabled     bool          `json:"enabled"`
 	ProjectID   string        `json:"project_id,omitempty"`
 	CreatedAt   time.Time     `json:"created_at"`
 	UpdatedAt   time.Time     `json:"updated_at"`
+
+	NotifyTemplate string `json:"notify_template,omitempty"` // Go template overriding channel formatting
 }
 
 // NewAlertRule creates a new AlertRule with initialized timestamps.
@@ -48,7 +50,6 @@ func NewAlertRule(name string, alertType AlertType, severity Severity) *AlertRul
 		Type:      alertType,
 		Severity:  severity,
 		Enabled:   true,
-		Notify:    []string{},
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
@@ -13,5 +13,6 @@ type AlertHistory struct {
 	MatchedLogs int       `json:"matched_logs"`
 	NotifiedAt  time.Time `json:"notified_at"`
 	ProjectID   string    `json:"project_id,omitempty"`
+	ReplayOf    string    `json:"replay_of,omitempty"` // ID of the entry a replayed notification re-sent
 	CreatedAt   time.Time `json:"created_at"`
 }
@@ -27,7 +27,12 @@ const (
 	LogTypeMagento    LogType = "magento"
 	LogTypePrestaShop LogType = "prestashop"
 	LogTypeWordPress  LogType = "wordpress"
+	LogTypeSyslog     LogType = "syslog"
+	LogTypeJSON       LogType = "json"
+	LogTypePostgres   LogType = "postgres"
+	LogTypeMySQL      LogType = "mysql"
 	LogTypeCustom     LogType = "custom"
+	LogTypeUnparsed   LogType = "unparsed" // lines forwarded as is after their parser rejected them
 	LogTypeUnknown    LogType = "unknown"
 )
 
@@ -6,11 +6,14 @@ import (
 
 // Project represents a logical grouping of connections and alerts.
 type Project struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// DefaultNotify lists notification channels inherited by the project's
+	// alert rules that don't set their own.
+	DefaultNotify []string  `json:"default_notify,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
 // NewProject creates a new Project with initialized timestamps.
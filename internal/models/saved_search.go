@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// SavedSearch is a named log query a user keeps for reuse.
+// The time range is stored relative to the moment the search is run.
+type SavedSearch struct {
+	ID         string        `json:"id"`
+	Name       string        `json:"name"`
+	Filter     string        `json:"filter,omitempty"`
+	SearchMode string        `json:"search_mode,omitempty"`
+	Range      time.Duration `json:"range"`
+	ProjectID  string        `json:"project_id,omitempty"`
+	OwnerID    string        `json:"owner_id"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+}
+
+// NewSavedSearch creates a new SavedSearch with initialized timestamps.
+func NewSavedSearch(name, ownerID string) *SavedSearch {
+	now := time.Now()
+	return &SavedSearch{
+		Name:      name,
+		OwnerID:   ownerID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+)
+
+// sharedFilterCodeBytes is the number of random bytes in a share code.
+// 9 bytes encode to a 12-character URL-safe string.
+const sharedFilterCodeBytes = 9
+
+// SharedFilter is an immutable, shareable log query identified by a short code.
+// The time range is stored relative to the moment the code is resolved.
+type SharedFilter struct {
+	Code       string        `json:"code"`
+	Filter     string        `json:"filter,omitempty"`
+	SearchMode string        `json:"search_mode,omitempty"`
+	Range      time.Duration `json:"range"`
+	ProjectID  string        `json:"project_id,omitempty"`
+	CreatedBy  string        `json:"created_by"`
+	ExpiresAt  time.Time     `json:"expires_at"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+// NewSharedFilter creates a new SharedFilter with a random short code.
+func NewSharedFilter(createdBy string, ttl time.Duration) (*SharedFilter, error) {
+	code, err := generateShareCode()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &SharedFilter{
+		Code:      code,
+		CreatedBy: createdBy,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}, nil
+}
+
+// IsExpired returns true if the shared filter has expired.
+func (f *SharedFilter) IsExpired() bool {
+	return time.Now().After(f.ExpiresAt)
+}
+
+// generateShareCode returns an opaque URL-safe code.
+func generateShareCode() (string, error) {
+	b := make([]byte, sharedFilterCodeBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"html"
 	"log"
 	"net"
 	"net/smtp"
@@ -74,18 +75,9 @@ func (e *EmailNotifier) Name() string {
 
 // Send sends an alert to all configured recipients.
 func (e *EmailNotifier) Send(ctx context.Context, alert *alerting.Alert) error {
-	// Convert alert to template data
-	data := AlertToTemplateData(alert)
-
-	// Render templates
-	htmlBody, err := e.templates.RenderHTML(&data)
-	if err != nil {
-		return fmt.Errorf("failed to render HTML template: %w", err)
-	}
-
-	plainBody, err := e.templates.RenderPlain(&data)
+	htmlBody, plainBody, err := e.renderBodies(alert)
 	if err != nil {
-		return fmt.Errorf("failed to render plain template: %w", err)
+		return err
 	}
 
 	// Build subject
@@ -98,6 +90,30 @@ func (e *EmailNotifier) Send(ctx context.Context, alert *alerting.Alert) error {
 	return e.sendMail(ctx, msg)
 }
 
+// renderBodies renders the HTML and plain text bodies, using the rule's
+// notification template when it has one.
+func (e *EmailNotifier) renderBodies(alert *alerting.Alert) (htmlBody, plainBody string, err error) {
+	if text, ok := customMessage(alert); ok {
+		htmlBody = `<pre style="font-family: inherit; white-space: pre-wrap;">` + html.EscapeString(text) + "</pre>"
+		return htmlBody, text, nil
+	}
+
+	// Convert alert to template data
+	data := AlertToTemplateData(alert)
+
+	// Render templates
+	htmlBody, err = e.templates.RenderHTML(&data)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to render HTML template: %w", err)
+	}
+
+	plainBody, err = e.templates.RenderPlain(&data)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to render plain template: %w", err)
+	}
+	return htmlBody, plainBody, nil
+}
+
 // Close clears the password reference.
 // Note: Go strings are immutable; []byte(str) creates a copy, so zeroing
 // pwBytes doesn't affect the original string memory. The GC will eventually
@@ -297,6 +297,33 @@ func TestDispatcherDispatchAll(t *testing.T) {
 	}
 }
 
+func TestEmailRenderBodiesCustomTemplate(t *testing.T) {
+	templates, err := LoadTemplates()
+	if err != nil {
+		t.Fatalf("LoadTemplates: %v", err)
+	}
+	e := &EmailNotifier{templates: templates}
+
+	alert := &alerting.Alert{
+		RuleName:       "Queue backlog",
+		Severity:       alerting.SeverityHigh,
+		Timestamp:      time.Now(),
+		Count:          7,
+		NotifyTemplate: "{{.RuleName}} <{{.Count}}>",
+	}
+
+	htmlBody, plainBody, err := e.renderBodies(alert)
+	if err != nil {
+		t.Fatalf("renderBodies: %v", err)
+	}
+	if plainBody != "Queue backlog <7>" {
+		t.Errorf("plain body = %q", plainBody)
+	}
+	if !strings.Contains(htmlBody, "Queue backlog &lt;7&gt;") {
+		t.Errorf("HTML body not escaped: %q", htmlBody)
+	}
+}
+
 func TestBuildMIMEMessage(t *testing.T) {
 	notifier := &EmailNotifier{
 		config: EmailConfig{
@@ -108,8 +108,18 @@ type slackText struct {
 	Emoji bool   `json:"emoji,omitempty"`
 }
 
+// slackSectionLimit is the maximum text length of a Slack section block.
+const slackSectionLimit = 3000
+
 // buildPayload builds the Slack Block Kit message payload.
 func (s *SlackNotifier) buildPayload(alert *alerting.Alert) slackMessage {
+	if text, ok := customMessage(alert); ok {
+		return slackMessage{Blocks: []slackBlock{{
+			Type: "section",
+			Text: &slackText{Type: "mrkdwn", Text: truncate(text, slackSectionLimit)},
+		}}}
+	}
+
 	emoji := severityEmoji(alert.Severity)
 	timestamp := alert.Timestamp.Format("2006-01-02 15:04:05 MST")
 
@@ -356,3 +356,40 @@ func TestSlackBlockFormatting(t *testing.T) {
 		t.Error("JSON missing severity")
 	}
 }
+
+func TestSlackCustomTemplate(t *testing.T) {
+	notifier := &SlackNotifier{}
+
+	tests := []struct {
+		name       string
+		template   string
+		wantBlocks int
+		wantText   string
+	}{
+		{"template replaces default", "*{{.RuleName}}*: {{.Count}} errors", 1, "*5xx burst*: 42 errors"},
+		{"render failure falls back", "{{.TriggeringEntry.Message}}", 4, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert := &alerting.Alert{
+				RuleName:       "5xx burst",
+				Severity:       alerting.SeverityHigh,
+				Timestamp:      time.Now(),
+				Count:          42,
+				Threshold:      10,
+				Window:         "1m",
+				NotifyTemplate: tt.template,
+			}
+
+			payload := notifier.buildPayload(alert)
+
+			if len(payload.Blocks) != tt.wantBlocks {
+				t.Fatalf("blocks = %d, want %d", len(payload.Blocks), tt.wantBlocks)
+			}
+			if tt.wantText != "" && payload.Blocks[0].Text.Text != tt.wantText {
+				t.Errorf("text = %q, want %q", payload.Blocks[0].Text.Text, tt.wantText)
+			}
+		})
+	}
+}
@@ -141,6 +141,12 @@ func (t *TeamsNotifier) buildPayload(alert *alerting.Alert) teamsMessage {
 	emoji := severityEmoji(alert.Severity)
 	color := teamsSeverityStyle(alert.Severity)
 
+	if text, ok := customMessage(alert); ok {
+		return teamsCard([]interface{}{
+			textBlock{Type: "TextBlock", Text: text, Wrap: true},
+		})
+	}
+
 	body := []interface{}{}
 
 	// Header container with severity color
@@ -231,6 +237,11 @@ func (t *TeamsNotifier) buildPayload(alert *alerting.Alert) teamsMessage {
 		})
 	}
 
+	return teamsCard(body)
+}
+
+// teamsCard wraps Adaptive Card body elements in a Teams message.
+func teamsCard(body []interface{}) teamsMessage {
 	return teamsMessage{
 		Type: "message",
 		Attachments: []teamsAttachment{
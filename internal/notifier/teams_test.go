@@ -410,3 +410,29 @@ func TestNewTeamsNotifierValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestTeamsCustomTemplate(t *testing.T) {
+	notifier := &TeamsNotifier{}
+
+	alert := &alerting.Alert{
+		RuleName:       "Checkout errors",
+		Severity:       alerting.SeverityCritical,
+		Timestamp:      time.Now(),
+		Labels:         map[string]string{"runbook": "https://runbooks/checkout"},
+		NotifyTemplate: "{{.RuleName}} - runbook: {{.Labels.runbook}}",
+	}
+
+	payload := notifier.buildPayload(alert)
+
+	body := payload.Attachments[0].Content.Body
+	if len(body) != 1 {
+		t.Fatalf("body elements = %d, want 1", len(body))
+	}
+	block, ok := body[0].(textBlock)
+	if !ok {
+		t.Fatalf("body[0] = %T, want textBlock", body[0])
+	}
+	if want := "Checkout errors - runbook: https://runbooks/checkout"; block.Text != want {
+		t.Errorf("text = %q, want %q", block.Text, want)
+	}
+}
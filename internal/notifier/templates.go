@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"embed"
 	"html/template"
+	"log"
 	"strings"
 
 	"github.com/good-yellow-bee/blazelog/internal/alerting"
@@ -127,3 +128,15 @@ func AlertToTemplateData(alert *alerting.Alert) TemplateData {
 
 	return data
 }
+
+// customMessage returns the alert's rendered notification template. When the
+// rule has no template, or it fails to render, ok is false and the channel
+// falls back to its default formatting so the alert still goes out.
+func customMessage(alert *alerting.Alert) (text string, ok bool) {
+	text, ok, err := alert.RenderNotification()
+	if err != nil {
+		log.Printf("alert %s: %v; using default formatting", alert.RuleName, err)
+		return "", false
+	}
+	return text, ok
+}
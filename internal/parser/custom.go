@@ -24,6 +24,8 @@ type CustomParserConfig struct {
 	JSONMode bool `yaml:"json_mode,omitempty"`
 	// StartPattern identifies the start of a new log entry (for multiline).
 	StartPattern string `yaml:"start_pattern,omitempty"`
+	// MultiLineMode is "merge" (default) or "first"; see MultiLineMode.
+	MultiLineMode string `yaml:"multiline_mode,omitempty"`
 	// TimestampField is the name of the field/group containing the timestamp.
 	TimestampField string `yaml:"timestamp_field,omitempty"`
 	// TimestampFormat is the Go time format for parsing timestamps.
@@ -38,6 +40,9 @@ type CustomParserConfig struct {
 	DefaultLevel string `yaml:"default_level,omitempty"`
 	// Labels are static labels added to all parsed entries.
 	Labels map[string]string `yaml:"labels,omitempty"`
+	// Prefix makes this a prefix demux parser (see PrefixParser); the
+	// format fields above except Labels are then ignored.
+	Prefix *PrefixConfig `yaml:"prefix,omitempty"`
 }
 
 // CustomParser implements the Parser interface for user-defined log formats.
@@ -47,6 +52,8 @@ type CustomParser struct {
 	regex      *regexp.Regexp
 	startRegex *regexp.Regexp
 	groupNames map[string]int
+
+	multiLineMode MultiLineMode
 }
 
 // NewCustomParser creates a new custom parser from configuration.
@@ -90,6 +97,16 @@ func NewCustomParser(cfg *CustomParserConfig, opts *Options) (*CustomParser, err
 		p.startRegex = startRegex
 	}
 
+	mode, ok := ParseMultiLineMode(cfg.MultiLineMode)
+	if !ok {
+		return nil, fmt.Errorf("invalid multiline_mode %q for parser %q", cfg.MultiLineMode, cfg.Name)
+	}
+	if mode == "" {
+		// Custom parsers have always merged continuation lines
+		mode = MultiLineMerge
+	}
+	p.multiLineMode = mode
+
 	// Set defaults
 	if cfg.TimestampField == "" {
 		cfg.TimestampField = "timestamp"
@@ -309,35 +326,5 @@ func (p *CustomParser) IsStartOfEntry(line string) bool {
 
 // ParseMultiLine parses multiple lines as a single log entry.
 func (p *CustomParser) ParseMultiLine(lines []string) (*models.LogEntry, error) {
-	if len(lines) == 0 {
-		return nil, ErrEmptyLine
-	}
-
-	// Parse the first line
-	entry, err := p.Parse(lines[0])
-	if err != nil {
-		return nil, err
-	}
-
-	// Append additional lines to message as stack trace
-	if len(lines) > 1 {
-		var stackTrace strings.Builder
-		for i := 1; i < len(lines); i++ {
-			if i > 1 {
-				stackTrace.WriteString("\n")
-			}
-			stackTrace.WriteString(lines[i])
-		}
-
-		entry.Fields["stack_trace"] = stackTrace.String()
-		entry.Fields["stack_frame_count"] = len(lines) - 1
-		entry.Fields["multiline"] = true
-
-		// Append to message if it exists
-		if entry.Message != "" {
-			entry.Message = entry.Message + "\n" + stackTrace.String()
-		}
-	}
-
-	return entry, nil
+	return p.parseMultiLine(lines, p.Parse, nil, p.multiLineMode)
 }
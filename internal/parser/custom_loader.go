@@ -40,8 +40,18 @@ func RegisterCustomParsers(registry *Registry, configs []CustomParserConfig) err
 		seen[name] = true
 	}
 
-	// Load and register
-	parsers, err := LoadCustomParsers(configs)
+	// Load and register; prefix parsers last so their inner parser can be
+	// another custom parser
+	var regular, prefixed []CustomParserConfig
+	for i := range configs {
+		if configs[i].Prefix != nil {
+			prefixed = append(prefixed, configs[i])
+		} else {
+			regular = append(regular, configs[i])
+		}
+	}
+
+	parsers, err := LoadCustomParsers(regular)
 	if err != nil {
 		return err
 	}
@@ -50,5 +60,18 @@ func RegisterCustomParsers(registry *Registry, configs []CustomParserConfig) err
 		registry.Register(p)
 	}
 
+	for i := range prefixed {
+		cfg := &prefixed[i]
+		opts := DefaultParserOptions()
+		for k, v := range cfg.Labels {
+			opts.Labels[k] = v
+		}
+		p, err := NewPrefixParser(cfg.Name, cfg.Prefix, registry, opts)
+		if err != nil {
+			return fmt.Errorf("load parser %q: %w", cfg.Name, err)
+		}
+		registry.Register(p)
+	}
+
 	return nil
 }
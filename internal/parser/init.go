@@ -26,4 +26,20 @@ func init() {
 	// Register WordPress parser for auto-detection
 	// WordPress uses PHP debug.log format with timestamps like [DD-Mon-YYYY HH:MM:SS TZ]
 	Register(NewWordPressParser(nil))
+
+	// Register syslog parser for auto-detection
+	// Handles BSD syslog (RFC 3164) with or without the <PRI> prefix
+	Register(NewSyslogParser(nil))
+
+	// Register PostgreSQL parser for auto-detection
+	// Handles the stderr format with the default log_line_prefix and csvlog
+	Register(NewPostgresParser(nil))
+
+	// Register MySQL parser for auto-detection
+	// Handles MySQL 5.7/8 and MariaDB error logs
+	Register(NewMySQLParser(nil))
+
+	// Register JSON parser for auto-detection
+	// Handles one JSON object per line with a "msg" key
+	Register(NewJSONParser(nil))
 }
@@ -0,0 +1,185 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/models"
+)
+
+// Default keys read by the JSON parser, as written by slog, zap and logrus
+// style structured loggers.
+const (
+	DefaultJSONTimeKey    = "time"
+	DefaultJSONLevelKey   = "level"
+	DefaultJSONMessageKey = "msg"
+)
+
+// JSONParser parses structured logs written as one JSON object per line.
+// Example:
+//
+//	{"time":"2024-01-15T10:23:45Z","level":"error","msg":"payment failed","order_id":42}
+//
+// The time, level and message keys map onto the entry's Timestamp, Level
+// and Message; every other key is kept in Fields as decoded.
+type JSONParser struct {
+	*BaseParser
+	timeKey    string
+	levelKey   string
+	messageKey string
+	timeFormat string
+	location   *time.Location
+}
+
+// NewJSONParser creates a new JSON line parser. opts.TimeKey, LevelKey and
+// MessageKey override the default keys. String timestamps are read as
+// RFC 3339 unless opts.TimeFormat is set, in opts.TimeZone (default UTC);
+// numeric ones as Unix seconds, or milliseconds when too large for seconds.
+func NewJSONParser(opts *Options) *JSONParser {
+	p := &JSONParser{
+		BaseParser: NewBaseParser(opts),
+		timeKey:    DefaultJSONTimeKey,
+		levelKey:   DefaultJSONLevelKey,
+		messageKey: DefaultJSONMessageKey,
+		timeFormat: time.RFC3339Nano,
+		location:   time.UTC,
+	}
+	if opts != nil {
+		if opts.TimeKey != "" {
+			p.timeKey = opts.TimeKey
+		}
+		if opts.LevelKey != "" {
+			p.levelKey = opts.LevelKey
+		}
+		if opts.MessageKey != "" {
+			p.messageKey = opts.MessageKey
+		}
+		if opts.TimeFormat != "" {
+			p.timeFormat = opts.TimeFormat
+		}
+		if opts.TimeZone != "" {
+			if loc, err := time.LoadLocation(opts.TimeZone); err == nil {
+				p.location = loc
+			}
+		}
+	}
+	return p
+}
+
+// Parse parses a single JSON log line.
+func (p *JSONParser) Parse(line string) (*models.LogEntry, error) {
+	return p.ParseWithContext(context.Background(), line)
+}
+
+// ParseWithContext parses a single JSON log line with context support.
+func (p *JSONParser) ParseWithContext(ctx context.Context, line string) (*models.LogEntry, error) {
+	if line == "" {
+		return nil, ErrEmptyLine
+	}
+
+	data, err := decodeJSONObject(line)
+	if err != nil {
+		return nil, err
+	}
+	msg, ok := data[p.messageKey]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing %q key", ErrInvalidFormat, p.messageKey)
+	}
+
+	entry := models.NewLogEntry()
+	entry.Type = models.LogTypeJSON
+	entry.Timestamp = time.Now()
+
+	// A non-string message (a number, an object) is kept in its JSON form
+	if s, ok := msg.(string); ok {
+		entry.Message = s
+	} else if b, err := json.Marshal(msg); err == nil {
+		entry.Message = string(b)
+	}
+	delete(data, p.messageKey)
+
+	if v, ok := data[p.levelKey]; ok {
+		if s, ok := v.(string); ok {
+			entry.Level = models.ParseLogLevel(strings.ToLower(strings.TrimSpace(s)))
+			delete(data, p.levelKey)
+		}
+	}
+
+	if v, ok := data[p.timeKey]; ok {
+		if ts, ok := p.parseTime(v); ok {
+			entry.Timestamp = ts
+			delete(data, p.timeKey)
+		}
+	}
+
+	// Remaining keys, including a level or time that couldn't be read
+	for k, v := range data {
+		entry.SetField(k, v)
+	}
+
+	p.ApplyOptions(entry, line)
+	return entry, nil
+}
+
+// parseTime reads a timestamp value.
+func (p *JSONParser) parseTime(v interface{}) (time.Time, bool) {
+	switch v := v.(type) {
+	case string:
+		ts, err := time.ParseInLocation(p.timeFormat, v, p.location)
+		return ts, err == nil
+	case float64:
+		// Seconds until year 5138; larger values are milliseconds
+		if v > 1e11 {
+			return time.UnixMilli(int64(v)).UTC(), true
+		}
+		sec, frac := math.Modf(v)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC(), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// decodeJSONObject decodes line as a single JSON object.
+func decodeJSONObject(line string) (map[string]interface{}, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || line[0] != '{' {
+		return nil, ErrInvalidFormat
+	}
+	if len(line) > maxJSONSize {
+		return nil, fmt.Errorf("%w: line exceeds max JSON size (%d bytes)", ErrInvalidFormat, maxJSONSize)
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &data); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFormat, err.Error())
+	}
+	return data, nil
+}
+
+// Name returns the parser name.
+func (p *JSONParser) Name() string {
+	return "json"
+}
+
+// Type returns the log type this parser handles.
+func (p *JSONParser) Type() models.LogType {
+	return models.LogTypeJSON
+}
+
+// CanParse returns true if the line is a JSON object with the message key.
+func (p *JSONParser) CanParse(line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" || line[0] != '{' || len(line) > maxJSONSize {
+		return false
+	}
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(line), &data); err != nil {
+		return false
+	}
+	_, ok := data[p.messageKey]
+	return ok
+}
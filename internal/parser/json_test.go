@@ -0,0 +1,196 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/models"
+)
+
+// TestJSONParser_Parse tests the JSON line parser with the default keys.
+func TestJSONParser_Parse(t *testing.T) {
+	parser := NewJSONParser(nil)
+
+	tests := []struct {
+		name          string
+		line          string
+		expectError   bool
+		expectedLevel models.LogLevel
+		expectedMsg   string
+		expectedTime  time.Time
+		fields        map[string]interface{}
+	}{
+		{
+			name:          "slog style",
+			line:          `{"time":"2024-01-15T10:23:45.5Z","level":"ERROR","msg":"payment failed","order_id":42,"user":{"id":"u1"}}`,
+			expectedLevel: models.LevelError,
+			expectedMsg:   "payment failed",
+			expectedTime:  time.Date(2024, 1, 15, 10, 23, 45, 500000000, time.UTC),
+			fields: map[string]interface{}{
+				"order_id": float64(42),
+			},
+		},
+		{
+			name:          "warn",
+			line:          `{"time":"2024-01-15T10:23:45+02:00","level":"warn","msg":"slow"}`,
+			expectedLevel: models.LevelWarning,
+			expectedMsg:   "slow",
+			expectedTime:  time.Date(2024, 1, 15, 8, 23, 45, 0, time.UTC),
+		},
+		{
+			name:          "err",
+			line:          `{"time":1705314225,"level":"err","msg":"boom"}`,
+			expectedLevel: models.LevelError,
+			expectedMsg:   "boom",
+			expectedTime:  time.Date(2024, 1, 15, 10, 23, 45, 0, time.UTC),
+		},
+		{
+			name:          "critical, unix milliseconds",
+			line:          `{"time":1705314225123,"level":"Critical","msg":"down"}`,
+			expectedLevel: models.LevelFatal,
+			expectedMsg:   "down",
+			expectedTime:  time.Date(2024, 1, 15, 10, 23, 45, 123000000, time.UTC),
+		},
+		{
+			name:          "unreadable time and level kept as fields",
+			line:          `{"time":"yesterday","level":3,"msg":"odd"}`,
+			expectedLevel: models.LevelUnknown,
+			expectedMsg:   "odd",
+			fields: map[string]interface{}{
+				"time":  "yesterday",
+				"level": float64(3),
+			},
+		},
+		{
+			name:          "non-string message",
+			line:          `{"msg":{"event":"login"}}`,
+			expectedLevel: models.LevelUnknown,
+			expectedMsg:   `{"event":"login"}`,
+		},
+		{
+			name:        "missing message key",
+			line:        `{"time":"2024-01-15T10:23:45Z","message":"wrong key"}`,
+			expectError: true,
+		},
+		{
+			name:        "array",
+			line:        `[{"msg":"x"}]`,
+			expectError: true,
+		},
+		{
+			name:        "invalid JSON",
+			line:        `{"msg":"x"`,
+			expectError: true,
+		},
+		{
+			name:        "empty line",
+			line:        "",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := parser.Parse(tt.line)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if entry.Type != models.LogTypeJSON {
+				t.Errorf("expected type %v, got %v", models.LogTypeJSON, entry.Type)
+			}
+			if entry.Level != tt.expectedLevel {
+				t.Errorf("expected level %v, got %v", tt.expectedLevel, entry.Level)
+			}
+			if entry.Message != tt.expectedMsg {
+				t.Errorf("expected message %q, got %q", tt.expectedMsg, entry.Message)
+			}
+			if !tt.expectedTime.IsZero() && !entry.Timestamp.Equal(tt.expectedTime) {
+				t.Errorf("expected timestamp %v, got %v", tt.expectedTime, entry.Timestamp)
+			}
+			for key, want := range tt.fields {
+				if got := entry.Fields[key]; got != want {
+					t.Errorf("field %s: expected %v, got %v", key, want, got)
+				}
+			}
+			if _, ok := entry.Fields["msg"]; ok {
+				t.Errorf("message key should not be kept in fields")
+			}
+		})
+	}
+}
+
+// TestJSONParser_Keys tests configured keys and time format.
+func TestJSONParser_Keys(t *testing.T) {
+	parser := NewJSONParser(&Options{
+		TimeKey:    "@timestamp",
+		LevelKey:   "severity",
+		MessageKey: "message",
+		TimeFormat: "2006-01-02 15:04:05",
+		TimeZone:   "Europe/Berlin",
+		IncludeRaw: true,
+	})
+
+	line := `{"@timestamp":"2024-07-01 12:00:00","severity":"fatal","message":"out of memory","msg":"other"}`
+	entry, err := parser.Parse(line)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if entry.Message != "out of memory" {
+		t.Errorf("expected message %q, got %q", "out of memory", entry.Message)
+	}
+	if entry.Level != models.LevelFatal {
+		t.Errorf("expected level fatal, got %v", entry.Level)
+	}
+	if expected := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC); !entry.Timestamp.Equal(expected) {
+		t.Errorf("expected timestamp %v, got %v", expected, entry.Timestamp)
+	}
+	if entry.Fields["msg"] != "other" {
+		t.Errorf("expected field msg=other, got %v", entry.Fields["msg"])
+	}
+	for _, key := range []string{"@timestamp", "severity", "message"} {
+		if _, ok := entry.Fields[key]; ok {
+			t.Errorf("mapped key %s should not be kept in fields", key)
+		}
+	}
+	if entry.Raw != line {
+		t.Errorf("expected raw %q, got %q", line, entry.Raw)
+	}
+}
+
+// TestJSONParser_CanParse tests JSON line detection.
+func TestJSONParser_CanParse(t *testing.T) {
+	parser := NewJSONParser(nil)
+
+	tests := []struct {
+		line     string
+		expected bool
+	}{
+		{`{"time":"2024-01-15T10:23:45Z","level":"info","msg":"started"}`, true},
+		{`  {"msg":""}  `, true},
+		{`{"time":"2024-01-15T10:23:45Z","message":"wrong key"}`, false},
+		{`{"msg":"truncated"`, false},
+		{`{"msg":"x"} trailing`, false},
+		{`["msg"]`, false},
+		{`[2024-01-15T10:23:45.123456+00:00] main.ERROR: Something failed {"msg":"x"} []`, false},
+		{`<34>Oct 11 22:14:15 mymachine su[230]: {"msg":"x"}`, false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		got := parser.CanParse(tt.line)
+		if got != tt.expected {
+			t.Errorf("CanParse(%q): expected %v, got %v", tt.line, tt.expected, got)
+		}
+	}
+
+	if custom := NewJSONParser(&Options{MessageKey: "message"}); !custom.CanParse(`{"message":"x"}`) {
+		t.Errorf("CanParse with message key %q: expected true", "message")
+	}
+}
@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/good-yellow-bee/blazelog/internal/models"
+)
+
+// inferenceOrder is the order levels are tried in, most severe first, so a
+// message mentioning both "warning" and "error" is an error.
+var inferenceOrder = []models.LogLevel{
+	models.LevelFatal,
+	models.LevelError,
+	models.LevelWarning,
+	models.LevelInfo,
+	models.LevelDebug,
+}
+
+// DefaultLevelKeywords returns the built-in keywords for level inference.
+func DefaultLevelKeywords() map[models.LogLevel][]string {
+	return map[models.LogLevel][]string{
+		models.LevelFatal:   {"fatal", "panic", "critical", "crit", "emerg", "emergency"},
+		models.LevelError:   {"error", "errors", "err", "fail", "failed", "failure", "exception", "traceback"},
+		models.LevelWarning: {"warn", "warning", "warnings", "deprecated"},
+		models.LevelInfo:    {"info", "notice"},
+		models.LevelDebug:   {"debug", "trace"},
+	}
+}
+
+// LevelInferencer assigns a level to entries their parser left at
+// LevelUnknown, from whole-word, case-insensitive keywords in the message.
+type LevelInferencer struct {
+	levels   []models.LogLevel
+	patterns []*regexp.Regexp
+}
+
+// NewLevelInferencer creates a level inferencer from the default keywords
+// plus extra, keyed by level name (debug, info, warning, error, fatal).
+func NewLevelInferencer(extra map[string][]string) (*LevelInferencer, error) {
+	keywords := DefaultLevelKeywords()
+	for name, words := range extra {
+		level := models.LogLevel(strings.ToLower(name))
+		if _, ok := keywords[level]; !ok {
+			return nil, fmt.Errorf("unknown level %q (want debug, info, warning, error or fatal)", name)
+		}
+		for _, w := range words {
+			if strings.TrimSpace(w) == "" {
+				return nil, fmt.Errorf("empty keyword for level %q", name)
+			}
+			keywords[level] = append(keywords[level], strings.TrimSpace(w))
+		}
+	}
+
+	li := &LevelInferencer{}
+	for _, level := range inferenceOrder {
+		words := keywords[level]
+		// Longest first so alternation prefers "failure" over "fail"
+		sort.Slice(words, func(i, j int) bool { return len(words[i]) > len(words[j]) })
+		quoted := make([]string, len(words))
+		for i, w := range words {
+			quoted[i] = regexp.QuoteMeta(w)
+		}
+		li.levels = append(li.levels, level)
+		li.patterns = append(li.patterns, regexp.MustCompile(`(?i)\b(?:`+strings.Join(quoted, "|")+`)\b`))
+	}
+	return li, nil
+}
+
+// Infer returns the level implied by message, or LevelUnknown.
+func (li *LevelInferencer) Infer(message string) models.LogLevel {
+	for i, re := range li.patterns {
+		if re.MatchString(message) {
+			return li.levels[i]
+		}
+	}
+	return models.LevelUnknown
+}
+
+// Apply sets the level of an entry whose level is unknown (or empty) from
+// its message. Entries with a parsed level are left alone. It reports
+// whether the level was changed.
+func (li *LevelInferencer) Apply(entry *models.LogEntry) bool {
+	if entry.Level != models.LevelUnknown && entry.Level != "" {
+		return false
+	}
+	level := li.Infer(entry.Message)
+	if level == models.LevelUnknown {
+		return false
+	}
+	entry.Level = level
+	return true
+}
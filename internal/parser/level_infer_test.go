@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/good-yellow-bee/blazelog/internal/models"
+)
+
+func TestLevelInferencer_Infer(t *testing.T) {
+	li, err := NewLevelInferencer(map[string][]string{
+		"error":   {"timeout", "connection refused"},
+		"WARNING": {"slow"},
+	})
+	if err != nil {
+		t.Fatalf("NewLevelInferencer() error = %v", err)
+	}
+
+	tests := []struct {
+		message string
+		want    models.LogLevel
+	}{
+		{"Failed to open socket", models.LevelError},
+		{"java.lang.NullPointerException: oops", models.LevelUnknown}, // not a whole word
+		{"Unhandled exception in worker", models.LevelError},
+		{"WARN disk almost full", models.LevelWarning},
+		{"warning: retrying after error", models.LevelError}, // most severe wins
+		{"kernel panic - not syncing", models.LevelFatal},
+		{"upstream timeout after 30s", models.LevelError},
+		{"Connection Refused by peer", models.LevelError},
+		{"slow query took 2s", models.LevelWarning},
+		{"errorless run", models.LevelUnknown},
+		{"stderr closed", models.LevelUnknown},
+		{"GET /health 200", models.LevelUnknown},
+		{"debug: cache miss", models.LevelDebug},
+	}
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			if got := li.Infer(tt.message); got != tt.want {
+				t.Errorf("Infer(%q) = %q, want %q", tt.message, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLevelInferencer_Apply(t *testing.T) {
+	li, err := NewLevelInferencer(nil)
+	if err != nil {
+		t.Fatalf("NewLevelInferencer() error = %v", err)
+	}
+
+	unknown := models.NewLogEntry()
+	unknown.Message = "request failed"
+	if !li.Apply(unknown) || unknown.Level != models.LevelError {
+		t.Errorf("Apply() on unknown level = %q, want error", unknown.Level)
+	}
+
+	parsed := models.NewLogEntry()
+	parsed.Level = models.LevelInfo
+	parsed.Message = "retry failed"
+	if li.Apply(parsed) || parsed.Level != models.LevelInfo {
+		t.Errorf("Apply() changed a parsed level to %q", parsed.Level)
+	}
+}
+
+func TestNewLevelInferencer_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		extra map[string][]string
+	}{
+		{"unknown level", map[string][]string{"severe": {"boom"}}},
+		{"empty keyword", map[string][]string{"error": {" "}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewLevelInferencer(tt.extra); err == nil {
+				t.Error("NewLevelInferencer() should fail")
+			}
+		})
+	}
+}
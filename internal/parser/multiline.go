@@ -0,0 +1,167 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/good-yellow-bee/blazelog/internal/models"
+)
+
+// MultiLineMode controls how continuation lines are folded into an entry.
+type MultiLineMode string
+
+// Multiline assembly modes.
+const (
+	// MultiLineFirst keeps the start line's message; continuation lines
+	// are only stored in the stack_trace field.
+	MultiLineFirst MultiLineMode = "first"
+
+	// MultiLineMerge appends continuation lines to the message as well.
+	MultiLineMerge MultiLineMode = "merge"
+)
+
+// ThrownOriginMode controls how the "thrown in <file> on line <n>" tail of
+// a PHP exception sets the php_file and php_line fields.
+type ThrownOriginMode string
+
+// Thrown origin modes.
+const (
+	// ThrownOriginOverride sets php_file/php_line from the tail, replacing
+	// a location parsed from the start line. The tail names where the
+	// exception was thrown, which the message often does not.
+	ThrownOriginOverride ThrownOriginMode = "override"
+
+	// ThrownOriginFill sets php_file/php_line from the tail only when the
+	// start line carried no location.
+	ThrownOriginFill ThrownOriginMode = "fill"
+
+	// ThrownOriginIgnore leaves the tail in stack_trace only.
+	ThrownOriginIgnore ThrownOriginMode = "ignore"
+)
+
+// thrownInRegex matches the tail line PHP prints after an uncaught
+// exception's stack trace.
+var thrownInRegex = regexp.MustCompile(`^\s*thrown in (\S+) on line (\d+)\s*$`)
+
+// ParseMultiLineMode parses a multiline mode string. Empty input returns "".
+func ParseMultiLineMode(s string) (MultiLineMode, bool) {
+	switch MultiLineMode(strings.ToLower(strings.TrimSpace(s))) {
+	case "":
+		return "", true
+	case MultiLineFirst:
+		return MultiLineFirst, true
+	case MultiLineMerge:
+		return MultiLineMerge, true
+	default:
+		return "", false
+	}
+}
+
+// ParseMultiLineAt parses lines as a single entry whose first line is
+// startLine in the source file. The entry's line number is always the start
+// line's, regardless of how many continuation lines were assembled.
+func ParseMultiLineAt(p MultiLineParser, lines []string, startLine int64) (*models.LogEntry, error) {
+	entry, err := p.ParseMultiLine(lines)
+	if err != nil {
+		return nil, err
+	}
+	entry.LineNumber = startLine
+	return entry, nil
+}
+
+// parseMultiLine is the shared ParseMultiLine implementation.
+//
+// The start line is parsed with parse and is authoritative for the entry's
+// timestamp and line number; continuation lines never change them, even if
+// they carry timestamp fragments of their own. isFrame reports whether a
+// continuation line counts as a stack frame (nil counts every line), and
+// mode falls back to defaultMode when the parser options leave it unset.
+func (p *BaseParser) parseMultiLine(lines []string, parse func(string) (*models.LogEntry, error), isFrame func(string) bool, defaultMode MultiLineMode) (*models.LogEntry, error) {
+	if len(lines) == 0 {
+		return nil, ErrEmptyLine
+	}
+
+	entry, err := parse(lines[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 1 {
+		return entry, nil
+	}
+
+	timestamp := entry.Timestamp
+	lineNumber := entry.LineNumber
+
+	// Continuation lines form the stack trace
+	continuation := lines[1:]
+	stackTrace := strings.Join(continuation, "\n")
+	entry.SetField("stack_trace", stackTrace)
+
+	frameCount := 0
+	for _, line := range continuation {
+		if isFrame == nil || isFrame(line) {
+			frameCount++
+		}
+	}
+	if frameCount > 0 {
+		entry.SetField("stack_frame_count", frameCount)
+	}
+
+	mode := defaultMode
+	if p.options != nil && p.options.MultiLineMode != "" {
+		mode = p.options.MultiLineMode
+	}
+	if mode == MultiLineMerge && entry.Message != "" {
+		entry.Message = entry.Message + "\n" + stackTrace
+	}
+
+	// Update raw to include all lines if IncludeRaw is enabled
+	if p.options != nil && p.options.IncludeRaw {
+		entry.Raw = strings.Join(lines, "\n")
+	}
+
+	entry.SetField("multiline", true)
+	entry.SetField("line_count", len(lines))
+
+	// The start line stays authoritative
+	entry.Timestamp = timestamp
+	entry.LineNumber = lineNumber
+
+	return entry, nil
+}
+
+// isPHPStackFrame reports whether a line is a PHP stack frame ("#0 ...").
+func isPHPStackFrame(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "#")
+}
+
+// parsePHPMultiLine is parseMultiLine for PHP logs: "#n" continuation lines
+// are stack frames, and a "thrown in" tail sets the exception origin per
+// the ThrownOrigin option, even when the trace has no numbered frames.
+func (p *BaseParser) parsePHPMultiLine(lines []string, parse func(string) (*models.LogEntry, error)) (*models.LogEntry, error) {
+	entry, err := p.parseMultiLine(lines, parse, isPHPStackFrame, MultiLineFirst)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := ThrownOriginOverride
+	if p.options != nil && p.options.ThrownOrigin != "" {
+		mode = p.options.ThrownOrigin
+	}
+	if mode == ThrownOriginIgnore {
+		return entry, nil
+	}
+	if _, ok := entry.GetField("php_file"); ok && mode == ThrownOriginFill {
+		return entry, nil
+	}
+
+	// PHP prints the tail after the trace, so search from the end
+	for i := len(lines) - 1; i > 0; i-- {
+		if matches := thrownInRegex.FindStringSubmatch(lines[i]); matches != nil {
+			entry.SetField("php_file", matches[1])
+			entry.SetField("php_line", matches[2])
+			break
+		}
+	}
+	return entry, nil
+}
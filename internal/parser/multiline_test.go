@@ -0,0 +1,199 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseMultiLine_StartLineAuthoritative checks that every multiline
+// parser keeps the start line's timestamp and line number.
+func TestParseMultiLine_StartLineAuthoritative(t *testing.T) {
+	custom, err := NewCustomParser(&CustomParserConfig{
+		Name:            "custom-multiline",
+		Pattern:         `^(?P<timestamp>\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z) (?P<level>\w+) (?P<message>.*)$`,
+		StartPattern:    `^\d{4}-\d{2}-\d{2}T`,
+		LevelField:      "level",
+		MessageField:    "message",
+		TimestampFormat: time.RFC3339,
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewCustomParser: %v", err)
+	}
+
+	start := time.Date(2024, 1, 15, 10, 23, 45, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		parser MultiLineParser
+		first  string
+	}{
+		{"magento", NewMagentoParser(nil), `[2024-01-15 10:23:45] main.CRITICAL: Boom [] []`},
+		{"prestashop", NewPrestaShopParser(nil), `[2024-01-15 10:23:45] request.CRITICAL: Boom [] []`},
+		{"wordpress", NewWordPressParser(nil), `[15-Jan-2024 10:23:45 UTC] PHP Fatal error:  Boom`},
+		{"custom", custom, `2024-01-15T10:23:45Z ERROR Boom`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := []string{
+				tt.first,
+				`#0 [2024-01-16 09:00:00] /var/www/app/File.php(12): run()`,
+				`#1 2024-01-16T09:00:01Z {main}`,
+			}
+
+			entry, err := ParseMultiLineAt(tt.parser, lines, 42)
+			if err != nil {
+				t.Fatalf("ParseMultiLineAt: %v", err)
+			}
+
+			if !entry.Timestamp.Equal(start) {
+				t.Errorf("timestamp = %v, want start line's %v", entry.Timestamp, start)
+			}
+			if entry.LineNumber != 42 {
+				t.Errorf("line_number = %d, want 42", entry.LineNumber)
+			}
+			if got := entry.GetFieldInt("line_count"); got != 3 {
+				t.Errorf("line_count = %d, want 3", got)
+			}
+			if got := entry.GetFieldInt("stack_frame_count"); got != 2 {
+				t.Errorf("stack_frame_count = %d, want 2", got)
+			}
+			if multiline, _ := entry.GetField("multiline"); multiline != true {
+				t.Error("multiline field should be true")
+			}
+		})
+	}
+}
+
+func TestParseMultiLine_Mode(t *testing.T) {
+	lines := []string{
+		`[2024-01-15 10:23:45] main.CRITICAL: Boom [] []`,
+		`#0 {main}`,
+	}
+
+	entry, err := NewMagentoParser(nil).ParseMultiLine(lines)
+	if err != nil {
+		t.Fatalf("ParseMultiLine: %v", err)
+	}
+	if entry.Message != "Boom" {
+		t.Errorf("first mode message = %q, want start line only", entry.Message)
+	}
+
+	opts := DefaultParserOptions()
+	opts.MultiLineMode = MultiLineMerge
+	entry, err = NewMagentoParser(opts).ParseMultiLine(lines)
+	if err != nil {
+		t.Fatalf("ParseMultiLine: %v", err)
+	}
+	if entry.Message != "Boom\n#0 {main}" {
+		t.Errorf("merge mode message = %q, want continuation appended", entry.Message)
+	}
+}
+
+func TestCustomParser_MultiLineMode(t *testing.T) {
+	cfg := func(mode string) *CustomParserConfig {
+		return &CustomParserConfig{
+			Name:          "custom",
+			Pattern:       `^(?P<level>\w+) (?P<message>.*)$`,
+			MessageField:  "message",
+			LevelField:    "level",
+			MultiLineMode: mode,
+		}
+	}
+	lines := []string{"ERROR Boom", "  at main()"}
+
+	tests := []struct {
+		mode    string
+		want    string
+		wantErr bool
+	}{
+		{mode: "", want: "Boom\n  at main()"},
+		{mode: "merge", want: "Boom\n  at main()"},
+		{mode: "first", want: "Boom"},
+		{mode: "bogus", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			p, err := NewCustomParser(cfg(tt.mode), nil)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "multiline_mode") {
+					t.Fatalf("err = %v, want multiline_mode error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewCustomParser: %v", err)
+			}
+			entry, err := p.ParseMultiLine(lines)
+			if err != nil {
+				t.Fatalf("ParseMultiLine: %v", err)
+			}
+			if entry.Message != tt.want {
+				t.Errorf("message = %q, want %q", entry.Message, tt.want)
+			}
+		})
+	}
+}
+
+// TestParsePHPMultiLine_ThrownOrigin checks that every PHP parser takes the
+// exception origin from the "thrown in" tail, with or without frames.
+func TestParsePHPMultiLine_ThrownOrigin(t *testing.T) {
+	const (
+		tail     = `  thrown in /var/www/html/plugins/plugin.php on line 25`
+		tailFile = "/var/www/html/plugins/plugin.php"
+	)
+
+	parsers := []struct {
+		name    string
+		new     func(*Options) MultiLineParser
+		first   string
+		msgFile string // location parsed from the start line, if any
+		msgLine string
+	}{
+		{"magento", func(o *Options) MultiLineParser { return NewMagentoParser(o) },
+			`[2024-01-15 10:23:45] main.CRITICAL: Uncaught Error: Call to undefined method Test::missing() [] []`, "", ""},
+		{"prestashop", func(o *Options) MultiLineParser { return NewPrestaShopParser(o) },
+			`[2024-01-15 10:23:45] request.CRITICAL: Uncaught Error: Call to undefined method Test::missing() [] []`, "", ""},
+		{"wordpress", func(o *Options) MultiLineParser { return NewWordPressParser(o) },
+			`[15-Jan-2024 10:23:45 UTC] PHP Fatal error:  Uncaught Error: Call to undefined method Test::missing() in /var/www/html/index.php:3`, "/var/www/html/index.php", "3"},
+	}
+
+	for _, pp := range parsers {
+		fillFile, fillLine := pp.msgFile, pp.msgLine
+		if fillFile == "" {
+			fillFile, fillLine = tailFile, "25"
+		}
+
+		tests := []struct {
+			name     string
+			mode     ThrownOriginMode
+			lines    []string
+			wantFile string
+			wantLine string
+		}{
+			{"no frames", "", []string{pp.first, tail}, tailFile, "25"},
+			{"with frames", ThrownOriginOverride, []string{pp.first, "Stack trace:", "#0 {main}", tail}, tailFile, "25"},
+			{"fill", ThrownOriginFill, []string{pp.first, tail}, fillFile, fillLine},
+			{"ignore", ThrownOriginIgnore, []string{pp.first, tail}, pp.msgFile, pp.msgLine},
+		}
+
+		for _, tt := range tests {
+			t.Run(pp.name+"/"+tt.name, func(t *testing.T) {
+				opts := DefaultParserOptions()
+				opts.ThrownOrigin = tt.mode
+				entry, err := pp.new(opts).ParseMultiLine(tt.lines)
+				if err != nil {
+					t.Fatalf("ParseMultiLine: %v", err)
+				}
+				if got := entry.GetFieldString("php_file"); got != tt.wantFile {
+					t.Errorf("php_file = %q, want %q", got, tt.wantFile)
+				}
+				if got := entry.GetFieldString("php_line"); got != tt.wantLine {
+					t.Errorf("php_line = %q, want %q", got, tt.wantLine)
+				}
+			})
+		}
+	}
+}
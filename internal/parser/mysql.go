@@ -0,0 +1,129 @@
+package parser
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/models"
+)
+
+// MySQLParser parses MySQL and MariaDB error logs.
+// Examples:
+//
+//	2024-01-15T10:23:45.123456Z 0 [ERROR] [MY-012345] [Server] Table 'shop.orders' doesn't exist
+//	2024-01-15T10:23:45.123456+01:00 8 [Warning] Aborted connection 8 to db: 'shop'
+//	2024-01-15 10:23:45 0 [Note] InnoDB: Buffer pool(s) load completed
+//
+// MySQL 8 lines yield the error code and subsystem fields; MySQL 5.7 and
+// MariaDB lines have neither.
+type MySQLParser struct {
+	*BaseParser
+	// Groups: 1=timestamp, 2=thread id, 3=level, 4=error code, 5=subsystem, 6=message
+	regex    *regexp.Regexp
+	location *time.Location
+}
+
+// MySQL timestamp formats: log_timestamps (UTC or SYSTEM) and MariaDB's.
+const (
+	mysqlTimeFormat   = time.RFC3339Nano
+	mariadbTimeFormat = "2006-01-02 15:04:05"
+)
+
+// NewMySQLParser creates a new MySQL/MariaDB error log parser. MariaDB
+// timestamps, which carry no zone, are read in opts.TimeZone, or UTC if
+// unset or unknown.
+func NewMySQLParser(opts *Options) *MySQLParser {
+	p := &MySQLParser{
+		BaseParser: NewBaseParser(opts),
+		regex:      regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}(?:T\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:\d{2})| \d{2}:\d{2}:\d{2})) +(\d+) \[(?i:(error|warning|note|system))\](?: \[(MY-\d+)\] \[(\w+)\])? (.*)$`),
+		location:   time.UTC,
+	}
+	if opts != nil && opts.TimeZone != "" {
+		if loc, err := time.LoadLocation(opts.TimeZone); err == nil {
+			p.location = loc
+		}
+	}
+	return p
+}
+
+// Parse parses a single MySQL error log line.
+func (p *MySQLParser) Parse(line string) (*models.LogEntry, error) {
+	return p.ParseWithContext(context.Background(), line)
+}
+
+// ParseWithContext parses a single MySQL error log line with context support.
+func (p *MySQLParser) ParseWithContext(ctx context.Context, line string) (*models.LogEntry, error) {
+	if line == "" {
+		return nil, ErrEmptyLine
+	}
+
+	matches := p.regex.FindStringSubmatch(line)
+	if matches == nil {
+		return nil, ErrInvalidFormat
+	}
+
+	entry := models.NewLogEntry()
+	entry.Type = models.LogTypeMySQL
+
+	var timestamp time.Time
+	var err error
+	if strings.Contains(matches[1], "T") {
+		timestamp, err = time.Parse(mysqlTimeFormat, matches[1])
+	} else {
+		timestamp, err = time.ParseInLocation(mariadbTimeFormat, matches[1], p.location)
+	}
+	if err != nil {
+		return nil, ErrInvalidFormat
+	}
+	entry.Timestamp = timestamp
+
+	threadID, _ := strconv.Atoi(matches[2])
+	entry.SetField("thread_id", threadID)
+
+	entry.Level = mysqlLevelToLogLevel(matches[3])
+	entry.SetField("severity", matches[3])
+
+	if matches[4] != "" {
+		entry.SetField("error_code", matches[4])
+		entry.SetField("subsystem", matches[5])
+	}
+
+	entry.Message = matches[6]
+
+	p.ApplyOptions(entry, line)
+	return entry, nil
+}
+
+// mysqlLevelToLogLevel converts a MySQL error log level to models.LogLevel.
+func mysqlLevelToLogLevel(level string) models.LogLevel {
+	switch strings.ToLower(level) {
+	case "error":
+		return models.LevelError
+	case "warning":
+		return models.LevelWarning
+	case "note", "system":
+		return models.LevelInfo
+	default:
+		return models.LevelUnknown
+	}
+}
+
+// Name returns the parser name.
+func (p *MySQLParser) Name() string {
+	return "mysql"
+}
+
+// Type returns the log type this parser handles.
+func (p *MySQLParser) Type() models.LogType {
+	return models.LogTypeMySQL
+}
+
+// CanParse returns true if the line looks like a MySQL error log line: a
+// timestamp and thread id followed by a [LEVEL] token, so ISO-8601
+// prefixed lines of other formats don't match.
+func (p *MySQLParser) CanParse(line string) bool {
+	return p.regex.MatchString(line)
+}
@@ -0,0 +1,142 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/models"
+)
+
+// TestMySQLParser_Parse tests the MySQL error log parser.
+func TestMySQLParser_Parse(t *testing.T) {
+	parser := NewMySQLParser(nil)
+
+	tests := []struct {
+		name          string
+		line          string
+		expectError   bool
+		expectedLevel models.LogLevel
+		expectedMsg   string
+		expectedTime  time.Time
+		fields        map[string]interface{}
+	}{
+		{
+			name:          "MySQL 8 error",
+			line:          `2024-01-15T10:23:45.123456Z 0 [ERROR] [MY-012345] [Server] Table 'shop.orders' doesn't exist`,
+			expectedLevel: models.LevelError,
+			expectedMsg:   "Table 'shop.orders' doesn't exist",
+			expectedTime:  time.Date(2024, 1, 15, 10, 23, 45, 123456000, time.UTC),
+			fields: map[string]interface{}{
+				"thread_id":  0,
+				"severity":   "ERROR",
+				"error_code": "MY-012345",
+				"subsystem":  "Server",
+			},
+		},
+		{
+			name:          "MySQL 8 system, InnoDB",
+			line:          `2024-01-15T10:23:45.000001Z 1 [System] [MY-013577] [InnoDB] InnoDB initialization has ended.`,
+			expectedLevel: models.LevelInfo,
+			expectedMsg:   "InnoDB initialization has ended.",
+			expectedTime:  time.Date(2024, 1, 15, 10, 23, 45, 1000, time.UTC),
+			fields: map[string]interface{}{
+				"thread_id":  1,
+				"error_code": "MY-013577",
+				"subsystem":  "InnoDB",
+			},
+		},
+		{
+			name:          "MySQL 5.7, system time zone",
+			line:          `2024-01-15T11:23:45.123456+01:00 8 [Warning] Aborted connection 8 to db: 'shop' user: 'app' host: '10.0.0.5' (Got timeout reading communication packets)`,
+			expectedLevel: models.LevelWarning,
+			expectedMsg:   "Aborted connection 8 to db: 'shop' user: 'app' host: '10.0.0.5' (Got timeout reading communication packets)",
+			expectedTime:  time.Date(2024, 1, 15, 10, 23, 45, 123456000, time.UTC),
+			fields: map[string]interface{}{
+				"thread_id": 8,
+				"severity":  "Warning",
+			},
+		},
+		{
+			name:          "MariaDB note",
+			line:          `2024-01-15 10:23:45 0 [Note] InnoDB: Buffer pool(s) load completed at 240115 10:23:45`,
+			expectedLevel: models.LevelInfo,
+			expectedMsg:   "InnoDB: Buffer pool(s) load completed at 240115 10:23:45",
+			expectedTime:  time.Date(2024, 1, 15, 10, 23, 45, 0, time.UTC),
+		},
+		{
+			name:        "no level token",
+			line:        `2024-01-15T10:23:45.123456Z 0 Table 'shop.orders' doesn't exist`,
+			expectError: true,
+		},
+		{
+			name:        "empty line",
+			line:        "",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := parser.Parse(tt.line)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if entry.Type != models.LogTypeMySQL {
+				t.Errorf("expected type %v, got %v", models.LogTypeMySQL, entry.Type)
+			}
+			if entry.Level != tt.expectedLevel {
+				t.Errorf("expected level %v, got %v", tt.expectedLevel, entry.Level)
+			}
+			if entry.Message != tt.expectedMsg {
+				t.Errorf("expected message %q, got %q", tt.expectedMsg, entry.Message)
+			}
+			if !entry.Timestamp.Equal(tt.expectedTime) {
+				t.Errorf("expected timestamp %v, got %v", tt.expectedTime, entry.Timestamp)
+			}
+			for key, want := range tt.fields {
+				if got := entry.Fields[key]; got != want {
+					t.Errorf("field %s: expected %v, got %v", key, want, got)
+				}
+			}
+			if _, ok := tt.fields["error_code"]; !ok {
+				if _, ok := entry.Fields["error_code"]; ok {
+					t.Errorf("unexpected error_code field %v", entry.Fields["error_code"])
+				}
+			}
+		})
+	}
+}
+
+// TestMySQLParser_CanParse tests MySQL format detection.
+func TestMySQLParser_CanParse(t *testing.T) {
+	parser := NewMySQLParser(nil)
+
+	tests := []struct {
+		line     string
+		expected bool
+	}{
+		{`2024-01-15T10:23:45.123456Z 0 [ERROR] [MY-012345] [Server] Table 'shop.orders' doesn't exist`, true},
+		{`2024-01-15T10:23:45.123456Z 0 [Note] Event Scheduler: Loaded 0 events`, true},
+		{`2024-01-15 10:23:45 0 [warning] Access denied for user 'root'@'localhost'`, true},
+		{`2024-01-15T10:23:45.123456Z {"level":"error","msg":"failed"}`, false},
+		{`2024-01-15T10:23:45Z ERROR failed to connect`, false},
+		{`2024-01-15T10:23:45.123456+00:00 [ERROR] main: failed`, false},
+		{`[2024-01-15T10:23:45.123456+00:00] main.ERROR: Something failed [] []`, false},
+		{`2024-01-15 10:23:45.123 UTC [12345] ERROR:  duplicate key value`, false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		got := parser.CanParse(tt.line)
+		if got != tt.expected {
+			t.Errorf("CanParse(%q): expected %v, got %v", tt.line, tt.expected, got)
+		}
+	}
+}
@@ -3,19 +3,38 @@ package parser
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/good-yellow-bee/blazelog/internal/models"
 )
 
 // NginxAccessParser parses Nginx access logs.
-// Supports both combined and common log formats.
+// Supports both combined and common log formats, and JSON lines written
+// with a log_format ... escape=json using the standard variable names:
+//
+//	{"time_iso8601":"2024-01-15T10:23:45+00:00","remote_addr":"10.0.0.1","request":"GET /api HTTP/1.1","status":"200","body_bytes_sent":"512","request_time":"0.004"}
+//
+// JSON lines yield the same fields as the text formats.
+//
+// When the log format includes $request_time and $upstream_response_time,
+// they are kept as request_time_ms and upstream_time_ms (float64
+// milliseconds; upstream times of several upstreams are summed). In the
+// combined format they may follow the user agent either as rt=/urt=
+// (or request_time=/upstream_response_time=) pairs, or bare, request time
+// first:
+//
+//	... "Mozilla/5.0" rt=0.250 uct="0.001" urt="0.100, 0.050"
+//	... "Mozilla/5.0" 0.250 0.150
 type NginxAccessParser struct {
 	*BaseParser
 	combinedRegex *regexp.Regexp
 	commonRegex   *regexp.Regexp
+	timingRegex   *regexp.Regexp
 }
 
 // Nginx access log timestamp format
@@ -26,9 +45,12 @@ func NewNginxAccessParser(opts *Options) *NginxAccessParser {
 	return &NginxAccessParser{
 		BaseParser: NewBaseParser(opts),
 		// Combined format: $remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent "$http_referer" "$http_user_agent"
-		combinedRegex: regexp.MustCompile(`^(\S+) - (\S+) \[([^\]]+)\] "(\S+) (\S+) (\S+)" (\d+) (\d+) "([^"]*)" "([^"]*)"$`),
+		// Anything after the user agent is kept for timing fields.
+		combinedRegex: regexp.MustCompile(`^(\S+) - (\S+) \[([^\]]+)\] "(\S+) (\S+) (\S+)" (\d+) (\d+) "([^"]*)" "([^"]*)"(?: (.*))?$`),
 		// Common format: $remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent
 		commonRegex: regexp.MustCompile(`^(\S+) - (\S+) \[([^\]]+)\] "(\S+) (\S+) (\S+)" (\d+) (\d+)$`),
+		// Groups: 1=key, 2=value, quoted or not
+		timingRegex: regexp.MustCompile(`(?:^|\s)(rt|urt|request_time|upstream_response_time)=("[^"]*"|\S+)`),
 	}
 }
 
@@ -46,6 +68,10 @@ func (p *NginxAccessParser) ParseWithContext(ctx context.Context, line string) (
 	entry := models.NewLogEntry()
 	entry.Type = models.LogTypeNginx
 
+	if strings.HasPrefix(line, "{") {
+		return p.parseJSON(entry, line)
+	}
+
 	// Try combined format first (most common)
 	if matches := p.combinedRegex.FindStringSubmatch(line); matches != nil {
 		return p.parseCombined(entry, line, matches)
@@ -77,6 +103,11 @@ func (p *NginxAccessParser) parseCombined(entry *models.LogEntry, line string, m
 		entry.SetField("http_user_agent", userAgent)
 	}
 
+	if tail := strings.TrimSpace(matches[11]); tail != "" {
+		requestTime, upstreamTime := p.parseTimingTail(tail)
+		setTimings(entry, requestTime, upstreamTime)
+	}
+
 	// Build message
 	entry.Message = buildAccessMessage(entry)
 
@@ -141,6 +172,177 @@ func (p *NginxAccessParser) parseCommonFields(entry *models.LogEntry, fields []s
 	return nil
 }
 
+// parseJSON parses a JSON access log line.
+func (p *NginxAccessParser) parseJSON(entry *models.LogEntry, line string) (*models.LogEntry, error) {
+	data, err := decodeJSONObject(line)
+	if err != nil {
+		return nil, err
+	}
+	get := func(key string) string {
+		return nginxJSONString(data[key])
+	}
+
+	remoteAddr := get("remote_addr")
+	if remoteAddr == "" {
+		return nil, fmt.Errorf("%w: missing remote_addr", ErrInvalidFormat)
+	}
+	entry.SetField("remote_addr", remoteAddr)
+	if user := get("remote_user"); user != "" {
+		entry.SetField("remote_user", user)
+	}
+
+	// Timestamp: $time_iso8601, $time_local or $msec
+	switch {
+	case get("time_iso8601") != "":
+		ts, err := time.Parse(time.RFC3339, get("time_iso8601"))
+		if err != nil {
+			return nil, ErrInvalidFormat
+		}
+		entry.Timestamp = ts
+	case get("time_local") != "":
+		ts, err := time.Parse(nginxAccessTimeFormat, get("time_local"))
+		if err != nil {
+			return nil, ErrInvalidFormat
+		}
+		entry.Timestamp = ts
+	case get("msec") != "":
+		msec, err := strconv.ParseFloat(get("msec"), 64)
+		if err != nil {
+			return nil, ErrInvalidFormat
+		}
+		entry.Timestamp = time.UnixMilli(int64(math.Round(msec * 1000))).UTC()
+	default:
+		return nil, fmt.Errorf("%w: missing time_iso8601, time_local or msec", ErrInvalidFormat)
+	}
+
+	// Request line, or its parts logged separately
+	method, uri, protocol := get("request_method"), get("request_uri"), get("server_protocol")
+	if request := get("request"); request != "" {
+		parts := strings.Fields(request)
+		if len(parts) != 3 {
+			return nil, ErrInvalidFormat
+		}
+		method, uri, protocol = parts[0], parts[1], parts[2]
+	}
+	if method == "" || uri == "" {
+		return nil, fmt.Errorf("%w: missing request", ErrInvalidFormat)
+	}
+	entry.SetField("method", method)
+	entry.SetField("request_uri", uri)
+	if protocol != "" {
+		entry.SetField("protocol", protocol)
+	}
+
+	status, err := strconv.Atoi(get("status"))
+	if err != nil {
+		return nil, ErrInvalidFormat
+	}
+	entry.SetField("status", status)
+	entry.Level = statusToLevel(status)
+
+	if v := get("body_bytes_sent"); v != "" {
+		bodyBytes, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, ErrInvalidFormat
+		}
+		entry.SetField("body_bytes_sent", bodyBytes)
+	}
+	if referer := get("http_referer"); referer != "" {
+		entry.SetField("http_referer", referer)
+	}
+	if userAgent := get("http_user_agent"); userAgent != "" {
+		entry.SetField("http_user_agent", userAgent)
+	}
+
+	setTimings(entry, get("request_time"), get("upstream_response_time"))
+
+	entry.Message = buildAccessMessage(entry)
+
+	p.ApplyOptions(entry, line)
+	return entry, nil
+}
+
+// nginxJSONString returns a JSON access log value as a string. nginx
+// writes "-" for unset variables, which reads as empty.
+func nginxJSONString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		if v == "-" {
+			return ""
+		}
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+// parseTimingTail finds $request_time and $upstream_response_time in what
+// follows the combined format: rt=/urt= style pairs, or else bare values,
+// request time first. Other values (such as $http_x_forwarded_for) are
+// ignored.
+func (p *NginxAccessParser) parseTimingTail(tail string) (requestTime, upstreamTime string) {
+	if pairs := p.timingRegex.FindAllStringSubmatch(tail, -1); pairs != nil {
+		for _, m := range pairs {
+			value := strings.Trim(m[2], `"`)
+			switch m[1] {
+			case "rt", "request_time":
+				requestTime = value
+			case "urt", "upstream_response_time":
+				upstreamTime = value
+			}
+		}
+		return requestTime, upstreamTime
+	}
+
+	first, rest, _ := strings.Cut(tail, " ")
+	if _, err := strconv.ParseFloat(first, 64); err != nil {
+		return "", ""
+	}
+	upstream := strings.Trim(strings.TrimSpace(rest), `"`)
+	if _, ok := sumUpstreamTimes(upstream); !ok {
+		// Something else follows the upstream time
+		if f := strings.Fields(rest); len(f) > 0 {
+			upstream = strings.Trim(f[0], `"`)
+		}
+	}
+	return first, upstream
+}
+
+// setTimings sets request_time_ms and upstream_time_ms from nginx's
+// $request_time and $upstream_response_time, given in seconds. Missing or
+// unreadable values ("-" when no upstream was used) leave the field out.
+func setTimings(entry *models.LogEntry, requestTime, upstreamTime string) {
+	if v, err := strconv.ParseFloat(requestTime, 64); err == nil && v >= 0 {
+		entry.SetField("request_time_ms", secondsToMillis(v))
+	}
+	if v, ok := sumUpstreamTimes(upstreamTime); ok {
+		entry.SetField("upstream_time_ms", secondsToMillis(v))
+	}
+}
+
+// secondsToMillis converts seconds to milliseconds, rounded to the
+// microsecond so 0.123s reads as 123, not 123.00000000000001.
+func secondsToMillis(s float64) float64 {
+	return math.Round(s*1e6) / 1e3
+}
+
+// sumUpstreamTimes adds up $upstream_response_time, which lists one time
+// per upstream tried, separated by ", " (and " : " across internal
+// redirects).
+func sumUpstreamTimes(value string) (float64, bool) {
+	var total float64
+	found := false
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ':' }) {
+		if v, err := strconv.ParseFloat(strings.TrimSpace(part), 64); err == nil {
+			total += v
+			found = true
+		}
+	}
+	return total, found
+}
+
 // statusToLevel converts HTTP status code to log level.
 func statusToLevel(status int) models.LogLevel {
 	switch {
@@ -171,7 +373,21 @@ func (p *NginxAccessParser) Type() models.LogType {
 	return models.LogTypeNginx
 }
 
-// CanParse returns true if the line looks like a Nginx access log.
+// CanParse returns true if the line looks like a Nginx access log. JSON
+// lines must hold remote_addr, status and a request.
 func (p *NginxAccessParser) CanParse(line string) bool {
+	if strings.HasPrefix(line, "{") {
+		data, err := decodeJSONObject(line)
+		if err != nil {
+			return false
+		}
+		_, hasRequest := data["request"]
+		if !hasRequest {
+			_, hasRequest = data["request_uri"]
+		}
+		_, hasAddr := data["remote_addr"]
+		_, hasStatus := data["status"]
+		return hasRequest && hasAddr && hasStatus
+	}
 	return p.combinedRegex.MatchString(line) || p.commonRegex.MatchString(line)
 }
@@ -216,6 +216,129 @@ func TestNginxAccessParser_CombinedFields(t *testing.T) {
 	}
 }
 
+// TestNginxAccessParser_Timings tests request and upstream times after the combined format.
+func TestNginxAccessParser_Timings(t *testing.T) {
+	parser := NewNginxAccessParser(nil)
+	const combined = `192.168.1.1 - - [10/Oct/2024:13:55:36 -0700] "GET /api HTTP/1.1" 200 512 "-" "curl/8.0"`
+
+	tests := []struct {
+		name         string
+		tail         string
+		requestTime  interface{}
+		upstreamTime interface{}
+	}{
+		{"absent", "", nil, nil},
+		{"key=value", ` rt=0.123 uct="0.001" uht="0.090" urt="0.100"`, 123.0, 100.0},
+		{"several upstreams", ` rt=0.250 urt="0.100, 0.050 : 0.020"`, 250.0, 170.0},
+		{"long names", ` request_time=1.5 upstream_response_time=1.25`, 1500.0, 1250.0},
+		{"no upstream", ` rt=0.002 urt="-"`, 2.0, nil},
+		{"bare", ` 0.123 0.100`, 123.0, 100.0},
+		{"bare, quoted upstreams", ` 0.250 "0.100, 0.050"`, 250.0, 150.0},
+		{"bare, more after", ` 0.123 0.100 "10.0.0.2"`, 123.0, 100.0},
+		{"unrelated extras", ` "10.0.0.2"`, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := parser.Parse(combined + tt.tail)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			if entry.GetFieldInt("status") != 200 || entry.GetFieldString("http_user_agent") != "curl/8.0" {
+				t.Errorf("combined fields not parsed: %v", entry.Fields)
+			}
+			if got := entry.Fields["request_time_ms"]; got != tt.requestTime {
+				t.Errorf("Expected request_time_ms %v, got %v", tt.requestTime, got)
+			}
+			if got := entry.Fields["upstream_time_ms"]; got != tt.upstreamTime {
+				t.Errorf("Expected upstream_time_ms %v, got %v", tt.upstreamTime, got)
+			}
+		})
+	}
+}
+
+// TestNginxAccessParser_JSON tests JSON access logs yield the text format's fields.
+func TestNginxAccessParser_JSON(t *testing.T) {
+	parser := NewNginxAccessParser(nil)
+	text, err := parser.Parse(`192.168.1.1 - john [10/Oct/2024:13:55:36 -0700] "GET /index.html?q=1 HTTP/1.1" 502 2326 "http://example.com/" "Mozilla/5.0"`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		line         string
+		expectError  bool
+		requestTime  interface{}
+		upstreamTime interface{}
+	}{
+		{
+			name:         "escape=json strings",
+			line:         `{"time_local":"10/Oct/2024:13:55:36 -0700","remote_addr":"192.168.1.1","remote_user":"john","request":"GET /index.html?q=1 HTTP/1.1","status":"502","body_bytes_sent":"2326","http_referer":"http://example.com/","http_user_agent":"Mozilla/5.0","request_time":"0.250","upstream_response_time":"0.100, 0.050"}`,
+			requestTime:  250.0,
+			upstreamTime: 150.0,
+		},
+		{
+			name:        "numbers, ISO time and split request",
+			line:        `{"time_iso8601":"2024-10-10T13:55:36-07:00","remote_addr":"192.168.1.1","remote_user":"john","request_method":"GET","request_uri":"/index.html?q=1","server_protocol":"HTTP/1.1","status":502,"body_bytes_sent":2326,"http_referer":"http://example.com/","http_user_agent":"Mozilla/5.0","request_time":0.25,"upstream_response_time":"-"}`,
+			requestTime: 250.0,
+		},
+		{
+			name: "msec",
+			line: `{"msec":"1728593736.000","remote_addr":"192.168.1.1","remote_user":"john","request":"GET /index.html?q=1 HTTP/1.1","status":"502","body_bytes_sent":"2326","http_referer":"http://example.com/","http_user_agent":"Mozilla/5.0"}`,
+		},
+		{
+			name:        "no timestamp",
+			line:        `{"remote_addr":"192.168.1.1","request":"GET / HTTP/1.1","status":"200"}`,
+			expectError: true,
+		},
+		{
+			name:        "bad status",
+			line:        `{"time_local":"10/Oct/2024:13:55:36 -0700","remote_addr":"192.168.1.1","request":"GET / HTTP/1.1","status":"ok"}`,
+			expectError: true,
+		},
+		{
+			name:        "invalid JSON",
+			line:        `{"remote_addr":"192.168.1.1"`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := parser.Parse(tt.line)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+
+			if !entry.Timestamp.Equal(text.Timestamp) {
+				t.Errorf("Expected timestamp %v, got %v", text.Timestamp, entry.Timestamp)
+			}
+			if entry.Level != text.Level || entry.Message != text.Message || entry.Type != text.Type {
+				t.Errorf("Expected level=%s message=%q type=%s, got level=%s message=%q type=%s",
+					text.Level, text.Message, text.Type, entry.Level, entry.Message, entry.Type)
+			}
+			for key, want := range text.Fields {
+				if got := entry.Fields[key]; got != want {
+					t.Errorf("Field %s: expected %v, got %v", key, want, got)
+				}
+			}
+			if got := entry.Fields["request_time_ms"]; got != tt.requestTime {
+				t.Errorf("Expected request_time_ms %v, got %v", tt.requestTime, got)
+			}
+			if got := entry.Fields["upstream_time_ms"]; got != tt.upstreamTime {
+				t.Errorf("Expected upstream_time_ms %v, got %v", tt.upstreamTime, got)
+			}
+		})
+	}
+}
+
 // TestNginxAccessParser_CanParse tests auto-detection.
 func TestNginxAccessParser_CanParse(t *testing.T) {
 	parser := NewNginxAccessParser(nil)
@@ -227,6 +350,10 @@ func TestNginxAccessParser_CanParse(t *testing.T) {
 		{`192.168.1.1 - - [10/Oct/2024:13:55:36 -0700] "GET /index.html HTTP/1.1" 200 2326 "-" "Mozilla/5.0"`, true},
 		{`192.168.1.1 - - [10/Oct/2024:13:55:36 -0700] "GET /index.html HTTP/1.1" 200 2326`, true},
 		{`2024/10/10 13:55:36 [error] 12345#67890: test message`, false},
+		{`{"time_local":"10/Oct/2024:13:55:36 -0700","remote_addr":"192.168.1.1","request":"GET / HTTP/1.1","status":"200"}`, true},
+		{`{"remote_addr":"192.168.1.1","request_method":"GET","request_uri":"/","status":200}`, true},
+		{`{"time":"2024-01-15T10:23:45Z","level":"info","msg":"started"}`, false},
+		{`{"remote_addr":"192.168.1.1","request":"GET / HTTP/1.1"`, false},
 		{"invalid line", false},
 		{"", false},
 	}
@@ -74,6 +74,21 @@ type Options struct {
 
 	// Source is the source identifier for all parsed entries.
 	Source string
+
+	// MultiLineMode overrides how continuation lines are folded into
+	// multiline entries. Empty uses the parser's default.
+	MultiLineMode MultiLineMode
+
+	// ThrownOrigin controls how PHP parsers use the "thrown in ... on line"
+	// tail of an exception for php_file/php_line. Empty uses
+	// ThrownOriginOverride.
+	ThrownOrigin ThrownOriginMode
+
+	// TimeKey, LevelKey and MessageKey name the keys the JSON parser reads
+	// the timestamp, level and message from. Empty uses the defaults.
+	TimeKey    string
+	LevelKey   string
+	MessageKey string
 }
 
 // DefaultParserOptions returns default parser options.
@@ -174,6 +189,10 @@ func ParseStreamDefault(ctx context.Context, p Parser, r io.Reader, entries chan
 type Registry struct {
 	parsers       map[models.LogType]Parser
 	customParsers map[string]Parser // name -> parser for custom parsers
+
+	// Registration order, so detection is deterministic
+	typeOrder   []models.LogType
+	customOrder []string
 }
 
 // NewRegistry creates a new parser registry.
@@ -187,8 +206,14 @@ func NewRegistry() *Registry {
 // Register adds a parser to the registry.
 func (r *Registry) Register(p Parser) {
 	if p.Type() == models.LogTypeCustom {
+		if _, ok := r.customParsers[p.Name()]; !ok {
+			r.customOrder = append(r.customOrder, p.Name())
+		}
 		r.customParsers[p.Name()] = p
 	} else {
+		if _, ok := r.parsers[p.Type()]; !ok {
+			r.typeOrder = append(r.typeOrder, p.Type())
+		}
 		r.parsers[p.Type()] = p
 	}
 }
@@ -215,15 +240,10 @@ func (r *Registry) GetByName(name string) (Parser, bool) {
 }
 
 // AutoDetect tries to detect the appropriate parser for the given line.
+// Built-in parsers are tried first, then custom parsers, each in
+// registration order; the first whose CanParse accepts the line wins.
 func (r *Registry) AutoDetect(line string) (Parser, bool) {
-	// Try built-in parsers first
-	for _, p := range r.parsers {
-		if p.CanParse(line) {
-			return p, true
-		}
-	}
-	// Then try custom parsers
-	for _, p := range r.customParsers {
+	for _, p := range r.All() {
 		if p.CanParse(line) {
 			return p, true
 		}
@@ -231,14 +251,32 @@ func (r *Registry) AutoDetect(line string) (Parser, bool) {
 	return nil, false
 }
 
-// All returns all registered parsers.
+// Detection is one parser's verdict on a line during auto-detection.
+type Detection struct {
+	Parser  string `json:"parser"`
+	Matched bool   `json:"matched"`
+}
+
+// Explain returns every parser's CanParse result for line, in the order
+// AutoDetect tries them. The first matching entry is the one AutoDetect
+// picks.
+func (r *Registry) Explain(line string) []Detection {
+	parsers := r.All()
+	result := make([]Detection, len(parsers))
+	for i, p := range parsers {
+		result[i] = Detection{Parser: p.Name(), Matched: p.CanParse(line)}
+	}
+	return result
+}
+
+// All returns all registered parsers, built-in first, in registration order.
 func (r *Registry) All() []Parser {
 	result := make([]Parser, 0, len(r.parsers)+len(r.customParsers))
-	for _, p := range r.parsers {
-		result = append(result, p)
+	for _, t := range r.typeOrder {
+		result = append(result, r.parsers[t])
 	}
-	for _, p := range r.customParsers {
-		result = append(result, p)
+	for _, name := range r.customOrder {
+		result = append(result, r.customParsers[name])
 	}
 	return result
 }
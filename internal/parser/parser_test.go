@@ -2,6 +2,7 @@ package parser
 
 import (
 	"context"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -225,6 +226,32 @@ func TestRegistry(t *testing.T) {
 	}
 }
 
+func TestRegistryExplain(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(NewNginxAccessParser(nil))
+	registry.Register(NewMockParser(nil))
+	registry.Register(NewWordPressParser(nil))
+
+	// The WordPress line also has a ": " for the mock parser, which is
+	// registered first and so wins
+	line := "[14-Oct-2026 10:00:00 UTC] PHP Warning: Undefined variable $x in /var/www/wp.php on line 3"
+	want := []Detection{
+		{Parser: "nginx-access", Matched: false},
+		{Parser: "mock", Matched: true},
+		{Parser: "wordpress", Matched: true},
+	}
+	if got := registry.Explain(line); !reflect.DeepEqual(got, want) {
+		t.Errorf("Explain() = %+v, want %+v", got, want)
+	}
+
+	for i := 0; i < 20; i++ {
+		p, ok := registry.AutoDetect(line)
+		if !ok || p.Name() != "mock" {
+			t.Fatalf("AutoDetect() = %v, %v, want mock on every call", p, ok)
+		}
+	}
+}
+
 func TestParseStreamDefault(t *testing.T) {
 	parser := NewMockParser(nil)
 	input := "INFO: message 1\nERROR: message 2\ninvalid\nDEBUG: message 3"
@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/good-yellow-bee/blazelog/internal/models"
+)
+
+// PathFields are the parsed fields holding source file paths, normalized
+// along with the entry's FilePath.
+var PathFields = []string{"php_file", "exception_file", "file", "file_path"}
+
+// PathNormalizer rewrites file paths in parsed entries to a single form, so
+// the same file can be filtered the same way whichever OS logged it.
+// Backslashes become forward slashes, repeated separators are collapsed
+// and drive letters are upper-cased: `c:\xampp\htdocs\wp-config.php`
+// becomes `C:/xampp/htdocs/wp-config.php`. With CaseFold, Windows paths
+// (with a drive letter, a UNC prefix or backslashes) are lower-cased
+// instead, since Windows matches file names case-insensitively. Unix paths
+// keep their case.
+type PathNormalizer struct {
+	CaseFold bool
+}
+
+// Apply normalizes the entry's FilePath and PathFields in place.
+func (n *PathNormalizer) Apply(entry *models.LogEntry) {
+	entry.FilePath = n.Normalize(entry.FilePath)
+	for _, key := range PathFields {
+		if s, ok := entry.Fields[key].(string); ok {
+			entry.Fields[key] = n.Normalize(s)
+		}
+	}
+}
+
+// Normalize returns path in normalized form.
+func (n *PathNormalizer) Normalize(path string) string {
+	windows := hasDriveLetter(path) || strings.Contains(path, `\`)
+	if !windows {
+		return path
+	}
+
+	unc := strings.HasPrefix(path, `\\`) || strings.HasPrefix(path, "//")
+	var b strings.Builder
+	b.Grow(len(path))
+	if unc {
+		b.WriteString("//")
+	}
+	prevSep := unc
+	for _, r := range path {
+		if r == '\\' || r == '/' {
+			if !prevSep {
+				b.WriteByte('/')
+			}
+			prevSep = true
+			continue
+		}
+		prevSep = false
+		b.WriteRune(r)
+	}
+	out := b.String()
+
+	if n.CaseFold {
+		return strings.ToLower(out)
+	}
+	if hasDriveLetter(out) {
+		out = strings.ToUpper(out[:1]) + out[1:]
+	}
+	return out
+}
+
+// hasDriveLetter reports whether path starts with a Windows drive letter,
+// as in "C:" or "c:/".
+func hasDriveLetter(path string) bool {
+	if len(path) < 2 || path[1] != ':' {
+		return false
+	}
+	c := path[0]
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,66 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/good-yellow-bee/blazelog/internal/models"
+)
+
+func TestPathNormalizer(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		caseFold bool
+		want     string
+	}{
+		{"unix unchanged", "/var/www/html/Wp-Config.php", false, "/var/www/html/Wp-Config.php"},
+		{"unix keeps case when folding", "/var/www/html/Wp-Config.php", true, "/var/www/html/Wp-Config.php"},
+		{"backslashes", `C:\xampp\htdocs\wp-config.php`, false, "C:/xampp/htdocs/wp-config.php"},
+		{"lower drive letter", "c:/xampp/htdocs/index.php", false, "C:/xampp/htdocs/index.php"},
+		{"mixed separators", `C:/xampp\htdocs/wp-content\plugins\foo.php`, false, "C:/xampp/htdocs/wp-content/plugins/foo.php"},
+		{"escaped backslashes", `C:\\xampp\\htdocs\\index.php`, false, "C:/xampp/htdocs/index.php"},
+		{"case fold", `C:\XAMPP\htdocs\WP-Config.php`, true, "c:/xampp/htdocs/wp-config.php"},
+		{"unc", `\\fileserver\logs\App.log`, false, "//fileserver/logs/App.log"},
+		{"unc case fold", `\\FileServer\Logs\App.log`, true, "//fileserver/logs/app.log"},
+		{"relative windows", `wp-content\themes\x.php`, false, "wp-content/themes/x.php"},
+		{"empty", "", true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := &PathNormalizer{CaseFold: tt.caseFold}
+			if got := n.Normalize(tt.path); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPathNormalizerApply(t *testing.T) {
+	p := NewWordPressParser(nil)
+	entry, err := p.Parse(`[15-Jan-2024 10:30:45 UTC] PHP Fatal error:  Uncaught Error in C:\xampp\htdocs\wp-includes\plugin.php on line 12`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	entry.FilePath = `C:\xampp\php\logs\php_error_log`
+	entry.SetField("exception_file", `c:\xampp\htdocs\index.php`)
+	entry.SetField("line_count", 3)
+
+	(&PathNormalizer{}).Apply(entry)
+
+	if got := entry.GetFieldString("php_file"); got != "C:/xampp/htdocs/wp-includes/plugin.php" {
+		t.Errorf("php_file = %q", got)
+	}
+	if got := entry.GetFieldString("exception_file"); got != "C:/xampp/htdocs/index.php" {
+		t.Errorf("exception_file = %q", got)
+	}
+	if entry.FilePath != "C:/xampp/php/logs/php_error_log" {
+		t.Errorf("FilePath = %q", entry.FilePath)
+	}
+	if entry.Fields["line_count"] != 3 {
+		t.Errorf("non-path field changed: %v", entry.Fields["line_count"])
+	}
+
+	// Entries without fields are fine
+	(&PathNormalizer{}).Apply(&models.LogEntry{FilePath: "/var/log/x.log"})
+}
@@ -0,0 +1,388 @@
+package parser
+
+import (
+	"context"
+	"encoding/csv"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/models"
+)
+
+// PostgresParser parses PostgreSQL server logs, in the stderr format with
+// the default log_line_prefix ('%m [%p] ', or '%t [%p]: ' before 10) and
+// in the csvlog format.
+// Examples:
+//
+//	2024-01-15 10:23:45.123 UTC [12345] ERROR:  duplicate key value violates unique constraint "users_pkey"
+//	2024-01-15 10:23:45.123 UTC [12345] LOG:  duration: 1502.331 ms  statement: SELECT * FROM orders
+//	2024-01-15 10:23:45 UTC [12345]: [3-1] user=app,db=shop LOG:  checkpoint starting: time
+//	2024-01-15 10:23:45.123 UTC,"app","shop",12345,"10.0.0.5:51234",65a5...,3,"INSERT",...,"ERROR","23505","duplicate key ...",...
+//
+// The DETAIL, HINT, CONTEXT, STATEMENT, QUERY and LOCATION lines Postgres
+// writes after a message, and the lines of a multi-line statement, are
+// folded into the message's entry by ParseMultiLine, each kept in a field
+// of its name.
+type PostgresParser struct {
+	*BaseParser
+	// Groups: 1=timestamp, 2=zone, 3=pid, 4=rest of prefix, 5=severity, 6=message
+	regex *regexp.Regexp
+	// Groups: 1=timestamp, 2=zone
+	csvStartRegex *regexp.Regexp
+	// Groups: 1=key, 2=value (user=app,db=shop in the prefix)
+	prefixFieldRegex *regexp.Regexp
+	// Groups: 1=duration, 2=rest of message
+	durationRegex *regexp.Regexp
+	location      *time.Location
+}
+
+// postgresDetailSeverities are the severities of lines that continue the
+// previous message rather than start an entry.
+var postgresDetailSeverities = map[string]bool{
+	"DETAIL": true, "HINT": true, "CONTEXT": true,
+	"STATEMENT": true, "QUERY": true, "LOCATION": true,
+}
+
+// Postgres timestamp format, without the zone.
+const postgresTimeFormat = "2006-01-02 15:04:05.999999999"
+
+// csvlog columns, of the 23 every version since 9.0 writes.
+const (
+	pgCSVUser          = 1
+	pgCSVDatabase      = 2
+	pgCSVPID           = 3
+	pgCSVClient        = 4
+	pgCSVSeverity      = 11
+	pgCSVSQLState      = 12
+	pgCSVMessage       = 13
+	pgCSVDetail        = 14
+	pgCSVHint          = 15
+	pgCSVInternalQuery = 16
+	pgCSVContext       = 18
+	pgCSVStatement     = 19
+	pgCSVLocation      = 21
+	pgCSVApplication   = 22
+	pgCSVMinColumns    = 23
+)
+
+// sqlstateRegex matches a SQLSTATE code, five digits or upper-case letters.
+var sqlstateRegex = regexp.MustCompile(`^[0-9A-Z]{5}$`)
+
+// sqlstateSuffixRegex matches a trailing "(SQLSTATE 23505)".
+var sqlstateSuffixRegex = regexp.MustCompile(`\s*\(SQLSTATE ([0-9A-Z]{5})\)$`)
+
+// NewPostgresParser creates a new PostgreSQL log parser. Timestamps whose
+// zone is neither UTC/GMT nor a numeric offset are read in opts.TimeZone,
+// or UTC if unset or unknown.
+func NewPostgresParser(opts *Options) *PostgresParser {
+	p := &PostgresParser{
+		BaseParser:       NewBaseParser(opts),
+		regex:            regexp.MustCompile(`^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}(?:\.\d+)?) (\S+) \[(\d+)\]:? (?:(.*?) )?(DEBUG[1-5]|LOG|INFO|NOTICE|WARNING|ERROR|FATAL|PANIC|DETAIL|HINT|CONTEXT|STATEMENT|QUERY|LOCATION):\s+(.*)$`),
+		csvStartRegex:    regexp.MustCompile(`^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}(?:\.\d+)?) (\S+),`),
+		prefixFieldRegex: regexp.MustCompile(`\b(user|db|app|client)=([^,\s]*)`),
+		durationRegex:    regexp.MustCompile(`(?s)^duration: (\d+(?:\.\d+)?) ms(?:\s+(.*))?$`),
+		location:         time.UTC,
+	}
+	if opts != nil && opts.TimeZone != "" {
+		if loc, err := time.LoadLocation(opts.TimeZone); err == nil {
+			p.location = loc
+		}
+	}
+	return p
+}
+
+// Parse parses a single Postgres log line.
+func (p *PostgresParser) Parse(line string) (*models.LogEntry, error) {
+	return p.ParseWithContext(context.Background(), line)
+}
+
+// ParseWithContext parses a single Postgres log line with context support.
+func (p *PostgresParser) ParseWithContext(ctx context.Context, line string) (*models.LogEntry, error) {
+	if line == "" {
+		return nil, ErrEmptyLine
+	}
+	if m := p.csvStartRegex.FindStringSubmatch(line); m != nil {
+		return p.parseCSV(line, m[1], m[2])
+	}
+
+	matches := p.regex.FindStringSubmatch(line)
+	if matches == nil {
+		return nil, ErrInvalidFormat
+	}
+
+	entry := models.NewLogEntry()
+	entry.Type = models.LogTypePostgres
+
+	timestamp, ok := p.parseTime(matches[1], matches[2])
+	if !ok {
+		return nil, ErrInvalidFormat
+	}
+	entry.Timestamp = timestamp
+
+	pid, _ := strconv.Atoi(matches[3])
+	entry.SetField("pid", pid)
+	for _, m := range p.prefixFieldRegex.FindAllStringSubmatch(matches[4], -1) {
+		if m[2] != "" && m[2] != "[unknown]" {
+			entry.SetField(postgresPrefixFields[m[1]], m[2])
+		}
+	}
+
+	severity := matches[5]
+	entry.SetField("severity", severity)
+	entry.Level = postgresLevelToLogLevel(severity)
+	p.setMessage(entry, matches[6], true)
+
+	p.ApplyOptions(entry, line)
+	return entry, nil
+}
+
+// postgresPrefixFields maps log_line_prefix keys to field names.
+var postgresPrefixFields = map[string]string{
+	"user":   "user",
+	"db":     "database",
+	"app":    "application",
+	"client": "client",
+}
+
+// setMessage sets the entry's message, taking out a SQLSTATE code when
+// present. verbose allows the "23505: message" form the stderr format uses
+// with log_error_verbosity = verbose. Slow query and log_statement lines
+// also yield duration_ms and statement.
+func (p *PostgresParser) setMessage(entry *models.LogEntry, message string, verbose bool) {
+	if m := sqlstateSuffixRegex.FindStringSubmatch(message); m != nil {
+		entry.SetField("sqlstate", m[1])
+		message = message[:len(message)-len(m[0])]
+	} else if code, rest, ok := strings.Cut(message, ": "); verbose && ok && sqlstateRegex.MatchString(code) {
+		entry.SetField("sqlstate", code)
+		message = rest
+	}
+	entry.Message = message
+
+	// "duration: 1502.331 ms  statement: ..." (log_min_duration_statement)
+	rest := message
+	if m := p.durationRegex.FindStringSubmatch(message); m != nil {
+		if d, err := strconv.ParseFloat(m[1], 64); err == nil {
+			entry.SetField("duration_ms", d)
+		}
+		rest = m[2]
+	}
+	for _, prefix := range []string{"statement: ", "execute ", "parse ", "bind "} {
+		if strings.HasPrefix(rest, prefix) {
+			statement := strings.TrimPrefix(rest, prefix)
+			if prefix != "statement: " {
+				// "execute <unnamed>: SELECT ..." for extended protocol
+				if _, s, ok := strings.Cut(statement, ": "); ok {
+					statement = s
+				}
+			}
+			entry.SetField("statement", statement)
+			break
+		}
+	}
+}
+
+// parseCSV parses a csvlog record, which may span several lines when a
+// quoted column holds newlines.
+func (p *PostgresParser) parseCSV(line, ts, zone string) (*models.LogEntry, error) {
+	record, ok := readPostgresCSV(line)
+	if !ok {
+		return nil, ErrInvalidFormat
+	}
+
+	entry := models.NewLogEntry()
+	entry.Type = models.LogTypePostgres
+
+	timestamp, ok := p.parseTime(ts, zone)
+	if !ok {
+		return nil, ErrInvalidFormat
+	}
+	entry.Timestamp = timestamp
+
+	if pid, err := strconv.Atoi(record[pgCSVPID]); err == nil {
+		entry.SetField("pid", pid)
+	}
+	severity := record[pgCSVSeverity]
+	entry.SetField("severity", severity)
+	entry.Level = postgresLevelToLogLevel(severity)
+	p.setMessage(entry, record[pgCSVMessage], false)
+	if code := record[pgCSVSQLState]; code != "" && code != "00000" {
+		entry.SetField("sqlstate", code)
+	}
+
+	for key, col := range map[string]int{
+		"user":        pgCSVUser,
+		"database":    pgCSVDatabase,
+		"client":      pgCSVClient,
+		"application": pgCSVApplication,
+		"detail":      pgCSVDetail,
+		"hint":        pgCSVHint,
+		"query":       pgCSVInternalQuery,
+		"context":     pgCSVContext,
+		"statement":   pgCSVStatement,
+		"location":    pgCSVLocation,
+	} {
+		if v := record[col]; v != "" {
+			entry.SetField(key, v)
+		}
+	}
+
+	p.ApplyOptions(entry, line)
+	return entry, nil
+}
+
+// parseTime parses a Postgres timestamp. The zone is an abbreviation
+// (%t and %m print the log_timezone abbreviation) or a numeric offset.
+func (p *PostgresParser) parseTime(value, zone string) (time.Time, bool) {
+	loc := p.location
+	switch {
+	case zone == "UTC" || zone == "GMT":
+		loc = time.UTC
+	case strings.HasPrefix(zone, "+") || strings.HasPrefix(zone, "-"):
+		offset, ok := parseZoneOffset(zone)
+		if !ok {
+			return time.Time{}, false
+		}
+		loc = time.FixedZone(zone, offset)
+	}
+	ts, err := time.ParseInLocation(postgresTimeFormat, value, loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return ts, true
+}
+
+// parseZoneOffset parses a zone offset like "+01", "-0530" or "+05:30" into
+// seconds east of UTC.
+func parseZoneOffset(zone string) (int, bool) {
+	sign := 1
+	if zone[0] == '-' {
+		sign = -1
+	}
+	digits := strings.ReplaceAll(zone[1:], ":", "")
+	if len(digits) != 2 && len(digits) != 4 {
+		return 0, false
+	}
+	hours, err := strconv.Atoi(digits[:2])
+	if err != nil {
+		return 0, false
+	}
+	minutes := 0
+	if len(digits) == 4 {
+		if minutes, err = strconv.Atoi(digits[2:]); err != nil {
+			return 0, false
+		}
+	}
+	return sign * (hours*3600 + minutes*60), true
+}
+
+// postgresLevelToLogLevel converts a Postgres severity to models.LogLevel.
+func postgresLevelToLogLevel(severity string) models.LogLevel {
+	switch severity {
+	case "DEBUG1", "DEBUG2", "DEBUG3", "DEBUG4", "DEBUG5":
+		return models.LevelDebug
+	case "LOG", "INFO", "NOTICE":
+		return models.LevelInfo
+	case "WARNING":
+		return models.LevelWarning
+	case "ERROR":
+		return models.LevelError
+	case "FATAL", "PANIC":
+		return models.LevelFatal
+	default:
+		return models.LevelUnknown
+	}
+}
+
+// Name returns the parser name.
+func (p *PostgresParser) Name() string {
+	return "postgres"
+}
+
+// Type returns the log type this parser handles.
+func (p *PostgresParser) Type() models.LogType {
+	return models.LogTypePostgres
+}
+
+// CanParse returns true if the line looks like a Postgres log line.
+func (p *PostgresParser) CanParse(line string) bool {
+	if p.csvStartRegex.MatchString(line) {
+		_, ok := readPostgresCSV(line)
+		return ok
+	}
+	return p.regex.MatchString(line)
+}
+
+// readPostgresCSV reads a csvlog record with at least the columns every
+// version writes.
+func readPostgresCSV(line string) ([]string, bool) {
+	r := csv.NewReader(strings.NewReader(line))
+	r.FieldsPerRecord = -1
+	record, err := r.Read()
+	if err != nil || len(record) < pgCSVMinColumns {
+		return nil, false
+	}
+	return record, true
+}
+
+// IsStartOfEntry returns true if the line starts a new log entry: a
+// prefixed line other than a DETAIL, HINT, STATEMENT or similar
+// continuation of the previous message, or a csvlog record.
+func (p *PostgresParser) IsStartOfEntry(line string) bool {
+	if p.csvStartRegex.MatchString(line) {
+		return true
+	}
+	m := p.regex.FindStringSubmatch(line)
+	return m != nil && !postgresDetailSeverities[m[5]]
+}
+
+// ParseMultiLine parses a message with its continuation lines as a single
+// log entry. Each DETAIL, HINT, CONTEXT, STATEMENT, QUERY or LOCATION line
+// sets the field of its lower-cased name, and unprefixed lines (the rest of
+// a multi-line statement or message) extend the message or field before
+// them. A csvlog record spanning several lines is parsed as one.
+func (p *PostgresParser) ParseMultiLine(lines []string) (*models.LogEntry, error) {
+	if len(lines) == 0 {
+		return nil, ErrEmptyLine
+	}
+	if p.csvStartRegex.MatchString(lines[0]) {
+		return p.Parse(strings.Join(lines, "\n"))
+	}
+
+	entry, err := p.Parse(lines[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 1 {
+		return entry, nil
+	}
+
+	current := "" // field being extended; "" = the message
+	for _, line := range lines[1:] {
+		if m := p.regex.FindStringSubmatch(line); m != nil && postgresDetailSeverities[m[5]] {
+			current = strings.ToLower(m[5])
+			entry.SetField(current, m[6])
+			continue
+		}
+		// The tab Postgres indents continuation lines with is dropped
+		text := strings.TrimPrefix(line, "\t")
+		if current == "" {
+			entry.Message += "\n" + text
+			// A statement logged with the message continues with it
+			if s, ok := entry.Fields["statement"].(string); ok {
+				entry.SetField("statement", s+"\n"+text)
+			}
+		} else {
+			entry.SetField(current, entry.GetFieldString(current)+"\n"+text)
+		}
+	}
+
+	if p.options != nil && p.options.IncludeRaw {
+		entry.Raw = strings.Join(lines, "\n")
+	}
+	entry.SetField("multiline", true)
+	entry.SetField("line_count", len(lines))
+
+	return entry, nil
+}
@@ -0,0 +1,256 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/models"
+)
+
+// TestPostgresParser_Parse tests the PostgreSQL parser.
+func TestPostgresParser_Parse(t *testing.T) {
+	parser := NewPostgresParser(nil)
+
+	tests := []struct {
+		name          string
+		line          string
+		expectError   bool
+		expectedLevel models.LogLevel
+		expectedMsg   string
+		expectedTime  time.Time
+		fields        map[string]interface{}
+	}{
+		{
+			name:          "error with SQLSTATE suffix",
+			line:          `2024-01-15 10:23:45.123 UTC [12345] ERROR:  duplicate key value violates unique constraint "users_pkey" (SQLSTATE 23505)`,
+			expectedLevel: models.LevelError,
+			expectedMsg:   `duplicate key value violates unique constraint "users_pkey"`,
+			expectedTime:  time.Date(2024, 1, 15, 10, 23, 45, 123000000, time.UTC),
+			fields: map[string]interface{}{
+				"pid":      12345,
+				"severity": "ERROR",
+				"sqlstate": "23505",
+			},
+		},
+		{
+			name:          "verbose SQLSTATE",
+			line:          `2024-01-15 10:23:45.123 UTC [12345] FATAL:  28P01: password authentication failed for user "app"`,
+			expectedLevel: models.LevelFatal,
+			expectedMsg:   `password authentication failed for user "app"`,
+			expectedTime:  time.Date(2024, 1, 15, 10, 23, 45, 123000000, time.UTC),
+			fields: map[string]interface{}{
+				"sqlstate": "28P01",
+			},
+		},
+		{
+			name:          "slow query",
+			line:          `2024-01-15 10:23:45.123 UTC [12345] LOG:  duration: 1502.331 ms  statement: SELECT * FROM orders WHERE id = 7`,
+			expectedLevel: models.LevelInfo,
+			expectedMsg:   "duration: 1502.331 ms  statement: SELECT * FROM orders WHERE id = 7",
+			expectedTime:  time.Date(2024, 1, 15, 10, 23, 45, 123000000, time.UTC),
+			fields: map[string]interface{}{
+				"duration_ms": 1502.331,
+				"statement":   "SELECT * FROM orders WHERE id = 7",
+			},
+		},
+		{
+			name:          "extended protocol",
+			line:          `2024-01-15 10:23:45.123 UTC [12345] LOG:  duration: 12.5 ms  execute <unnamed>: SELECT 1`,
+			expectedLevel: models.LevelInfo,
+			expectedMsg:   "duration: 12.5 ms  execute <unnamed>: SELECT 1",
+			expectedTime:  time.Date(2024, 1, 15, 10, 23, 45, 123000000, time.UTC),
+			fields: map[string]interface{}{
+				"duration_ms": 12.5,
+				"statement":   "SELECT 1",
+			},
+		},
+		{
+			name:          "pre-10 prefix with user and db",
+			line:          `2024-01-15 10:23:45 CET [812]: [3-1] user=app,db=shop,app=[unknown],client=10.0.0.5 WARNING:  there is no transaction in progress`,
+			expectedLevel: models.LevelWarning,
+			expectedMsg:   "there is no transaction in progress",
+			expectedTime:  time.Date(2024, 1, 15, 10, 23, 45, 0, time.UTC),
+			fields: map[string]interface{}{
+				"pid":      812,
+				"user":     "app",
+				"database": "shop",
+				"client":   "10.0.0.5",
+			},
+		},
+		{
+			name:          "numeric zone, debug",
+			line:          `2024-01-15 10:23:45.5 +01 [7] DEBUG2:  autovacuum: processing database "shop"`,
+			expectedLevel: models.LevelDebug,
+			expectedMsg:   `autovacuum: processing database "shop"`,
+			expectedTime:  time.Date(2024, 1, 15, 9, 23, 45, 500000000, time.UTC),
+		},
+		{
+			name:          "panic",
+			line:          `2024-01-15 10:23:45.123 UTC [1] PANIC:  could not locate a valid checkpoint record`,
+			expectedLevel: models.LevelFatal,
+			expectedMsg:   "could not locate a valid checkpoint record",
+			expectedTime:  time.Date(2024, 1, 15, 10, 23, 45, 123000000, time.UTC),
+		},
+		{
+			name:          "csvlog",
+			line:          `2024-01-15 10:23:45.123 UTC,"app","shop",12345,"10.0.0.5:51234",65a5071d.3039,3,"INSERT",2024-01-15 10:20:01 UTC,3/42,0,ERROR,23505,"duplicate key value violates unique constraint ""users_pkey""","Key (id)=(1) already exists.",,,,,"INSERT INTO users (id) VALUES (1)",,,"psql","client backend",,0`,
+			expectedLevel: models.LevelError,
+			expectedMsg:   `duplicate key value violates unique constraint "users_pkey"`,
+			expectedTime:  time.Date(2024, 1, 15, 10, 23, 45, 123000000, time.UTC),
+			fields: map[string]interface{}{
+				"pid":         12345,
+				"user":        "app",
+				"database":    "shop",
+				"client":      "10.0.0.5:51234",
+				"application": "psql",
+				"sqlstate":    "23505",
+				"detail":      "Key (id)=(1) already exists.",
+				"statement":   "INSERT INTO users (id) VALUES (1)",
+			},
+		},
+		{
+			name:        "too few csv columns",
+			line:        `2024-01-15 10:23:45.123 UTC,"app","shop",12345`,
+			expectError: true,
+		},
+		{
+			name:        "not postgres",
+			line:        `2024/10/10 13:55:36 [error] 12345#67890: test message`,
+			expectError: true,
+		},
+		{
+			name:        "empty line",
+			line:        "",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := parser.Parse(tt.line)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if entry.Type != models.LogTypePostgres {
+				t.Errorf("expected type %v, got %v", models.LogTypePostgres, entry.Type)
+			}
+			if entry.Level != tt.expectedLevel {
+				t.Errorf("expected level %v, got %v", tt.expectedLevel, entry.Level)
+			}
+			if entry.Message != tt.expectedMsg {
+				t.Errorf("expected message %q, got %q", tt.expectedMsg, entry.Message)
+			}
+			if !entry.Timestamp.Equal(tt.expectedTime) {
+				t.Errorf("expected timestamp %v, got %v", tt.expectedTime, entry.Timestamp)
+			}
+			for key, want := range tt.fields {
+				if got := entry.Fields[key]; got != want {
+					t.Errorf("field %s: expected %v, got %v", key, want, got)
+				}
+			}
+		})
+	}
+}
+
+// TestPostgresParser_MultiLine tests folding continuation lines.
+func TestPostgresParser_MultiLine(t *testing.T) {
+	parser := NewPostgresParser(&Options{IncludeRaw: true})
+
+	lines := []string{
+		`2024-01-15 10:23:45.123 UTC [12345] ERROR:  duplicate key value violates unique constraint "users_pkey"`,
+		`2024-01-15 10:23:45.123 UTC [12345] DETAIL:  Key (id)=(1) already exists.`,
+		`2024-01-15 10:23:45.123 UTC [12345] STATEMENT:  INSERT INTO users (id, name)`,
+		`	VALUES (1, 'a')`,
+		`2024-01-15 10:23:46.001 UTC [12345] LOG:  disconnection: session time: 0:00:01.002`,
+	}
+
+	var starts []int
+	for i, line := range lines {
+		if parser.IsStartOfEntry(line) {
+			starts = append(starts, i)
+		}
+	}
+	if len(starts) != 2 || starts[0] != 0 || starts[1] != 4 {
+		t.Fatalf("IsStartOfEntry starts = %v, want [0 4]", starts)
+	}
+
+	entry, err := parser.ParseMultiLine(lines[:4])
+	if err != nil {
+		t.Fatalf("ParseMultiLine() error = %v", err)
+	}
+	if entry.Message != `duplicate key value violates unique constraint "users_pkey"` {
+		t.Errorf("message = %q", entry.Message)
+	}
+	if got := entry.GetFieldString("detail"); got != "Key (id)=(1) already exists." {
+		t.Errorf("detail = %q", got)
+	}
+	if got := entry.GetFieldString("statement"); got != "INSERT INTO users (id, name)\nVALUES (1, 'a')" {
+		t.Errorf("statement = %q", got)
+	}
+	if entry.GetFieldInt("line_count") != 4 || entry.Raw != `2024-01-15 10:23:45.123 UTC [12345] ERROR:  duplicate key value violates unique constraint "users_pkey"
+2024-01-15 10:23:45.123 UTC [12345] DETAIL:  Key (id)=(1) already exists.
+2024-01-15 10:23:45.123 UTC [12345] STATEMENT:  INSERT INTO users (id, name)
+	VALUES (1, 'a')` {
+		t.Errorf("line_count = %d, raw = %q", entry.GetFieldInt("line_count"), entry.Raw)
+	}
+
+	// A multi-line statement logged with its duration
+	entry, err = parser.ParseMultiLine([]string{
+		`2024-01-15 10:23:45.123 UTC [12345] LOG:  duration: 2001.000 ms  statement: SELECT *`,
+		`	FROM orders`,
+	})
+	if err != nil {
+		t.Fatalf("ParseMultiLine() error = %v", err)
+	}
+	if got := entry.GetFieldString("statement"); got != "SELECT *\nFROM orders" {
+		t.Errorf("statement = %q", got)
+	}
+
+	// A csvlog record with a newline in a quoted column
+	entry, err = parser.ParseMultiLine([]string{
+		`2024-01-15 10:23:45.123 UTC,"app","shop",12345,"10.0.0.5:51234",65a5071d.3039,3,"SELECT",2024-01-15 10:20:01 UTC,3/42,0,LOG,00000,"duration: 1.5 ms  statement: SELECT 1,`,
+		`	2",,,,,,,,,"psql","client backend",,0`,
+	})
+	if err != nil {
+		t.Fatalf("ParseMultiLine() csv error = %v", err)
+	}
+	if got := entry.GetFieldString("statement"); got != "SELECT 1,\n\t2" {
+		t.Errorf("csv statement = %q", got)
+	}
+	if _, ok := entry.Fields["sqlstate"]; ok {
+		t.Errorf("csv sqlstate 00000 should be left out")
+	}
+}
+
+// TestPostgresParser_CanParse tests Postgres format detection.
+func TestPostgresParser_CanParse(t *testing.T) {
+	parser := NewPostgresParser(nil)
+
+	tests := []struct {
+		line     string
+		expected bool
+	}{
+		{`2024-01-15 10:23:45.123 UTC [12345] LOG:  database system is ready to accept connections`, true},
+		{`2024-01-15 10:23:45 UTC [12345]: [1-1] user=,db= LOG:  autovacuum launcher started`, true},
+		{`2024-01-15 10:23:45.123 UTC,,,812,,65a5071d.32c,1,,2024-01-15 10:23:45 UTC,,0,LOG,00000,"database system is ready to accept connections",,,,,,,,,"","postmaster",,0`, true},
+		{`2024-01-15 10:23:45.123 UTC,"app"`, false},
+		{`[2024-01-15T10:23:45.123456+00:00] main.ERROR: Something failed [] []`, false},
+		{`2024-01-15 10:23:45 [error] 12345#67890: test message`, false},
+		{`2024-01-15 10:23:45,123 ERROR [main] app: failed`, false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		got := parser.CanParse(tt.line)
+		if got != tt.expected {
+			t.Errorf("CanParse(%q): expected %v, got %v", tt.line, tt.expected, got)
+		}
+	}
+}
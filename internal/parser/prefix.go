@@ -0,0 +1,177 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/models"
+)
+
+// DefaultPrefixPattern matches the service prefixes of multiplexed stdout:
+// "web-1  | " (docker compose) and "name[pid]: " (supervisord, syslog tags).
+const DefaultPrefixPattern = `(?P<service>[A-Za-z0-9][A-Za-z0-9_.@-]*)(?:\[(?P<pid>\d+)\])?(?:\s*\| ?|: )`
+
+// PrefixConfig configures a prefix demux parser: a service prefix is
+// stripped into labels and fields, and the rest of the line is parsed by
+// an inner parser.
+type PrefixConfig struct {
+	// Pattern is a regex matched at the start of the line (default:
+	// DefaultPrefixPattern). Named groups are extracted; the remainder of
+	// the line after the match is handed to the inner parser.
+	Pattern string `yaml:"pattern,omitempty"`
+	// Inner names the parser for the remainder ("auto" or empty =
+	// auto-detect per line).
+	Inner string `yaml:"inner,omitempty"`
+	// Labels lists the named groups stored as labels (default: service).
+	// Other groups are stored as fields.
+	Labels []string `yaml:"labels,omitempty"`
+}
+
+// PrefixParser demultiplexes lines carrying a service prefix. Lines without
+// the prefix are parsed whole by the inner parser. With auto-detection, a
+// remainder no parser recognizes becomes a plain entry with the remainder
+// as its message.
+type PrefixParser struct {
+	*BaseParser
+	name     string
+	prefix   *regexp.Regexp
+	labels   map[string]bool
+	inner    Parser    // nil = auto-detect
+	registry *Registry // for auto-detection
+}
+
+// NewPrefixParser creates a prefix demux parser named name. The inner
+// parser is looked up in registry, which is also used for auto-detection.
+func NewPrefixParser(name string, cfg *PrefixConfig, registry *Registry, opts *Options) (*PrefixParser, error) {
+	if name == "" {
+		return nil, fmt.Errorf("parser name is required")
+	}
+	if cfg == nil {
+		cfg = &PrefixConfig{}
+	}
+
+	pattern := cfg.Pattern
+	if pattern == "" {
+		pattern = DefaultPrefixPattern
+	}
+	// Anchor so the prefix can only match at the start of the line
+	prefix, err := regexp.Compile(`\A(?:` + pattern + `)`)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prefix pattern for parser %q: %w", name, err)
+	}
+
+	labelGroups := cfg.Labels
+	if len(labelGroups) == 0 {
+		labelGroups = []string{"service"}
+	}
+	labels := make(map[string]bool, len(labelGroups))
+	for _, g := range labelGroups {
+		if !slices.Contains(prefix.SubexpNames(), g) {
+			return nil, fmt.Errorf("prefix label %q is not a named group in the pattern of parser %q", g, name)
+		}
+		labels[g] = true
+	}
+
+	p := &PrefixParser{
+		BaseParser: NewBaseParser(opts),
+		name:       name,
+		prefix:     prefix,
+		labels:     labels,
+		registry:   registry,
+	}
+	if cfg.Inner != "" && cfg.Inner != "auto" {
+		inner, ok := registry.GetByName(cfg.Inner)
+		if !ok {
+			inner, ok = registry.Get(models.LogType(cfg.Inner))
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown inner parser %q for parser %q", cfg.Inner, name)
+		}
+		p.inner = inner
+	}
+	return p, nil
+}
+
+// Name returns the parser name.
+func (p *PrefixParser) Name() string {
+	return p.name
+}
+
+// Type returns the log type.
+func (p *PrefixParser) Type() models.LogType {
+	return models.LogTypeCustom
+}
+
+// CanParse returns true if the line starts with the prefix.
+func (p *PrefixParser) CanParse(line string) bool {
+	return p.prefix.MatchString(line)
+}
+
+// Parse parses a single log line.
+func (p *PrefixParser) Parse(line string) (*models.LogEntry, error) {
+	return p.ParseWithContext(context.Background(), line)
+}
+
+// ParseWithContext strips the prefix and parses the remainder with the
+// inner parser.
+func (p *PrefixParser) ParseWithContext(ctx context.Context, line string) (*models.LogEntry, error) {
+	if line == "" {
+		return nil, ErrEmptyLine
+	}
+
+	rest := line
+	match := p.prefix.FindStringSubmatchIndex(line)
+	if match != nil {
+		rest = line[match[1]:]
+	}
+
+	entry, err := p.parseInner(ctx, rest)
+	if err != nil {
+		return nil, err
+	}
+
+	if match != nil {
+		for i, group := range p.prefix.SubexpNames() {
+			if group == "" || match[2*i] < 0 {
+				continue
+			}
+			value := line[match[2*i]:match[2*i+1]]
+			if p.labels[group] {
+				entry.SetLabel(group, value)
+			} else if _, ok := entry.GetField(group); !ok {
+				entry.SetField(group, value)
+			}
+		}
+	}
+	p.ApplyOptions(entry, line)
+	return entry, nil
+}
+
+// parseInner parses the remainder of a line with the inner parser, or the
+// first other registered parser that recognizes it.
+func (p *PrefixParser) parseInner(ctx context.Context, rest string) (*models.LogEntry, error) {
+	if p.inner != nil {
+		return p.inner.ParseWithContext(ctx, rest)
+	}
+	if p.registry != nil {
+		for _, candidate := range p.registry.All() {
+			// Never recurse into another demux parser
+			if _, ok := candidate.(*PrefixParser); ok {
+				continue
+			}
+			if candidate.CanParse(rest) {
+				if entry, err := candidate.ParseWithContext(ctx, rest); err == nil {
+					return entry, nil
+				}
+			}
+		}
+	}
+
+	entry := models.NewLogEntry()
+	entry.Timestamp = time.Now()
+	entry.Message = rest
+	return entry, nil
+}
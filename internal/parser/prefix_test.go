@@ -0,0 +1,172 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/good-yellow-bee/blazelog/internal/models"
+)
+
+func newTestPrefixRegistry() *Registry {
+	registry := NewRegistry()
+	registry.Register(NewNginxAccessParser(nil))
+	registry.Register(NewMagentoParser(nil))
+	return registry
+}
+
+func TestPrefixParser_InterleavedServices(t *testing.T) {
+	p, err := NewPrefixParser("compose", nil, newTestPrefixRegistry(), nil)
+	if err != nil {
+		t.Fatalf("NewPrefixParser() error = %v", err)
+	}
+
+	tests := []struct {
+		line        string
+		wantService string
+		wantType    models.LogType
+		wantMessage string
+		wantPID     string
+	}{
+		{
+			line:        `web-1     | 192.168.1.1 - - [10/Oct/2024:13:55:36 -0700] "GET /index.html HTTP/1.1" 200 2326 "-" "curl/8.0"`,
+			wantService: "web-1",
+			wantType:    models.LogTypeNginx,
+		},
+		{
+			line:        `magento-1 | [2024-01-15 10:23:45] main.INFO: Information message [] []`,
+			wantService: "magento-1",
+			wantType:    models.LogTypeMagento,
+			wantMessage: "Information message",
+		},
+		{
+			line:        `worker-1  | processing job 42`,
+			wantService: "worker-1",
+			wantType:    models.LogTypeUnknown,
+			wantMessage: "processing job 42",
+		},
+		{
+			line:        `web-1     | 10.0.0.2 - - [10/Oct/2024:13:55:37 -0700] "POST /api HTTP/1.1" 502 12 "-" "curl/8.0"`,
+			wantService: "web-1",
+			wantType:    models.LogTypeNginx,
+		},
+		{
+			line:        `queue[1234]: job failed`,
+			wantService: "queue",
+			wantType:    models.LogTypeUnknown,
+			wantMessage: "job failed",
+			wantPID:     "1234",
+		},
+		{
+			line:        `no prefix here`,
+			wantType:    models.LogTypeUnknown,
+			wantMessage: "no prefix here",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			entry, err := p.Parse(tt.line)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := entry.Labels["service"]; got != tt.wantService {
+				t.Errorf("service label = %q, want %q", got, tt.wantService)
+			}
+			if entry.Type != tt.wantType {
+				t.Errorf("type = %q, want %q", entry.Type, tt.wantType)
+			}
+			if tt.wantMessage != "" && entry.Message != tt.wantMessage {
+				t.Errorf("message = %q, want %q", entry.Message, tt.wantMessage)
+			}
+			if got := entry.GetFieldString("pid"); got != tt.wantPID {
+				t.Errorf("pid field = %q, want %q", got, tt.wantPID)
+			}
+			if entry.Raw != tt.line {
+				t.Errorf("raw = %q, want the full line", entry.Raw)
+			}
+		})
+	}
+}
+
+func TestPrefixParser_FixedInner(t *testing.T) {
+	p, err := NewPrefixParser("compose-nginx", &PrefixConfig{Inner: "nginx-access"}, newTestPrefixRegistry(), nil)
+	if err != nil {
+		t.Fatalf("NewPrefixParser() error = %v", err)
+	}
+
+	entry, err := p.Parse(`web-1 | 192.168.1.1 - - [10/Oct/2024:13:55:36 -0700] "GET / HTTP/1.1" 404 0 "-" "curl/8.0"`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if entry.Labels["service"] != "web-1" || entry.GetFieldInt("status") != 404 {
+		t.Errorf("entry = labels %v, status %v; want web-1, 404", entry.Labels, entry.Fields["status"])
+	}
+
+	if _, err := p.Parse("web-1 | not an access log"); err == nil {
+		t.Error("Parse() should fail when the fixed inner parser rejects the remainder")
+	}
+}
+
+func TestPrefixParser_CustomPattern(t *testing.T) {
+	cfg := &PrefixConfig{
+		Pattern: `(?P<container>[a-z]+)/(?P<stream>stdout|stderr) `,
+		Labels:  []string{"container", "stream"},
+	}
+	p, err := NewPrefixParser("demux", cfg, NewRegistry(), nil)
+	if err != nil {
+		t.Fatalf("NewPrefixParser() error = %v", err)
+	}
+
+	if !p.CanParse("api/stderr boom") || p.CanParse("x api/stderr boom") {
+		t.Error("CanParse() should only match the prefix at the start of the line")
+	}
+	entry, err := p.Parse("api/stderr boom")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if entry.Labels["container"] != "api" || entry.Labels["stream"] != "stderr" || entry.Message != "boom" {
+		t.Errorf("entry = labels %v, message %q", entry.Labels, entry.Message)
+	}
+}
+
+func TestNewPrefixParser_Errors(t *testing.T) {
+	registry := newTestPrefixRegistry()
+	tests := []struct {
+		name string
+		cfg  *PrefixConfig
+	}{
+		{"invalid pattern", &PrefixConfig{Pattern: `(`}},
+		{"unknown inner", &PrefixConfig{Inner: "missing"}},
+		{"label not a group", &PrefixConfig{Labels: []string{"host"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewPrefixParser("demux", tt.cfg, registry, nil); err == nil {
+				t.Error("NewPrefixParser() should fail")
+			}
+		})
+	}
+}
+
+func TestRegisterCustomParsers_Prefix(t *testing.T) {
+	registry := NewRegistry()
+	configs := []CustomParserConfig{
+		// Listed before its inner parser on purpose
+		{Name: "compose", Prefix: &PrefixConfig{Inner: "app-json"}, Labels: map[string]string{"env": "dev"}},
+		{Name: "app-json", JSONMode: true, MessageField: "msg"},
+	}
+	if err := RegisterCustomParsers(registry, configs); err != nil {
+		t.Fatalf("RegisterCustomParsers() error = %v", err)
+	}
+
+	p, ok := registry.GetByName("compose")
+	if !ok {
+		t.Fatal("compose should be registered")
+	}
+	entry, err := p.Parse(`api-1 | {"msg":"started"}`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if entry.Message != "started" || entry.Labels["service"] != "api-1" || entry.Labels["env"] != "dev" {
+		t.Errorf("entry = message %q, labels %v", entry.Message, entry.Labels)
+	}
+}
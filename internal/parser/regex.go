@@ -0,0 +1,132 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/models"
+)
+
+// Capture groups the regex parser maps onto entry attributes.
+const (
+	RegexTimestampGroup = "timestamp"
+	RegexLevelGroup     = "level"
+	RegexMessageGroup   = "message"
+)
+
+// RegexParser parses lines with a user-supplied regex of named capture
+// groups. Example pattern:
+//
+//	^(?P<timestamp>\S+ \S+) \[(?P<level>\w+)\] (?P<worker>\w+): (?P<message>.*)$
+//
+// The timestamp, level and message groups map onto the entry's Timestamp,
+// Level and Message; every other named group that matched becomes a field.
+// Unlike a CustomParser it needs no parsers entry, so it suits one-off
+// source formats.
+type RegexParser struct {
+	*BaseParser
+	regex      *regexp.Regexp
+	timeFormat string
+	location   *time.Location
+}
+
+// NewRegexParser creates a parser for pattern, which must compile and have
+// a message group. Timestamps are read with the Go layout opts.TimeFormat
+// (default RFC 3339) in opts.TimeZone (default UTC).
+func NewRegexParser(pattern string, opts *Options) (*RegexParser, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("pattern is required")
+	}
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	if regex.SubexpIndex(RegexMessageGroup) < 0 {
+		return nil, fmt.Errorf("pattern has no (?P<%s>...) group", RegexMessageGroup)
+	}
+
+	p := &RegexParser{
+		BaseParser: NewBaseParser(opts),
+		regex:      regex,
+		timeFormat: time.RFC3339,
+		location:   time.UTC,
+	}
+	if opts != nil {
+		if opts.TimeFormat != "" {
+			p.timeFormat = opts.TimeFormat
+		}
+		if opts.TimeZone != "" {
+			loc, err := time.LoadLocation(opts.TimeZone)
+			if err != nil {
+				return nil, fmt.Errorf("invalid time zone: %w", err)
+			}
+			p.location = loc
+		}
+	}
+	return p, nil
+}
+
+// Parse parses a single log line.
+func (p *RegexParser) Parse(line string) (*models.LogEntry, error) {
+	return p.ParseWithContext(context.Background(), line)
+}
+
+// ParseWithContext parses a single log line with context support.
+func (p *RegexParser) ParseWithContext(ctx context.Context, line string) (*models.LogEntry, error) {
+	if line == "" {
+		return nil, ErrEmptyLine
+	}
+
+	matches := p.regex.FindStringSubmatch(line)
+	if matches == nil {
+		return nil, ErrInvalidFormat
+	}
+
+	entry := models.NewLogEntry()
+	entry.Type = models.LogTypeCustom
+	entry.Timestamp = time.Now()
+
+	for i, name := range p.regex.SubexpNames() {
+		value := matches[i]
+		if name == "" || value == "" {
+			continue
+		}
+		switch name {
+		case RegexMessageGroup:
+			entry.Message = value
+		case RegexLevelGroup:
+			entry.Level = models.ParseLogLevel(strings.ToLower(strings.TrimSpace(value)))
+		case RegexTimestampGroup:
+			// An unreadable timestamp is kept as a field
+			ts, err := time.ParseInLocation(p.timeFormat, value, p.location)
+			if err != nil {
+				entry.SetField(name, value)
+				continue
+			}
+			entry.Timestamp = ts
+		default:
+			entry.SetField(name, value)
+		}
+	}
+
+	p.ApplyOptions(entry, line)
+	return entry, nil
+}
+
+// Name returns the parser name.
+func (p *RegexParser) Name() string {
+	return "regex"
+}
+
+// Type returns the log type this parser handles.
+func (p *RegexParser) Type() models.LogType {
+	return models.LogTypeCustom
+}
+
+// CanParse returns true if the line matches the pattern.
+func (p *RegexParser) CanParse(line string) bool {
+	return p.regex.MatchString(line)
+}
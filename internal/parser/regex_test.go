@@ -0,0 +1,144 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/models"
+)
+
+const testRegexPattern = `^(?P<timestamp>\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}) \[(?P<level>\w+)\] (?P<worker>\w+)(?: req=(?P<request_id>\w+))?: (?P<message>.*)$`
+
+// TestNewRegexParser tests pattern validation.
+func TestNewRegexParser(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		opts    *Options
+		wantErr string
+	}{
+		{name: "valid", pattern: testRegexPattern},
+		{name: "message only", pattern: `(?P<message>.*)`},
+		{name: "empty", pattern: "", wantErr: "pattern is required"},
+		{name: "does not compile", pattern: `(?P<message>.*`, wantErr: "invalid pattern"},
+		{name: "no message group", pattern: `^(?P<timestamp>\S+) (?P<msg>.*)$`, wantErr: "no (?P<message>...) group"},
+		{name: "unknown time zone", pattern: `(?P<message>.*)`, opts: &Options{TimeZone: "Mars/Olympus"}, wantErr: "invalid time zone"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewRegexParser(tt.pattern, tt.opts)
+			if tt.wantErr == "" {
+				if err != nil || p == nil {
+					t.Fatalf("NewRegexParser() = %v, %v", p, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewRegexParser() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestRegexParser_Parse tests group mapping.
+func TestRegexParser_Parse(t *testing.T) {
+	parser, err := NewRegexParser(testRegexPattern, &Options{
+		TimeFormat: "2006-01-02 15:04:05",
+		TimeZone:   "Europe/Berlin",
+		IncludeRaw: true,
+		Source:     "billing",
+	})
+	if err != nil {
+		t.Fatalf("NewRegexParser() error = %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		line          string
+		expectError   bool
+		expectedLevel models.LogLevel
+		expectedMsg   string
+		expectedTime  time.Time
+		fields        map[string]interface{}
+	}{
+		{
+			name:          "all groups",
+			line:          `2024-07-01 12:00:00 [WARN] invoicer req=ab12: retrying payment`,
+			expectedLevel: models.LevelWarning,
+			expectedMsg:   "retrying payment",
+			expectedTime:  time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC),
+			fields: map[string]interface{}{
+				"worker":     "invoicer",
+				"request_id": "ab12",
+			},
+		},
+		{
+			name:          "unmatched optional group",
+			line:          `2024-07-01 12:00:00 [error] mailer: smtp timeout`,
+			expectedLevel: models.LevelError,
+			expectedMsg:   "smtp timeout",
+			expectedTime:  time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC),
+			fields: map[string]interface{}{
+				"worker": "mailer",
+			},
+		},
+		{
+			name:          "unreadable timestamp kept as field",
+			line:          `2024-13-45 12:00:00 [info] mailer: sent`,
+			expectedLevel: models.LevelInfo,
+			expectedMsg:   "sent",
+			fields: map[string]interface{}{
+				"timestamp": "2024-13-45 12:00:00",
+				"worker":    "mailer",
+			},
+		},
+		{
+			name:        "no match",
+			line:        `something else entirely`,
+			expectError: true,
+		},
+		{
+			name:        "empty line",
+			line:        "",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := parser.Parse(tt.line)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if entry.Level != tt.expectedLevel {
+				t.Errorf("expected level %v, got %v", tt.expectedLevel, entry.Level)
+			}
+			if entry.Message != tt.expectedMsg {
+				t.Errorf("expected message %q, got %q", tt.expectedMsg, entry.Message)
+			}
+			if !tt.expectedTime.IsZero() && !entry.Timestamp.Equal(tt.expectedTime) {
+				t.Errorf("expected timestamp %v, got %v", tt.expectedTime, entry.Timestamp)
+			}
+			if len(entry.Fields) != len(tt.fields) {
+				t.Errorf("expected fields %v, got %v", tt.fields, entry.Fields)
+			}
+			for key, want := range tt.fields {
+				if got := entry.Fields[key]; got != want {
+					t.Errorf("field %s: expected %v, got %v", key, want, got)
+				}
+			}
+			if entry.Raw != tt.line || entry.Source != "billing" {
+				t.Errorf("expected raw %q and source billing, got %q and %q", tt.line, entry.Raw, entry.Source)
+			}
+		})
+	}
+}
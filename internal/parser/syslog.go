@@ -0,0 +1,191 @@
+// Package parser provides log parsing functionality for various log formats.
+package parser
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/models"
+)
+
+// SyslogParser parses BSD syslog (RFC 3164) lines.
+// Format: <PRI>Mmm dd hh:mm:ss hostname tag[pid]: message
+// Examples:
+//
+//	<34>Oct 11 22:14:15 mymachine su[230]: 'su root' failed for lonvick on /dev/pts/8
+//	Oct 11 22:14:15 gateway kernel: eth0: link up
+//
+// The <PRI> part is optional, since files written by syslog daemons usually
+// drop it. RFC 5424 lines (<PRI>1 2003-10-11T22:14:15Z ...) do not match.
+type SyslogParser struct {
+	*BaseParser
+	// Groups: 1=priority, 2=timestamp, 3=hostname, 4=rest of line
+	regex *regexp.Regexp
+	// Groups: 1=tag, 2=pid, 3=message
+	tagRegex *regexp.Regexp
+	location *time.Location
+	now      func() time.Time
+}
+
+// Syslog timestamp format, with the year the line lacks prepended.
+const syslogTimeFormat = "2006 Jan _2 15:04:05"
+
+// syslogFutureSkew is how far past now an inferred timestamp may be before
+// the previous year is assumed: a Dec 31 line read on Jan 1 is last year's.
+const syslogFutureSkew = 24 * time.Hour
+
+var syslogFacilities = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+var syslogSeverities = []string{
+	"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug",
+}
+
+// NewSyslogParser creates a new BSD syslog parser. Timestamps are read in
+// opts.TimeZone, or UTC if unset or unknown.
+func NewSyslogParser(opts *Options) *SyslogParser {
+	p := &SyslogParser{
+		BaseParser: NewBaseParser(opts),
+		regex:      regexp.MustCompile(`^(?:<(\d{1,3})>)?((?:Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec) [ \d]\d \d{2}:\d{2}:\d{2}) (\S+) (.*)$`),
+		tagRegex:   regexp.MustCompile(`^([^\s:\[\]]{1,48})(?:\[(\d+)\])?:(?: (.*))?$`),
+		location:   time.UTC,
+		now:        time.Now,
+	}
+	if opts != nil && opts.TimeZone != "" {
+		if loc, err := time.LoadLocation(opts.TimeZone); err == nil {
+			p.location = loc
+		}
+	}
+	return p
+}
+
+// NewSyslogBSDParser creates a new BSD syslog (RFC 3164) parser. It is the
+// same parser as NewSyslogParser, named for callers that also handle
+// RFC 5424.
+func NewSyslogBSDParser(opts *Options) *SyslogParser {
+	return NewSyslogParser(opts)
+}
+
+// Parse parses a single syslog line.
+func (p *SyslogParser) Parse(line string) (*models.LogEntry, error) {
+	return p.ParseWithContext(context.Background(), line)
+}
+
+// ParseWithContext parses a single syslog line with context support.
+func (p *SyslogParser) ParseWithContext(ctx context.Context, line string) (*models.LogEntry, error) {
+	if line == "" {
+		return nil, ErrEmptyLine
+	}
+
+	matches := p.regex.FindStringSubmatch(line)
+	if matches == nil {
+		return nil, ErrInvalidFormat
+	}
+
+	entry := models.NewLogEntry()
+	entry.Type = models.LogTypeSyslog
+
+	// Parse priority (optional)
+	if matches[1] != "" {
+		pri, err := strconv.Atoi(matches[1])
+		if err != nil || pri > 191 {
+			return nil, ErrInvalidFormat
+		}
+		severity := pri % 8
+		entry.Level = syslogSeverityToLogLevel(severity)
+		entry.SetField("priority", pri)
+		entry.SetField("facility", syslogFacilities[pri/8])
+		entry.SetField("severity", syslogSeverities[severity])
+	}
+
+	// Parse timestamp, inferring the year
+	timestamp, ok := p.inferTimestamp(matches[2])
+	if !ok {
+		return nil, ErrInvalidFormat
+	}
+	entry.Timestamp = timestamp
+
+	entry.SetField("hostname", matches[3])
+
+	// Parse tag and PID ("app" is kept as an alias of "tag"); lines without a tag keep the whole rest as message
+	message := matches[4]
+	if tagMatch := p.tagRegex.FindStringSubmatch(message); tagMatch != nil {
+		entry.SetField("tag", tagMatch[1])
+		entry.SetField("app", tagMatch[1])
+		if tagMatch[2] != "" {
+			pid, _ := strconv.Atoi(tagMatch[2])
+			entry.SetField("pid", pid)
+		}
+		message = tagMatch[3]
+	}
+	entry.Message = message
+
+	p.ApplyOptions(entry, line)
+	return entry, nil
+}
+
+// inferTimestamp parses a year-less syslog timestamp, choosing the latest
+// year that does not put it more than syslogFutureSkew past now. That keeps
+// December lines read in January in the old year, and tolerates a sender
+// whose clock is slightly ahead on New Year's Eve.
+func (p *SyslogParser) inferTimestamp(value string) (time.Time, bool) {
+	now := p.now().In(p.location)
+	for year := now.Year() + 1; year >= now.Year()-1; year-- {
+		// Feb 29 fails to parse in non-leap years, so those are skipped
+		ts, err := time.ParseInLocation(syslogTimeFormat, strconv.Itoa(year)+" "+value, p.location)
+		if err != nil {
+			continue
+		}
+		if !ts.After(now.Add(syslogFutureSkew)) {
+			return ts, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// syslogSeverityToLogLevel converts a syslog severity (0-7) to models.LogLevel.
+func syslogSeverityToLogLevel(severity int) models.LogLevel {
+	switch severity {
+	case 0, 1, 2: // emerg, alert, crit
+		return models.LevelFatal
+	case 3: // err
+		return models.LevelError
+	case 4: // warning
+		return models.LevelWarning
+	case 5, 6: // notice, info
+		return models.LevelInfo
+	case 7: // debug
+		return models.LevelDebug
+	default:
+		return models.LevelUnknown
+	}
+}
+
+// Name returns the parser name.
+func (p *SyslogParser) Name() string {
+	return "syslog"
+}
+
+// Type returns the log type this parser handles.
+func (p *SyslogParser) Type() models.LogType {
+	return models.LogTypeSyslog
+}
+
+// CanParse returns true if the line looks like a BSD syslog line.
+func (p *SyslogParser) CanParse(line string) bool {
+	matches := p.regex.FindStringSubmatch(line)
+	if matches == nil {
+		return false
+	}
+	if matches[1] != "" {
+		if pri, err := strconv.Atoi(matches[1]); err != nil || pri > 191 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,275 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/models"
+)
+
+// newTestSyslogParser returns a syslog parser whose clock reads now.
+func newTestSyslogParser(now time.Time) *SyslogParser {
+	p := NewSyslogParser(nil)
+	p.now = func() time.Time { return now }
+	return p
+}
+
+// TestSyslogParser_Parse tests the BSD syslog parser.
+func TestSyslogParser_Parse(t *testing.T) {
+	parser := newTestSyslogParser(time.Date(2024, 10, 12, 0, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name          string
+		line          string
+		expectError   bool
+		expectedLevel models.LogLevel
+		expectedMsg   string
+		expectedTime  time.Time
+		fields        map[string]interface{}
+	}{
+		{
+			name:          "RFC 3164 example",
+			line:          `<34>Oct 11 22:14:15 mymachine su[230]: 'su root' failed for lonvick on /dev/pts/8`,
+			expectedLevel: models.LevelFatal,
+			expectedMsg:   "'su root' failed for lonvick on /dev/pts/8",
+			expectedTime:  time.Date(2024, 10, 11, 22, 14, 15, 0, time.UTC),
+			fields: map[string]interface{}{
+				"priority": 34,
+				"facility": "auth",
+				"severity": "crit",
+				"hostname": "mymachine",
+				"tag":      "su",
+				"app":      "su",
+				"pid":      230,
+			},
+		},
+		{
+			name:          "no priority, space-padded day",
+			line:          `Oct  1 08:00:01 gateway kernel: eth0: link up`,
+			expectedLevel: models.LevelUnknown,
+			expectedMsg:   "eth0: link up",
+			expectedTime:  time.Date(2024, 10, 1, 8, 0, 1, 0, time.UTC),
+			fields: map[string]interface{}{
+				"hostname": "gateway",
+				"tag":      "kernel",
+				"app":      "kernel",
+			},
+		},
+		{
+			name:          "local7 warning",
+			line:          `<188>Oct 11 22:14:15 10.0.0.1 %LINK-4-UPDOWN: Interface Gi0/1, changed state to down`,
+			expectedLevel: models.LevelWarning,
+			expectedMsg:   "Interface Gi0/1, changed state to down",
+			expectedTime:  time.Date(2024, 10, 11, 22, 14, 15, 0, time.UTC),
+			fields: map[string]interface{}{
+				"facility": "local7",
+				"severity": "warning",
+				"hostname": "10.0.0.1",
+				"app":      "%LINK-4-UPDOWN",
+			},
+		},
+		{
+			name:          "no tag",
+			line:          `<13>Oct 11 22:14:15 host last message repeated 3 times`,
+			expectedLevel: models.LevelInfo,
+			expectedMsg:   "last message repeated 3 times",
+			expectedTime:  time.Date(2024, 10, 11, 22, 14, 15, 0, time.UTC),
+			fields: map[string]interface{}{
+				"severity": "notice",
+				"hostname": "host",
+			},
+		},
+		{
+			name:        "priority out of range",
+			line:        `<192>Oct 11 22:14:15 host app: message`,
+			expectError: true,
+		},
+		{
+			name:        "RFC 5424",
+			line:        `<34>1 2003-10-11T22:14:15.003Z mymachine.example.com su - ID47 - message`,
+			expectError: true,
+		},
+		{
+			name:        "empty line",
+			line:        "",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := parser.Parse(tt.line)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if entry.Type != models.LogTypeSyslog {
+				t.Errorf("expected type %v, got %v", models.LogTypeSyslog, entry.Type)
+			}
+			if entry.Level != tt.expectedLevel {
+				t.Errorf("expected level %v, got %v", tt.expectedLevel, entry.Level)
+			}
+			if entry.Message != tt.expectedMsg {
+				t.Errorf("expected message %q, got %q", tt.expectedMsg, entry.Message)
+			}
+			if !entry.Timestamp.Equal(tt.expectedTime) {
+				t.Errorf("expected timestamp %v, got %v", tt.expectedTime, entry.Timestamp)
+			}
+			for key, want := range tt.fields {
+				if got := entry.Fields[key]; got != want {
+					t.Errorf("field %s: expected %v, got %v", key, want, got)
+				}
+			}
+			if _, ok := tt.fields["pid"]; !ok {
+				if _, ok := entry.Fields["pid"]; ok {
+					t.Errorf("unexpected pid field %v", entry.Fields["pid"])
+				}
+			}
+		})
+	}
+}
+
+// TestSyslogParser_YearInference tests year inference around New Year's.
+func TestSyslogParser_YearInference(t *testing.T) {
+	tests := []struct {
+		name     string
+		now      time.Time
+		line     string
+		expected time.Time
+	}{
+		{
+			name:     "same year",
+			now:      time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC),
+			line:     `Jun 15 11:59:00 host app: msg`,
+			expected: time.Date(2024, 6, 15, 11, 59, 0, 0, time.UTC),
+		},
+		{
+			name:     "December line read in January",
+			now:      time.Date(2025, 1, 1, 0, 0, 30, 0, time.UTC),
+			line:     `Dec 31 23:59:58 host app: msg`,
+			expected: time.Date(2024, 12, 31, 23, 59, 58, 0, time.UTC),
+		},
+		{
+			name:     "January line from a sender slightly ahead",
+			now:      time.Date(2024, 12, 31, 23, 59, 50, 0, time.UTC),
+			line:     `Jan  1 00:00:05 host app: msg`,
+			expected: time.Date(2025, 1, 1, 0, 0, 5, 0, time.UTC),
+		},
+		{
+			name:     "within future skew",
+			now:      time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC),
+			line:     `Mar 11 02:00:00 host app: msg`,
+			expected: time.Date(2024, 3, 11, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "beyond future skew is last year",
+			now:      time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC),
+			line:     `Mar 20 02:00:00 host app: msg`,
+			expected: time.Date(2023, 3, 20, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Feb 29 skips non-leap years",
+			now:      time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+			line:     `Feb 29 10:00:00 host app: msg`,
+			expected: time.Date(2024, 2, 29, 10, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := newTestSyslogParser(tt.now).Parse(tt.line)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !entry.Timestamp.Equal(tt.expected) {
+				t.Errorf("expected timestamp %v, got %v", tt.expected, entry.Timestamp)
+			}
+		})
+	}
+}
+
+// TestSyslogParser_TimeZone tests that timestamps are read in opts.TimeZone.
+func TestSyslogParser_TimeZone(t *testing.T) {
+	opts := DefaultParserOptions()
+	opts.TimeZone = "Europe/Berlin"
+	parser := NewSyslogParser(opts)
+	parser.now = func() time.Time { return time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC) }
+
+	entry, err := parser.Parse(`Jul  1 12:00:00 host app: msg`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+	if !entry.Timestamp.Equal(expected) {
+		t.Errorf("expected timestamp %v, got %v", expected, entry.Timestamp)
+	}
+}
+
+// TestSyslogParser_CanParse tests syslog format detection.
+func TestSyslogParser_CanParse(t *testing.T) {
+	parser := NewSyslogBSDParser(nil)
+
+	tests := []struct {
+		line     string
+		expected bool
+	}{
+		{`<34>Oct 11 22:14:15 mymachine su[230]: 'su root' failed`, true},
+		{`Oct 11 22:14:15 mymachine sshd[1042]: Accepted publickey for deploy`, true},
+		{`<0>Jan  5 01:02:03 host kernel: panic`, true},
+		{`<192>Oct 11 22:14:15 host app: message`, false},
+		{`<34>1 2003-10-11T22:14:15.003Z mymachine.example.com su - ID47 - message`, false},
+		{`[2024-01-15T10:23:45.123456+00:00] main.ERROR: Something failed [] []`, false},
+		{`[15-Jan-2024 10:23:45 UTC] PHP Notice:  Undefined variable: foo`, false},
+		{`[15-Jan-2024 10:23:45 UTC] WordPress database error Table 'wp_posts' doesn't exist`, false},
+		{`15-Jan-2024 10:23:45 UTC PHP Fatal error:  Uncaught Exception`, false},
+		{`[Jan 15 10:23:45] host app: message`, false},
+		{`2024/10/10 13:55:36 [error] 12345#67890: *123 test message`, false},
+		{`Foo 11 22:14:15 host app: message`, false},
+		{"invalid line", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		got := parser.CanParse(tt.line)
+		if got != tt.expected {
+			t.Errorf("CanParse(%q): expected %v, got %v", tt.line, tt.expected, got)
+		}
+	}
+}
+
+// TestSyslogParser_Options tests that parser options are applied.
+func TestSyslogParser_Options(t *testing.T) {
+	opts := &Options{
+		IncludeRaw: true,
+		Source:     "router-1",
+		Labels:     map[string]string{"env": "prod"},
+	}
+	parser := NewSyslogParser(opts)
+
+	line := `<14>Oct 11 22:14:15 router-1 dhcpd: DHCPACK on 10.0.0.5`
+	entry, err := parser.Parse(line)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if entry.Raw != line {
+		t.Errorf("expected raw %q, got %q", line, entry.Raw)
+	}
+	if entry.Source != "router-1" {
+		t.Errorf("expected source router-1, got %q", entry.Source)
+	}
+	if entry.Labels["env"] != "prod" {
+		t.Errorf("expected label env=prod, got %q", entry.Labels["env"])
+	}
+	if parser.Name() != "syslog" {
+		t.Errorf("expected name syslog, got %q", parser.Name())
+	}
+	if parser.Type() != models.LogTypeSyslog {
+		t.Errorf("expected type %v, got %v", models.LogTypeSyslog, parser.Type())
+	}
+}
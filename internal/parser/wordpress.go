@@ -194,46 +194,5 @@ func (p *WordPressParser) IsStartOfEntry(line string) bool {
 // ParseMultiLine parses multiple lines as a single log entry.
 // This handles stack traces and other multiline content in WordPress logs.
 func (p *WordPressParser) ParseMultiLine(lines []string) (*models.LogEntry, error) {
-	if len(lines) == 0 {
-		return nil, ErrEmptyLine
-	}
-
-	// Parse the first line normally
-	entry, err := p.Parse(lines[0])
-	if err != nil {
-		return nil, err
-	}
-
-	// If there are additional lines, they are part of the message (stack trace)
-	if len(lines) > 1 {
-		// Combine all lines for the raw field
-		fullRaw := strings.Join(lines, "\n")
-
-		// Extract stack trace from continuation lines
-		stackTraceLines := lines[1:]
-		stackTrace := strings.Join(stackTraceLines, "\n")
-		entry.SetField("stack_trace", stackTrace)
-
-		// Count stack frames (lines starting with "#" after trimming)
-		frameCount := 0
-		for _, line := range stackTraceLines {
-			trimmed := strings.TrimSpace(line)
-			if strings.HasPrefix(trimmed, "#") {
-				frameCount++
-			}
-		}
-		if frameCount > 0 {
-			entry.SetField("stack_frame_count", frameCount)
-		}
-
-		// Update raw to include all lines if IncludeRaw is enabled
-		if p.options != nil && p.options.IncludeRaw {
-			entry.Raw = fullRaw
-		}
-
-		// Mark this as a multiline entry
-		entry.SetField("multiline", true)
-	}
-
-	return entry, nil
+	return p.parsePHPMultiLine(lines, p.Parse)
 }
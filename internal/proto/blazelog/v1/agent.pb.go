@@ -31,6 +31,9 @@ const (
 	CommandType_COMMAND_TYPE_PAUSE         CommandType = 2
 	CommandType_COMMAND_TYPE_RESUME        CommandType = 3
 	CommandType_COMMAND_TYPE_SHUTDOWN      CommandType = 4
+	// Stream raw bytes of a source back as LogBatch.raw_tail chunks.
+	// Parameters: request_id, source, duration (e.g. "10s"), max_bytes.
+	CommandType_COMMAND_TYPE_RAW_TAIL CommandType = 5
 )
 
 // Enum value maps for CommandType.
@@ -41,6 +44,7 @@ var (
 		2: "COMMAND_TYPE_PAUSE",
 		3: "COMMAND_TYPE_RESUME",
 		4: "COMMAND_TYPE_SHUTDOWN",
+		5: "COMMAND_TYPE_RAW_TAIL",
 	}
 	CommandType_value = map[string]int32{
 		"COMMAND_TYPE_UNSPECIFIED":   0,
@@ -48,6 +52,7 @@ var (
 		"COMMAND_TYPE_PAUSE":         2,
 		"COMMAND_TYPE_RESUME":        3,
 		"COMMAND_TYPE_SHUTDOWN":      4,
+		"COMMAND_TYPE_RAW_TAIL":      5,
 	}
 )
 
@@ -774,13 +779,14 @@ const file_blazelog_v1_agent_proto_rawDesc = "" +
 	"parameters\x1a=\n" +
 	"\x0fParametersEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01*\x97\x01\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01*\xb2\x01\n" +
 	"\vCommandType\x12\x1c\n" +
 	"\x18COMMAND_TYPE_UNSPECIFIED\x10\x00\x12\x1e\n" +
 	"\x1aCOMMAND_TYPE_RELOAD_CONFIG\x10\x01\x12\x16\n" +
 	"\x12COMMAND_TYPE_PAUSE\x10\x02\x12\x17\n" +
 	"\x13COMMAND_TYPE_RESUME\x10\x03\x12\x19\n" +
-	"\x15COMMAND_TYPE_SHUTDOWN\x10\x04B\xb5\x01\n" +
+	"\x15COMMAND_TYPE_SHUTDOWN\x10\x04\x12\x19\n" +
+	"\x15COMMAND_TYPE_RAW_TAIL\x10\x05B\xb5\x01\n" +
 	"\x0fcom.blazelog.v1B\n" +
 	"AgentProtoP\x01ZIgithub.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1;blazelogv1\xa2\x02\x03BXX\xaa\x02\vBlazelog.V1\xca\x02\vBlazelog\\V1\xe2\x02\x17Blazelog\\V1\\GPBMetadata\xea\x02\fBlazelog::V1b\x06proto3"
 
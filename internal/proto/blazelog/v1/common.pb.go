@@ -90,17 +90,30 @@ const (
 	LogType_LOG_TYPE_MAGENTO     LogType = 3
 	LogType_LOG_TYPE_PRESTASHOP  LogType = 4
 	LogType_LOG_TYPE_WORDPRESS   LogType = 5
+	LogType_LOG_TYPE_SYSLOG      LogType = 6
+	LogType_LOG_TYPE_JSON        LogType = 7
+	LogType_LOG_TYPE_POSTGRES    LogType = 8
+	LogType_LOG_TYPE_MYSQL       LogType = 9
+	LogType_LOG_TYPE_CUSTOM      LogType = 10
+	// Lines the source's parser rejected, forwarded as is.
+	LogType_LOG_TYPE_UNPARSED    LogType = 11
 )
 
 // Enum value maps for LogType.
 var (
 	LogType_name = map[int32]string{
-		0: "LOG_TYPE_UNSPECIFIED",
-		1: "LOG_TYPE_NGINX",
-		2: "LOG_TYPE_APACHE",
-		3: "LOG_TYPE_MAGENTO",
-		4: "LOG_TYPE_PRESTASHOP",
-		5: "LOG_TYPE_WORDPRESS",
+		0:  "LOG_TYPE_UNSPECIFIED",
+		1:  "LOG_TYPE_NGINX",
+		2:  "LOG_TYPE_APACHE",
+		3:  "LOG_TYPE_MAGENTO",
+		4:  "LOG_TYPE_PRESTASHOP",
+		5:  "LOG_TYPE_WORDPRESS",
+		6:  "LOG_TYPE_SYSLOG",
+		7:  "LOG_TYPE_JSON",
+		8:  "LOG_TYPE_POSTGRES",
+		9:  "LOG_TYPE_MYSQL",
+		10: "LOG_TYPE_CUSTOM",
+		11: "LOG_TYPE_UNPARSED",
 	}
 	LogType_value = map[string]int32{
 		"LOG_TYPE_UNSPECIFIED": 0,
@@ -109,6 +122,12 @@ var (
 		"LOG_TYPE_MAGENTO":     3,
 		"LOG_TYPE_PRESTASHOP":  4,
 		"LOG_TYPE_WORDPRESS":   5,
+		"LOG_TYPE_SYSLOG":      6,
+		"LOG_TYPE_JSON":        7,
+		"LOG_TYPE_POSTGRES":    8,
+		"LOG_TYPE_MYSQL":       9,
+		"LOG_TYPE_CUSTOM":      10,
+		"LOG_TYPE_UNPARSED":    11,
 	}
 )
 
@@ -206,14 +225,21 @@ const file_blazelog_v1_common_proto_rawDesc = "" +
 	"\x0eLOG_LEVEL_INFO\x10\x02\x12\x15\n" +
 	"\x11LOG_LEVEL_WARNING\x10\x03\x12\x13\n" +
 	"\x0fLOG_LEVEL_ERROR\x10\x04\x12\x13\n" +
-	"\x0fLOG_LEVEL_FATAL\x10\x05*\x93\x01\n" +
+	"\x0fLOG_LEVEL_FATAL\x10\x05*\x92\x02\n" +
 	"\aLogType\x12\x18\n" +
 	"\x14LOG_TYPE_UNSPECIFIED\x10\x00\x12\x12\n" +
 	"\x0eLOG_TYPE_NGINX\x10\x01\x12\x13\n" +
 	"\x0fLOG_TYPE_APACHE\x10\x02\x12\x14\n" +
 	"\x10LOG_TYPE_MAGENTO\x10\x03\x12\x17\n" +
 	"\x13LOG_TYPE_PRESTASHOP\x10\x04\x12\x16\n" +
-	"\x12LOG_TYPE_WORDPRESS\x10\x05*u\n" +
+	"\x12LOG_TYPE_WORDPRESS\x10\x05\x12\x13\n" +
+	"\x0fLOG_TYPE_SYSLOG\x10\x06\x12\x11\n" +
+	"\rLOG_TYPE_JSON\x10\a\x12\x15\n" +
+	"\x11LOG_TYPE_POSTGRES\x10\b\x12\x12\n" +
+	"\x0eLOG_TYPE_MYSQL\x10\t\x12\x13\n" +
+	"\x0fLOG_TYPE_CUSTOM\x10\n" +
+	"\x12\x15\n" +
+	"\x11LOG_TYPE_UNPARSED\x10\v*u\n" +
 	"\bSeverity\x12\x18\n" +
 	"\x14SEVERITY_UNSPECIFIED\x10\x00\x12\x10\n" +
 	"\fSEVERITY_LOW\x10\x01\x12\x13\n" +
@@ -160,7 +160,10 @@ type LogBatch struct {
 	// Sequence number for ordering/deduplication.
 	Sequence uint64 `protobuf:"varint,3,opt,name=sequence,proto3" json:"sequence,omitempty"`
 	// Project this batch belongs to (all entries inherit this).
-	ProjectId     string `protobuf:"bytes,4,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	ProjectId string `protobuf:"bytes,4,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	// Raw source bytes answering a raw tail command. Batches carrying a
+	// chunk have no entries and are not acknowledged.
+	RawTail       *RawTailChunk `protobuf:"bytes,5,opt,name=raw_tail,json=rawTail,proto3" json:"raw_tail,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -223,6 +226,86 @@ func (x *LogBatch) GetProjectId() string {
 	return ""
 }
 
+func (x *LogBatch) GetRawTail() *RawTailChunk {
+	if x != nil {
+		return x.RawTail
+	}
+	return nil
+}
+
+// RawTailChunk carries unparsed bytes read from an agent's source file.
+type RawTailChunk struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Request ID from the raw tail command.
+	RequestId string `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	// Bytes read from the source since the previous chunk.
+	Data []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	// Whether this is the last chunk for the request.
+	Done bool `protobuf:"varint,3,opt,name=done,proto3" json:"done,omitempty"`
+	// Why the tail stopped early (set with done).
+	Error         string `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RawTailChunk) Reset() {
+	*x = RawTailChunk{}
+	mi := &file_blazelog_v1_log_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RawTailChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RawTailChunk) ProtoMessage() {}
+
+func (x *RawTailChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_blazelog_v1_log_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RawTailChunk.ProtoReflect.Descriptor instead.
+func (*RawTailChunk) Descriptor() ([]byte, []int) {
+	return file_blazelog_v1_log_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RawTailChunk) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *RawTailChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *RawTailChunk) GetDone() bool {
+	if x != nil {
+		return x.Done
+	}
+	return false
+}
+
+func (x *RawTailChunk) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
 var File_blazelog_v1_log_proto protoreflect.FileDescriptor
 
 const file_blazelog_v1_log_proto_rawDesc = "" +
@@ -243,13 +326,20 @@ const file_blazelog_v1_log_proto_rawDesc = "" +
 	" \x01(\tR\bfilePath\x1a9\n" +
 	"\vLabelsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x91\x01\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xc7\x01\n" +
 	"\bLogBatch\x12/\n" +
 	"\aentries\x18\x01 \x03(\v2\x15.blazelog.v1.LogEntryR\aentries\x12\x19\n" +
 	"\bagent_id\x18\x02 \x01(\tR\aagentId\x12\x1a\n" +
 	"\bsequence\x18\x03 \x01(\x04R\bsequence\x12\x1d\n" +
 	"\n" +
-	"project_id\x18\x04 \x01(\tR\tprojectIdB\xb3\x01\n" +
+	"project_id\x18\x04 \x01(\tR\tprojectId\x124\n" +
+	"\braw_tail\x18\x05 \x01(\v2\x19.blazelog.v1.RawTailChunkR\arawTail\"k\n" +
+	"\fRawTailChunk\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\x12\x12\n" +
+	"\x04done\x18\x03 \x01(\bR\x04done\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05errorB\xb3\x01\n" +
 	"\x0fcom.blazelog.v1B\bLogProtoP\x01ZIgithub.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1;blazelogv1\xa2\x02\x03BXX\xaa\x02\vBlazelog.V1\xca\x02\vBlazelog\\V1\xe2\x02\x17Blazelog\\V1\\GPBMetadata\xea\x02\fBlazelog::V1b\x06proto3"
 
 var (
@@ -264,28 +354,30 @@ func file_blazelog_v1_log_proto_rawDescGZIP() []byte {
 	return file_blazelog_v1_log_proto_rawDescData
 }
 
-var file_blazelog_v1_log_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_blazelog_v1_log_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
 var file_blazelog_v1_log_proto_goTypes = []any{
 	(*LogEntry)(nil),              // 0: blazelog.v1.LogEntry
 	(*LogBatch)(nil),              // 1: blazelog.v1.LogBatch
-	nil,                           // 2: blazelog.v1.LogEntry.LabelsEntry
-	(*timestamppb.Timestamp)(nil), // 3: google.protobuf.Timestamp
-	(LogLevel)(0),                 // 4: blazelog.v1.LogLevel
-	(LogType)(0),                  // 5: blazelog.v1.LogType
-	(*structpb.Struct)(nil),       // 6: google.protobuf.Struct
+	(*RawTailChunk)(nil),          // 2: blazelog.v1.RawTailChunk
+	nil,                           // 3: blazelog.v1.LogEntry.LabelsEntry
+	(*timestamppb.Timestamp)(nil), // 4: google.protobuf.Timestamp
+	(LogLevel)(0),                 // 5: blazelog.v1.LogLevel
+	(LogType)(0),                  // 6: blazelog.v1.LogType
+	(*structpb.Struct)(nil),       // 7: google.protobuf.Struct
 }
 var file_blazelog_v1_log_proto_depIdxs = []int32{
-	3, // 0: blazelog.v1.LogEntry.timestamp:type_name -> google.protobuf.Timestamp
-	4, // 1: blazelog.v1.LogEntry.level:type_name -> blazelog.v1.LogLevel
-	5, // 2: blazelog.v1.LogEntry.type:type_name -> blazelog.v1.LogType
-	6, // 3: blazelog.v1.LogEntry.fields:type_name -> google.protobuf.Struct
-	2, // 4: blazelog.v1.LogEntry.labels:type_name -> blazelog.v1.LogEntry.LabelsEntry
+	4, // 0: blazelog.v1.LogEntry.timestamp:type_name -> google.protobuf.Timestamp
+	5, // 1: blazelog.v1.LogEntry.level:type_name -> blazelog.v1.LogLevel
+	6, // 2: blazelog.v1.LogEntry.type:type_name -> blazelog.v1.LogType
+	7, // 3: blazelog.v1.LogEntry.fields:type_name -> google.protobuf.Struct
+	3, // 4: blazelog.v1.LogEntry.labels:type_name -> blazelog.v1.LogEntry.LabelsEntry
 	0, // 5: blazelog.v1.LogBatch.entries:type_name -> blazelog.v1.LogEntry
-	6, // [6:6] is the sub-list for method output_type
-	6, // [6:6] is the sub-list for method input_type
-	6, // [6:6] is the sub-list for extension type_name
-	6, // [6:6] is the sub-list for extension extendee
-	0, // [0:6] is the sub-list for field type_name
+	2, // 6: blazelog.v1.LogBatch.raw_tail:type_name -> blazelog.v1.RawTailChunk
+	7, // [7:7] is the sub-list for method output_type
+	7, // [7:7] is the sub-list for method input_type
+	7, // [7:7] is the sub-list for extension type_name
+	7, // [7:7] is the sub-list for extension extendee
+	0, // [0:7] is the sub-list for field type_name
 }
 
 func init() { file_blazelog_v1_log_proto_init() }
@@ -300,7 +392,7 @@ func file_blazelog_v1_log_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_blazelog_v1_log_proto_rawDesc), len(file_blazelog_v1_log_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   3,
+			NumMessages:   4,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -50,10 +50,13 @@ func (d *DSL) Parse(expression string) (*ParsedQuery, error) {
 
 	env := d.buildEnv()
 
+	// The SQL builder translates the checked tree as written; the optimizer
+	// would rewrite ranges and in-lists into forms meant for the expr VM
 	program, err := expr.Compile(
 		expression,
 		expr.Env(env),
 		expr.AsBool(),
+		expr.Optimize(false),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("parse error: %w", err)
@@ -221,12 +224,42 @@ func (v *validationVisitor) Visit(node *ast.Node) {
 			}
 		}
 
+		// Ranges and regexes only apply to the field types they can match
+		if rng, ok := n.Right.(*ast.BinaryNode); ok && n.Operator == "in" && rng.Operator == ".." {
+			if err := checkRange(n.Left, rng, v.fields); err != nil {
+				v.err = err
+				return
+			}
+		}
+		if n.Operator == "matches" {
+			if err := checkRegex(n.Left, n.Right, v.fields); err != nil {
+				v.err = err
+				return
+			}
+		}
+
+		// Reject wildcards the SQL builder cannot turn into a prefix match
+		if ident, ok := n.Left.(*ast.IdentifierNode); ok && (n.Operator == "==" || n.Operator == "!=") {
+			if field, ok := v.fields[ident.Value]; ok && field.Type == FieldTypeString {
+				if str, ok := n.Right.(*ast.StringNode); ok {
+					if _, _, err := wildcardPrefix(str.Value); err != nil {
+						v.err = err
+						return
+					}
+				}
+			}
+		}
+
 	case *ast.MemberNode:
 		// Handle JSON field access like fields.status
 		if ident, ok := n.Node.(*ast.IdentifierNode); ok {
 			if field, ok := v.fields[ident.Value]; ok {
 				if field.Type != FieldTypeJSON {
 					v.err = fmt.Errorf("field %q does not support member access", ident.Value)
+					return
+				}
+				if prop, ok := n.Property.(*ast.StringNode); ok && !isValidJSONPropertyName(prop.Value) {
+					v.err = invalidPropertyError(prop.Value)
 				}
 			}
 		}
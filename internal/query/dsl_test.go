@@ -1,6 +1,7 @@
 package query
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -25,11 +26,23 @@ func TestQueryDSL_Parse(t *testing.T) {
 		{"numeric less than", `http_status < 400`, false},
 		{"complex boolean", `level == "error" and (http_status >= 500 or message contains "fatal")`, false},
 		{"time function", `timestamp > now() - duration("1h")`, false},
+		{"prefix wildcard", `source == "nginx*"`, false},
+		{"negated prefix wildcard", `uri != "/health*"`, false},
+		{"numeric range", `http_status in 400..499`, false},
+		{"regex", `uri matches "^/api/v[0-9]+/"`, false},
 
 		// Invalid expressions
 		{"empty expression", ``, true},
 		{"unknown field", `foo == "bar"`, true},
 		{"syntax error", `level ==`, true},
+		{"leading wildcard", `uri == "*/login"`, true},
+		{"bare wildcard", `source == "*"`, true},
+		{"range on string field", `fields.status in 400..499`, true},
+		{"range with computed bound", `http_status in 400..(500 - 1)`, true},
+		{"regex on numeric field", `http_status matches "5.."`, true},
+		{"regex on json field", `fields.error matches "timeout"`, true},
+		{"invalid regex", `message matches "("`, true},
+		{"nested quantifiers", `message matches "(a+)+"`, true},
 	}
 
 	for _, tt := range tests {
@@ -52,6 +65,11 @@ func TestQueryDSL_ParseWithJSONFields(t *testing.T) {
 	}{
 		{"json field access", `fields.status == "200"`, false},
 		{"labels access", `labels.env == "production"`, false},
+		{"numeric json key", `fields.bytes_sent > 1000`, false},
+		{"quoted json key", `fields["exception-class"] == "LocalizedException"`, false},
+		{"json key with quote", `fields["a') or 1=1 --"] == "x"`, true},
+		{"json key too long", `fields.` + strings.Repeat("k", maxJSONPropertyLength+1) + ` == "x"`, true},
+		{"member of a column", `message.foo == "x"`, true},
 	}
 
 	for _, tt := range tests {
@@ -88,3 +106,26 @@ func TestFieldDef_IsOperatorAllowed(t *testing.T) {
 		})
 	}
 }
+
+func TestQueryDSL_ParseRangeAndRegexErrors(t *testing.T) {
+	dsl := NewQueryDSL(DefaultFields)
+
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{`fields.status in 400..499`, "range requires a numeric field"},
+		{`http_status in 400..(500 - 1)`, `range bounds for "http_status" must be integer literals`},
+		{`fields.error matches "timeout"`, "regex match requires a string field"},
+		{`message matches "(a+)+"`, "nested quantifiers"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := dsl.Parse(tt.expr)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
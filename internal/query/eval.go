@@ -0,0 +1,611 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/expr-lang/expr/ast"
+)
+
+// numberText matches the text of a number, as numeric JSON keys are read.
+var numberText = regexp.MustCompile(postgresNumberPattern)
+
+// Evaluator matches parsed expressions against single records, for log
+// storage without SQL. It follows the SQL builders: string comparisons and
+// methods fold case, prefix wildcards fold case only on Lowercase fields,
+// and JSON keys compared with numbers are read as numbers.
+//
+// As in a SQL WHERE clause, a missing value (a JSON key the record lacks,
+// or one that isn't a number where a number is wanted) is unknown: it makes
+// comparisons unknown, and an unknown result doesn't match.
+//
+// An Evaluator caches the regexes it compiles and is not safe for
+// concurrent use.
+type Evaluator struct {
+	fields  map[string]FieldDef
+	regexps map[string]*regexp.Regexp
+}
+
+// NewEvaluator creates a new evaluator with the given field definitions.
+func NewEvaluator(fields map[string]FieldDef) *Evaluator {
+	return &Evaluator{fields: fields, regexps: make(map[string]*regexp.Regexp)}
+}
+
+// Match reports whether the record env matches pq. env holds the record's
+// value of each field, by field name: strings, numbers, time.Time for time
+// fields and maps for JSON fields. Fields env lacks are empty, as a column
+// is never NULL.
+func (e *Evaluator) Match(pq *ParsedQuery, env map[string]any) (bool, error) {
+	result, err := e.eval(pq.Node(), env)
+	if err != nil {
+		return false, err
+	}
+	switch result := result.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return result, nil
+	default:
+		return false, fmt.Errorf("expression is %T, not a condition", result)
+	}
+}
+
+// eval returns the value of node, or nil if it is unknown.
+func (e *Evaluator) eval(node ast.Node, env map[string]any) (any, error) {
+	switch n := node.(type) {
+	case *ast.BinaryNode:
+		return e.evalBinary(n, env)
+	case *ast.UnaryNode:
+		return e.evalUnary(n, env)
+	case *ast.IdentifierNode:
+		field, ok := e.fields[n.Value]
+		if !ok {
+			return nil, fmt.Errorf("unknown field: %s", n.Value)
+		}
+		if v, ok := env[n.Value]; ok {
+			return normalizeValue(v), nil
+		}
+		return zeroValue(field.Type), nil
+	case *ast.StringNode:
+		return strings.ToLower(n.Value), nil
+	case *ast.IntegerNode:
+		return float64(n.Value), nil
+	case *ast.FloatNode:
+		return n.Value, nil
+	case *ast.BoolNode:
+		return n.Value, nil
+	case *ast.ArrayNode:
+		values := make([]any, len(n.Nodes))
+		for i, elem := range n.Nodes {
+			v, err := e.eval(elem, env)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		return values, nil
+	case *ast.ConstantNode:
+		return evalConstant(n)
+	case *ast.CallNode:
+		return e.evalCall(n, env)
+	case *ast.MemberNode:
+		v, err := e.memberValue(n, env)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		return jsonText(v), nil
+	case *ast.NilNode:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported node type: %T", n)
+	}
+}
+
+func (e *Evaluator) evalBinary(n *ast.BinaryNode, env map[string]any) (any, error) {
+	switch n.Operator {
+	case "contains", "startsWith", "endsWith", "matches":
+		return e.evalStringMethod(n, env)
+	}
+
+	// JSON keys compared with numbers, like fields.bytes_sent > 1000
+	if member, ok := n.Left.(*ast.MemberNode); ok && isNumericLiteral(n.Right) {
+		v, err := e.memberValue(member, env)
+		if err != nil {
+			return nil, err
+		}
+		return e.compare(n.Operator, jsonNumber(v), n.Right, env)
+	}
+
+	// Prefix wildcards like uri == "/api/*", matched as stored
+	if isStringField(n.Left, e.fields) && (n.Operator == "==" || n.Operator == "!=") {
+		if str, ok := n.Right.(*ast.StringNode); ok {
+			prefix, wildcard, err := wildcardPrefix(str.Value)
+			if err != nil {
+				return nil, err
+			}
+			if wildcard {
+				return e.evalWildcard(n, prefix, env)
+			}
+		}
+	}
+
+	// Numeric ranges like http_status in 400..499
+	if rng, ok := n.Right.(*ast.BinaryNode); ok && n.Operator == "in" && rng.Operator == ".." {
+		if err := checkRange(n.Left, rng, e.fields); err != nil {
+			return nil, err
+		}
+		v, err := e.eval(n.Left, env)
+		if err != nil {
+			return nil, err
+		}
+		x, ok := v.(float64)
+		if !ok {
+			return nil, nil
+		}
+		low, high := rng.Left.(*ast.IntegerNode).Value, rng.Right.(*ast.IntegerNode).Value
+		return x >= float64(low) && x <= float64(high), nil
+	}
+
+	switch n.Operator {
+	case "and", "&&", "or", "||":
+		left, err := e.eval(n.Left, env)
+		if err != nil {
+			return nil, err
+		}
+		right, err := e.eval(n.Right, env)
+		if err != nil {
+			return nil, err
+		}
+		return logic(n.Operator, left, right)
+	}
+
+	left, err := e.eval(n.Left, env)
+	if err != nil {
+		return nil, err
+	}
+	if s, ok := left.(string); ok && isStringField(n.Left, e.fields) && (n.Operator == "==" || n.Operator == "!=") {
+		left = strings.ToLower(s)
+	}
+	return e.compare(n.Operator, left, n.Right, env)
+}
+
+// compare applies a comparison, arithmetic or in operator to left and the
+// value of rightNode.
+func (e *Evaluator) compare(op string, left any, rightNode ast.Node, env map[string]any) (any, error) {
+	right, err := e.eval(rightNode, env)
+	if err != nil {
+		return nil, err
+	}
+	if left == nil || right == nil {
+		return nil, nil
+	}
+
+	switch op {
+	case "in":
+		values, ok := right.([]any)
+		if !ok {
+			return nil, fmt.Errorf("in requires a list, got %T", right)
+		}
+		for _, v := range values {
+			if c, err := compareValues(left, v); err == nil && c == 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "==", "!=", ">=", "<=", ">", "<":
+		c, err := compareValues(left, right)
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "==":
+			return c == 0, nil
+		case "!=":
+			return c != 0, nil
+		case ">=":
+			return c >= 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		default:
+			return c < 0, nil
+		}
+	case "+", "-", "*", "/", "%":
+		return arithmetic(op, left, right)
+	default:
+		return nil, fmt.Errorf("unknown operator: %s", op)
+	}
+}
+
+// evalStringMethod matches contains, startsWith and endsWith, folding the
+// case of the value; the literal is lowercased already.
+func (e *Evaluator) evalStringMethod(n *ast.BinaryNode, env map[string]any) (any, error) {
+	if n.Operator == "matches" {
+		return e.evalMatches(n, env)
+	}
+	left, err := e.eval(n.Left, env)
+	if err != nil {
+		return nil, err
+	}
+	right, err := e.eval(n.Right, env)
+	if err != nil {
+		return nil, err
+	}
+	if left == nil || right == nil {
+		return nil, nil
+	}
+	pattern, ok := right.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s requires a string, got %T", n.Operator, right)
+	}
+	s := strings.ToLower(jsonText(left))
+
+	switch n.Operator {
+	case "contains":
+		return strings.Contains(s, pattern), nil
+	case "startsWith":
+		return strings.HasPrefix(s, pattern), nil
+	default:
+		return strings.HasSuffix(s, pattern), nil
+	}
+}
+
+// evalMatches matches field matches "pattern" as the SQL builder does: the
+// value as stored against the pattern as written, case-insensitively.
+func (e *Evaluator) evalMatches(n *ast.BinaryNode, env map[string]any) (any, error) {
+	if err := checkRegex(n.Left, n.Right, e.fields); err != nil {
+		return nil, err
+	}
+	left, err := e.eval(n.Left, env)
+	if err != nil || left == nil {
+		return nil, err
+	}
+
+	pattern := foldRegex(n.Right.(*ast.StringNode).Value)
+	re, ok := e.regexps[pattern]
+	if !ok {
+		if re, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid regex pattern: %w", err)
+		}
+		e.regexps[pattern] = re
+	}
+	return re.MatchString(jsonText(left)), nil
+}
+
+// evalWildcard matches field == "prefix*", or its negation for !=.
+func (e *Evaluator) evalWildcard(n *ast.BinaryNode, prefix string, env map[string]any) (any, error) {
+	left, err := e.eval(n.Left, env)
+	if err != nil || left == nil {
+		return nil, err
+	}
+	if ident, ok := n.Left.(*ast.IdentifierNode); ok && e.fields[ident.Value].Lowercase {
+		prefix = strings.ToLower(prefix)
+	}
+	match := strings.HasPrefix(jsonText(left), prefix)
+	if n.Operator == "!=" {
+		return !match, nil
+	}
+	return match, nil
+}
+
+func (e *Evaluator) evalUnary(n *ast.UnaryNode, env map[string]any) (any, error) {
+	v, err := e.eval(n.Node, env)
+	if err != nil || v == nil {
+		return nil, err
+	}
+
+	switch n.Operator {
+	case "not", "!":
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("not requires a condition, got %T", v)
+		}
+		return !b, nil
+	case "-":
+		x, ok := v.(float64)
+		if !ok {
+			return nil, fmt.Errorf("- requires a number, got %T", v)
+		}
+		return -x, nil
+	default:
+		return nil, fmt.Errorf("unsupported unary operator: %s", n.Operator)
+	}
+}
+
+func (e *Evaluator) evalCall(n *ast.CallNode, env map[string]any) (any, error) {
+	callee, ok := n.Callee.(*ast.IdentifierNode)
+	if !ok {
+		return nil, fmt.Errorf("unsupported callee type")
+	}
+
+	switch callee.Value {
+	case "now":
+		return time.Now(), nil
+
+	case "duration":
+		if len(n.Arguments) != 1 {
+			return nil, fmt.Errorf("duration() requires exactly 1 argument")
+		}
+		strNode, ok := n.Arguments[0].(*ast.StringNode)
+		if !ok {
+			return nil, fmt.Errorf("duration() argument must be a string")
+		}
+		dur, err := time.ParseDuration(strNode.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration: %w", err)
+		}
+		return dur, nil
+
+	case "lower", "upper", "len":
+		if len(n.Arguments) != 1 {
+			return nil, fmt.Errorf("%s() requires exactly 1 argument", callee.Value)
+		}
+		v, err := e.eval(n.Arguments[0], env)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		s := jsonText(v)
+		switch callee.Value {
+		case "lower":
+			return strings.ToLower(s), nil
+		case "upper":
+			return strings.ToUpper(s), nil
+		default:
+			return float64(len(s)), nil
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported function: %s", callee.Value)
+	}
+}
+
+// memberValue returns the value of a JSON key like fields.status, or nil
+// if the record lacks it.
+func (e *Evaluator) memberValue(n *ast.MemberNode, env map[string]any) (any, error) {
+	ident, ok := n.Node.(*ast.IdentifierNode)
+	if !ok {
+		return nil, fmt.Errorf("unsupported member access")
+	}
+	field, ok := e.fields[ident.Value]
+	if !ok {
+		return nil, fmt.Errorf("unknown field: %s", ident.Value)
+	}
+	if field.Type != FieldTypeJSON {
+		return nil, fmt.Errorf("field %q does not support member access", ident.Value)
+	}
+
+	var propName string
+	switch prop := n.Property.(type) {
+	case *ast.StringNode:
+		propName = prop.Value
+	case *ast.IdentifierNode:
+		propName = prop.Value
+	default:
+		return nil, fmt.Errorf("unsupported property type")
+	}
+	if !isValidJSONPropertyName(propName) {
+		return nil, invalidPropertyError(propName)
+	}
+
+	m, _ := normalizeValue(env[ident.Value]).(map[string]any)
+	return normalizeValue(m[propName]), nil
+}
+
+// evalConstant is visitConstant for the evaluator.
+func evalConstant(n *ast.ConstantNode) (any, error) {
+	switch val := n.Value.(type) {
+	case []any:
+		values := make([]any, len(val))
+		for i, item := range val {
+			if s, ok := item.(string); ok {
+				item = strings.ToLower(s)
+			}
+			values[i] = normalizeValue(item)
+		}
+		return values, nil
+	case map[string]struct{}:
+		values := make([]any, 0, len(val))
+		for key := range val {
+			values = append(values, strings.ToLower(key))
+		}
+		return values, nil
+	case string:
+		return strings.ToLower(val), nil
+	case int, int64, float64, bool:
+		return normalizeValue(val), nil
+	default:
+		return nil, fmt.Errorf("unsupported constant type: %T", val)
+	}
+}
+
+// normalizeValue converts numbers to float64 and label maps to
+// map[string]any, so values compare alike whatever their Go type.
+func normalizeValue(v any) any {
+	switch v := v.(type) {
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint16:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return v.String()
+		}
+		return f
+	case map[string]string:
+		m := make(map[string]any, len(v))
+		for k, s := range v {
+			m[k] = s
+		}
+		return m
+	}
+	return v
+}
+
+// zeroValue returns the empty value of a field type.
+func zeroValue(t FieldType) any {
+	switch t {
+	case FieldTypeInt, FieldTypeFloat:
+		return 0.0
+	case FieldTypeTime:
+		return time.Time{}
+	case FieldTypeJSON:
+		return map[string]any{}
+	default:
+		return ""
+	}
+}
+
+// jsonText returns a value as text, as a JSON key is read as a string:
+// strings as they are, other values as JSON.
+func jsonText(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// jsonNumber returns a JSON value as a number: numbers, and strings
+// holding a number ("0.25"). Other values are unknown (nil).
+func jsonNumber(v any) any {
+	switch v := v.(type) {
+	case float64:
+		return v
+	case string:
+		if !numberText.MatchString(v) {
+			return nil
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil
+		}
+		return f
+	}
+	return nil
+}
+
+// compareValues orders two known values of the same kind.
+func compareValues(a, b any) (int, error) {
+	switch a := a.(type) {
+	case float64:
+		if b, ok := b.(float64); ok {
+			switch {
+			case a < b:
+				return -1, nil
+			case a > b:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			return strings.Compare(a, b), nil
+		}
+	case time.Time:
+		if b, ok := b.(time.Time); ok {
+			return a.Compare(b), nil
+		}
+	case bool:
+		if b, ok := b.(bool); ok {
+			if a == b {
+				return 0, nil
+			}
+			if b {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+	return 0, fmt.Errorf("cannot compare %T with %T", a, b)
+}
+
+// arithmetic applies +, -, *, / or % to numbers, or adds a duration to a
+// time, as in timestamp > now() - duration("1h").
+func arithmetic(op string, a, b any) (any, error) {
+	if t, ok := a.(time.Time); ok {
+		if d, ok := b.(time.Duration); ok {
+			switch op {
+			case "+":
+				return t.Add(d), nil
+			case "-":
+				return t.Add(-d), nil
+			}
+		}
+		return nil, fmt.Errorf("operator %s not supported for %T and %T", op, a, b)
+	}
+
+	x, okA := a.(float64)
+	y, okB := b.(float64)
+	if !okA || !okB {
+		return nil, fmt.Errorf("operator %s not supported for %T and %T", op, a, b)
+	}
+	switch op {
+	case "+":
+		return x + y, nil
+	case "-":
+		return x - y, nil
+	case "*":
+		return x * y, nil
+	case "/":
+		if y == 0 {
+			return nil, nil
+		}
+		return x / y, nil
+	default:
+		if y == 0 {
+			return nil, nil
+		}
+		return float64(int64(x) % int64(y)), nil
+	}
+}
+
+// logic applies and or or with SQL's three-valued logic: unknown and false
+// is false, unknown or true is true, and otherwise unknown stays unknown.
+func logic(op string, left, right any) (any, error) {
+	l, lKnown := left.(bool)
+	r, rKnown := right.(bool)
+	if (left != nil && !lKnown) || (right != nil && !rKnown) {
+		return nil, fmt.Errorf("%s requires conditions, got %T and %T", op, left, right)
+	}
+
+	if op == "and" || op == "&&" {
+		switch {
+		case (lKnown && !l) || (rKnown && !r):
+			return false, nil
+		case lKnown && rKnown:
+			return true, nil
+		}
+		return nil, nil
+	}
+	switch {
+	case (lKnown && l) || (rKnown && r):
+		return true, nil
+	case lKnown && rKnown:
+		return false, nil
+	}
+	return nil, nil
+}
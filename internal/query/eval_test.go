@@ -0,0 +1,75 @@
+package query
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvaluator_Match(t *testing.T) {
+	dsl := NewQueryDSL(DefaultFields)
+	evaluator := NewEvaluator(DefaultFields)
+
+	env := map[string]any{
+		"level":       "error",
+		"message":     "Connection Timeout after 30s",
+		"source":      "Nginx-1",
+		"type":        "nginx",
+		"uri":         "/API/orders/42",
+		"http_status": 502,
+		"timestamp":   time.Now().Add(-30 * time.Minute),
+		"fields":      map[string]any{"bytes_sent": 2048.0, "request_time": "0.25", "user": "Alice"},
+		"labels":      map[string]string{"env": "Prod"},
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`level == "ERROR"`, true},
+		{`level != "error"`, false},
+		{`level in ["warning", "error"]`, true},
+		{`level not in ["warning", "error"]`, false},
+		{`http_status >= 500 && http_status < 600`, true},
+		{`http_status in 400..499`, false},
+		{`http_status in 500..599`, true},
+		{`message contains "TIMEOUT"`, true},
+		{`message startsWith "connection"`, true},
+		{`message endsWith "30S"`, true},
+		{`message matches "timeout after [0-9]+s"`, true},
+		{`message matches "^\\S+ \\S+ after \\d+s$"`, true}, // escapes keep their case
+		{`message matches "\\D\\W\\B"`, false},
+		{`uri == "/API/*"`, true},
+		{`uri == "/api/*"`, false}, // wildcards match as stored
+		{`type == "NGINX*"`, true}, // except on Lowercase fields
+		{`source != "Nginx*"`, false},
+		{`fields.bytes_sent > 1000`, true},
+		{`fields.request_time > 0.2`, true}, // numbers stored as strings count
+		{`fields.user > 1`, false},          // not a number: unknown
+		{`not (fields.user > 1)`, false},    // still unknown
+		{`fields.user > 1 || level == "error"`, true},
+		{`fields.user == "alice"`, true},
+		{`fields.missing == "x"`, false},
+		{`fields.missing != "x"`, false},
+		{`labels.env == "prod"`, true},
+		{`labels.env in ["prod", "staging"]`, false}, // in doesn't fold the value
+		{`timestamp > now() - duration("1h")`, true},
+		{`timestamp > now() - duration("10m")`, false},
+		{`source == "nginx-1" and file_path == ""`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			pq, err := dsl.Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got, err := evaluator.Match(pq, env)
+			if err != nil {
+				t.Fatalf("Match() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
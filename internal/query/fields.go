@@ -12,12 +12,28 @@ const (
 	FieldTypeJSON
 )
 
+// String returns the field type name exposed to API clients.
+func (t FieldType) String() string {
+	switch t {
+	case FieldTypeInt, FieldTypeFloat:
+		return "numeric"
+	case FieldTypeTime:
+		return "time"
+	case FieldTypeJSON:
+		return "map"
+	default:
+		return "string"
+	}
+}
+
 // FieldDef defines a queryable field with its allowed operators.
 type FieldDef struct {
 	Name      string    // expr field name
 	Column    string    // ClickHouse column name
 	Type      FieldType // data type
 	Operators []string  // allowed operators
+	FullText  bool      // supports full-text search via the q parameter
+	Lowercase bool      // values are stored lowercased, so prefix wildcards fold case
 }
 
 // DefaultFields contains all queryable log fields.
@@ -36,12 +52,15 @@ var DefaultFields = map[string]FieldDef{
 		Column:    "level",
 		Type:      FieldTypeString,
 		Operators: []string{"==", "!=", "in"},
+
+		Lowercase: true,
 	},
 	"message": {
 		Name:      "message",
 		Column:    "message",
 		Type:      FieldTypeString,
 		Operators: []string{"==", "!=", "contains", "startsWith", "endsWith", "matches"},
+		FullText:  true,
 	},
 	"source": {
 		Name:      "source",
@@ -54,6 +73,8 @@ var DefaultFields = map[string]FieldDef{
 		Column:    "type",
 		Type:      FieldTypeString,
 		Operators: []string{"==", "!=", "in"},
+
+		Lowercase: true,
 	},
 	"agent_id": {
 		Name:      "agent_id",
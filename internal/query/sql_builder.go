@@ -13,16 +13,34 @@ import (
 // Matches nested quantifiers like (a+)+, (a*)+, (a|a)+, etc.
 var reDoSPattern = regexp.MustCompile(`\([^)]*[+*][^)]*\)[+*]|\([^)]*\|[^)]*\)[+*]`)
 
-// SQLBuilder converts parsed expressions to ClickHouse SQL.
+// Dialect is the SQL flavour an SQLBuilder writes.
+type Dialect int
+
+const (
+	// DialectClickHouse writes ClickHouse SQL.
+	DialectClickHouse Dialect = iota
+
+	// DialectPostgres writes PostgreSQL, for the logs table of the Postgres
+	// log storage. Placeholders are still ?, for the caller to rebind.
+	DialectPostgres
+)
+
+// SQLBuilder converts parsed expressions to SQL, ClickHouse by default.
 type SQLBuilder struct {
-	fields map[string]FieldDef
+	fields  map[string]FieldDef
+	dialect Dialect
 }
 
-// NewSQLBuilder creates a new SQL builder.
+// NewSQLBuilder creates a new SQL builder for ClickHouse.
 func NewSQLBuilder(fields map[string]FieldDef) *SQLBuilder {
 	return &SQLBuilder{fields: fields}
 }
 
+// NewSQLBuilderWithDialect creates a new SQL builder for dialect.
+func NewSQLBuilderWithDialect(fields map[string]FieldDef, dialect Dialect) *SQLBuilder {
+	return &SQLBuilder{fields: fields, dialect: dialect}
+}
+
 // BuildResult contains the generated SQL and parameters.
 type BuildResult struct {
 	SQL  string
@@ -32,8 +50,9 @@ type BuildResult struct {
 // Build generates SQL WHERE clause from a parsed query.
 func (b *SQLBuilder) Build(pq *ParsedQuery) (*BuildResult, error) {
 	v := &sqlVisitor{
-		fields: b.fields,
-		args:   make([]any, 0),
+		fields:  b.fields,
+		dialect: b.dialect,
+		args:    make([]any, 0),
 	}
 
 	node := pq.Node()
@@ -50,8 +69,9 @@ func (b *SQLBuilder) Build(pq *ParsedQuery) (*BuildResult, error) {
 
 // sqlVisitor traverses AST and generates SQL.
 type sqlVisitor struct {
-	fields map[string]FieldDef
-	args   []any
+	fields  map[string]FieldDef
+	dialect Dialect
+	args    []any
 }
 
 func (v *sqlVisitor) visit(node *ast.Node) (string, error) {
@@ -91,6 +111,31 @@ func (v *sqlVisitor) visitBinary(n *ast.BinaryNode) (string, error) {
 		return v.handleStringMethod(n)
 	}
 
+	// Handle numeric comparisons of JSON keys like fields.bytes_sent > 1000
+	if _, ok := n.Left.(*ast.MemberNode); ok && isNumericLiteral(n.Right) {
+		return v.handleNumericMember(n)
+	}
+
+	// Handle prefix wildcards like uri == "/api/*"
+	if isStringField(n.Left, v.fields) && (n.Operator == "==" || n.Operator == "!=") {
+		if str, ok := n.Right.(*ast.StringNode); ok {
+			prefix, wildcard, err := wildcardPrefix(str.Value)
+			if err != nil {
+				return "", err
+			}
+			if wildcard {
+				return v.handleWildcard(n, prefix)
+			}
+		}
+	}
+
+	// Handle numeric ranges like http_status in 400..499
+	if n.Operator == "in" {
+		if rng, ok := n.Right.(*ast.BinaryNode); ok && rng.Operator == ".." {
+			return v.handleRange(n.Left, rng)
+		}
+	}
+
 	left, err := v.visit(&n.Left)
 	if err != nil {
 		return "", err
@@ -112,7 +157,7 @@ func (v *sqlVisitor) visitBinary(n *ast.BinaryNode) (string, error) {
 	}
 
 	// Handle case-insensitive string comparison
-	if v.isStringField(n.Left) && (n.Operator == "==" || n.Operator == "!=") {
+	if isStringField(n.Left, v.fields) && (n.Operator == "==" || n.Operator == "!=") {
 		left = fmt.Sprintf("lower(%s)", left)
 		// The right side value will be lowercased when added as arg
 	}
@@ -159,10 +204,21 @@ func (v *sqlVisitor) visitFloat(n *ast.FloatNode) (string, error) {
 }
 
 func (v *sqlVisitor) visitBool(n *ast.BoolNode) (string, error) {
-	if n.Value {
-		return "1", nil
+	return v.boolLiteral(n.Value), nil
+}
+
+// boolLiteral writes a boolean constant.
+func (v *sqlVisitor) boolLiteral(b bool) string {
+	switch {
+	case v.dialect == DialectPostgres && b:
+		return "TRUE"
+	case v.dialect == DialectPostgres:
+		return "FALSE"
+	case b:
+		return "1"
+	default:
+		return "0"
 	}
-	return "0", nil
 }
 
 func (v *sqlVisitor) visitArray(n *ast.ArrayNode) (string, error) {
@@ -207,10 +263,7 @@ func (v *sqlVisitor) visitConstant(n *ast.ConstantNode) (string, error) {
 		v.args = append(v.args, val)
 		return "?", nil
 	case bool:
-		if val {
-			return "1", nil
-		}
-		return "0", nil
+		return v.boolLiteral(val), nil
 	default:
 		return "", fmt.Errorf("unsupported constant type: %T", val)
 	}
@@ -276,7 +329,19 @@ func (v *sqlVisitor) visitCall(n *ast.CallNode) (string, error) {
 }
 
 func (v *sqlVisitor) visitMember(n *ast.MemberNode) (string, error) {
-	// Handle JSON field access: fields.status or labels.key
+	column, err := v.memberKey(n)
+	if err != nil {
+		return "", err
+	}
+	if v.dialect == DialectPostgres {
+		return fmt.Sprintf("(%s ->> ?)", column), nil
+	}
+	return fmt.Sprintf("JSONExtractString(%s, ?)", column), nil
+}
+
+// memberKey resolves JSON field access like fields.status or labels.key,
+// adding the key as an arg, and returns the field's column.
+func (v *sqlVisitor) memberKey(n *ast.MemberNode) (string, error) {
 	ident, ok := n.Node.(*ast.IdentifierNode)
 	if !ok {
 		return "", fmt.Errorf("unsupported member access")
@@ -301,15 +366,67 @@ func (v *sqlVisitor) visitMember(n *ast.MemberNode) (string, error) {
 		return "", fmt.Errorf("unsupported property type")
 	}
 
-	// Validate property name to prevent SQL injection
-	// Only allow alphanumeric characters, underscores, and hyphens
+	// The key is passed as a parameter; validating it as well keeps
+	// typos and oversized keys out of the query
 	if !isValidJSONPropertyName(propName) {
-		return "", fmt.Errorf("invalid JSON property name: %q", propName)
+		return "", invalidPropertyError(propName)
+	}
+
+	v.args = append(v.args, propName)
+	return field.Column, nil
+}
+
+// handleNumericMember builds a comparison of a JSON key with a number,
+// reading the key as a number, or NULL. Numbers stored as JSON strings
+// ("0.25") count too.
+func (v *sqlVisitor) handleNumericMember(n *ast.BinaryNode) (string, error) {
+	column, err := v.memberKey(n.Left.(*ast.MemberNode))
+	if err != nil {
+		return "", err
+	}
+	left := fmt.Sprintf(`toFloat64OrNull(trim(BOTH '"' FROM JSONExtractRaw(%s, ?)))`, column)
+	if v.dialect == DialectPostgres {
+		// No cast returns NULL on bad input, so only numbers are cast; the
+		// key is read twice
+		v.args = append(v.args, v.args[len(v.args)-1])
+		left = fmt.Sprintf(`(CASE WHEN (%[1]s ->> ?) ~ '%[2]s' THEN (%[1]s ->> ?)::float8 END)`, column, postgresNumberPattern)
 	}
 
-	// Use JSONExtractString for JSON field access
-	// Property name is validated above to be safe
-	return fmt.Sprintf("JSONExtractString(%s, '%s')", field.Column, propName), nil
+	right, err := v.visit(&n.Right)
+	if err != nil {
+		return "", err
+	}
+
+	switch n.Operator {
+	case "in":
+		return fmt.Sprintf("%s IN %s", left, right), nil
+	case "==", "!=", ">=", "<=", ">", "<":
+		op, err := v.mapOperator(n.Operator)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("(%s %s %s)", left, op, right), nil
+	default:
+		return "", fmt.Errorf("operator %q not supported for numeric JSON comparison", n.Operator)
+	}
+}
+
+// isNumericLiteral checks if a node is a number or a list of numbers.
+func isNumericLiteral(node ast.Node) bool {
+	switch n := node.(type) {
+	case *ast.IntegerNode, *ast.FloatNode:
+		return true
+	case *ast.ArrayNode:
+		for _, elem := range n.Nodes {
+			switch elem.(type) {
+			case *ast.IntegerNode, *ast.FloatNode:
+			default:
+				return false
+			}
+		}
+		return len(n.Nodes) > 0
+	}
+	return false
 }
 
 // isStringMethodCall checks if this is a string method call like "message contains x".
@@ -323,6 +440,10 @@ func (v *sqlVisitor) isStringMethodCall(n *ast.BinaryNode) bool {
 
 // handleStringMethod handles string method operations.
 func (v *sqlVisitor) handleStringMethod(n *ast.BinaryNode) (string, error) {
+	if n.Operator == "matches" {
+		return v.handleMatches(n)
+	}
+
 	left, err := v.visit(&n.Left)
 	if err != nil {
 		return "", err
@@ -333,6 +454,10 @@ func (v *sqlVisitor) handleStringMethod(n *ast.BinaryNode) (string, error) {
 		return "", err
 	}
 
+	if v.dialect == DialectPostgres {
+		return v.postgresStringMethod(n, left, right)
+	}
+
 	switch n.Operator {
 	case "contains":
 		return fmt.Sprintf("position(lower(%s), %s) > 0", left, right), nil
@@ -340,25 +465,171 @@ func (v *sqlVisitor) handleStringMethod(n *ast.BinaryNode) (string, error) {
 		return fmt.Sprintf("startsWith(lower(%s), %s)", left, right), nil
 	case "endsWith":
 		return fmt.Sprintf("endsWith(lower(%s), %s)", left, right), nil
-	case "matches":
-		// Validate regex for ReDoS before using
-		if strNode, ok := n.Right.(*ast.StringNode); ok {
-			if reDoSPattern.MatchString(strNode.Value) {
-				return "", fmt.Errorf("potentially dangerous regex pattern: nested quantifiers detected")
-			}
-		}
-		return fmt.Sprintf("match(lower(%s), %s)", left, right), nil
 	default:
 		return "", fmt.Errorf("unknown string method: %s", n.Operator)
 	}
 }
 
-// isStringField checks if a node is a string type field.
-func (v *sqlVisitor) isStringField(node ast.Node) bool {
-	if ident, ok := node.(*ast.IdentifierNode); ok {
-		if field, ok := v.fields[ident.Value]; ok {
+// postgresNumberPattern matches the text of a JSON number, or of a number
+// stored as a JSON string.
+const postgresNumberPattern = `^\s*[-+]?([0-9]+\.?[0-9]*|\.[0-9]+)([eE][-+]?[0-9]+)?\s*$`
+
+// postgresStringMethod is handleStringMethod for PostgreSQL.
+func (v *sqlVisitor) postgresStringMethod(n *ast.BinaryNode, left, right string) (string, error) {
+	switch n.Operator {
+	case "contains":
+		return fmt.Sprintf("strpos(lower(%s), %s) > 0", left, right), nil
+	case "startsWith":
+		return fmt.Sprintf("starts_with(lower(%s), %s)", left, right), nil
+	case "endsWith":
+		return fmt.Sprintf("starts_with(reverse(lower(%s)), reverse(%s))", left, right), nil
+	default:
+		return "", fmt.Errorf("unknown string method: %s", n.Operator)
+	}
+}
+
+// handleMatches builds field matches "pattern" against the column as
+// stored. The pattern is sent as written, since lowercasing it would flip
+// escapes like \S and \W; foldRegex makes the match case-insensitive.
+func (v *sqlVisitor) handleMatches(n *ast.BinaryNode) (string, error) {
+	// Validate regex for ReDoS before using
+	if err := checkRegex(n.Left, n.Right, v.fields); err != nil {
+		return "", err
+	}
+	left, err := v.visit(&n.Left)
+	if err != nil {
+		return "", err
+	}
+	v.args = append(v.args, foldRegex(n.Right.(*ast.StringNode).Value))
+
+	if v.dialect == DialectPostgres {
+		return fmt.Sprintf("(%s ~ ?)", left), nil
+	}
+	return fmt.Sprintf("match(%s, ?)", left), nil
+}
+
+// handleWildcard builds a prefix match for field == "prefix*", or its
+// negation for !=. The column is compared as stored, so ClickHouse can use
+// the sort key and skip indexes: the match folds case only on Lowercase
+// fields, by lowercasing the prefix.
+func (v *sqlVisitor) handleWildcard(n *ast.BinaryNode, prefix string) (string, error) {
+	left, err := v.visit(&n.Left)
+	if err != nil {
+		return "", err
+	}
+	if ident, ok := n.Left.(*ast.IdentifierNode); ok && v.fields[ident.Value].Lowercase {
+		prefix = strings.ToLower(prefix)
+	}
+	v.args = append(v.args, prefix)
+
+	sql := fmt.Sprintf("startsWith(%s, ?)", left)
+	if v.dialect == DialectPostgres {
+		sql = fmt.Sprintf("starts_with(%s, ?)", left)
+	}
+	if n.Operator == "!=" {
+		return fmt.Sprintf("NOT %s", sql), nil
+	}
+	return sql, nil
+}
+
+// handleRange builds an inclusive range match for field in low..high.
+func (v *sqlVisitor) handleRange(field ast.Node, rng *ast.BinaryNode) (string, error) {
+	if err := checkRange(field, rng, v.fields); err != nil {
+		return "", err
+	}
+	left, err := v.visit(&field)
+	if err != nil {
+		return "", err
+	}
+	v.args = append(v.args, rng.Left.(*ast.IntegerNode).Value, rng.Right.(*ast.IntegerNode).Value)
+	return fmt.Sprintf("(%s BETWEEN ? AND ?)", left), nil
+}
+
+// checkRange reports whether field in low..high can be built: a numeric
+// field and integer literal bounds.
+func checkRange(field ast.Node, rng *ast.BinaryNode, fields map[string]FieldDef) error {
+	ident, ok := field.(*ast.IdentifierNode)
+	if !ok {
+		return fmt.Errorf("range requires a numeric field")
+	}
+	def, ok := fields[ident.Value]
+	if !ok {
+		return fmt.Errorf("unknown field: %s", ident.Value)
+	}
+	if def.Type != FieldTypeInt && def.Type != FieldTypeFloat {
+		return fmt.Errorf("range requires a numeric field, %q is %s", ident.Value, def.Type)
+	}
+	_, lowOK := rng.Left.(*ast.IntegerNode)
+	_, highOK := rng.Right.(*ast.IntegerNode)
+	if !lowOK || !highOK {
+		return fmt.Errorf("range bounds for %q must be integer literals", ident.Value)
+	}
+	return nil
+}
+
+// checkRegex reports whether field matches pattern can be built: a string
+// field and a literal pattern without nested quantifiers.
+func checkRegex(field, pattern ast.Node, fields map[string]FieldDef) error {
+	ident, ok := field.(*ast.IdentifierNode)
+	if !ok {
+		return fmt.Errorf("regex match requires a string field")
+	}
+	def, ok := fields[ident.Value]
+	if !ok {
+		return fmt.Errorf("unknown field: %s", ident.Value)
+	}
+	if def.Type != FieldTypeString {
+		return fmt.Errorf("regex match requires a string field, %q is %s", ident.Value, def.Type)
+	}
+	str, ok := pattern.(*ast.StringNode)
+	if !ok {
+		return fmt.Errorf("regex pattern for %q must be a string literal", ident.Value)
+	}
+	if _, err := regexp.Compile(str.Value); err != nil {
+		return fmt.Errorf("invalid regex pattern for %q: %w", ident.Value, err)
+	}
+	if reDoSPattern.MatchString(str.Value) {
+		return fmt.Errorf("potentially dangerous regex pattern: nested quantifiers detected")
+	}
+	return nil
+}
+
+// foldRegex makes a regex pattern case-insensitive. Both RE2 and
+// PostgreSQL only take the (?i) flag at the start of the pattern.
+func foldRegex(pattern string) string {
+	if strings.HasPrefix(pattern, "(?i)") {
+		return pattern
+	}
+	return "(?i)" + pattern
+}
+
+// wildcardPrefix reports whether value is a prefix pattern ending in "*"
+// and returns the prefix. Only trailing wildcards are supported, since a
+// leading one cannot use an index; a "*" elsewhere matches literally.
+func wildcardPrefix(value string) (string, bool, error) {
+	if strings.HasPrefix(value, "*") {
+		return "", false, fmt.Errorf("leading wildcard in %q is not supported; use contains for substring search", value)
+	}
+	if !strings.HasSuffix(value, "*") {
+		return "", false, nil
+	}
+	return strings.TrimSuffix(value, "*"), true, nil
+}
+
+// isStringField checks if a node is a string type field. JSON keys like
+// fields.status are read as strings unless compared with a number.
+func isStringField(node ast.Node, fields map[string]FieldDef) bool {
+	switch n := node.(type) {
+	case *ast.IdentifierNode:
+		if field, ok := fields[n.Value]; ok {
 			return field.Type == FieldTypeString
 		}
+	case *ast.MemberNode:
+		if ident, ok := n.Node.(*ast.IdentifierNode); ok {
+			if field, ok := fields[ident.Value]; ok {
+				return field.Type == FieldTypeJSON
+			}
+		}
 	}
 	return false
 }
@@ -385,8 +656,12 @@ func (v *sqlVisitor) mapOperator(op string) (string, error) {
 	}
 }
 
-// durationToInterval converts Go duration to ClickHouse interval.
+// durationToInterval converts Go duration to a ClickHouse or PostgreSQL
+// interval.
 func (v *sqlVisitor) durationToInterval(d time.Duration) string {
+	if v.dialect == DialectPostgres {
+		return fmt.Sprintf("INTERVAL '%d seconds'", int64(d.Seconds()))
+	}
 	hours := d.Hours()
 	if hours >= 24 {
 		days := int(hours / 24)
@@ -402,10 +677,13 @@ func (v *sqlVisitor) durationToInterval(d time.Duration) string {
 	return fmt.Sprintf("INTERVAL %d SECOND", int(d.Seconds()))
 }
 
+// maxJSONPropertyLength caps the length of a JSON key in an expression.
+const maxJSONPropertyLength = 64
+
 // isValidJSONPropertyName checks if a property name is safe for use in SQL.
 // Only allows alphanumeric characters, underscores, and hyphens.
 func isValidJSONPropertyName(name string) bool {
-	if name == "" {
+	if name == "" || len(name) > maxJSONPropertyLength {
 		return false
 	}
 	for _, r := range name {
@@ -416,3 +694,8 @@ func isValidJSONPropertyName(name string) bool {
 	}
 	return true
 }
+
+// invalidPropertyError reports a JSON key isValidJSONPropertyName rejects.
+func invalidPropertyError(name string) error {
+	return fmt.Errorf("invalid JSON property name %q: use letters, digits, _ and -, at most %d characters", name, maxJSONPropertyLength)
+}
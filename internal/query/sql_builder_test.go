@@ -46,6 +46,36 @@ func TestSQLBuilder_Build(t *testing.T) {
 			wantSQL:  "startsWith(lower(uri), ?)",
 			wantArgs: []any{"/api/"},
 		},
+		{
+			name:     "prefix wildcard",
+			expr:     `uri == "/API/*"`,
+			wantSQL:  "startsWith(uri, ?)",
+			wantArgs: []any{"/API/"},
+		},
+		{
+			name:     "negated prefix wildcard",
+			expr:     `source != "nginx*"`,
+			wantSQL:  "NOT startsWith(source, ?)",
+			wantArgs: []any{"nginx"},
+		},
+		{
+			name:     "lowercase field prefix folds case",
+			expr:     `type == "Magento*"`,
+			wantSQL:  "startsWith(type, ?)",
+			wantArgs: []any{"magento"},
+		},
+		{
+			name:     "inner star is literal",
+			expr:     `message == "a*b"`,
+			wantSQL:  "(lower(message) = ?)",
+			wantArgs: []any{"a*b"},
+		},
+		{
+			name:     "wildcard with and",
+			expr:     `level == "error" and file_path == "/var/log/nginx/*"`,
+			wantSQL:  "((lower(level) = ?) AND startsWith(file_path, ?))",
+			wantArgs: []any{"error", "/var/log/nginx/"},
+		},
 		{
 			name:     "and logic",
 			expr:     `level == "error" and http_status >= 500`,
@@ -64,6 +94,78 @@ func TestSQLBuilder_Build(t *testing.T) {
 			wantSQL:  "NOT ((lower(level) = ?))",
 			wantArgs: []any{"debug"},
 		},
+		{
+			name:     "numeric range",
+			expr:     `http_status in 400..499`,
+			wantSQL:  "(http_status BETWEEN ? AND ?)",
+			wantArgs: []any{400, 499},
+		},
+		{
+			name:     "negated range",
+			expr:     `http_status not in 500..599 and level == "error"`,
+			wantSQL:  "(NOT ((http_status BETWEEN ? AND ?)) AND (lower(level) = ?))",
+			wantArgs: []any{500, 599, "error"},
+		},
+		{
+			name:     "regex",
+			expr:     `message matches "timeout|refused"`,
+			wantSQL:  "match(message, ?)",
+			wantArgs: []any{"(?i)timeout|refused"},
+		},
+		{
+			name:     "regex keeps escapes",
+			expr:     `message matches "^\\S+ Timeout \\D"`,
+			wantSQL:  "match(message, ?)",
+			wantArgs: []any{`(?i)^\S+ Timeout \D`},
+		},
+		{
+			name:     "in list",
+			expr:     `level in ["error", "fatal"] and http_status >= 500`,
+			wantSQL:  "(level IN (?, ?) AND (http_status >= ?))",
+			wantArgs: []any{"error", "fatal", 500},
+		},
+		{
+			name:     "grouped or with and not",
+			expr:     `(level == "error" or level == "fatal") and not (source == "healthcheck")`,
+			wantSQL:  "(((lower(level) = ?) OR (lower(level) = ?)) AND NOT ((lower(source) = ?)))",
+			wantArgs: []any{"error", "fatal", "healthcheck"},
+		},
+		{
+			name:     "symbolic operators",
+			expr:     `(level == "error" || level == "fatal") && !(source == "healthcheck")`,
+			wantSQL:  "(((lower(level) = ?) OR (lower(level) = ?)) AND NOT ((lower(source) = ?)))",
+			wantArgs: []any{"error", "fatal", "healthcheck"},
+		},
+		{
+			name:     "and binds tighter than or",
+			expr:     `level == "fatal" or level == "error" and http_status >= 500`,
+			wantSQL:  "((lower(level) = ?) OR ((lower(level) = ?) AND (http_status >= ?)))",
+			wantArgs: []any{"fatal", "error", 500},
+		},
+		{
+			name:     "nested groups",
+			expr:     `not ((level == "debug" or level == "info") and (message contains "health" or uri == "/ping*"))`,
+			wantSQL:  "NOT ((((lower(level) = ?) OR (lower(level) = ?)) AND (position(lower(message), ?) > 0 OR startsWith(uri, ?))))",
+			wantArgs: []any{"debug", "info", "health", "/ping"},
+		},
+		{
+			name:     "not in",
+			expr:     `level not in ["debug"]`,
+			wantSQL:  "NOT (level IN (?))",
+			wantArgs: []any{"debug"},
+		},
+		{
+			name:     "not contains",
+			expr:     `message not contains "health"`,
+			wantSQL:  "NOT (position(lower(message), ?) > 0)",
+			wantArgs: []any{"health"},
+		},
+		{
+			name:     "quotes stay in args",
+			expr:     `source == "x') or 1=1 --" or not (source == "y")`,
+			wantSQL:  "((lower(source) = ?) OR NOT ((lower(source) = ?)))",
+			wantArgs: []any{"x') or 1=1 --", "y"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -94,19 +196,58 @@ func TestSQLBuilder_JSONFields(t *testing.T) {
 	builder := NewSQLBuilder(DefaultFields)
 
 	tests := []struct {
-		name    string
-		expr    string
-		wantSQL string
+		name     string
+		expr     string
+		wantSQL  string
+		wantArgs []any
 	}{
 		{
-			name:    "json field access",
-			expr:    `fields.status == "200"`,
-			wantSQL: "(JSONExtractString(fields, 'status') = ?)",
+			name:     "json field access",
+			expr:     `fields.status == "200"`,
+			wantSQL:  "(lower(JSONExtractString(fields, ?)) = ?)",
+			wantArgs: []any{"status", "200"},
+		},
+		{
+			name:     "labels access",
+			expr:     `labels.env == "production"`,
+			wantSQL:  "(lower(JSONExtractString(labels, ?)) = ?)",
+			wantArgs: []any{"env", "production"},
+		},
+		{
+			name:     "string key is case-insensitive",
+			expr:     `fields.exception_class == "LocalizedException"`,
+			wantSQL:  "(lower(JSONExtractString(fields, ?)) = ?)",
+			wantArgs: []any{"exception_class", "localizedexception"},
+		},
+		{
+			name:     "string key prefix",
+			expr:     `fields["exception-class"] != "Magento*"`,
+			wantSQL:  "NOT startsWith(JSONExtractString(fields, ?), ?)",
+			wantArgs: []any{"exception-class", "Magento"},
+		},
+		{
+			name:     "string key contains",
+			expr:     `fields.exception_class contains "Localized"`,
+			wantSQL:  "position(lower(JSONExtractString(fields, ?)), ?) > 0",
+			wantArgs: []any{"exception_class", "localized"},
 		},
 		{
-			name:    "labels access",
-			expr:    `labels.env == "production"`,
-			wantSQL: "(JSONExtractString(labels, 'env') = ?)",
+			name:     "numeric key",
+			expr:     `fields.bytes_sent > 1000`,
+			wantSQL:  `(toFloat64OrNull(trim(BOTH '"' FROM JSONExtractRaw(fields, ?))) > ?)`,
+			wantArgs: []any{"bytes_sent", 1000},
+		},
+		{
+			name:     "numeric key equality",
+			expr:     `fields.pid == 4242 or fields.duration >= 0.5`,
+			wantSQL:  `((toFloat64OrNull(trim(BOTH '"' FROM JSONExtractRaw(fields, ?))) = ?) OR (toFloat64OrNull(trim(BOTH '"' FROM JSONExtractRaw(fields, ?))) >= ?))`,
+			wantArgs: []any{"pid", 4242, "duration", 0.5},
+		},
+		{
+			name:     "numeric key in list",
+			expr:     `fields.status in [502, 504]`,
+			wantSQL:  `toFloat64OrNull(trim(BOTH '"' FROM JSONExtractRaw(fields, ?))) IN (?, ?)`,
+			wantArgs: []any{"status", 502, 504},
 		},
 	}
 
@@ -125,6 +266,9 @@ func TestSQLBuilder_JSONFields(t *testing.T) {
 			if result.SQL != tt.wantSQL {
 				t.Errorf("SQL = %q, want %q", result.SQL, tt.wantSQL)
 			}
+			if !reflect.DeepEqual(result.Args, tt.wantArgs) {
+				t.Errorf("Args = %v, want %v", result.Args, tt.wantArgs)
+			}
 		})
 	}
 }
@@ -173,3 +317,96 @@ func TestSQLBuilder_TimeFunctions(t *testing.T) {
 		})
 	}
 }
+
+func TestSQLBuilder_Postgres(t *testing.T) {
+	dsl := NewQueryDSL(DefaultFields)
+	builder := NewSQLBuilderWithDialect(DefaultFields, DialectPostgres)
+
+	tests := []struct {
+		name     string
+		expr     string
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:     "equality",
+			expr:     `level == "error" and http_status >= 500`,
+			wantSQL:  "((lower(level) = ?) AND (http_status >= ?))",
+			wantArgs: []any{"error", 500},
+		},
+		{
+			name:     "contains",
+			expr:     `message contains "Timeout"`,
+			wantSQL:  "strpos(lower(message), ?) > 0",
+			wantArgs: []any{"timeout"},
+		},
+		{
+			name:     "startsWith and endsWith",
+			expr:     `uri startsWith "/api/" or uri endsWith ".php"`,
+			wantSQL:  "(starts_with(lower(uri), ?) OR starts_with(reverse(lower(uri)), reverse(?)))",
+			wantArgs: []any{"/api/", ".php"},
+		},
+		{
+			name:     "regex",
+			expr:     `message matches "timeout|refused"`,
+			wantSQL:  "(message ~ ?)",
+			wantArgs: []any{"(?i)timeout|refused"},
+		},
+		{
+			name:     "regex keeps escapes",
+			expr:     `uri matches "^/API/\\d+"`,
+			wantSQL:  "(uri ~ ?)",
+			wantArgs: []any{`(?i)^/API/\d+`},
+		},
+		{
+			name:     "prefix wildcard",
+			expr:     `source != "Nginx*"`,
+			wantSQL:  "NOT starts_with(source, ?)",
+			wantArgs: []any{"Nginx"},
+		},
+		{
+			name:     "json string key",
+			expr:     `fields.exception_class == "LocalizedException"`,
+			wantSQL:  "(lower((fields ->> ?)) = ?)",
+			wantArgs: []any{"exception_class", "localizedexception"},
+		},
+		{
+			name:     "json numeric key",
+			expr:     `fields.bytes_sent > 1000`,
+			wantSQL:  "((CASE WHEN (fields ->> ?) ~ '" + postgresNumberPattern + "' THEN (fields ->> ?)::float8 END) > ?)",
+			wantArgs: []any{"bytes_sent", "bytes_sent", 1000},
+		},
+		{
+			name:     "range",
+			expr:     `http_status in 400..499`,
+			wantSQL:  "(http_status BETWEEN ? AND ?)",
+			wantArgs: []any{400, 499},
+		},
+		{
+			name:    "duration",
+			expr:    `timestamp > now() - duration("90m")`,
+			wantSQL: "(timestamp > (now() - INTERVAL '5400 seconds'))",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := dsl.Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			result, err := builder.Build(parsed)
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+
+			if result.SQL != tt.wantSQL {
+				t.Errorf("SQL = %q, want %q", result.SQL, tt.wantSQL)
+			}
+			if tt.wantArgs != nil && !reflect.DeepEqual(result.Args, tt.wantArgs) {
+				t.Errorf("Args = %v, want %v", result.Args, tt.wantArgs)
+			}
+		})
+	}
+}
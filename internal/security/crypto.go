@@ -5,6 +5,7 @@ import (
 	"crypto/cipher"
 	"crypto/rand"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -24,6 +25,8 @@ const (
 	PBKDF2Iterations = 100000
 	// EncryptedFileSuffix indicates an encrypted file.
 	EncryptedFileSuffix = ".enc"
+	// SecretSize is the size in bytes of generated server secrets.
+	SecretSize = 32
 )
 
 // EncryptedData holds the components needed to decrypt data.
@@ -42,6 +45,17 @@ func GenerateSalt() ([]byte, error) {
 	return salt, nil
 }
 
+// GenerateSecret returns a random secret of SecretSize bytes, base64
+// encoded, in the format the server expects for its keys (the same as
+// `openssl rand -base64 32`).
+func GenerateSecret() (string, error) {
+	secret := make([]byte, SecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("generate secret: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(secret), nil
+}
+
 // DeriveKey derives an AES-256 key from a password and salt using PBKDF2.
 func DeriveKey(password, salt []byte) []byte {
 	return pbkdf2.Key(password, salt, PBKDF2Iterations, KeySizeAES, sha256.New)
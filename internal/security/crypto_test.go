@@ -2,6 +2,7 @@ package security
 
 import (
 	"bytes"
+	"encoding/base64"
 	"os"
 	"path/filepath"
 	"testing"
@@ -28,6 +29,29 @@ func TestGenerateSalt(t *testing.T) {
 	}
 }
 
+func TestGenerateSecret(t *testing.T) {
+	secret1, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret failed: %v", err)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(secret1)
+	if err != nil {
+		t.Fatalf("secret is not base64: %v", err)
+	}
+	if len(raw) != SecretSize {
+		t.Errorf("secret size: got %d, want %d", len(raw), SecretSize)
+	}
+
+	secret2, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret failed: %v", err)
+	}
+	if secret1 == secret2 {
+		t.Error("secrets should be unique")
+	}
+}
+
 func TestDeriveKey(t *testing.T) {
 	password := []byte("test-password")
 	salt := []byte("1234567890123456") // 16 bytes
@@ -12,9 +12,10 @@ import (
 
 // ServerTLSConfig holds server TLS configuration.
 type ServerTLSConfig struct {
-	CertFile     string // Server certificate file
-	KeyFile      string // Server private key file
-	ClientCAFile string // CA certificate for verifying client certs
+	CertFile     string    // Server certificate file
+	KeyFile      string    // Server private key file
+	ClientCAFile string    // CA certificate for verifying client certs
+	Policy       TLSPolicy // Allowed versions and cipher suites (zero = TLS 1.3 only)
 }
 
 // ClientTLSConfig holds client TLS configuration.
@@ -49,7 +50,9 @@ func LoadServerTLS(cfg *ServerTLSConfig) (credentials.TransportCredentials, erro
 		Certificates: []tls.Certificate{serverCert},
 		ClientAuth:   tls.RequireAndVerifyClientCert,
 		ClientCAs:    caPool,
-		MinVersion:   tls.VersionTLS13,
+	}
+	if err := cfg.Policy.Apply(tlsConfig); err != nil {
+		return nil, fmt.Errorf("tls policy: %w", err)
 	}
 
 	return credentials.NewTLS(tlsConfig), nil
@@ -0,0 +1,120 @@
+package security
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+)
+
+// TLS versions accepted by TLSPolicy.MinVersion.
+const (
+	TLSVersion12 = "1.2"
+	TLSVersion13 = "1.3"
+)
+
+// TLSPolicy restricts the protocol versions and cipher suites a TLS server
+// accepts. The zero value allows TLS 1.3 only.
+type TLSPolicy struct {
+	// MinVersion is the lowest accepted version, TLSVersion12 or
+	// TLSVersion13 (empty = TLSVersion13).
+	MinVersion string
+
+	// CipherSuites lists the allowed TLS 1.2 cipher suites by IANA name,
+	// e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256. Empty uses Go's secure
+	// defaults. Only forward-secret AEAD suites are accepted. TLS 1.3
+	// suites are always enabled and cannot be restricted.
+	CipherSuites []string
+}
+
+// Validate reports whether the policy is usable and secure.
+func (p TLSPolicy) Validate() error {
+	_, _, err := p.resolve()
+	return err
+}
+
+// Apply sets MinVersion and CipherSuites on cfg according to the policy.
+func (p TLSPolicy) Apply(cfg *tls.Config) error {
+	version, suites, err := p.resolve()
+	if err != nil {
+		return err
+	}
+	cfg.MinVersion = version
+	cfg.CipherSuites = suites
+	return nil
+}
+
+// resolve validates the policy and returns its version and suite IDs.
+func (p TLSPolicy) resolve() (uint16, []uint16, error) {
+	var version uint16
+	switch p.MinVersion {
+	case "", TLSVersion13:
+		version = tls.VersionTLS13
+	case TLSVersion12:
+		version = tls.VersionTLS12
+	case "1.0", "1.1":
+		return 0, nil, fmt.Errorf("min_version %s is insecure; use %s or %s", p.MinVersion, TLSVersion12, TLSVersion13)
+	default:
+		return 0, nil, fmt.Errorf("unknown min_version %q; use %s or %s", p.MinVersion, TLSVersion12, TLSVersion13)
+	}
+
+	if len(p.CipherSuites) == 0 {
+		return version, nil, nil
+	}
+	if version == tls.VersionTLS13 {
+		return 0, nil, fmt.Errorf("cipher_suites only apply to TLS 1.2; TLS 1.3 suites cannot be restricted, so set min_version %s or remove cipher_suites", TLSVersion12)
+	}
+
+	ids := make([]uint16, 0, len(p.CipherSuites))
+	http2Suite := false
+	for _, name := range p.CipherSuites {
+		suite, err := lookupCipherSuite(name)
+		if err != nil {
+			return 0, nil, err
+		}
+		if suite.ID == tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 || suite.ID == tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256 {
+			http2Suite = true
+		}
+		ids = append(ids, suite.ID)
+	}
+	// HTTP/2 (and so gRPC) fails the handshake without one of these
+	if !http2Suite {
+		return 0, nil, fmt.Errorf("cipher_suites must include TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 or TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, required by HTTP/2")
+	}
+	return version, ids, nil
+}
+
+// lookupCipherSuite returns the TLS 1.2 suite called name, rejecting
+// insecure suites and those without forward secrecy or AEAD encryption.
+func lookupCipherSuite(name string) (*tls.CipherSuite, error) {
+	for _, suite := range tls.InsecureCipherSuites() {
+		if suite.Name == name {
+			return nil, fmt.Errorf("cipher suite %s is insecure", name)
+		}
+	}
+	for _, suite := range tls.CipherSuites() {
+		if suite.Name != name {
+			continue
+		}
+		if !supportsTLS12(suite) {
+			return nil, fmt.Errorf("cipher suite %s is a TLS 1.3 suite; TLS 1.3 suites are always enabled", name)
+		}
+		if !strings.HasPrefix(name, "TLS_ECDHE_") {
+			return nil, fmt.Errorf("cipher suite %s is not forward secret; use an ECDHE suite", name)
+		}
+		if !strings.Contains(name, "_GCM_") && !strings.Contains(name, "_CHACHA20_POLY1305") {
+			return nil, fmt.Errorf("cipher suite %s is not AEAD; use a GCM or CHACHA20_POLY1305 suite", name)
+		}
+		return suite, nil
+	}
+	return nil, fmt.Errorf("unknown cipher suite %q", name)
+}
+
+// supportsTLS12 reports whether suite can be negotiated under TLS 1.2.
+func supportsTLS12(suite *tls.CipherSuite) bool {
+	for _, v := range suite.SupportedVersions {
+		if v == tls.VersionTLS12 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package security
+
+import (
+	"crypto/tls"
+	"strings"
+	"testing"
+)
+
+func TestTLSPolicy_Apply(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      TLSPolicy
+		wantVersion uint16
+		wantSuites  []uint16
+		wantErr     string
+	}{
+		{
+			name:        "zero value is TLS 1.3 only",
+			wantVersion: tls.VersionTLS13,
+		},
+		{
+			name:        "TLS 1.2 with default suites",
+			policy:      TLSPolicy{MinVersion: TLSVersion12},
+			wantVersion: tls.VersionTLS12,
+		},
+		{
+			name: "TLS 1.2 with explicit suites",
+			policy: TLSPolicy{
+				MinVersion:   TLSVersion12,
+				CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384", "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+			},
+			wantVersion: tls.VersionTLS12,
+			wantSuites:  []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384, tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+		},
+		{
+			name:    "TLS 1.1 rejected",
+			policy:  TLSPolicy{MinVersion: "1.1"},
+			wantErr: "insecure",
+		},
+		{
+			name:    "unknown version",
+			policy:  TLSPolicy{MinVersion: "1.4"},
+			wantErr: "unknown min_version",
+		},
+		{
+			name:    "suites with TLS 1.3",
+			policy:  TLSPolicy{CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}},
+			wantErr: "only apply to TLS 1.2",
+		},
+		{
+			name:    "insecure suite",
+			policy:  TLSPolicy{MinVersion: TLSVersion12, CipherSuites: []string{"TLS_ECDHE_RSA_WITH_RC4_128_SHA"}},
+			wantErr: "is insecure",
+		},
+		{
+			name:    "CBC suite",
+			policy:  TLSPolicy{MinVersion: TLSVersion12, CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA"}},
+			wantErr: "not AEAD",
+		},
+		{
+			name:    "TLS 1.3 suite",
+			policy:  TLSPolicy{MinVersion: TLSVersion12, CipherSuites: []string{"TLS_AES_128_GCM_SHA256"}},
+			wantErr: "TLS 1.3 suite",
+		},
+		{
+			name:    "unknown suite",
+			policy:  TLSPolicy{MinVersion: TLSVersion12, CipherSuites: []string{"TLS_MADE_UP"}},
+			wantErr: "unknown cipher suite",
+		},
+		{
+			name:    "missing HTTP/2 suite",
+			policy:  TLSPolicy{MinVersion: TLSVersion12, CipherSuites: []string{"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256"}},
+			wantErr: "required by HTTP/2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &tls.Config{}
+			err := tt.policy.Apply(cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Apply() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if cfg.MinVersion != tt.wantVersion {
+				t.Errorf("MinVersion = %x, want %x", cfg.MinVersion, tt.wantVersion)
+			}
+			if len(cfg.CipherSuites) != len(tt.wantSuites) {
+				t.Fatalf("CipherSuites = %v, want %v", cfg.CipherSuites, tt.wantSuites)
+			}
+			for i, id := range tt.wantSuites {
+				if cfg.CipherSuites[i] != id {
+					t.Errorf("CipherSuites[%d] = %x, want %x", i, cfg.CipherSuites[i], id)
+				}
+			}
+		})
+	}
+}
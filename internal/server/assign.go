@@ -0,0 +1,48 @@
+package server
+
+import "path"
+
+// ProjectRule assigns a project to records whose agent and labels match.
+// Empty criteria match anything; a rule with both set requires both.
+type ProjectRule struct {
+	Project string            // project ID to assign
+	AgentID string            // glob pattern (path.Match syntax) for the agent ID
+	Labels  map[string]string // labels that must all be present with these values
+}
+
+// ProjectAssignment sets a project on records from agents that did not
+// report one. Rules are evaluated in order and the first match wins;
+// Default applies when no rule matches. Records with a project are never
+// reassigned.
+type ProjectAssignment struct {
+	Rules   []ProjectRule
+	Default string // "" = leave unmatched records unassigned
+}
+
+// resolve returns the project for a record without one.
+func (a *ProjectAssignment) resolve(agentID string, labels map[string]string) string {
+	if a == nil {
+		return ""
+	}
+	for _, rule := range a.Rules {
+		if rule.matches(agentID, labels) {
+			return rule.Project
+		}
+	}
+	return a.Default
+}
+
+// matches reports whether the rule applies to a record.
+func (r ProjectRule) matches(agentID string, labels map[string]string) bool {
+	if r.AgentID != "" {
+		if ok, _ := path.Match(r.AgentID, agentID); !ok {
+			return false
+		}
+	}
+	for k, v := range r.Labels {
+		if got, ok := labels[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
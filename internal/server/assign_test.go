@@ -0,0 +1,74 @@
+package server
+
+import (
+	"testing"
+
+	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
+)
+
+func TestProjectAssignment_Resolve(t *testing.T) {
+	a := &ProjectAssignment{
+		Rules: []ProjectRule{
+			{Project: "shop", AgentID: "shop-*"},
+			{Project: "blog-prod", Labels: map[string]string{"app": "blog", "env": "prod"}},
+			{Project: "blog", Labels: map[string]string{"app": "blog"}},
+		},
+		Default: "unassigned-bucket",
+	}
+
+	tests := []struct {
+		name    string
+		agentID string
+		labels  map[string]string
+		want    string
+	}{
+		{"agent pattern", "shop-web-1", nil, "shop"},
+		{"all labels match", "host-1", map[string]string{"app": "blog", "env": "prod"}, "blog-prod"},
+		{"first match wins", "host-1", map[string]string{"app": "blog", "env": "dev"}, "blog"},
+		{"pattern before labels", "shop-1", map[string]string{"app": "blog"}, "shop"},
+		{"default", "host-1", map[string]string{"app": "crm"}, "unassigned-bucket"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := a.resolve(tt.agentID, tt.labels); got != tt.want {
+				t.Errorf("resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	var none *ProjectAssignment
+	if got := none.resolve("shop-1", nil); got != "" {
+		t.Errorf("nil assignment resolve() = %q, want empty", got)
+	}
+}
+
+func TestProcessor_ProjectAssignment(t *testing.T) {
+	processor := NewProcessor(false, nil)
+	processor.SetProjectAssignment(&ProjectAssignment{
+		Rules: []ProjectRule{{Project: "shop", Labels: map[string]string{"app": "shop"}}},
+	})
+
+	records := processor.convertToRecords(&blazelogv1.LogBatch{
+		AgentId: "agent-1",
+		Entries: []*blazelogv1.LogEntry{
+			{Message: "match", Labels: map[string]string{"app": "shop"}},
+			{Message: "no match"},
+		},
+	})
+	if records[0].ProjectID != "shop" {
+		t.Errorf("matched record project = %q, want shop", records[0].ProjectID)
+	}
+	if records[1].ProjectID != "" {
+		t.Errorf("unmatched record project = %q, want empty without default", records[1].ProjectID)
+	}
+
+	// An agent-reported project is never overridden
+	records = processor.convertToRecords(&blazelogv1.LogBatch{
+		ProjectId: "reported",
+		Entries:   []*blazelogv1.LogEntry{{Message: "x", Labels: map[string]string{"app": "shop"}}},
+	})
+	if records[0].ProjectID != "reported" {
+		t.Errorf("project = %q, want agent-reported project", records[0].ProjectID)
+	}
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+// Dedup defaults.
+const (
+	defaultDedupWindow     = time.Minute
+	defaultDedupMaxEntries = 100000
+)
+
+// DedupConfig configures ingest-side content-hash deduplication.
+type DedupConfig struct {
+	Window     time.Duration // How long a content hash is remembered
+	MaxEntries int           // Max remembered hashes; oldest are forgotten first
+}
+
+type dedupKey [sha256.Size]byte
+
+type dedupItem struct {
+	key    dedupKey
+	seenAt time.Time
+}
+
+// Deduplicator remembers recently seen record hashes in a bounded,
+// time-windowed set. It does not guarantee exactly-once delivery: once a
+// hash ages out of the window or is evicted by the size cap, a repeat of
+// the same record is accepted again.
+type Deduplicator struct {
+	mu         sync.Mutex
+	window     time.Duration
+	maxEntries int
+	seen       map[dedupKey]time.Time
+	order      []dedupItem // insertion order, oldest first
+}
+
+// NewDeduplicator creates a deduplicator. Zero values use defaults.
+func NewDeduplicator(window time.Duration, maxEntries int) *Deduplicator {
+	if window <= 0 {
+		window = defaultDedupWindow
+	}
+	if maxEntries <= 0 {
+		maxEntries = defaultDedupMaxEntries
+	}
+	return &Deduplicator{
+		window:     window,
+		maxEntries: maxEntries,
+		seen:       make(map[dedupKey]time.Time),
+	}
+}
+
+// Seen reports whether a record with the same content was seen within the
+// window, and remembers it otherwise.
+func (d *Deduplicator) Seen(agentID string, ts time.Time, message string, lineNumber int64, now time.Time) bool {
+	key := contentHash(agentID, ts, message, lineNumber)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.pruneLocked(now)
+
+	if seenAt, ok := d.seen[key]; ok && now.Sub(seenAt) < d.window {
+		return true
+	}
+
+	d.seen[key] = now
+	d.order = append(d.order, dedupItem{key: key, seenAt: now})
+
+	// Enforce size cap by forgetting the oldest hashes
+	for len(d.seen) > d.maxEntries && len(d.order) > 0 {
+		d.forgetOldestLocked()
+	}
+
+	return false
+}
+
+// Len returns the number of remembered hashes.
+func (d *Deduplicator) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.seen)
+}
+
+// pruneLocked forgets hashes older than the window.
+// Must be called with lock held.
+func (d *Deduplicator) pruneLocked(now time.Time) {
+	cutoff := now.Add(-d.window)
+	for len(d.order) > 0 && !d.order[0].seenAt.After(cutoff) {
+		d.forgetOldestLocked()
+	}
+	// Reclaim the backing array once it is mostly consumed
+	if cap(d.order) > 2*d.maxEntries && len(d.order) < cap(d.order)/4 {
+		d.order = append([]dedupItem(nil), d.order...)
+	}
+}
+
+// forgetOldestLocked drops the oldest remembered hash.
+// Must be called with lock held.
+func (d *Deduplicator) forgetOldestLocked() {
+	item := d.order[0]
+	d.order = d.order[1:]
+	// Only delete if the map entry wasn't refreshed by a later insert
+	if seenAt, ok := d.seen[item.key]; ok && seenAt.Equal(item.seenAt) {
+		delete(d.seen, item.key)
+	}
+}
+
+// contentHash hashes the identifying content of a record.
+func contentHash(agentID string, ts time.Time, message string, lineNumber int64) dedupKey {
+	h := sha256.New()
+	var buf [8]byte
+
+	h.Write([]byte(agentID))
+	h.Write([]byte{0})
+	binary.BigEndian.PutUint64(buf[:], uint64(ts.UnixNano()))
+	h.Write(buf[:])
+	binary.BigEndian.PutUint64(buf[:], uint64(lineNumber))
+	h.Write(buf[:])
+	h.Write([]byte(message))
+
+	var key dedupKey
+	copy(key[:], h.Sum(nil))
+	return key
+}
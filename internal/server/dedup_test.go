@@ -0,0 +1,132 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestDeduplicator_Seen(t *testing.T) {
+	d := NewDeduplicator(time.Minute, 100)
+	now := time.Now()
+	ts := now.Add(-time.Second)
+
+	if d.Seen("agent-1", ts, "hello", 1, now) {
+		t.Fatal("first occurrence should not be a duplicate")
+	}
+	if !d.Seen("agent-1", ts, "hello", 1, now.Add(time.Second)) {
+		t.Error("repeat within window should be a duplicate")
+	}
+
+	// Any differing component makes the record distinct
+	if d.Seen("agent-2", ts, "hello", 1, now) {
+		t.Error("different agent should not be a duplicate")
+	}
+	if d.Seen("agent-1", ts, "hello", 2, now) {
+		t.Error("different line number should not be a duplicate")
+	}
+	if d.Seen("agent-1", ts.Add(time.Nanosecond), "hello", 1, now) {
+		t.Error("different timestamp should not be a duplicate")
+	}
+	if d.Seen("agent-1", ts, "hello!", 1, now) {
+		t.Error("different message should not be a duplicate")
+	}
+}
+
+func TestDeduplicator_WindowExpiry(t *testing.T) {
+	d := NewDeduplicator(time.Minute, 100)
+	now := time.Now()
+
+	d.Seen("agent-1", now, "hello", 1, now)
+	if d.Seen("agent-1", now, "hello", 1, now.Add(2*time.Minute)) {
+		t.Error("repeat after window should not be a duplicate")
+	}
+	if d.Len() != 1 {
+		t.Errorf("len = %d, want 1", d.Len())
+	}
+}
+
+func TestDeduplicator_MaxEntries(t *testing.T) {
+	d := NewDeduplicator(time.Hour, 3)
+	now := time.Now()
+
+	for i := int64(0); i < 10; i++ {
+		d.Seen("agent-1", now, "line", i, now)
+	}
+	if d.Len() != 3 {
+		t.Errorf("len = %d, want 3", d.Len())
+	}
+
+	// Oldest hashes are forgotten first
+	if !d.Seen("agent-1", now, "line", 9, now) {
+		t.Error("most recent hash should still be remembered")
+	}
+	if d.Seen("agent-1", now, "line", 0, now) {
+		t.Error("oldest hash should have been evicted")
+	}
+}
+
+type recordingLogBuffer struct {
+	records []*LogRecord
+}
+
+func (b *recordingLogBuffer) AddBatch(entries []*LogRecord) error {
+	b.records = append(b.records, entries...)
+	return nil
+}
+
+func (b *recordingLogBuffer) Close() error { return nil }
+
+func TestProcessor_Dedup(t *testing.T) {
+	buf := &recordingLogBuffer{}
+	processor := NewProcessor(false, buf)
+	processor.SetDeduplicator(NewDeduplicator(time.Minute, 100))
+
+	ts := timestamppb.Now()
+	batch := &blazelogv1.LogBatch{
+		AgentId: "agent-1",
+		Entries: []*blazelogv1.LogEntry{
+			{Timestamp: ts, Message: "dup", LineNumber: 7},
+			{Timestamp: ts, Message: "dup", LineNumber: 7},
+			{Timestamp: ts, Message: "other", LineNumber: 8},
+		},
+	}
+
+	if err := processor.ProcessBatch(batch); err != nil {
+		t.Fatalf("ProcessBatch: %v", err)
+	}
+	if len(buf.records) != 2 {
+		t.Fatalf("stored %d records, want 2", len(buf.records))
+	}
+
+	// Re-delivery of the whole batch is fully suppressed
+	if err := processor.ProcessBatch(batch); err != nil {
+		t.Fatalf("ProcessBatch: %v", err)
+	}
+	if len(buf.records) != 2 {
+		t.Errorf("stored %d records after re-delivery, want 2", len(buf.records))
+	}
+}
+
+func TestProcessor_NoDedupByDefault(t *testing.T) {
+	buf := &recordingLogBuffer{}
+	processor := NewProcessor(false, buf)
+
+	ts := timestamppb.Now()
+	batch := &blazelogv1.LogBatch{
+		AgentId: "agent-1",
+		Entries: []*blazelogv1.LogEntry{
+			{Timestamp: ts, Message: "dup", LineNumber: 7},
+			{Timestamp: ts, Message: "dup", LineNumber: 7},
+		},
+	}
+
+	if err := processor.ProcessBatch(batch); err != nil {
+		t.Fatalf("ProcessBatch: %v", err)
+	}
+	if len(buf.records) != 2 {
+		t.Errorf("stored %d records, want 2", len(buf.records))
+	}
+}
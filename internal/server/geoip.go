@@ -0,0 +1,283 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/good-yellow-bee/blazelog/internal/metrics"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// DefaultGeoIPField is the record field holding the client IP.
+const DefaultGeoIPField = "client_ip"
+
+// Fields added by GeoIP enrichment.
+const (
+	fieldGeoCountry = "geo_country"
+	fieldGeoCity    = "geo_city"
+	fieldGeoASN     = "geo_asn"
+)
+
+// defaultGeoIPReloadDelay is how long a database file must be quiet after a
+// change before it is reloaded, so a file still being written isn't opened.
+const defaultGeoIPReloadDelay = time.Second
+
+// GeoIPConfig configures ingest-side GeoIP enrichment from MaxMind mmdb
+// databases.
+type GeoIPConfig struct {
+	Database    string // City or Country database
+	ASNDatabase string // Optional ASN database
+	IPField     string // Field holding the client IP (default: DefaultGeoIPField)
+}
+
+// geoRecord is the part of a City, Country or ASN database record used for
+// enrichment.
+type geoRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	ASN uint `maxminddb:"autonomous_system_number"`
+}
+
+// geoDB looks up IPs in one database.
+type geoDB interface {
+	lookup(ip net.IP) (geoRecord, bool, error)
+	Close() error
+}
+
+// mmdb is a geoDB backed by a MaxMind database file.
+type mmdb struct {
+	r *maxminddb.Reader
+}
+
+func openMMDB(path string) (geoDB, error) {
+	r, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &mmdb{r: r}, nil
+}
+
+func (m *mmdb) lookup(ip net.IP) (geoRecord, bool, error) {
+	var rec geoRecord
+	_, ok, err := m.r.LookupNetwork(ip, &rec)
+	return rec, ok, err
+}
+
+func (m *mmdb) Close() error {
+	return m.r.Close()
+}
+
+// GeoIP adds geo_country, geo_city and geo_asn fields to records whose IP
+// field holds a public IP found in the databases. Records without the
+// field, or with a private, loopback or unparsable IP, are left alone, as
+// are geo fields the record already has.
+type GeoIP struct {
+	cfg         GeoIPConfig
+	open        func(path string) (geoDB, error)
+	reloadDelay time.Duration
+
+	mu   sync.RWMutex
+	city geoDB
+	asn  geoDB // nil without an ASN database
+}
+
+// NewGeoIP opens the configured databases.
+func NewGeoIP(cfg GeoIPConfig) (*GeoIP, error) {
+	return newGeoIP(cfg, openMMDB)
+}
+
+func newGeoIP(cfg GeoIPConfig, open func(string) (geoDB, error)) (*GeoIP, error) {
+	if cfg.IPField == "" {
+		cfg.IPField = DefaultGeoIPField
+	}
+	g := &GeoIP{cfg: cfg, open: open, reloadDelay: defaultGeoIPReloadDelay}
+	if err := g.load(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// load opens the databases and swaps them in, closing the previous ones.
+// On error the previous databases stay in use.
+func (g *GeoIP) load() error {
+	city, err := g.open(g.cfg.Database)
+	if err != nil {
+		return fmt.Errorf("open geoip database %s: %w", g.cfg.Database, err)
+	}
+	var asn geoDB
+	if g.cfg.ASNDatabase != "" {
+		if asn, err = g.open(g.cfg.ASNDatabase); err != nil {
+			city.Close()
+			return fmt.Errorf("open geoip asn database %s: %w", g.cfg.ASNDatabase, err)
+		}
+	}
+
+	g.mu.Lock()
+	oldCity, oldASN := g.city, g.asn
+	g.city, g.asn = city, asn
+	g.mu.Unlock()
+
+	// Lookups in flight hold the read lock, so nothing uses these anymore
+	if oldCity != nil {
+		oldCity.Close()
+	}
+	if oldASN != nil {
+		oldASN.Close()
+	}
+	return nil
+}
+
+// apply enriches a record's fields in place.
+func (g *GeoIP) apply(fields map[string]interface{}) {
+	if g == nil || fields == nil {
+		return
+	}
+	value, ok := fields[g.cfg.IPField].(string)
+	if !ok || value == "" {
+		return
+	}
+	ip := parseClientIP(value)
+	if ip == nil {
+		metrics.IngestGeoIPLookupsTotal.WithLabelValues("invalid").Inc()
+		return
+	}
+	if !isPublicIP(ip) {
+		metrics.IngestGeoIPLookupsTotal.WithLabelValues("private").Inc()
+		return
+	}
+
+	g.mu.RLock()
+	rec, found, err := g.city.lookup(ip)
+	if err == nil && g.asn != nil {
+		var asnRec geoRecord
+		var asnFound bool
+		if asnRec, asnFound, err = g.asn.lookup(ip); asnFound {
+			rec.ASN, found = asnRec.ASN, true
+		}
+	}
+	g.mu.RUnlock()
+
+	if err != nil {
+		metrics.IngestGeoIPLookupsTotal.WithLabelValues("error").Inc()
+		slog.Debug("geoip lookup failed", "ip", value, "error", err)
+		return
+	}
+	if !found {
+		metrics.IngestGeoIPLookupsTotal.WithLabelValues("not_found").Inc()
+		return
+	}
+	metrics.IngestGeoIPLookupsTotal.WithLabelValues("found").Inc()
+
+	setIfAbsent(fields, fieldGeoCountry, rec.Country.ISOCode)
+	setIfAbsent(fields, fieldGeoCity, rec.City.Names["en"])
+	if rec.ASN != 0 {
+		if _, exists := fields[fieldGeoASN]; !exists {
+			fields[fieldGeoASN] = rec.ASN
+		}
+	}
+}
+
+// setIfAbsent sets a non-empty string field the record doesn't have yet.
+func setIfAbsent(fields map[string]interface{}, key, value string) {
+	if value == "" {
+		return
+	}
+	if _, exists := fields[key]; !exists {
+		fields[key] = value
+	}
+}
+
+// parseClientIP parses an IP, with or without a port.
+func parseClientIP(s string) net.IP {
+	if ip := net.ParseIP(s); ip != nil {
+		return ip
+	}
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		return net.ParseIP(host)
+	}
+	return nil
+}
+
+// isPublicIP reports whether ip can be in a GeoIP database.
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+// Watch reloads the databases when their files change, until ctx is done.
+// The directories are watched, so files replaced by rename (as geoipupdate
+// does) are picked up too. A failed reload keeps the loaded databases.
+func (g *GeoIP) Watch(ctx context.Context) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create geoip watcher: %w", err)
+	}
+	defer w.Close()
+
+	watched := map[string]bool{filepath.Clean(g.cfg.Database): true}
+	if g.cfg.ASNDatabase != "" {
+		watched[filepath.Clean(g.cfg.ASNDatabase)] = true
+	}
+	dirs := make(map[string]bool)
+	for path := range watched {
+		dirs[filepath.Dir(path)] = true
+	}
+	for dir := range dirs {
+		if err := w.Add(dir); err != nil {
+			return fmt.Errorf("watch %s: %w", dir, err)
+		}
+	}
+
+	var reload <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if watched[filepath.Clean(ev.Name)] && (ev.Has(fsnotify.Write) || ev.Has(fsnotify.Create)) {
+				reload = time.After(g.reloadDelay)
+			}
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Warn("geoip watcher error", "error", err)
+		case <-reload:
+			reload = nil
+			if err := g.load(); err != nil {
+				slog.Warn("geoip reload failed, keeping the loaded database", "error", err)
+				continue
+			}
+			slog.Info("geoip database reloaded", "database", g.cfg.Database, "asn_database", g.cfg.ASNDatabase)
+		}
+	}
+}
+
+// Close closes the databases.
+func (g *GeoIP) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var err error
+	if g.city != nil {
+		err = g.city.Close()
+	}
+	if g.asn != nil {
+		if aerr := g.asn.Close(); err == nil {
+			err = aerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeGeoDB maps IPs to records.
+type fakeGeoDB struct {
+	records map[string]geoRecord
+	closed  atomic.Bool
+}
+
+func (f *fakeGeoDB) lookup(ip net.IP) (geoRecord, bool, error) {
+	rec, ok := f.records[ip.String()]
+	return rec, ok, nil
+}
+
+func (f *fakeGeoDB) Close() error {
+	f.closed.Store(true)
+	return nil
+}
+
+func cityRecord(country, city string) geoRecord {
+	var rec geoRecord
+	rec.Country.ISOCode = country
+	rec.City.Names = map[string]string{"en": city}
+	return rec
+}
+
+func TestGeoIPApply(t *testing.T) {
+	city := &fakeGeoDB{records: map[string]geoRecord{
+		"8.8.8.8":     cityRecord("US", "Mountain View"),
+		"2001:db8::1": cityRecord("DE", ""),
+	}}
+	asn := &fakeGeoDB{records: map[string]geoRecord{"8.8.8.8": {ASN: 15169}, "1.1.1.1": {ASN: 13335}}}
+	g, err := newGeoIP(GeoIPConfig{Database: "city.mmdb", ASNDatabase: "asn.mmdb"}, func(path string) (geoDB, error) {
+		if path == "asn.mmdb" {
+			return asn, nil
+		}
+		return city, nil
+	})
+	if err != nil {
+		t.Fatalf("newGeoIP() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		fields map[string]interface{}
+		want   map[string]interface{}
+	}{
+		{"city and asn", map[string]interface{}{"client_ip": "8.8.8.8"},
+			map[string]interface{}{"geo_country": "US", "geo_city": "Mountain View", "geo_asn": uint(15169)}},
+		{"with port", map[string]interface{}{"client_ip": "8.8.8.8:51234"},
+			map[string]interface{}{"geo_country": "US", "geo_city": "Mountain View", "geo_asn": uint(15169)}},
+		{"ipv6 country only", map[string]interface{}{"client_ip": "2001:db8::1"},
+			map[string]interface{}{"geo_country": "DE"}},
+		{"asn only", map[string]interface{}{"client_ip": "1.1.1.1"},
+			map[string]interface{}{"geo_asn": uint(13335)}},
+		{"not found", map[string]interface{}{"client_ip": "9.9.9.9"}, nil},
+		{"private", map[string]interface{}{"client_ip": "10.1.2.3"}, nil},
+		{"loopback", map[string]interface{}{"client_ip": "127.0.0.1"}, nil},
+		{"invalid", map[string]interface{}{"client_ip": "unknown"}, nil},
+		{"missing field", map[string]interface{}{"remote_addr": "8.8.8.8"}, nil},
+		{"existing geo field kept", map[string]interface{}{"client_ip": "8.8.8.8", "geo_country": "XX"},
+			map[string]interface{}{"geo_country": "XX", "geo_city": "Mountain View", "geo_asn": uint(15169)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g.apply(tt.fields)
+			for _, key := range []string{fieldGeoCountry, fieldGeoCity, fieldGeoASN} {
+				if got, want := tt.fields[key], tt.want[key]; got != want {
+					t.Errorf("%s = %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+
+	var nilGeo *GeoIP
+	nilGeo.apply(map[string]interface{}{"client_ip": "8.8.8.8"}) // must not panic
+}
+
+func TestGeoIPWatchReloads(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "GeoLite2-City.mmdb")
+	if err := os.WriteFile(path, []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var opened []*fakeGeoDB
+	failNext := false
+	open := func(p string) (geoDB, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failNext {
+			failNext = false
+			return nil, errors.New("truncated database")
+		}
+		data, _ := os.ReadFile(p)
+		db := &fakeGeoDB{records: map[string]geoRecord{"8.8.8.8": cityRecord(string(data), "")}}
+		opened = append(opened, db)
+		return db, nil
+	}
+	g, err := newGeoIP(GeoIPConfig{Database: path}, open)
+	if err != nil {
+		t.Fatalf("newGeoIP() error = %v", err)
+	}
+	g.reloadDelay = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go g.Watch(ctx)
+	time.Sleep(50 * time.Millisecond) // let the watcher start
+
+	country := func() interface{} {
+		fields := map[string]interface{}{"client_ip": "8.8.8.8"}
+		g.apply(fields)
+		return fields[fieldGeoCountry]
+	}
+	waitFor := func(want string) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for country() != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("country = %v, want %s", country(), want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// Replaced by rename, as geoipupdate does
+	tmp := filepath.Join(dir, "GeoLite2-City.mmdb.tmp")
+	if err := os.WriteFile(tmp, []byte("v2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	waitFor("v2")
+
+	mu.Lock()
+	if !opened[0].closed.Load() {
+		t.Error("previous database should be closed after reload")
+	}
+	failNext = true
+	mu.Unlock()
+
+	// A failed reload keeps the loaded database
+	if err := os.WriteFile(path, []byte("v3"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if got := country(); got != "v2" {
+		t.Errorf("country after failed reload = %v, want v2", got)
+	}
+
+	// Unrelated files in the directory are ignored
+	mu.Lock()
+	n := len(opened)
+	mu.Unlock()
+	if err := os.WriteFile(filepath.Join(dir, "other.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	mu.Lock()
+	if len(opened) != n {
+		t.Errorf("opened %d databases after an unrelated write, want %d", len(opened), n)
+	}
+	mu.Unlock()
+}
+
+func TestNewGeoIPOpenError(t *testing.T) {
+	_, err := NewGeoIP(GeoIPConfig{Database: filepath.Join(t.TempDir(), "missing.mmdb")})
+	if err == nil {
+		t.Fatal("NewGeoIP() should fail for a missing database")
+	}
+}
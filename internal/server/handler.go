@@ -4,17 +4,21 @@ import (
 	"context"
 	"errors"
 	"io"
-	"log"
+	"log/slog"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/good-yellow-bee/blazelog/internal/encoding"
+	"github.com/good-yellow-bee/blazelog/internal/encoding/zstd"
 	"github.com/good-yellow-bee/blazelog/internal/metrics"
 	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
 	"github.com/google/uuid"
 	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
@@ -46,6 +50,12 @@ type Handler struct {
 
 	// Rate limiters
 	registerLimiter *rate.Limiter // 10/sec with burst of 50
+	quotas          *AgentQuotas  // per-agent lines/sec; nil = unlimited
+
+	// Server-to-agent commands, delivered on heartbeat
+	cmdMu    sync.Mutex
+	commands map[string][]*blazelogv1.ServerCommand // agent_id -> pending commands
+	rawTails map[string]*rawTailSession             // request_id -> running raw tail
 
 	// Cleanup
 	agentTTL time.Duration
@@ -61,6 +71,8 @@ func NewHandler(processor *Processor, verbose bool) *Handler {
 		registerLimiter: rate.NewLimiter(10, 50), // 10/sec with burst of 50
 		agentTTL:        30 * time.Minute,        // Agents inactive for 30 min are removed
 		stopCh:          make(chan struct{}),
+		commands:        make(map[string][]*blazelogv1.ServerCommand),
+		rawTails:        make(map[string]*rawTailSession),
 	}
 	go h.cleanupLoop()
 	return h
@@ -81,10 +93,20 @@ func (h *Handler) cleanupLoop() {
 	}
 }
 
+// SetQuotas caps the lines per second each agent may stream; batches over
+// the quota end the stream with RESOURCE_EXHAUSTED. Nil disables quotas.
+// Must be called before serving.
+func (h *Handler) SetQuotas(q *AgentQuotas) {
+	h.quotas = q
+}
+
 // cleanupInactiveAgents removes agents that haven't been active recently.
 func (h *Handler) cleanupInactiveAgents() {
 	cutoff := time.Now().Add(-h.agentTTL)
 	removed := 0
+	if h.quotas != nil {
+		h.quotas.prune(cutoff)
+	}
 
 	h.agents.Range(func(key, value any) bool {
 		entry := value.(*agentEntry)
@@ -97,7 +119,7 @@ func (h *Handler) cleanupInactiveAgents() {
 	})
 
 	if removed > 0 && h.verbose {
-		log.Printf("cleaned up %d inactive agents", removed)
+		slog.Info("cleaned up inactive agents", "count", removed)
 	}
 }
 
@@ -160,20 +182,24 @@ func (h *Handler) Register(ctx context.Context, req *blazelogv1.RegisterRequest)
 
 	projectID := agent.ProjectId
 	if projectID != "" {
-		log.Printf("agent registered: id=%s name=%s hostname=%s project=%s sources=%d",
-			agentID, agent.Name, agent.Hostname, projectID, len(agent.Sources))
+		slog.Info("agent registered", "id", agentID, "name", agent.Name, "hostname", agent.Hostname,
+			"project", projectID, "sources", len(agent.Sources))
 	} else {
-		log.Printf("agent registered: id=%s name=%s hostname=%s sources=%d (no project)",
-			agentID, agent.Name, agent.Hostname, len(agent.Sources))
+		slog.Info("agent registered without project", "id", agentID, "name", agent.Name, "hostname", agent.Hostname,
+			"sources", len(agent.Sources))
 	}
 
+	// Advertise the stream compressors agents may pick; fails only when
+	// called outside a gRPC request, as in handler tests
+	_ = grpc.SetHeader(ctx, metadata.Pairs(encoding.CompressorsHeader, encoding.JoinCompressors(gzip.Name, zstd.Name)))
+
 	return &blazelogv1.RegisterResponse{
 		Success: true,
 		AgentId: agentID,
 		Config: &blazelogv1.StreamConfig{
 			MaxBatchSize:       100,
 			FlushIntervalMs:    1000,
-			CompressionEnabled: false,
+			CompressionEnabled: true, // gzip for agents that predate the compressors header
 		},
 	}, nil
 }
@@ -199,7 +225,7 @@ func (h *Handler) StreamLogs(stream grpc.BidiStreamingServer[blazelogv1.LogBatch
 	}()
 
 	if h.verbose {
-		log.Printf("stream started, active streams: %d", atomic.LoadInt32(&h.activeStreams))
+		slog.Debug("stream started", "active_streams", atomic.LoadInt32(&h.activeStreams))
 	}
 
 	// Idle timeout timer
@@ -234,7 +260,7 @@ func (h *Handler) StreamLogs(stream grpc.BidiStreamingServer[blazelogv1.LogBatch
 		case err := <-errCh:
 			if errors.Is(err, io.EOF) {
 				if h.verbose {
-					log.Printf("stream closed by client")
+					slog.Debug("stream closed by client")
 				}
 				return nil
 			}
@@ -250,14 +276,29 @@ func (h *Handler) StreamLogs(stream grpc.BidiStreamingServer[blazelogv1.LogBatch
 			}
 			idleTimer.Reset(streamIdleTimeout)
 
+			// Raw tail chunks go to the waiting operator, not to storage
+			if batch.RawTail != nil {
+				h.deliverRawTail(batch.AgentId, batch.RawTail)
+				continue
+			}
+
 			// Validate batch size
 			if len(batch.Entries) > maxBatchSize {
 				return status.Errorf(codes.InvalidArgument, "batch size %d exceeds maximum %d", len(batch.Entries), maxBatchSize)
 			}
 
+			// Enforce the agent's quota; the unacked batch is re-sent by
+			// agents tracking acks once they reconnect
+			if h.quotas != nil {
+				agentID := quotaAgentID(stream.Context(), batch.AgentId)
+				if !h.quotas.Allow(agentID, len(batch.Entries), time.Now()) {
+					return status.Errorf(codes.ResourceExhausted, "agent %s exceeded its log line quota", agentID)
+				}
+			}
+
 			// Process the batch
 			if err := h.processor.ProcessBatch(batch); err != nil {
-				log.Printf("process batch error: %v", err)
+				slog.Error("process batch", "error", err)
 				metrics.GRPCBatchProcessErrors.Inc()
 				// Send error response but continue
 				if sendErr := stream.Send(&blazelogv1.StreamResponse{
@@ -298,15 +339,16 @@ func (h *Handler) Heartbeat(ctx context.Context, req *blazelogv1.HeartbeatReques
 	if h.verbose {
 		reqStatus := req.Status
 		if reqStatus != nil {
-			log.Printf("heartbeat from %s: processed=%d buffer=%d sources=%d",
-				req.AgentId, reqStatus.EntriesProcessed, reqStatus.BufferSize, reqStatus.ActiveSources)
+			slog.Debug("heartbeat", "agent", req.AgentId, "processed", reqStatus.EntriesProcessed,
+				"buffer", reqStatus.BufferSize, "sources", reqStatus.ActiveSources)
 		} else {
-			log.Printf("heartbeat from %s", req.AgentId)
+			slog.Debug("heartbeat", "agent", req.AgentId)
 		}
 	}
 
 	return &blazelogv1.HeartbeatResponse{
 		Acknowledged: true,
+		Command:      h.nextCommand(req.AgentId),
 	}, nil
 }
 
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"sort"
+
+	"github.com/good-yellow-bee/blazelog/internal/metrics"
+)
+
+// FieldsTruncatedKey is the field set on records whose fields or labels
+// were dropped by the per-record caps.
+const FieldsTruncatedKey = "fields_truncated"
+
+// capFields returns fields reduced to at most maxFields keys.
+// Keys are kept in lexicographic order so the same key set always yields the
+// same subset. maxFields <= 0 disables the cap.
+func capFields(fields map[string]interface{}, maxFields int) (map[string]interface{}, bool) {
+	if maxFields <= 0 || len(fields) <= maxFields {
+		return fields, false
+	}
+	kept := make(map[string]interface{}, maxFields+1)
+	for _, k := range firstSortedKeys(fields, maxFields) {
+		kept[k] = fields[k]
+	}
+	return kept, true
+}
+
+// capLabels returns labels reduced to at most maxLabels keys, using the same
+// lexicographic selection as capFields. The input map is never modified.
+func capLabels(labels map[string]string, maxLabels int) (map[string]string, bool) {
+	if maxLabels <= 0 || len(labels) <= maxLabels {
+		return labels, false
+	}
+	kept := make(map[string]string, maxLabels)
+	for _, k := range firstSortedKeys(labels, maxLabels) {
+		kept[k] = labels[k]
+	}
+	return kept, true
+}
+
+// firstSortedKeys returns the n lexicographically smallest keys of m.
+func firstSortedKeys[V any](m map[string]V, n int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys[:n]
+}
+
+// applyFieldLimits enforces the processor's field and label caps on a record.
+func (p *Processor) applyFieldLimits(record *LogRecord) {
+	var fieldsCut, labelsCut bool
+	record.Fields, fieldsCut = capFields(record.Fields, p.maxFields)
+	record.Labels, labelsCut = capLabels(record.Labels, p.maxLabels)
+
+	if fieldsCut {
+		metrics.IngestFieldsTruncatedTotal.WithLabelValues("fields").Inc()
+	}
+	if labelsCut {
+		metrics.IngestFieldsTruncatedTotal.WithLabelValues("labels").Inc()
+	}
+	if fieldsCut || labelsCut {
+		if record.Fields == nil {
+			record.Fields = make(map[string]interface{}, 1)
+		}
+		record.Fields[FieldsTruncatedKey] = true
+	}
+}
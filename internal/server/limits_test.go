@@ -0,0 +1,106 @@
+package server
+
+import (
+	"testing"
+
+	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestCapFields(t *testing.T) {
+	fields := map[string]interface{}{"d": 4, "b": 2, "a": 1, "c": 3}
+
+	kept, truncated := capFields(fields, 2)
+	if !truncated {
+		t.Fatal("expected truncation")
+	}
+	if len(kept) != 2 || kept["a"] != 1 || kept["b"] != 2 {
+		t.Errorf("kept = %v, want lexicographically first keys a, b", kept)
+	}
+	if len(fields) != 4 {
+		t.Error("input map should not be modified")
+	}
+
+	if _, truncated := capFields(fields, 0); truncated {
+		t.Error("zero cap should disable truncation")
+	}
+	if _, truncated := capFields(fields, 4); truncated {
+		t.Error("map at cap should not be truncated")
+	}
+}
+
+func TestCapLabels(t *testing.T) {
+	labels := map[string]string{"env": "prod", "app": "shop", "zone": "eu"}
+
+	kept, truncated := capLabels(labels, 1)
+	if !truncated {
+		t.Fatal("expected truncation")
+	}
+	if len(kept) != 1 || kept["app"] != "shop" {
+		t.Errorf("kept = %v, want app only", kept)
+	}
+	if len(labels) != 3 {
+		t.Error("input map should not be modified")
+	}
+}
+
+func TestProcessor_FieldLimits(t *testing.T) {
+	fields, err := structpb.NewStruct(map[string]interface{}{
+		"status":  500,
+		"method":  "GET",
+		"zz_key1": "x",
+		"zz_key2": "y",
+	})
+	if err != nil {
+		t.Fatalf("new struct: %v", err)
+	}
+
+	processor := NewProcessor(false, nil)
+	processor.SetFieldLimits(2, 1)
+
+	records := processor.convertToRecords(&blazelogv1.LogBatch{
+		AgentId: "agent-1",
+		Entries: []*blazelogv1.LogEntry{{
+			Timestamp: timestamppb.Now(),
+			Message:   "boom",
+			Fields:    fields,
+			Labels:    map[string]string{"b": "2", "a": "1"},
+		}},
+	})
+	if len(records) != 1 {
+		t.Fatalf("records = %d, want 1", len(records))
+	}
+	rec := records[0]
+
+	if rec.Fields[FieldsTruncatedKey] != true {
+		t.Error("expected fields_truncated flag")
+	}
+	if _, ok := rec.Fields["zz_key1"]; ok {
+		t.Error("excess field should be dropped")
+	}
+	// Denormalized columns are extracted before the cap
+	if rec.HTTPStatus != 500 || rec.HTTPMethod != "GET" {
+		t.Errorf("http status/method = %d/%q, want 500/GET", rec.HTTPStatus, rec.HTTPMethod)
+	}
+	if len(rec.Labels) != 1 || rec.Labels["a"] != "1" {
+		t.Errorf("labels = %v, want a only", rec.Labels)
+	}
+}
+
+func TestProcessor_NoFieldLimitsByDefault(t *testing.T) {
+	processor := NewProcessor(false, nil)
+
+	records := processor.convertToRecords(&blazelogv1.LogBatch{
+		Entries: []*blazelogv1.LogEntry{{
+			Message: "ok",
+			Labels:  map[string]string{"a": "1", "b": "2"},
+		}},
+	})
+	if len(records[0].Labels) != 2 {
+		t.Errorf("labels = %v, want both kept", records[0].Labels)
+	}
+	if _, ok := records[0].Fields[FieldsTruncatedKey]; ok {
+		t.Error("unexpected fields_truncated flag")
+	}
+}
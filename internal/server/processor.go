@@ -2,12 +2,14 @@
 package server
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/good-yellow-bee/blazelog/internal/metrics"
 	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
 )
 
@@ -31,7 +33,16 @@ const (
 // Processor handles log processing and output.
 type Processor struct {
 	verbose   bool
-	logBuffer LogBuffer // nil if ClickHouse disabled
+	logBuffer LogBuffer      // nil if ClickHouse disabled
+	dedup     *Deduplicator  // nil if deduplication disabled
+	maxFields int            // 0 = unlimited
+	maxLabels int            // 0 = unlimited
+	renames   FieldRenames   // nil = no field renaming
+	idFields  RecordIDFields // nil = every record gets a random ID
+
+	projects  *ProjectAssignment // nil = no project assignment
+	validator *Validator         // nil = records are not validated
+	geoIP     *GeoIP             // nil = no GeoIP enrichment
 }
 
 // NewProcessor creates a new log processor.
@@ -50,17 +61,23 @@ func NewProcessor(verbose bool, logBuffer LogBuffer) *Processor {
 // project IDs will simply result in logs that are orphaned until the project is
 // created, or filtered out by project-scoped queries.
 func (p *Processor) ProcessBatch(batch *blazelogv1.LogBatch) error {
-	// Console output
-	for _, entry := range batch.Entries {
-		output := p.formatEntry(entry, batch.AgentId)
-		log.Print(output)
+	// Console output, at debug level only
+	if slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+		for _, entry := range batch.Entries {
+			slog.Debug(p.formatEntry(entry, batch.AgentId))
+		}
 	}
 
 	// ClickHouse insertion via buffer
 	if p.logBuffer != nil {
+		if p.validator != nil && p.validator.policy == ValidationReject {
+			if err := p.validator.checkBatch(batch, time.Now()); err != nil {
+				return fmt.Errorf("batch rejected by ingest validation: %w", err)
+			}
+		}
 		records := p.convertToRecords(batch)
 		if err := p.logBuffer.AddBatch(records); err != nil {
-			log.Printf("log buffer error: %v", err)
+			slog.Error("log buffer", "error", err)
 			// Don't fail the batch - logs already printed
 		}
 	}
@@ -68,6 +85,49 @@ func (p *Processor) ProcessBatch(batch *blazelogv1.LogBatch) error {
 	return nil
 }
 
+// SetDeduplicator enables content-hash deduplication of stored records.
+// Pass nil to disable. Must be called before batches are processed.
+func (p *Processor) SetDeduplicator(d *Deduplicator) {
+	p.dedup = d
+}
+
+// SetFieldLimits caps the number of fields and labels kept per record.
+// Zero or negative values disable the respective cap.
+func (p *Processor) SetFieldLimits(maxFields, maxLabels int) {
+	p.maxFields = maxFields
+	p.maxLabels = maxLabels
+}
+
+// SetFieldRenames sets the per-type field rename mapping applied at ingest.
+// Must be called before batches are processed.
+func (p *Processor) SetFieldRenames(r FieldRenames) {
+	p.renames = r
+}
+
+// SetRecordIDFields sets the per-type fields that hold a source event ID,
+// used as the stored record ID. Must be called before batches are processed.
+func (p *Processor) SetRecordIDFields(f RecordIDFields) {
+	p.idFields = f
+}
+
+// SetProjectAssignment sets the rules assigning a project to records from
+// agents that did not report one. Must be called before batches are processed.
+func (p *Processor) SetProjectAssignment(a *ProjectAssignment) {
+	p.projects = a
+}
+
+// SetValidator enables validation of records before they are stored.
+// Pass nil to disable. Must be called before batches are processed.
+func (p *Processor) SetValidator(v *Validator) {
+	p.validator = v
+}
+
+// SetGeoIP enables GeoIP enrichment of records with a client IP field.
+// Pass nil to disable. Must be called before batches are processed.
+func (p *Processor) SetGeoIP(g *GeoIP) {
+	p.geoIP = g
+}
+
 // truncateString truncates a string to maxLen if it exceeds the limit.
 func truncateString(s string, maxLen int) string {
 	if len(s) <= maxLen {
@@ -79,16 +139,28 @@ func truncateString(s string, maxLen int) string {
 // convertToRecords converts a proto batch to storage records.
 func (p *Processor) convertToRecords(batch *blazelogv1.LogBatch) []*LogRecord {
 	records := make([]*LogRecord, 0, len(batch.Entries))
+	now := time.Now()
 	for _, entry := range batch.Entries {
-		var ts time.Time
-		if entry.Timestamp != nil {
-			ts = entry.Timestamp.AsTime()
-		} else {
-			ts = time.Now()
+		ts := entryTimestamp(entry)
+		message := entry.Message
+		if p.validator != nil {
+			var ok bool
+			if ts, message, ok = p.validator.apply(entry, ts, now); !ok {
+				continue
+			}
+		}
+		if entry.Timestamp == nil {
+			ts = now
+		}
+
+		// Skip exact re-deliveries (hash of original, untruncated content)
+		if p.dedup != nil && p.dedup.Seen(batch.AgentId, ts, entry.Message, entry.LineNumber, now) {
+			metrics.IngestDeduplicatedTotal.Inc()
+			continue
 		}
 
 		// Truncate fields to prevent oversized data
-		message := truncateString(entry.Message, maxMessageLen)
+		message = truncateString(message, maxMessageLen)
 		raw := truncateString(entry.Raw, maxRawLen)
 		source := truncateString(entry.Source, maxSourceLen)
 		filePath := truncateString(entry.FilePath, maxFilePathLen)
@@ -107,6 +179,9 @@ func (p *Processor) convertToRecords(batch *blazelogv1.LogBatch) []*LogRecord {
 			LineNumber: entry.LineNumber,
 			Labels:     entry.Labels,
 		}
+		if record.ProjectID == "" {
+			record.ProjectID = p.projects.resolve(batch.AgentId, entry.Labels)
+		}
 
 		// Convert protobuf struct to map
 		if entry.Fields != nil {
@@ -123,6 +198,17 @@ func (p *Processor) convertToRecords(batch *blazelogv1.LogBatch) []*LogRecord {
 			}
 		}
 
+		// Canonicalize field names, then cap fields/labels. Both run after
+		// extraction so denormalized columns survive.
+		p.renames.apply(record.Type, record.Fields)
+		// After renames, so a renamed field (e.g. remote_addr) can feed it
+		p.geoIP.apply(record.Fields)
+		// Take the event ID before field limits can drop its field
+		if id := p.idFields.id(record.Type, record.ProjectID, record.Fields); id != "" {
+			record.ID = id
+		}
+		p.applyFieldLimits(record)
+
 		records = append(records, record)
 	}
 	return records
@@ -159,6 +245,18 @@ func typeToString(logType blazelogv1.LogType) string {
 		return "prestashop"
 	case blazelogv1.LogType_LOG_TYPE_WORDPRESS:
 		return "wordpress"
+	case blazelogv1.LogType_LOG_TYPE_SYSLOG:
+		return "syslog"
+	case blazelogv1.LogType_LOG_TYPE_JSON:
+		return "json"
+	case blazelogv1.LogType_LOG_TYPE_POSTGRES:
+		return "postgres"
+	case blazelogv1.LogType_LOG_TYPE_MYSQL:
+		return "mysql"
+	case blazelogv1.LogType_LOG_TYPE_CUSTOM:
+		return "custom"
+	case blazelogv1.LogType_LOG_TYPE_UNPARSED:
+		return "unparsed"
 	default:
 		return "unknown"
 	}
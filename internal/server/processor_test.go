@@ -0,0 +1,31 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/good-yellow-bee/blazelog/internal/agent"
+	"github.com/good-yellow-bee/blazelog/internal/models"
+	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
+)
+
+// Every type an agent sends must be stored as itself, not as unknown.
+func TestLogTypeRoundTrip(t *testing.T) {
+	types := []models.LogType{
+		models.LogTypeNginx, models.LogTypeApache, models.LogTypeMagento,
+		models.LogTypePrestaShop, models.LogTypeWordPress, models.LogTypeSyslog,
+		models.LogTypeJSON, models.LogTypePostgres, models.LogTypeMySQL,
+		models.LogTypeCustom, models.LogTypeUnparsed, models.LogTypeUnknown,
+	}
+	for _, logType := range types {
+		if got := typeToString(agent.ToProtoLogType(logType)); got != string(logType) {
+			t.Errorf("type %q is stored as %q", logType, got)
+		}
+	}
+
+	// And no proto value is left unmapped
+	for number, name := range blazelogv1.LogType_name {
+		if number != 0 && typeToString(blazelogv1.LogType(number)) == "unknown" {
+			t.Errorf("%s is stored as unknown", name)
+		}
+	}
+}
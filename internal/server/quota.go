@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/metrics"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// QuotaConfig caps the log lines per second each agent may send.
+type QuotaConfig struct {
+	Default   AgentQuota            // Applies to agents without an override
+	Overrides map[string]AgentQuota // Agent ID -> own quota
+}
+
+// MinQuotaBurst is the smallest bucket an agent quota may have: the
+// largest batch an agent may send, so a full batch always fits once the
+// bucket refills.
+const MinQuotaBurst = maxBatchSize
+
+// AgentQuota is one agent's token bucket. A zero rate leaves the agent
+// unlimited; a zero burst allows one second's worth of lines at once, but
+// never less than MinQuotaBurst.
+type AgentQuota struct {
+	LinesPerSecond float64
+	Burst          int
+}
+
+// unlimited reports whether the quota lets lines through unchecked.
+func (q AgentQuota) unlimited() bool {
+	return q.LinesPerSecond <= 0
+}
+
+// burst returns the bucket size, at least MinQuotaBurst lines.
+func (q AgentQuota) burst() int {
+	b := q.Burst
+	if b == 0 {
+		b = int(q.LinesPerSecond)
+	}
+	return max(b, MinQuotaBurst)
+}
+
+type quotaBucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// AgentQuotas holds a token bucket per agent ID.
+type AgentQuotas struct {
+	config QuotaConfig
+
+	mu      sync.Mutex
+	buckets map[string]*quotaBucket
+}
+
+// NewAgentQuotas creates per-agent limiters for cfg.
+func NewAgentQuotas(cfg QuotaConfig) *AgentQuotas {
+	return &AgentQuotas{
+		config:  cfg,
+		buckets: make(map[string]*quotaBucket),
+	}
+}
+
+// quotaFor returns the quota that applies to agentID.
+func (q *AgentQuotas) quotaFor(agentID string) AgentQuota {
+	if o, ok := q.config.Overrides[agentID]; ok {
+		return o
+	}
+	return q.config.Default
+}
+
+// Allow reports whether agentID may send n more lines at now, taking them
+// from its bucket if so. Batches are at most MinQuotaBurst lines, so a
+// refused batch fits once the bucket refills.
+func (q *AgentQuotas) Allow(agentID string, n int, now time.Time) bool {
+	quota := q.quotaFor(agentID)
+	if quota.unlimited() {
+		return true
+	}
+
+	q.mu.Lock()
+	b, ok := q.buckets[agentID]
+	if !ok {
+		b = &quotaBucket{limiter: rate.NewLimiter(rate.Limit(quota.LinesPerSecond), quota.burst())}
+		q.buckets[agentID] = b
+	}
+	b.lastSeen = now
+	q.mu.Unlock()
+
+	allowed := b.limiter.AllowN(now, n)
+	used := 1 - b.limiter.TokensAt(now)/float64(b.limiter.Burst())
+	metrics.GRPCAgentQuotaUsage.WithLabelValues(agentID).Set(used)
+	if !allowed {
+		metrics.GRPCAgentQuotaRejectedTotal.WithLabelValues(agentID).Add(float64(n))
+	}
+	return allowed
+}
+
+// prune forgets buckets of agents not seen since cutoff.
+func (q *AgentQuotas) prune(cutoff time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for id, b := range q.buckets {
+		if b.lastSeen.Before(cutoff) {
+			delete(q.buckets, id)
+			metrics.GRPCAgentQuotaUsage.DeleteLabelValues(id)
+		}
+	}
+}
+
+// quotaAgentID returns the ID an agent's lines are counted under: the
+// common name of its verified mTLS client certificate, so an agent can't
+// dodge its quota by changing the ID it reports, or else batchAgentID.
+func quotaAgentID(ctx context.Context, batchAgentID string) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return batchAgentID
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return batchAgentID
+	}
+	if cn := tlsInfo.State.VerifiedChains[0][0].Subject.CommonName; cn != "" {
+		return cn
+	}
+	return batchAgentID
+}
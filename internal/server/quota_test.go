@@ -0,0 +1,155 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestAgentQuotas_Allow(t *testing.T) {
+	q := NewAgentQuotas(QuotaConfig{
+		Default: AgentQuota{LinesPerSecond: 200},
+		Overrides: map[string]AgentQuota{
+			"big":  {LinesPerSecond: 100, Burst: 500},
+			"free": {},
+		},
+	})
+	now := time.Now()
+
+	if !q.Allow("a", 200, now) {
+		t.Error("default burst of 200 lines should be allowed")
+	}
+	if q.Allow("a", 1, now) {
+		t.Error("201st line in the same instant should be refused")
+	}
+	if !q.Allow("a", 100, now.Add(500*time.Millisecond)) {
+		t.Error("100 lines after 500ms at 200/s should be allowed")
+	}
+	if !q.Allow("b", 200, now) {
+		t.Error("agents should have separate buckets")
+	}
+
+	if !q.Allow("big", 500, now) {
+		t.Error("override burst of 500 should be allowed")
+	}
+	if q.Allow("big", 11, now) {
+		t.Error("override bucket should be empty")
+	}
+	if !q.Allow("free", 1_000_000, now) {
+		t.Error("zero-rate override should be unlimited")
+	}
+}
+
+func TestAgentQuotas_FullBatchUnderLowRate(t *testing.T) {
+	// Regression: a burst of one second's worth at under maxBatchSize
+	// lines/s refused every full batch, wedging agents that re-send it
+	tests := []struct {
+		name  string
+		quota AgentQuota
+	}{
+		{"default burst", AgentQuota{LinesPerSecond: 10}},
+		{"fractional rate", AgentQuota{LinesPerSecond: 0.5}},
+		{"small explicit burst", AgentQuota{LinesPerSecond: 10, Burst: 20}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewAgentQuotas(QuotaConfig{Default: tt.quota})
+			now := time.Now()
+			if !q.Allow("a", maxBatchSize, now) {
+				t.Fatal("a full batch should fit an idle bucket")
+			}
+			if q.Allow("a", maxBatchSize, now) {
+				t.Fatal("a second full batch in the same instant should be refused")
+			}
+			refill := time.Duration(float64(maxBatchSize) / tt.quota.LinesPerSecond * float64(time.Second))
+			if !q.Allow("a", maxBatchSize, now.Add(refill)) {
+				t.Error("a refused full batch should fit once the bucket refills")
+			}
+		})
+	}
+}
+
+func TestAgentQuotas_Prune(t *testing.T) {
+	q := NewAgentQuotas(QuotaConfig{Default: AgentQuota{LinesPerSecond: 10}})
+	now := time.Now()
+	q.Allow("old", 1, now.Add(-time.Hour))
+	q.Allow("new", 1, now)
+
+	q.prune(now.Add(-time.Minute))
+	if _, ok := q.buckets["old"]; ok {
+		t.Error("idle bucket should be pruned")
+	}
+	if _, ok := q.buckets["new"]; !ok {
+		t.Error("active bucket should be kept")
+	}
+}
+
+func TestQuotaAgentID_WithoutTLS(t *testing.T) {
+	if got := quotaAgentID(context.Background(), "agent-1"); got != "agent-1" {
+		t.Errorf("quotaAgentID() = %q, want batch agent ID", got)
+	}
+}
+
+func TestStreamLogs_QuotaExceeded(t *testing.T) {
+	var lc net.ListenConfig
+	listener, err := lc.Listen(context.Background(), "tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find available port: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	srv, err := New(&Config{
+		GRPCAddress: addr,
+		Quota:       &QuotaConfig{Default: AgentQuota{LinesPerSecond: 1}},
+	})
+	if err != nil {
+		t.Fatalf("New server failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go srv.Run(ctx)
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	stream, err := blazelogv1.NewLogServiceClient(conn).StreamLogs(ctx)
+	if err != nil {
+		t.Fatalf("StreamLogs failed: %v", err)
+	}
+
+	entry := &blazelogv1.LogEntry{Timestamp: timestamppb.Now(), Message: "line", Type: blazelogv1.LogType_LOG_TYPE_NGINX}
+	entries := make([]*blazelogv1.LogEntry, maxBatchSize)
+	for i := range entries {
+		entries[i] = entry
+	}
+	batch := &blazelogv1.LogBatch{AgentId: "noisy", Sequence: 1, Entries: entries}
+	if err := stream.Send(batch); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if resp, err := stream.Recv(); err != nil || resp.AckedSequence != 1 {
+		t.Fatalf("first batch: resp=%v err=%v, want ack", resp, err)
+	}
+
+	batch.Sequence = 2
+	if err := stream.Send(batch); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	_, err = stream.Recv()
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("second batch: err=%v, want ResourceExhausted", err)
+	}
+}
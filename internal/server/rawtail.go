@@ -0,0 +1,232 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
+	"github.com/google/uuid"
+)
+
+// Raw tail limits. Requests above the maximums are capped.
+const (
+	DefaultRawTailDuration = 10 * time.Second
+	MaxRawTailDuration     = time.Minute
+	DefaultRawTailBytes    = 64 << 10 // 64KB
+	MaxRawTailBytes        = 1 << 20  // 1MB
+
+	// rawTailStartTimeout bounds the wait for an agent's first chunk. The
+	// command is delivered on the agent's next heartbeat (every 15s by
+	// default), so this allows for a couple of missed ones.
+	rawTailStartTimeout = 45 * time.Second
+
+	// rawTailChunkBuffer is how many chunks may queue for a slow reader
+	// before further chunks are dropped, so a stalled HTTP client never
+	// blocks the agent's log stream.
+	rawTailChunkBuffer = 256
+)
+
+// rawTailGapMarker is written where chunks were dropped for a slow reader,
+// so the gap in the output isn't silent.
+const rawTailGapMarker = "\n[blazelog: %d bytes dropped, the reader fell behind]\n"
+
+// Raw tail errors.
+var (
+	ErrAgentNotConnected = errors.New("agent not connected")
+	ErrRawTailBusy       = errors.New("a raw tail is already running for this agent")
+	ErrRawTailTimeout    = errors.New("agent did not answer the raw tail request")
+)
+
+// RawTailRequest asks an agent for a live tail of one source's raw bytes.
+type RawTailRequest struct {
+	AgentID  string
+	Source   string        // Source name from the agent's config
+	Duration time.Duration // How long to tail (0 = DefaultRawTailDuration)
+	MaxBytes int           // Stop after this many bytes (0 = DefaultRawTailBytes)
+}
+
+// normalize applies defaults and caps.
+func (r *RawTailRequest) normalize() {
+	if r.Duration <= 0 {
+		r.Duration = DefaultRawTailDuration
+	}
+	r.Duration = min(r.Duration, MaxRawTailDuration)
+	if r.MaxBytes <= 0 {
+		r.MaxBytes = DefaultRawTailBytes
+	}
+	r.MaxBytes = min(r.MaxBytes, MaxRawTailBytes)
+}
+
+// rawTailSession routes chunks for one request to its reader.
+type rawTailSession struct {
+	agentID string
+	chunks  chan rawTailDelivery
+	dropped atomic.Int64 // bytes dropped since the last queued chunk
+}
+
+// rawTailDelivery is a queued chunk and the bytes dropped just before it.
+type rawTailDelivery struct {
+	chunk   *blazelogv1.RawTailChunk
+	dropped int64
+}
+
+// RawTail asks the agent for a live tail of a source and copies the raw
+// bytes it sends to w until the agent finishes, the byte or time limit is
+// reached, or ctx is done. It returns the number of bytes written. Only
+// one tail may run per agent at a time.
+func (h *Handler) RawTail(ctx context.Context, req RawTailRequest, w io.Writer) (int, error) {
+	req.normalize()
+	if _, ok := h.agents.Load(req.AgentID); !ok {
+		return 0, ErrAgentNotConnected
+	}
+
+	requestID := uuid.New().String()
+	session := &rawTailSession{
+		agentID: req.AgentID,
+		chunks:  make(chan rawTailDelivery, rawTailChunkBuffer),
+	}
+
+	h.cmdMu.Lock()
+	for _, s := range h.rawTails {
+		if s.agentID == req.AgentID {
+			h.cmdMu.Unlock()
+			return 0, ErrRawTailBusy
+		}
+	}
+	h.rawTails[requestID] = session
+	h.commands[req.AgentID] = append(h.commands[req.AgentID], &blazelogv1.ServerCommand{
+		Type: blazelogv1.CommandType_COMMAND_TYPE_RAW_TAIL,
+		Parameters: map[string]string{
+			"request_id": requestID,
+			"source":     req.Source,
+			"duration":   req.Duration.String(),
+			"max_bytes":  strconv.Itoa(req.MaxBytes),
+		},
+	})
+	h.cmdMu.Unlock()
+	defer h.endRawTail(req.AgentID, requestID)
+
+	// Wait for the command to be picked up, then for the tail to finish
+	// (with the start timeout as slack for delivery and the final chunk).
+	timer := time.NewTimer(rawTailStartTimeout)
+	defer timer.Stop()
+	started := false
+	written := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return written, ctx.Err()
+		case <-timer.C:
+			if !started {
+				return written, ErrRawTailTimeout
+			}
+			return written, reportRawTailGap(w, session.dropped.Swap(0))
+		case d := <-session.chunks:
+			chunk := d.chunk
+			if !started {
+				started = true
+				timer.Reset(req.Duration + rawTailStartTimeout)
+			}
+			if err := reportRawTailGap(w, d.dropped); err != nil {
+				return written, err
+			}
+			data := chunk.Data
+			if len(data) > req.MaxBytes-written {
+				data = data[:req.MaxBytes-written]
+			}
+			if len(data) > 0 {
+				n, err := w.Write(data)
+				written += n
+				if err != nil {
+					return written, fmt.Errorf("write raw tail: %w", err)
+				}
+			}
+			if chunk.Error != "" {
+				return written, fmt.Errorf("agent: %s", chunk.Error)
+			}
+			if chunk.Done || written >= req.MaxBytes {
+				return written, reportRawTailGap(w, session.dropped.Swap(0))
+			}
+		}
+	}
+}
+
+// reportRawTailGap writes rawTailGapMarker for dropped bytes, if any. The
+// marker isn't counted toward the request's max bytes.
+func reportRawTailGap(w io.Writer, dropped int64) error {
+	if dropped == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintf(w, rawTailGapMarker, dropped); err != nil {
+		return fmt.Errorf("write raw tail: %w", err)
+	}
+	return nil
+}
+
+// endRawTail removes a finished request and its command if the agent has
+// not picked it up yet.
+func (h *Handler) endRawTail(agentID, requestID string) {
+	h.cmdMu.Lock()
+	defer h.cmdMu.Unlock()
+	delete(h.rawTails, requestID)
+
+	pending := h.commands[agentID][:0]
+	for _, cmd := range h.commands[agentID] {
+		if cmd.Parameters["request_id"] != requestID {
+			pending = append(pending, cmd)
+		}
+	}
+	if len(pending) == 0 {
+		delete(h.commands, agentID)
+	} else {
+		h.commands[agentID] = pending
+	}
+}
+
+// deliverRawTail hands a chunk from agentID to the request it answers.
+// Chunks for unknown or finished requests, or from another agent, are
+// dropped. So are chunks a slow reader has no room for; their bytes are
+// counted and reported to the reader with the next chunk it gets.
+func (h *Handler) deliverRawTail(agentID string, chunk *blazelogv1.RawTailChunk) {
+	h.cmdMu.Lock()
+	session, ok := h.rawTails[chunk.RequestId]
+	h.cmdMu.Unlock()
+	if !ok || session.agentID != agentID {
+		return
+	}
+	d := rawTailDelivery{chunk: chunk, dropped: session.dropped.Swap(0)}
+	select {
+	case session.chunks <- d:
+	default:
+		session.dropped.Add(d.dropped + int64(len(chunk.Data)))
+		slog.Warn("raw tail reader too slow, dropping chunk", "agent", agentID, "bytes", len(chunk.Data))
+	}
+}
+
+// nextCommand pops the oldest command queued for agentID, or returns nil.
+func (h *Handler) nextCommand(agentID string) *blazelogv1.ServerCommand {
+	h.cmdMu.Lock()
+	defer h.cmdMu.Unlock()
+	queue := h.commands[agentID]
+	if len(queue) == 0 {
+		return nil
+	}
+	if len(queue) == 1 {
+		delete(h.commands, agentID)
+	} else {
+		h.commands[agentID] = queue[1:]
+	}
+	return queue[0]
+}
+
+// RawTail asks an agent for a live tail of one of its sources; see
+// Handler.RawTail.
+func (s *Server) RawTail(ctx context.Context, req RawTailRequest, w io.Writer) (int, error) {
+	return s.handler.RawTail(ctx, req, w)
+}
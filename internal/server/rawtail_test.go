@@ -0,0 +1,253 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
+)
+
+// registerTestAgent registers an agent with handler and returns its ID.
+func registerTestAgent(t *testing.T, handler *Handler) string {
+	t.Helper()
+	resp, err := handler.Register(context.Background(), &blazelogv1.RegisterRequest{
+		Agent: &blazelogv1.AgentInfo{AgentId: "agent-1", Name: "test"},
+	})
+	if err != nil || !resp.Success {
+		t.Fatalf("Register() = %v, %v", resp, err)
+	}
+	return resp.AgentId
+}
+
+// awaitCommand polls heartbeats until the agent receives a command.
+func awaitCommand(t *testing.T, handler *Handler, agentID string) *blazelogv1.ServerCommand {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		resp, err := handler.Heartbeat(context.Background(), &blazelogv1.HeartbeatRequest{AgentId: agentID})
+		if err != nil {
+			t.Fatalf("Heartbeat() error = %v", err)
+		}
+		if resp.Command != nil {
+			return resp.Command
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("no command delivered on heartbeat")
+	return nil
+}
+
+type rawTailResult struct {
+	n   int
+	err error
+}
+
+func TestHandler_RawTail(t *testing.T) {
+	handler := NewHandler(NewProcessor(false, nil), false)
+	defer handler.Stop()
+	agentID := registerTestAgent(t, handler)
+
+	var out bytes.Buffer
+	done := make(chan rawTailResult, 1)
+	go func() {
+		n, err := handler.RawTail(context.Background(), RawTailRequest{
+			AgentID:  agentID,
+			Source:   "nginx",
+			Duration: 5 * time.Second,
+			MaxBytes: 10,
+		}, &out)
+		done <- rawTailResult{n, err}
+	}()
+
+	cmd := awaitCommand(t, handler, agentID)
+	if cmd.Type != blazelogv1.CommandType_COMMAND_TYPE_RAW_TAIL {
+		t.Fatalf("command type = %v, want RAW_TAIL", cmd.Type)
+	}
+	if got := cmd.Parameters["source"]; got != "nginx" {
+		t.Errorf("source parameter = %q, want nginx", got)
+	}
+	if got := cmd.Parameters["duration"]; got != "5s" {
+		t.Errorf("duration parameter = %q, want 5s", got)
+	}
+	requestID := cmd.Parameters["request_id"]
+
+	// A second tail for the same agent is refused while this one runs
+	if _, err := handler.RawTail(context.Background(), RawTailRequest{AgentID: agentID}, &bytes.Buffer{}); !errors.Is(err, ErrRawTailBusy) {
+		t.Errorf("concurrent RawTail() error = %v, want ErrRawTailBusy", err)
+	}
+
+	// Chunks from another agent or for another request are ignored
+	handler.deliverRawTail("agent-2", &blazelogv1.RawTailChunk{RequestId: requestID, Data: []byte("spoofed")})
+	handler.deliverRawTail(agentID, &blazelogv1.RawTailChunk{RequestId: "other", Data: []byte("stale")})
+
+	handler.deliverRawTail(agentID, &blazelogv1.RawTailChunk{RequestId: requestID, Data: []byte("line 1\n")})
+	handler.deliverRawTail(agentID, &blazelogv1.RawTailChunk{RequestId: requestID, Data: []byte("line 2\n")})
+
+	select {
+	case res := <-done:
+		if res.err != nil {
+			t.Fatalf("RawTail() error = %v", res.err)
+		}
+		if res.n != 10 {
+			t.Errorf("RawTail() wrote %d bytes, want 10 (max_bytes)", res.n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("RawTail() did not stop at max_bytes")
+	}
+	if got := out.String(); got != "line 1\nlin" {
+		t.Errorf("output = %q, want %q", got, "line 1\nlin")
+	}
+}
+
+func TestHandler_RawTailAgentError(t *testing.T) {
+	handler := NewHandler(NewProcessor(false, nil), false)
+	defer handler.Stop()
+	agentID := registerTestAgent(t, handler)
+
+	done := make(chan rawTailResult, 1)
+	go func() {
+		n, err := handler.RawTail(context.Background(), RawTailRequest{AgentID: agentID, Source: "nginx"}, &bytes.Buffer{})
+		done <- rawTailResult{n, err}
+	}()
+
+	cmd := awaitCommand(t, handler, agentID)
+	handler.deliverRawTail(agentID, &blazelogv1.RawTailChunk{
+		RequestId: cmd.Parameters["request_id"],
+		Done:      true,
+		Error:     "raw tail is disabled on this agent",
+	})
+
+	res := <-done
+	if res.err == nil || res.err.Error() != "agent: raw tail is disabled on this agent" {
+		t.Errorf("RawTail() error = %v, want the agent's reason", res.err)
+	}
+}
+
+func TestHandler_RawTailNotConnected(t *testing.T) {
+	handler := NewHandler(NewProcessor(false, nil), false)
+	defer handler.Stop()
+
+	_, err := handler.RawTail(context.Background(), RawTailRequest{AgentID: "missing"}, &bytes.Buffer{})
+	if !errors.Is(err, ErrAgentNotConnected) {
+		t.Errorf("RawTail() error = %v, want ErrAgentNotConnected", err)
+	}
+}
+
+func TestHandler_RawTailCanceledDropsCommand(t *testing.T) {
+	handler := NewHandler(NewProcessor(false, nil), false)
+	defer handler.Stop()
+	agentID := registerTestAgent(t, handler)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := handler.RawTail(ctx, RawTailRequest{AgentID: agentID}, &bytes.Buffer{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("RawTail() error = %v, want context.DeadlineExceeded", err)
+	}
+
+	resp, err := handler.Heartbeat(context.Background(), &blazelogv1.HeartbeatRequest{AgentId: agentID})
+	if err != nil {
+		t.Fatalf("Heartbeat() error = %v", err)
+	}
+	if resp.Command != nil {
+		t.Errorf("heartbeat delivered %v for an abandoned tail", resp.Command)
+	}
+}
+
+func TestRawTailRequest_Normalize(t *testing.T) {
+	tests := []struct {
+		name         string
+		req          RawTailRequest
+		wantDuration time.Duration
+		wantBytes    int
+	}{
+		{"defaults", RawTailRequest{}, DefaultRawTailDuration, DefaultRawTailBytes},
+		{"within limits", RawTailRequest{Duration: 30 * time.Second, MaxBytes: 1024}, 30 * time.Second, 1024},
+		{"capped", RawTailRequest{Duration: time.Hour, MaxBytes: 1 << 30}, MaxRawTailDuration, MaxRawTailBytes},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.normalize()
+			if tt.req.Duration != tt.wantDuration || tt.req.MaxBytes != tt.wantBytes {
+				t.Errorf("normalize() = %s/%d, want %s/%d", tt.req.Duration, tt.req.MaxBytes, tt.wantDuration, tt.wantBytes)
+			}
+		})
+	}
+}
+
+// gatedWriter blocks writes until released, signaling the first one.
+type gatedWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.started) })
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *gatedWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestHandler_RawTailReportsDroppedChunks(t *testing.T) {
+	handler := NewHandler(NewProcessor(false, nil), false)
+	defer handler.Stop()
+	agentID := registerTestAgent(t, handler)
+
+	out := &gatedWriter{started: make(chan struct{}), release: make(chan struct{})}
+	done := make(chan rawTailResult, 1)
+	go func() {
+		n, err := handler.RawTail(context.Background(), RawTailRequest{
+			AgentID:  agentID,
+			Duration: 5 * time.Second,
+			MaxBytes: 4096,
+		}, out)
+		done <- rawTailResult{n, err}
+	}()
+	requestID := awaitCommand(t, handler, agentID).Parameters["request_id"]
+	chunk := func(data string) *blazelogv1.RawTailChunk {
+		return &blazelogv1.RawTailChunk{RequestId: requestID, Data: []byte(data)}
+	}
+
+	// The reader blocks writing the first chunk, the queue fills up and
+	// the last 3 chunks are dropped
+	handler.deliverRawTail(agentID, chunk("x\n"))
+	<-out.started
+	for i := 0; i < rawTailChunkBuffer+3; i++ {
+		handler.deliverRawTail(agentID, chunk("x\n"))
+	}
+	close(out.release)
+
+	queued := strings.Repeat("x\n", rawTailChunkBuffer+1)
+	deadline := time.Now().Add(time.Second)
+	for out.String() != queued && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	handler.deliverRawTail(agentID, &blazelogv1.RawTailChunk{RequestId: requestID, Data: []byte("end\n"), Done: true})
+
+	res := <-done
+	if res.err != nil {
+		t.Fatalf("RawTail() error = %v", res.err)
+	}
+	want := queued + "\n[blazelog: 6 bytes dropped, the reader fell behind]\n" + "end\n"
+	if got := out.String(); got != want {
+		t.Errorf("output after the queued chunks = %q, want %q", strings.TrimPrefix(got, queued), strings.TrimPrefix(want, queued))
+	}
+	if res.n != len(queued)+4 {
+		t.Errorf("RawTail() wrote %d bytes, want %d (marker not counted)", res.n, len(queued)+4)
+	}
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// recordIDNamespace is the UUIDv5 namespace for IDs derived from a source
+// event ID that is not itself a UUID.
+var recordIDNamespace = uuid.MustParse("8f1c2a4e-5b7d-4e0a-9c3f-6d2b1e8a7f45")
+
+// RecordIDFields maps a log type (e.g. "nginx", or AllTypesKey) to the field
+// holding a unique event ID. Records carrying that field get a stable ID
+// derived from it instead of a random one, so re-ingesting an event
+// produces the same record ID.
+//
+// Precedence: a type-specific field wins over the AllTypesKey field.
+type RecordIDFields map[string]string
+
+// id returns the record ID for a record of the given log type and project,
+// or "" if the record has no usable ID field. A UUID value is used as is;
+// any other string or number is hashed with the project into a UUIDv5, so
+// equal event IDs in different projects never collide.
+func (r RecordIDFields) id(logType, projectID string, fields map[string]interface{}) string {
+	if len(r) == 0 || len(fields) == 0 {
+		return ""
+	}
+	name, ok := r[logType]
+	if !ok {
+		name, ok = r[AllTypesKey]
+	}
+	if !ok {
+		return ""
+	}
+
+	var value string
+	switch v := fields[name].(type) {
+	case string:
+		value = v
+	case float64:
+		value = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return ""
+	}
+	if value == "" {
+		return ""
+	}
+
+	if parsed, err := uuid.Parse(value); err == nil {
+		return parsed.String()
+	}
+	return uuid.NewSHA1(recordIDNamespace, []byte(projectID+"\x00"+value)).String()
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"testing"
+
+	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestRecordIDFields_ID(t *testing.T) {
+	idFields := RecordIDFields{
+		AllTypesKey: "event_id",
+		"nginx":     "request_id",
+	}
+
+	tests := []struct {
+		name    string
+		logType string
+		project string
+		fields  map[string]interface{}
+		want    string // "" = no ID
+	}{
+		{
+			name:    "UUID value used as is",
+			logType: "magento",
+			fields:  map[string]interface{}{"event_id": "6BA7B810-9DAD-11D1-80B4-00C04FD430C8"},
+			want:    "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+		},
+		{
+			name:    "other string hashed with project",
+			logType: "magento",
+			project: "shop",
+			fields:  map[string]interface{}{"event_id": "evt-42"},
+			want:    uuid.NewSHA1(recordIDNamespace, []byte("shop\x00evt-42")).String(),
+		},
+		{
+			name:    "number formatted without exponent",
+			logType: "magento",
+			fields:  map[string]interface{}{"event_id": float64(1234567890)},
+			want:    uuid.NewSHA1(recordIDNamespace, []byte("\x001234567890")).String(),
+		},
+		{
+			name:    "type field wins over global",
+			logType: "nginx",
+			fields:  map[string]interface{}{"event_id": "a", "request_id": "b"},
+			want:    uuid.NewSHA1(recordIDNamespace, []byte("\x00b")).String(),
+		},
+		{
+			name:    "missing field",
+			logType: "nginx",
+			fields:  map[string]interface{}{"event_id": "a"},
+		},
+		{
+			name:    "empty value",
+			logType: "magento",
+			fields:  map[string]interface{}{"event_id": ""},
+		},
+		{
+			name:    "unsupported value type",
+			logType: "magento",
+			fields:  map[string]interface{}{"event_id": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := idFields.id(tt.logType, tt.project, tt.fields); got != tt.want {
+				t.Errorf("id() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := RecordIDFields(nil).id("nginx", "", map[string]interface{}{"event_id": "a"}); got != "" {
+		t.Errorf("nil RecordIDFields id() = %q, want empty", got)
+	}
+}
+
+func TestProcessor_RecordIDFields(t *testing.T) {
+	fields, err := structpb.NewStruct(map[string]interface{}{"event_id": "evt-1"})
+	if err != nil {
+		t.Fatalf("new struct: %v", err)
+	}
+
+	processor := NewProcessor(false, nil)
+	processor.SetRecordIDFields(RecordIDFields{AllTypesKey: "event_id"})
+
+	batch := &blazelogv1.LogBatch{
+		ProjectId: "shop",
+		Entries: []*blazelogv1.LogEntry{
+			{Message: "a", Fields: fields},
+			{Message: "b"},
+		},
+	}
+	first := processor.convertToRecords(batch)
+	again := processor.convertToRecords(batch)
+
+	if first[0].ID != again[0].ID {
+		t.Errorf("re-ingested ID = %s, want stable %s", again[0].ID, first[0].ID)
+	}
+	if first[1].ID == again[1].ID {
+		t.Errorf("record without event ID kept ID %s, want a fresh random one", first[1].ID)
+	}
+}
@@ -0,0 +1,44 @@
+package server
+
+// AllTypesKey is the FieldRenames key whose mapping applies to every log type.
+const AllTypesKey = "*"
+
+// FieldRenames maps a log type (e.g. "nginx", or AllTypesKey) to a
+// from→to map of field names applied at ingest.
+//
+// Precedence: a type-specific mapping for a field wins over the AllTypesKey
+// mapping. A field is not renamed if the target name is already present on
+// the record, so parser-provided values are never overwritten.
+type FieldRenames map[string]map[string]string
+
+// apply renames fields in place for a record of the given log type.
+func (r FieldRenames) apply(logType string, fields map[string]interface{}) {
+	if len(r) == 0 || len(fields) == 0 {
+		return
+	}
+	typed := r[logType]
+	global := r[AllTypesKey]
+	if len(typed) == 0 && len(global) == 0 {
+		return
+	}
+
+	// Resolve targets first so a rename never feeds into another one
+	renames := make(map[string]string)
+	for from := range fields {
+		to, ok := typed[from]
+		if !ok {
+			to, ok = global[from]
+		}
+		if ok && to != from {
+			renames[from] = to
+		}
+	}
+
+	for from, to := range renames {
+		if _, exists := fields[to]; exists {
+			continue
+		}
+		fields[to] = fields[from]
+		delete(fields, from)
+	}
+}
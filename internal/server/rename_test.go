@@ -0,0 +1,92 @@
+package server
+
+import (
+	"testing"
+
+	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestFieldRenames_Apply(t *testing.T) {
+	renames := FieldRenames{
+		AllTypesKey: {"remote_host": "client_ip", "request_uri": "uri"},
+		"apache":    {"remote_host": "apache_client"},
+	}
+
+	tests := []struct {
+		name    string
+		logType string
+		fields  map[string]interface{}
+		want    map[string]interface{}
+	}{
+		{
+			name:    "global mapping",
+			logType: "nginx",
+			fields:  map[string]interface{}{"remote_host": "1.2.3.4", "request_uri": "/"},
+			want:    map[string]interface{}{"client_ip": "1.2.3.4", "uri": "/"},
+		},
+		{
+			name:    "type mapping wins over global",
+			logType: "apache",
+			fields:  map[string]interface{}{"remote_host": "1.2.3.4"},
+			want:    map[string]interface{}{"apache_client": "1.2.3.4"},
+		},
+		{
+			name:    "existing target is not overwritten",
+			logType: "nginx",
+			fields:  map[string]interface{}{"remote_host": "1.2.3.4", "client_ip": "5.6.7.8"},
+			want:    map[string]interface{}{"remote_host": "1.2.3.4", "client_ip": "5.6.7.8"},
+		},
+		{
+			name:    "unmapped fields untouched",
+			logType: "magento",
+			fields:  map[string]interface{}{"channel": "main"},
+			want:    map[string]interface{}{"channel": "main"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renames.apply(tt.logType, tt.fields)
+			if len(tt.fields) != len(tt.want) {
+				t.Fatalf("fields = %v, want %v", tt.fields, tt.want)
+			}
+			for k, v := range tt.want {
+				if tt.fields[k] != v {
+					t.Errorf("fields[%q] = %v, want %v", k, tt.fields[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessor_FieldRenames(t *testing.T) {
+	fields, err := structpb.NewStruct(map[string]interface{}{
+		"request_uri": "/checkout",
+		"remote_host": "10.0.0.1",
+	})
+	if err != nil {
+		t.Fatalf("new struct: %v", err)
+	}
+
+	processor := NewProcessor(false, nil)
+	processor.SetFieldRenames(FieldRenames{
+		"nginx": {"request_uri": "uri", "remote_host": "client_ip"},
+	})
+
+	records := processor.convertToRecords(&blazelogv1.LogBatch{
+		Entries: []*blazelogv1.LogEntry{{
+			Type:   blazelogv1.LogType_LOG_TYPE_NGINX,
+			Fields: fields,
+		}},
+	})
+	rec := records[0]
+
+	if rec.Fields["client_ip"] != "10.0.0.1" || rec.Fields["uri"] != "/checkout" {
+		t.Errorf("fields = %v, want renamed client_ip and uri", rec.Fields)
+	}
+	// Denormalized column is extracted before renaming
+	if rec.URI != "/checkout" {
+		t.Errorf("URI = %q, want /checkout", rec.URI)
+	}
+}
@@ -3,14 +3,18 @@ package server
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
+	"sync/atomic"
 	"time"
 
+	_ "github.com/good-yellow-bee/blazelog/internal/encoding/zstd" // accept zstd-compressed agent streams
+	"github.com/good-yellow-bee/blazelog/internal/metrics"
 	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
 	"github.com/good-yellow-bee/blazelog/internal/security"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	_ "google.golang.org/grpc/encoding/gzip" // accept gzip-compressed agent streams
 	"google.golang.org/grpc/keepalive"
 )
 
@@ -18,8 +22,25 @@ import (
 type Config struct {
 	GRPCAddress string
 	Verbose     bool
-	TLS         *TLSConfig // nil = insecure mode
-	LogBuffer   LogBuffer  // nil = no ClickHouse storage
+	TLS         *TLSConfig   // nil = insecure mode
+	LogBuffer   LogBuffer    // nil = no ClickHouse storage
+	Dedup       *DedupConfig // nil = ingest deduplication disabled
+	Quota       *QuotaConfig // nil = agents may send any number of lines
+	MaxFields   int          // Max fields kept per record (0 = unlimited)
+	MaxLabels   int          // Max labels kept per record (0 = unlimited)
+
+	MaxMessageSize int            // Max gRPC message size in bytes (0 = 4MB)
+	FieldRenames   FieldRenames   // Per-type field renames applied at ingest
+	RecordIDFields RecordIDFields // Per-type event ID fields used as record IDs
+
+	Projects   *ProjectAssignment // nil = records without a project stay unassigned
+	Validation *ValidationConfig  // nil = records are stored unvalidated
+	GeoIP      *GeoIP             // nil = no GeoIP enrichment; reloaded while Run runs
+
+	// TransferReportInterval is how often bytes received from all agents,
+	// before and after compression, are logged (0 = off; metrics are
+	// always exported).
+	TransferReportInterval time.Duration
 }
 
 // LogBuffer interface for log buffering (implemented by storage.LogBuffer).
@@ -53,31 +74,59 @@ type TLSConfig struct {
 	CertFile     string
 	KeyFile      string
 	ClientCAFile string
+	MinVersion   string   // "1.2" or "1.3" (empty = 1.3)
+	CipherSuites []string // Allowed TLS 1.2 suites (empty = Go defaults)
 }
 
+// drainTimeout bounds how long Run waits for in-flight streams to finish
+// on shutdown before force-closing them. Agents keep streams open, so a
+// graceful stop alone could wait until the stream idle timeout.
+const drainTimeout = 10 * time.Second
+
 // Server is the BlazeLog gRPC server.
 type Server struct {
 	config     *Config
 	grpcServer *grpc.Server
 	handler    *Handler
 	processor  *Processor
+	transfer   *metrics.TransferCounter // payload bytes received from agents
+	forced     atomic.Bool              // streams were force-closed on shutdown
 }
 
 // New creates a new BlazeLog server.
 func New(cfg *Config) (*Server, error) {
 	processor := NewProcessor(cfg.Verbose, cfg.LogBuffer)
+	if cfg.Dedup != nil {
+		processor.SetDeduplicator(NewDeduplicator(cfg.Dedup.Window, cfg.Dedup.MaxEntries))
+	}
+	processor.SetFieldLimits(cfg.MaxFields, cfg.MaxLabels)
+	processor.SetFieldRenames(cfg.FieldRenames)
+	processor.SetRecordIDFields(cfg.RecordIDFields)
+	processor.SetProjectAssignment(cfg.Projects)
+	if cfg.Validation != nil {
+		processor.SetValidator(NewValidator(*cfg.Validation))
+	}
+	processor.SetGeoIP(cfg.GeoIP)
 	handler := NewHandler(processor, cfg.Verbose)
+	if cfg.Quota != nil {
+		handler.SetQuotas(NewAgentQuotas(*cfg.Quota))
+	}
 
 	// Message size limits to prevent DoS via memory exhaustion
 	const (
-		maxRecvMsgSize      = 4 * 1024 * 1024 // 4MB
-		maxSendMsgSize      = 4 * 1024 * 1024 // 4MB
+		defaultMaxMsgSize   = 4 * 1024 * 1024 // 4MB
 		maxConcurrentStream = 100
 	)
+	maxMsgSize := cfg.MaxMessageSize
+	if maxMsgSize <= 0 {
+		maxMsgSize = defaultMaxMsgSize
+	}
 
+	transfer := metrics.NewTransferCounter(true, metrics.GRPCReceivedBytesTotal)
 	opts := []grpc.ServerOption{
-		grpc.MaxRecvMsgSize(maxRecvMsgSize),
-		grpc.MaxSendMsgSize(maxSendMsgSize),
+		grpc.StatsHandler(transfer),
+		grpc.MaxRecvMsgSize(maxMsgSize),
+		grpc.MaxSendMsgSize(maxMsgSize),
 		grpc.MaxConcurrentStreams(maxConcurrentStream),
 		grpc.KeepaliveParams(keepalive.ServerParameters{
 			MaxConnectionIdle: 15 * time.Minute,
@@ -92,16 +141,20 @@ func New(cfg *Config) (*Server, error) {
 			CertFile:     cfg.TLS.CertFile,
 			KeyFile:      cfg.TLS.KeyFile,
 			ClientCAFile: cfg.TLS.ClientCAFile,
+			Policy: security.TLSPolicy{
+				MinVersion:   cfg.TLS.MinVersion,
+				CipherSuites: cfg.TLS.CipherSuites,
+			},
 		}
 		creds, err := security.LoadServerTLS(tlsCfg)
 		if err != nil {
 			return nil, fmt.Errorf("load server TLS: %w", err)
 		}
 		opts = append(opts, grpc.Creds(creds))
-		log.Printf("mTLS enabled for gRPC server")
+		slog.Info("mTLS enabled for gRPC server")
 	} else {
 		opts = append(opts, grpc.Creds(insecure.NewCredentials()))
-		log.Printf("WARNING: gRPC server running in insecure mode (no TLS)")
+		slog.Warn("gRPC server running in insecure mode (no TLS)")
 	}
 
 	grpcServer := grpc.NewServer(opts...)
@@ -112,6 +165,7 @@ func New(cfg *Config) (*Server, error) {
 		grpcServer: grpcServer,
 		handler:    handler,
 		processor:  processor,
+		transfer:   transfer,
 	}, nil
 }
 
@@ -123,13 +177,35 @@ func (s *Server) Run(ctx context.Context) error {
 		return fmt.Errorf("listen on %s: %w", s.config.GRPCAddress, err)
 	}
 
-	log.Printf("gRPC server listening on %s", s.config.GRPCAddress)
+	slog.Info("gRPC server listening", "address", s.config.GRPCAddress)
+
+	if s.config.TransferReportInterval > 0 {
+		go metrics.ReportTransfers(ctx, s.transfer, s.config.TransferReportInterval, metrics.GRPCCompressionRatio, logTransferReport)
+	}
+	if s.config.GeoIP != nil {
+		go func() {
+			if err := s.config.GeoIP.Watch(ctx); err != nil {
+				slog.Warn("geoip database will not be reloaded on change", "error", err)
+			}
+		}()
+	}
 
 	// Handle shutdown
 	go func() {
 		<-ctx.Done()
-		log.Printf("shutting down gRPC server...")
-		s.grpcServer.GracefulStop()
+		slog.Info("shutting down gRPC server")
+		stopped := make(chan struct{})
+		go func() {
+			s.grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(drainTimeout):
+			slog.Warn("gRPC drain timed out, closing remaining streams", "timeout", drainTimeout)
+			s.forced.Store(true)
+			s.grpcServer.Stop()
+		}
 	}()
 
 	if err := s.grpcServer.Serve(listener); err != nil {
@@ -145,9 +221,27 @@ func (s *Server) Shutdown() {
 	s.grpcServer.GracefulStop()
 }
 
+// Drained reports whether the last shutdown finished without force-closing
+// agent streams. Only meaningful after Run has returned.
+func (s *Server) Drained() bool {
+	return !s.forced.Load()
+}
+
 // Stats returns current server statistics.
 func (s *Server) Stats() (batches, entries uint64, streams int32, agents int) {
 	batches, entries, streams = s.handler.Stats()
 	agents = s.handler.AgentCount()
 	return
 }
+
+// logTransferReport logs the bytes received from all agents in one report
+// interval.
+func logTransferReport(r metrics.TransferReport) {
+	slog.Info("gRPC transfer report",
+		"bytes", r.Uncompressed,
+		"wire_bytes", r.Compressed,
+		"compression_ratio", fmt.Sprintf("%.2f", r.Ratio()),
+		"interval", r.Elapsed.Round(time.Second),
+		"kb_per_sec", fmt.Sprintf("%.1f", r.Throughput()/1024),
+		"wire_kb_per_sec", fmt.Sprintf("%.1f", r.WireThroughput()/1024))
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/metrics"
+	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
+)
+
+// Validation policies, applied to records that fail a check.
+const (
+	ValidationDrop   = "drop"   // skip the record
+	ValidationCoerce = "coerce" // repair the record if possible, else skip it
+	ValidationReject = "reject" // fail the whole batch
+)
+
+// Validation failure reasons, used as the reason metric label.
+const (
+	reasonEmptyMessage    = "empty_message"
+	reasonZeroTimestamp   = "zero_timestamp"
+	reasonFutureTimestamp = "future_timestamp"
+	reasonOldTimestamp    = "old_timestamp"
+	reasonUnknownLevel    = "unknown_level"
+)
+
+// ValidationConfig configures ingest-side record validation.
+type ValidationConfig struct {
+	Policy        string        // drop, coerce or reject (empty = drop)
+	MaxFutureSkew time.Duration // How far past receipt a timestamp may be (0 = unchecked)
+	MaxAge        time.Duration // How far before receipt a timestamp may be (0 = unchecked)
+	Levels        []string      // Accepted levels (empty = any)
+}
+
+// Validator checks records against ingest rules: a non-empty message, a set
+// timestamp that is neither too far in the future nor too old, and
+// optionally a level from an accepted set.
+type Validator struct {
+	policy        string
+	maxFutureSkew time.Duration
+	maxAge        time.Duration
+	levels        map[string]bool // nil = any level
+}
+
+// NewValidator creates a validator from cfg.
+func NewValidator(cfg ValidationConfig) *Validator {
+	v := &Validator{
+		policy:        cfg.Policy,
+		maxFutureSkew: cfg.MaxFutureSkew,
+		maxAge:        cfg.MaxAge,
+	}
+	if v.policy == "" {
+		v.policy = ValidationDrop
+	}
+	if len(cfg.Levels) > 0 {
+		v.levels = make(map[string]bool, len(cfg.Levels))
+		for _, level := range cfg.Levels {
+			v.levels[strings.ToLower(level)] = true
+		}
+	}
+	return v
+}
+
+// violations returns the reasons an entry fails validation at receipt time
+// now. ts is the entry's timestamp, zero if it has none.
+func (v *Validator) violations(entry *blazelogv1.LogEntry, ts, now time.Time) []string {
+	var reasons []string
+	if strings.TrimSpace(entry.Message) == "" {
+		reasons = append(reasons, reasonEmptyMessage)
+	}
+	if !ts.After(time.Unix(0, 0)) {
+		reasons = append(reasons, reasonZeroTimestamp)
+	} else if v.maxFutureSkew > 0 && ts.Sub(now) > v.maxFutureSkew {
+		reasons = append(reasons, reasonFutureTimestamp)
+	} else if v.maxAge > 0 && now.Sub(ts) > v.maxAge {
+		reasons = append(reasons, reasonOldTimestamp)
+	}
+	if v.levels != nil && !v.levels[levelToString(entry.Level)] {
+		reasons = append(reasons, reasonUnknownLevel)
+	}
+	return reasons
+}
+
+// checkBatch returns an error describing the first invalid entry in batch.
+// Every invalid entry is counted as rejected.
+func (v *Validator) checkBatch(batch *blazelogv1.LogBatch, now time.Time) error {
+	var first error
+	for i, entry := range batch.Entries {
+		reasons := v.violations(entry, entryTimestamp(entry), now)
+		if len(reasons) == 0 {
+			continue
+		}
+		for _, reason := range reasons {
+			metrics.IngestInvalidTotal.WithLabelValues(reason, "rejected").Inc()
+		}
+		if first == nil {
+			first = fmt.Errorf("record %d: %s", i, strings.Join(reasons, ", "))
+		}
+	}
+	return first
+}
+
+// apply validates an entry under the drop or coerce policy. It returns the
+// timestamp and message to store, and false if the entry must be skipped.
+func (v *Validator) apply(entry *blazelogv1.LogEntry, ts, now time.Time) (time.Time, string, bool) {
+	message := entry.Message
+	reasons := v.violations(entry, ts, now)
+	if len(reasons) == 0 {
+		return ts, message, true
+	}
+
+	if v.policy == ValidationCoerce {
+		fixed := true
+		for _, reason := range reasons {
+			switch {
+			case reason == reasonZeroTimestamp || reason == reasonFutureTimestamp || reason == reasonOldTimestamp:
+				ts = now
+			case reason == reasonEmptyMessage && strings.TrimSpace(entry.Raw) != "":
+				message = entry.Raw
+			default:
+				fixed = false
+			}
+		}
+		if fixed {
+			for _, reason := range reasons {
+				metrics.IngestInvalidTotal.WithLabelValues(reason, "coerced").Inc()
+			}
+			return ts, message, true
+		}
+	}
+
+	for _, reason := range reasons {
+		metrics.IngestInvalidTotal.WithLabelValues(reason, "dropped").Inc()
+	}
+	return ts, message, false
+}
+
+// entryTimestamp returns the entry's timestamp, or the zero time if unset.
+func entryTimestamp(entry *blazelogv1.LogEntry) time.Time {
+	if entry.Timestamp == nil {
+		return time.Time{}
+	}
+	return entry.Timestamp.AsTime()
+}
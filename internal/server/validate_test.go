@@ -0,0 +1,148 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	blazelogv1 "github.com/good-yellow-bee/blazelog/internal/proto/blazelog/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestValidator_Violations(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	v := NewValidator(ValidationConfig{
+		MaxFutureSkew: time.Hour,
+		MaxAge:        24 * time.Hour,
+		Levels:        []string{"warning", "error"},
+	})
+
+	tests := []struct {
+		name  string
+		entry *blazelogv1.LogEntry
+		want  []string
+	}{
+		{
+			name:  "valid",
+			entry: &blazelogv1.LogEntry{Message: "ok", Level: blazelogv1.LogLevel_LOG_LEVEL_ERROR, Timestamp: timestamppb.New(now)},
+		},
+		{
+			name:  "empty message",
+			entry: &blazelogv1.LogEntry{Message: "  ", Level: blazelogv1.LogLevel_LOG_LEVEL_ERROR, Timestamp: timestamppb.New(now)},
+			want:  []string{reasonEmptyMessage},
+		},
+		{
+			name:  "missing timestamp",
+			entry: &blazelogv1.LogEntry{Message: "ok", Level: blazelogv1.LogLevel_LOG_LEVEL_ERROR},
+			want:  []string{reasonZeroTimestamp},
+		},
+		{
+			name:  "epoch timestamp",
+			entry: &blazelogv1.LogEntry{Message: "ok", Level: blazelogv1.LogLevel_LOG_LEVEL_ERROR, Timestamp: &timestamppb.Timestamp{}},
+			want:  []string{reasonZeroTimestamp},
+		},
+		{
+			name:  "future timestamp",
+			entry: &blazelogv1.LogEntry{Message: "ok", Level: blazelogv1.LogLevel_LOG_LEVEL_ERROR, Timestamp: timestamppb.New(now.Add(2 * time.Hour))},
+			want:  []string{reasonFutureTimestamp},
+		},
+		{
+			name:  "old timestamp",
+			entry: &blazelogv1.LogEntry{Message: "ok", Level: blazelogv1.LogLevel_LOG_LEVEL_ERROR, Timestamp: timestamppb.New(time.Unix(5, 0))},
+			want:  []string{reasonOldTimestamp},
+		},
+		{
+			name:  "level not accepted",
+			entry: &blazelogv1.LogEntry{Message: "ok", Level: blazelogv1.LogLevel_LOG_LEVEL_UNSPECIFIED, Timestamp: timestamppb.New(now)},
+			want:  []string{reasonUnknownLevel},
+		},
+		{
+			name:  "several",
+			entry: &blazelogv1.LogEntry{Level: blazelogv1.LogLevel_LOG_LEVEL_INFO},
+			want:  []string{reasonEmptyMessage, reasonZeroTimestamp, reasonUnknownLevel},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := v.violations(tt.entry, entryTimestamp(tt.entry), now)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("violations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessor_Validation(t *testing.T) {
+	future := timestamppb.New(time.Now().Add(48 * time.Hour))
+	old := timestamppb.New(time.Now().Add(-30 * 24 * time.Hour))
+	batch := func() *blazelogv1.LogBatch {
+		return &blazelogv1.LogBatch{
+			AgentId: "agent-1",
+			Entries: []*blazelogv1.LogEntry{
+				{Timestamp: timestamppb.Now(), Message: "good", Level: blazelogv1.LogLevel_LOG_LEVEL_INFO},
+				{Timestamp: future, Message: "from the future", Level: blazelogv1.LogLevel_LOG_LEVEL_INFO},
+				{Timestamp: old, Message: "from the past", Level: blazelogv1.LogLevel_LOG_LEVEL_INFO},
+				{Timestamp: timestamppb.Now(), Raw: "raw line", Level: blazelogv1.LogLevel_LOG_LEVEL_INFO},
+				{Timestamp: timestamppb.Now(), Level: blazelogv1.LogLevel_LOG_LEVEL_INFO},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		policy   string
+		wantErr  bool
+		messages []string
+	}{
+		{"drop", ValidationDrop, false, []string{"good"}},
+		{"coerce", ValidationCoerce, false, []string{"good", "from the future", "from the past", "raw line"}},
+		{"reject", ValidationReject, true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &recordingLogBuffer{}
+			processor := NewProcessor(false, buf)
+			processor.SetValidator(NewValidator(ValidationConfig{Policy: tt.policy, MaxFutureSkew: time.Hour, MaxAge: 24 * time.Hour}))
+
+			err := processor.ProcessBatch(batch())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProcessBatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var got []string
+			for _, r := range buf.records {
+				got = append(got, r.Message)
+				if r.Timestamp.After(time.Now().Add(time.Hour)) {
+					t.Errorf("stored future timestamp %v for %q", r.Timestamp, r.Message)
+				}
+				if r.Timestamp.Before(time.Now().Add(-24 * time.Hour)) {
+					t.Errorf("stored old timestamp %v for %q", r.Timestamp, r.Message)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.messages, ",") {
+				t.Errorf("stored %q, want %q", got, tt.messages)
+			}
+		})
+	}
+}
+
+func TestProcessor_NoValidationByDefault(t *testing.T) {
+	buf := &recordingLogBuffer{}
+	processor := NewProcessor(false, buf)
+
+	err := processor.ProcessBatch(&blazelogv1.LogBatch{
+		AgentId: "agent-1",
+		Entries: []*blazelogv1.LogEntry{{}, {Message: "no timestamp"}},
+	})
+	if err != nil {
+		t.Fatalf("ProcessBatch: %v", err)
+	}
+	if len(buf.records) != 2 {
+		t.Fatalf("stored %d records, want 2", len(buf.records))
+	}
+	if buf.records[1].Timestamp.IsZero() {
+		t.Error("missing timestamp was not set to the receive time")
+	}
+}
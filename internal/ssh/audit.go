@@ -49,6 +49,8 @@ type AuditEvent struct {
 	Error       string `json:"error,omitempty"`
 	IsNew       *bool  `json:"is_new,omitempty"`
 	Stored      *bool  `json:"stored,omitempty"`
+	Agent       string `json:"agent,omitempty"`
+	Source      string `json:"source,omitempty"`
 }
 
 // JSONAuditLogger writes audit events as JSON lines.
@@ -173,6 +175,32 @@ func (l *JSONAuditLogger) LogFileOp(host, op, path string, bytes int64, err erro
 	l.log(event)
 }
 
+// LogRawTailStart logs a user starting a raw tail of an agent's source.
+func (l *JSONAuditLogger) LogRawTailStart(user, agentID, source string) {
+	l.log(&AuditEvent{
+		Event:  "raw_tail_start",
+		User:   user,
+		Agent:  agentID,
+		Source: source,
+	})
+}
+
+// LogRawTailEnd logs the end of a raw tail.
+func (l *JSONAuditLogger) LogRawTailEnd(user, agentID, source string, bytes int64, duration time.Duration, err error) {
+	event := &AuditEvent{
+		Event:      "raw_tail_end",
+		User:       user,
+		Agent:      agentID,
+		Source:     source,
+		Bytes:      bytes,
+		DurationMs: duration.Milliseconds(),
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	l.log(event)
+}
+
 // Close closes the underlying writer.
 func (l *JSONAuditLogger) Close() error {
 	l.mu.Lock()
@@ -190,4 +218,7 @@ func (NopAuditLogger) LogHostKeyRejected(host, expected, actual string)
 func (NopAuditLogger) LogHostKeyWarning(host, fingerprint string, stored bool)           {}
 func (NopAuditLogger) LogCommand(host, cmd string, success bool, duration time.Duration) {}
 func (NopAuditLogger) LogFileOp(host, op, path string, bytes int64, err error)           {}
-func (NopAuditLogger) Close() error                                                      { return nil }
+func (NopAuditLogger) LogRawTailStart(user, agentID, source string)                      {}
+func (NopAuditLogger) LogRawTailEnd(user, agentID, source string, bytes int64, duration time.Duration, err error) {
+}
+func (NopAuditLogger) Close() error { return nil }
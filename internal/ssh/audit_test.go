@@ -197,6 +197,46 @@ func TestJSONAuditLogger_LogFileOp_WithError(t *testing.T) {
 	}
 }
 
+func TestJSONAuditLogger_LogRawTail(t *testing.T) {
+	buf := newTestWriteCloser()
+	logger := NewJSONAuditLoggerWriter(buf)
+
+	logger.LogRawTailStart("admin", "agent-1", "/var/log/nginx/error.log")
+	logger.LogRawTailEnd("admin", "agent-1", "/var/log/nginx/error.log", 2048, 3*time.Second, io.ErrUnexpectedEOF)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+
+	var start, end AuditEvent
+	if err := json.Unmarshal([]byte(lines[0]), &start); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &end); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+
+	if start.Event != "raw_tail_start" {
+		t.Errorf("event: got %q, want %q", start.Event, "raw_tail_start")
+	}
+	if start.User != "admin" || start.Agent != "agent-1" || start.Source != "/var/log/nginx/error.log" {
+		t.Errorf("start: got user=%q agent=%q source=%q", start.User, start.Agent, start.Source)
+	}
+	if end.Event != "raw_tail_end" {
+		t.Errorf("event: got %q, want %q", end.Event, "raw_tail_end")
+	}
+	if end.Bytes != 2048 {
+		t.Errorf("bytes: got %d, want %d", end.Bytes, 2048)
+	}
+	if end.DurationMs != 3000 {
+		t.Errorf("duration_ms: got %d, want %d", end.DurationMs, 3000)
+	}
+	if end.Error != io.ErrUnexpectedEOF.Error() {
+		t.Errorf("error: got %q, want %q", end.Error, io.ErrUnexpectedEOF.Error())
+	}
+}
+
 func TestJSONAuditLogger_MultipleEvents(t *testing.T) {
 	buf := newTestWriteCloser()
 	logger := NewJSONAuditLoggerWriter(buf)
@@ -246,6 +286,8 @@ func TestNopAuditLogger(t *testing.T) {
 	logger.LogHostKeyRejected("host:22", "a", "b")
 	logger.LogCommand("host:22", "cmd", true, time.Second)
 	logger.LogFileOp("host:22", "read", "/path", 100, nil)
+	logger.LogRawTailStart("user", "agent-1", "/path")
+	logger.LogRawTailEnd("user", "agent-1", "/path", 100, time.Second, nil)
 
 	if err := logger.Close(); err != nil {
 		t.Errorf("Close should return nil, got %v", err)
@@ -5,12 +5,15 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
 	"github.com/google/uuid"
+
+	"github.com/good-yellow-bee/blazelog/internal/metrics"
 )
 
 // ClickHouseConfig holds ClickHouse connection settings.
@@ -41,13 +44,73 @@ type ClickHouseConfig struct {
 
 	// RetentionDays is the TTL in days for log retention.
 	RetentionDays int
+
+	// RetentionRules keep the logs they match for their own number of
+	// days; the first matching rule wins. Migrate sets them in the table
+	// TTL, and DeleteExpired deletes by them.
+	RetentionRules []RetentionRule
+
+	// PoolStatsInterval is how often connection pool stats are exported
+	// as metrics. Zero disables sampling.
+	PoolStatsInterval time.Duration
+
+	// PoolWaitWarnThreshold logs a warning when queries spend at least
+	// this long waiting for a connection within one sampling interval.
+	// Zero disables the warning.
+	PoolWaitWarnThreshold time.Duration
+
+	// SchemaRetries is how many more times Migrate tries to create indexes
+	// and materialized views found missing. Zero uses the default (3);
+	// negative disables retries.
+	SchemaRetries int
+
+	// SchemaRetryDelay is the wait between schema retries (default: 2s).
+	SchemaRetryDelay time.Duration
+
+	// Engine is the logs table engine: EngineMergeTree (default) or
+	// EngineReplacingMergeTree. It only takes effect when Migrate creates
+	// the table; an existing table keeps its engine.
+	Engine string
+
+	// IndexHints are extra data skipping indexes Migrate adds to the logs
+	// table, alongside the built-in ones.
+	IndexHints []IndexHint
+
+	// Cluster is the ClickHouse cluster to create the schema on. When set,
+	// Migrate issues DDL ON CLUSTER, stores rows in a Replicated engine
+	// table (logs_local) on every host and creates logs as a Distributed
+	// table over it. Empty (default) keeps a single-node logs table.
+	Cluster string
+
+	// AsyncInsert makes InsertBatch use ClickHouse asynchronous inserts:
+	// the server buffers small batches and writes them as one part. Each
+	// insert still waits for the buffer to be flushed before returning.
+	AsyncInsert bool
+
+	// AsyncInsertBusyTimeout is the longest ClickHouse buffers async
+	// inserts before writing them. Zero uses the server setting.
+	AsyncInsertBusyTimeout time.Duration
 }
 
+// Logs table engines.
+const (
+	// EngineMergeTree keeps every inserted row.
+	EngineMergeTree = "MergeTree"
+
+	// EngineReplacingMergeTree collapses rows with the same sort key
+	// (project, agent, type, level, timestamp and id), keeping the last
+	// inserted. Collapsing happens in background merges, so reads apply
+	// FINAL to hide duplicates that are not merged yet.
+	EngineReplacingMergeTree = "ReplacingMergeTree"
+)
+
 // ClickHouseStorage implements LogStorage for ClickHouse.
 type ClickHouseStorage struct {
 	config *ClickHouseConfig
+	tables clickhouseTables
 	db     *sql.DB
 	logs   *clickhouseLogRepo
+	pool   *poolSampler
 }
 
 // NewClickHouseStorage creates a new ClickHouse storage.
@@ -65,8 +128,17 @@ func NewClickHouseStorage(config *ClickHouseConfig) *ClickHouseStorage {
 	if config.RetentionDays == 0 {
 		config.RetentionDays = 30
 	}
+	if config.SchemaRetries == 0 {
+		config.SchemaRetries = defaultSchemaRetries
+	}
+	if config.SchemaRetryDelay <= 0 {
+		config.SchemaRetryDelay = defaultSchemaRetryDelay
+	}
+	if config.Engine == "" {
+		config.Engine = EngineMergeTree
+	}
 
-	return &ClickHouseStorage{config: config}
+	return &ClickHouseStorage{config: config, tables: clickhouseTables{cluster: config.Cluster}}
 }
 
 // Open initializes the ClickHouse connection.
@@ -89,6 +161,11 @@ func (s *ClickHouseStorage) Open() error {
 		}
 	}
 
+	if s.config.Engine == EngineReplacingMergeTree {
+		// Dedup on read: FINAL on every table that supports it
+		opts.Settings = clickhouse.Settings{"final": 1}
+	}
+
 	db := clickhouse.OpenDB(opts)
 
 	// Test connection
@@ -101,7 +178,17 @@ func (s *ClickHouseStorage) Open() error {
 	}
 
 	s.db = db
-	s.logs = &clickhouseLogRepo{db: db}
+	s.logs = &clickhouseLogRepo{
+		db:             db,
+		tables:         s.tables,
+		insertSettings: s.config.insertSettings(),
+		retentionDays:  retentionDays(s.config.RetentionRules, s.config.RetentionDays),
+	}
+
+	if s.config.PoolStatsInterval > 0 {
+		s.pool = newPoolSampler("clickhouse", db.Stats, s.config.PoolStatsInterval, s.config.PoolWaitWarnThreshold)
+		s.pool.start()
+	}
 	return nil
 }
 
@@ -110,17 +197,34 @@ func (s *ClickHouseStorage) Close() error {
 	if s.db == nil {
 		return nil
 	}
+	if s.pool != nil {
+		s.pool.stop()
+		s.pool = nil
+	}
 	return s.db.Close()
 }
 
-// Migrate creates the logs table if it doesn't exist.
+// Migrate creates the logs table if it doesn't exist. With a cluster
+// configured it also creates the Distributed table in front of it.
 func (s *ClickHouseStorage) Migrate() error {
+	if err := ValidateClusterName(s.config.Cluster); err != nil {
+		return err
+	}
+	hints, err := indexHintObjects(s.config.IndexHints)
+	if err != nil {
+		return err
+	}
+	if err := validateRetentionRules(s.config.RetentionRules); err != nil {
+		return err
+	}
+	ttl := retentionTTL(s.config.RetentionRules, s.config.RetentionDays)
+
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	// Create logs table
 	createTable := fmt.Sprintf(`
-		CREATE TABLE IF NOT EXISTS logs (
+		CREATE TABLE IF NOT EXISTS %s%s (
 			id UUID DEFAULT generateUUIDv4(),
 			project_id String DEFAULT '',
 			timestamp DateTime64(3, 'UTC'),
@@ -139,100 +243,61 @@ func (s *ClickHouseStorage) Migrate() error {
 			uri String DEFAULT '',
 			_date Date DEFAULT toDate(timestamp)
 		)
-		ENGINE = MergeTree()
+		ENGINE = %s
 		PARTITION BY toYYYYMM(_date)
 		ORDER BY (project_id, agent_id, type, level, timestamp, id)
-		TTL _date + INTERVAL %d DAY DELETE
+		TTL %s
 		SETTINGS index_granularity = 8192
-	`, s.config.RetentionDays)
+	`, s.tables.local(), s.tables.onCluster(), s.tables.engine(s.config.Engine), ttl)
 
 	if _, err := s.db.ExecContext(ctx, createTable); err != nil {
 		return fmt.Errorf("create logs table: %w", err)
 	}
 
+	// The engine is fixed at creation; changing it means recreating the table
+	var engine string
+	wantEngine := s.config.Engine
+	if s.tables.cluster != "" {
+		wantEngine = "Replicated" + wantEngine
+	}
+	if err := s.db.QueryRowContext(ctx, "SELECT engine FROM system.tables WHERE database = currentDatabase() AND name = ?", s.tables.local()).Scan(&engine); err != nil {
+		slog.Warn("clickhouse logs table engine check failed", "error", err)
+	} else if engine != wantEngine {
+		slog.Warn("clickhouse logs table engine differs from config; recreate the table to change it",
+			"engine", engine, "configured", wantEngine)
+	}
+
 	// Migration: Add project_id column to existing tables (before indexes that depend on it)
 	migrations := []string{
-		"ALTER TABLE logs ADD COLUMN IF NOT EXISTS project_id String DEFAULT '' AFTER id",
+		"ALTER TABLE {logs}{on_cluster} ADD COLUMN IF NOT EXISTS project_id String DEFAULT '' AFTER id",
 	}
 	for _, migration := range migrations {
-		if _, err := s.db.ExecContext(ctx, migration); err != nil {
-			fmt.Printf("warning: migration failed (may already exist): %v\n", err)
+		if _, err := s.db.ExecContext(ctx, s.tables.expand(migration)); err != nil {
+			slog.Warn("clickhouse migration failed (may already exist)", "error", err)
 		}
 	}
 
-	// Add indexes (these are idempotent in ClickHouse)
-	indexes := []string{
-		"ALTER TABLE logs ADD INDEX IF NOT EXISTS idx_message message TYPE tokenbf_v1(32768, 3, 0) GRANULARITY 4",
-		"ALTER TABLE logs ADD INDEX IF NOT EXISTS idx_source source TYPE bloom_filter(0.01) GRANULARITY 4",
-		"ALTER TABLE logs ADD INDEX IF NOT EXISTS idx_file_path file_path TYPE bloom_filter(0.01) GRANULARITY 4",
-		// Project index for multi-tenant filtering
-		"ALTER TABLE logs ADD INDEX IF NOT EXISTS idx_project_id project_id TYPE bloom_filter(0.01) GRANULARITY 4",
-		// Advanced indexes (Milestone 21)
-		"ALTER TABLE logs ADD INDEX IF NOT EXISTS idx_message_ngram message TYPE ngrambf_v1(3, 65536, 3, 0) GRANULARITY 4",
-		"ALTER TABLE logs ADD INDEX IF NOT EXISTS idx_timestamp_minmax timestamp TYPE minmax GRANULARITY 3",
-		"ALTER TABLE logs ADD INDEX IF NOT EXISTS idx_http_status http_status TYPE set(100) GRANULARITY 4",
+	// Bring an existing table's TTL up to date with the retention config.
+	// Without materializing, this only changes metadata: parts written
+	// before pick up the new TTL as they merge, and DeleteExpired purges them.
+	ttlCtx := clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{"materialize_ttl_after_modify": 0}))
+	if _, err := s.db.ExecContext(ttlCtx, s.tables.expand("ALTER TABLE {logs}{on_cluster} MODIFY TTL "+ttl)); err != nil {
+		slog.Warn("clickhouse logs table TTL update failed", "error", err)
 	}
 
-	for _, idx := range indexes {
-		if _, err := s.db.ExecContext(ctx, idx); err != nil {
-			// Log warning but don't fail - index creation may not be supported in all ClickHouse versions
-			fmt.Printf("warning: failed to create index: %v\n", err)
+	// Distributed table after the migrations, so it copies their columns
+	if ddl := s.tables.distributedDDL(); ddl != "" {
+		if _, err := s.db.ExecContext(ctx, ddl); err != nil {
+			return fmt.Errorf("create distributed logs table: %w", err)
 		}
 	}
 
-	// Create materialized views for dashboards (Milestone 21)
-	materializedViews := []string{
-		// Hourly error counts for error rate dashboards
-		`CREATE MATERIALIZED VIEW IF NOT EXISTS logs_hourly_errors_mv
-		ENGINE = SummingMergeTree()
-		PARTITION BY toYYYYMM(hour)
-		ORDER BY (agent_id, type, level, hour)
-		AS SELECT
-			agent_id,
-			type,
-			level,
-			toStartOfHour(timestamp) AS hour,
-			count() AS count
-		FROM logs
-		WHERE level IN ('error', 'fatal', 'warning')
-		GROUP BY agent_id, type, level, hour`,
-
-		// Daily log volume for capacity planning
-		`CREATE MATERIALIZED VIEW IF NOT EXISTS logs_daily_volume_mv
-		ENGINE = SummingMergeTree()
-		PARTITION BY toYYYYMM(day)
-		ORDER BY (agent_id, type, day)
-		AS SELECT
-			agent_id,
-			type,
-			toDate(timestamp) AS day,
-			count() AS total_count,
-			countIf(level = 'error') AS error_count,
-			countIf(level = 'fatal') AS fatal_count,
-			countIf(level = 'warning') AS warning_count
-		FROM logs
-		GROUP BY agent_id, type, day`,
-
-		// HTTP status distribution for web server monitoring
-		`CREATE MATERIALIZED VIEW IF NOT EXISTS logs_http_stats_mv
-		ENGINE = SummingMergeTree()
-		PARTITION BY toYYYYMM(hour)
-		ORDER BY (agent_id, hour, http_status)
-		AS SELECT
-			agent_id,
-			toStartOfHour(timestamp) AS hour,
-			http_status,
-			count() AS count
-		FROM logs
-		WHERE http_status > 0
-		GROUP BY agent_id, hour, http_status`,
-	}
+	// Indexes and materialized views; failures are retried, not fatal
+	s.ensureSchemaObjects(context.Background(), hints)
 
-	for _, mv := range materializedViews {
-		if _, err := s.db.ExecContext(ctx, mv); err != nil {
-			fmt.Printf("warning: failed to create materialized view: %v\n", err)
-		}
-	}
+	rollupCtx, rollupCancel := context.WithTimeout(context.Background(), schemaOpTimeout)
+	defer rollupCancel()
+	s.loadRollup(rollupCtx)
 
 	return nil
 }
@@ -247,9 +312,29 @@ func (s *ClickHouseStorage) Logs() LogRepository {
 	return s.logs
 }
 
+// insertSettings returns the query settings for log inserts, or nil when
+// none apply.
+func (c *ClickHouseConfig) insertSettings() clickhouse.Settings {
+	if !c.AsyncInsert {
+		return nil
+	}
+	settings := clickhouse.Settings{
+		"async_insert":          1,
+		"wait_for_async_insert": 1,
+	}
+	if c.AsyncInsertBusyTimeout > 0 {
+		settings["async_insert_busy_timeout_ms"] = c.AsyncInsertBusyTimeout.Milliseconds()
+	}
+	return settings
+}
+
 // clickhouseLogRepo implements LogRepository for ClickHouse.
 type clickhouseLogRepo struct {
-	db *sql.DB
+	db             *sql.DB
+	tables         clickhouseTables
+	insertSettings clickhouse.Settings
+	retentionDays  string // expression giving each log's retention in days
+	rollup         clickhouseRollup
 }
 
 // InsertBatch inserts multiple log entries using batch insert.
@@ -258,6 +343,10 @@ func (r *clickhouseLogRepo) InsertBatch(ctx context.Context, entries []*LogRecor
 		return nil
 	}
 
+	if r.insertSettings != nil {
+		ctx = clickhouse.Context(ctx, clickhouse.WithSettings(r.insertSettings))
+	}
+
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("begin tx: %w", err)
@@ -289,12 +378,12 @@ func (r *clickhouseLogRepo) InsertBatch(ctx context.Context, entries []*LogRecor
 
 		fieldsJSON, err := json.Marshal(entry.Fields)
 		if err != nil {
-			log.Printf("warning: failed to marshal fields for log entry: %v", err)
+			slog.Warn("failed to marshal fields for log entry", "error", err)
 			fieldsJSON = []byte("{}")
 		}
 		labelsJSON, err := json.Marshal(entry.Labels)
 		if err != nil {
-			log.Printf("warning: failed to marshal labels for log entry: %v", err)
+			slog.Warn("failed to marshal labels for log entry", "error", err)
 			labelsJSON = []byte("{}")
 		}
 
@@ -328,10 +417,16 @@ func (r *clickhouseLogRepo) InsertBatch(ctx context.Context, entries []*LogRecor
 	return nil
 }
 
+// observeQuery records the latency of a read query started at start.
+func observeQuery(operation string, start time.Time) {
+	metrics.StorageQueryDuration.WithLabelValues(operation, "clickhouse").Observe(time.Since(start).Seconds())
+}
+
 // Query retrieves logs matching the filter.
 // Uses limit+1 optimization to determine HasMore without a separate COUNT query.
 // Only computes Total when on first page (offset=0) for pagination UI.
 func (r *clickhouseLogRepo) Query(ctx context.Context, filter *LogFilter) (*LogQueryResult, error) {
+	defer observeQuery("query", time.Now())
 	// Use local copy to avoid mutating input filter
 	// Fetch limit+1 to efficiently detect if there are more results
 	queryFilter := *filter
@@ -347,48 +442,10 @@ func (r *clickhouseLogRepo) Query(ctx context.Context, filter *LogFilter) (*LogQ
 	}
 	defer rows.Close()
 
-	var entries []*LogRecord
-	for rows.Next() {
-		entry := &LogRecord{}
-		var fieldsJSON, labelsJSON string
-
-		err := rows.Scan(
-			&entry.ID,
-			&entry.ProjectID,
-			&entry.Timestamp,
-			&entry.Level,
-			&entry.Message,
-			&entry.Source,
-			&entry.Type,
-			&entry.Raw,
-			&entry.AgentID,
-			&entry.FilePath,
-			&entry.LineNumber,
-			&fieldsJSON,
-			&labelsJSON,
-			&entry.HTTPStatus,
-			&entry.HTTPMethod,
-			&entry.URI,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("scan: %w", err)
-		}
-
-		// Parse JSON fields
-		if fieldsJSON != "" {
-			if err := json.Unmarshal([]byte(fieldsJSON), &entry.Fields); err != nil {
-				log.Printf("warning: failed to unmarshal fields for log entry %s: %v", entry.ID, err)
-			}
-		}
-		if labelsJSON != "" {
-			if err := json.Unmarshal([]byte(labelsJSON), &entry.Labels); err != nil {
-				log.Printf("warning: failed to unmarshal labels for log entry %s: %v", entry.ID, err)
-			}
-		}
-
-		entries = append(entries, entry)
+	entries, err := r.scanLogColumns(rows, filter.projection())
+	if err != nil {
+		return nil, err
 	}
-
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("rows: %w", err)
 	}
@@ -402,7 +459,7 @@ func (r *clickhouseLogRepo) Query(ctx context.Context, filter *LogFilter) (*LogQ
 
 	// Compute Total for accurate pagination
 	var total int64
-	if hasMore {
+	if hasMore && !filter.SkipTotal {
 		// Always get actual count for accurate pagination
 		total, err = r.Count(ctx, filter)
 		if err != nil {
@@ -422,6 +479,7 @@ func (r *clickhouseLogRepo) Query(ctx context.Context, filter *LogFilter) (*LogQ
 
 // Count returns the count of logs matching the filter.
 func (r *clickhouseLogRepo) Count(ctx context.Context, filter *LogFilter) (int64, error) {
+	defer observeQuery("count", time.Now())
 	query, args := r.buildQuery(filter, true)
 
 	var count int64
@@ -441,9 +499,13 @@ func (r *clickhouseLogRepo) DeleteBefore(ctx context.Context, before time.Time)
 	if err != nil {
 		return 0, fmt.Errorf("count: %w", err)
 	}
+	if count == 0 {
+		// Nothing to delete; don't queue a mutation that rewrites no rows
+		return 0, nil
+	}
 
-	// Delete using ALTER TABLE DELETE (async in ClickHouse)
-	_, err = r.db.ExecContext(ctx, "ALTER TABLE logs DELETE WHERE timestamp < ?", before)
+	// Delete using ALTER TABLE DELETE (async in ClickHouse, see Mutations)
+	_, err = r.db.ExecContext(ctx, r.tables.expand("ALTER TABLE {logs}{on_cluster} DELETE WHERE timestamp < ?"), before)
 	if err != nil {
 		return 0, fmt.Errorf("delete: %w", err)
 	}
@@ -451,6 +513,41 @@ func (r *clickhouseLogRepo) DeleteBefore(ctx context.Context, before time.Time)
 	return count, nil
 }
 
+// DeleteExpired removes logs past their retention, by the retention rules
+// or else RetentionDays. Like DeleteBefore, the delete runs asynchronously.
+func (r *clickhouseLogRepo) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	expired := fmt.Sprintf("timestamp < ? - toIntervalDay(%s)", r.retentionDays)
+
+	var count int64
+	if err := r.db.QueryRowContext(ctx, "SELECT count() FROM logs WHERE "+expired, now).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count: %w", err)
+	}
+	if count == 0 {
+		return 0, nil
+	}
+
+	if _, err := r.db.ExecContext(ctx, r.tables.expand("ALTER TABLE {logs}{on_cluster} DELETE WHERE ")+expired, now); err != nil {
+		return 0, fmt.Errorf("delete: %w", err)
+	}
+	return count, nil
+}
+
+// Mutations returns the progress of the unfinished mutations, such as
+// DeleteBefore's, on the logs table, across all replicas in cluster mode.
+func (r *clickhouseLogRepo) Mutations(ctx context.Context) (*MutationStatus, error) {
+	query := fmt.Sprintf(`
+		SELECT count(), sum(parts_to_do), anyIf(latest_fail_reason, latest_fail_reason != '')
+		FROM %s
+		WHERE database = currentDatabase() AND table = ? AND NOT is_done
+	`, r.tables.system("mutations"))
+
+	status := &MutationStatus{}
+	if err := r.db.QueryRowContext(ctx, query, r.tables.local()).Scan(&status.Pending, &status.PartsToDo, &status.FailReason); err != nil {
+		return nil, fmt.Errorf("get mutations: %w", err)
+	}
+	return status, nil
+}
+
 // buildQuery constructs the SQL query based on filter.
 func (r *clickhouseLogRepo) buildQuery(filter *LogFilter, countOnly bool) (string, []interface{}) {
 	var sb strings.Builder
@@ -460,12 +557,7 @@ func (r *clickhouseLogRepo) buildQuery(filter *LogFilter, countOnly bool) (strin
 	if countOnly {
 		sb.WriteString("SELECT count() FROM logs")
 	} else {
-		sb.WriteString(`
-			SELECT id, project_id, timestamp, level, message, source, type, raw,
-			       agent_id, file_path, line_number, fields, labels,
-			       http_status, http_method, uri
-			FROM logs
-		`)
+		sb.WriteString("SELECT " + strings.Join(filter.projection(), ", ") + " FROM logs")
 	}
 
 	// Build PREWHERE clause for indexed columns (timestamp optimization)
@@ -489,6 +581,16 @@ func (r *clickhouseLogRepo) buildQuery(filter *LogFilter, countOnly bool) (strin
 		args = append(args, projectArgs...)
 	}
 
+	// Keyset cursor, in the direction of the ORDER BY below
+	if filter.AfterID != "" {
+		op := "<"
+		if filter.ascending() {
+			op = ">"
+		}
+		conditions = append(conditions, fmt.Sprintf("(timestamp %s ? OR (timestamp = ? AND id %s ?))", op, op))
+		args = append(args, filter.AfterTime, filter.AfterTime, filter.AfterID)
+	}
+
 	// DSL filter takes precedence if set
 	if filter.FilterSQL != "" {
 		conditions = append(conditions, "("+filter.FilterSQL+")")
@@ -581,28 +683,17 @@ func (r *clickhouseLogRepo) buildQuery(filter *LogFilter, countOnly bool) (strin
 		return sb.String(), prewhereArgs
 	}
 
-	// ORDER BY - use allowlist to prevent SQL injection
-	// Map of allowed sort fields to their actual column names
-	allowedOrderColumns := map[string]string{
-		"timestamp":   "timestamp",
-		"level":       "level",
-		"source":      "source",
-		"type":        "type",
-		"agent_id":    "agent_id",
-		"http_status": "http_status",
-	}
-	orderBy := "timestamp" // default
-	if filter.OrderBy != "" {
-		if col, ok := allowedOrderColumns[filter.OrderBy]; ok {
-			orderBy = col
-		}
-		// If not in allowlist, use default (timestamp)
-	}
+	// ORDER BY - allowlisted column only, to prevent SQL injection
+	orderBy := filter.orderColumn()
 	orderDir := "DESC"
-	if !filter.OrderDesc && filter.OrderBy != "" {
+	if filter.ascending() {
 		orderDir = "ASC"
 	}
 	sb.WriteString(fmt.Sprintf(" ORDER BY %s %s", orderBy, orderDir))
+	if orderBy == "timestamp" {
+		// Ties broken by id, so pages and cursors are stable
+		sb.WriteString(", id " + orderDir)
+	}
 
 	// LIMIT and OFFSET
 	limit := filter.Limit
@@ -619,18 +710,8 @@ func (r *clickhouseLogRepo) buildQuery(filter *LogFilter, countOnly bool) (strin
 
 // GetErrorRates returns error statistics for the given filter.
 func (r *clickhouseLogRepo) GetErrorRates(ctx context.Context, filter *AggregationFilter) (*ErrorRateResult, error) {
-	query := `
-		SELECT
-			count() AS total,
-			countIf(level = 'error') AS errors,
-			countIf(level = 'warning') AS warnings,
-			countIf(level = 'fatal') AS fatals
-		FROM logs
-	`
-	args, whereClause := r.buildAggregationWhere(filter)
-	if whereClause != "" {
-		query += " WHERE " + whereClause
-	}
+	defer observeQuery("error_rates", time.Now())
+	query, args := r.errorRatesQuery(filter, time.Now())
 
 	result := &ErrorRateResult{}
 	err := r.db.QueryRowContext(ctx, query, args...).Scan(
@@ -652,6 +733,7 @@ func (r *clickhouseLogRepo) GetErrorRates(ctx context.Context, filter *Aggregati
 
 // GetTopSources returns the top sources by log count.
 func (r *clickhouseLogRepo) GetTopSources(ctx context.Context, filter *AggregationFilter, limit int) ([]*SourceCount, error) {
+	defer observeQuery("top_sources", time.Now())
 	if limit <= 0 {
 		limit = 10
 	}
@@ -687,32 +769,23 @@ func (r *clickhouseLogRepo) GetTopSources(ctx context.Context, filter *Aggregati
 	return results, rows.Err()
 }
 
-// GetLogVolume returns time-series log volume data.
-func (r *clickhouseLogRepo) GetLogVolume(ctx context.Context, filter *AggregationFilter, interval string) ([]*VolumePoint, error) {
-	// Determine time function based on interval
-	var timeFunc string
+// intervalStart returns the expression truncating timestamp to the start
+// of its interval ("minute", "hour" or "day"; default hour).
+func intervalStart(interval string) string {
 	switch interval {
 	case "minute":
-		timeFunc = "toStartOfMinute(timestamp)"
+		return "toStartOfMinute(timestamp)"
 	case "day":
-		timeFunc = "toStartOfDay(timestamp)"
+		return "toStartOfDay(timestamp)"
 	default: // hour
-		timeFunc = "toStartOfHour(timestamp)"
+		return "toStartOfHour(timestamp)"
 	}
+}
 
-	query := fmt.Sprintf(`
-		SELECT
-			%s AS ts,
-			count() AS total,
-			countIf(level IN ('error', 'fatal')) AS errors
-		FROM logs
-	`, timeFunc)
-
-	args, whereClause := r.buildAggregationWhere(filter)
-	if whereClause != "" {
-		query += " WHERE " + whereClause
-	}
-	query += " GROUP BY ts ORDER BY ts ASC"
+// GetLogVolume returns time-series log volume data.
+func (r *clickhouseLogRepo) GetLogVolume(ctx context.Context, filter *AggregationFilter, interval string) ([]*VolumePoint, error) {
+	defer observeQuery("volume", time.Now())
+	query, args := r.logVolumeQuery(filter, interval, time.Now())
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -734,6 +807,7 @@ func (r *clickhouseLogRepo) GetLogVolume(ctx context.Context, filter *Aggregatio
 
 // GetHTTPStats returns HTTP status code distribution.
 func (r *clickhouseLogRepo) GetHTTPStats(ctx context.Context, filter *AggregationFilter) (*HTTPStatsResult, error) {
+	defer observeQuery("http_stats", time.Now())
 	query := `
 		SELECT
 			countIf(http_status >= 200 AND http_status < 300) AS total_2xx,
@@ -789,6 +863,181 @@ func (r *clickhouseLogRepo) GetHTTPStats(ctx context.Context, filter *Aggregatio
 	return result, rows.Err()
 }
 
+// numericField reads the Fields entry named by a query parameter as a
+// number, or NULL. Numbers stored as JSON strings ("0.25") count too.
+const numericField = `toFloat64OrNull(trim(BOTH '"' FROM JSONExtractRaw(fields, ?)))`
+
+// GetFieldPercentile returns a percentile of a numeric entry in Fields.
+// Values stored as JSON strings ("0.25") count too; non-numeric ones are
+// ignored.
+func (r *clickhouseLogRepo) GetFieldPercentile(ctx context.Context, filter *AggregationFilter, field string, percentile float64) (*PercentileResult, error) {
+	defer observeQuery("percentile", time.Now())
+	if percentile <= 0 || percentile >= 1 {
+		return nil, fmt.Errorf("percentile must be between 0 and 1, got %g", percentile)
+	}
+
+	// quantile() takes its level as a constant, not a parameter
+	query := fmt.Sprintf(`
+		SELECT count(v), quantile(%g)(v)
+		FROM (
+			SELECT %s AS v
+			FROM logs
+	`, percentile, numericField)
+	whereArgs, whereClause := r.buildAggregationWhere(filter)
+	if whereClause != "" {
+		query += " WHERE " + whereClause
+	}
+	query += ")"
+	args := append([]interface{}{field}, whereArgs...)
+
+	var value sql.NullFloat64
+	result := &PercentileResult{}
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&result.Samples, &value); err != nil {
+		return nil, fmt.Errorf("get field percentile: %w", err)
+	}
+	if result.Samples > 0 && value.Valid {
+		result.Value = value.Float64
+	}
+	return result, nil
+}
+
+// GetLatencyStats returns the p50/p90/p95/p99 of a numeric entry in
+// Fields, overall and per interval. Values are read as by
+// GetFieldPercentile.
+func (r *clickhouseLogRepo) GetLatencyStats(ctx context.Context, filter *AggregationFilter, field, interval string) (*LatencyStats, error) {
+	defer observeQuery("latency", time.Now())
+	whereArgs, whereClause := r.buildAggregationWhere(filter)
+	if whereClause != "" {
+		whereClause = " WHERE " + whereClause
+	}
+	args := append([]interface{}{field}, whereArgs...)
+
+	// quantiles() computes all levels from one sample, keeping them ordered
+	query := fmt.Sprintf(`
+		SELECT n, q[1], q[2], q[3], q[4]
+		FROM (
+			SELECT count(v) AS n, quantiles(0.5, 0.9, 0.95, 0.99)(v) AS q
+			FROM (SELECT %s AS v FROM logs%s)
+		)
+	`, numericField, whereClause)
+	result := &LatencyStats{}
+	row := r.db.QueryRowContext(ctx, query, args...)
+	if err := scanLatencyQuantiles(row.Scan, &result.LatencyQuantiles); err != nil {
+		return nil, fmt.Errorf("get latency stats: %w", err)
+	}
+	if result.Samples == 0 {
+		return result, nil
+	}
+
+	query = fmt.Sprintf(`
+		SELECT ts, n, q[1], q[2], q[3], q[4]
+		FROM (
+			SELECT ts, count(v) AS n, quantiles(0.5, 0.9, 0.95, 0.99)(v) AS q
+			FROM (SELECT %s AS ts, %s AS v FROM logs%s)
+			GROUP BY ts
+			HAVING n > 0
+		)
+		ORDER BY ts ASC
+	`, intervalStart(interval), numericField, whereClause)
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get latency series: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		lp := &LatencyPoint{}
+		scan := func(dest ...interface{}) error {
+			return rows.Scan(append([]interface{}{&lp.Timestamp}, dest...)...)
+		}
+		if err := scanLatencyQuantiles(scan, &lp.LatencyQuantiles); err != nil {
+			return nil, fmt.Errorf("scan latency point: %w", err)
+		}
+		result.Series = append(result.Series, lp)
+	}
+	return result, rows.Err()
+}
+
+// scanLatencyQuantiles scans a sample count and the four quantiles into q.
+// The quantiles of no samples are NaN or NULL and read as 0.
+func scanLatencyQuantiles(scan func(dest ...interface{}) error, q *LatencyQuantiles) error {
+	var p50, p90, p95, p99 sql.NullFloat64
+	if err := scan(&q.Samples, &p50, &p90, &p95, &p99); err != nil {
+		return err
+	}
+	if q.Samples > 0 {
+		q.P50, q.P90, q.P95, q.P99 = p50.Float64, p90.Float64, p95.Float64, p99.Float64
+	}
+	return nil
+}
+
+// errorMessageMasks are the replacements that normalize error messages
+// into groups, applied in order. They are RE2 patterns, as ClickHouse's
+// replaceRegexpAll takes.
+var errorMessageMasks = []struct {
+	pattern     string
+	replacement string
+}{
+	{`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`, "<uuid>"},
+	{`[\w./\\-]*\.[A-Za-z]\w*(?::\d+|\(\d+\))`, "<file>"}, // Order.php:42, Controller.php(17)
+	{`\d+`, "<n>"},
+}
+
+// normalizedMessage returns the expression normalizing message by
+// errorMessageMasks, and its arguments.
+func normalizedMessage() (string, []interface{}) {
+	expr := "message"
+	var args []interface{}
+	for _, m := range errorMessageMasks {
+		expr = fmt.Sprintf("replaceRegexpAll(%s, ?, ?)", expr)
+		args = append(args, m.pattern, m.replacement)
+	}
+	return expr, args
+}
+
+// GetTopErrors returns the most frequent normalized error and fatal
+// messages.
+func (r *clickhouseLogRepo) GetTopErrors(ctx context.Context, filter *AggregationFilter, limit int) ([]*ErrorGroup, error) {
+	defer observeQuery("top_errors", time.Now())
+	if limit <= 0 {
+		limit = 10
+	}
+
+	pattern, args := normalizedMessage()
+	query := fmt.Sprintf(`
+		SELECT
+			%s AS pattern,
+			count() AS total,
+			any(message) AS example,
+			max(timestamp) AS last_seen
+		FROM logs
+		WHERE level IN ('error', 'fatal')
+	`, pattern)
+	whereArgs, whereClause := r.buildAggregationWhere(filter)
+	if whereClause != "" {
+		query += " AND " + whereClause
+	}
+	args = append(args, whereArgs...)
+	query += fmt.Sprintf(" GROUP BY pattern ORDER BY total DESC LIMIT %d", limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get top errors: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*ErrorGroup
+	for rows.Next() {
+		eg := &ErrorGroup{}
+		if err := rows.Scan(&eg.Pattern, &eg.Count, &eg.Example, &eg.LastSeen); err != nil {
+			return nil, fmt.Errorf("scan error group: %w", err)
+		}
+		results = append(results, eg)
+	}
+
+	return results, rows.Err()
+}
+
 // buildProjectFilter builds the project filter clause for log queries.
 func (r *clickhouseLogRepo) buildProjectFilter(filter *LogFilter) (string, []interface{}) {
 	var conditions []string
@@ -855,6 +1104,12 @@ func (r *clickhouseLogRepo) buildAggregationProjectFilter(filter *AggregationFil
 
 // GetByID retrieves a single log entry by ID.
 func (r *clickhouseLogRepo) GetByID(ctx context.Context, id string) (*LogRecord, error) {
+	defer observeQuery("get", time.Now())
+	// IDs are UUIDs; anything else can't match and would fail to parse
+	if _, err := uuid.Parse(id); err != nil {
+		//nolint:nilnil
+		return nil, nil
+	}
 	query := `
 		SELECT id, project_id, timestamp, level, message, source, type, raw,
 		       agent_id, file_path, line_number, fields, labels,
@@ -896,12 +1151,12 @@ func (r *clickhouseLogRepo) GetByID(ctx context.Context, id string) (*LogRecord,
 	// Parse JSON fields
 	if fieldsJSON != "" {
 		if err := json.Unmarshal([]byte(fieldsJSON), &entry.Fields); err != nil {
-			log.Printf("warning: failed to unmarshal fields for log entry %s: %v", entry.ID, err)
+			slog.Warn("failed to unmarshal fields for log entry", "id", entry.ID, "error", err)
 		}
 	}
 	if labelsJSON != "" {
 		if err := json.Unmarshal([]byte(labelsJSON), &entry.Labels); err != nil {
-			log.Printf("warning: failed to unmarshal labels for log entry %s: %v", entry.ID, err)
+			slog.Warn("failed to unmarshal labels for log entry", "id", entry.ID, "error", err)
 		}
 	}
 
@@ -910,6 +1165,7 @@ func (r *clickhouseLogRepo) GetByID(ctx context.Context, id string) (*LogRecord,
 
 // GetContext retrieves logs surrounding a target log entry.
 func (r *clickhouseLogRepo) GetContext(ctx context.Context, filter *ContextFilter) (*ContextResult, error) {
+	defer observeQuery("context", time.Now())
 	if filter.Before > 50 {
 		filter.Before = 50
 	}
@@ -937,6 +1193,10 @@ func (r *clickhouseLogRepo) GetContext(ctx context.Context, filter *ContextFilte
 	// Build base conditions
 	baseConditions := "project_id = ? AND agent_id = ?"
 	baseArgs := []interface{}{filter.ProjectID, filter.AgentID}
+	if filter.FilePath != "" {
+		baseConditions += " AND file_path = ?"
+		baseArgs = append(baseArgs, filter.FilePath)
+	}
 
 	// Query BEFORE logs (older than anchor)
 	if filter.Before > 0 {
@@ -946,21 +1206,21 @@ func (r *clickhouseLogRepo) GetContext(ctx context.Context, filter *ContextFilte
 			       http_status, http_method, uri
 			FROM logs
 			PREWHERE timestamp >= ? AND timestamp <= ?
-			WHERE %s AND (timestamp < ? OR (timestamp = ? AND id < ?))
-			ORDER BY timestamp DESC, id DESC
+			WHERE %s AND (timestamp < ? OR (timestamp = ? AND (line_number < ? OR (line_number = ? AND id < ?))))
+			ORDER BY timestamp DESC, line_number DESC, id DESC
 			LIMIT ?
 		`, baseConditions)
 
-		cursorTS, cursorID := filter.Timestamp, filter.TargetID
+		cursorTS, cursorLine, cursorID := filter.Timestamp, filter.LineNumber, filter.TargetID
 		if filter.BeforeCursor != "" {
-			parsedTS, parsedID, ok := parseCursor(filter.BeforeCursor)
+			parsedTS, parsedLine, parsedID, ok := parseCursor(filter.BeforeCursor)
 			if ok {
-				cursorTS, cursorID = parsedTS, parsedID
+				cursorTS, cursorLine, cursorID = parsedTS, parsedLine, parsedID
 			}
 		}
 
 		beforeArgs := append([]interface{}{windowStart, filter.Timestamp}, baseArgs...)
-		beforeArgs = append(beforeArgs, cursorTS, cursorTS, cursorID, filter.Before+1)
+		beforeArgs = append(beforeArgs, cursorTS, cursorTS, cursorLine, cursorLine, cursorID, filter.Before+1)
 
 		beforeRows, err := r.db.QueryContext(ctx, beforeQuery, beforeArgs...)
 		if err != nil {
@@ -982,7 +1242,7 @@ func (r *clickhouseLogRepo) GetContext(ctx context.Context, filter *ContextFilte
 		// Set cursor from oldest log in result
 		if len(beforeLogs) > 0 {
 			oldest := beforeLogs[len(beforeLogs)-1]
-			result.BeforeCursor = formatCursor(oldest.Timestamp, oldest.ID)
+			result.BeforeCursor = formatCursor(oldest.Timestamp, oldest.LineNumber, oldest.ID)
 		}
 
 		// Reverse to oldest-first order
@@ -1000,21 +1260,21 @@ func (r *clickhouseLogRepo) GetContext(ctx context.Context, filter *ContextFilte
 			       http_status, http_method, uri
 			FROM logs
 			PREWHERE timestamp >= ? AND timestamp <= ?
-			WHERE %s AND (timestamp > ? OR (timestamp = ? AND id > ?))
-			ORDER BY timestamp ASC, id ASC
+			WHERE %s AND (timestamp > ? OR (timestamp = ? AND (line_number > ? OR (line_number = ? AND id > ?))))
+			ORDER BY timestamp ASC, line_number ASC, id ASC
 			LIMIT ?
 		`, baseConditions)
 
-		cursorTS, cursorID := filter.Timestamp, filter.TargetID
+		cursorTS, cursorLine, cursorID := filter.Timestamp, filter.LineNumber, filter.TargetID
 		if filter.AfterCursor != "" {
-			parsedTS, parsedID, ok := parseCursor(filter.AfterCursor)
+			parsedTS, parsedLine, parsedID, ok := parseCursor(filter.AfterCursor)
 			if ok {
-				cursorTS, cursorID = parsedTS, parsedID
+				cursorTS, cursorLine, cursorID = parsedTS, parsedLine, parsedID
 			}
 		}
 
 		afterArgs := append([]interface{}{filter.Timestamp, windowEnd}, baseArgs...)
-		afterArgs = append(afterArgs, cursorTS, cursorTS, cursorID, filter.After+1)
+		afterArgs = append(afterArgs, cursorTS, cursorTS, cursorLine, cursorLine, cursorID, filter.After+1)
 
 		afterRows, err := r.db.QueryContext(ctx, afterQuery, afterArgs...)
 		if err != nil {
@@ -1036,7 +1296,7 @@ func (r *clickhouseLogRepo) GetContext(ctx context.Context, filter *ContextFilte
 		// Set cursor from newest log in result
 		if len(afterLogs) > 0 {
 			newest := afterLogs[len(afterLogs)-1]
-			result.AfterCursor = formatCursor(newest.Timestamp, newest.ID)
+			result.AfterCursor = formatCursor(newest.Timestamp, newest.LineNumber, newest.ID)
 		}
 
 		result.After = afterLogs
@@ -1047,67 +1307,72 @@ func (r *clickhouseLogRepo) GetContext(ctx context.Context, filter *ContextFilte
 
 // scanLogRows scans rows into LogRecord slice.
 func (r *clickhouseLogRepo) scanLogRows(rows *sql.Rows) ([]*LogRecord, error) {
+	entries, err := r.scanLogColumns(rows, logColumns)
+	if err != nil {
+		return nil, err
+	}
+	return entries, rows.Err()
+}
+
+// scanLogColumns scans rows selecting columns, a subset of logColumns in
+// their order. Errors from rows.Err are left to the caller.
+func (r *clickhouseLogRepo) scanLogColumns(rows *sql.Rows, columns []string) ([]*LogRecord, error) {
 	var entries []*LogRecord
 	for rows.Next() {
 		entry := &LogRecord{}
 		var fieldsJSON, labelsJSON string
 
-		err := rows.Scan(
-			&entry.ID,
-			&entry.ProjectID,
-			&entry.Timestamp,
-			&entry.Level,
-			&entry.Message,
-			&entry.Source,
-			&entry.Type,
-			&entry.Raw,
-			&entry.AgentID,
-			&entry.FilePath,
-			&entry.LineNumber,
-			&fieldsJSON,
-			&labelsJSON,
-			&entry.HTTPStatus,
-			&entry.HTTPMethod,
-			&entry.URI,
-		)
-		if err != nil {
+		dest := make([]interface{}, len(columns))
+		for i, c := range columns {
+			dest[i] = logColumnDest(entry, c, &fieldsJSON, &labelsJSON)
+		}
+		if err := rows.Scan(dest...); err != nil {
 			return nil, fmt.Errorf("scan: %w", err)
 		}
 
+		// Parse JSON fields
 		if fieldsJSON != "" {
 			if err := json.Unmarshal([]byte(fieldsJSON), &entry.Fields); err != nil {
-				log.Printf("warning: failed to unmarshal fields: %v", err)
+				slog.Warn("failed to unmarshal fields for log entry", "id", entry.ID, "error", err)
 			}
 		}
 		if labelsJSON != "" {
 			if err := json.Unmarshal([]byte(labelsJSON), &entry.Labels); err != nil {
-				log.Printf("warning: failed to unmarshal labels: %v", err)
+				slog.Warn("failed to unmarshal labels for log entry", "id", entry.ID, "error", err)
 			}
 		}
 
 		entries = append(entries, entry)
 	}
-	return entries, rows.Err()
+	return entries, nil
 }
 
-// formatCursor creates a cursor string from timestamp and ID.
-func formatCursor(ts time.Time, id string) string {
-	return ts.Format(time.RFC3339Nano) + ":" + id
+// formatCursor creates a cursor string from timestamp, line number and ID.
+func formatCursor(ts time.Time, line int64, id string) string {
+	return ts.Format(time.RFC3339Nano) + ":" + strconv.FormatInt(line, 10) + ":" + id
 }
 
-// parseCursor extracts timestamp and ID from cursor string.
-func parseCursor(cursor string) (time.Time, string, bool) {
+// parseCursor extracts timestamp, line number and ID from cursor string.
+// Cursors without a line number, from before lines were ordered by it,
+// read as line 0.
+func parseCursor(cursor string) (time.Time, int64, string, bool) {
 	idx := strings.LastIndex(cursor, ":")
 	if idx == -1 {
-		return time.Time{}, "", false
+		return time.Time{}, 0, "", false
+	}
+	rest, id := cursor[:idx], cursor[idx+1:]
+	if i := strings.LastIndex(rest, ":"); i != -1 {
+		if n, err := strconv.ParseInt(rest[i+1:], 10, 64); err == nil {
+			if ts, err := time.Parse(time.RFC3339Nano, rest[:i]); err == nil {
+				return ts, n, id, true
+			}
+		}
 	}
-	tsStr := cursor[:idx]
-	id := cursor[idx+1:]
-	ts, err := time.Parse(time.RFC3339Nano, tsStr)
+	ts, err := time.Parse(time.RFC3339Nano, rest)
 	if err != nil {
-		return time.Time{}, "", false
+		return time.Time{}, 0, "", false
 	}
-	return ts, id, true
+	return ts, 0, id, true
 }
 
 // buildAggregationWhere builds WHERE clause for aggregation queries.
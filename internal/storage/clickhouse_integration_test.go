@@ -4,8 +4,12 @@ package storage
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // Integration tests require running ClickHouse.
@@ -80,6 +84,23 @@ func TestClickHouseStorage_InsertBatch_Integration(t *testing.T) {
 	if len(result.Entries) != 1 {
 		t.Errorf("expected 1 entry, got %d", len(result.Entries))
 	}
+
+	// A summary projection leaves out the fields and labels JSON
+	result, err = store.Logs().Query(ctx, &LogFilter{
+		StartTime:  time.Now().Add(-time.Hour),
+		EndTime:    time.Now().Add(time.Hour),
+		AgentID:    "test-agent",
+		Projection: LogSummaryProjection,
+	})
+	if err != nil {
+		t.Fatalf("query summary: %v", err)
+	}
+	if len(result.Entries) != 1 {
+		t.Fatalf("expected 1 summary entry, got %d", len(result.Entries))
+	}
+	if e := result.Entries[0]; e.Message != "Test log message" || e.Fields != nil || e.Labels != nil {
+		t.Errorf("summary entry = %+v, want message without fields or labels", e)
+	}
 }
 
 func TestClickHouseStorage_Query_Integration(t *testing.T) {
@@ -179,6 +200,38 @@ func TestClickHouseStorage_DeleteBefore_Integration(t *testing.T) {
 	}
 }
 
+func TestClickHouseStorage_PurgeLogsVerified_Integration(t *testing.T) {
+	store, cleanup := setupClickHouseTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	entries := []*LogRecord{
+		{Timestamp: time.Now().Add(-48 * time.Hour), Level: "info", Message: "old", AgentID: "test"},
+		{Timestamp: time.Now(), Level: "info", Message: "new", AgentID: "test"},
+	}
+	if err := store.Logs().InsertBatch(ctx, entries); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	r := LogRetention{MaxAge: 24 * time.Hour, VerifyTimeout: time.Minute, PollInterval: 100 * time.Millisecond}
+	purge, err := PurgeLogs(ctx, store.Logs(), r, time.Now())
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if purge.Deleted != 1 || !purge.Verified {
+		t.Errorf("expected 1 deleted and verified, got %+v", purge)
+	}
+
+	count, err := store.Logs().Count(ctx, &LogFilter{})
+	if err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 log left after verified purge, got %d", count)
+	}
+}
+
 // Milestone 21: Advanced ClickHouse Integration Tests
 
 func TestClickHouseStorage_SearchModes_Integration(t *testing.T) {
@@ -395,3 +448,295 @@ func TestClickHouseStorage_GetHTTPStats_Integration(t *testing.T) {
 		t.Errorf("expected Total5xx 2, got %d", result.Total5xx)
 	}
 }
+
+func TestClickHouseStorage_GetFieldPercentile_Integration(t *testing.T) {
+	store, cleanup := setupClickHouseTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	var entries []*LogRecord
+	for i := 1; i <= 100; i++ {
+		var v interface{} = float64(i)
+		if i%2 == 0 {
+			v = fmt.Sprintf("%d", i) // numbers stored as strings count too
+		}
+		entries = append(entries, &LogRecord{Timestamp: time.Now(), Level: "info", AgentID: "test",
+			Fields: map[string]interface{}{"request_time": v}})
+	}
+	entries = append(entries, &LogRecord{Timestamp: time.Now(), Level: "info", AgentID: "test"})
+	store.Logs().InsertBatch(ctx, entries)
+
+	filter := &AggregationFilter{StartTime: time.Now().Add(-time.Hour), EndTime: time.Now().Add(time.Hour)}
+	result, err := store.Logs().GetFieldPercentile(ctx, filter, "request_time", 0.95)
+	if err != nil {
+		t.Fatalf("get field percentile: %v", err)
+	}
+	if result.Samples != 100 {
+		t.Errorf("expected 100 samples, got %d", result.Samples)
+	}
+	if result.Value < 90 || result.Value > 100 {
+		t.Errorf("expected p95 near 95, got %v", result.Value)
+	}
+
+	result, err = store.Logs().GetFieldPercentile(ctx, filter, "missing", 0.95)
+	if err != nil {
+		t.Fatalf("get field percentile: %v", err)
+	}
+	if result.Samples != 0 {
+		t.Errorf("expected 0 samples for a missing field, got %d", result.Samples)
+	}
+}
+
+func TestClickHouseStorage_GetLatencyStats_Integration(t *testing.T) {
+	store, cleanup := setupClickHouseTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Hour).Add(30 * time.Minute)
+	var entries []*LogRecord
+	for i := 1; i <= 100; i++ {
+		ts := now
+		if i <= 20 {
+			ts = now.Add(-time.Hour)
+		}
+		entries = append(entries, &LogRecord{Timestamp: ts, Level: "info", AgentID: "test",
+			Fields: map[string]interface{}{"request_time_ms": float64(i)}})
+	}
+	entries = append(entries, &LogRecord{Timestamp: now, Level: "info", AgentID: "test"})
+	store.Logs().InsertBatch(ctx, entries)
+
+	filter := &AggregationFilter{StartTime: now.Add(-2 * time.Hour), EndTime: now.Add(time.Hour)}
+	result, err := store.Logs().GetLatencyStats(ctx, filter, "request_time_ms", "hour")
+	if err != nil {
+		t.Fatalf("get latency stats: %v", err)
+	}
+	if result.Samples != 100 {
+		t.Errorf("expected 100 samples, got %d", result.Samples)
+	}
+	if result.P50 < 40 || result.P50 > 60 || result.P99 < 95 || result.P50 > result.P90 || result.P90 > result.P95 || result.P95 > result.P99 {
+		t.Errorf("unexpected quantiles %+v", result.LatencyQuantiles)
+	}
+	if len(result.Series) != 2 {
+		t.Fatalf("expected 2 series points, got %d", len(result.Series))
+	}
+	if result.Series[0].Samples != 20 || result.Series[1].Samples != 80 {
+		t.Errorf("expected 20 and 80 samples per hour, got %d and %d", result.Series[0].Samples, result.Series[1].Samples)
+	}
+
+	result, err = store.Logs().GetLatencyStats(ctx, filter, "missing", "hour")
+	if err != nil {
+		t.Fatalf("get latency stats: %v", err)
+	}
+	if result.Samples != 0 || len(result.Series) != 0 {
+		t.Errorf("expected no samples for a missing field, got %+v", result)
+	}
+}
+
+func TestClickHouseStorage_GetTopErrors_Integration(t *testing.T) {
+	store, cleanup := setupClickHouseTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	now := time.Now()
+	var entries []*LogRecord
+	for i := 1; i <= 3; i++ {
+		entries = append(entries, &LogRecord{Timestamp: now, Level: "error", AgentID: "test",
+			Message: fmt.Sprintf("Order %d not found in /var/www/app/Order.php:%d", i, 40+i)})
+	}
+	entries = append(entries,
+		&LogRecord{Timestamp: now, Level: "fatal", AgentID: "test", Message: "out of memory"},
+		&LogRecord{Timestamp: now, Level: "info", AgentID: "test", Message: "Order 4 placed"},
+	)
+	store.Logs().InsertBatch(ctx, entries)
+
+	result, err := store.Logs().GetTopErrors(ctx, &AggregationFilter{
+		StartTime: now.Add(-time.Hour),
+		EndTime:   now.Add(time.Hour),
+	}, 10)
+	if err != nil {
+		t.Fatalf("get top errors: %v", err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("expected 2 error groups, got %d", len(result))
+	}
+	if result[0].Pattern != "Order <n> not found in <file>" || result[0].Count != 3 {
+		t.Errorf("expected the order group with 3 logs first, got %q (%d)", result[0].Pattern, result[0].Count)
+	}
+	if result[0].Example == "" || result[0].LastSeen.IsZero() {
+		t.Errorf("expected an example and last seen time, got %+v", result[0])
+	}
+}
+
+func TestClickHouseStorage_GetContext_Integration(t *testing.T) {
+	store, cleanup := setupClickHouseTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	// Lines logged in the same instant are ordered by line number
+	now := time.Now().Truncate(time.Second)
+	var entries []*LogRecord
+	for line := int64(1); line <= 5; line++ {
+		entries = append(entries, &LogRecord{ID: fmt.Sprintf("00000000-0000-0000-0000-00000000000%d", 6-line),
+			Timestamp: now, Level: "info", AgentID: "test", FilePath: "/var/log/app.log", LineNumber: line,
+			Message: fmt.Sprintf("line %d", line)})
+	}
+	entries = append(entries, &LogRecord{ID: "00000000-0000-0000-0000-000000000009",
+		Timestamp: now, Level: "info", AgentID: "test", FilePath: "/var/log/other.log", LineNumber: 1})
+	store.Logs().InsertBatch(ctx, entries)
+
+	anchor := entries[2]
+	filter := &ContextFilter{TargetID: anchor.ID, AgentID: "test", FilePath: anchor.FilePath,
+		Timestamp: anchor.Timestamp, LineNumber: anchor.LineNumber, Before: 1, After: 5}
+	result, err := store.Logs().GetContext(ctx, filter)
+	if err != nil {
+		t.Fatalf("get context: %v", err)
+	}
+	if len(result.Before) != 1 || result.Before[0].LineNumber != 2 || !result.HasMoreBefore {
+		t.Errorf("expected line 2 before with more, got %+v", result.Before)
+	}
+	if len(result.After) != 2 || result.After[0].LineNumber != 4 || result.After[1].LineNumber != 5 || result.HasMoreAfter {
+		t.Errorf("expected lines 4 and 5 after, got %+v", result.After)
+	}
+
+	filter.BeforeCursor = result.BeforeCursor
+	filter.After = 0
+	result, err = store.Logs().GetContext(ctx, filter)
+	if err != nil {
+		t.Fatalf("get context: %v", err)
+	}
+	if len(result.Before) != 1 || result.Before[0].LineNumber != 1 {
+		t.Errorf("expected line 1 before the cursor, got %+v", result.Before)
+	}
+}
+
+func TestClickHouseStorage_GetByID_Integration(t *testing.T) {
+	store, cleanup := setupClickHouseTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	entry := &LogRecord{ID: "00000000-0000-0000-0000-000000000001", Timestamp: time.Now(), Level: "error",
+		Message: "boom", AgentID: "test", Fields: map[string]interface{}{"status": float64(502)},
+		Labels: map[string]string{"env": "prod"}}
+	store.Logs().InsertBatch(ctx, []*LogRecord{entry})
+
+	got, err := store.Logs().GetByID(ctx, entry.ID)
+	if err != nil {
+		t.Fatalf("get by id: %v", err)
+	}
+	if got == nil || got.Message != "boom" || got.Fields["status"] != float64(502) || got.Labels["env"] != "prod" {
+		t.Errorf("expected the stored log, got %+v", got)
+	}
+
+	for _, id := range []string{"00000000-0000-0000-0000-000000000002", "not-a-uuid"} {
+		got, err := store.Logs().GetByID(ctx, id)
+		if err != nil || got != nil {
+			t.Errorf("GetByID(%q) = %+v, %v, want nil, nil", id, got, err)
+		}
+	}
+}
+
+func TestClickHouseStorage_QueryAfterCursor_Integration(t *testing.T) {
+	store, cleanup := setupClickHouseTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	// Two logs share each timestamp, so pages split ties by id
+	now := time.Now().Truncate(time.Second)
+	var entries []*LogRecord
+	for i := 0; i < 6; i++ {
+		entries = append(entries, &LogRecord{ID: fmt.Sprintf("00000000-0000-0000-0000-00000000000%d", i),
+			Timestamp: now.Add(-time.Duration(i/2) * time.Second), Level: "info", AgentID: "test"})
+	}
+	store.Logs().InsertBatch(ctx, entries)
+
+	filter := &LogFilter{StartTime: now.Add(-time.Minute), EndTime: now.Add(time.Minute),
+		OrderBy: "timestamp", OrderDesc: true, Limit: 4}
+	var seen []string
+	for page := 0; page < 3; page++ {
+		result, err := store.Logs().Query(ctx, filter)
+		if err != nil {
+			t.Fatalf("query: %v", err)
+		}
+		for _, e := range result.Entries {
+			seen = append(seen, e.ID[len(e.ID)-1:])
+		}
+		if !result.HasMore {
+			break
+		}
+		last := result.Entries[len(result.Entries)-1]
+		filter.AfterTime, filter.AfterID = last.Timestamp, last.ID
+	}
+	if got := fmt.Sprint(seen); got != "[1 0 3 2 5 4]" {
+		t.Errorf("expected every log once, newest first, got %s", got)
+	}
+}
+
+func TestClickHouseStorage_AggregationRollup_Integration(t *testing.T) {
+	store, cleanup := setupClickHouseTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if store.logs.rollup.since.IsZero() {
+		t.Skip("hourly volume view not available")
+	}
+
+	// A unique agent keeps rows the view kept from earlier runs out
+	agent := "rollup-" + uuid.New().String()
+	now := time.Now().UTC()
+	var entries []*LogRecord
+	for h := 0; h < 6; h++ {
+		ts := now.Add(-time.Duration(h)*time.Hour - 5*time.Minute)
+		entries = append(entries,
+			&LogRecord{Timestamp: ts, Level: "info", Message: "ok", AgentID: agent},
+			&LogRecord{Timestamp: ts, Level: "error", Message: "failed", AgentID: agent},
+		)
+	}
+	if err := store.Logs().InsertBatch(ctx, entries); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	filter := &AggregationFilter{AgentID: agent, StartTime: now.Add(-7 * time.Hour), EndTime: now}
+	repo := store.logs
+	viewed := *repo
+	viewed.rollup = clickhouseRollup{since: now.Add(-8 * time.Hour).Truncate(time.Hour), retentionDays: 30}
+	if query, _ := viewed.errorRatesQuery(filter, time.Now()); !strings.Contains(query, hourlyVolumeView) {
+		t.Fatalf("expected the rollup to be used:\n%s", query)
+	}
+	scanned := *repo
+	scanned.rollup = clickhouseRollup{}
+
+	want, err := scanned.GetErrorRates(ctx, filter)
+	if err != nil {
+		t.Fatalf("error rates: %v", err)
+	}
+	got, err := viewed.GetErrorRates(ctx, filter)
+	if err != nil {
+		t.Fatalf("error rates from rollup: %v", err)
+	}
+	if *got != *want || got.TotalLogs != 12 {
+		t.Errorf("rollup error rates = %+v, want %+v", got, want)
+	}
+
+	wantVolume, err := scanned.GetLogVolume(ctx, filter, "hour")
+	if err != nil {
+		t.Fatalf("volume: %v", err)
+	}
+	gotVolume, err := viewed.GetLogVolume(ctx, filter, "hour")
+	if err != nil {
+		t.Fatalf("volume from rollup: %v", err)
+	}
+	if len(gotVolume) != len(wantVolume) {
+		t.Fatalf("rollup volume has %d points, want %d", len(gotVolume), len(wantVolume))
+	}
+	for i := range wantVolume {
+		if !gotVolume[i].Timestamp.Equal(wantVolume[i].Timestamp) || gotVolume[i].TotalCount != wantVolume[i].TotalCount || gotVolume[i].ErrorCount != wantVolume[i].ErrorCount {
+			t.Errorf("rollup volume[%d] = %+v, want %+v", i, gotVolume[i], wantVolume[i])
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// hourlyVolumeView is the rollup of log counts per project, agent, type
+// and hour that GetErrorRates and GetLogVolume read whole hours from
+// instead of scanning logs.
+const hourlyVolumeView = "logs_hourly_volume_mv"
+
+// minRollupSpan is the fewest whole hours worth reading from the view;
+// over shorter ranges the base table scan is cheap anyway.
+const minRollupSpan = 3 * time.Hour
+
+// clickhouseRollup tells which hours hourlyVolumeView counts exactly.
+type clickhouseRollup struct {
+	// since is the first hour the view has every log of: it only counts
+	// logs inserted after it was created. Zero disables the view.
+	since time.Time
+
+	// retentionDays is the shortest log retention. Older hours may have
+	// lost logs to TTL or DeleteExpired that the view still counts.
+	retentionDays int
+}
+
+// rollupDisabled reports why Migrate leaves the view unused, or "".
+func (s *ClickHouseStorage) rollupDisabled() string {
+	switch {
+	case s.tables.cluster != "":
+		// Each shard's view only counts the rows of its logs_local
+		return "cluster"
+	case s.config.Engine == EngineReplacingMergeTree:
+		// Queries collapse duplicates with FINAL; the view counted each copy
+		return "engine"
+	}
+	return ""
+}
+
+// loadRollup enables the view for the hours after it was created.
+func (s *ClickHouseStorage) loadRollup(ctx context.Context) {
+	if reason := s.rollupDisabled(); reason != "" {
+		slog.Debug("clickhouse rollup unused", "view", hourlyVolumeView, "reason", reason)
+		return
+	}
+
+	var created time.Time
+	err := s.db.QueryRowContext(ctx, "SELECT metadata_modification_time FROM system.tables WHERE database = currentDatabase() AND name = ?", hourlyVolumeView).Scan(&created)
+	if err != nil {
+		slog.Warn("clickhouse rollup unavailable", "view", hourlyVolumeView, "error", err)
+		return
+	}
+
+	retentionDays := s.config.RetentionDays
+	for _, rule := range s.config.RetentionRules {
+		retentionDays = min(retentionDays, rule.Days)
+	}
+	s.logs.rollup = clickhouseRollup{
+		since:         ceilHour(created),
+		retentionDays: retentionDays,
+	}
+}
+
+// ceilHour returns the first hour boundary at or after t.
+func ceilHour(t time.Time) time.Time {
+	h := t.UTC().Truncate(time.Hour)
+	if h.Before(t) {
+		h = h.Add(time.Hour)
+	}
+	return h
+}
+
+// window returns the whole hours [start, end) of filter's range the view
+// can answer, or ok = false if too few to be worth it.
+func (r clickhouseRollup) window(filter *AggregationFilter, now time.Time) (start, end time.Time, ok bool) {
+	if r.since.IsZero() {
+		return time.Time{}, time.Time{}, false
+	}
+	start = r.since
+	if retained := ceilHour(now.AddDate(0, 0, -r.retentionDays)); retained.After(start) {
+		start = retained
+	}
+	if !filter.StartTime.IsZero() {
+		if first := ceilHour(filter.StartTime); first.After(start) {
+			start = first
+		}
+	}
+
+	// The hour holding EndTime (inclusive) or now is partial
+	end = now.UTC().Truncate(time.Hour)
+	if !filter.EndTime.IsZero() && filter.EndTime.Before(now) {
+		end = filter.EndTime.UTC().Truncate(time.Hour)
+	}
+	if end.Sub(start) < minRollupSpan {
+		return time.Time{}, time.Time{}, false
+	}
+	return start, end, true
+}
+
+// splitAggregation returns, for a rollup window, the conditions reading the
+// rest of filter's range from logs and the window from the view.
+func (r *clickhouseLogRepo) splitAggregation(filter *AggregationFilter, start, end time.Time) (baseArgs []interface{}, baseWhere string, viewArgs []interface{}, viewWhere string) {
+	baseArgs, baseWhere = r.buildAggregationWhere(filter)
+	if baseWhere != "" {
+		baseWhere += " AND "
+	}
+	baseWhere += "(timestamp < ? OR timestamp >= ?)"
+	baseArgs = append(baseArgs, start, end)
+
+	// The view has the project, agent and type columns to filter on
+	viewWhere, viewArgs = r.buildAggregationProjectFilter(filter)
+	conditions := []string{"hour >= ?", "hour < ?"}
+	if viewWhere != "" {
+		conditions = append([]string{viewWhere}, conditions...)
+	}
+	viewArgs = append(viewArgs, start, end)
+	if filter.AgentID != "" {
+		conditions = append(conditions, "agent_id = ?")
+		viewArgs = append(viewArgs, filter.AgentID)
+	}
+	if filter.Type != "" {
+		conditions = append(conditions, "type = ?")
+		viewArgs = append(viewArgs, filter.Type)
+	}
+	viewWhere = strings.Join(conditions, " AND ")
+	return baseArgs, baseWhere, viewArgs, viewWhere
+}
+
+// errorRatesQuery returns the GetErrorRates query and its arguments,
+// reading whole hours from the view over wide ranges.
+func (r *clickhouseLogRepo) errorRatesQuery(filter *AggregationFilter, now time.Time) (string, []interface{}) {
+	start, end, ok := r.rollup.window(filter, now)
+	if !ok {
+		query := `
+		SELECT
+			count() AS total,
+			countIf(level = 'error') AS errors,
+			countIf(level = 'warning') AS warnings,
+			countIf(level = 'fatal') AS fatals
+		FROM logs
+	`
+		args, whereClause := r.buildAggregationWhere(filter)
+		if whereClause != "" {
+			query += " WHERE " + whereClause
+		}
+		return query, args
+	}
+
+	baseArgs, baseWhere, viewArgs, viewWhere := r.splitAggregation(filter, start, end)
+	query := fmt.Sprintf(`
+		SELECT sum(total), sum(errors), sum(warnings), sum(fatals)
+		FROM (
+			SELECT
+				count() AS total,
+				countIf(level = 'error') AS errors,
+				countIf(level = 'warning') AS warnings,
+				countIf(level = 'fatal') AS fatals
+			FROM logs
+			WHERE %s
+			UNION ALL
+			SELECT sum(total_count), sum(error_count), sum(warning_count), sum(fatal_count)
+			FROM %s
+			WHERE %s
+		)
+	`, baseWhere, hourlyVolumeView, viewWhere)
+	return query, append(baseArgs, viewArgs...)
+}
+
+// logVolumeQuery returns the GetLogVolume query and its arguments, reading
+// whole hours from the view over wide hour or day ranges.
+func (r *clickhouseLogRepo) logVolumeQuery(filter *AggregationFilter, interval string, now time.Time) (string, []interface{}) {
+	start, end, ok := r.rollup.window(filter, now)
+	if !ok || interval == "minute" {
+		query := fmt.Sprintf(`
+		SELECT
+			%s AS ts,
+			count() AS total,
+			countIf(level IN ('error', 'fatal')) AS errors
+		FROM logs
+	`, intervalStart(interval))
+		args, whereClause := r.buildAggregationWhere(filter)
+		if whereClause != "" {
+			query += " WHERE " + whereClause
+		}
+		query += " GROUP BY ts ORDER BY ts ASC"
+		return query, args
+	}
+
+	// Both sides as DateTime, so UNION ALL doesn't mix in DateTime64
+	viewStart := "toStartOfHour(hour)"
+	if interval == "day" {
+		viewStart = "toStartOfDay(hour)"
+	}
+	baseArgs, baseWhere, viewArgs, viewWhere := r.splitAggregation(filter, start, end)
+	query := fmt.Sprintf(`
+		SELECT ts, sum(total), sum(errors)
+		FROM (
+			SELECT toDateTime(%s, 'UTC') AS ts, count() AS total, countIf(level IN ('error', 'fatal')) AS errors
+			FROM logs
+			WHERE %s
+			GROUP BY ts
+			UNION ALL
+			SELECT toDateTime(%s, 'UTC') AS ts, sum(total_count) AS total, sum(error_count + fatal_count) AS errors
+			FROM %s
+			WHERE %s
+			GROUP BY ts
+		)
+		GROUP BY ts ORDER BY ts ASC
+	`, intervalStart(interval), baseWhere, viewStart, hourlyVolumeView, viewWhere)
+	return query, append(baseArgs, viewArgs...)
+}
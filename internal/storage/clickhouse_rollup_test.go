@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClickHouseRollupWindow(t *testing.T) {
+	now := time.Date(2026, 10, 14, 12, 30, 0, 0, time.UTC)
+	rollup := clickhouseRollup{since: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), retentionDays: 30}
+
+	tests := []struct {
+		name      string
+		rollup    clickhouseRollup
+		filter    AggregationFilter
+		wantStart time.Time
+		wantEnd   time.Time
+		wantOK    bool
+	}{
+		{
+			name:      "whole hours inside the range",
+			rollup:    rollup,
+			filter:    AggregationFilter{StartTime: now.Add(-24*time.Hour - 10*time.Minute), EndTime: now.Add(-time.Hour)},
+			wantStart: time.Date(2026, 10, 13, 13, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2026, 10, 14, 11, 0, 0, 0, time.UTC),
+			wantOK:    true,
+		},
+		{
+			name:      "not before the view was created",
+			rollup:    rollup,
+			filter:    AggregationFilter{StartTime: now.AddDate(0, 0, -20)},
+			wantStart: rollup.since,
+			wantEnd:   time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC),
+			wantOK:    true,
+		},
+		{
+			name:      "not past the shortest retention",
+			rollup:    clickhouseRollup{since: rollup.since, retentionDays: 7},
+			filter:    AggregationFilter{},
+			wantStart: time.Date(2026, 10, 7, 13, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC),
+			wantOK:    true,
+		},
+		{
+			name:   "range too narrow",
+			rollup: rollup,
+			filter: AggregationFilter{StartTime: now.Add(-2 * time.Hour)},
+		},
+		{
+			name:   "view unavailable",
+			filter: AggregationFilter{StartTime: now.AddDate(0, 0, -7)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, ok := tt.rollup.window(&tt.filter, now)
+			if ok != tt.wantOK || !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("window() = %v, %v, %v, want %v, %v, %v", start, end, ok, tt.wantStart, tt.wantEnd, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestClickHouseAggregationQueriesUseRollup(t *testing.T) {
+	now := time.Date(2026, 10, 14, 12, 30, 0, 0, time.UTC)
+	r := &clickhouseLogRepo{rollup: clickhouseRollup{since: now.AddDate(0, 0, -10), retentionDays: 30}}
+	wide := &AggregationFilter{ProjectID: "shop", Type: "nginx", StartTime: now.AddDate(0, 0, -7), EndTime: now}
+	narrow := &AggregationFilter{ProjectID: "shop", StartTime: now.Add(-time.Hour), EndTime: now}
+
+	query, args := r.errorRatesQuery(wide, now)
+	for _, want := range []string{"FROM logs_hourly_volume_mv", "(timestamp < ? OR timestamp >= ?)", "project_id = ? AND hour >= ? AND hour < ? AND type = ?"} {
+		if !strings.Contains(query, want) {
+			t.Errorf("wide error rates query missing %q:\n%s", want, query)
+		}
+	}
+	if got, want := strings.Count(query, "?"), len(args); got != want {
+		t.Errorf("wide error rates query has %d placeholders for %d args", got, want)
+	}
+
+	query, args = r.logVolumeQuery(wide, "day", now)
+	if !strings.Contains(query, "toDateTime(toStartOfDay(hour), 'UTC') AS ts") {
+		t.Errorf("wide volume query doesn't read the view by day:\n%s", query)
+	}
+	if got, want := strings.Count(query, "?"), len(args); got != want {
+		t.Errorf("wide volume query has %d placeholders for %d args", got, want)
+	}
+
+	for name, query := range map[string]string{
+		"narrow error rates": first(r.errorRatesQuery(narrow, now)),
+		"narrow volume":      first(r.logVolumeQuery(narrow, "hour", now)),
+		"minute volume":      first(r.logVolumeQuery(wide, "minute", now)),
+	} {
+		if strings.Contains(query, hourlyVolumeView) {
+			t.Errorf("%s query reads the view:\n%s", name, query)
+		}
+	}
+}
+
+func first(query string, _ []interface{}) string {
+	return query
+}
@@ -0,0 +1,505 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema retry defaults.
+const (
+	defaultSchemaRetries    = 3
+	defaultSchemaRetryDelay = 2 * time.Second
+	schemaOpTimeout         = 30 * time.Second // per DDL statement or check
+)
+
+// schemaObject is an index or materialized view Migrate creates. Its DDL
+// is a template expanded by clickhouseTables.expand.
+type schemaObject struct {
+	name string
+	kind string // "index" or "materialized view"
+	ddl  string
+}
+
+// DDL template placeholders.
+const (
+	ddlLogs      = "{logs}"       // table holding the log rows
+	ddlOnCluster = "{on_cluster}" // ON CLUSTER clause, empty on a single node
+	ddlSumming   = "{summing}"    // SummingMergeTree engine for rollups
+)
+
+// Table names in cluster mode.
+const (
+	logsTable      = "logs"
+	logsLocalTable = "logs_local"
+)
+
+// replicaArgs are the ReplicatedMergeTree arguments: one ZooKeeper path per
+// shard and table, one replica per host. {shard} and {replica} come from
+// the macros in each server's config.
+const replicaArgs = `'/clickhouse/tables/{shard}/{database}/{table}', '{replica}'`
+
+// clusterNamePattern matches the cluster names ClickHouseConfig accepts.
+var clusterNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// ValidateClusterName checks a ClickHouse cluster name; empty means a
+// single node.
+func ValidateClusterName(name string) error {
+	if name != "" && !clusterNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid cluster name %q (letters, digits, _, . and - only)", name)
+	}
+	return nil
+}
+
+// clickhouseTables lays out the logs tables. On a single node logs is a
+// MergeTree table. On a cluster, logs_local is a ReplicatedMergeTree table
+// on every host and logs is a Distributed table over it, so inserts and
+// queries keep using logs while DDL and mutations target logs_local.
+type clickhouseTables struct {
+	cluster string
+}
+
+// local returns the table that stores the rows.
+func (t clickhouseTables) local() string {
+	if t.cluster == "" {
+		return logsTable
+	}
+	return logsLocalTable
+}
+
+// onCluster returns the ON CLUSTER clause for DDL, with a leading space.
+func (t clickhouseTables) onCluster() string {
+	if t.cluster == "" {
+		return ""
+	}
+	return fmt.Sprintf(" ON CLUSTER '%s'", t.cluster)
+}
+
+// engine returns the table engine clause for a MergeTree family engine.
+func (t clickhouseTables) engine(name string) string {
+	if t.cluster == "" {
+		return name + "()"
+	}
+	return fmt.Sprintf("Replicated%s(%s)", name, replicaArgs)
+}
+
+// expand fills in the placeholders of a DDL template.
+func (t clickhouseTables) expand(ddl string) string {
+	return strings.NewReplacer(
+		ddlLogs, t.local(),
+		ddlOnCluster, t.onCluster(),
+		ddlSumming, t.engine("SummingMergeTree"),
+	).Replace(ddl)
+}
+
+// distributedDDL returns the statement that creates the Distributed logs
+// table, or "" on a single node. Rows are sharded by ID so copies of a
+// record meet on one shard, where ReplacingMergeTree can collapse them.
+func (t clickhouseTables) distributedDDL() string {
+	if t.cluster == "" {
+		return ""
+	}
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s%s AS %s ENGINE = Distributed('%s', currentDatabase(), %s, cityHash64(id))",
+		logsTable, t.onCluster(), logsLocalTable, t.cluster, logsLocalTable)
+}
+
+// system returns the expression reading a system table, from every
+// replica in cluster mode.
+func (t clickhouseTables) system(table string) string {
+	if t.cluster == "" {
+		return "system." + table
+	}
+	return fmt.Sprintf("clusterAllReplicas('%s', system.%s)", t.cluster, table)
+}
+
+// logIndexes are the data skipping indexes on the logs table. ADD INDEX IF
+// NOT EXISTS is idempotent, so they are safe to re-issue.
+var logIndexes = []schemaObject{
+	{"idx_message", "index", "ALTER TABLE {logs}{on_cluster} ADD INDEX IF NOT EXISTS idx_message message TYPE tokenbf_v1(32768, 3, 0) GRANULARITY 4"},
+	{"idx_source", "index", "ALTER TABLE {logs}{on_cluster} ADD INDEX IF NOT EXISTS idx_source source TYPE bloom_filter(0.01) GRANULARITY 4"},
+	{"idx_file_path", "index", "ALTER TABLE {logs}{on_cluster} ADD INDEX IF NOT EXISTS idx_file_path file_path TYPE bloom_filter(0.01) GRANULARITY 4"},
+	// Project index for multi-tenant filtering
+	{"idx_project_id", "index", "ALTER TABLE {logs}{on_cluster} ADD INDEX IF NOT EXISTS idx_project_id project_id TYPE bloom_filter(0.01) GRANULARITY 4"},
+	// Advanced indexes (Milestone 21)
+	{"idx_message_ngram", "index", "ALTER TABLE {logs}{on_cluster} ADD INDEX IF NOT EXISTS idx_message_ngram message TYPE ngrambf_v1(3, 65536, 3, 0) GRANULARITY 4"},
+	{"idx_timestamp_minmax", "index", "ALTER TABLE {logs}{on_cluster} ADD INDEX IF NOT EXISTS idx_timestamp_minmax timestamp TYPE minmax GRANULARITY 3"},
+	{"idx_http_status", "index", "ALTER TABLE {logs}{on_cluster} ADD INDEX IF NOT EXISTS idx_http_status http_status TYPE set(100) GRANULARITY 4"},
+}
+
+// DefaultIndexGranularity is the granularity of an IndexHint that sets none.
+const DefaultIndexGranularity = 4
+
+// IndexHint is an extra data skipping index on the logs table, configured
+// per deployment for its query patterns.
+type IndexHint struct {
+	// Column is a logs column (e.g. "agent_id") or a JSON key written
+	// "labels.<key>" or "fields.<key>". A JSON key is indexed on the same
+	// JSONExtractString expression the query builder emits, so filters like
+	// labels.tenant == "acme" can use it.
+	Column string
+
+	// Type is bloom_filter, minmax or set, optionally with parameters, e.g.
+	// "bloom_filter(0.01)" or "set(100)".
+	Type string
+
+	// Granularity is the index granularity (default: DefaultIndexGranularity).
+	Granularity int
+}
+
+// indexableColumns are the logs columns an IndexHint may name directly.
+var indexableColumns = map[string]bool{
+	"id": true, "project_id": true, "timestamp": true, "level": true, "message": true,
+	"source": true, "type": true, "raw": true, "agent_id": true, "file_path": true,
+	"line_number": true, "http_status": true, "http_method": true, "uri": true,
+}
+
+// jsonKeyPattern matches the JSON keys the query builder accepts.
+var jsonKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// indexTypePattern matches the index types an IndexHint may use.
+var indexTypePattern = regexp.MustCompile(`^(minmax|bloom_filter(\(0?\.[0-9]+\))?|set\([0-9]+\))$`)
+
+// Name returns the index name, derived from the column so that re-issuing
+// the hint finds the existing index.
+func (h IndexHint) Name() string {
+	return "idx_hint_" + strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, h.Column)
+}
+
+// expression returns the indexed SQL expression for the hint's column.
+func (h IndexHint) expression() (string, error) {
+	if indexableColumns[h.Column] {
+		return h.Column, nil
+	}
+	column, key, ok := strings.Cut(h.Column, ".")
+	if !ok || (column != "labels" && column != "fields") {
+		return "", fmt.Errorf("unknown column %q (want a logs column, labels.<key> or fields.<key>)", h.Column)
+	}
+	if !jsonKeyPattern.MatchString(key) {
+		return "", fmt.Errorf("invalid %s key %q (letters, digits, _ and - only)", column, key)
+	}
+	return fmt.Sprintf("JSONExtractString(%s, '%s')", column, key), nil
+}
+
+// Validate checks the hint's column, type and granularity.
+func (h IndexHint) Validate() error {
+	_, err := h.schemaObject()
+	return err
+}
+
+// schemaObject returns the ADD INDEX statement for the hint.
+func (h IndexHint) schemaObject() (schemaObject, error) {
+	expr, err := h.expression()
+	if err != nil {
+		return schemaObject{}, err
+	}
+	if !indexTypePattern.MatchString(h.Type) {
+		return schemaObject{}, fmt.Errorf("unsupported index type %q (want bloom_filter, minmax or set(N))", h.Type)
+	}
+	granularity := h.Granularity
+	if granularity == 0 {
+		granularity = DefaultIndexGranularity
+	}
+	if granularity < 0 {
+		return schemaObject{}, fmt.Errorf("granularity must be > 0")
+	}
+	name := h.Name()
+	return schemaObject{name, "index", fmt.Sprintf("ALTER TABLE {logs}{on_cluster} ADD INDEX IF NOT EXISTS %s %s TYPE %s GRANULARITY %d",
+		name, expr, h.Type, granularity)}, nil
+}
+
+// indexHintObjects returns the schema objects for hints, rejecting invalid
+// hints and hints that resolve to the same index name.
+func indexHintObjects(hints []IndexHint) ([]schemaObject, error) {
+	objects := make([]schemaObject, 0, len(hints))
+	seen := make(map[string]bool, len(hints))
+	for _, h := range hints {
+		obj, err := h.schemaObject()
+		if err != nil {
+			return nil, fmt.Errorf("index hint %q: %w", h.Column, err)
+		}
+		if seen[obj.name] {
+			return nil, fmt.Errorf("index hint %q: duplicate index %s", h.Column, obj.name)
+		}
+		seen[obj.name] = true
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// RetentionRule keeps the logs it matches for Days instead of
+// RetentionDays. A log must match every condition set; at least one must be.
+type RetentionRule struct {
+	Type      string            // Log type, e.g. "nginx"
+	ProjectID string            // Project ID
+	Labels    map[string]string // Label values, e.g. {"team": "security"}
+	Days      int               // Days to keep matching logs (> 0)
+}
+
+// Validate checks the rule has a condition, valid label keys and days.
+func (r RetentionRule) Validate() error {
+	if r.Type == "" && r.ProjectID == "" && len(r.Labels) == 0 {
+		return fmt.Errorf("match needs a type, project_id or label")
+	}
+	for key := range r.Labels {
+		if !jsonKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid label key %q (letters, digits, _ and - only)", key)
+		}
+	}
+	if r.Days <= 0 {
+		return fmt.Errorf("days must be > 0")
+	}
+	return nil
+}
+
+// quoteLiteral returns s as a ClickHouse string literal. TTL expressions
+// are DDL, which takes no query parameters.
+func quoteLiteral(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+// condition returns the SQL condition matching the rule's logs.
+func (r RetentionRule) condition() string {
+	var conditions []string
+	if r.Type != "" {
+		conditions = append(conditions, "type = "+quoteLiteral(r.Type))
+	}
+	if r.ProjectID != "" {
+		conditions = append(conditions, "project_id = "+quoteLiteral(r.ProjectID))
+	}
+	keys := make([]string, 0, len(r.Labels))
+	for key := range r.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys) // stable DDL, so re-issuing it is a no-op
+	for _, key := range keys {
+		conditions = append(conditions, fmt.Sprintf("JSONExtractString(labels, '%s') = %s", key, quoteLiteral(r.Labels[key])))
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+// validateRetentionRules checks every rule, naming the first invalid one.
+func validateRetentionRules(rules []RetentionRule) error {
+	for i, r := range rules {
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("retention rule %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// retentionDays returns the expression giving each log's retention in
+// days: the first rule it matches, else defaultDays.
+func retentionDays(rules []RetentionRule, defaultDays int) string {
+	if len(rules) == 0 {
+		return strconv.Itoa(defaultDays)
+	}
+	args := make([]string, 0, 2*len(rules)+1)
+	for _, r := range rules {
+		args = append(args, r.condition(), strconv.Itoa(r.Days))
+	}
+	return fmt.Sprintf("multiIf(%s, %d)", strings.Join(args, ", "), defaultDays)
+}
+
+// retentionTTL returns the logs table TTL for the rules.
+func retentionTTL(rules []RetentionRule, defaultDays int) string {
+	if len(rules) == 0 {
+		return fmt.Sprintf("_date + INTERVAL %d DAY DELETE", defaultDays)
+	}
+	return fmt.Sprintf("_date + toIntervalDay(%s) DELETE", retentionDays(rules, defaultDays))
+}
+
+// logMaterializedViews are the dashboard rollups (Milestone 21).
+var logMaterializedViews = []schemaObject{
+	// Hourly error counts for error rate dashboards
+	{"logs_hourly_errors_mv", "materialized view", `CREATE MATERIALIZED VIEW IF NOT EXISTS logs_hourly_errors_mv{on_cluster}
+		ENGINE = {summing}
+		PARTITION BY toYYYYMM(hour)
+		ORDER BY (agent_id, type, level, hour)
+		AS SELECT
+			agent_id,
+			type,
+			level,
+			toStartOfHour(timestamp) AS hour,
+			count() AS count
+		FROM {logs}
+		WHERE level IN ('error', 'fatal', 'warning')
+		GROUP BY agent_id, type, level, hour`},
+
+	// Daily log volume for capacity planning
+	{"logs_daily_volume_mv", "materialized view", `CREATE MATERIALIZED VIEW IF NOT EXISTS logs_daily_volume_mv{on_cluster}
+		ENGINE = {summing}
+		PARTITION BY toYYYYMM(day)
+		ORDER BY (agent_id, type, day)
+		AS SELECT
+			agent_id,
+			type,
+			toDate(timestamp) AS day,
+			count() AS total_count,
+			countIf(level = 'error') AS error_count,
+			countIf(level = 'fatal') AS fatal_count,
+			countIf(level = 'warning') AS warning_count
+		FROM {logs}
+		GROUP BY agent_id, type, day`},
+
+	// Hourly log volume per project, read by GetErrorRates and GetLogVolume
+	// over wide ranges (see clickhouse_rollup.go)
+	{hourlyVolumeView, "materialized view", `CREATE MATERIALIZED VIEW IF NOT EXISTS logs_hourly_volume_mv{on_cluster}
+		ENGINE = {summing}
+		PARTITION BY toYYYYMM(hour)
+		ORDER BY (project_id, agent_id, type, hour)
+		AS SELECT
+			project_id,
+			agent_id,
+			type,
+			toStartOfHour(timestamp) AS hour,
+			count() AS total_count,
+			countIf(level = 'error') AS error_count,
+			countIf(level = 'fatal') AS fatal_count,
+			countIf(level = 'warning') AS warning_count
+		FROM {logs}
+		GROUP BY project_id, agent_id, type, hour`},
+
+	// HTTP status distribution for web server monitoring
+	{"logs_http_stats_mv", "materialized view", `CREATE MATERIALIZED VIEW IF NOT EXISTS logs_http_stats_mv{on_cluster}
+		ENGINE = {summing}
+		PARTITION BY toYYYYMM(hour)
+		ORDER BY (agent_id, hour, http_status)
+		AS SELECT
+			agent_id,
+			toStartOfHour(timestamp) AS hour,
+			http_status,
+			count() AS count
+		FROM {logs}
+		WHERE http_status > 0
+		GROUP BY agent_id, hour, http_status`},
+}
+
+// schemaEnsurer creates missing schema objects, checking what exists after
+// each round and retrying failures with a fixed delay.
+type schemaEnsurer struct {
+	objects []schemaObject
+	retries int // rounds after the first
+	delay   time.Duration
+
+	existing func(ctx context.Context) (map[string]bool, error)
+	create   func(ctx context.Context, ddl string) error
+}
+
+// ensure creates the objects not yet present and returns those still
+// missing after the last round.
+func (e *schemaEnsurer) ensure(ctx context.Context) []schemaObject {
+	missing := e.objects
+	for attempt := 0; ; attempt++ {
+		for _, obj := range missing {
+			if err := e.create(ctx, obj.ddl); err != nil {
+				slog.Warn("clickhouse schema object create failed", "kind", obj.kind, "name", obj.name, "attempt", attempt+1, "error", err)
+			}
+		}
+
+		present, err := e.existing(ctx)
+		if err != nil {
+			slog.Warn("clickhouse schema check failed", "attempt", attempt+1, "error", err)
+		} else {
+			missing = missingSchemaObjects(e.objects, present)
+		}
+		if len(missing) == 0 || attempt >= max(e.retries, 0) {
+			return missing
+		}
+
+		select {
+		case <-ctx.Done():
+			return missing
+		case <-time.After(e.delay):
+		}
+	}
+}
+
+// missingSchemaObjects returns the objects whose names are not in present.
+func missingSchemaObjects(objects []schemaObject, present map[string]bool) []schemaObject {
+	var missing []schemaObject
+	for _, obj := range objects {
+		if !present[obj.name] {
+			missing = append(missing, obj)
+		}
+	}
+	return missing
+}
+
+// ensureSchemaObjects creates the logs indexes, the configured index hints
+// and the materialized views, retrying any that are missing, and logs the
+// resulting state.
+func (s *ClickHouseStorage) ensureSchemaObjects(ctx context.Context, hints []schemaObject) {
+	objects := append(append(append([]schemaObject{}, logIndexes...), hints...), logMaterializedViews...)
+	e := &schemaEnsurer{
+		objects: objects,
+		retries: s.config.SchemaRetries,
+		delay:   s.config.SchemaRetryDelay,
+		create: func(ctx context.Context, ddl string) error {
+			ctx, cancel := context.WithTimeout(ctx, schemaOpTimeout)
+			defer cancel()
+			_, err := s.db.ExecContext(ctx, s.tables.expand(ddl))
+			return err
+		},
+		existing: func(ctx context.Context) (map[string]bool, error) {
+			ctx, cancel := context.WithTimeout(ctx, schemaOpTimeout)
+			defer cancel()
+			return s.existingSchemaObjects(ctx)
+		},
+	}
+
+	missing := e.ensure(ctx)
+	if len(missing) == 0 {
+		slog.Info("clickhouse schema ready", "indexes", len(logIndexes), "index_hints", len(hints), "materialized_views", len(logMaterializedViews))
+		return
+	}
+	names := make([]string, len(missing))
+	for i, obj := range missing {
+		names[i] = obj.name
+	}
+	// Not fatal: queries still work, only slower
+	slog.Warn("clickhouse schema incomplete", "missing", strings.Join(names, ","),
+		"present", len(objects)-len(missing), "expected", len(objects))
+}
+
+// existingSchemaObjects returns the names of the logs indexes and the
+// materialized views in the current database.
+func (s *ClickHouseStorage) existingSchemaObjects(ctx context.Context) (map[string]bool, error) {
+	present := make(map[string]bool)
+	queries := []struct {
+		query string
+		args  []interface{}
+	}{
+		{"SELECT name FROM system.data_skipping_indices WHERE database = currentDatabase() AND table = ?", []interface{}{s.tables.local()}},
+		{"SELECT name FROM system.tables WHERE database = currentDatabase() AND engine = 'MaterializedView'", nil},
+	}
+	for _, q := range queries {
+		rows, err := s.db.QueryContext(ctx, q.query, q.args...)
+		if err != nil {
+			return nil, fmt.Errorf("list schema objects: %w", err)
+		}
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("scan schema object: %w", err)
+			}
+			present[name] = true
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("list schema objects: %w", err)
+		}
+	}
+	return present, nil
+}
@@ -0,0 +1,264 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSchema records created objects and fails creates a set number of times.
+type fakeSchema struct {
+	present   map[string]bool
+	failFirst int // creates that fail before any succeed
+	broken    map[string]bool
+	creates   int
+}
+
+func (f *fakeSchema) create(_ context.Context, ddl string) error {
+	f.creates++
+	if f.failFirst > 0 {
+		f.failFirst--
+		return errors.New("too many parts")
+	}
+	for _, obj := range testSchemaObjects {
+		if obj.ddl == ddl && !f.broken[obj.name] {
+			f.present[obj.name] = true
+		}
+	}
+	return nil
+}
+
+func (f *fakeSchema) existing(context.Context) (map[string]bool, error) {
+	present := make(map[string]bool, len(f.present))
+	for name := range f.present {
+		present[name] = true
+	}
+	return present, nil
+}
+
+var testSchemaObjects = []schemaObject{
+	{"idx_a", "index", "ADD INDEX idx_a"},
+	{"idx_b", "index", "ADD INDEX idx_b"},
+	{"a_mv", "materialized view", "CREATE MATERIALIZED VIEW a_mv"},
+}
+
+func TestSchemaEnsurer(t *testing.T) {
+	tests := []struct {
+		name        string
+		present     []string
+		failFirst   int
+		broken      []string
+		retries     int
+		wantMissing []string
+		wantCreates int
+	}{
+		{"all created", nil, 0, nil, 3, nil, 3},
+		{"already present", []string{"idx_a", "a_mv"}, 0, nil, 3, nil, 3},
+		{"transient failure retried", nil, 2, nil, 3, nil, 5},
+		{"permanent failure reported", nil, 0, []string{"a_mv"}, 2, []string{"a_mv"}, 5},
+		{"no retries", nil, 1, nil, -1, []string{"idx_a"}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeSchema{present: map[string]bool{}, failFirst: tt.failFirst, broken: map[string]bool{}}
+			for _, name := range tt.present {
+				f.present[name] = true
+			}
+			for _, name := range tt.broken {
+				f.broken[name] = true
+			}
+			e := &schemaEnsurer{
+				objects:  testSchemaObjects,
+				retries:  tt.retries,
+				delay:    time.Millisecond,
+				existing: f.existing,
+				create:   f.create,
+			}
+
+			missing := e.ensure(context.Background())
+			if len(missing) != len(tt.wantMissing) {
+				t.Fatalf("missing = %v, want %v", missing, tt.wantMissing)
+			}
+			for i, obj := range missing {
+				if obj.name != tt.wantMissing[i] {
+					t.Errorf("missing[%d] = %s, want %s", i, obj.name, tt.wantMissing[i])
+				}
+			}
+			if f.creates != tt.wantCreates {
+				t.Errorf("creates = %d, want %d", f.creates, tt.wantCreates)
+			}
+		})
+	}
+}
+
+func TestSchemaEnsurerStopsOnCancel(t *testing.T) {
+	f := &fakeSchema{present: map[string]bool{}, broken: map[string]bool{"idx_a": true}}
+	e := &schemaEnsurer{
+		objects:  testSchemaObjects,
+		retries:  100,
+		delay:    time.Hour,
+		existing: f.existing,
+		create:   f.create,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	missing := e.ensure(ctx)
+	if len(missing) != 1 || missing[0].name != "idx_a" {
+		t.Errorf("missing = %v, want [idx_a]", missing)
+	}
+}
+
+func TestLogSchemaObjectsNamedInDDL(t *testing.T) {
+	for _, tables := range []clickhouseTables{{}, {cluster: "logs_cluster"}} {
+		for _, obj := range append(append([]schemaObject{}, logIndexes...), logMaterializedViews...) {
+			ddl := tables.expand(obj.ddl)
+			if !strings.Contains(ddl, "IF NOT EXISTS "+obj.name+" ") && !strings.Contains(ddl, "IF NOT EXISTS "+obj.name+"\n") {
+				t.Errorf("cluster %q: %s %s: DDL does not create it", tables.cluster, obj.kind, obj.name)
+			}
+			if strings.Contains(ddl, "{logs}") || strings.Contains(ddl, "{on_cluster}") || strings.Contains(ddl, "{summing}") {
+				t.Errorf("cluster %q: %s %s: unexpanded placeholder in %q", tables.cluster, obj.kind, obj.name, ddl)
+			}
+		}
+	}
+}
+
+func TestClickHouseTables(t *testing.T) {
+	single := clickhouseTables{}
+	if got := single.expand(logIndexes[0].ddl); !strings.HasPrefix(got, "ALTER TABLE logs ADD INDEX") {
+		t.Errorf("single node index DDL = %q", got)
+	}
+	if got := single.expand(logMaterializedViews[0].ddl); !strings.Contains(got, "ENGINE = SummingMergeTree()") || !strings.Contains(got, "FROM logs\n") {
+		t.Errorf("single node view DDL = %q", got)
+	}
+	if got := single.engine(EngineMergeTree); got != "MergeTree()" {
+		t.Errorf("single node engine = %q", got)
+	}
+	if got := single.distributedDDL(); got != "" {
+		t.Errorf("single node distributed DDL = %q, want none", got)
+	}
+	if got := single.system("mutations"); got != "system.mutations" {
+		t.Errorf("single node system table = %q", got)
+	}
+
+	cluster := clickhouseTables{cluster: "prod"}
+	if got := cluster.expand(logIndexes[0].ddl); !strings.HasPrefix(got, "ALTER TABLE logs_local ON CLUSTER 'prod' ADD INDEX") {
+		t.Errorf("cluster index DDL = %q", got)
+	}
+	view := cluster.expand(logMaterializedViews[0].ddl)
+	for _, want := range []string{"logs_hourly_errors_mv ON CLUSTER 'prod'", "ENGINE = ReplicatedSummingMergeTree('/clickhouse/tables/{shard}/{database}/{table}', '{replica}')", "FROM logs_local\n"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("cluster view DDL missing %q:\n%s", want, view)
+		}
+	}
+	if got := cluster.engine(EngineReplacingMergeTree); got != "ReplicatedReplacingMergeTree('/clickhouse/tables/{shard}/{database}/{table}', '{replica}')" {
+		t.Errorf("cluster engine = %q", got)
+	}
+	want := "CREATE TABLE IF NOT EXISTS logs ON CLUSTER 'prod' AS logs_local ENGINE = Distributed('prod', currentDatabase(), logs_local, cityHash64(id))"
+	if got := cluster.distributedDDL(); got != want {
+		t.Errorf("distributed DDL = %q, want %q", got, want)
+	}
+	if got := cluster.system("mutations"); got != "clusterAllReplicas('prod', system.mutations)" {
+		t.Errorf("cluster system table = %q", got)
+	}
+}
+
+func TestValidateClusterName(t *testing.T) {
+	for name, wantErr := range map[string]bool{"": false, "prod": false, "logs-eu.1": false, "a'b": true, "a b": true} {
+		if err := ValidateClusterName(name); (err != nil) != wantErr {
+			t.Errorf("ValidateClusterName(%q) error = %v, wantErr %v", name, err, wantErr)
+		}
+	}
+}
+
+func TestIndexHintObjects(t *testing.T) {
+	tests := []struct {
+		name    string
+		hint    IndexHint
+		wantDDL string
+		wantErr string
+	}{
+		{"column", IndexHint{Column: "agent_id", Type: "bloom_filter(0.01)"},
+			"ALTER TABLE logs ADD INDEX IF NOT EXISTS idx_hint_agent_id agent_id TYPE bloom_filter(0.01) GRANULARITY 4", ""},
+		{"label", IndexHint{Column: "labels.tenant", Type: "set(100)", Granularity: 2},
+			"ALTER TABLE logs ADD INDEX IF NOT EXISTS idx_hint_labels_tenant JSONExtractString(labels, 'tenant') TYPE set(100) GRANULARITY 2", ""},
+		{"field", IndexHint{Column: "fields.request-id", Type: "bloom_filter"},
+			"ALTER TABLE logs ADD INDEX IF NOT EXISTS idx_hint_fields_request_id JSONExtractString(fields, 'request-id') TYPE bloom_filter GRANULARITY 4", ""},
+		{"minmax", IndexHint{Column: "line_number", Type: "minmax"},
+			"ALTER TABLE logs ADD INDEX IF NOT EXISTS idx_hint_line_number line_number TYPE minmax GRANULARITY 4", ""},
+		{"unknown column", IndexHint{Column: "tenant", Type: "minmax"}, "", "unknown column"},
+		{"whole json column", IndexHint{Column: "labels", Type: "bloom_filter"}, "", "unknown column"},
+		{"unsafe key", IndexHint{Column: "labels.a'b", Type: "bloom_filter"}, "", "invalid labels key"},
+		{"unknown type", IndexHint{Column: "source", Type: "hash"}, "", "unsupported index type"},
+		{"set without size", IndexHint{Column: "source", Type: "set"}, "", "unsupported index type"},
+		{"negative granularity", IndexHint{Column: "source", Type: "minmax", Granularity: -1}, "", "granularity"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects, err := indexHintObjects([]IndexHint{tt.hint})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("indexHintObjects() error = %v", err)
+			}
+			if ddl := (clickhouseTables{}).expand(objects[0].ddl); ddl != tt.wantDDL {
+				t.Errorf("ddl = %q, want %q", ddl, tt.wantDDL)
+			}
+			if objects[0].name != tt.hint.Name() {
+				t.Errorf("name = %q, want %q", objects[0].name, tt.hint.Name())
+			}
+		})
+	}
+
+	if _, err := indexHintObjects([]IndexHint{
+		{Column: "labels.a-b", Type: "minmax"},
+		{Column: "labels.a_b", Type: "bloom_filter"},
+	}); err == nil || !strings.Contains(err.Error(), "duplicate") {
+		t.Errorf("same index name twice: error = %v, want duplicate", err)
+	}
+}
+
+func TestRetentionRuleValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    RetentionRule
+		wantErr bool
+	}{
+		{"type", RetentionRule{Type: "security", Days: 365}, false},
+		{"project and label", RetentionRule{ProjectID: "shop", Labels: map[string]string{"env": "dev"}, Days: 7}, false},
+		{"no condition", RetentionRule{Days: 7}, true},
+		{"no days", RetentionRule{Type: "debug"}, true},
+		{"bad label key", RetentionRule{Labels: map[string]string{"a') OR 1 = 1 --": "x"}, Days: 7}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rule.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetentionTTL(t *testing.T) {
+	if got := retentionTTL(nil, 30); got != "_date + INTERVAL 30 DAY DELETE" {
+		t.Errorf("retentionTTL(no rules) = %q", got)
+	}
+
+	rules := []RetentionRule{
+		{Type: "security", Days: 365},
+		{ProjectID: "it's", Labels: map[string]string{"tier": "debug", "env": `dev\`}, Days: 7},
+	}
+	want := `_date + toIntervalDay(multiIf(type = 'security', 365, ` +
+		`project_id = 'it\'s' AND JSONExtractString(labels, 'env') = 'dev\\' AND JSONExtractString(labels, 'tier') = 'debug', 7, 30)) DELETE`
+	if got := retentionTTL(rules, 30); got != want {
+		t.Errorf("retentionTTL() = %q, want %q", got, want)
+	}
+}
@@ -2,8 +2,16 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 // Unit tests (no ClickHouse required)
@@ -126,7 +134,7 @@ func TestLogBuffer_Flush(t *testing.T) {
 	})
 
 	// Manual flush
-	err := buffer.Flush()
+	err := buffer.Flush(context.Background())
 	if err != nil {
 		t.Fatalf("Flush failed: %v", err)
 	}
@@ -136,6 +144,39 @@ func TestLogBuffer_Flush(t *testing.T) {
 	}
 }
 
+func TestLogBuffer_LenAndMetrics(t *testing.T) {
+	mock := &mockLogRepo{}
+
+	buffer := NewLogBuffer(mock, &LogBufferConfig{
+		BatchSize:     100,
+		FlushInterval: time.Hour,
+		MaxSize:       100,
+	})
+	defer buffer.Close()
+
+	buffer.AddBatch([]*LogRecord{{ID: "1"}, {ID: "2"}})
+	if buffer.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", buffer.Len())
+	}
+	if got := testutil.ToFloat64(metrics.BufferDepth); got != 2 {
+		t.Errorf("buffer_depth = %v, want 2", got)
+	}
+
+	flushes := testutil.ToFloat64(metrics.BufferFlushesTotal)
+	if err := buffer.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if buffer.Len() != 0 {
+		t.Errorf("Len() after flush = %d, want 0", buffer.Len())
+	}
+	if got := testutil.ToFloat64(metrics.BufferDepth); got != 0 {
+		t.Errorf("buffer_depth after flush = %v, want 0", got)
+	}
+	if got := testutil.ToFloat64(metrics.BufferFlushesTotal); got != flushes+1 {
+		t.Errorf("flushes_total = %v, want %v", got, flushes+1)
+	}
+}
+
 func TestLogBuffer_Backpressure(t *testing.T) {
 	mock := &mockLogRepo{
 		insertBatchErr: nil,
@@ -167,6 +208,167 @@ func TestLogBuffer_Backpressure(t *testing.T) {
 	}
 }
 
+func TestLogBuffer_DropNew(t *testing.T) {
+	buffer := NewLogBuffer(&mockLogRepo{}, &LogBufferConfig{
+		BatchSize:     100,
+		FlushInterval: time.Hour,
+		MaxSize:       5,
+		OnFull:        OnFullDropNew,
+	})
+	defer buffer.Close()
+
+	buffer.AddBatch([]*LogRecord{{ID: "1"}, {ID: "2"}, {ID: "3"}})
+	buffer.AddBatch([]*LogRecord{{ID: "4"}, {ID: "5"}, {ID: "6"}, {ID: "7"}})
+
+	var ids []string
+	for _, r := range buffer.buffer {
+		ids = append(ids, r.ID)
+	}
+	if got := strings.Join(ids, ","); got != "1,2,3,4,5" {
+		t.Errorf("buffered = %s, want 1,2,3,4,5", got)
+	}
+	if stats := buffer.Stats(); stats.Dropped != 2 {
+		t.Errorf("dropped = %d, want 2", stats.Dropped)
+	}
+}
+
+// gatedRepo fails inserts until opened.
+type gatedRepo struct {
+	LogRepository
+	mu       sync.Mutex
+	open     bool
+	inserted int
+}
+
+func (r *gatedRepo) InsertBatch(ctx context.Context, entries []*LogRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.open {
+		return errors.New("storage down")
+	}
+	r.inserted += len(entries)
+	return nil
+}
+
+func (r *gatedRepo) setOpen(open bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.open = open
+}
+
+func TestLogBuffer_Block(t *testing.T) {
+	repo := &gatedRepo{}
+	buffer := NewLogBuffer(repo, &LogBufferConfig{
+		BatchSize:     100,
+		FlushInterval: 20 * time.Millisecond,
+		MaxSize:       2,
+		OnFull:        OnFullBlock,
+	})
+	defer buffer.Close()
+
+	if err := buffer.AddBatch([]*LogRecord{{ID: "1"}, {ID: "2"}}); err != nil {
+		t.Fatalf("AddBatch failed: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- buffer.AddBatch([]*LogRecord{{ID: "3"}}) }()
+
+	select {
+	case err := <-done:
+		t.Fatalf("AddBatch returned %v while the buffer was full", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	repo.setOpen(true)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("AddBatch failed: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("AddBatch still blocked after storage recovered")
+	}
+	if stats := buffer.Stats(); stats.Dropped != 0 {
+		t.Errorf("dropped = %d, want 0 when blocking", stats.Dropped)
+	}
+}
+
+// slowFailRepo holds each insert until release, then fails it.
+type slowFailRepo struct {
+	LogRepository
+	started chan struct{}
+	release chan struct{}
+}
+
+func (r *slowFailRepo) InsertBatch(ctx context.Context, entries []*LogRecord) error {
+	r.started <- struct{}{}
+	<-r.release
+	return errors.New("storage down")
+}
+
+func TestLogBuffer_BlockCountsInFlight(t *testing.T) {
+	repo := &slowFailRepo{started: make(chan struct{}, 1), release: make(chan struct{})}
+	buffer := NewLogBuffer(repo, &LogBufferConfig{
+		BatchSize:     100,
+		FlushInterval: time.Hour,
+		MaxSize:       2,
+		OnFull:        OnFullBlock,
+	})
+
+	if err := buffer.AddBatch([]*LogRecord{{ID: "1"}, {ID: "2"}}); err != nil {
+		t.Fatalf("AddBatch failed: %v", err)
+	}
+	flushed := make(chan error, 1)
+	go func() { flushed <- buffer.Flush(context.Background()) }()
+	<-repo.started
+
+	// The buffer is empty, but its entries are still being inserted
+	done := make(chan error, 1)
+	go func() { done <- buffer.AddBatch([]*LogRecord{{ID: "3"}}) }()
+	select {
+	case err := <-done:
+		t.Fatalf("AddBatch returned %v while a full buffer was in flight", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(repo.release)
+	if err := <-flushed; err == nil {
+		t.Fatal("Flush succeeded, want the insert error")
+	}
+	if n := buffer.Len(); n != 2 {
+		t.Errorf("Len() = %d after a failed flush, want 2 (max size)", n)
+	}
+
+	buffer.Close()
+	if err := <-done; !errors.Is(err, ErrBufferStopped) {
+		t.Errorf("AddBatch error = %v, want ErrBufferStopped", err)
+	}
+}
+
+func TestLogBuffer_BlockUnblocksOnClose(t *testing.T) {
+	buffer := NewLogBuffer(&gatedRepo{}, &LogBufferConfig{
+		BatchSize:     100,
+		FlushInterval: time.Hour,
+		MaxSize:       1,
+		OnFull:        OnFullBlock,
+	})
+
+	buffer.AddBatch([]*LogRecord{{ID: "1"}})
+	done := make(chan error, 1)
+	go func() { done <- buffer.AddBatch([]*LogRecord{{ID: "2"}}) }()
+	time.Sleep(50 * time.Millisecond)
+
+	buffer.Close()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrBufferStopped) {
+			t.Errorf("AddBatch error = %v, want ErrBufferStopped", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("AddBatch still blocked after Close")
+	}
+}
+
 func TestLogBuffer_Stats(t *testing.T) {
 	mock := &mockLogRepo{}
 
@@ -235,6 +437,18 @@ func (m *mockLogRepo) GetHTTPStats(ctx context.Context, filter *AggregationFilte
 	return &HTTPStatsResult{}, nil
 }
 
+func (m *mockLogRepo) GetFieldPercentile(ctx context.Context, filter *AggregationFilter, field string, percentile float64) (*PercentileResult, error) {
+	return &PercentileResult{}, nil
+}
+
+func (m *mockLogRepo) GetLatencyStats(ctx context.Context, filter *AggregationFilter, field, interval string) (*LatencyStats, error) {
+	return &LatencyStats{}, nil
+}
+
+func (m *mockLogRepo) GetTopErrors(ctx context.Context, filter *AggregationFilter, limit int) ([]*ErrorGroup, error) {
+	return nil, nil
+}
+
 func (m *mockLogRepo) GetByID(ctx context.Context, id string) (*LogRecord, error) {
 	return nil, nil
 }
@@ -324,5 +538,147 @@ func TestHTTPStatsResult_Fields(t *testing.T) {
 	}
 }
 
+func TestErrorMessageMasks(t *testing.T) {
+	// The masks run in ClickHouse; Go's regexp is RE2 too
+	normalize := func(msg string) string {
+		for _, m := range errorMessageMasks {
+			msg = regexp.MustCompile(m.pattern).ReplaceAllString(msg, m.replacement)
+		}
+		return msg
+	}
+
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"Order 1234 not found", "Order <n> not found"},
+		{"user 3f2b8c1e-9a4d-4e6f-8b7a-1c2d3e4f5a6b not authorized", "user <uuid> not authorized"},
+		{"Exception: Order not found in /var/www/app/Order.php:42", "Exception: Order not found in <file>"},
+		{"#0 /var/www/app/Controller.php(17): Order->load()", "#<n> <file>: Order->load()"},
+		{"upstream timed out after 30s to 10.0.0.1:8080", "upstream timed out after <n>s to <n>.<n>.<n>.<n>:<n>"},
+		{"no placeholders here", "no placeholders here"},
+	}
+	for _, tt := range tests {
+		if got := normalize(tt.message); got != tt.want {
+			t.Errorf("normalize(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+
+	expr, args := normalizedMessage()
+	if strings.Count(expr, "?") != len(args) || len(args) != 2*len(errorMessageMasks) {
+		t.Errorf("normalizedMessage() = %q with %d args", expr, len(args))
+	}
+}
+
+func TestClickHouseConfig_InsertSettings(t *testing.T) {
+	if s := (&ClickHouseConfig{}).insertSettings(); s != nil {
+		t.Errorf("insertSettings() = %v, want nil without AsyncInsert", s)
+	}
+
+	s := (&ClickHouseConfig{AsyncInsert: true}).insertSettings()
+	if s["async_insert"] != 1 || s["wait_for_async_insert"] != 1 {
+		t.Errorf("insertSettings() = %v, want async_insert and wait_for_async_insert", s)
+	}
+	if _, ok := s["async_insert_busy_timeout_ms"]; ok {
+		t.Errorf("insertSettings() = %v, want server busy timeout", s)
+	}
+
+	s = (&ClickHouseConfig{AsyncInsert: true, AsyncInsertBusyTimeout: 250 * time.Millisecond}).insertSettings()
+	if got := s["async_insert_busy_timeout_ms"]; got != int64(250) {
+		t.Errorf("async_insert_busy_timeout_ms = %v, want 250", got)
+	}
+}
+
+func TestParseCursor(t *testing.T) {
+	ts := time.Date(2024, 1, 15, 10, 0, 0, 123000000, time.FixedZone("", 3600))
+	id := "3f2b8c1e-9a4d-4e6f-8b7a-1c2d3e4f5a6b"
+
+	tests := []struct {
+		name     string
+		cursor   string
+		wantLine int64
+		wantOK   bool
+	}{
+		{"round trip", formatCursor(ts, 42, id), 42, true},
+		{"without line number", ts.Format(time.RFC3339Nano) + ":" + id, 0, true},
+		{"invalid timestamp", "yesterday:42:" + id, 0, false},
+		{"no separator", id, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotTS, gotLine, gotID, ok := parseCursor(tt.cursor)
+			if ok != tt.wantOK {
+				t.Fatalf("parseCursor(%q) ok = %v, want %v", tt.cursor, ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if !gotTS.Equal(ts) || gotLine != tt.wantLine || gotID != id {
+				t.Errorf("parseCursor(%q) = %v, %d, %q", tt.cursor, gotTS, gotLine, gotID)
+			}
+		})
+	}
+}
+
 // Integration tests are in clickhouse_integration_test.go
 // Run with: go test -tags=integration ./internal/storage/...
+
+func TestBuildQuery_AfterCursor(t *testing.T) {
+	after := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		orderBy   string
+		orderDesc bool
+		wantWhere string
+		wantOrder string
+	}{
+		{"default order", "", false, "(timestamp < ? OR (timestamp = ? AND id < ?))", "ORDER BY timestamp DESC, id DESC"},
+		{"newest first", "timestamp", true, "(timestamp < ? OR (timestamp = ? AND id < ?))", "ORDER BY timestamp DESC, id DESC"},
+		{"oldest first", "timestamp", false, "(timestamp > ? OR (timestamp = ? AND id > ?))", "ORDER BY timestamp ASC, id ASC"},
+		{"unknown column sorts as default", "message", false, "(timestamp < ? OR (timestamp = ? AND id < ?))", "ORDER BY timestamp DESC, id DESC"},
+		{"injection sorts as default", "level; DROP TABLE logs", false, "(timestamp < ? OR (timestamp = ? AND id < ?))", "ORDER BY timestamp DESC, id DESC LIMIT 50"},
+	}
+	r := &clickhouseLogRepo{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := &LogFilter{OrderBy: tt.orderBy, OrderDesc: tt.orderDesc, Limit: 50,
+				AfterTime: after, AfterID: "log-1", Level: "error"}
+			query, args := r.buildQuery(filter, false)
+			if !strings.Contains(query, tt.wantWhere) {
+				t.Errorf("query %q lacks %q", query, tt.wantWhere)
+			}
+			if !strings.Contains(query, tt.wantOrder) {
+				t.Errorf("query %q lacks %q", query, tt.wantOrder)
+			}
+			if strings.Contains(query, "OFFSET") {
+				t.Errorf("query %q has an OFFSET", query)
+			}
+			want := []interface{}{after, after, "log-1", "error"}
+			if fmt.Sprint(args) != fmt.Sprint(want) {
+				t.Errorf("args = %v, want %v", args, want)
+			}
+		})
+	}
+}
+
+func TestBuildQuery_Projection(t *testing.T) {
+	tests := []struct {
+		name       string
+		projection []string
+		wantSelect string
+	}{
+		{"default selects all", nil, "SELECT id, project_id, timestamp, level, message, source, type, raw, agent_id, file_path, line_number, fields, labels, http_status, http_method, uri FROM logs"},
+		{"summary", LogSummaryProjection, "SELECT id, project_id, timestamp, level, message, source, type, agent_id, file_path, line_number, http_status, http_method, uri FROM logs"},
+		{"adds id and timestamp in column order", []string{"message", "level"}, "SELECT id, timestamp, level, message FROM logs"},
+		{"ignores unknown columns", []string{"message", "1; DROP TABLE logs"}, "SELECT id, timestamp, message FROM logs"},
+	}
+	r := &clickhouseLogRepo{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _ := r.buildQuery(&LogFilter{Projection: tt.projection}, false)
+			if !strings.HasPrefix(query, tt.wantSelect) {
+				t.Errorf("query = %q, want prefix %q", query, tt.wantSelect)
+			}
+		})
+	}
+}
@@ -3,24 +3,42 @@ package storage
 import (
 	"context"
 	"errors"
-	"log"
+	"log/slog"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/metrics"
 )
 
 // ErrBufferStopped is returned when AddBatch is called on a stopped buffer.
 var ErrBufferStopped = errors.New("log buffer is stopped")
 
+// flushTimeout bounds a single flush's insert.
+const flushTimeout = 30 * time.Second
+
+// What AddBatch does when the buffer is full (LogBufferConfig.OnFull).
+const (
+	// OnFullDropOldest drops the oldest buffered entries to make room.
+	OnFullDropOldest = "drop_oldest"
+
+	// OnFullDropNew drops the incoming entries that don't fit.
+	OnFullDropNew = "drop_new"
+
+	// OnFullBlock makes AddBatch wait until a flush frees room, so the
+	// caller (an agent's gRPC stream) is slowed down instead of logs being
+	// lost.
+	OnFullBlock = "block"
+)
+
 // LogBuffer buffers log entries for batch insertion.
 // It flushes on either batch size threshold or time interval,
-// whichever comes first. It implements backpressure by dropping
-// oldest entries when the buffer reaches max capacity.
+// whichever comes first. When the buffer reaches max capacity it drops
+// the oldest or the new entries, or blocks AddBatch, per its OnFull policy.
 //
 // Flush ordering guarantee: Entries are flushed in FIFO order within a batch.
 // On flush failure, entries are prepended back to the buffer, preserving order
-// for the next flush attempt. Dropped entries (due to backpressure) are always
-// the oldest entries in the buffer.
+// for the next flush attempt.
 //
 // TOCTOU note: There is a potential time-of-check-time-of-use race between
 // checking buffer size in AddBatch and the actual flush in Flush. This is benign:
@@ -32,9 +50,12 @@ type LogBuffer struct {
 	batchSize     int
 	flushInterval time.Duration
 	maxSize       int
+	onFull        string
 
 	mu       sync.Mutex
+	space    *sync.Cond // signaled when a flush or Close frees room; uses mu
 	buffer   []*LogRecord
+	inFlight int // entries taken by a Flush whose insert hasn't returned
 	stopCh   chan struct{}
 	doneCh   chan struct{}
 	stopped  atomic.Bool
@@ -54,8 +75,12 @@ type LogBufferConfig struct {
 	// FlushInterval is the time interval to trigger a flush.
 	FlushInterval time.Duration
 
-	// MaxSize is the maximum buffer size. When reached, oldest entries are dropped.
+	// MaxSize is the maximum buffer size.
 	MaxSize int
+
+	// OnFull is what happens when MaxSize is reached: OnFullDropOldest
+	// (the default), OnFullDropNew or OnFullBlock.
+	OnFull string
 }
 
 // NewLogBuffer creates a new log buffer.
@@ -70,16 +95,21 @@ func NewLogBuffer(repo LogRepository, config *LogBufferConfig) *LogBuffer {
 	if config.MaxSize == 0 {
 		config.MaxSize = 100000
 	}
+	if config.OnFull == "" {
+		config.OnFull = OnFullDropOldest
+	}
 
 	b := &LogBuffer{
 		repo:          repo,
 		batchSize:     config.BatchSize,
 		flushInterval: config.FlushInterval,
 		maxSize:       config.MaxSize,
+		onFull:        config.OnFull,
 		buffer:        make([]*LogRecord, 0, config.BatchSize),
 		stopCh:        make(chan struct{}),
 		doneCh:        make(chan struct{}),
 	}
+	b.space = sync.NewCond(&b.mu)
 
 	go b.flushLoop()
 	return b
@@ -98,14 +128,31 @@ func (b *LogBuffer) AddBatch(entries []*LogRecord) error {
 
 	b.mu.Lock()
 
+	switch b.onFull {
+	case OnFullBlock:
+		if err := b.waitForRoom(len(entries)); err != nil {
+			b.mu.Unlock()
+			return err
+		}
+	case OnFullDropNew:
+		if room := b.maxSize - len(b.buffer); len(entries) > room {
+			if room < 0 {
+				room = 0
+			}
+			b.drop(len(entries) - room)
+			slog.Warn("log buffer overflow, dropped new entries", "count", len(entries)-room)
+			entries = entries[:room]
+		}
+	}
+
 	// Check if we need to drop old entries (backpressure)
 	newLen := len(b.buffer) + len(entries)
-	if newLen > b.maxSize {
+	if b.onFull == OnFullDropOldest && newLen > b.maxSize {
 		// Calculate how many to drop
 		toDrop := newLen - b.maxSize
 		if toDrop >= len(b.buffer) {
 			// Drop all existing + some new (extreme case)
-			b.dropped.Add(int64(len(b.buffer)))
+			b.drop(len(b.buffer))
 			b.buffer = b.buffer[:0]
 			// Only keep entries that fit
 			keep := b.maxSize
@@ -113,29 +160,80 @@ func (b *LogBuffer) AddBatch(entries []*LogRecord) error {
 				keep = len(entries)
 			}
 			drop := len(entries) - keep
-			b.dropped.Add(int64(drop))
+			b.drop(drop)
 			entries = entries[drop:]
-			log.Printf("warning: log buffer overflow, dropped %d entries", toDrop)
+			slog.Warn("log buffer overflow, dropped entries", "count", toDrop)
 		} else {
 			// Drop oldest from existing buffer
-			b.dropped.Add(int64(toDrop))
+			b.drop(toDrop)
 			b.buffer = b.buffer[toDrop:]
-			log.Printf("warning: log buffer overflow, dropped %d oldest entries", toDrop)
+			slog.Warn("log buffer overflow, dropped oldest entries", "count", toDrop)
 		}
 	}
 
 	b.buffer = append(b.buffer, entries...)
 	shouldFlush := len(b.buffer) >= b.batchSize
+	metrics.BufferDepth.Set(float64(len(b.buffer)))
 	b.mu.Unlock()
 
 	if shouldFlush {
-		return b.Flush()
+		return b.Flush(context.Background())
+	}
+	return nil
+}
+
+// waitForRoom blocks until n more entries fit in the buffer, flushing to
+// make room. Entries being inserted still count: a failed insert puts them
+// back. A batch larger than the whole buffer is let in once nothing is
+// pending. Failed flushes are retried by the flush loop, so the wait ends
+// once storage recovers or the buffer is closed. Callers hold b.mu.
+func (b *LogBuffer) waitForRoom(n int) error {
+	for b.pending() > 0 && b.pending()+n > b.maxSize {
+		if b.stopped.Load() {
+			return ErrBufferStopped
+		}
+		if len(b.buffer) > 0 {
+			b.mu.Unlock()
+			err := b.Flush(context.Background())
+			b.mu.Lock()
+			if err == nil {
+				continue
+			}
+		}
+		// Another flush is inserting, or ours failed: wait for one to succeed
+		if b.pending() > 0 && b.pending()+n > b.maxSize && !b.stopped.Load() {
+			b.space.Wait()
+		}
+	}
+	if b.stopped.Load() {
+		return ErrBufferStopped
 	}
 	return nil
 }
 
-// Flush forces a flush of the current buffer.
-func (b *LogBuffer) Flush() error {
+// pending returns the buffered and in-flight entries. Callers hold b.mu.
+func (b *LogBuffer) pending() int {
+	return len(b.buffer) + b.inFlight
+}
+
+// drop records n entries dropped because the buffer was full. Callers hold
+// b.mu.
+func (b *LogBuffer) drop(n int) {
+	b.dropped.Add(int64(n))
+	metrics.BufferDroppedOnFullTotal.Add(float64(n))
+}
+
+// Len returns the number of entries waiting to be flushed.
+func (b *LogBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.buffer)
+}
+
+// Flush writes the current buffer to storage, waiting at most until ctx is
+// done (and no longer than 30s). On failure the entries are put back to be
+// flushed next.
+func (b *LogBuffer) Flush(ctx context.Context) error {
 	b.mu.Lock()
 	if len(b.buffer) == 0 {
 		b.mu.Unlock()
@@ -144,27 +242,49 @@ func (b *LogBuffer) Flush() error {
 
 	toFlush := b.buffer
 	b.buffer = make([]*LogRecord, 0, b.batchSize)
+	b.inFlight += len(toFlush)
+	metrics.BufferDepth.Set(0)
 	b.mu.Unlock()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, flushTimeout)
 	defer cancel()
 
-	if err := b.repo.InsertBatch(ctx, toFlush); err != nil {
+	start := time.Now()
+	err := b.repo.InsertBatch(ctx, toFlush)
+	metrics.BufferFlushDuration.Observe(time.Since(start).Seconds())
+	metrics.BufferFlushesTotal.Inc()
+	if err != nil {
+		metrics.BufferFlushErrors.Inc()
 		// Put entries back on error (at front so they're flushed next)
 		b.mu.Lock()
+		b.inFlight -= len(toFlush)
 		b.buffer = append(toFlush, b.buffer...)
-		// Apply max size limit again
-		if len(b.buffer) > b.maxSize {
-			excess := len(b.buffer) - b.maxSize
-			b.dropped.Add(int64(excess))
-			b.buffer = b.buffer[excess:]
+		// Apply max size limit again; blocking keeps everything, as
+		// AddBatch counted these entries while they were in flight
+		if excess := len(b.buffer) - b.maxSize; excess > 0 {
+			switch b.onFull {
+			case OnFullDropOldest:
+				b.drop(excess)
+				b.buffer = b.buffer[excess:]
+			case OnFullDropNew:
+				b.drop(excess)
+				b.buffer = b.buffer[:b.maxSize]
+			}
 		}
+		metrics.BufferDepth.Set(float64(len(b.buffer)))
 		b.mu.Unlock()
 		return err
 	}
 
+	// Room is only freed once the entries are stored
+	b.mu.Lock()
+	b.inFlight -= len(toFlush)
+	b.space.Broadcast()
+	b.mu.Unlock()
+
 	b.flushed.Add(1)
 	b.inserted.Add(int64(len(toFlush)))
+	metrics.BufferInsertedTotal.Add(float64(len(toFlush)))
 	return nil
 }
 
@@ -177,13 +297,13 @@ func (b *LogBuffer) flushLoop() {
 	for {
 		select {
 		case <-ticker.C:
-			if err := b.Flush(); err != nil {
-				log.Printf("log buffer flush error: %v", err)
+			if err := b.Flush(context.Background()); err != nil {
+				slog.Error("log buffer flush", "error", err)
 			}
 		case <-b.stopCh:
 			// Final flush on shutdown
-			if err := b.Flush(); err != nil {
-				log.Printf("log buffer final flush error: %v", err)
+			if err := b.Flush(context.Background()); err != nil {
+				slog.Error("log buffer final flush", "error", err)
 				b.flushErr = err
 			}
 			return
@@ -197,6 +317,10 @@ func (b *LogBuffer) Close() error {
 	if b.stopped.Swap(true) {
 		return nil // Already stopped
 	}
+	// Wake blocked AddBatch calls so they fail instead of waiting forever
+	b.mu.Lock()
+	b.space.Broadcast()
+	b.mu.Unlock()
 	close(b.stopCh)
 	<-b.doneCh
 	return b.flushErr
@@ -204,12 +328,8 @@ func (b *LogBuffer) Close() error {
 
 // Stats returns buffer statistics.
 func (b *LogBuffer) Stats() LogBufferStats {
-	b.mu.Lock()
-	pending := len(b.buffer)
-	b.mu.Unlock()
-
 	return LogBufferStats{
-		Pending:  pending,
+		Pending:  b.Len(),
 		Dropped:  b.dropped.Load(),
 		Flushed:  b.flushed.Load(),
 		Inserted: b.inserted.Load(),
@@ -221,7 +341,8 @@ type LogBufferStats struct {
 	// Pending is the number of entries waiting to be flushed.
 	Pending int
 
-	// Dropped is the total number of entries dropped due to backpressure.
+	// Dropped is the total number of entries dropped because the buffer
+	// was full.
 	Dropped int64
 
 	// Flushed is the total number of flush operations.
@@ -67,6 +67,19 @@ type LogRepository interface {
 
 	// GetHTTPStats returns HTTP status code distribution.
 	GetHTTPStats(ctx context.Context, filter *AggregationFilter) (*HTTPStatsResult, error)
+
+	// GetFieldPercentile returns a percentile (0-1) of a numeric entry in
+	// Fields (e.g. request_time) over the logs that have it.
+	GetFieldPercentile(ctx context.Context, filter *AggregationFilter, field string, percentile float64) (*PercentileResult, error)
+
+	// GetLatencyStats returns the p50/p90/p95/p99 of a numeric entry in
+	// Fields (e.g. request_time_ms), overall and per interval ("minute",
+	// "hour", "day").
+	GetLatencyStats(ctx context.Context, filter *AggregationFilter, field, interval string) (*LatencyStats, error)
+
+	// GetTopErrors returns the most frequent error and fatal messages,
+	// grouped with UUIDs, file:line references and numbers masked.
+	GetTopErrors(ctx context.Context, filter *AggregationFilter, limit int) ([]*ErrorGroup, error)
 }
 
 // LogRecord represents a log entry for storage.
@@ -144,16 +157,134 @@ type LogFilter struct {
 	Limit  int
 	Offset int
 
-	// Sorting (default: timestamp DESC).
-	OrderBy   string // "timestamp", "level"
+	// Keyset pagination: only rows past (AfterTime, AfterID) in timestamp,
+	// id order. Unlike Offset it doesn't scan the skipped rows. Requires
+	// ordering by timestamp.
+	AfterTime time.Time
+	AfterID   string
+
+	// SkipTotal leaves out the count Query runs for Total when more rows
+	// match; Total is then only the rows up to this page. For callers that
+	// page to the end anyway.
+	SkipTotal bool
+
+	// Sorting (default: timestamp DESC). Columns outside logOrderColumns
+	// sort as the default.
+	OrderBy   string // "timestamp", "level", "source", "type", "agent_id", "http_status"
 	OrderDesc bool
 
+	// Projection lists the logColumns Query selects (default: all). Other
+	// LogRecord fields are left zero, except ID and Timestamp, which are
+	// always selected for cursors. Unknown columns are ignored.
+	Projection []string
+
 	// DSL filter (takes precedence over flat filters if set).
 	FilterExpr string // Original expression for display/debugging.
 	FilterSQL  string // Generated SQL WHERE clause.
 	FilterArgs []any  // SQL parameters.
 }
 
+// logOrderColumns are the columns LogFilter.OrderBy may name. Query
+// implementations only sort by these, so OrderBy is safe to fill from user
+// input whatever the caller validates.
+var logOrderColumns = map[string]bool{
+	"timestamp":   true,
+	"level":       true,
+	"source":      true,
+	"type":        true,
+	"agent_id":    true,
+	"http_status": true,
+}
+
+// orderColumn returns the column to sort by: OrderBy if allowlisted, else
+// timestamp.
+func (f *LogFilter) orderColumn() string {
+	if logOrderColumns[f.OrderBy] {
+		return f.OrderBy
+	}
+	return "timestamp"
+}
+
+// ascending reports whether to sort in ascending order. Only an allowlisted
+// OrderBy without OrderDesc does; anything else sorts as by default, newest
+// first.
+func (f *LogFilter) ascending() bool {
+	return logOrderColumns[f.OrderBy] && !f.OrderDesc
+}
+
+// logColumns are the logs columns a LogRecord is scanned from, in scan
+// order.
+var logColumns = []string{
+	"id", "project_id", "timestamp", "level", "message", "source", "type", "raw",
+	"agent_id", "file_path", "line_number", "fields", "labels",
+	"http_status", "http_method", "uri",
+}
+
+// LogSummaryProjection selects every column but the raw line and the fields
+// and labels JSON, for listings that show only the summary of each log.
+var LogSummaryProjection = []string{
+	"id", "project_id", "timestamp", "level", "message", "source", "type",
+	"agent_id", "file_path", "line_number", "http_status", "http_method", "uri",
+}
+
+// projection returns the columns Query selects, in logColumns order.
+func (f *LogFilter) projection() []string {
+	if len(f.Projection) == 0 {
+		return logColumns
+	}
+	want := map[string]bool{"id": true, "timestamp": true}
+	for _, c := range f.Projection {
+		want[c] = true
+	}
+	columns := make([]string, 0, len(want))
+	for _, c := range logColumns {
+		if want[c] {
+			columns = append(columns, c)
+		}
+	}
+	return columns
+}
+
+// logColumnDest returns where to scan column into entry. The fields and
+// labels columns scan as JSON text into fieldsJSON and labelsJSON.
+func logColumnDest(entry *LogRecord, column string, fieldsJSON, labelsJSON *string) interface{} {
+	switch column {
+	case "id":
+		return &entry.ID
+	case "project_id":
+		return &entry.ProjectID
+	case "timestamp":
+		return &entry.Timestamp
+	case "level":
+		return &entry.Level
+	case "message":
+		return &entry.Message
+	case "source":
+		return &entry.Source
+	case "type":
+		return &entry.Type
+	case "raw":
+		return &entry.Raw
+	case "agent_id":
+		return &entry.AgentID
+	case "file_path":
+		return &entry.FilePath
+	case "line_number":
+		return &entry.LineNumber
+	case "fields":
+		return fieldsJSON
+	case "labels":
+		return labelsJSON
+	case "http_status":
+		return &entry.HTTPStatus
+	case "http_method":
+		return &entry.HTTPMethod
+	case "uri":
+		return &entry.URI
+	}
+	return nil
+}
+
 // LogQueryResult contains query results with pagination info.
 type LogQueryResult struct {
 	// Entries contains the matching log records.
@@ -209,6 +340,43 @@ type HTTPStatsResult struct {
 	TopURIs  []*URICount
 }
 
+// PercentileResult contains a percentile of a numeric field.
+type PercentileResult struct {
+	Value   float64
+	Samples int64 // logs with a numeric value for the field (0 = Value is meaningless)
+}
+
+// LatencyQuantiles contains the quantiles of a numeric field.
+type LatencyQuantiles struct {
+	Samples int64 // logs with a numeric value for the field (0 = quantiles are meaningless)
+	P50     float64
+	P90     float64
+	P95     float64
+	P99     float64
+}
+
+// LatencyPoint represents the quantiles of one time bucket.
+type LatencyPoint struct {
+	Timestamp time.Time
+	LatencyQuantiles
+}
+
+// LatencyStats contains the quantiles of a numeric field over a time
+// range, and per time bucket. Buckets without samples are left out.
+type LatencyStats struct {
+	LatencyQuantiles
+	Series []*LatencyPoint
+}
+
+// ErrorGroup represents error and fatal logs whose messages differ only in
+// masked parts.
+type ErrorGroup struct {
+	Pattern  string // normalized message, e.g. "Order <n> not found in <file>"
+	Count    int64
+	Example  string // one of the messages as logged
+	LastSeen time.Time
+}
+
 // URICount represents request count per URI.
 type URICount struct {
 	URI   string
@@ -220,11 +388,13 @@ type ContextFilter struct {
 	TargetID     string    // Anchor log UUID
 	ProjectID    string    // For access filtering
 	AgentID      string    // Group by same agent
+	FilePath     string    // Optional: only lines of the same file
 	Timestamp    time.Time // Anchor timestamp
+	LineNumber   int64     // Anchor line number, orders lines logged in the same instant
 	Before       int       // Count before (max 50)
 	After        int       // Count after (max 50)
-	BeforeCursor string    // Optional: timestamp:id for pagination
-	AfterCursor  string    // Optional: timestamp:id for pagination
+	BeforeCursor string    // Optional: timestamp:line:id for pagination
+	AfterCursor  string    // Optional: timestamp:line:id for pagination
 }
 
 // ContextResult contains logs surrounding a target log entry.
@@ -0,0 +1,834 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/good-yellow-bee/blazelog/internal/query"
+	"github.com/google/uuid"
+)
+
+// MemoryMaxEntries is how many logs MemoryLogStorage keeps; inserting more
+// drops the oldest inserted.
+const MemoryMaxEntries = 100000
+
+// MemoryLogStorage implements LogStorage in memory, for demos and tests.
+// It scans every entry on each query, so it suits small volumes only, and
+// loses everything on restart.
+type MemoryLogStorage struct {
+	logs *memoryLogRepo
+}
+
+// NewMemoryLogStorage creates a new in-memory log storage.
+func NewMemoryLogStorage() *MemoryLogStorage {
+	return &MemoryLogStorage{logs: &memoryLogRepo{maxEntries: MemoryMaxEntries}}
+}
+
+// Open is a no-op.
+func (s *MemoryLogStorage) Open() error { return nil }
+
+// Close is a no-op; the logs stay readable.
+func (s *MemoryLogStorage) Close() error { return nil }
+
+// Migrate is a no-op.
+func (s *MemoryLogStorage) Migrate() error { return nil }
+
+// Ping always succeeds.
+func (s *MemoryLogStorage) Ping(ctx context.Context) error { return nil }
+
+// Logs returns the log repository.
+func (s *MemoryLogStorage) Logs() LogRepository {
+	return s.logs
+}
+
+// memoryLogRepo implements LogRepository over a slice in insertion order.
+type memoryLogRepo struct {
+	mu         sync.RWMutex
+	entries    []*LogRecord
+	maxEntries int
+}
+
+// cloneLogRecord copies a record so callers can't change stored entries.
+func cloneLogRecord(r *LogRecord) *LogRecord {
+	c := *r
+	if r.Fields != nil {
+		c.Fields = make(map[string]interface{}, len(r.Fields))
+		for k, v := range r.Fields {
+			c.Fields[k] = v
+		}
+	}
+	if r.Labels != nil {
+		c.Labels = make(map[string]string, len(r.Labels))
+		for k, v := range r.Labels {
+			c.Labels[k] = v
+		}
+	}
+	return &c
+}
+
+// projectLogRecord clears the fields of r outside columns, as the SQL
+// backends leave the columns they don't select.
+func projectLogRecord(r *LogRecord, columns []string) {
+	selected := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		selected[c] = true
+	}
+	var fieldsJSON, labelsJSON string
+	for _, c := range logColumns {
+		if selected[c] {
+			continue
+		}
+		switch dest := logColumnDest(r, c, &fieldsJSON, &labelsJSON).(type) {
+		case *string:
+			*dest = ""
+		case *int:
+			*dest = 0
+		case *int64:
+			*dest = 0
+		}
+	}
+	if !selected["fields"] {
+		r.Fields = nil
+	}
+	if !selected["labels"] {
+		r.Labels = nil
+	}
+}
+
+// InsertBatch appends the entries, assigning IDs to those without one.
+func (r *memoryLogRepo) InsertBatch(ctx context.Context, entries []*LogRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, entry := range entries {
+		c := cloneLogRecord(entry)
+		if c.ID == "" {
+			c.ID = uuid.New().String()
+		}
+		c.Timestamp = c.Timestamp.UTC()
+		r.entries = append(r.entries, c)
+	}
+	if over := len(r.entries) - r.maxEntries; r.maxEntries > 0 && over > 0 {
+		r.entries = append([]*LogRecord(nil), r.entries[over:]...)
+	}
+	return nil
+}
+
+// hasToken reports whether token is one of the words of s, split on
+// non-alphanumerics, as ClickHouse's hasToken does.
+func hasToken(s, token string) bool {
+	for _, word := range strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if word == token {
+			return true
+		}
+	}
+	return false
+}
+
+// inProjects reports whether projectID passes a project filter; no filter
+// passes everything.
+func inProjects(projectID, only string, ids []string, includeUnassigned bool) bool {
+	if only != "" {
+		return projectID == only
+	}
+	if len(ids) == 0 && !includeUnassigned {
+		return true
+	}
+	if includeUnassigned && projectID == "" {
+		return true
+	}
+	for _, id := range ids {
+		if projectID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesLogFilter reports whether e matches the flat filters of f.
+func matchesLogFilter(e *LogRecord, f *LogFilter) bool {
+	if !inLogWindow(e, f) {
+		return false
+	}
+	if f.AgentID != "" && e.AgentID != f.AgentID {
+		return false
+	}
+	if f.Level != "" && e.Level != f.Level {
+		return false
+	}
+	if len(f.Levels) > 0 && !containsString(f.Levels, e.Level) {
+		return false
+	}
+	if f.Type != "" && e.Type != f.Type {
+		return false
+	}
+	if len(f.Types) > 0 && !containsString(f.Types, e.Type) {
+		return false
+	}
+	if f.Source != "" && e.Source != f.Source {
+		return false
+	}
+	if f.FilePath != "" && e.FilePath != f.FilePath {
+		return false
+	}
+	if f.MessageContains != "" {
+		switch f.SearchMode {
+		case SearchModeSubstring:
+			return strings.Contains(e.Message, f.MessageContains)
+		case SearchModePhrase:
+			for _, word := range strings.Fields(f.MessageContains) {
+				if !hasToken(e.Message, word) {
+					return false
+				}
+			}
+		default: // SearchModeToken
+			return hasToken(e.Message, f.MessageContains)
+		}
+	}
+	return true
+}
+
+// logMatcher matches entries against a LogFilter. A DSL filter is
+// evaluated against each entry and takes precedence over the flat filters,
+// as in the SQL backends.
+type logMatcher struct {
+	filter    *LogFilter
+	parsed    *query.ParsedQuery // nil without a DSL filter
+	evaluator *query.Evaluator
+
+	// err is the first error evaluating the DSL filter; once set, nothing
+	// matches.
+	err error
+}
+
+// newLogMatcher creates a matcher for f, parsing its DSL filter if set.
+func newLogMatcher(f *LogFilter) (*logMatcher, error) {
+	m := &logMatcher{filter: f}
+	if f.FilterSQL == "" {
+		return m, nil
+	}
+	if f.FilterExpr == "" {
+		return nil, ErrFilterUnsupported
+	}
+	parsed, err := query.NewQueryDSL(query.DefaultFields).Parse(f.FilterExpr)
+	if err != nil {
+		return nil, fmt.Errorf("parse filter: %w", err)
+	}
+	m.parsed, m.evaluator = parsed, query.NewEvaluator(query.DefaultFields)
+	return m, nil
+}
+
+// match reports whether e matches the filter.
+func (m *logMatcher) match(e *LogRecord) bool {
+	if m.parsed == nil {
+		return matchesLogFilter(e, m.filter)
+	}
+	if m.err != nil || !inLogWindow(e, m.filter) {
+		return false
+	}
+	ok, err := m.evaluator.Match(m.parsed, logRecordEnv(e))
+	if err != nil {
+		m.err = fmt.Errorf("evaluate filter: %w", err)
+	}
+	return ok
+}
+
+// logRecordEnv returns the query fields of e, by name, for the DSL
+// evaluator.
+func logRecordEnv(e *LogRecord) map[string]any {
+	return map[string]any{
+		"project_id":  e.ProjectID,
+		"level":       e.Level,
+		"message":     e.Message,
+		"source":      e.Source,
+		"type":        e.Type,
+		"agent_id":    e.AgentID,
+		"file_path":   e.FilePath,
+		"timestamp":   e.Timestamp,
+		"http_status": e.HTTPStatus,
+		"http_method": e.HTTPMethod,
+		"uri":         e.URI,
+		"fields":      e.Fields,
+		"labels":      e.Labels,
+	}
+}
+
+// inLogWindow reports whether e is in the time range, projects and cursor
+// of f, which apply with a DSL filter too.
+func inLogWindow(e *LogRecord, f *LogFilter) bool {
+	if !f.StartTime.IsZero() && e.Timestamp.Before(f.StartTime) {
+		return false
+	}
+	if !f.EndTime.IsZero() && e.Timestamp.After(f.EndTime) {
+		return false
+	}
+	if !inProjects(e.ProjectID, f.ProjectID, f.ProjectIDs, f.IncludeUnassigned) {
+		return false
+	}
+	if f.AfterID != "" {
+		// Keyset cursor, in the direction of the sort
+		c := compareTimeID(e, f.AfterTime, f.AfterID)
+		if f.ascending() {
+			if c <= 0 {
+				return false
+			}
+		} else if c >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// containsString reports whether values contains s.
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// compareTimeID orders e against a (timestamp, id) position.
+func compareTimeID(e *LogRecord, ts time.Time, id string) int {
+	switch {
+	case e.Timestamp.Before(ts):
+		return -1
+	case e.Timestamp.After(ts):
+		return 1
+	}
+	return strings.Compare(e.ID, id)
+}
+
+// matching returns copies of the stored entries match accepts.
+func (r *memoryLogRepo) matching(match func(e *LogRecord) bool) []*LogRecord {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var out []*LogRecord
+	for _, e := range r.entries {
+		if match(e) {
+			out = append(out, cloneLogRecord(e))
+		}
+	}
+	return out
+}
+
+// sortLogs sorts entries by the filter's order, as the ClickHouse query
+// builder does: an allowlisted column (default timestamp, newest first),
+// with ties on timestamp broken by ID.
+func sortLogs(entries []*LogRecord, f *LogFilter) {
+	desc := !f.ascending()
+	column := f.orderColumn()
+	key := func(e *LogRecord) string {
+		switch column {
+		case "level":
+			return e.Level
+		case "source":
+			return e.Source
+		case "type":
+			return e.Type
+		case "agent_id":
+			return e.AgentID
+		}
+		return ""
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		var c int
+		switch column {
+		case "level", "source", "type", "agent_id":
+			c = strings.Compare(key(a), key(b))
+		case "http_status":
+			c = a.HTTPStatus - b.HTTPStatus
+		default:
+			c = compareTimeID(a, b.Timestamp, b.ID)
+		}
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+}
+
+// Query retrieves logs matching the filter.
+func (r *memoryLogRepo) Query(ctx context.Context, filter *LogFilter) (*LogQueryResult, error) {
+	m, err := newLogMatcher(filter)
+	if err != nil {
+		return nil, err
+	}
+	entries := r.matching(m.match)
+	if m.err != nil {
+		return nil, m.err
+	}
+	sortLogs(entries, filter)
+
+	total := len(entries)
+	limit := filter.Limit
+	if limit == 0 {
+		limit = 100 // Default limit
+	}
+	start := min(filter.Offset, total)
+	end := min(start+limit, total)
+	if len(filter.Projection) > 0 {
+		columns := filter.projection()
+		for _, e := range entries[start:end] {
+			projectLogRecord(e, columns)
+		}
+	}
+
+	result := &LogQueryResult{
+		Entries: entries[start:end],
+		Total:   int64(total),
+		HasMore: end < total,
+	}
+	if filter.SkipTotal {
+		result.Total = int64(end)
+	}
+	return result, nil
+}
+
+// Count returns the count of logs matching the filter.
+func (r *memoryLogRepo) Count(ctx context.Context, filter *LogFilter) (int64, error) {
+	m, err := newLogMatcher(filter)
+	if err != nil {
+		return 0, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var n int64
+	for _, e := range r.entries {
+		if m.match(e) {
+			n++
+		}
+	}
+	if m.err != nil {
+		return 0, m.err
+	}
+	return n, nil
+}
+
+// DeleteBefore removes logs older than the specified time.
+func (r *memoryLogRepo) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.entries[:0]
+	for _, e := range r.entries {
+		if !e.Timestamp.Before(before) {
+			kept = append(kept, e)
+		}
+	}
+	deleted := int64(len(r.entries) - len(kept))
+	clear(r.entries[len(kept):])
+	r.entries = kept
+	return deleted, nil
+}
+
+// aggregated returns the entries an aggregation filter selects.
+func (r *memoryLogRepo) aggregated(f *AggregationFilter) []*LogRecord {
+	return r.matching(func(e *LogRecord) bool {
+		if !f.StartTime.IsZero() && e.Timestamp.Before(f.StartTime) {
+			return false
+		}
+		if !f.EndTime.IsZero() && e.Timestamp.After(f.EndTime) {
+			return false
+		}
+		if f.AgentID != "" && e.AgentID != f.AgentID {
+			return false
+		}
+		if f.Type != "" && e.Type != f.Type {
+			return false
+		}
+		return inProjects(e.ProjectID, f.ProjectID, f.ProjectIDs, f.IncludeUnassigned)
+	})
+}
+
+// isErrorLevel reports whether level counts as an error in stats.
+func isErrorLevel(level string) bool {
+	return level == "error" || level == "fatal"
+}
+
+// GetErrorRates returns error statistics for the given filter.
+func (r *memoryLogRepo) GetErrorRates(ctx context.Context, filter *AggregationFilter) (*ErrorRateResult, error) {
+	result := &ErrorRateResult{}
+	for _, e := range r.aggregated(filter) {
+		result.TotalLogs++
+		switch e.Level {
+		case "error":
+			result.ErrorCount++
+		case "warning":
+			result.WarningCount++
+		case "fatal":
+			result.FatalCount++
+		}
+	}
+	if result.TotalLogs > 0 {
+		result.ErrorRate = float64(result.ErrorCount+result.FatalCount) / float64(result.TotalLogs)
+	}
+	return result, nil
+}
+
+// GetTopSources returns the top sources by log count.
+func (r *memoryLogRepo) GetTopSources(ctx context.Context, filter *AggregationFilter, limit int) ([]*SourceCount, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	bySource := make(map[string]*SourceCount)
+	for _, e := range r.aggregated(filter) {
+		sc, ok := bySource[e.Source]
+		if !ok {
+			sc = &SourceCount{Source: e.Source}
+			bySource[e.Source] = sc
+		}
+		sc.Count++
+		if isErrorLevel(e.Level) {
+			sc.ErrorCount++
+		}
+	}
+
+	results := make([]*SourceCount, 0, len(bySource))
+	for _, sc := range bySource {
+		results = append(results, sc)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Count != results[j].Count {
+			return results[i].Count > results[j].Count
+		}
+		return results[i].Source < results[j].Source
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// truncateInterval returns the start of the UTC interval ("minute", "hour"
+// or "day"; default hour) holding t.
+func truncateInterval(t time.Time, interval string) time.Time {
+	switch interval {
+	case "minute":
+		return t.UTC().Truncate(time.Minute)
+	case "day":
+		return t.UTC().Truncate(24 * time.Hour)
+	default: // hour
+		return t.UTC().Truncate(time.Hour)
+	}
+}
+
+// GetLogVolume returns time-series log volume data.
+func (r *memoryLogRepo) GetLogVolume(ctx context.Context, filter *AggregationFilter, interval string) ([]*VolumePoint, error) {
+	byTime := make(map[time.Time]*VolumePoint)
+	for _, e := range r.aggregated(filter) {
+		ts := truncateInterval(e.Timestamp, interval)
+		vp, ok := byTime[ts]
+		if !ok {
+			vp = &VolumePoint{Timestamp: ts}
+			byTime[ts] = vp
+		}
+		vp.TotalCount++
+		if isErrorLevel(e.Level) {
+			vp.ErrorCount++
+		}
+	}
+
+	results := make([]*VolumePoint, 0, len(byTime))
+	for _, vp := range byTime {
+		results = append(results, vp)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Timestamp.Before(results[j].Timestamp) })
+	return results, nil
+}
+
+// GetHTTPStats returns HTTP status code distribution.
+func (r *memoryLogRepo) GetHTTPStats(ctx context.Context, filter *AggregationFilter) (*HTTPStatsResult, error) {
+	result := &HTTPStatsResult{}
+	byURI := make(map[string]int64)
+	for _, e := range r.aggregated(filter) {
+		switch {
+		case e.HTTPStatus >= 200 && e.HTTPStatus < 300:
+			result.Total2xx++
+		case e.HTTPStatus >= 300 && e.HTTPStatus < 400:
+			result.Total3xx++
+		case e.HTTPStatus >= 400 && e.HTTPStatus < 500:
+			result.Total4xx++
+		case e.HTTPStatus >= 500 && e.HTTPStatus < 600:
+			result.Total5xx++
+		}
+		if e.HTTPStatus > 0 && e.URI != "" {
+			byURI[e.URI]++
+		}
+	}
+
+	for uri, n := range byURI {
+		result.TopURIs = append(result.TopURIs, &URICount{URI: uri, Count: n})
+	}
+	sort.Slice(result.TopURIs, func(i, j int) bool {
+		if result.TopURIs[i].Count != result.TopURIs[j].Count {
+			return result.TopURIs[i].Count > result.TopURIs[j].Count
+		}
+		return result.TopURIs[i].URI < result.TopURIs[j].URI
+	})
+	if len(result.TopURIs) > 10 {
+		result.TopURIs = result.TopURIs[:10]
+	}
+	return result, nil
+}
+
+// numericFieldValue reads a Fields entry as a number. Numbers stored as
+// strings ("0.25") count too.
+func numericFieldValue(fields map[string]interface{}, field string) (float64, bool) {
+	switch v := fields[field].(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// quantile returns the q quantile of sorted values, interpolating between
+// the closest ranks.
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	pos := q * float64(len(sorted)-1)
+	lo := int(pos)
+	if lo+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lo] + (pos-float64(lo))*(sorted[lo+1]-sorted[lo])
+}
+
+// latencyQuantiles returns the quantiles of values, sorting them.
+func latencyQuantiles(values []float64) LatencyQuantiles {
+	sort.Float64s(values)
+	q := LatencyQuantiles{Samples: int64(len(values))}
+	if len(values) > 0 {
+		q.P50, q.P90, q.P95, q.P99 = quantile(values, 0.5), quantile(values, 0.9), quantile(values, 0.95), quantile(values, 0.99)
+	}
+	return q
+}
+
+// GetFieldPercentile returns a percentile of a numeric entry in Fields.
+func (r *memoryLogRepo) GetFieldPercentile(ctx context.Context, filter *AggregationFilter, field string, percentile float64) (*PercentileResult, error) {
+	if percentile <= 0 || percentile >= 1 {
+		return nil, fmt.Errorf("percentile must be between 0 and 1, got %g", percentile)
+	}
+	var values []float64
+	for _, e := range r.aggregated(filter) {
+		if v, ok := numericFieldValue(e.Fields, field); ok {
+			values = append(values, v)
+		}
+	}
+	sort.Float64s(values)
+	return &PercentileResult{Value: quantile(values, percentile), Samples: int64(len(values))}, nil
+}
+
+// GetLatencyStats returns the p50/p90/p95/p99 of a numeric entry in
+// Fields, overall and per interval.
+func (r *memoryLogRepo) GetLatencyStats(ctx context.Context, filter *AggregationFilter, field, interval string) (*LatencyStats, error) {
+	var all []float64
+	byTime := make(map[time.Time][]float64)
+	for _, e := range r.aggregated(filter) {
+		if v, ok := numericFieldValue(e.Fields, field); ok {
+			all = append(all, v)
+			ts := truncateInterval(e.Timestamp, interval)
+			byTime[ts] = append(byTime[ts], v)
+		}
+	}
+
+	result := &LatencyStats{LatencyQuantiles: latencyQuantiles(all)}
+	for ts, values := range byTime {
+		result.Series = append(result.Series, &LatencyPoint{Timestamp: ts, LatencyQuantiles: latencyQuantiles(values)})
+	}
+	sort.Slice(result.Series, func(i, j int) bool { return result.Series[i].Timestamp.Before(result.Series[j].Timestamp) })
+	return result, nil
+}
+
+// errorMessageMaskRegexps are errorMessageMasks compiled; the patterns are
+// RE2, so Go can run them as ClickHouse does.
+var errorMessageMaskRegexps = func() []*regexp.Regexp {
+	res := make([]*regexp.Regexp, len(errorMessageMasks))
+	for i, m := range errorMessageMasks {
+		res[i] = regexp.MustCompile(m.pattern)
+	}
+	return res
+}()
+
+// normalizeErrorMessage masks message by errorMessageMasks.
+func normalizeErrorMessage(message string) string {
+	for i, re := range errorMessageMaskRegexps {
+		message = re.ReplaceAllLiteralString(message, errorMessageMasks[i].replacement)
+	}
+	return message
+}
+
+// GetTopErrors returns the most frequent normalized error and fatal
+// messages.
+func (r *memoryLogRepo) GetTopErrors(ctx context.Context, filter *AggregationFilter, limit int) ([]*ErrorGroup, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	byPattern := make(map[string]*ErrorGroup)
+	for _, e := range r.aggregated(filter) {
+		if !isErrorLevel(e.Level) {
+			continue
+		}
+		pattern := normalizeErrorMessage(e.Message)
+		eg, ok := byPattern[pattern]
+		if !ok {
+			eg = &ErrorGroup{Pattern: pattern, Example: e.Message}
+			byPattern[pattern] = eg
+		}
+		eg.Count++
+		if e.Timestamp.After(eg.LastSeen) {
+			eg.LastSeen = e.Timestamp
+		}
+	}
+
+	results := make([]*ErrorGroup, 0, len(byPattern))
+	for _, eg := range byPattern {
+		results = append(results, eg)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Count != results[j].Count {
+			return results[i].Count > results[j].Count
+		}
+		return results[i].Pattern < results[j].Pattern
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// GetByID retrieves a single log entry by ID.
+func (r *memoryLogRepo) GetByID(ctx context.Context, id string) (*LogRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, e := range r.entries {
+		if e.ID == id {
+			return cloneLogRecord(e), nil
+		}
+	}
+	//nolint:nilnil
+	return nil, nil
+}
+
+// compareContext orders e against a (timestamp, line, id) position, the
+// order GetContext pages in.
+func compareContext(e *LogRecord, ts time.Time, line int64, id string) int {
+	switch {
+	case e.Timestamp.Before(ts):
+		return -1
+	case e.Timestamp.After(ts):
+		return 1
+	case e.LineNumber < line:
+		return -1
+	case e.LineNumber > line:
+		return 1
+	}
+	return strings.Compare(e.ID, id)
+}
+
+// GetContext retrieves logs surrounding a target log entry, within an hour
+// of it, as the ClickHouse storage does.
+func (r *memoryLogRepo) GetContext(ctx context.Context, filter *ContextFilter) (*ContextResult, error) {
+	if filter.Before > 50 {
+		filter.Before = 50
+	}
+	if filter.After > 50 {
+		filter.After = 50
+	}
+
+	target, err := r.GetByID(ctx, filter.TargetID)
+	if err != nil || target == nil {
+		return nil, err
+	}
+	result := &ContextResult{Target: target}
+
+	windowStart := filter.Timestamp.Add(-time.Hour)
+	windowEnd := filter.Timestamp.Add(time.Hour)
+	around := r.matching(func(e *LogRecord) bool {
+		return e.ProjectID == filter.ProjectID && e.AgentID == filter.AgentID &&
+			(filter.FilePath == "" || e.FilePath == filter.FilePath) &&
+			!e.Timestamp.Before(windowStart) && !e.Timestamp.After(windowEnd)
+	})
+	sort.Slice(around, func(i, j int) bool {
+		return compareContext(around[i], around[j].Timestamp, around[j].LineNumber, around[j].ID) < 0
+	})
+
+	if filter.Before > 0 {
+		cursorTS, cursorLine, cursorID := filter.Timestamp, filter.LineNumber, filter.TargetID
+		if filter.BeforeCursor != "" {
+			if ts, line, id, ok := parseCursor(filter.BeforeCursor); ok {
+				cursorTS, cursorLine, cursorID = ts, line, id
+			}
+		}
+		var before []*LogRecord
+		for _, e := range around {
+			if !e.Timestamp.After(filter.Timestamp) && compareContext(e, cursorTS, cursorLine, cursorID) < 0 {
+				before = append(before, e)
+			}
+		}
+		if len(before) > filter.Before {
+			result.HasMoreBefore = true
+			before = before[len(before)-filter.Before:]
+		}
+		if len(before) > 0 {
+			oldest := before[0]
+			result.BeforeCursor = formatCursor(oldest.Timestamp, oldest.LineNumber, oldest.ID)
+		}
+		result.Before = before
+	}
+
+	if filter.After > 0 {
+		cursorTS, cursorLine, cursorID := filter.Timestamp, filter.LineNumber, filter.TargetID
+		if filter.AfterCursor != "" {
+			if ts, line, id, ok := parseCursor(filter.AfterCursor); ok {
+				cursorTS, cursorLine, cursorID = ts, line, id
+			}
+		}
+		var after []*LogRecord
+		for _, e := range around {
+			if !e.Timestamp.Before(filter.Timestamp) && compareContext(e, cursorTS, cursorLine, cursorID) > 0 {
+				after = append(after, e)
+			}
+		}
+		if len(after) > filter.After {
+			result.HasMoreAfter = true
+			after = after[:filter.After]
+		}
+		if len(after) > 0 {
+			newest := after[len(after)-1]
+			result.AfterCursor = formatCursor(newest.Timestamp, newest.LineNumber, newest.ID)
+		}
+		result.After = after
+	}
+
+	return result, nil
+}
@@ -0,0 +1,258 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestMemoryRepo(t *testing.T, entries ...*LogRecord) LogRepository {
+	t.Helper()
+	repo := NewMemoryLogStorage().Logs()
+	if err := repo.InsertBatch(context.Background(), entries); err != nil {
+		t.Fatalf("InsertBatch() error = %v", err)
+	}
+	return repo
+}
+
+func TestMemoryLogRepo_Query(t *testing.T) {
+	base := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	repo := newTestMemoryRepo(t,
+		&LogRecord{ID: "a", ProjectID: "p1", Timestamp: base, Level: "info", Message: "user logged in", Source: "web"},
+		&LogRecord{ID: "b", ProjectID: "p1", Timestamp: base.Add(time.Minute), Level: "error", Message: "connection timeout", Source: "db"},
+		&LogRecord{ID: "c", ProjectID: "p2", Timestamp: base.Add(2 * time.Minute), Level: "error", Message: "timeout-retry exhausted", Source: "db"},
+		&LogRecord{ID: "d", Timestamp: base.Add(3 * time.Minute), Level: "warning", Message: "timeouts rising", Source: "web"},
+	)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		filter *LogFilter
+		want   []string
+	}{
+		{"all newest first", &LogFilter{}, []string{"d", "c", "b", "a"}},
+		{"project", &LogFilter{ProjectID: "p1"}, []string{"b", "a"}},
+		{"projects and unassigned", &LogFilter{ProjectIDs: []string{"p2"}, IncludeUnassigned: true}, []string{"d", "c"}},
+		{"levels", &LogFilter{Levels: []string{"error", "warning"}}, []string{"d", "c", "b"}},
+		{"time range", &LogFilter{StartTime: base.Add(time.Minute), EndTime: base.Add(2 * time.Minute)}, []string{"c", "b"}},
+		{"token", &LogFilter{MessageContains: "timeout"}, []string{"c", "b"}},
+		{"substring", &LogFilter{MessageContains: "timeout", SearchMode: SearchModeSubstring}, []string{"d", "c", "b"}},
+		{"phrase", &LogFilter{MessageContains: "timeout retry", SearchMode: SearchModePhrase}, []string{"c"}},
+		{"order by source asc", &LogFilter{OrderBy: "source"}, []string{"b", "c", "a", "d"}},
+		{"unknown order sorts as default", &LogFilter{OrderBy: "message"}, []string{"d", "c", "b", "a"}},
+		{"keyset", &LogFilter{AfterTime: base.Add(2 * time.Minute), AfterID: "c"}, []string{"b", "a"}},
+		{"limit and offset", &LogFilter{Limit: 2, Offset: 1}, []string{"c", "b"}},
+		{"dsl", &LogFilter{FilterExpr: `level == "ERROR" && message contains "TIMEOUT"`, FilterSQL: "(...)"}, []string{"c", "b"}},
+		{"dsl replaces flat filters", &LogFilter{FilterExpr: `source == "db*"`, FilterSQL: "(...)", Level: "info", ProjectID: "p1"}, []string{"b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := repo.Query(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			var got []string
+			for _, e := range result.Entries {
+				got = append(got, e.ID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Query() ids = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Query() ids = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	result, err := repo.Query(ctx, &LogFilter{Limit: 3})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if result.Total != 4 || !result.HasMore {
+		t.Errorf("Query() total = %d, hasMore = %v, want 4, true", result.Total, result.HasMore)
+	}
+
+	result, err = repo.Query(ctx, &LogFilter{Limit: 3, SkipTotal: true})
+	if err != nil {
+		t.Fatalf("Query(SkipTotal) error = %v", err)
+	}
+	if result.Total != 3 || !result.HasMore {
+		t.Errorf("Query(SkipTotal) total = %d, hasMore = %v, want 3, true", result.Total, result.HasMore)
+	}
+
+	result, err = repo.Query(ctx, &LogFilter{ProjectID: "p1", Limit: 1, Projection: []string{"level"}})
+	if err != nil {
+		t.Fatalf("Query(Projection) error = %v", err)
+	}
+	if e := result.Entries[0]; e.ID != "b" || e.Level != "error" || !e.Timestamp.Equal(base.Add(time.Minute)) || e.Message != "" || e.ProjectID != "" {
+		t.Errorf("Query(Projection) entry = %+v, want only id, timestamp and level", e)
+	}
+
+	if n, err := repo.Count(ctx, &LogFilter{FilterExpr: `level in ["warning", "error"] and not (message startsWith "timeouts")`, FilterSQL: "(...)"}); err != nil || n != 2 {
+		t.Errorf("Count(FilterExpr) = %d, %v; want 2", n, err)
+	}
+	if _, err := repo.Query(ctx, &LogFilter{FilterSQL: "level = ?"}); !errors.Is(err, ErrFilterUnsupported) {
+		t.Errorf("Query(FilterSQL without FilterExpr) error = %v, want ErrFilterUnsupported", err)
+	}
+}
+
+func TestMemoryLogRepo_InsertCopiesAndCaps(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryLogStorage()
+	storage.logs.maxEntries = 2
+	repo := storage.Logs()
+
+	entry := &LogRecord{Message: "first", Fields: map[string]interface{}{"k": "v"}}
+	if err := repo.InsertBatch(ctx, []*LogRecord{entry, {Message: "second"}, {Message: "third"}}); err != nil {
+		t.Fatalf("InsertBatch() error = %v", err)
+	}
+	entry.Fields["k"] = "changed"
+
+	result, err := repo.Query(ctx, &LogFilter{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(result.Entries) != 2 {
+		t.Fatalf("Query() returned %d entries, want 2", len(result.Entries))
+	}
+	for _, e := range result.Entries {
+		if e.ID == "" {
+			t.Error("InsertBatch() left an entry without an ID")
+		}
+		if e.Message == "first" {
+			t.Error("InsertBatch() kept the oldest entry past the cap")
+		}
+	}
+}
+
+func TestMemoryLogRepo_DeleteBefore(t *testing.T) {
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	repo := newTestMemoryRepo(t,
+		&LogRecord{Timestamp: base},
+		&LogRecord{Timestamp: base.Add(time.Hour)},
+		&LogRecord{Timestamp: base.Add(2 * time.Hour)},
+	)
+	ctx := context.Background()
+
+	deleted, err := repo.DeleteBefore(ctx, base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("DeleteBefore() error = %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("DeleteBefore() = %d, want 1", deleted)
+	}
+	if n, _ := repo.Count(ctx, &LogFilter{}); n != 2 {
+		t.Errorf("Count() = %d, want 2", n)
+	}
+}
+
+func TestMemoryLogRepo_Aggregations(t *testing.T) {
+	base := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	repo := newTestMemoryRepo(t,
+		&LogRecord{Timestamp: base, Level: "info", Source: "web", HTTPStatus: 200, URI: "/", Fields: map[string]interface{}{"request_time": 0.1}},
+		&LogRecord{Timestamp: base.Add(time.Minute), Level: "error", Source: "web", HTTPStatus: 500, URI: "/api", Message: "Order 42 not found", Fields: map[string]interface{}{"request_time": "0.3"}},
+		&LogRecord{Timestamp: base.Add(time.Hour), Level: "fatal", Source: "db", Message: "Order 7 not found", Fields: map[string]interface{}{"request_time": 0.5}},
+		&LogRecord{Timestamp: base.Add(time.Hour), Level: "warning", Source: "web", HTTPStatus: 404, URI: "/api"},
+	)
+	ctx := context.Background()
+	filter := &AggregationFilter{}
+
+	rates, err := repo.GetErrorRates(ctx, filter)
+	if err != nil {
+		t.Fatalf("GetErrorRates() error = %v", err)
+	}
+	if rates.TotalLogs != 4 || rates.ErrorCount != 1 || rates.FatalCount != 1 || rates.WarningCount != 1 || rates.ErrorRate != 0.5 {
+		t.Errorf("GetErrorRates() = %+v", rates)
+	}
+
+	sources, err := repo.GetTopSources(ctx, filter, 10)
+	if err != nil {
+		t.Fatalf("GetTopSources() error = %v", err)
+	}
+	if len(sources) != 2 || sources[0].Source != "web" || sources[0].Count != 3 || sources[0].ErrorCount != 1 {
+		t.Errorf("GetTopSources() = %+v", sources)
+	}
+
+	volume, err := repo.GetLogVolume(ctx, filter, "hour")
+	if err != nil {
+		t.Fatalf("GetLogVolume() error = %v", err)
+	}
+	if len(volume) != 2 || !volume[0].Timestamp.Equal(base) || volume[0].TotalCount != 2 || volume[1].ErrorCount != 1 {
+		t.Errorf("GetLogVolume() = %+v", volume)
+	}
+
+	httpStats, err := repo.GetHTTPStats(ctx, filter)
+	if err != nil {
+		t.Fatalf("GetHTTPStats() error = %v", err)
+	}
+	if httpStats.Total2xx != 1 || httpStats.Total4xx != 1 || httpStats.Total5xx != 1 || len(httpStats.TopURIs) != 2 || httpStats.TopURIs[0].URI != "/api" {
+		t.Errorf("GetHTTPStats() = %+v", httpStats)
+	}
+
+	p, err := repo.GetFieldPercentile(ctx, filter, "request_time", 0.5)
+	if err != nil {
+		t.Fatalf("GetFieldPercentile() error = %v", err)
+	}
+	if p.Samples != 3 || p.Value != 0.3 {
+		t.Errorf("GetFieldPercentile() = %+v, want 0.3 over 3 samples", p)
+	}
+
+	latency, err := repo.GetLatencyStats(ctx, filter, "request_time", "hour")
+	if err != nil {
+		t.Fatalf("GetLatencyStats() error = %v", err)
+	}
+	if latency.Samples != 3 || len(latency.Series) != 2 || latency.Series[0].Samples != 2 {
+		t.Errorf("GetLatencyStats() = %+v", latency)
+	}
+
+	groups, err := repo.GetTopErrors(ctx, filter, 10)
+	if err != nil {
+		t.Fatalf("GetTopErrors() error = %v", err)
+	}
+	if len(groups) != 1 || groups[0].Pattern != "Order <n> not found" || groups[0].Count != 2 || !groups[0].LastSeen.Equal(base.Add(time.Hour)) {
+		t.Errorf("GetTopErrors() = %+v", groups)
+	}
+}
+
+func TestMemoryLogRepo_GetContext(t *testing.T) {
+	base := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	var entries []*LogRecord
+	for i, id := range []string{"l1", "l2", "l3", "l4", "l5"} {
+		entries = append(entries, &LogRecord{ID: id, ProjectID: "p", AgentID: "a", Timestamp: base, LineNumber: int64(i + 1)})
+	}
+	entries = append(entries, &LogRecord{ID: "other", ProjectID: "p", AgentID: "b", Timestamp: base})
+	repo := newTestMemoryRepo(t, entries...)
+	ctx := context.Background()
+
+	filter := &ContextFilter{TargetID: "l3", ProjectID: "p", AgentID: "a", Timestamp: base, LineNumber: 3, Before: 1, After: 5}
+	result, err := repo.GetContext(ctx, filter)
+	if err != nil {
+		t.Fatalf("GetContext() error = %v", err)
+	}
+	if result.Target.ID != "l3" {
+		t.Errorf("Target = %q, want l3", result.Target.ID)
+	}
+	if len(result.Before) != 1 || result.Before[0].ID != "l2" || !result.HasMoreBefore {
+		t.Errorf("Before = %v, hasMore = %v, want [l2], true", result.Before, result.HasMoreBefore)
+	}
+	if len(result.After) != 2 || result.After[0].ID != "l4" || result.HasMoreAfter {
+		t.Errorf("After = %v, hasMore = %v, want [l4 l5], false", result.After, result.HasMoreAfter)
+	}
+
+	filter.BeforeCursor = result.BeforeCursor
+	result, err = repo.GetContext(ctx, filter)
+	if err != nil {
+		t.Fatalf("GetContext(cursor) error = %v", err)
+	}
+	if len(result.Before) != 1 || result.Before[0].ID != "l1" || result.HasMoreBefore {
+		t.Errorf("Before page 2 = %v, hasMore = %v, want [l1], false", result.Before, result.HasMoreBefore)
+	}
+
+	missing, err := repo.GetContext(ctx, &ContextFilter{TargetID: "nope"})
+	if err != nil || missing != nil {
+		t.Errorf("GetContext(missing) = %v, %v, want nil, nil", missing, err)
+	}
+}
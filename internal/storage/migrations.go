@@ -140,6 +140,69 @@ var migrations = []Migration{
 			CREATE INDEX IF NOT EXISTS idx_alert_history_created_at ON alert_history(created_at);
 		`,
 	},
+	{
+		Version: 4,
+		Name:    "add_shared_filters",
+		Up: `
+			-- Shared filters table for short-code "share this view" links
+			CREATE TABLE IF NOT EXISTS shared_filters (
+				code TEXT PRIMARY KEY,
+				filter TEXT NOT NULL DEFAULT '',
+				search_mode TEXT NOT NULL DEFAULT '',
+				range_ns INTEGER NOT NULL,
+				project_id TEXT,
+				created_by TEXT NOT NULL,
+				expires_at DATETIME NOT NULL,
+				created_at DATETIME NOT NULL
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_shared_filters_expires_at ON shared_filters(expires_at);
+		`,
+	},
+	{
+		Version: 5,
+		Name:    "add_project_default_notify",
+		Up: `
+			-- Default notification channels inherited by project alert rules
+			ALTER TABLE projects ADD COLUMN default_notify_json TEXT;
+		`,
+	},
+	{
+		Version: 6,
+		Name:    "add_alert_history_replay_of",
+		Up: `
+			-- Links replayed notifications to the history entry they re-sent
+			ALTER TABLE alert_history ADD COLUMN replay_of TEXT;
+		`,
+	},
+	{
+		Version: 7,
+		Name:    "add_alert_notify_template",
+		Up: `
+			-- Per-rule notification template overriding channel formatting
+			ALTER TABLE alerts ADD COLUMN notify_template TEXT;
+		`,
+	},
+	{
+		Version: 8,
+		Name:    "add_saved_searches",
+		Up: `
+			-- Saved searches: named, reusable log queries owned by a user
+			CREATE TABLE IF NOT EXISTS saved_searches (
+				id TEXT PRIMARY KEY,
+				name TEXT NOT NULL,
+				filter TEXT NOT NULL DEFAULT '',
+				search_mode TEXT NOT NULL DEFAULT '',
+				range_ns INTEGER NOT NULL,
+				project_id TEXT,
+				owner_id TEXT NOT NULL,
+				created_at DATETIME NOT NULL,
+				updated_at DATETIME NOT NULL,
+				UNIQUE (owner_id, name),
+				FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE
+			);
+		`,
+	},
 }
 
 // runMigrations applies all pending migrations.
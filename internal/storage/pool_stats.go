@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"database/sql"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/metrics"
+)
+
+// poolSampler periodically exports sql.DB pool stats as metrics and warns
+// when callers spend too long waiting for a connection.
+type poolSampler struct {
+	backend       string
+	stats         func() sql.DBStats
+	interval      time.Duration
+	warnThreshold time.Duration // 0 = never warn
+
+	last sql.DBStats
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newPoolSampler(backend string, stats func() sql.DBStats, interval, warnThreshold time.Duration) *poolSampler {
+	return &poolSampler{
+		backend:       backend,
+		stats:         stats,
+		interval:      interval,
+		warnThreshold: warnThreshold,
+		quit:          make(chan struct{}),
+	}
+}
+
+// start samples once immediately, then every interval until stop.
+func (p *poolSampler) start() {
+	p.sample()
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.sample()
+			case <-p.quit:
+				return
+			}
+		}
+	}()
+}
+
+// stop stops sampling and waits for the sampler to exit.
+func (p *poolSampler) stop() {
+	close(p.quit)
+	p.wg.Wait()
+}
+
+// sample records the current pool stats and returns the waits since the
+// previous sample.
+func (p *poolSampler) sample() (waits int64, waited time.Duration) {
+	st := p.stats()
+
+	metrics.StoragePoolConnections.WithLabelValues(p.backend, "open").Set(float64(st.OpenConnections))
+	metrics.StoragePoolConnections.WithLabelValues(p.backend, "in_use").Set(float64(st.InUse))
+	metrics.StoragePoolConnections.WithLabelValues(p.backend, "idle").Set(float64(st.Idle))
+	metrics.StoragePoolConnections.WithLabelValues(p.backend, "max_open").Set(float64(st.MaxOpenConnections))
+	metrics.StoragePoolWaitCount.WithLabelValues(p.backend).Set(float64(st.WaitCount))
+	metrics.StoragePoolWaitSeconds.WithLabelValues(p.backend).Set(st.WaitDuration.Seconds())
+
+	waits = st.WaitCount - p.last.WaitCount
+	waited = st.WaitDuration - p.last.WaitDuration
+	p.last = st
+
+	if p.warnThreshold > 0 && waits > 0 && waited >= p.warnThreshold {
+		slog.Warn(p.backend+" pool contention; consider raising max_open_conns",
+			"waits", waits,
+			"waited", waited.Round(time.Millisecond),
+			"interval", p.interval,
+			"in_use", st.InUse,
+			"max_open", maxOpenString(st.MaxOpenConnections))
+	}
+	return waits, waited
+}
+
+// maxOpenString formats a pool limit, where 0 means unlimited.
+func maxOpenString(n int) string {
+	if n <= 0 {
+		return "unlimited"
+	}
+	return strconv.Itoa(n)
+}
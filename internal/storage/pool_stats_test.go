@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"bytes"
+	"database/sql"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/good-yellow-bee/blazelog/internal/metrics"
+)
+
+func TestPoolSampler_Sample(t *testing.T) {
+	var buf bytes.Buffer
+	orig := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(orig)
+
+	current := sql.DBStats{MaxOpenConnections: 5, OpenConnections: 2, InUse: 1, Idle: 1}
+	p := newPoolSampler("test", func() sql.DBStats { return current }, 15*time.Second, time.Second)
+
+	tests := []struct {
+		name       string
+		waitCount  int64
+		waitTotal  time.Duration
+		wantWaits  int64
+		wantWaited time.Duration
+		wantWarn   bool
+	}{
+		{"no waits", 0, 0, 0, 0, false},
+		{"short waits", 3, 200 * time.Millisecond, 3, 200 * time.Millisecond, false},
+		{"contention", 10, 2 * time.Second, 7, 1800 * time.Millisecond, true},
+		{"recovered", 10, 2 * time.Second, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			current.WaitCount = tt.waitCount
+			current.WaitDuration = tt.waitTotal
+
+			waits, waited := p.sample()
+
+			if waits != tt.wantWaits || waited != tt.wantWaited {
+				t.Errorf("sample() = %d, %s, want %d, %s", waits, waited, tt.wantWaits, tt.wantWaited)
+			}
+			if warned := strings.Contains(buf.String(), "pool contention"); warned != tt.wantWarn {
+				t.Errorf("warned = %v, want %v (log: %q)", warned, tt.wantWarn, buf.String())
+			}
+			if got := testutil.ToFloat64(metrics.StoragePoolWaitCount.WithLabelValues("test")); got != float64(tt.waitCount) {
+				t.Errorf("wait count metric = %v, want %d", got, tt.waitCount)
+			}
+		})
+	}
+
+	if got := testutil.ToFloat64(metrics.StoragePoolConnections.WithLabelValues("test", "max_open")); got != 5 {
+		t.Errorf("max_open metric = %v, want 5", got)
+	}
+}
+
+func TestPoolSampler_StartStop(t *testing.T) {
+	calls := make(chan struct{}, 10)
+	p := newPoolSampler("test", func() sql.DBStats {
+		select {
+		case calls <- struct{}{}:
+		default:
+		}
+		return sql.DBStats{}
+	}, time.Millisecond, 0)
+
+	p.start()
+	// One immediate sample, then at least one from the ticker
+	for i := 0; i < 2; i++ {
+		select {
+		case <-calls:
+		case <-time.After(time.Second):
+			t.Fatalf("got %d samples, want at least 2", i)
+		}
+	}
+	p.stop()
+}
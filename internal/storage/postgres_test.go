@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT 1", "SELECT 1"},
+		{"a = ? AND b = ?", "a = $1 AND b = $2"},
+		{"x ~ '^a?b$' AND y = ?", "x ~ '^a?b$' AND y = $1"},
+		{"x = 'it''s?' AND y = ?::uuid", "x = 'it''s?' AND y = $1::uuid"},
+	}
+	for _, tt := range tests {
+		if got := rebind(tt.query); got != tt.want {
+			t.Errorf("rebind(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestPostgresNumericFieldPlaceholders(t *testing.T) {
+	// The regex literal holds '?'s that must not become parameters
+	got := rebind(postgresNumericField)
+	if !strings.Contains(got, "fields->>$1 ~") || !strings.Contains(got, "(fields->>$2)::float8") || strings.Contains(got, "$3") {
+		t.Errorf("rebind(numeric field) = %q, want parameters $1 and $2 only", got)
+	}
+}
+
+func TestPostgresBuildQuery(t *testing.T) {
+	r := &postgresLogRepo{}
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	query, args, err := r.buildQuery(&LogFilter{
+		StartTime:         start,
+		ProjectIDs:        []string{"p1", "p2"},
+		IncludeUnassigned: true,
+		Levels:            []string{"error", "fatal"},
+		MessageContains:   "timeout",
+		Limit:             50,
+	}, false)
+	if err != nil {
+		t.Fatalf("buildQuery() error = %v", err)
+	}
+	for _, want := range []string{
+		"timestamp >= $1",
+		"(project_id IN ($2, $3) OR project_id = '')",
+		"level = ANY($4)",
+		"to_tsvector('simple', message) @@ plainto_tsquery('simple', $5)",
+		"ORDER BY timestamp DESC, id DESC LIMIT 50",
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("query missing %q:\n%s", want, query)
+		}
+	}
+	if len(args) != 5 {
+		t.Errorf("args = %v, want 5", args)
+	}
+
+	query, _, err = r.buildQuery(&LogFilter{MessageContains: "conn reset", SearchMode: SearchModePhrase, OrderBy: "level; DROP TABLE logs"}, true)
+	if err != nil {
+		t.Fatalf("buildQuery() error = %v", err)
+	}
+	if !strings.HasPrefix(query, "SELECT count(*) FROM logs WHERE") || strings.Count(query, "plainto_tsquery") != 2 {
+		t.Errorf("phrase count query = %q", query)
+	}
+
+	query, _, _ = r.buildQuery(&LogFilter{OrderBy: "level; DROP TABLE logs"}, false)
+	if strings.Contains(query, "DROP") || !strings.Contains(query, "ORDER BY timestamp DESC, id DESC") {
+		t.Errorf("order by not allowlisted: %q", query)
+	}
+
+	query, _, _ = r.buildQuery(&LogFilter{Projection: []string{"message", "labels"}}, false)
+	if !strings.HasPrefix(query, "SELECT id::text, timestamp, message, labels::text FROM logs") {
+		t.Errorf("projection query = %q", query)
+	}
+
+	if _, _, err := r.buildQuery(&LogFilter{FilterSQL: "hasToken(message, ?)"}, false); !errors.Is(err, ErrFilterUnsupported) {
+		t.Errorf("FilterSQL: error = %v, want ErrFilterUnsupported", err)
+	}
+
+	query, args, err = r.buildQuery(&LogFilter{
+		FilterExpr: `level == "error" && fields.status >= 500`,
+		FilterSQL:  "(lower(level) = ?) AND ...",
+		Level:      "warning",
+		AgentID:    "agent-1",
+	}, true)
+	if err != nil {
+		t.Fatalf("buildQuery(FilterExpr) error = %v", err)
+	}
+	if !strings.Contains(query, "lower(level) = $1") || !strings.Contains(query, "(fields ->> $2)") {
+		t.Errorf("FilterExpr query = %q", query)
+	}
+	// The flat Level and AgentID filters are ignored, as in ClickHouse
+	if strings.Contains(query, "agent_id") || len(args) != 4 || args[0] != "error" || args[1] != "status" || args[2] != "status" {
+		t.Errorf("FilterExpr args = %v", args)
+	}
+}
+
+func TestPgWhere(t *testing.T) {
+	if got := pgWhere("", ""); got != "" {
+		t.Errorf("pgWhere() = %q, want empty", got)
+	}
+	if got := pgWhere("a = ?", "", "b > 0"); got != " WHERE a = ? AND b > 0" {
+		t.Errorf("pgWhere() = %q", got)
+	}
+}
+
+func TestPartitionName(t *testing.T) {
+	day := time.Date(2026, 10, 14, 23, 30, 0, 0, time.FixedZone("X", -3*3600))
+	if got := partitionName(day); got != "logs_p20261015" {
+		t.Errorf("partitionName() = %q, want logs_p20261015 (UTC day)", got)
+	}
+}
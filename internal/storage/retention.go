@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/metrics"
+)
+
+// AlertHistoryRetention limits how much alert history SQLite keeps.
+type AlertHistoryRetention struct {
+	MaxAge   time.Duration // Delete entries older than this (0 = keep regardless of age)
+	MaxRows  int           // Keep at most this many entries, newest first (0 = no cap)
+	Interval time.Duration // How often to purge
+}
+
+// Enabled reports whether r limits anything.
+func (r AlertHistoryRetention) Enabled() bool {
+	return r.MaxAge > 0 || r.MaxRows > 0
+}
+
+// PurgeAlertHistory deletes the alert history outside r, first by age and
+// then down to the row cap, and returns the number deleted for each.
+func PurgeAlertHistory(ctx context.Context, repo AlertHistoryRepository, r AlertHistoryRetention, now time.Time) (byAge, byCap int64, err error) {
+	if r.MaxAge > 0 {
+		byAge, err = repo.DeleteBefore(ctx, now.Add(-r.MaxAge))
+		if err != nil {
+			return 0, 0, err
+		}
+		metrics.StorageAlertHistoryPurged.WithLabelValues("age").Add(float64(byAge))
+	}
+	if r.MaxRows > 0 {
+		byCap, err = repo.DeleteExcess(ctx, r.MaxRows)
+		if err != nil {
+			return byAge, 0, err
+		}
+		metrics.StorageAlertHistoryPurged.WithLabelValues("max_rows").Add(float64(byCap))
+	}
+	return byAge, byCap, nil
+}
+
+// RunAlertHistoryRetention purges alert history at startup and then every
+// r.Interval until ctx is canceled.
+func RunAlertHistoryRetention(ctx context.Context, repo AlertHistoryRepository, r AlertHistoryRetention) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		byAge, byCap, err := PurgeAlertHistory(ctx, repo, r, time.Now())
+		if err != nil {
+			slog.Error("alert history retention", "error", err)
+		} else if byAge > 0 || byCap > 0 {
+			slog.Info("purged alert history", "by_age", byAge, "by_max_rows", byCap)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// defaultMutationPollInterval is how often PurgeLogs checks async deletes.
+const defaultMutationPollInterval = 10 * time.Second
+
+// MutationStatus is the progress of a log storage's unfinished async
+// mutations.
+type MutationStatus struct {
+	Pending    int64  // Mutations not done yet
+	PartsToDo  int64  // Data parts they still have to rewrite
+	FailReason string // Why the latest attempt failed, if it did (mutations retry)
+}
+
+// MutationTracker is implemented by log repositories whose DeleteBefore
+// only queues the delete, as with ClickHouse mutations.
+type MutationTracker interface {
+	Mutations(ctx context.Context) (*MutationStatus, error)
+}
+
+// ExpiredLogDeleter is implemented by log repositories with their own
+// retention rules; PurgeLogs calls DeleteExpired instead of DeleteBefore.
+type ExpiredLogDeleter interface {
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// LogRetention enforces log retention by deleting old logs on a schedule,
+// on top of any TTL the storage applies itself.
+type LogRetention struct {
+	MaxAge        time.Duration // Delete logs older than this (unless the repository has rules)
+	Interval      time.Duration // How often to purge
+	VerifyTimeout time.Duration // Wait this long for async deletes to finish (0 = don't wait)
+	PollInterval  time.Duration // How often to check async deletes (default: 10s)
+}
+
+// LogPurge reports one PurgeLogs run.
+type LogPurge struct {
+	Deleted  int64         // Logs matched by the delete
+	Parts    int64         // Data parts the async delete has to rewrite
+	Verified bool          // The async delete was seen to finish
+	Waited   time.Duration // Time spent waiting for it
+}
+
+// PurgeLogs deletes the logs older than r.MaxAge, or past the retention
+// rules of an ExpiredLogDeleter. If repo deletes
+// asynchronously and r.VerifyTimeout is set, it waits for the delete to
+// finish and returns an error if it hasn't within the timeout.
+func PurgeLogs(ctx context.Context, repo LogRepository, r LogRetention, now time.Time) (*LogPurge, error) {
+	var deleted int64
+	var err error
+	if d, ok := repo.(ExpiredLogDeleter); ok {
+		deleted, err = d.DeleteExpired(ctx, now)
+	} else {
+		deleted, err = repo.DeleteBefore(ctx, now.Add(-r.MaxAge))
+	}
+	if err != nil {
+		return nil, err
+	}
+	metrics.StorageLogsPurged.Add(float64(deleted))
+	purge := &LogPurge{Deleted: deleted}
+
+	tracker, ok := repo.(MutationTracker)
+	if !ok || deleted == 0 {
+		metrics.StorageLogRetentionLastSuccess.SetToCurrentTime()
+		return purge, nil
+	}
+	status, err := tracker.Mutations(ctx)
+	if err != nil {
+		return purge, err
+	}
+	purge.Parts = status.PartsToDo
+	if r.VerifyTimeout <= 0 {
+		metrics.StorageLogRetentionLastSuccess.SetToCurrentTime()
+		return purge, nil
+	}
+
+	pollInterval := r.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultMutationPollInterval
+	}
+	start := time.Now()
+	deadline := time.NewTimer(r.VerifyTimeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for status.Pending > 0 {
+		select {
+		case <-ctx.Done():
+			return purge, ctx.Err()
+		case <-deadline.C:
+			purge.Waited = time.Since(start)
+			if status.FailReason != "" {
+				return purge, fmt.Errorf("delete not finished after %s, %d parts to do: %s", r.VerifyTimeout, status.PartsToDo, status.FailReason)
+			}
+			return purge, fmt.Errorf("delete not finished after %s, %d parts to do", r.VerifyTimeout, status.PartsToDo)
+		case <-ticker.C:
+		}
+		if status, err = tracker.Mutations(ctx); err != nil {
+			return purge, err
+		}
+	}
+
+	purge.Verified = true
+	purge.Waited = time.Since(start)
+	metrics.StorageLogRetentionLastSuccess.SetToCurrentTime()
+	return purge, nil
+}
+
+// RunLogRetention purges logs at startup and then every r.Interval until
+// ctx is canceled.
+func RunLogRetention(ctx context.Context, repo LogRepository, r LogRetention) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		purge, err := PurgeLogs(ctx, repo, r, time.Now())
+		switch {
+		case err != nil && ctx.Err() == nil:
+			slog.Error("log retention", "error", err)
+		case err == nil && purge.Verified:
+			slog.Info("purged logs", "deleted", purge.Deleted, "parts", purge.Parts, "waited", purge.Waited)
+		case err == nil && purge.Deleted > 0:
+			slog.Info("purging logs", "deleted", purge.Deleted, "parts", purge.Parts)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
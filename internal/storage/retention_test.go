@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mutatingLogRepo deletes asynchronously: Mutations reports the delete
+// pending for the given number of polls.
+type mutatingLogRepo struct {
+	LogRepository
+	deleted    int64
+	pollsToGo  int
+	failReason string
+	before     time.Time
+}
+
+func (r *mutatingLogRepo) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	r.before = before
+	return r.deleted, nil
+}
+
+func (r *mutatingLogRepo) Mutations(ctx context.Context) (*MutationStatus, error) {
+	if r.pollsToGo == 0 {
+		return &MutationStatus{}, nil
+	}
+	r.pollsToGo--
+	return &MutationStatus{Pending: 1, PartsToDo: 3, FailReason: r.failReason}, nil
+}
+
+// expiringLogRepo deletes by its own retention rules.
+type expiringLogRepo struct {
+	LogRepository
+	deleted int64
+	now     time.Time
+}
+
+func (r *expiringLogRepo) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	r.now = now
+	return r.deleted, nil
+}
+
+func TestPurgeLogs(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 14, 3, 0, 0, 0, time.UTC)
+	r := LogRetention{MaxAge: 30 * 24 * time.Hour, PollInterval: time.Millisecond}
+
+	t.Run("synchronous delete", func(t *testing.T) {
+		repo := NewMemoryLogStorage().Logs()
+		if err := repo.InsertBatch(ctx, []*LogRecord{{Timestamp: now.AddDate(0, 0, -31)}, {Timestamp: now}}); err != nil {
+			t.Fatalf("InsertBatch() error = %v", err)
+		}
+		purge, err := PurgeLogs(ctx, repo, r, now)
+		if err != nil {
+			t.Fatalf("PurgeLogs() error = %v", err)
+		}
+		if purge.Deleted != 1 || purge.Verified {
+			t.Errorf("PurgeLogs() = %+v, want 1 deleted, not verified", purge)
+		}
+	})
+
+	t.Run("async delete without verify", func(t *testing.T) {
+		repo := &mutatingLogRepo{deleted: 10, pollsToGo: 5}
+		purge, err := PurgeLogs(ctx, repo, r, now)
+		if err != nil {
+			t.Fatalf("PurgeLogs() error = %v", err)
+		}
+		if !repo.before.Equal(now.Add(-r.MaxAge)) {
+			t.Errorf("DeleteBefore(%v), want %v", repo.before, now.Add(-r.MaxAge))
+		}
+		if purge.Deleted != 10 || purge.Parts != 3 || purge.Verified {
+			t.Errorf("PurgeLogs() = %+v, want 10 deleted in 3 parts, not verified", purge)
+		}
+	})
+
+	t.Run("async delete verified", func(t *testing.T) {
+		repo := &mutatingLogRepo{deleted: 10, pollsToGo: 3}
+		verify := r
+		verify.VerifyTimeout = time.Minute
+		purge, err := PurgeLogs(ctx, repo, verify, now)
+		if err != nil {
+			t.Fatalf("PurgeLogs() error = %v", err)
+		}
+		if !purge.Verified || repo.pollsToGo != 0 {
+			t.Errorf("PurgeLogs() = %+v with %d polls to go, want verified", purge, repo.pollsToGo)
+		}
+	})
+
+	t.Run("async delete times out", func(t *testing.T) {
+		repo := &mutatingLogRepo{deleted: 10, pollsToGo: 1 << 30, failReason: "Memory limit exceeded"}
+		verify := r
+		verify.VerifyTimeout = 20 * time.Millisecond
+		purge, err := PurgeLogs(ctx, repo, verify, now)
+		if err == nil || !strings.Contains(err.Error(), "Memory limit exceeded") {
+			t.Fatalf("PurgeLogs() error = %v, want timeout with fail reason", err)
+		}
+		if purge.Verified {
+			t.Error("PurgeLogs() verified a pending delete")
+		}
+	})
+
+	t.Run("repository retention rules", func(t *testing.T) {
+		repo := &expiringLogRepo{deleted: 4}
+		purge, err := PurgeLogs(ctx, repo, r, now)
+		if err != nil {
+			t.Fatalf("PurgeLogs() error = %v", err)
+		}
+		if purge.Deleted != 4 || !repo.now.Equal(now) {
+			t.Errorf("PurgeLogs() = %+v, DeleteExpired(%v), want 4 deleted at %v", purge, repo.now, now)
+		}
+	})
+
+	t.Run("nothing to delete", func(t *testing.T) {
+		repo := &mutatingLogRepo{pollsToGo: 5}
+		verify := r
+		verify.VerifyTimeout = time.Minute
+		purge, err := PurgeLogs(ctx, repo, verify, now)
+		if err != nil {
+			t.Fatalf("PurgeLogs() error = %v", err)
+		}
+		if purge.Deleted != 0 || repo.pollsToGo != 5 {
+			t.Errorf("PurgeLogs() = %+v, polled with nothing deleted", purge)
+		}
+	})
+}
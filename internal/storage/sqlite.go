@@ -36,6 +36,8 @@ type SQLiteStorage struct {
 	connections  *sqliteConnectionRepo
 	tokens       *sqliteTokenRepo
 	alertHistory *sqliteAlertHistoryRepo
+	sharedFilter *sqliteSharedFilterRepo
+	savedSearch  *sqliteSavedSearchRepo
 }
 
 // NewSQLiteStorage creates a new SQLite storage.
@@ -97,6 +99,8 @@ func (s *SQLiteStorage) Open() error {
 	s.connections = &sqliteConnectionRepo{db: db, masterKey: s.masterKey}
 	s.tokens = &sqliteTokenRepo{db: db}
 	s.alertHistory = &sqliteAlertHistoryRepo{db: db}
+	s.sharedFilter = &sqliteSharedFilterRepo{db: db}
+	s.savedSearch = &sqliteSavedSearchRepo{db: db}
 
 	return nil
 }
@@ -191,3 +195,13 @@ func (s *SQLiteStorage) Tokens() TokenRepository {
 func (s *SQLiteStorage) AlertHistory() AlertHistoryRepository {
 	return s.alertHistory
 }
+
+// SharedFilters returns the shared filter repository.
+func (s *SQLiteStorage) SharedFilters() SharedFilterRepository {
+	return s.sharedFilter
+}
+
+// SavedSearches returns the saved search repository.
+func (s *SQLiteStorage) SavedSearches() SavedSearchRepository {
+	return s.savedSearch
+}
@@ -16,12 +16,12 @@ type sqliteAlertHistoryRepo struct {
 func (r *sqliteAlertHistoryRepo) Create(ctx context.Context, h *models.AlertHistory) error {
 	query := `
 		INSERT INTO alert_history (id, alert_id, alert_name, severity, message,
-			matched_logs, notified_at, project_id, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			matched_logs, notified_at, project_id, replay_of, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	_, err := r.db.ExecContext(ctx, query,
 		h.ID, h.AlertID, h.AlertName, h.Severity, h.Message,
-		h.MatchedLogs, h.NotifiedAt, nullString(h.ProjectID), h.CreatedAt,
+		h.MatchedLogs, h.NotifiedAt, nullString(h.ProjectID), nullString(h.ReplayOf), h.CreatedAt,
 	)
 	if err != nil {
 		return fmt.Errorf("create alert history: %w", err)
@@ -38,7 +38,7 @@ func (r *sqliteAlertHistoryRepo) List(ctx context.Context, limit, offset int) ([
 
 	query := `
 		SELECT id, alert_id, alert_name, severity, message, matched_logs,
-			notified_at, project_id, created_at
+			notified_at, project_id, replay_of, created_at
 		FROM alert_history ORDER BY created_at DESC LIMIT ? OFFSET ?
 	`
 	rows, err := r.db.QueryContext(ctx, query, limit, offset)
@@ -63,7 +63,7 @@ func (r *sqliteAlertHistoryRepo) ListByAlert(ctx context.Context, alertID string
 
 	query := `
 		SELECT id, alert_id, alert_name, severity, message, matched_logs,
-			notified_at, project_id, created_at
+			notified_at, project_id, replay_of, created_at
 		FROM alert_history WHERE alert_id = ? ORDER BY created_at DESC LIMIT ? OFFSET ?
 	`
 	rows, err := r.db.QueryContext(ctx, query, alertID, limit, offset)
@@ -88,7 +88,7 @@ func (r *sqliteAlertHistoryRepo) ListByProject(ctx context.Context, projectID st
 
 	query := `
 		SELECT id, alert_id, alert_name, severity, message, matched_logs,
-			notified_at, project_id, created_at
+			notified_at, project_id, replay_of, created_at
 		FROM alert_history WHERE project_id = ? ORDER BY created_at DESC LIMIT ? OFFSET ?
 	`
 	rows, err := r.db.QueryContext(ctx, query, projectID, limit, offset)
@@ -104,6 +104,65 @@ func (r *sqliteAlertHistoryRepo) ListByProject(ctx context.Context, projectID st
 	return histories, total, rows.Err()
 }
 
+func (r *sqliteAlertHistoryRepo) GetByID(ctx context.Context, id string) (*models.AlertHistory, error) {
+	query := `
+		SELECT id, alert_id, alert_name, severity, message, matched_logs,
+			notified_at, project_id, replay_of, created_at
+		FROM alert_history WHERE id = ?
+	`
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("query alert history by id: %w", err)
+	}
+	defer rows.Close()
+
+	histories, err := r.scanHistories(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query alert history by id: %w", err)
+	}
+	if len(histories) == 0 {
+		//nolint:nilnil
+		return nil, nil
+	}
+	return histories[0], nil
+}
+
+func (r *sqliteAlertHistoryRepo) ListBetween(ctx context.Context, alertID string, start, end time.Time, limit int) ([]*models.AlertHistory, int64, error) {
+	where := `notified_at >= ? AND notified_at < ? AND replay_of IS NULL
+		AND id NOT IN (SELECT replay_of FROM alert_history WHERE replay_of IS NOT NULL)`
+	args := []any{start, end}
+	if alertID != "" {
+		where += " AND alert_id = ?"
+		args = append(args, alertID)
+	}
+
+	var total int64
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM alert_history WHERE "+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count alert history between: %w", err)
+	}
+
+	query := `
+		SELECT id, alert_id, alert_name, severity, message, matched_logs,
+			notified_at, project_id, replay_of, created_at
+		FROM alert_history WHERE ` + where + ` ORDER BY notified_at ASC LIMIT ?
+	`
+	rows, err := r.db.QueryContext(ctx, query, append(args, limit)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query alert history between: %w", err)
+	}
+	defer rows.Close()
+
+	histories, err := r.scanHistories(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return histories, total, rows.Err()
+}
+
 func (r *sqliteAlertHistoryRepo) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
 	result, err := r.db.ExecContext(ctx, "DELETE FROM alert_history WHERE created_at < ?", before)
 	if err != nil {
@@ -112,17 +171,31 @@ func (r *sqliteAlertHistoryRepo) DeleteBefore(ctx context.Context, before time.T
 	return result.RowsAffected()
 }
 
+func (r *sqliteAlertHistoryRepo) DeleteExcess(ctx context.Context, keep int) (int64, error) {
+	query := `
+		DELETE FROM alert_history WHERE id IN (
+			SELECT id FROM alert_history ORDER BY created_at DESC, id DESC LIMIT -1 OFFSET ?
+		)
+	`
+	result, err := r.db.ExecContext(ctx, query, keep)
+	if err != nil {
+		return 0, fmt.Errorf("delete excess alert history: %w", err)
+	}
+	return result.RowsAffected()
+}
+
 func (r *sqliteAlertHistoryRepo) scanHistories(rows *sql.Rows) ([]*models.AlertHistory, error) {
 	var histories []*models.AlertHistory
 	for rows.Next() {
 		h := &models.AlertHistory{}
-		var projectID sql.NullString
+		var projectID, replayOf sql.NullString
 		err := rows.Scan(&h.ID, &h.AlertID, &h.AlertName, &h.Severity, &h.Message,
-			&h.MatchedLogs, &h.NotifiedAt, &projectID, &h.CreatedAt)
+			&h.MatchedLogs, &h.NotifiedAt, &projectID, &replayOf, &h.CreatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("scan alert history: %w", err)
 		}
 		h.ProjectID = projectID.String
+		h.ReplayOf = replayOf.String
 		histories = append(histories, h)
 	}
 	return histories, nil
@@ -22,13 +22,13 @@ func (r *sqliteAlertRepo) Create(ctx context.Context, alert *models.AlertRule) e
 
 	query := `
 		INSERT INTO alerts (id, name, description, type, condition_json, severity,
-			window_ns, cooldown_ns, notify_json, enabled, project_id, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			window_ns, cooldown_ns, notify_json, notify_template, enabled, project_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	_, err = r.db.ExecContext(ctx, query,
 		alert.ID, alert.Name, alert.Description, alert.Type, alert.Condition, alert.Severity,
 		alert.Window.Nanoseconds(), alert.Cooldown.Nanoseconds(), string(notifyJSON),
-		boolToInt(alert.Enabled), nullString(alert.ProjectID),
+		nullString(alert.NotifyTemplate), boolToInt(alert.Enabled), nullString(alert.ProjectID),
 		alert.CreatedAt, alert.UpdatedAt,
 	)
 	if err != nil {
@@ -40,7 +40,7 @@ func (r *sqliteAlertRepo) Create(ctx context.Context, alert *models.AlertRule) e
 func (r *sqliteAlertRepo) GetByID(ctx context.Context, id string) (*models.AlertRule, error) {
 	query := `
 		SELECT id, name, description, type, condition_json, severity,
-			window_ns, cooldown_ns, notify_json, enabled, project_id, created_at, updated_at
+			window_ns, cooldown_ns, notify_json, notify_template, enabled, project_id, created_at, updated_at
 		FROM alerts WHERE id = ?
 	`
 	return r.scanAlert(r.db.QueryRowContext(ctx, query, id))
@@ -55,13 +55,13 @@ func (r *sqliteAlertRepo) Update(ctx context.Context, alert *models.AlertRule) e
 	query := `
 		UPDATE alerts SET name = ?, description = ?, type = ?, condition_json = ?,
 			severity = ?, window_ns = ?, cooldown_ns = ?, notify_json = ?,
-			enabled = ?, project_id = ?, updated_at = ?
+			notify_template = ?, enabled = ?, project_id = ?, updated_at = ?
 		WHERE id = ?
 	`
 	result, err := r.db.ExecContext(ctx, query,
 		alert.Name, alert.Description, alert.Type, alert.Condition, alert.Severity,
 		alert.Window.Nanoseconds(), alert.Cooldown.Nanoseconds(), string(notifyJSON),
-		boolToInt(alert.Enabled), nullString(alert.ProjectID), alert.UpdatedAt,
+		nullString(alert.NotifyTemplate), boolToInt(alert.Enabled), nullString(alert.ProjectID), alert.UpdatedAt,
 		alert.ID,
 	)
 	if err != nil {
@@ -89,7 +89,7 @@ func (r *sqliteAlertRepo) Delete(ctx context.Context, id string) error {
 func (r *sqliteAlertRepo) List(ctx context.Context) ([]*models.AlertRule, error) {
 	query := `
 		SELECT id, name, description, type, condition_json, severity,
-			window_ns, cooldown_ns, notify_json, enabled, project_id, created_at, updated_at
+			window_ns, cooldown_ns, notify_json, notify_template, enabled, project_id, created_at, updated_at
 		FROM alerts ORDER BY name
 	`
 	return r.queryAlerts(ctx, query)
@@ -98,7 +98,7 @@ func (r *sqliteAlertRepo) List(ctx context.Context) ([]*models.AlertRule, error)
 func (r *sqliteAlertRepo) ListByProject(ctx context.Context, projectID string) ([]*models.AlertRule, error) {
 	query := `
 		SELECT id, name, description, type, condition_json, severity,
-			window_ns, cooldown_ns, notify_json, enabled, project_id, created_at, updated_at
+			window_ns, cooldown_ns, notify_json, notify_template, enabled, project_id, created_at, updated_at
 		FROM alerts WHERE project_id = ? ORDER BY name
 	`
 	return r.queryAlertsWithArg(ctx, query, projectID)
@@ -107,7 +107,7 @@ func (r *sqliteAlertRepo) ListByProject(ctx context.Context, projectID string) (
 func (r *sqliteAlertRepo) ListEnabled(ctx context.Context) ([]*models.AlertRule, error) {
 	query := `
 		SELECT id, name, description, type, condition_json, severity,
-			window_ns, cooldown_ns, notify_json, enabled, project_id, created_at, updated_at
+			window_ns, cooldown_ns, notify_json, notify_template, enabled, project_id, created_at, updated_at
 		FROM alerts WHERE enabled = 1 ORDER BY name
 	`
 	return r.queryAlerts(ctx, query)
@@ -160,14 +160,14 @@ func (r *sqliteAlertRepo) scanAlerts(rows *sql.Rows) ([]*models.AlertRule, error
 
 func (r *sqliteAlertRepo) scanAlert(row *sql.Row) (*models.AlertRule, error) {
 	alert := &models.AlertRule{}
-	var description, projectID sql.NullString
+	var description, projectID, notifyTemplate sql.NullString
 	var notifyJSON string
 	var windowNS, cooldownNS int64
 	var enabled int
 
 	err := row.Scan(
 		&alert.ID, &alert.Name, &description, &alert.Type, &alert.Condition, &alert.Severity,
-		&windowNS, &cooldownNS, &notifyJSON, &enabled, &projectID,
+		&windowNS, &cooldownNS, &notifyJSON, &notifyTemplate, &enabled, &projectID,
 		&alert.CreatedAt, &alert.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
@@ -180,6 +180,7 @@ func (r *sqliteAlertRepo) scanAlert(row *sql.Row) (*models.AlertRule, error) {
 
 	alert.Description = description.String
 	alert.ProjectID = projectID.String
+	alert.NotifyTemplate = notifyTemplate.String
 	alert.Window = time.Duration(windowNS)
 	alert.Cooldown = time.Duration(cooldownNS)
 	alert.Enabled = enabled != 0
@@ -193,14 +194,14 @@ func (r *sqliteAlertRepo) scanAlert(row *sql.Row) (*models.AlertRule, error) {
 
 func (r *sqliteAlertRepo) scanAlertRow(rows *sql.Rows) (*models.AlertRule, error) {
 	alert := &models.AlertRule{}
-	var description, projectID sql.NullString
+	var description, projectID, notifyTemplate sql.NullString
 	var notifyJSON string
 	var windowNS, cooldownNS int64
 	var enabled int
 
 	err := rows.Scan(
 		&alert.ID, &alert.Name, &description, &alert.Type, &alert.Condition, &alert.Severity,
-		&windowNS, &cooldownNS, &notifyJSON, &enabled, &projectID,
+		&windowNS, &cooldownNS, &notifyJSON, &notifyTemplate, &enabled, &projectID,
 		&alert.CreatedAt, &alert.UpdatedAt,
 	)
 	if err != nil {
@@ -209,6 +210,7 @@ func (r *sqliteAlertRepo) scanAlertRow(rows *sql.Rows) (*models.AlertRule, error
 
 	alert.Description = description.String
 	alert.ProjectID = projectID.String
+	alert.NotifyTemplate = notifyTemplate.String
 	alert.Window = time.Duration(windowNS)
 	alert.Cooldown = time.Duration(cooldownNS)
 	alert.Enabled = enabled != 0
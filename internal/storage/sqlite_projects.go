@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 
 	"github.com/good-yellow-bee/blazelog/internal/models"
@@ -13,12 +14,17 @@ type sqliteProjectRepo struct {
 }
 
 func (r *sqliteProjectRepo) Create(ctx context.Context, project *models.Project) error {
+	defaultNotify, err := json.Marshal(project.DefaultNotify)
+	if err != nil {
+		return fmt.Errorf("marshal default notify: %w", err)
+	}
+
 	query := `
-		INSERT INTO projects (id, name, description, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO projects (id, name, description, default_notify_json, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
 	`
-	_, err := r.db.ExecContext(ctx, query,
-		project.ID, project.Name, project.Description,
+	_, err = r.db.ExecContext(ctx, query,
+		project.ID, project.Name, project.Description, string(defaultNotify),
 		project.CreatedAt, project.UpdatedAt,
 	)
 	if err != nil {
@@ -29,13 +35,13 @@ func (r *sqliteProjectRepo) Create(ctx context.Context, project *models.Project)
 
 func (r *sqliteProjectRepo) GetByID(ctx context.Context, id string) (*models.Project, error) {
 	query := `
-		SELECT id, name, description, created_at, updated_at
+		SELECT id, name, description, default_notify_json, created_at, updated_at
 		FROM projects WHERE id = ?
 	`
 	project := &models.Project{}
-	var description sql.NullString
+	var description, defaultNotify sql.NullString
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&project.ID, &project.Name, &description,
+		&project.ID, &project.Name, &description, &defaultNotify,
 		&project.CreatedAt, &project.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
@@ -46,18 +52,21 @@ func (r *sqliteProjectRepo) GetByID(ctx context.Context, id string) (*models.Pro
 		return nil, fmt.Errorf("get project by id: %w", err)
 	}
 	project.Description = description.String
+	if err := unmarshalDefaultNotify(defaultNotify, project); err != nil {
+		return nil, err
+	}
 	return project, nil
 }
 
 func (r *sqliteProjectRepo) GetByName(ctx context.Context, name string) (*models.Project, error) {
 	query := `
-		SELECT id, name, description, created_at, updated_at
+		SELECT id, name, description, default_notify_json, created_at, updated_at
 		FROM projects WHERE name = ?
 	`
 	project := &models.Project{}
-	var description sql.NullString
+	var description, defaultNotify sql.NullString
 	err := r.db.QueryRowContext(ctx, query, name).Scan(
-		&project.ID, &project.Name, &description,
+		&project.ID, &project.Name, &description, &defaultNotify,
 		&project.CreatedAt, &project.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
@@ -68,16 +77,24 @@ func (r *sqliteProjectRepo) GetByName(ctx context.Context, name string) (*models
 		return nil, fmt.Errorf("get project by name: %w", err)
 	}
 	project.Description = description.String
+	if err := unmarshalDefaultNotify(defaultNotify, project); err != nil {
+		return nil, err
+	}
 	return project, nil
 }
 
 func (r *sqliteProjectRepo) Update(ctx context.Context, project *models.Project) error {
+	defaultNotify, err := json.Marshal(project.DefaultNotify)
+	if err != nil {
+		return fmt.Errorf("marshal default notify: %w", err)
+	}
+
 	query := `
-		UPDATE projects SET name = ?, description = ?, updated_at = ?
+		UPDATE projects SET name = ?, description = ?, default_notify_json = ?, updated_at = ?
 		WHERE id = ?
 	`
 	result, err := r.db.ExecContext(ctx, query,
-		project.Name, project.Description, project.UpdatedAt,
+		project.Name, project.Description, string(defaultNotify), project.UpdatedAt,
 		project.ID,
 	)
 	if err != nil {
@@ -104,7 +121,7 @@ func (r *sqliteProjectRepo) Delete(ctx context.Context, id string) error {
 
 func (r *sqliteProjectRepo) List(ctx context.Context) ([]*models.Project, error) {
 	query := `
-		SELECT id, name, description, created_at, updated_at
+		SELECT id, name, description, default_notify_json, created_at, updated_at
 		FROM projects ORDER BY name
 	`
 	rows, err := r.db.QueryContext(ctx, query)
@@ -116,15 +133,18 @@ func (r *sqliteProjectRepo) List(ctx context.Context) ([]*models.Project, error)
 	var projects []*models.Project
 	for rows.Next() {
 		project := &models.Project{}
-		var description sql.NullString
+		var description, defaultNotify sql.NullString
 		err := rows.Scan(
-			&project.ID, &project.Name, &description,
+			&project.ID, &project.Name, &description, &defaultNotify,
 			&project.CreatedAt, &project.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("scan project: %w", err)
 		}
 		project.Description = description.String
+		if err := unmarshalDefaultNotify(defaultNotify, project); err != nil {
+			return nil, err
+		}
 		projects = append(projects, project)
 	}
 	return projects, rows.Err()
@@ -214,7 +234,7 @@ func (r *sqliteProjectRepo) GetProjectMembers(ctx context.Context, projectID str
 
 func (r *sqliteProjectRepo) GetProjectsForUser(ctx context.Context, userID string) ([]*models.Project, error) {
 	query := `
-		SELECT p.id, p.name, p.description, p.created_at, p.updated_at
+		SELECT p.id, p.name, p.description, p.default_notify_json, p.created_at, p.updated_at
 		FROM projects p
 		INNER JOIN project_users pu ON p.id = pu.project_id
 		WHERE pu.user_id = ?
@@ -229,16 +249,30 @@ func (r *sqliteProjectRepo) GetProjectsForUser(ctx context.Context, userID strin
 	var projects []*models.Project
 	for rows.Next() {
 		project := &models.Project{}
-		var description sql.NullString
+		var description, defaultNotify sql.NullString
 		err := rows.Scan(
-			&project.ID, &project.Name, &description,
+			&project.ID, &project.Name, &description, &defaultNotify,
 			&project.CreatedAt, &project.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("scan project: %w", err)
 		}
 		project.Description = description.String
+		if err := unmarshalDefaultNotify(defaultNotify, project); err != nil {
+			return nil, err
+		}
 		projects = append(projects, project)
 	}
 	return projects, rows.Err()
 }
+
+// unmarshalDefaultNotify decodes the default_notify_json column into project.
+func unmarshalDefaultNotify(raw sql.NullString, project *models.Project) error {
+	if !raw.Valid || raw.String == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(raw.String), &project.DefaultNotify); err != nil {
+		return fmt.Errorf("unmarshal default notify: %w", err)
+	}
+	return nil
+}
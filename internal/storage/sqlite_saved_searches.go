@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/models"
+)
+
+type sqliteSavedSearchRepo struct {
+	db *sql.DB
+}
+
+const savedSearchColumns = `id, name, filter, search_mode, range_ns, project_id, owner_id, created_at, updated_at`
+
+func (r *sqliteSavedSearchRepo) Create(ctx context.Context, s *models.SavedSearch) error {
+	query := `
+		INSERT INTO saved_searches (` + savedSearchColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		s.ID, s.Name, s.Filter, s.SearchMode, int64(s.Range), nullString(s.ProjectID),
+		s.OwnerID, s.CreatedAt, s.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert saved search: %w", err)
+	}
+	return nil
+}
+
+func (r *sqliteSavedSearchRepo) GetByID(ctx context.Context, id string) (*models.SavedSearch, error) {
+	query := `SELECT ` + savedSearchColumns + ` FROM saved_searches WHERE id = ?`
+	return scanSavedSearch(r.db.QueryRowContext(ctx, query, id))
+}
+
+func (r *sqliteSavedSearchRepo) GetByName(ctx context.Context, ownerID, name string) (*models.SavedSearch, error) {
+	query := `SELECT ` + savedSearchColumns + ` FROM saved_searches WHERE owner_id = ? AND name = ?`
+	return scanSavedSearch(r.db.QueryRowContext(ctx, query, ownerID, name))
+}
+
+func (r *sqliteSavedSearchRepo) Update(ctx context.Context, s *models.SavedSearch) error {
+	query := `
+		UPDATE saved_searches SET name = ?, filter = ?, search_mode = ?, range_ns = ?,
+			project_id = ?, updated_at = ?
+		WHERE id = ?
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		s.Name, s.Filter, s.SearchMode, int64(s.Range), nullString(s.ProjectID), s.UpdatedAt,
+		s.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("update saved search: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("saved search not found: %s", s.ID)
+	}
+	return nil
+}
+
+func (r *sqliteSavedSearchRepo) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM saved_searches WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("delete saved search: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("saved search not found: %s", id)
+	}
+	return nil
+}
+
+func (r *sqliteSavedSearchRepo) ListByOwner(ctx context.Context, ownerID string) ([]*models.SavedSearch, error) {
+	query := `SELECT ` + savedSearchColumns + ` FROM saved_searches WHERE owner_id = ? ORDER BY name`
+	rows, err := r.db.QueryContext(ctx, query, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("query saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	var searches []*models.SavedSearch
+	for rows.Next() {
+		s, err := scanSavedSearch(rows)
+		if err != nil {
+			return nil, err
+		}
+		searches = append(searches, s)
+	}
+	return searches, rows.Err()
+}
+
+// scanSavedSearch scans a row of savedSearchColumns from a *sql.Row or
+// *sql.Rows. A *sql.Row with no result gives nil, nil.
+func scanSavedSearch(row interface{ Scan(...any) error }) (*models.SavedSearch, error) {
+	s := &models.SavedSearch{}
+	var rangeNs int64
+	var projectID sql.NullString
+	err := row.Scan(
+		&s.ID, &s.Name, &s.Filter, &s.SearchMode, &rangeNs, &projectID,
+		&s.OwnerID, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		//nolint:nilnil
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan saved search: %w", err)
+	}
+	s.Range = time.Duration(rangeNs)
+	s.ProjectID = projectID.String
+	return s, nil
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/good-yellow-bee/blazelog/internal/models"
+)
+
+type sqliteSharedFilterRepo struct {
+	db *sql.DB
+}
+
+func (r *sqliteSharedFilterRepo) Create(ctx context.Context, f *models.SharedFilter) error {
+	query := `
+		INSERT INTO shared_filters (code, filter, search_mode, range_ns, project_id,
+			created_by, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		f.Code, f.Filter, f.SearchMode, int64(f.Range), nullString(f.ProjectID),
+		f.CreatedBy, f.ExpiresAt, f.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert shared filter: %w", err)
+	}
+	return nil
+}
+
+func (r *sqliteSharedFilterRepo) GetByCode(ctx context.Context, code string) (*models.SharedFilter, error) {
+	query := `
+		SELECT code, filter, search_mode, range_ns, project_id, created_by, expires_at, created_at
+		FROM shared_filters WHERE code = ?
+	`
+	f := &models.SharedFilter{}
+	var rangeNs int64
+	var projectID sql.NullString
+	err := r.db.QueryRowContext(ctx, query, code).Scan(
+		&f.Code, &f.Filter, &f.SearchMode, &rangeNs, &projectID,
+		&f.CreatedBy, &f.ExpiresAt, &f.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		//nolint:nilnil
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get shared filter: %w", err)
+	}
+	f.Range = time.Duration(rangeNs)
+	f.ProjectID = projectID.String
+	return f, nil
+}
+
+func (r *sqliteSharedFilterRepo) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM shared_filters WHERE expires_at < ?", time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("delete expired shared filters: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strconv"
 	"testing"
 	"time"
 
@@ -234,6 +235,41 @@ func TestProjectRepository_CRUD(t *testing.T) {
 	}
 }
 
+func TestProjectRepository_DefaultNotify(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	project := models.NewProject("notify-project", "")
+	project.ID = uuid.New().String()
+	project.DefaultNotify = []string{"slack", "email"}
+
+	if err := store.Projects().Create(ctx, project); err != nil {
+		t.Fatalf("create project: %v", err)
+	}
+
+	got, err := store.Projects().GetByID(ctx, project.ID)
+	if err != nil {
+		t.Fatalf("get project: %v", err)
+	}
+	if len(got.DefaultNotify) != 2 || got.DefaultNotify[0] != "slack" || got.DefaultNotify[1] != "email" {
+		t.Errorf("default_notify = %v, want [slack email]", got.DefaultNotify)
+	}
+
+	// Clearing the defaults round-trips as nil
+	project.DefaultNotify = nil
+	if err := store.Projects().Update(ctx, project); err != nil {
+		t.Fatalf("update project: %v", err)
+	}
+	got, err = store.Projects().GetByID(ctx, project.ID)
+	if err != nil {
+		t.Fatalf("get project: %v", err)
+	}
+	if got.DefaultNotify != nil {
+		t.Errorf("default_notify = %v, want nil", got.DefaultNotify)
+	}
+}
+
 func TestProjectRepository_UserAssociation(t *testing.T) {
 	store, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -337,13 +373,22 @@ func TestAlertRepository_CRUD(t *testing.T) {
 		t.Errorf("notify count = %d, want 2", len(got.Notify))
 	}
 
+	if got.NotifyTemplate != "" {
+		t.Errorf("notify template = %q, want empty", got.NotifyTemplate)
+	}
+
 	// Update
 	alert.Description = "Updated description"
+	alert.NotifyTemplate = "{{.RuleName}}: {{.Count}}"
 	alert.UpdatedAt = time.Now()
 	err = store.Alerts().Update(ctx, alert)
 	if err != nil {
 		t.Fatalf("update alert: %v", err)
 	}
+	got, _ = store.Alerts().GetByID(ctx, alert.ID)
+	if got.NotifyTemplate != alert.NotifyTemplate {
+		t.Errorf("notify template = %q, want %q", got.NotifyTemplate, alert.NotifyTemplate)
+	}
 
 	// List enabled
 	enabled, err := store.Alerts().ListEnabled(ctx)
@@ -377,6 +422,293 @@ func TestAlertRepository_CRUD(t *testing.T) {
 	}
 }
 
+func TestAlertHistoryRepository_Replay(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	alert := &models.AlertRule{
+		ID:        uuid.New().String(),
+		Name:      "history-alert",
+		Type:      models.AlertTypePattern,
+		Condition: `{"pattern": "ERROR"}`,
+		Severity:  models.SeverityHigh,
+		Enabled:   true,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := store.Alerts().Create(ctx, alert); err != nil {
+		t.Fatalf("create alert: %v", err)
+	}
+
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	newHistory := func(id string, at time.Time, replayOf string) *models.AlertHistory {
+		return &models.AlertHistory{
+			ID:         id,
+			AlertID:    alert.ID,
+			AlertName:  alert.Name,
+			Severity:   alert.Severity,
+			Message:    "pattern matched",
+			NotifiedAt: at,
+			ReplayOf:   replayOf,
+			CreatedAt:  at,
+		}
+	}
+	for _, h := range []*models.AlertHistory{
+		newHistory("h1", base, ""),
+		newHistory("h2", base.Add(time.Hour), ""),
+		newHistory("h3", base.Add(3*time.Hour), ""),
+		newHistory("r1", base.Add(90*time.Minute), "h1"),
+	} {
+		if err := store.AlertHistory().Create(ctx, h); err != nil {
+			t.Fatalf("create history %s: %v", h.ID, err)
+		}
+	}
+
+	got, err := store.AlertHistory().GetByID(ctx, "r1")
+	if err != nil {
+		t.Fatalf("get history by id: %v", err)
+	}
+	if got == nil || got.ReplayOf != "h1" {
+		t.Fatalf("replay_of = %+v, want h1", got)
+	}
+	if missing, _ := store.AlertHistory().GetByID(ctx, "nope"); missing != nil {
+		t.Error("missing history should be nil")
+	}
+
+	// Replays and replayed entries are excluded
+	items, total, err := store.AlertHistory().ListBetween(ctx, "", base, base.Add(2*time.Hour), 10)
+	if err != nil {
+		t.Fatalf("list between: %v", err)
+	}
+	if total != 1 || len(items) != 1 || items[0].ID != "h2" {
+		t.Fatalf("total = %d, items = %+v, want only h2", total, items)
+	}
+
+	// Results are oldest first; limit caps items but not the total
+	items, total, err = store.AlertHistory().ListBetween(ctx, alert.ID, base, base.Add(4*time.Hour), 1)
+	if err != nil {
+		t.Fatalf("list between with limit: %v", err)
+	}
+	if total != 2 || len(items) != 1 {
+		t.Fatalf("total = %d, items = %d, want 2, 1", total, len(items))
+	}
+	if items[0].ID != "h2" {
+		t.Errorf("first item = %s, want h2", items[0].ID)
+	}
+}
+
+func TestAlertHistoryRetention(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	alert := &models.AlertRule{
+		ID:        uuid.New().String(),
+		Name:      "retention-alert",
+		Type:      models.AlertTypePattern,
+		Condition: `{"pattern": "ERROR"}`,
+		Severity:  models.SeverityHigh,
+		Enabled:   true,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := store.Alerts().Create(ctx, alert); err != nil {
+		t.Fatalf("create alert: %v", err)
+	}
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		at := now.Add(-time.Duration(i) * 24 * time.Hour)
+		h := &models.AlertHistory{
+			ID:         "h" + strconv.Itoa(i),
+			AlertID:    alert.ID,
+			AlertName:  alert.Name,
+			Severity:   alert.Severity,
+			Message:    "pattern matched",
+			NotifiedAt: at,
+			CreatedAt:  at,
+		}
+		if err := store.AlertHistory().Create(ctx, h); err != nil {
+			t.Fatalf("create history %s: %v", h.ID, err)
+		}
+	}
+
+	// h8 and h9 are older than 7 days; the cap then trims h5..h7
+	r := AlertHistoryRetention{MaxAge: 7 * 24 * time.Hour, MaxRows: 5}
+	byAge, byCap, err := PurgeAlertHistory(ctx, store.AlertHistory(), r, now)
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if byAge != 2 || byCap != 3 {
+		t.Errorf("purged by age = %d, by cap = %d, want 2, 3", byAge, byCap)
+	}
+
+	items, total, err := store.AlertHistory().List(ctx, 100, 0)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if total != 5 {
+		t.Fatalf("total = %d, want 5", total)
+	}
+	if items[0].ID != "h0" || items[len(items)-1].ID != "h4" {
+		t.Errorf("kept %s..%s, want newest h0..h4", items[0].ID, items[len(items)-1].ID)
+	}
+
+	// Nothing left to purge
+	byAge, byCap, err = PurgeAlertHistory(ctx, store.AlertHistory(), r, now)
+	if err != nil || byAge != 0 || byCap != 0 {
+		t.Errorf("second purge = %d, %d, %v, want 0, 0, nil", byAge, byCap, err)
+	}
+}
+
+func TestSharedFilterRepository(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	sf, err := models.NewSharedFilter("user-1", time.Hour)
+	if err != nil {
+		t.Fatalf("new shared filter: %v", err)
+	}
+	sf.Filter = `level == "error"`
+	sf.Range = 15 * time.Minute
+
+	if err := store.SharedFilters().Create(ctx, sf); err != nil {
+		t.Fatalf("create shared filter: %v", err)
+	}
+
+	got, err := store.SharedFilters().GetByCode(ctx, sf.Code)
+	if err != nil {
+		t.Fatalf("get shared filter: %v", err)
+	}
+	if got == nil {
+		t.Fatal("shared filter should exist")
+	}
+	if got.Filter != sf.Filter || got.Range != sf.Range {
+		t.Errorf("got filter=%q range=%s, want filter=%q range=%s", got.Filter, got.Range, sf.Filter, sf.Range)
+	}
+	if got.ProjectID != "" {
+		t.Errorf("project_id = %q, want empty", got.ProjectID)
+	}
+
+	// Expired entries are purged
+	expired, err := models.NewSharedFilter("user-1", -time.Minute)
+	if err != nil {
+		t.Fatalf("new shared filter: %v", err)
+	}
+	expired.Range = time.Hour
+	if err := store.SharedFilters().Create(ctx, expired); err != nil {
+		t.Fatalf("create expired shared filter: %v", err)
+	}
+
+	deleted, err := store.SharedFilters().DeleteExpired(ctx)
+	if err != nil {
+		t.Fatalf("delete expired: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("deleted = %d, want 1", deleted)
+	}
+
+	got, _ = store.SharedFilters().GetByCode(ctx, expired.Code)
+	if got != nil {
+		t.Error("expired shared filter should be deleted")
+	}
+}
+
+func TestSavedSearchRepository(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	user := &models.User{
+		ID:           uuid.New().String(),
+		Username:     "searcher",
+		Email:        "searcher@example.com",
+		PasswordHash: "hash",
+		Role:         models.RoleViewer,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	if err := store.Users().Create(ctx, user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	search := models.NewSavedSearch("5xx", user.ID)
+	search.ID = uuid.New().String()
+	search.Filter = `http_status >= 500`
+	search.Range = time.Hour
+	if err := store.SavedSearches().Create(ctx, search); err != nil {
+		t.Fatalf("create saved search: %v", err)
+	}
+
+	// Names are unique per owner
+	dup := models.NewSavedSearch("5xx", user.ID)
+	dup.ID = uuid.New().String()
+	dup.Range = time.Hour
+	if err := store.SavedSearches().Create(ctx, dup); err == nil {
+		t.Error("duplicate name should fail")
+	}
+
+	got, err := store.SavedSearches().GetByID(ctx, search.ID)
+	if err != nil {
+		t.Fatalf("get saved search: %v", err)
+	}
+	if got == nil || got.Filter != search.Filter || got.Range != search.Range || got.OwnerID != user.ID {
+		t.Fatalf("got %+v, want %+v", got, search)
+	}
+	if got.ProjectID != "" {
+		t.Errorf("project_id = %q, want empty", got.ProjectID)
+	}
+
+	got, err = store.SavedSearches().GetByName(ctx, user.ID, "5xx")
+	if err != nil || got == nil || got.ID != search.ID {
+		t.Errorf("get by name = %+v, %v", got, err)
+	}
+	got, err = store.SavedSearches().GetByName(ctx, "other-user", "5xx")
+	if err != nil || got != nil {
+		t.Errorf("get by name for another owner = %+v, %v, want nil", got, err)
+	}
+
+	search.Name = "server errors"
+	search.SearchMode = "phrase"
+	search.Range = 15 * time.Minute
+	if err := store.SavedSearches().Update(ctx, search); err != nil {
+		t.Fatalf("update saved search: %v", err)
+	}
+	searches, err := store.SavedSearches().ListByOwner(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("list saved searches: %v", err)
+	}
+	if len(searches) != 1 || searches[0].Name != "server errors" || searches[0].SearchMode != "phrase" || searches[0].Range != 15*time.Minute {
+		t.Errorf("list = %+v", searches)
+	}
+
+	if err := store.SavedSearches().Delete(ctx, search.ID); err != nil {
+		t.Fatalf("delete saved search: %v", err)
+	}
+	if got, _ := store.SavedSearches().GetByID(ctx, search.ID); got != nil {
+		t.Error("saved search should be deleted")
+	}
+	if err := store.SavedSearches().Delete(ctx, search.ID); err == nil {
+		t.Error("deleting a missing saved search should fail")
+	}
+
+	// Deleting the owner deletes their searches
+	other := models.NewSavedSearch("errors", user.ID)
+	other.ID = uuid.New().String()
+	other.Range = time.Hour
+	if err := store.SavedSearches().Create(ctx, other); err != nil {
+		t.Fatalf("create saved search: %v", err)
+	}
+	if err := store.Users().Delete(ctx, user.ID); err != nil {
+		t.Fatalf("delete user: %v", err)
+	}
+	if got, _ := store.SavedSearches().GetByID(ctx, other.ID); got != nil {
+		t.Error("saved search should be deleted with its owner")
+	}
+}
+
 func TestConnectionRepository_CRUD(t *testing.T) {
 	store, cleanup := setupTestDB(t)
 	defer cleanup()
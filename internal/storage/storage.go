@@ -26,6 +26,8 @@ type Storage interface {
 	Connections() ConnectionRepository
 	Tokens() TokenRepository
 	AlertHistory() AlertHistoryRepository
+	SharedFilters() SharedFilterRepository
+	SavedSearches() SavedSearchRepository
 }
 
 // UserRepository defines operations for user management.
@@ -97,5 +99,29 @@ type AlertHistoryRepository interface {
 	List(ctx context.Context, limit, offset int) ([]*models.AlertHistory, int64, error)
 	ListByAlert(ctx context.Context, alertID string, limit, offset int) ([]*models.AlertHistory, int64, error)
 	ListByProject(ctx context.Context, projectID string, limit, offset int) ([]*models.AlertHistory, int64, error)
+	GetByID(ctx context.Context, id string) (*models.AlertHistory, error)
+	// ListBetween returns entries notified in [start, end) that are neither
+	// replays nor already replayed, oldest first, with the total match count.
+	// An empty alertID matches all alerts.
+	ListBetween(ctx context.Context, alertID string, start, end time.Time, limit int) ([]*models.AlertHistory, int64, error)
 	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+	// DeleteExcess deletes all but the newest keep entries.
+	DeleteExcess(ctx context.Context, keep int) (int64, error)
+}
+
+// SharedFilterRepository defines operations for shareable short-code filters.
+type SharedFilterRepository interface {
+	Create(ctx context.Context, filter *models.SharedFilter) error
+	GetByCode(ctx context.Context, code string) (*models.SharedFilter, error)
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// SavedSearchRepository defines operations for users' saved searches.
+type SavedSearchRepository interface {
+	Create(ctx context.Context, search *models.SavedSearch) error
+	GetByID(ctx context.Context, id string) (*models.SavedSearch, error)
+	GetByName(ctx context.Context, ownerID, name string) (*models.SavedSearch, error)
+	Update(ctx context.Context, search *models.SavedSearch) error
+	Delete(ctx context.Context, id string) error
+	ListByOwner(ctx context.Context, ownerID string) ([]*models.SavedSearch, error)
 }
@@ -0,0 +1,151 @@
+package tailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// isNamedPipe reports whether info describes a FIFO.
+func isNamedPipe(info os.FileInfo) bool {
+	return info != nil && info.Mode()&os.ModeNamedPipe != 0
+}
+
+// startPipe begins tailing a named pipe. Reads on a pipe block until a writer
+// sends data, so canceling ctx stops the tailer to unblock them.
+func (t *Tailer) startPipe(ctx context.Context) {
+	go func() {
+		select {
+		case <-ctx.Done():
+			t.Stop()
+		case <-t.done:
+		}
+	}()
+	go t.runPipe()
+}
+
+// runPipe tails a named pipe. Pipes can't be seeked or stat'ed for size, so
+// instead of the file logic this reads continuously: EOF only means that no
+// writer is connected right now, and reading resumes once a writer (re)opens
+// the pipe. If the pipe is replaced on disk, it is reopened when ReOpen is set.
+func (t *Tailer) runPipe() {
+	defer close(t.lines)
+
+	// Held outside the reader so a partial line survives a writer reconnect
+	lr := newLineReader(t.opts.MaxLineLength)
+	for {
+		if !t.ensurePipe() {
+			if !t.waitPoll() {
+				return
+			}
+			continue
+		}
+
+		t.mu.Lock()
+		reader := t.reader
+		t.mu.Unlock()
+
+		raw, err := lr.read(reader)
+		if err == nil {
+			t.sendLine(t.line(raw))
+			continue
+		}
+
+		if t.isClosed() {
+			return
+		}
+		if !errors.Is(err, io.EOF) {
+			t.sendLine(Line{Err: fmt.Errorf("read error: %w", err)})
+			t.closePipe()
+		}
+
+		// No writer connected: keep any partial line for when one returns
+		if !t.opts.Follow {
+			if lr.pending() > 0 {
+				t.sendLine(t.line(lr.take()))
+			}
+			return
+		}
+		if t.opts.ReOpen && t.pipeReplaced() {
+			t.closePipe()
+		}
+		if !t.waitPoll() {
+			return
+		}
+	}
+}
+
+// ensurePipe opens the pipe if it isn't open yet.
+func (t *Tailer) ensurePipe() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return false
+	}
+	if t.file != nil {
+		return true
+	}
+
+	file, err := openPipe(t.filePath)
+	if err != nil {
+		return false
+	}
+	t.file = file
+	t.reader = t.newReader(file)
+	return true
+}
+
+// closePipe closes the current pipe handle so the next read reopens it.
+func (t *Tailer) closePipe() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.file != nil {
+		t.file.Close()
+		t.file = nil
+		t.reader = nil
+	}
+}
+
+// pipeReplaced reports whether the path now refers to a different file than
+// the open pipe (e.g. the FIFO was removed and created again).
+func (t *Tailer) pipeReplaced() bool {
+	t.mu.Lock()
+	file := t.file
+	t.mu.Unlock()
+	if file == nil {
+		return false
+	}
+
+	current, err := os.Stat(t.filePath)
+	if err != nil {
+		return false
+	}
+	opened, err := file.Stat()
+	if err != nil {
+		return true
+	}
+	return !os.SameFile(current, opened)
+}
+
+// waitPoll sleeps for the poll interval. It returns false if the tailer was
+// stopped in the meantime.
+func (t *Tailer) waitPoll() bool {
+	select {
+	case <-t.done:
+		return false
+	case <-time.After(t.opts.PollInterval):
+		return true
+	}
+}
+
+// isClosed reports whether Stop has been called.
+func (t *Tailer) isClosed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.closed
+}
@@ -0,0 +1,107 @@
+//go:build linux
+
+package tailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// writeFIFO opens the pipe for writing, writes data and closes it again,
+// simulating a writer that connects and disconnects.
+func writeFIFO(t *testing.T, path, data string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("open fifo for writing: %v", err)
+	}
+	if _, err := f.WriteString(data); err != nil {
+		t.Fatalf("write fifo: %v", err)
+	}
+	f.Close()
+}
+
+func readLines(t *testing.T, tailer *Tailer, n int) []string {
+	t.Helper()
+	var lines []string
+	timeout := time.After(3 * time.Second)
+	for len(lines) < n {
+		select {
+		case line, ok := <-tailer.Lines():
+			if !ok {
+				t.Fatalf("lines channel closed after %d lines", len(lines))
+			}
+			if line.Err != nil {
+				t.Fatalf("unexpected error: %v", line.Err)
+			}
+			lines = append(lines, line.Text)
+		case <-timeout:
+			t.Fatalf("timeout: got %d of %d lines: %v", len(lines), n, lines)
+		}
+	}
+	return lines
+}
+
+func TestTailerFIFO(t *testing.T) {
+	fifo := filepath.Join(t.TempDir(), "app.pipe")
+	if err := syscall.Mkfifo(fifo, 0600); err != nil {
+		t.Fatalf("mkfifo: %v", err)
+	}
+
+	opts := DefaultOptions()
+	opts.PollInterval = 20 * time.Millisecond
+
+	tailer, err := NewTailer(fifo, opts)
+	if err != nil {
+		t.Fatalf("NewTailer: %v", err)
+	}
+	defer tailer.Stop()
+	if !tailer.isPipe {
+		t.Fatal("expected fifo to be detected as a named pipe")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := tailer.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	// First writer, including a line split across two writes
+	writeFIFO(t, fifo, "line 1\nline")
+	writeFIFO(t, fifo, " 2\r\n")
+
+	// Writer reconnects after the pipe saw EOF
+	writeFIFO(t, fifo, "line 3\n")
+
+	// Pipe replaced on disk
+	if err := os.Remove(fifo); err != nil {
+		t.Fatalf("remove fifo: %v", err)
+	}
+	if err := syscall.Mkfifo(fifo, 0600); err != nil {
+		t.Fatalf("mkfifo: %v", err)
+	}
+	writeFIFO(t, fifo, "line 4\n")
+
+	got := readLines(t, tailer, 4)
+	want := []string{"line 1", "line 2", "line 3", "line 4"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	// Cancellation unblocks the pending read and closes the channel
+	cancel()
+	select {
+	case _, ok := <-tailer.Lines():
+		if ok {
+			t.Error("expected lines channel to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("tailer did not stop after context cancel")
+	}
+}
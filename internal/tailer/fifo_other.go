@@ -0,0 +1,13 @@
+//go:build !unix
+
+package tailer
+
+import (
+	"errors"
+	"os"
+)
+
+// openPipe is not supported on this platform.
+func openPipe(path string) (*os.File, error) {
+	return nil, errors.New("named pipes are not supported on this platform")
+}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package tailer
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// openPipe opens a named pipe for reading without blocking until a writer
+// connects.
+func openPipe(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pipe: %w", err)
+	}
+	return file, nil
+}
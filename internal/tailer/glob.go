@@ -0,0 +1,152 @@
+package tailer
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// hasMeta reports whether path contains glob metacharacters.
+func hasMeta(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// isRecursive reports whether pattern contains a "**" segment.
+func isRecursive(pattern string) bool {
+	for _, seg := range strings.Split(filepath.ToSlash(pattern), "/") {
+		if seg == "**" {
+			return true
+		}
+	}
+	return false
+}
+
+// Glob returns the regular files matching pattern, in lexical order, using
+// the same syntax as tailer patterns ("**" matches any number of
+// directories).
+func Glob(pattern string) ([]string, error) {
+	return expandGlob(pattern)
+}
+
+// expandGlob returns the regular files matching pattern, in lexical order.
+// Besides filepath.Match syntax, a "**" segment matches zero or more
+// directories.
+func expandGlob(pattern string) ([]string, error) {
+	if !isRecursive(pattern) {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return regularFiles(matches), nil
+	}
+
+	// Validate the segments up front; WalkDir would otherwise hide errors.
+	for _, seg := range strings.Split(filepath.ToSlash(pattern), "/") {
+		if _, err := filepath.Match(seg, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	var matches []string
+	err := filepath.WalkDir(globRoot(pattern), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable directories are skipped, not fatal
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() && d.Type()&fs.ModeNamedPipe == 0 {
+			return nil
+		}
+		if ok, _ := matchGlob(pattern, path); ok {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// globRoot returns the longest leading directory of pattern without
+// metacharacters, where a recursive walk starts.
+func globRoot(pattern string) string {
+	segs := strings.Split(filepath.ToSlash(pattern), "/")
+	var root []string
+	for _, seg := range segs[:len(segs)-1] {
+		if hasMeta(seg) {
+			break
+		}
+		root = append(root, seg)
+	}
+	if len(root) == 0 {
+		return "."
+	}
+	if len(root) == 1 && root[0] == "" {
+		return "/"
+	}
+	return filepath.FromSlash(strings.Join(root, "/"))
+}
+
+// matchGlob reports whether path matches pattern, where a "**" segment
+// matches zero or more path segments.
+func matchGlob(pattern, path string) (bool, error) {
+	if !isRecursive(pattern) {
+		return filepath.Match(pattern, path)
+	}
+	return matchSegments(
+		strings.Split(filepath.ToSlash(pattern), "/"),
+		strings.Split(filepath.ToSlash(path), "/"),
+	)
+}
+
+func matchSegments(pattern, path []string) (bool, error) {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Collapse repeated ** and try every split point
+			for len(pattern) > 0 && pattern[0] == "**" {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true, nil
+			}
+			for i := 0; i <= len(path); i++ {
+				ok, err := matchSegments(pattern, path[i:])
+				if err != nil || ok {
+					return ok, err
+				}
+			}
+			return false, nil
+		}
+		if len(path) == 0 {
+			return false, nil
+		}
+		ok, err := filepath.Match(pattern[0], path[0])
+		if err != nil || !ok {
+			return false, err
+		}
+		pattern, path = pattern[1:], path[1:]
+	}
+	return len(path) == 0, nil
+}
+
+// regularFiles filters out directories and other non-file matches.
+// Named pipes are kept since the tailer reads them too.
+func regularFiles(paths []string) []string {
+	files := paths[:0]
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+		if info.Mode().IsRegular() || isNamedPipe(info) {
+			files = append(files, p)
+		}
+	}
+	return files
+}
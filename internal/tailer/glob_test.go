@@ -0,0 +1,72 @@
+package tailer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"/var/log/*.log", "/var/log/app.log", true},
+		{"/var/log/*.log", "/var/log/nginx/app.log", false},
+		{"/var/log/**/*.log", "/var/log/app.log", true},
+		{"/var/log/**/*.log", "/var/log/nginx/sites/app.log", true},
+		{"/var/log/**/*.log", "/var/log/nginx/app.txt", false},
+		{"/var/log/**", "/var/log/a/b/c", true},
+		{"/var/log/**/worker-*/out.log", "/var/log/a/worker-3/out.log", true},
+		{"/var/log/**/worker-*/out.log", "/var/log/a/worker-3/b/out.log", false},
+		{"/srv/**/**/x.log", "/srv/x.log", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			got, err := matchGlob(tt.pattern, tt.path)
+			if err != nil {
+				t.Fatalf("matchGlob: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandGlobRecursive(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a.log", "nginx/b.log", "nginx/sites/c.log", "nginx/skip.txt"} {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("content\n"), 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+	}
+	// Directories matching the pattern are not files to tail
+	if err := os.MkdirAll(filepath.Join(tmpDir, "dir.log"), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+
+	got, err := expandGlob(filepath.Join(tmpDir, "**", "*.log"))
+	if err != nil {
+		t.Fatalf("expandGlob: %v", err)
+	}
+	want := []string{
+		filepath.Join(tmpDir, "a.log"),
+		filepath.Join(tmpDir, "nginx", "b.log"),
+		filepath.Join(tmpDir, "nginx", "sites", "c.log"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expandGlob = %v, want %v", got, want)
+	}
+
+	if _, err := expandGlob(filepath.Join(tmpDir, "**", "[")); err == nil {
+		t.Error("expected error for malformed pattern")
+	}
+}
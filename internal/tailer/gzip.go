@@ -0,0 +1,48 @@
+package tailer
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// isCompressed reports whether path names a gzip-compressed file.
+func isCompressed(path string) bool {
+	return strings.HasSuffix(path, ".gz")
+}
+
+// runCompressed reads a gzip-compressed file once, decompressing it while
+// streaming, and closes Lines at its end. Archives don't grow, so Follow,
+// StartPosition and Resume don't apply, and lines carry no Offset.
+func (t *Tailer) runCompressed(ctx context.Context) {
+	defer close(t.lines)
+
+	if t.file == nil {
+		return
+	}
+	zr, err := gzip.NewReader(t.file)
+	if err != nil {
+		t.sendLine(Line{Err: fmt.Errorf("gzip: %w", err)})
+		return
+	}
+	defer zr.Close()
+
+	r := t.newReader(zr)
+	lr := newLineReader(t.opts.MaxLineLength)
+	for ctx.Err() == nil {
+		raw, err := lr.read(r)
+		if err == nil {
+			t.sendLine(t.line(raw))
+			continue
+		}
+		if lr.pending() > 0 {
+			t.sendLine(t.line(lr.take()))
+		}
+		if err != io.EOF {
+			t.sendLine(Line{Err: fmt.Errorf("read error: %w", err)})
+		}
+		return
+	}
+}
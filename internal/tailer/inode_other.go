@@ -0,0 +1,15 @@
+//go:build !unix
+
+package tailer
+
+import "os"
+
+// Inode returns 0: this platform has no inode numbers.
+func Inode(info os.FileInfo) uint64 {
+	return 0
+}
+
+// isRemoved always reports false on this platform.
+func isRemoved(info os.FileInfo) bool {
+	return false
+}
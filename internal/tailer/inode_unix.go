@@ -0,0 +1,26 @@
+//go:build unix
+
+package tailer
+
+import (
+	"os"
+	"syscall"
+)
+
+// Inode returns the inode number of the file info describes, or 0 if
+// unknown.
+func Inode(info os.FileInfo) uint64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Ino)
+	}
+	return 0
+}
+
+// isRemoved reports whether the file info describes has no links left,
+// like an open file deleted by rotation.
+func isRemoved(info os.FileInfo) bool {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return st.Nlink == 0
+	}
+	return false
+}
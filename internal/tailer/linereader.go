@@ -0,0 +1,138 @@
+package tailer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+	"unicode/utf8"
+)
+
+// Read buffer and line length defaults, used when Options.ReadBufferSize
+// or Options.MaxLineLength is unset.
+const (
+	DefaultReadBufferSize = 64 * 1024
+	DefaultMaxLineLength  = 1024 * 1024
+)
+
+// truncationMarker is appended to a line cut to the maximum length.
+const truncationMarker = "...[truncated %d bytes]"
+
+// rawLine is a line read by a lineReader.
+type rawLine struct {
+	text string // line content without the line ending, at most max bytes
+	n    int    // bytes consumed, including the line ending
+	size int    // line length in bytes without the ending, before truncation
+}
+
+// truncated reports whether the line was cut to fit the maximum length.
+func (l rawLine) truncated() bool {
+	return l.size > len(l.text)
+}
+
+// lineReader reads lines of any length while keeping at most max bytes of
+// each, so a giant line costs neither unbounded memory nor the lines after
+// it. A partial line is held across reads until its newline arrives.
+type lineReader struct {
+	max  int
+	buf  []byte  // start of the current line, capped at max+2 bytes
+	n    int     // bytes of the current line consumed so far
+	tail [2]byte // last two bytes consumed, to find the line ending
+}
+
+func newLineReader(max int) *lineReader {
+	if max <= 0 {
+		max = DefaultMaxLineLength
+	}
+	return &lineReader{max: max}
+}
+
+// read reads up to the end of the current line from r. On an error,
+// including io.EOF before a newline, the partial line is kept for the next
+// read.
+func (lr *lineReader) read(r *bufio.Reader) (rawLine, error) {
+	for {
+		chunk, err := r.ReadSlice('\n')
+		lr.add(chunk)
+		switch err {
+		case nil:
+			return lr.take(), nil
+		case bufio.ErrBufferFull:
+			continue
+		default:
+			return rawLine{}, err
+		}
+	}
+}
+
+func (lr *lineReader) add(chunk []byte) {
+	lr.n += len(chunk)
+	// Room for the maximum plus "\r\n", so a line of exactly max bytes
+	// isn't mistaken for a longer one
+	if room := lr.max + 2 - len(lr.buf); room > 0 {
+		lr.buf = append(lr.buf, chunk[:min(room, len(chunk))]...)
+	}
+	for _, b := range chunk[max(0, len(chunk)-2):] {
+		lr.tail[0], lr.tail[1] = lr.tail[1], b
+	}
+}
+
+// pending returns the number of bytes of the partial line read so far.
+func (lr *lineReader) pending() int {
+	return lr.n
+}
+
+// take returns the line read so far without its "\n" or "\r\n" ending,
+// truncated to max bytes at a UTF-8 boundary, and starts a new line.
+func (lr *lineReader) take() rawLine {
+	size := lr.n
+	switch {
+	case lr.tail[1] == '\n':
+		size--
+		if size > 0 && lr.tail[0] == '\r' {
+			size--
+		}
+	case lr.tail[1] == '\r':
+		size--
+	}
+
+	text := lr.buf[:min(size, len(lr.buf))]
+	if size > lr.max {
+		cut := lr.max
+		for cut > 0 && !utf8.RuneStart(lr.buf[cut]) {
+			cut--
+		}
+		text = lr.buf[:cut]
+	}
+	line := rawLine{text: string(text), n: lr.n, size: size}
+	lr.reset()
+	return line
+}
+
+// reset discards the partial line.
+func (lr *lineReader) reset() {
+	lr.buf = lr.buf[:0]
+	lr.n = 0
+	lr.tail = [2]byte{}
+}
+
+// newReader returns a buffered reader of the configured size.
+func (t *Tailer) newReader(r io.Reader) *bufio.Reader {
+	size := t.opts.ReadBufferSize
+	if size <= 0 {
+		size = DefaultReadBufferSize
+	}
+	return bufio.NewReaderSize(r, size)
+}
+
+// line builds the Line emitted for a line read from the file. A truncated
+// line ends in a marker with the number of bytes cut.
+func (t *Tailer) line(raw rawLine) Line {
+	line := Line{Text: raw.text, FilePath: t.filePath, Time: time.Now()}
+	if raw.truncated() {
+		line.Text += fmt.Sprintf(truncationMarker, raw.size-len(raw.text))
+		line.Truncated = true
+		line.Size = raw.size
+	}
+	return line
+}
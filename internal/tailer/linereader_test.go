@@ -0,0 +1,104 @@
+package tailer
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLineReader(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		max           int
+		wantText      string
+		wantN         int
+		wantSize      int
+		wantTruncated bool
+	}{
+		{"short", "hello\n", 10, "hello", 6, 5, false},
+		{"crlf", "hello\r\n", 10, "hello", 7, 5, false},
+		{"exactly max", "0123456789\r\n", 10, "0123456789", 12, 10, false},
+		{"one over max", "0123456789a\n", 10, "0123456789", 12, 11, true},
+		{"longer than the read buffer", strings.Repeat("x", 100) + "\n", 40, strings.Repeat("x", 40), 101, 100, true},
+		{"cut at a rune boundary", "123456789é\n", 10, "123456789", 12, 11, true},
+		{"empty", "\n", 10, "", 1, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lr := newLineReader(tt.max)
+			raw, err := lr.read(bufio.NewReaderSize(strings.NewReader(tt.input+"next\n"), 16))
+			if err != nil {
+				t.Fatalf("read() error = %v", err)
+			}
+			if raw.text != tt.wantText || raw.n != tt.wantN || raw.size != tt.wantSize || raw.truncated() != tt.wantTruncated {
+				t.Errorf("read() = %q n=%d size=%d truncated=%v, want %q n=%d size=%d truncated=%v",
+					raw.text, raw.n, raw.size, raw.truncated(), tt.wantText, tt.wantN, tt.wantSize, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestLineReaderPartialLine(t *testing.T) {
+	lr := newLineReader(8)
+	if _, err := lr.read(bufio.NewReader(strings.NewReader("abcdefghij"))); err != io.EOF {
+		t.Fatalf("read() error = %v, want io.EOF", err)
+	}
+	if lr.pending() != 10 {
+		t.Errorf("pending() = %d, want 10", lr.pending())
+	}
+
+	// The rest of the line arrives on a new reader, as after a pipe reconnect
+	raw, err := lr.read(bufio.NewReader(strings.NewReader("kl\n")))
+	if err != nil {
+		t.Fatalf("read() error = %v", err)
+	}
+	if raw.text != "abcdefgh" || raw.size != 12 || raw.n != 13 {
+		t.Errorf("read() = %q size=%d n=%d, want abcdefgh size=12 n=13", raw.text, raw.size, raw.n)
+	}
+	if lr.pending() != 0 {
+		t.Errorf("pending() after a full line = %d, want 0", lr.pending())
+	}
+}
+
+func TestTailerTruncatesLongLines(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "test.log")
+	long := strings.Repeat("x", 5000)
+	content := "short\n" + long + "\nafter\n"
+	if err := os.WriteFile(tmpFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+
+	opts := DefaultOptions()
+	opts.Follow = false
+	opts.ReadBufferSize = 64
+	opts.MaxLineLength = 100
+	tailer, err := NewTailer(tmpFile, opts)
+	if err != nil {
+		t.Fatalf("failed to create tailer: %v", err)
+	}
+	defer tailer.Stop()
+
+	tailer.readLines()
+	var lines []Line
+	for len(tailer.lines) > 0 {
+		lines = append(lines, <-tailer.lines)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3", len(lines))
+	}
+	if lines[1].Text != long[:100]+"...[truncated 4900 bytes]" || !lines[1].Truncated || lines[1].Size != 5000 {
+		t.Errorf("long line = %d bytes truncated=%v size=%d, want 100 bytes truncated size=5000",
+			len(lines[1].Text), lines[1].Truncated, lines[1].Size)
+	}
+	if lines[0].Truncated || lines[2].Text != "after" || lines[2].Truncated {
+		t.Errorf("lines around the long one = %+v, %+v", lines[0], lines[2])
+	}
+	if tailer.offset != int64(len(content)) {
+		t.Errorf("offset = %d, want %d", tailer.offset, len(content))
+	}
+}
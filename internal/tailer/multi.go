@@ -3,19 +3,26 @@ package tailer
 import (
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 )
 
 // MultiTailer watches multiple files matching glob patterns and emits lines
-// from all of them.
+// from all of them. When following, it rescans the patterns periodically:
+// files that start matching are tailed from their beginning, and files that
+// vanish are dropped. Compressed files are read once, and only when a
+// pattern names them (e.g. "/archive/*.log.gz").
 type MultiTailer struct {
 	patterns []string
 	opts     *Options
 
 	tailers map[string]*Tailer
+	missing map[string]bool        // tailed paths absent at the last rescan
+	rotated map[string]os.FileInfo // matching paths skipped as rotated-away files
 	watcher *fsnotify.Watcher
 
 	lines chan Line
@@ -28,6 +35,8 @@ type MultiTailer struct {
 
 // NewMultiTailer creates a new MultiTailer for the given patterns.
 // Patterns can be file paths or glob patterns (e.g., "/var/log/*.log").
+// A "**" segment matches any number of directories
+// (e.g., "/var/log/apps/**/*.log").
 func NewMultiTailer(patterns []string, opts *Options) (*MultiTailer, error) {
 	if opts == nil {
 		opts = DefaultOptions()
@@ -42,6 +51,8 @@ func NewMultiTailer(patterns []string, opts *Options) (*MultiTailer, error) {
 		patterns: patterns,
 		opts:     opts,
 		tailers:  make(map[string]*Tailer),
+		missing:  make(map[string]bool),
+		rotated:  make(map[string]os.FileInfo),
 		watcher:  watcher,
 		lines:    make(chan Line, 100),
 		done:     make(chan struct{}),
@@ -55,9 +66,9 @@ func (mt *MultiTailer) Lines() <-chan Line {
 	return mt.lines
 }
 
-// Start begins tailing all files matching the patterns.
-// When Follow is false, reads existing content from the beginning.
-// When Follow is true, starts from the end and follows new content.
+// Start begins tailing all files matching the patterns. Files present now
+// start at Options.StartPosition; when following, files that appear later
+// are read from the beginning.
 func (mt *MultiTailer) Start(ctx context.Context) error {
 	// Expand patterns and create tailers
 	if err := mt.expandPatterns(); err != nil {
@@ -70,12 +81,13 @@ func (mt *MultiTailer) Start(ctx context.Context) error {
 			return err
 		}
 		go mt.watchForNewFiles(ctx)
+		go mt.rescanLoop(ctx)
 	}
 
 	// Start all tailers
 	for _, t := range mt.tailers {
 		var err error
-		if mt.opts.Follow {
+		if mt.opts.FromEnd() {
 			err = t.StartFromEnd(ctx)
 		} else {
 			err = t.Start(ctx)
@@ -126,7 +138,7 @@ func (mt *MultiTailer) Files() []string {
 
 func (mt *MultiTailer) expandPatterns() error {
 	for _, pattern := range mt.patterns {
-		matches, err := filepath.Glob(pattern)
+		matches, err := expandGlob(pattern)
 		if err != nil {
 			return fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
 		}
@@ -153,6 +165,9 @@ func (mt *MultiTailer) addTailer(filePath string) error {
 	if _, exists := mt.tailers[filePath]; exists {
 		return nil
 	}
+	if mt.skipArchive(filePath) {
+		return nil
+	}
 
 	tailer, err := NewTailer(filePath, mt.opts)
 	if err != nil {
@@ -167,8 +182,24 @@ func (mt *MultiTailer) watchDirectories() error {
 	dirs := make(map[string]bool)
 
 	for _, pattern := range mt.patterns {
-		dir := filepath.Dir(pattern)
-		if !dirs[dir] {
+		// Recursive patterns rely on the periodic rescan
+		if isRecursive(pattern) {
+			continue
+		}
+		// A pattern such as /var/log/*/app.log watches every matching
+		// directory that exists at start.
+		candidates := []string{filepath.Dir(pattern)}
+		if hasMeta(candidates[0]) {
+			matches, err := filepath.Glob(candidates[0])
+			if err != nil {
+				return fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+			}
+			candidates = matches
+		}
+		for _, dir := range candidates {
+			if dirs[dir] {
+				continue
+			}
 			dirs[dir] = true
 			if err := mt.watcher.Add(dir); err != nil {
 				return fmt.Errorf("failed to watch directory %s: %w", dir, err)
@@ -203,30 +234,164 @@ func (mt *MultiTailer) watchForNewFiles(ctx context.Context) {
 func (mt *MultiTailer) handleNewFile(ctx context.Context, filePath string) {
 	// Check if this file matches any of our patterns
 	for _, pattern := range mt.patterns {
-		matched, err := filepath.Match(pattern, filePath)
+		matched, err := matchGlob(pattern, filePath)
+		if err != nil || !matched {
+			continue
+		}
+
+		mt.mu.Lock()
+		mt.followLocked(ctx, filePath)
+		mt.mu.Unlock()
+		return
+	}
+}
+
+// rescanLoop re-expands the patterns every RescanInterval until stopped.
+func (mt *MultiTailer) rescanLoop(ctx context.Context) {
+	interval := mt.opts.RescanInterval
+	if interval <= 0 {
+		interval = defaultRescanInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-mt.done:
+			return
+		case <-ticker.C:
+			mt.rescan(ctx)
+		}
+	}
+}
+
+// rescan starts tailing newly matching files and drops tailers whose files
+// are gone. A file must be missing on two consecutive rescans before it is
+// dropped, since rename rotation briefly leaves the path empty.
+func (mt *MultiTailer) rescan(ctx context.Context) {
+	matches := make(map[string]bool)
+	for _, pattern := range mt.patterns {
+		paths, err := expandGlob(pattern)
 		if err != nil {
 			continue
 		}
+		for _, path := range paths {
+			matches[path] = true
+		}
+	}
 
-		if matched {
-			if err := mt.addTailer(filePath); err != nil {
-				continue
-			}
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	if mt.closed {
+		return
+	}
 
-			mt.mu.Lock()
-			tailer := mt.tailers[filePath]
-			mt.mu.Unlock()
+	for path, t := range mt.tailers {
+		if matches[path] {
+			delete(mt.missing, path)
+			continue
+		}
+		if _, err := os.Stat(path); err == nil {
+			// Still there (e.g. a literal path no pattern lists)
+			delete(mt.missing, path)
+			continue
+		}
+		if !mt.missing[path] {
+			mt.missing[path] = true
+			continue
+		}
+		t.Stop()
+		delete(mt.tailers, path)
+		delete(mt.missing, path)
+	}
 
-			if tailer != nil {
-				if err := tailer.StartFromEnd(ctx); err != nil {
-					continue
-				}
-				mt.wg.Add(1)
-				go mt.forwardLines(tailer)
-			}
-			break
+	previous := mt.rotated
+	mt.rotated = make(map[string]os.FileInfo)
+	for path := range matches {
+		if _, ok := mt.tailers[path]; ok {
+			continue
+		}
+		mt.followNew(ctx, path, previous)
+	}
+}
+
+// followLocked starts tailing a file that appeared after Start. The caller
+// must hold mt.mu.
+func (mt *MultiTailer) followLocked(ctx context.Context, path string) {
+	if mt.closed {
+		return
+	}
+	if _, ok := mt.tailers[path]; ok {
+		// A tailed file was recreated; its tailer reopens it
+		delete(mt.missing, path)
+		return
+	}
+	mt.followNew(ctx, path, mt.rotated)
+}
+
+// followNew tails path from its beginning unless it is a file already read
+// under another name, such as access.log renamed to access.log.1 by
+// rotation, which would otherwise be read twice. The caller must hold mt.mu.
+func (mt *MultiTailer) followNew(ctx context.Context, path string, rotated map[string]os.FileInfo) {
+	info, err := os.Stat(path)
+	if err != nil || !(info.Mode().IsRegular() || isNamedPipe(info)) {
+		return
+	}
+	if mt.isRotated(info, rotated) {
+		mt.rotated[path] = info
+		return
+	}
+	if mt.skipArchive(path) {
+		return
+	}
+
+	t, err := NewTailer(path, mt.opts)
+	if err != nil {
+		return
+	}
+	if err := t.Start(ctx); err != nil {
+		t.Stop()
+		return
+	}
+	mt.tailers[path] = t
+	mt.wg.Add(1)
+	go mt.forwardLines(t)
+}
+
+// skipArchive reports whether path is a compressed file matched only by
+// patterns that don't name archives, such as access.log.2.gz matched by
+// access.log*: a rotated copy, not to be read in full on every start.
+func (mt *MultiTailer) skipArchive(path string) bool {
+	if !isCompressed(path) {
+		return false
+	}
+	for _, pattern := range mt.patterns {
+		if !isCompressed(pattern) {
+			continue
+		}
+		if matched, err := matchGlob(pattern, path); err == nil && matched {
+			return false
+		}
+	}
+	return true
+}
+
+// isRotated reports whether info is a file some tailer has already read,
+// directly or via an earlier rotated name.
+func (mt *MultiTailer) isRotated(info os.FileInfo, rotated map[string]os.FileInfo) bool {
+	for _, t := range mt.tailers {
+		if t.hasOpened(info) {
+			return true
+		}
+	}
+	for _, seen := range rotated {
+		if os.SameFile(seen, info) {
+			return true
 		}
 	}
+	return false
 }
 
 func (mt *MultiTailer) forwardLines(t *Tailer) {
@@ -218,6 +218,114 @@ func TestMultiTailerNoMatchingFiles(t *testing.T) {
 	}
 }
 
+func TestMultiTailerDirectoryGlobFollow(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	for _, dir := range []string{"shop", "blog"} {
+		if err := os.MkdirAll(filepath.Join(tmpDir, dir), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(tmpDir, dir, "app.log"), []byte("content\n"), 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+	}
+
+	opts := DefaultOptions()
+	opts.Follow = true
+
+	mt, err := NewMultiTailer([]string{filepath.Join(tmpDir, "*", "app.log")}, opts)
+	if err != nil {
+		t.Fatalf("failed to create multi-tailer: %v", err)
+	}
+	defer mt.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	// Directory globs are expanded when watching for new files
+	if err := mt.Start(ctx); err != nil {
+		t.Fatalf("failed to start multi-tailer: %v", err)
+	}
+	if files := mt.Files(); len(files) != 2 {
+		t.Errorf("expected 2 files, got %d: %v", len(files), files)
+	}
+}
+
+// collectLines reads lines from mt until want lines arrive or timeout.
+func collectLines(t *testing.T, mt *MultiTailer, want int) []string {
+	t.Helper()
+	var got []string
+	timeout := time.After(2 * time.Second)
+	for len(got) < want {
+		select {
+		case line := <-mt.Lines():
+			if line.Err == nil {
+				got = append(got, filepath.Base(line.FilePath)+": "+line.Text)
+			}
+		case <-timeout:
+			t.Fatalf("got %d lines, want %d: %v", len(got), want, got)
+		}
+	}
+	return got
+}
+
+func TestMultiTailerRescan(t *testing.T) {
+	tmpDir := t.TempDir()
+	appLog := filepath.Join(tmpDir, "app.log")
+	if err := os.WriteFile(appLog, []byte("old\n"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	opts := DefaultOptions()
+	opts.RescanInterval = time.Hour // rescans are triggered by hand
+
+	mt, err := NewMultiTailer([]string{filepath.Join(tmpDir, "**", "*.log*")}, opts)
+	if err != nil {
+		t.Fatalf("failed to create multi-tailer: %v", err)
+	}
+	defer mt.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := mt.Start(ctx); err != nil {
+		t.Fatalf("failed to start multi-tailer: %v", err)
+	}
+
+	// A file appearing in a new subdirectory is read from its beginning
+	worker := filepath.Join(tmpDir, "workers", "w1.log")
+	if err := os.MkdirAll(filepath.Dir(worker), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(worker, []byte("w1 first\n"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	mt.rescan(ctx)
+	if got := collectLines(t, mt, 1); got[0] != "w1.log: w1 first" {
+		t.Errorf("new file line = %q, want %q", got[0], "w1.log: w1 first")
+	}
+
+	// A renamed-away file is not read again under its new name
+	if err := os.Rename(appLog, appLog+".1"); err != nil {
+		t.Fatalf("failed to rotate: %v", err)
+	}
+	mt.rescan(ctx)
+	if files := mt.Files(); len(files) != 2 {
+		t.Errorf("after rotation tailing %v, want app.log and w1.log", files)
+	}
+
+	// A vanished file is dropped after two rescans
+	if err := os.Remove(worker); err != nil {
+		t.Fatalf("failed to remove file: %v", err)
+	}
+	mt.rescan(ctx)
+	mt.rescan(ctx)
+	for _, f := range mt.Files() {
+		if f == worker {
+			t.Errorf("vanished file %s still tailed", worker)
+		}
+	}
+}
+
 func TestMultiTailerInvalidGlobPattern(t *testing.T) {
 	// This test verifies behavior with invalid glob patterns
 	// The filepath.Glob function handles most patterns gracefully
@@ -272,3 +380,47 @@ func TestMultiTailerStop(t *testing.T) {
 	// Calling Stop again should be safe
 	mt.Stop()
 }
+
+func TestMultiTailerArchives(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "app.log"), []byte("live\n"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	writeGzip(t, filepath.Join(tmpDir, "app.log.1.gz"), "archived\n", time.Now())
+
+	tests := []struct {
+		name    string
+		pattern string
+		want    []string
+	}{
+		{name: "rotated archives are skipped", pattern: "app.log*", want: []string{"app.log: live"}},
+		{name: "a pattern naming archives reads them", pattern: "*.gz", want: []string{"app.log.1.gz: archived"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.Follow = false
+			mt, err := NewMultiTailer([]string{filepath.Join(tmpDir, tt.pattern)}, opts)
+			if err != nil {
+				t.Fatalf("failed to create multi-tailer: %v", err)
+			}
+			defer mt.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			if err := mt.Start(ctx); err != nil {
+				t.Fatalf("failed to start multi-tailer: %v", err)
+			}
+			got := collectLines(t, mt, len(tt.want))
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("line %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+			if files := mt.Files(); len(files) != 1 {
+				t.Errorf("tailing %v, want one file", files)
+			}
+		})
+	}
+}
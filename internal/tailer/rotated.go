@@ -0,0 +1,135 @@
+package tailer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// fingerprintSize is how many leading bytes of a file identify it when
+// looking for its rotated copy.
+const fingerprintSize = 256
+
+// maxRotatedRemainder bounds the bytes read from a rotated file, so a huge
+// or hostile archive can't stall the tailer.
+const maxRotatedRemainder = 64 * 1024 * 1024
+
+// rotatedSuffix matches what rotation tools append to a rotated file's name:
+// ".1", ".1.gz", "-20240115", "-20240115.gz", ".2024-01-15.gz".
+var rotatedSuffix = regexp.MustCompile(`^[.-][0-9][0-9A-Za-z_.-]*$`)
+
+// latestRotated returns the most recently modified file in path's directory
+// named like a rotated copy of path, or "" if there is none.
+func latestRotated(path string) string {
+	dir, base := filepath.Split(path)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+
+	var latest string
+	var latestMod time.Time
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, base) || !rotatedSuffix.MatchString(name[len(base):]) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(latestMod) {
+			latest, latestMod = filepath.Join(dir, name), info.ModTime()
+		}
+	}
+	return latest
+}
+
+// readFingerprint returns up to fingerprintSize leading bytes of f.
+func readFingerprint(f *os.File) []byte {
+	buf := make([]byte, fingerprintSize)
+	n, _ := f.ReadAt(buf, 0)
+	return buf[:n]
+}
+
+// openRotated opens a rotated file, transparently decompressing gzip.
+func openRotated(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(f)
+	magic, _ := br.Peek(2)
+	if len(magic) < 2 || magic[0] != 0x1f || magic[1] != 0x8b {
+		return struct {
+			io.Reader
+			io.Closer
+		}{br, f}, nil
+	}
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("gzip: %w", err)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{zr, f}, nil
+}
+
+// readRotatedRemainder emits the lines of the most recently rotated copy of
+// the file that come after the consumed bytes already read. The copy is
+// identified by its leading bytes matching fingerprint, so an older rotation
+// is never replayed. Used with Options.ReadRotated.
+func (t *Tailer) readRotatedRemainder(fingerprint []byte, consumed int64, current os.FileInfo) {
+	if len(fingerprint) == 0 {
+		return // nothing read yet, so no way to recognize the copy
+	}
+	path := latestRotated(t.filePath)
+	if path == "" {
+		return
+	}
+	// A renamed file still open here was already drained through its handle
+	if info, err := os.Stat(path); err != nil || (current != nil && os.SameFile(info, current)) {
+		return
+	}
+
+	rc, err := openRotated(path)
+	if err != nil {
+		t.sendLine(Line{Err: fmt.Errorf("read rotated file %s: %w", path, err)})
+		return
+	}
+	defer rc.Close()
+
+	r := t.newReader(io.LimitReader(rc, consumed+maxRotatedRemainder))
+	head, _ := r.Peek(len(fingerprint))
+	if !bytes.Equal(head, fingerprint) {
+		return // not a copy of the file we were reading
+	}
+	if _, err := io.CopyN(io.Discard, r, consumed); err != nil {
+		return // no lines past what was already read
+	}
+
+	lr := newLineReader(t.opts.MaxLineLength)
+	for {
+		raw, err := lr.read(r)
+		if err == nil {
+			t.sendLine(t.line(raw))
+			continue
+		}
+		if lr.pending() > 0 {
+			t.sendLine(t.line(lr.take()))
+		}
+		if err != io.EOF {
+			t.sendLine(Line{Err: fmt.Errorf("read rotated file %s: %w", path, err)})
+		}
+		return
+	}
+}
@@ -17,10 +17,16 @@ import (
 
 // Line represents a single line read from a file.
 type Line struct {
-	Text     string    // The line content
+	Text     string    // The line content; a truncated line ends in "...[truncated N bytes]"
 	FilePath string    // The source file path
 	Time     time.Time // When the line was read
 	Err      error     // Any error that occurred
+
+	Truncated bool // The line was longer than MaxLineLength and was cut
+	Size      int  // Length in bytes of a truncated line before it was cut
+
+	Offset int64  // Offset in the file just past the line; 0 if unknown (pipes, rotated copies)
+	Inode  uint64 // Inode of the file the line was read from; 0 if unknown
 }
 
 // Options contains options for configuring a Tailer.
@@ -33,6 +39,57 @@ type Options struct {
 	ReOpen bool
 	// MustExist indicates whether the file must exist at startup.
 	MustExist bool
+	// StartPosition selects where tailing begins in files present at
+	// start: StartEnd skips existing content, StartBeginning reads it.
+	// Empty means StartEnd when following and StartBeginning otherwise.
+	StartPosition string
+	// RescanInterval is how often a MultiTailer re-expands its patterns
+	// to pick up new files and drop vanished ones (0 = default).
+	RescanInterval time.Duration
+	// ReadRotated reads, on rotation or truncation, the lines past the
+	// last read position from the most recently rotated copy of the file
+	// (app.log.1, app.log.1.gz, ...), decompressing gzip. This catches
+	// lines written between the last read and the rotation.
+	ReadRotated bool
+	// ReadBufferSize is the size in bytes of the file read buffer
+	// (0 = DefaultReadBufferSize).
+	ReadBufferSize int
+	// MaxLineLength is the longest line in bytes emitted as is (0 =
+	// DefaultMaxLineLength). Longer lines are cut to it and flagged as
+	// Truncated rather than dropped.
+	MaxLineLength int
+	// Resume returns the offset to continue a file from, such as one saved
+	// before a restart. When it returns true, the file starts there rather
+	// than at StartPosition; an offset past the end of the file, which was
+	// truncated since, starts at its beginning.
+	Resume func(path string, info os.FileInfo) (int64, bool)
+}
+
+// Start positions.
+const (
+	StartBeginning = "beginning"
+	StartEnd       = "end"
+)
+
+// defaultRescanInterval is the MultiTailer rescan interval when
+// Options.RescanInterval is unset.
+const defaultRescanInterval = 10 * time.Second
+
+// maxOpenedHistory bounds how many previously opened files a Tailer
+// remembers for rotation detection.
+const maxOpenedHistory = 8
+
+// FromEnd reports whether tailing should skip the existing content of
+// files present at start.
+func (o *Options) FromEnd() bool {
+	switch o.StartPosition {
+	case StartEnd:
+		return true
+	case StartBeginning:
+		return false
+	default:
+		return o.Follow
+	}
 }
 
 // DefaultOptions returns Options with sensible defaults.
@@ -45,22 +102,31 @@ func DefaultOptions() *Options {
 	}
 }
 
-// Tailer watches a file and emits new lines as they're written.
+// Tailer watches a file and emits new lines as they're written. A file
+// named *.gz is an archive instead: it is decompressed and read once.
 type Tailer struct {
 	filePath string
 	opts     *Options
 	watcher  *fsnotify.Watcher
+	isPipe   bool // filePath is a named pipe (FIFO)
+
+	compressed bool // filePath is gzip-compressed, read once to its end
 
 	file   *os.File
 	reader *bufio.Reader
-	offset int64
+	lr     *lineReader
+	offset int64 // bytes of the current file consumed as lines
 	size   int64
+	inode  uint64 // Inode of the current file
+
+	fingerprint []byte // leading bytes of the current file, for ReadRotated
 
 	lines chan Line
 	done  chan struct{}
 
 	mu     sync.Mutex
 	closed bool
+	opened []os.FileInfo // files opened so far, most recent last
 }
 
 // NewTailer creates a new Tailer for the given file path.
@@ -93,12 +159,16 @@ func NewTailer(filePath string, opts *Options) (*Tailer, error) {
 		filePath: absPath,
 		opts:     opts,
 		watcher:  watcher,
+		lr:       newLineReader(opts.MaxLineLength),
 		lines:    make(chan Line, 100),
 		done:     make(chan struct{}),
+		isPipe:   isNamedPipe(info),
+
+		compressed: isCompressed(absPath),
 	}
 
-	// Open file for reading
-	if info != nil {
+	// Open file for reading (pipes are opened once tailing starts)
+	if info != nil && !t.isPipe {
 		if err := t.openFile(); err != nil {
 			watcher.Close()
 			return nil, err
@@ -115,8 +185,23 @@ func (t *Tailer) Lines() <-chan Line {
 }
 
 // Start begins tailing the file. It reads existing content from the current
-// position and then watches for new content.
+// position, or the one Options.Resume returns, and then watches for new
+// content.
 func (t *Tailer) Start(ctx context.Context) error {
+	t.resume()
+	return t.start(ctx)
+}
+
+func (t *Tailer) start(ctx context.Context) error {
+	if t.isPipe {
+		t.startPipe(ctx)
+		return nil
+	}
+	if t.compressed {
+		go t.runCompressed(ctx)
+		return nil
+	}
+
 	// Add directory to watcher for rotation detection
 	dir := filepath.Dir(t.filePath)
 	if err := t.watcher.Add(dir); err != nil {
@@ -129,8 +214,14 @@ func (t *Tailer) Start(ctx context.Context) error {
 	return nil
 }
 
-// StartFromEnd begins tailing from the end of the file (skipping existing content).
+// StartFromEnd begins tailing from the end of the file (skipping existing
+// content), unless Options.Resume returns a position for it. A compressed
+// file is read in full.
 func (t *Tailer) StartFromEnd(ctx context.Context) error {
+	if t.compressed || t.resume() {
+		return t.start(ctx)
+	}
+
 	// Seek to end of file
 	if t.file != nil {
 		offset, err := t.file.Seek(0, io.SeekEnd)
@@ -138,10 +229,37 @@ func (t *Tailer) StartFromEnd(ctx context.Context) error {
 			return fmt.Errorf("failed to seek to end: %w", err)
 		}
 		t.offset = offset
-		t.reader = bufio.NewReader(t.file)
+		t.reader = t.newReader(t.file)
+		t.lr.reset()
 	}
 
-	return t.Start(ctx)
+	return t.start(ctx)
+}
+
+// resume seeks the file to the offset Options.Resume returns for it,
+// reporting whether it did.
+func (t *Tailer) resume() bool {
+	if t.opts.Resume == nil || t.file == nil || t.compressed {
+		return false
+	}
+	info, err := t.file.Stat()
+	if err != nil {
+		return false
+	}
+	offset, ok := t.opts.Resume(t.filePath, info)
+	if !ok {
+		return false
+	}
+	if offset > info.Size() {
+		offset = 0
+	}
+	if _, err := t.file.Seek(offset, io.SeekStart); err != nil {
+		return false
+	}
+	t.offset = offset
+	t.reader = t.newReader(t.file)
+	t.lr.reset()
+	return true
 }
 
 // Stop stops the tailer.
@@ -167,11 +285,37 @@ func (t *Tailer) openFile() error {
 		return fmt.Errorf("failed to open file: %w", err)
 	}
 	t.file = file
-	t.reader = bufio.NewReader(file)
+	t.reader = t.newReader(file)
+	t.lr.reset()
 	t.offset = 0
+	t.fingerprint = nil
+
+	t.inode = 0
+	if info, err := file.Stat(); err == nil {
+		t.inode = Inode(info)
+		t.mu.Lock()
+		t.opened = append(t.opened, info)
+		if len(t.opened) > maxOpenedHistory {
+			t.opened = t.opened[len(t.opened)-maxOpenedHistory:]
+		}
+		t.mu.Unlock()
+	}
 	return nil
 }
 
+// hasOpened reports whether info is a file this tailer has read, such as
+// its file before a rename rotation.
+func (t *Tailer) hasOpened(info os.FileInfo) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, opened := range t.opened {
+		if os.SameFile(opened, info) {
+			return true
+		}
+	}
+	return false
+}
+
 func (t *Tailer) run(ctx context.Context) {
 	defer close(t.lines)
 
@@ -216,7 +360,8 @@ func (t *Tailer) handleEvent(event fsnotify.Event) {
 	}
 
 	if event.Has(fsnotify.Write) {
-		t.readLines()
+		// Also catches a copytruncate between two writes
+		t.checkForChanges()
 	} else if event.Has(fsnotify.Create) {
 		// File was recreated (rotation)
 		if t.opts.ReOpen {
@@ -236,10 +381,18 @@ func (t *Tailer) checkForChanges() {
 		return
 	}
 
+	// Check for rename rotation: the path is a new file, in case its
+	// create event was missed
+	if t.opts.ReOpen && !t.isOpen(info) {
+		t.handleRotation()
+		return
+	}
+
 	newSize := info.Size()
 
-	// Check for file truncation (log rotation with copytruncate)
-	if newSize < t.size {
+	// Check for file truncation (log rotation with copytruncate). The file
+	// may have grown again since, but not past what was already read.
+	if newSize < t.offset || newSize < t.size {
 		t.handleTruncation()
 		return
 	}
@@ -251,9 +404,34 @@ func (t *Tailer) checkForChanges() {
 	}
 }
 
+// isOpen reports whether info is the file the tailer has open. A removed
+// file never matches, since its replacement may reuse its inode.
+func (t *Tailer) isOpen(info os.FileInfo) bool {
+	if t.file == nil {
+		return false
+	}
+	current, err := t.file.Stat()
+	if err != nil {
+		return true // can't tell; keep reading it
+	}
+	return os.SameFile(current, info) && !isRemoved(current)
+}
+
 func (t *Tailer) handleRotation() {
-	// Close old file
+	// Nothing to do if polling already switched to the new file
+	if info, err := os.Stat(t.filePath); err == nil && t.isOpen(info) {
+		t.readLines()
+		return
+	}
+
+	// Drain lines written to the old file before it was rotated away, then
+	// close it
+	t.readLines()
 	if t.file != nil {
+		if t.opts.ReadRotated {
+			old, _ := t.file.Stat()
+			t.readRotatedRemainder(t.fingerprint, t.offset, old)
+		}
 		t.file.Close()
 		t.file = nil
 	}
@@ -276,8 +454,15 @@ func (t *Tailer) handleRotation() {
 func (t *Tailer) handleTruncation() {
 	// File was truncated, seek to beginning
 	if t.file != nil {
+		if t.opts.ReadRotated {
+			// copytruncate: the lines since the last read are in the copy
+			current, _ := t.file.Stat()
+			t.readRotatedRemainder(t.fingerprint, t.offset, current)
+		}
+		t.fingerprint = nil
 		t.file.Seek(0, io.SeekStart)
-		t.reader = bufio.NewReader(t.file)
+		t.reader = t.newReader(t.file)
+		t.lr.reset()
 		t.offset = 0
 		t.size = 0
 		t.readLines()
@@ -290,38 +475,29 @@ func (t *Tailer) readLines() {
 	}
 
 	for {
-		line, err := t.reader.ReadString('\n')
+		raw, err := t.lr.read(t.reader)
 		if err != nil {
 			if err == io.EOF {
 				// No more data available right now
-				// Update offset for partial line handling
-				if len(line) > 0 {
+				if n := t.lr.pending(); n > 0 {
 					// Partial line, we'll read it next time
 					// Seek back to re-read it
-					seekBack := int64(len(line))
-					t.file.Seek(-seekBack, io.SeekCurrent)
-					t.reader = bufio.NewReader(t.file)
+					t.file.Seek(-int64(n), io.SeekCurrent)
+					t.reader = t.newReader(t.file)
+					t.lr.reset()
+				}
+				if t.opts.ReadRotated && len(t.fingerprint) < fingerprintSize {
+					t.fingerprint = readFingerprint(t.file)
 				}
 				return
 			}
 			t.sendLine(Line{Err: fmt.Errorf("read error: %w", err)})
 			return
 		}
-
-		// Remove trailing newline
-		if len(line) > 0 && line[len(line)-1] == '\n' {
-			line = line[:len(line)-1]
-		}
-		// Remove trailing carriage return (Windows)
-		if len(line) > 0 && line[len(line)-1] == '\r' {
-			line = line[:len(line)-1]
-		}
-
-		t.sendLine(Line{
-			Text:     line,
-			FilePath: t.filePath,
-			Time:     time.Now(),
-		})
+		t.offset += int64(raw.n)
+		line := t.line(raw)
+		line.Offset, line.Inode = t.offset, t.inode
+		t.sendLine(line)
 	}
 }
 
@@ -1,11 +1,16 @@
 package tailer
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/fsnotify/fsnotify"
 )
 
 func TestNewTailer(t *testing.T) {
@@ -99,6 +104,69 @@ func TestTailerReadExistingContent(t *testing.T) {
 	}
 }
 
+func TestTailerResume(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "test.log")
+	if err := os.WriteFile(tmpFile, []byte("line 1\nline 2\nline 3\n"), 0644); err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		offset    int64
+		fromEnd   bool
+		expected  []string
+		endOffset int64
+	}{
+		{name: "saved offset", offset: 7, expected: []string{"line 2", "line 3"}, endOffset: 21},
+		{name: "saved offset overrides start at end", offset: 14, fromEnd: true, expected: []string{"line 3"}, endOffset: 21},
+		{name: "offset past the end", offset: 100, expected: []string{"line 1", "line 2", "line 3"}, endOffset: 21},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.Follow = false
+			opts.Resume = func(path string, info os.FileInfo) (int64, bool) {
+				if path != tmpFile {
+					t.Errorf("Resume(%s), want %s", path, tmpFile)
+				}
+				return tt.offset, true
+			}
+			tailer, err := NewTailer(tmpFile, opts)
+			if err != nil {
+				t.Fatalf("failed to create tailer: %v", err)
+			}
+			defer tailer.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			start := tailer.Start
+			if tt.fromEnd {
+				start = tailer.StartFromEnd
+			}
+			if err := start(ctx); err != nil {
+				t.Fatalf("failed to start tailer: %v", err)
+			}
+
+			var lines []Line
+			for line := range tailer.Lines() {
+				lines = append(lines, line)
+			}
+			if len(lines) != len(tt.expected) {
+				t.Fatalf("expected %d lines, got %d", len(tt.expected), len(lines))
+			}
+			for i, line := range lines {
+				if line.Text != tt.expected[i] {
+					t.Errorf("line %d: expected %q, got %q", i, tt.expected[i], line.Text)
+				}
+			}
+			if last := lines[len(lines)-1]; last.Offset != tt.endOffset {
+				t.Errorf("last line offset = %d, want %d", last.Offset, tt.endOffset)
+			}
+		})
+	}
+}
+
 func TestTailerFollowNewContent(t *testing.T) {
 	tmpDir := t.TempDir()
 	tmpFile := filepath.Join(tmpDir, "test.log")
@@ -324,6 +392,227 @@ readLoop:
 	}
 }
 
+func TestTailerTruncationBelowOffset(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "test.log")
+	if err := os.WriteFile(tmpFile, []byte("first\n"), 0644); err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	tailer, err := NewTailer(tmpFile, DefaultOptions())
+	if err != nil {
+		t.Fatalf("failed to create tailer: %v", err)
+	}
+	defer tailer.Stop()
+	tailer.readLines()
+
+	// Lines read on write events, between two polls
+	appendFile(t, tmpFile, "second line\nthird line\n")
+	tailer.handleEvent(fsnotify.Event{Name: tmpFile, Op: fsnotify.Write})
+	if got := strings.Join(drainLines(tailer), "|"); got != "first|second line|third line" {
+		t.Fatalf("before truncation: got %q", got)
+	}
+
+	// copytruncate, then a write: larger than the last polled size, but
+	// smaller than what was read
+	if err := os.WriteFile(tmpFile, []byte("after truncation\n"), 0644); err != nil {
+		t.Fatalf("failed to rewrite file: %v", err)
+	}
+	tailer.handleEvent(fsnotify.Event{Name: tmpFile, Op: fsnotify.Write})
+	if got := strings.Join(drainLines(tailer), "|"); got != "after truncation" {
+		t.Errorf("after truncation: got %q, want %q", got, "after truncation")
+	}
+}
+
+func TestTailerRenameRotationPolling(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "test.log")
+	if err := os.WriteFile(tmpFile, []byte("old 1\n"), 0644); err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	tailer, err := NewTailer(tmpFile, DefaultOptions())
+	if err != nil {
+		t.Fatalf("failed to create tailer: %v", err)
+	}
+	defer tailer.Stop()
+	tailer.readLines()
+
+	// The last line reaches the old file after the rename
+	if err := os.Rename(tmpFile, tmpFile+".1"); err != nil {
+		t.Fatalf("failed to rotate: %v", err)
+	}
+	appendFile(t, tmpFile+".1", "old 2\n")
+	if err := os.WriteFile(tmpFile, []byte("new 1\n"), 0644); err != nil {
+		t.Fatalf("failed to create new file: %v", err)
+	}
+
+	// No create event: the poll notices the new file
+	tailer.checkForChanges()
+	if got := strings.Join(drainLines(tailer), "|"); got != "old 1|old 2|new 1" {
+		t.Fatalf("after rotation: got %q, want %q", got, "old 1|old 2|new 1")
+	}
+
+	// The create event arriving late doesn't read the new file again
+	tailer.handleEvent(fsnotify.Event{Name: tmpFile, Op: fsnotify.Create})
+	appendFile(t, tmpFile, "new 2\n")
+	tailer.checkForChanges()
+	if got := strings.Join(drainLines(tailer), "|"); got != "new 2" {
+		t.Errorf("after the create event: got %q, want %q", got, "new 2")
+	}
+}
+
+// appendFile appends content to the file at path.
+func appendFile(t *testing.T, path, content string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatalf("append to %s: %v", path, err)
+	}
+}
+
+func writeGzip(t *testing.T, path, content string, mtime time.Time) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(content))
+	zw.Close()
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatalf("chtimes %s: %v", path, err)
+	}
+}
+
+func TestTailerCompressed(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "app.log.1.gz")
+	writeGzip(t, tmpFile, "gz 1\ngz 2\ngz 3", time.Now())
+
+	// Follow and the end start position don't apply to an archive
+	opts := DefaultOptions()
+	tailer, err := NewTailer(tmpFile, opts)
+	if err != nil {
+		t.Fatalf("failed to create tailer: %v", err)
+	}
+	defer tailer.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := tailer.StartFromEnd(ctx); err != nil {
+		t.Fatalf("failed to start tailer: %v", err)
+	}
+
+	var texts []string
+	for line := range tailer.Lines() {
+		if line.Err != nil {
+			t.Fatalf("unexpected error: %v", line.Err)
+		}
+		if line.Offset != 0 {
+			t.Errorf("line %q: Offset = %d, want 0", line.Text, line.Offset)
+		}
+		texts = append(texts, line.Text)
+	}
+	if got := strings.Join(texts, "|"); got != "gz 1|gz 2|gz 3" {
+		t.Errorf("lines = %q, want %q", got, "gz 1|gz 2|gz 3")
+	}
+}
+
+// drainLines returns the texts of the lines buffered on the tailer.
+func drainLines(tailer *Tailer) []string {
+	var texts []string
+	for len(tailer.lines) > 0 {
+		line := <-tailer.lines
+		if line.Err == nil {
+			texts = append(texts, line.Text)
+		}
+	}
+	return texts
+}
+
+func TestTailerReadRotatedRemainder(t *testing.T) {
+	tmpDir := t.TempDir()
+	tmpFile := filepath.Join(tmpDir, "app.log")
+	if err := os.WriteFile(tmpFile, []byte("one\ntwo\n"), 0644); err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+
+	opts := DefaultOptions()
+	opts.ReadRotated = true
+	tailer, err := NewTailer(tmpFile, opts)
+	if err != nil {
+		t.Fatalf("failed to create tailer: %v", err)
+	}
+	defer tailer.Stop()
+
+	tailer.readLines()
+	if got := drainLines(tailer); strings.Join(got, ",") != "one,two" {
+		t.Fatalf("initial lines = %v", got)
+	}
+
+	// "three" and a partial line are written, then copytruncate rotates and
+	// compresses before the tailer reads them. An older rotation is ignored.
+	now := time.Now()
+	writeGzip(t, tmpFile+".2.gz", "older\nrotation\nthree\n", now.Add(-24*time.Hour))
+	writeGzip(t, tmpFile+".1.gz", "one\ntwo\nthree\nfour", now)
+	if err := os.WriteFile(tmpFile, []byte("five\n"), 0644); err != nil {
+		t.Fatalf("truncate and write: %v", err)
+	}
+
+	tailer.handleTruncation()
+	if got := drainLines(tailer); strings.Join(got, ",") != "three,four,five" {
+		t.Errorf("lines after truncation = %v, want three,four,five", got)
+	}
+}
+
+func TestTailerReadRotatedSkipsForeignCopy(t *testing.T) {
+	tmpDir := t.TempDir()
+	tmpFile := filepath.Join(tmpDir, "app.log")
+	if err := os.WriteFile(tmpFile, []byte("current\n"), 0644); err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+
+	opts := DefaultOptions()
+	opts.ReadRotated = true
+	tailer, err := NewTailer(tmpFile, opts)
+	if err != nil {
+		t.Fatalf("failed to create tailer: %v", err)
+	}
+	defer tailer.Stop()
+	tailer.readLines()
+	drainLines(tailer)
+
+	// The newest rotated file isn't a copy of what was read
+	writeGzip(t, tmpFile+"-20240115.gz", "someone\nelse\n", time.Now())
+	tailer.readRotatedRemainder(tailer.fingerprint, tailer.offset, nil)
+	if got := drainLines(tailer); len(got) != 0 {
+		t.Errorf("lines = %v, want none from a foreign rotated file", got)
+	}
+}
+
+func TestLatestRotated(t *testing.T) {
+	tmpDir := t.TempDir()
+	base := filepath.Join(tmpDir, "app.log")
+	old := time.Now().Add(-time.Hour)
+	for _, name := range []string{"app.log", "app.log.1", "app.log.2.gz", "app.log.bak", "app.logger.1", "other.log.1"} {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, old, old)
+	}
+	newest := filepath.Join(tmpDir, "app.log.2.gz")
+	os.Chtimes(newest, time.Now(), time.Now())
+	// Not rotation names, even though newer
+	for _, name := range []string{"app.log.bak", "app.logger.1"} {
+		os.Chtimes(filepath.Join(tmpDir, name), time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	}
+
+	if got := latestRotated(base); got != newest {
+		t.Errorf("latestRotated() = %q, want %q", got, newest)
+	}
+}
+
 func TestDefaultOptions(t *testing.T) {
 	opts := DefaultOptions()
 
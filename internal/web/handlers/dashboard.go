@@ -3,7 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 	"sync"
 	"time"
@@ -147,7 +147,7 @@ func (h *Handler) renderStatsJSON(w http.ResponseWriter, r *http.Request, timeRa
 	stats := h.fetchDashboardStats(r.Context(), timeRange, projectID, access)
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(stats); err != nil {
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 	}
 }
 
@@ -172,7 +172,7 @@ func (h *Handler) fetchDashboardStats(ctx context.Context, timeRange, projectID
 	// Apply project access filtering (error already validated in handler)
 	if access != nil {
 		if err := access.ApplyToAggregationFilter(filter, projectID); err != nil {
-			log.Printf("warning: aggregation filter error: %v", err)
+			slog.Error("warning: aggregation filter", "error", err)
 		}
 	}
 
@@ -64,6 +64,18 @@ func (r *mockLogRepo) GetHTTPStats(ctx context.Context, filter *storage.Aggregat
 	return r.mock.httpStats, nil
 }
 
+func (r *mockLogRepo) GetFieldPercentile(ctx context.Context, filter *storage.AggregationFilter, field string, percentile float64) (*storage.PercentileResult, error) {
+	return &storage.PercentileResult{}, nil
+}
+
+func (r *mockLogRepo) GetLatencyStats(ctx context.Context, filter *storage.AggregationFilter, field, interval string) (*storage.LatencyStats, error) {
+	return &storage.LatencyStats{}, nil
+}
+
+func (r *mockLogRepo) GetTopErrors(ctx context.Context, filter *storage.AggregationFilter, limit int) ([]*storage.ErrorGroup, error) {
+	return nil, nil
+}
+
 func (r *mockLogRepo) GetByID(ctx context.Context, id string) (*storage.LogRecord, error) {
 	return nil, nil
 }
@@ -15,6 +15,7 @@ type Handler struct {
 	sessions       *session.Store
 	csrfKey        string
 	lockoutTracker *auth.LockoutTracker
+	maxResultRows  int // absolute cap on rows a query or export can return
 }
 
 // HandlerConfig contains configuration for the web handler.
@@ -25,6 +26,7 @@ type HandlerConfig struct {
 	CSRFKey          string
 	LockoutThreshold int
 	LockoutDuration  time.Duration
+	MaxResultRows    int // Max rows per query window or export (default: 100000)
 }
 
 func NewHandler(storage storage.Storage, logStorage storage.LogStorage, sessions *session.Store, csrfKey string) *Handler {
@@ -49,13 +51,26 @@ func NewHandlerWithConfig(cfg HandlerConfig) *Handler {
 	if cfg.LockoutDuration == 0 {
 		cfg.LockoutDuration = 15 * time.Minute
 	}
+	if cfg.MaxResultRows <= 0 {
+		cfg.MaxResultRows = defaultMaxResultRows
+	}
 	return &Handler{
 		storage:        cfg.Storage,
 		logStorage:     cfg.LogStorage,
 		sessions:       cfg.Sessions,
 		csrfKey:        cfg.CSRFKey,
 		lockoutTracker: auth.NewLockoutTracker(cfg.LockoutThreshold, cfg.LockoutDuration),
+		maxResultRows:  cfg.MaxResultRows,
+	}
+}
+
+// SetMaxResultRows caps the rows a logs query window or export can return.
+// Zero or negative values restore the default.
+func (h *Handler) SetMaxResultRows(n int) {
+	if n <= 0 {
+		n = defaultMaxResultRows
 	}
+	h.maxResultRows = n
 }
 
 // Helper to get session from context
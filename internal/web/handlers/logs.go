@@ -5,7 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"math"
 	"net/http"
 	"strconv"
@@ -25,6 +25,9 @@ import (
 const maxFilterLength = 1000
 const maxStreamDuration = 30 * time.Minute
 
+// defaultMaxResultRows caps rows per query window or export when unset.
+const defaultMaxResultRows = 100000
+
 // Export rate limiter: 10 requests per minute globally
 // Note: rate.Limiter is already thread-safe, no mutex needed
 var exportLimiter = rate.NewLimiter(rate.Every(6*time.Second), 10) // 10/min with burst of 10
@@ -46,6 +49,7 @@ type LogsDataResponse struct {
 	Page       int        `json:"page"`
 	PerPage    int        `json:"per_page"`
 	TotalPages int        `json:"total_pages"`
+	Truncated  bool       `json:"truncated,omitempty"` // Total exceeds the server's max result rows
 }
 
 // LogItem represents a log entry in web responses
@@ -178,6 +182,17 @@ func (h *Handler) GetLogsData(w http.ResponseWriter, r *http.Request) {
 		messageContains = ""
 	}
 
+	// Bound the result window regardless of how deep the page is
+	offset := (page - 1) * perPage
+	if offset >= h.maxResultRows {
+		http.Error(w, fmt.Sprintf("page is beyond the max result window of %d rows; narrow the time range or filter", h.maxResultRows), http.StatusBadRequest)
+		return
+	}
+	limit := perPage
+	if offset+limit > h.maxResultRows {
+		limit = h.maxResultRows - offset
+	}
+
 	filter := &storage.LogFilter{
 		StartTime:       startTime,
 		EndTime:         endTime,
@@ -186,8 +201,8 @@ func (h *Handler) GetLogsData(w http.ResponseWriter, r *http.Request) {
 		Source:          source,
 		MessageContains: messageContains,
 		SearchMode:      searchMode,
-		Limit:           perPage,
-		Offset:          (page - 1) * perPage,
+		Limit:           limit,
+		Offset:          offset,
 		OrderBy:         "timestamp",
 		OrderDesc:       true,
 		FilterExpr:      filterExpr,
@@ -226,7 +241,12 @@ func (h *Handler) GetLogsData(w http.ResponseWriter, r *http.Request) {
 		result, err := h.logStorage.Logs().Query(ctx, filter)
 		if err == nil && result != nil {
 			response.Total = result.Total
-			response.TotalPages = int(math.Ceil(float64(result.Total) / float64(perPage)))
+			reachable := result.Total
+			if reachable > int64(h.maxResultRows) {
+				reachable = int64(h.maxResultRows)
+				response.Truncated = true
+			}
+			response.TotalPages = int(math.Ceil(float64(reachable) / float64(perPage)))
 			response.Items = make([]*LogItem, len(result.Entries))
 			for i, entry := range result.Entries {
 				response.Items[i] = recordToLogItem(entry)
@@ -236,7 +256,7 @@ func (h *Handler) GetLogsData(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 	}
 }
 
@@ -272,7 +292,7 @@ func (h *Handler) Context(w http.ResponseWriter, r *http.Request) {
 
 	anchor, err := h.logStorage.Logs().GetByID(ctx, id)
 	if err != nil {
-		log.Printf("get log by id error: %v", err)
+		slog.Error("get log by id", "error", err)
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
@@ -285,7 +305,7 @@ func (h *Handler) Context(w http.ResponseWriter, r *http.Request) {
 		role := models.ParseRole(sess.Role)
 		access, err := middleware.GetProjectAccess(ctx, sess.UserID, role, h.storage)
 		if err != nil {
-			log.Printf("project access error: %v", err)
+			slog.Error("project access", "error", err)
 			http.Error(w, "internal server error", http.StatusInternalServerError)
 			return
 		}
@@ -299,14 +319,16 @@ func (h *Handler) Context(w http.ResponseWriter, r *http.Request) {
 		TargetID:     id,
 		ProjectID:    anchor.ProjectID,
 		AgentID:      anchor.AgentID,
+		FilePath:     anchor.FilePath,
 		Timestamp:    anchor.Timestamp,
+		LineNumber:   anchor.LineNumber,
 		Before:       before,
 		After:        after,
 		BeforeCursor: beforeCursor,
 		AfterCursor:  afterCursor,
 	})
 	if err != nil {
-		log.Printf("get context error: %v", err)
+		slog.Error("get context", "error", err)
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
@@ -334,7 +356,7 @@ func (h *Handler) Context(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 	}
 }
 
@@ -430,6 +452,10 @@ func (h *Handler) ExportLogs(w http.ResponseWriter, r *http.Request) {
 			limit = 1000
 		}
 	}
+	capped := limit > h.maxResultRows
+	if capped {
+		limit = h.maxResultRows
+	}
 
 	// Build filter
 	projectID := q.Get("project_id")
@@ -472,6 +498,11 @@ func (h *Handler) ExportLogs(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Signal that the export stopped at the server's row cap
+	if capped && len(entries) >= limit {
+		w.Header().Set("X-Result-Truncated", "true")
+	}
+
 	// Generate filename
 	filename := fmt.Sprintf("logs-export-%s.%s", time.Now().Format("2006-01-02"), format)
 
@@ -492,7 +523,7 @@ func (h *Handler) writeJSON(w http.ResponseWriter, entries []*storage.LogRecord)
 		items[i] = recordToLogItem(entry)
 	}
 	if err := json.NewEncoder(w).Encode(items); err != nil {
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 	}
 }
 
@@ -502,7 +533,7 @@ func (h *Handler) writeCSV(w http.ResponseWriter, entries []*storage.LogRecord)
 
 	// Header
 	if err := writer.Write([]string{"timestamp", "project_id", "level", "source", "type", "message", "file_path", "http_status", "http_method", "uri"}); err != nil {
-		log.Printf("csv header write error: %v", err)
+		slog.Error("csv header write", "error", err)
 		return
 	}
 
@@ -520,7 +551,7 @@ func (h *Handler) writeCSV(w http.ResponseWriter, entries []*storage.LogRecord)
 			e.HTTPMethod,
 			e.URI,
 		}); err != nil {
-			log.Printf("csv row write error: %v", err)
+			slog.Error("csv row write", "error", err)
 			return
 		}
 	}
@@ -569,7 +600,7 @@ func (h *Handler) GetProjects(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(projects); err != nil {
-		log.Printf("json encode error: %v", err)
+		slog.Error("json encode", "error", err)
 		return
 	}
 }
@@ -670,7 +701,7 @@ func (h *Handler) StreamLogs(w http.ResponseWriter, r *http.Request) {
 
 			result, err := h.logStorage.Logs().Query(ctx, &filter)
 			if err != nil {
-				log.Printf("streaming logs query error: %v", err)
+				slog.Error("streaming logs query", "error", err)
 				continue
 			}
 
@@ -683,7 +714,7 @@ func (h *Handler) StreamLogs(w http.ResponseWriter, r *http.Request) {
 				item := recordToLogItem(entry)
 				data, err := json.Marshal(item)
 				if err != nil {
-					log.Printf("streaming logs marshal error: %v", err)
+					slog.Error("streaming logs marshal", "error", err)
 					continue
 				}
 				fmt.Fprintf(w, "event: log\ndata: %s\n\n", data)